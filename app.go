@@ -2,15 +2,33 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"os/exec"
+	"os"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/control"
+	"moodle-prototype-manager/core"
 	"moodle-prototype-manager/docker"
 	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/events"
+	"moodle-prototype-manager/fleet"
+	"moodle-prototype-manager/i18n"
+	"moodle-prototype-manager/kiosk"
+	"moodle-prototype-manager/mdns"
+	"moodle-prototype-manager/moodle"
+	"moodle-prototype-manager/notify"
+	"moodle-prototype-manager/proxy"
+	"moodle-prototype-manager/scenario"
 	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/telemetry"
 	"moodle-prototype-manager/utils"
 
 	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
@@ -18,53 +36,444 @@ import (
 
 // App struct
 type App struct {
-	ctx               context.Context
+	ctx context.Context
+	// core is the logic shared with the CLI; the managers below are its components. Running,
+	// stopping, removing and updating Moodle go through core, which drives a docker.Client
+	// and is tested against docker.FakeClient; dockerManager serves the features outside it.
+	core              *core.Service
 	dockerManager     *docker.Manager
 	credentialManager *storage.CredentialManager
 	fileManager       *storage.FileManager
-	logParser         *docker.LogParser
+	settingsManager   *storage.SettingsManager
+	catalogManager    *storage.CatalogManager
+	tagStore          *storage.TagStore
+	cronScheduler     *docker.CronScheduler
+	statsCollector    *docker.StatsCollector
+	idleMonitor       *docker.IdleMonitor
+	healthMonitor     *docker.HealthMonitor
+	timeline          *storage.Timeline
+	journal           *storage.Journal
+	// serversMu serializes starting, stopping and replacing the network servers: advertiser,
+	// wakeProxy, tlsProxy, kioskAPI, controlAPI and fleetAPI. Bindings run concurrently, and
+	// two settings changes racing to start a server would leak the listener of one of them.
+	serversMu  sync.Mutex
+	advertiser *mdns.Responder
+	wakeProxy  *proxy.WakeProxy
+	// tlsMu guards tlsProxy and tlsPort, which request handlers read while serversMu is held
+	tlsMu    sync.Mutex
+	tlsProxy *proxy.TLSProxy
+	tlsPort  int
+	hostname string
+	undo     undoStack
+	tasks    taskRegistry
+	// events delivers backend events to subscribers at their chosen verbosity
+	events    *events.Bus
+	prePuller *docker.PrePullScheduler
+	// adminerInfo is set while the Adminer sidecar is running
+	adminerInfo *docker.AdminerInfo
+	// logFollower and logBatcher are set while the log viewer follows the container
+	logMu       sync.Mutex
+	logFollower *docker.LogFollower
+	logBatcher  *events.Batcher
+	// phpLog follows the PHP error log while Moodle runs
+	phpLog phpLogWatch
+	// notifier routes alerts to the desktop, webhook and email sinks chosen per category
+	notifier *notify.Router
+	// smtpPassword keeps the email sink's password out of settings.json
+	smtpPassword *storage.SecretManager
+	// wakePasscode keeps the wake proxy's passcode out of settings.json
+	wakePasscode *storage.SecretManager
+	// fleetToken, kioskToken and controlToken keep the API bearer tokens out of settings.json
+	fleetToken   *storage.SecretManager
+	kioskToken   *storage.SecretManager
+	controlToken *storage.SecretManager
+	// recorder captures a session trace for bug reports while recording is on
+	recorder *scenario.Recorder
+	// kioskAPI and watchdog run while kiosk mode is enabled; kioskAPI is guarded by serversMu
+	kioskAPI *kiosk.Server
+	watchdog *kiosk.Watchdog
+	// controlAPI runs while the control API is enabled; it is guarded by serversMu
+	controlAPI *control.Server
+	// fleetAPI runs while fleet mode is enabled, letting other members drive this one; it is
+	// guarded by serversMu
+	fleetAPI *fleet.Server
+	// telemetry sends anonymous usage and crash reports once the user opts in
+	telemetry *telemetry.Reporter
+	// i18n translates notifications, progress labels and error suggestions
+	i18n *i18n.Localizer
+	// instanceLock keeps a second manager from driving the same container; lockHolder is set
+	// instead when another manager holds it, making this one read-only
+	instanceLock *storage.InstanceLock
+	lockHolder   *storage.LockInfo
+	// quitAsked is set while the quit dialog asks what to do with the running container, and
+	// quitAction holds the answer for OnShutdown
+	quitMu     sync.Mutex
+	quitAsked  bool
+	quitAction string
+	// lastHealth is the container health last sent as moodle:health
+	healthMu   sync.Mutex
+	lastHealth string
+	// moodleClient is the web service client for the container and credentials in moodleClientKey
+	moodleMu        sync.Mutex
+	moodleClient    *moodle.Client
+	moodleClientKey string
 }
 
+// SiteStatus is what the dashboard shows about the Moodle site itself
+type SiteStatus struct {
+	SiteName string `json:"siteName"`
+	SiteURL  string `json:"siteUrl"`
+	// Release is the human-readable version, e.g. "5.0.2 (Build: 20250811)"
+	Release string `json:"release"`
+	Version string `json:"version"`
+	Courses int    `json:"courses"`
+	Users   int    `json:"users"`
+	// CronLastRun is zero when cron has never run
+	CronLastRun time.Time `json:"cronLastRun"`
+}
+
+// CredentialRefresh reports what RefreshCredentials found and changed in moodle.txt
+type CredentialRefresh struct {
+	URL string `json:"url"`
+	// PasswordVerified is set when the stored password is the admin's current password
+	PasswordVerified bool `json:"passwordVerified"`
+	// PasswordUpdated is set when a newer password from the logs replaced the stored one
+	PasswordUpdated bool `json:"passwordUpdated"`
+	URLUpdated      bool `json:"urlUpdated"`
+	// Drift is set when neither the stored password nor one in the logs logs the admin in
+	Drift   bool   `json:"drift"`
+	Message string `json:"message"`
+}
+
+// InstanceLockStatus tells the frontend whether this window may change anything
+type InstanceLockStatus struct {
+	ReadOnly bool `json:"readOnly"`
+	// Holder is the manager that owns the container while this one is read-only
+	Holder *storage.LockInfo `json:"holder,omitempty"`
+}
+
+// AppInfo describes this build and the environment it runs in, for the About dialog and
+// support requests
+type AppInfo struct {
+	Version      string `json:"version"`
+	Commit       string `json:"commit"`
+	BuildTime    string `json:"buildTime"`
+	GoVersion    string `json:"goVersion"`
+	WailsVersion string `json:"wailsVersion"`
+	OS           string `json:"os"`
+	Arch         string `json:"arch"`
+	// Docker is nil when the Docker CLI could not be run
+	Docker *docker.DockerVersion `json:"docker,omitempty"`
+	// DockerError explains a missing Docker version or daemon
+	DockerError string `json:"dockerError,omitempty"`
+}
+
+// recorderSubscriberID is the event bus subscription used by the session recorder
+const recorderSubscriberID = "session-recorder"
+
 // NewApp creates a new App application struct
 func NewApp() *App {
 	// Initialize logging
 	utils.InitLogger()
 	utils.LogInfo("Initializing Moodle Prototype Manager")
 
-	return &App{
-		dockerManager:     docker.NewManager(),
-		credentialManager: storage.NewCredentialManager(),
-		fileManager:       storage.NewFileManager(),
-		logParser:         docker.NewLogParser(),
+	// The app also uses Manager features outside docker.Client, such as snapshots and stats
+	dockerManager := docker.NewManager()
+	service := core.NewServiceWithDocker(dockerManager)
+	app := &App{
+		core:              service,
+		dockerManager:     dockerManager,
+		credentialManager: service.Credentials,
+		fileManager:       service.Files,
+		settingsManager:   service.Settings,
+		catalogManager:    storage.NewCatalogManager(),
+		tagStore:          storage.NewTagStore(),
+		timeline:          storage.NewTimeline(),
+		journal:           service.Journal,
+		notifier:          notify.NewRouter(notify.NewDesktopNotifier()),
+		smtpPassword:      storage.NewSecretManager(storage.SMTPPasswordSecret),
+		wakePasscode:      storage.NewSecretManager(storage.WakePasscodeSecret),
+		fleetToken:        storage.NewSecretManager(storage.FleetTokenSecret),
+		kioskToken:        storage.NewSecretManager(storage.KioskTokenSecret),
+		controlToken:      storage.NewSecretManager(storage.ControlAPITokenSecret),
+		recorder:          scenario.NewRecorder(newTraceSanitizer()),
+		telemetry:         telemetry.NewReporter(newTraceSanitizer()),
+		i18n:              i18n.New(i18n.Detect()),
+	}
+	app.tasks.onPanic = app.telemetry.ReportPanic
+	app.cronScheduler = docker.NewCronScheduler(app.dockerManager, app.core.ContainerID)
+	app.statsCollector = docker.NewStatsCollector(app.dockerManager, app.core.RunningContainerID, app.onResourceAlert)
+	app.idleMonitor = docker.NewIdleMonitor(app.dockerManager, app.core.RunningContainerID, app.onIdle)
+	app.healthMonitor = docker.NewHealthMonitor(app.sampleHealth, app.onHealthChange)
+	app.prePuller = docker.NewPrePullScheduler(app.dockerManager, app.fileManager.DataFilePath(storage.PrePullStateFile), app.prePullImages, app.onImagePrePulled)
+	app.watchdog = kiosk.NewWatchdog(kioskBackend{app: app}, app.onKioskRecovery)
+	app.events = events.NewBus(app.emitToFrontend)
+	registerEventTopics(app.events)
+
+	return app
+}
+
+// newTraceSanitizer removes the user's home directory and name from recorded sessions
+func newTraceSanitizer() *scenario.Sanitizer {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = ""
+	}
+	return scenario.NewSanitizer(home, docker.CurrentUser())
+}
+
+// registerEventTopics sets the verbosity level of each event topic; unlisted topics are state events
+func registerEventTopics(bus *events.Bus) {
+	for _, topic := range []string{"docker:pull:progress", "docker:load:progress", "docker:save:progress", "moodle:run:progress", "moodle:stop:progress", "moodle:install:progress", "moodle:plugin:progress", "moodle:export:progress", "moodle:seed:progress", "moodle:instance:export:progress", "moodle:instance:import:progress"} {
+		bus.RegisterTopic(topic, events.LevelProgress)
+	}
+	bus.RegisterTopic("moodle:tests:output", events.LevelDebug)
+	bus.RegisterTopic("moodle:logs:batch", events.LevelDebug)
+}
+
+// emit publishes an event to subscribers that want its level of detail
+func (a *App) emit(topic string, data ...interface{}) {
+	a.events.Publish(topic, data...)
+}
+
+// emitToFrontend sends an event over the Wails bridge
+func (a *App) emitToFrontend(topic string, data ...interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, topic, data...)
+}
+
+// SubscribeEvents sets the event verbosity ("state", "progress" or "debug") for a frontend
+// window such as the tray icon. Once any window subscribes, only requested levels are emitted.
+func (a *App) SubscribeEvents(subscriberID, level string) (err error) {
+	defer a.recoverBinding("SubscribeEvents", &err)
+	utils.LogInfo(fmt.Sprintf("SubscribeEvents called (subscriber: %s, level: %s)", subscriberID, level))
+
+	if err := errors.ValidateNotEmpty("subscriberID", subscriberID); err != nil {
+		return err
+	}
+	parsed, err := events.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid event level: %w", err)
 	}
+	a.events.Subscribe(subscriberID, parsed, nil)
+	return nil
+}
+
+// UnsubscribeEvents removes a frontend window's event subscription
+func (a *App) UnsubscribeEvents(subscriberID string) {
+	defer a.recoverBinding("UnsubscribeEvents", nil)
+	utils.LogInfo(fmt.Sprintf("UnsubscribeEvents called (subscriber: %s)", subscriberID))
+	a.events.Unsubscribe(subscriberID)
 }
 
 // OnStartup is called when the app starts
 func (a *App) OnStartup(ctx context.Context) {
 	a.ctx = ctx
+	a.acquireInstanceLock()
+	// Operations the other manager is running are not interrupted; only the lock holder checks
+	if a.lockHolder == nil {
+		if err := a.journal.MarkInterrupted(); err != nil {
+			utils.LogError("Failed to check the operation journal", err)
+		}
+	}
 
-	// Load image configuration
-	imageName, err := a.fileManager.LoadImageName()
-	if err != nil {
-		utils.LogError("Failed to load image configuration", err)
-		// Return error rather than using potentially wrong fallback
-		// This will make the issue visible to users so they can fix it
-		utils.LogError("Cannot start application without valid image configuration", fmt.Errorf("image.docker file missing or unreadable"))
-		// Use a safe fallback but log it prominently
-		imageName = "wenkhairu/moodle-prototype:502-stable"
-		utils.LogWarning(fmt.Sprintf("FALLBACK: Using default image '%s' - please create image.docker file with correct image name", imageName))
+	a.core.LoadAssets()
+
+	// Load image configuration and settings, then start background services
+	imageName := a.core.LoadImageConfig()
+	settings := a.core.LoadSettings()
+
+	// List image.docker in the catalog so a catalog selection can be undone
+	if settings.SelectedImage() == "" {
+		if err := a.catalogManager.EnsureEntry(imageName); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to add configured image to catalog: %v", err))
+		}
+	}
+
+	if a.lockHolder == nil {
+		a.migrateSecrets(settings)
 	}
+	a.applySettings(settings)
+	utils.LogInfo(fmt.Sprintf("Namespacing containers for OS user %q as %s on host port %d",
+		a.dockerManager.GetUserName(), docker.ContainerName(a.dockerManager.GetInstanceName()), settings.HostPort))
 
-	// Set the image name in Docker manager
-	a.dockerManager.SetImageName(imageName)
-	utils.LogInfo(fmt.Sprintf("Using Docker image: %s", imageName))
+	// Look for containers whose ID was lost so the user can adopt them
+	a.goReported("checkOrphanedContainers", a.checkOrphanedContainers)
+
+	a.goReported("reportSession", a.reportSession)
+
+	if a.lockHolder == nil {
+		a.goReported("reconcileOnStartup", func() {
+			// Stale state must be cleared before anything starts the container
+			a.reconcileOnStartup()
+
+			// An unattended booth starts the prototype without anyone pressing Start
+			if settings.Kiosk.Enabled {
+				if err := (kioskBackend{app: a}).Start(); err != nil {
+					utils.LogError("Kiosk auto-start failed; the watchdog will retry", err)
+				}
+			}
+		})
+	}
 
 	utils.LogInfo("Application startup completed")
 }
 
+// OnBeforeClose shows the quit dialog when the exit action is "ask" and Moodle is running.
+// Closing the window again while the dialog is open quits without waiting for an answer, so
+// an unresponsive frontend cannot keep the app open.
+func (a *App) OnBeforeClose(ctx context.Context) (prevent bool) {
+	if a.lockHolder != nil || a.core.LoadSettings().ExitAction != storage.ExitActionAsk {
+		return false
+	}
+
+	a.quitMu.Lock()
+	defer a.quitMu.Unlock()
+	if a.quitAsked || a.quitAction != "" {
+		return false
+	}
+	if _, err := a.core.RunningContainerID(); err != nil {
+		return false
+	}
+
+	utils.LogInfo("Asking whether to keep Moodle running after quitting")
+	a.quitAsked = true
+	a.emit("app:quit:requested")
+	return true
+}
+
+// Quit closes the app from the quit dialog, stopping Moodle or leaving it running. remember
+// makes the choice the exit action, so the dialog is not shown again.
+func (a *App) Quit(keepRunning, remember bool) (err error) {
+	defer a.recoverBinding("Quit", &err)
+	utils.LogInfo(fmt.Sprintf("Quit called (keepRunning: %v, remember: %v)", keepRunning, remember))
+
+	action := storage.ExitActionStop
+	if keepRunning {
+		action = storage.ExitActionKeep
+	}
+	if remember {
+		if err := a.SetExitAction(action); err != nil {
+			return err
+		}
+	}
+
+	a.quitMu.Lock()
+	a.quitAction = action
+	a.quitMu.Unlock()
+	wailsruntime.Quit(a.ctx)
+	return nil
+}
+
+// CancelQuit is called when the quit dialog is dismissed; the app keeps running
+func (a *App) CancelQuit() {
+	defer a.recoverBinding("CancelQuit", nil)
+	utils.LogInfo("CancelQuit called")
+
+	a.quitMu.Lock()
+	defer a.quitMu.Unlock()
+	a.quitAsked = false
+}
+
+// SetExitAction chooses what happens to a running container when the app quits: stop, keep
+// (the next launch picks it up again) or ask
+func (a *App) SetExitAction(action string) (err error) {
+	defer a.recoverBinding("SetExitAction", &err)
+	utils.LogInfo(fmt.Sprintf("SetExitAction called (action: %s)", action))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+	if _, err := a.updateSettings(fmt.Sprintf("Set exit action to %s", action), func(s *storage.Settings) {
+		s.ExitAction = action
+	}); err != nil {
+		utils.LogError("Failed to save exit action", err)
+		return fmt.Errorf("failed to save exit action: %w", err)
+	}
+	return nil
+}
+
+// keepRunningOnExit reports whether OnShutdown leaves the container running, from the quit
+// dialog's answer or else the exit action
+func (a *App) keepRunningOnExit() bool {
+	a.quitMu.Lock()
+	action := a.quitAction
+	a.quitMu.Unlock()
+	if action == "" {
+		action = a.core.LoadSettings().ExitAction
+	}
+	return action == storage.ExitActionKeep
+}
+
+// reconcileOnStartup brings the stored state in line with Docker, which may have changed
+// while the manager was closed. A deleted container is forgotten and one that kept running is
+// picked up again, as if it had just been started. Scripts reading status.json see the result
+// rather than the state from the last session.
+func (a *App) reconcileOnStartup() {
+	result, err := a.core.Reconcile(a.publicURL)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not reconcile the stored state with Docker: %v", err))
+		return
+	}
+	if result.PreviousPort != 0 {
+		a.retargetTLSProxy()
+	}
+
+	switch result.State {
+	case core.StateMissing:
+		a.stopSidecars()
+		a.publishHealth(&docker.HealthReport{Status: docker.HealthStopped})
+		message := "The Moodle container was deleted outside the manager; its state was cleared"
+		if err := a.timeline.Add("container:missing", message, map[string]string{"id": result.ContainerID}); err != nil {
+			utils.LogError("Failed to record missing container in timeline", err)
+		}
+	case core.StateRunning:
+		utils.LogInfo(fmt.Sprintf("Container %s is still running; resuming", result.ContainerID))
+		a.startSidecars(result.ContainerID)
+		// Reads the login of an install the last session did not see finish, or points
+		// wwwroot at the current URL
+		a.startCredentialWait(&core.StartResult{ContainerID: result.ContainerID, StartTime: time.Now()}, docker.NewRunTracker())
+	}
+	a.emit("moodle:reconciled", result)
+}
+
 // OnShutdown is called when the app is shutting down
 func (a *App) OnShutdown(ctx context.Context) {
 	utils.LogInfo("Application shutdown initiated")
+	// Leave the slowest docker commands in the log for diagnostics
+	defer docker.LogPerformanceSummary(10)
+	defer a.releaseInstanceLock()
+
+	// Stop background services before touching the container
+	servedOverTLS := a.httpsPort() != 0
+	a.stopServers()
+	a.cronScheduler.Stop()
+	a.statsCollector.Stop()
+	a.idleMonitor.Stop()
+	a.healthMonitor.Stop()
+	a.prePuller.Stop()
+	a.StopFollowingLogs()
+	a.tasks.cancelAll()
+	a.telemetry.Flush(2 * time.Second)
+
+	// The container belongs to the manager holding the lock
+	if a.lockHolder != nil {
+		utils.LogInfo("Read-only instance shutting down; leaving the container to the running manager")
+		return
+	}
+
+	if a.keepRunningOnExit() {
+		utils.LogInfo("Leaving Moodle running; the next launch picks it up again")
+		// The HTTPS proxy stopped with the app, so Moodle must answer at its plain URL
+		if servedOverTLS {
+			if err := a.syncPublicURL(false); err != nil {
+				utils.LogError("Failed to point Moodle back at its plain URL", err)
+			}
+		}
+		return
+	}
 
 	// Check if container is running and stop it gracefully
 	if !a.fileManager.ContainerIDExists() {
@@ -90,9 +499,11 @@ func (a *App) OnShutdown(ctx context.Context) {
 		return
 	}
 
+	a.stopSidecars()
+
 	if running {
 		utils.LogInfo("Stopping running container on app shutdown...")
-		err := a.dockerManager.StopContainer(containerID)
+		err := a.dockerManager.StopContainerWithEscalation(containerID, a.emitStopProgress)
 		if err != nil {
 			utils.LogError("Failed to stop container during shutdown", err)
 		} else {
@@ -103,191 +514,481 @@ func (a *App) OnShutdown(ctx context.Context) {
 	}
 }
 
+// acquireInstanceLock locks the data directory, or switches to read-only mode when another
+// manager already runs. Other failures are logged and ignored rather than blocking the user.
+func (a *App) acquireInstanceLock() {
+	lock, err := storage.AcquireInstanceLock(a.fileManager.DataFilePath(storage.LockFile))
+	if err == nil {
+		a.instanceLock = lock
+		return
+	}
+
+	if running, ok := err.(*storage.AlreadyRunningError); ok {
+		utils.LogWarning(fmt.Sprintf("%v; starting read-only", running))
+		a.lockHolder = &running.Holder
+		return
+	}
+	utils.LogError("Failed to lock the data directory; another instance could interfere", err)
+}
+
+// releaseInstanceLock removes the lock so the next launch is not read-only
+func (a *App) releaseInstanceLock() {
+	if a.instanceLock == nil {
+		return
+	}
+	if err := a.instanceLock.Release(); err != nil {
+		utils.LogError("Failed to release instance lock", err)
+	}
+}
+
+// checkWritable refuses changes while another manager holds the lock
+func (a *App) checkWritable() error {
+	if a.lockHolder == nil {
+		return nil
+	}
+	return errors.WithCode(fmt.Errorf("Moodle Prototype Manager is already running (PID %d on %s); this window is read-only",
+		a.lockHolder.PID, a.lockHolder.Hostname), errors.CodeReadOnly)
+}
+
+// GetInstanceLockStatus reports whether this window is read-only because another manager runs
+func (a *App) GetInstanceLockStatus() InstanceLockStatus {
+	defer a.recoverBinding("GetInstanceLockStatus", nil)
+	return InstanceLockStatus{ReadOnly: a.lockHolder != nil, Holder: a.lockHolder}
+}
+
+// GetAppInfo returns the app version, commit and build time, the Go and Wails versions it was
+// built with and the detected Docker version. A missing Docker is reported in the result
+// rather than as an error so the About dialog always has something to show.
+func (a *App) GetAppInfo() *AppInfo {
+	defer a.recoverBinding("GetAppInfo", nil)
+	utils.LogInfo("GetAppInfo called")
+
+	info := &AppInfo{
+		Version:      buildinfo.Version,
+		Commit:       buildinfo.Commit,
+		BuildTime:    buildinfo.BuildTime,
+		GoVersion:    buildinfo.GoVersion(),
+		WailsVersion: buildinfo.WailsVersion(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+	}
+	version, err := docker.GetDockerVersion()
+	info.Docker = version
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to detect the Docker version: %v", err))
+		info.DockerError = err.Error()
+	}
+	return info
+}
+
+// CheckStateCompatibility returns an error when saved state was written by a newer app
+// version, e.g. through a home directory synced with another machine; the frontend shows it
+// at startup so users update instead of losing settings
+func (a *App) CheckStateCompatibility() (err error) {
+	defer a.recoverBinding("CheckStateCompatibility", &err)
+	utils.LogInfo("CheckStateCompatibility called")
+
+	if _, err := a.settingsManager.Load(); storage.IsStateFromNewerVersion(err) {
+		return err
+	}
+	if _, err := a.tagStore.All(); storage.IsStateFromNewerVersion(err) {
+		return err
+	}
+	if _, err := a.timeline.Entries(); storage.IsStateFromNewerVersion(err) {
+		return err
+	}
+	return nil
+}
+
+// HealthCheckResult is what HealthCheck reports to the frontend
+type HealthCheckResult struct {
+	Docker    bool `json:"docker"`
+	Internet  bool `json:"internet"`
+	DiskSpace bool `json:"diskSpace"`
+	Memory    bool `json:"memory"`
+	// Virtualization is only reported on Windows
+	Virtualization *bool `json:"virtualization,omitempty"`
+	// Quota is only reported once a workspace quota has been configured
+	Quota *bool `json:"quota,omitempty"`
+}
+
 // HealthCheck performs Docker and Internet connectivity checks
-func (a *App) HealthCheck() map[string]bool {
+func (a *App) HealthCheck() *HealthCheckResult {
+	defer a.recoverBinding("HealthCheck", nil)
 	utils.LogInfo("Frontend requested health check")
 
-	healthStatus := docker.PerformHealthChecks()
+	healthStatus := docker.PerformHealthChecksForImage(a.dockerManager.GetImageName())
 
-	result := map[string]bool{
-		"docker":   healthStatus.Docker,
-		"internet": healthStatus.Internet,
+	result := &HealthCheckResult{
+		Docker:    healthStatus.Docker,
+		Internet:  healthStatus.Internet,
+		DiskSpace: healthStatus.DiskSpace,
+		Memory:    healthStatus.Memory,
+	}
+	if healthStatus.Virtualization.Applicable {
+		ok := healthStatus.Virtualization.OK()
+		result.Virtualization = &ok
+	}
+	if report, err := a.GetQuotaReport(); err == nil && len(report) > 0 {
+		withinQuota := true
+		for _, status := range report {
+			withinQuota = withinQuota && !status.OverQuota
+		}
+		result.Quota = &withinQuota
 	}
 
-	utils.LogInfo(fmt.Sprintf("Returning health status to frontend: %+v", result))
+	utils.LogInfo(fmt.Sprintf("Returning health status to frontend: %+v", *result))
 	return result
 }
 
-// RunMoodle starts the Moodle container
-func (a *App) RunMoodle() error {
-	utils.LogInfo("RunMoodle called")
+// ValidateEnvironment runs every check RunMoodle depends on without changing anything and
+// reports pass, warn or fail for each; the frontend only enables Run when CanRun is set
+func (a *App) ValidateEnvironment() *docker.PreflightReport {
+	defer a.recoverBinding("ValidateEnvironment", nil)
+	utils.LogInfo("ValidateEnvironment called")
+	report := docker.NewPreflightReport()
+
+	if err := a.checkWritable(); err != nil {
+		report.Add(docker.PreflightDataDirectory, "Data directory", docker.PreflightFail, err.Error())
+	} else if err := a.fileManager.CheckWritable(); err != nil {
+		report.Add(docker.PreflightDataDirectory, "Data directory", docker.PreflightFail,
+			fmt.Sprintf("Credentials cannot be saved in %s: %v", a.fileManager.DataDirectory(), err))
+	} else {
+		report.Add(docker.PreflightDataDirectory, "Data directory", docker.PreflightPass, a.fileManager.DataDirectory())
+	}
+
+	if !docker.CheckDockerHealth() {
+		report.Add(docker.PreflightDocker, "Docker", docker.PreflightFail, "Docker is not running or not installed; start Docker Desktop and try again")
+		utils.LogInfo(fmt.Sprintf("Preflight finished without Docker: %v", report.Failures()))
+		return report
+	}
+	report.Add(docker.PreflightDocker, "Docker", docker.PreflightPass, "Docker is running")
+
+	online := a.preflightNetwork(report)
+	a.preflightImage(report, online)
+	docker.PreflightResources(report, a.dockerManager.GetImageName())
+	a.preflightPort(report)
+
+	utils.LogInfo(fmt.Sprintf("Preflight finished with status %s", report.Status))
+	return report
+}
+
+// preflightNetwork checks internet access through the configured proxy, reporting whether the
+// internet can be reached
+func (a *App) preflightNetwork(report *docker.PreflightReport) bool {
+	proxy := docker.GetProxyConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	switch {
+	case proxy.IsSet() && docker.CheckHTTPSReachability(ctx):
+		report.Add(docker.PreflightNetwork, "Network", docker.PreflightPass, "Internet reachable through the configured proxy")
+	case proxy.IsSet():
+		report.Add(docker.PreflightNetwork, "Network", docker.PreflightWarn, "The internet cannot be reached through the configured proxy; check the proxy settings")
+		return false
+	case docker.CheckInternetHealth():
+		report.Add(docker.PreflightNetwork, "Network", docker.PreflightPass, "Internet reachable")
+	default:
+		report.Add(docker.PreflightNetwork, "Network", docker.PreflightWarn, "No internet connection; Moodle can only start from an image already downloaded")
+		return false
+	}
+	return true
+}
 
-	// For existing containers, we'll preserve the password and only update after container is ready
-	// For new containers, we'll clear to start fresh
+// preflightImage checks that the image is present or can be downloaded
+func (a *App) preflightImage(report *docker.PreflightReport, online bool) {
+	imageName := a.dockerManager.GetImageName()
+	if imageName == "" {
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightFail, "No Docker image name configured; check the image.docker file")
+		return
+	}
 
-	// Check if container already exists
+	// An existing container starts without the image being pulled again
 	if a.fileManager.ContainerIDExists() {
-		containerID, err := a.fileManager.LoadContainerID()
-		if err == nil {
-			utils.LogInfo(fmt.Sprintf("Found existing container ID: %s", containerID))
-
-			// Try to start existing container
-			running, err := a.dockerManager.IsContainerRunning(containerID)
-			if err == nil {
-				if running {
-					utils.LogWarning("Container is already running")
-					return fmt.Errorf("container is already running")
-				}
-				// Start existing container
-				utils.LogInfo("Starting existing container")
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightPass, "Using the existing container")
+		return
+	}
+
+	exists, err := a.dockerManager.CheckImageExists()
+	switch {
+	case err != nil:
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightFail, fmt.Sprintf("Cannot check image %s: %v", imageName, err))
+	case exists:
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightPass, fmt.Sprintf("%s is downloaded", imageName))
+	case online:
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightWarn,
+			fmt.Sprintf("%s will be downloaded first (about %s)", imageName, docker.FormatBytes(docker.EstimatedImageBytes)))
+	default:
+		report.Add(docker.PreflightImage, "Moodle image", docker.PreflightFail,
+			fmt.Sprintf("%s is not downloaded and there is no internet connection; connect or load the image from a file", imageName))
+	}
+}
 
-				// Record the time before starting to only look for new logs
-				startTime := time.Now()
+// preflightPort checks the host port Moodle will be published on
+func (a *App) preflightPort(report *docker.PreflightReport) {
+	port := a.dockerManager.GetHostPort()
 
-				err := a.dockerManager.StartContainer(containerID)
-				if err != nil {
-					return fmt.Errorf("failed to start existing container: %w", err)
-				}
+	if containerID, err := a.core.ContainerID(); err == nil && containerID != "" {
+		running, err := a.dockerManager.IsContainerRunning(containerID)
+		switch {
+		case err != nil:
+			report.Add(docker.PreflightPort, "Host port", docker.PreflightWarn, fmt.Sprintf("Cannot check the existing container: %v", err))
+		case running:
+			report.Add(docker.PreflightPort, "Host port", docker.PreflightPass, fmt.Sprintf("Moodle is already running on port %d", port))
+		case docker.CheckHostPortAvailable(port):
+			report.Add(docker.PreflightPort, "Host port", docker.PreflightPass, fmt.Sprintf("Port %d is free", port))
+		default:
+			// An existing container keeps the port it was created with
+			report.Add(docker.PreflightPort, "Host port", docker.PreflightFail,
+				fmt.Sprintf("Port %d is used by another program; close it or change the host port in settings", port))
+		}
+		return
+	}
+
+	if docker.CheckHostPortAvailable(port) {
+		report.Add(docker.PreflightPort, "Host port", docker.PreflightPass, fmt.Sprintf("Port %d is free", port))
+		return
+	}
+	free, err := docker.FindFreeHostPort(port, nil)
+	if err != nil {
+		report.Add(docker.PreflightPort, "Host port", docker.PreflightFail, err.Error())
+		return
+	}
+	report.Add(docker.PreflightPort, "Host port", docker.PreflightWarn, fmt.Sprintf("Port %d is in use; Moodle will use port %d instead", port, free))
+}
 
-				// Wait for existing container to be ready and extract credentials
-				utils.LogInfo("Waiting for existing container to be ready...")
-				go a.waitForContainerAndExtractCredentialsSince(containerID, startTime)
+// GetResourceReport returns disk and memory preflight details with actionable thresholds
+func (a *App) GetResourceReport() docker.ResourceReport {
+	defer a.recoverBinding("GetResourceReport", nil)
+	utils.LogInfo("Frontend requested resource report")
+	return docker.CheckResources(a.dockerManager.GetImageName())
+}
 
-				return nil
-			}
-			utils.LogWarning(fmt.Sprintf("Error checking container status: %v", err))
+// GetPerformanceStats returns per-command timings of the docker CLI invocations made since
+// startup or the last ResetPerformanceStats, slowest first
+func (a *App) GetPerformanceStats() docker.PerformanceStats {
+	defer a.recoverBinding("GetPerformanceStats", nil)
+	return docker.GetPerformanceStats()
+}
+
+// ResetPerformanceStats clears the collected docker command timings
+func (a *App) ResetPerformanceStats() {
+	defer a.recoverBinding("ResetPerformanceStats", nil)
+	utils.LogInfo("ResetPerformanceStats called")
+	docker.ResetPerformanceStats()
+}
+
+// SetWorkspaceQuota caps the disk used by an instance's container and volumes; 0 removes the quota
+func (a *App) SetWorkspaceQuota(instance string, quotaMB int) (err error) {
+	defer a.recoverBinding("SetWorkspaceQuota", &err)
+	utils.LogInfo(fmt.Sprintf("SetWorkspaceQuota called for %s (quotaMB: %d)", instance, quotaMB))
+
+	if err := errors.ValidateNotEmpty("instance", instance); err != nil {
+		return err
+	}
+	if _, err := a.updateSettings(fmt.Sprintf("Set quota of %s to %d MB", instance, quotaMB), func(s *storage.Settings) {
+		if quotaMB == 0 {
+			delete(s.QuotasMB, instance)
+			return
 		}
+		if s.QuotasMB == nil {
+			s.QuotasMB = make(map[string]int)
+		}
+		s.QuotasMB[instance] = quotaMB
+	}); err != nil {
+		utils.LogError("Failed to save workspace quota", err)
+		return fmt.Errorf("failed to save workspace quota: %w", err)
 	}
+	return nil
+}
 
-	// First-time setup: check if image exists
-	utils.LogInfo("Checking if Docker image exists")
-	utils.LogInfo(fmt.Sprintf("Current image name: %s", a.dockerManager.GetImageName()))
+// GetQuotaReport returns disk usage against quota, with suggested cleanups, for each of
+// this user's workspaces that has a quota
+func (a *App) GetQuotaReport() (_ []docker.QuotaStatus, err error) {
+	defer a.recoverBinding("GetQuotaReport", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
 
-	// Ensure we have an image name
-	if a.dockerManager.GetImageName() == "" {
-		utils.LogError("No Docker image name configured", nil)
-		return fmt.Errorf("no Docker image name configured - please check image.docker file")
+	report := make([]docker.QuotaStatus, 0)
+	if len(settings.QuotasMB) == 0 {
+		return report, nil
 	}
 
-	imageExists, err := a.dockerManager.CheckImageExists()
+	containers, err := a.dockerManager.ListInstanceContainers()
 	if err != nil {
-		utils.LogError("Failed to check image", err)
-		return fmt.Errorf("failed to check Docker image: %w", err)
+		utils.LogError("Failed to list workspaces for quota report", err)
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
 	}
 
-	// Pull image if it doesn't exist
-	if !imageExists {
-		utils.LogInfo("Docker image not found, pulling with progress tracking...")
-
-		// Use PullImageWithProgress to track download progress
-		err := a.dockerManager.PullImageWithProgress(func(percentage float64, status string) {
-			// Emit progress event to frontend
-			progressData := map[string]any{
-				"percentage": percentage,
-				"status":     status,
-			}
-			wailsruntime.EventsEmit(a.ctx, "docker:pull:progress", progressData)
-			utils.LogDebug(fmt.Sprintf("Pull progress: %.1f%% - %s", percentage, status))
-		})
+	for _, container := range containers {
+		quotaMB := settings.QuotasMB[container.Instance]
+		if quotaMB == 0 {
+			continue
+		}
 
+		usage, err := a.dockerManager.GetWorkspaceUsage(container)
 		if err != nil {
-			utils.LogError("Failed to pull image with progress", err)
-			return fmt.Errorf("failed to pull image: %w", err)
+			utils.LogError(fmt.Sprintf("Failed to measure workspace %s", container.Instance), err)
+			continue
 		}
-		utils.LogInfo("Docker image pulled successfully")
-	} else {
-		utils.LogInfo("Docker image already exists")
-	}
 
-	// Clear old credentials for new container
-	utils.LogInfo("Clearing old credentials for new container")
-	if err := a.credentialManager.Clear(); err != nil {
-		utils.LogWarning(fmt.Sprintf("Failed to clear old credentials: %v", err))
+		status := docker.EvaluateQuota(*usage, uint64(quotaMB)*1024*1024)
+		if status.OverQuota {
+			utils.LogWarning(fmt.Sprintf("Workspace %s uses %s, over its %s quota", container.Instance,
+				docker.FormatBytes(status.TotalBytes), docker.FormatBytes(status.QuotaBytes)))
+		}
+		report = append(report, status)
 	}
+	return report, nil
+}
 
-	// Run new container
-	utils.LogInfo("Running new container")
+// GetVirtualizationStatus returns WSL2/virtualization checks with remediation hints (Windows only)
+func (a *App) GetVirtualizationStatus() *docker.VirtualizationStatus {
+	defer a.recoverBinding("GetVirtualizationStatus", nil)
+	utils.LogInfo("Frontend requested virtualization status")
+	return docker.CheckVirtualization()
+}
 
-	// Record the time before starting to only look for new logs
-	startTime := time.Now()
+// RunMoodle starts the Moodle container
+func (a *App) RunMoodle() (err error) {
+	defer a.recoverBinding("RunMoodle", &err)
+	utils.LogInfo("RunMoodle called")
 
-	containerID, err := a.dockerManager.RunContainer()
-	if err != nil {
-		utils.LogError("Failed to run container", err)
-		return fmt.Errorf("failed to run container: %w", err)
+	if err := a.checkWritable(); err != nil {
+		return err
 	}
-	utils.LogInfo(fmt.Sprintf("Container started with ID: %s", containerID))
 
-	// Save container ID
-	if err := a.fileManager.SaveContainerID(containerID); err != nil {
-		utils.LogError("Failed to save container ID", err)
-		return fmt.Errorf("failed to save container ID: %w", err)
+	a.watchdog.Resume()
+
+	tracker := docker.NewRunTracker()
+	if err := a.startMoodle(tracker); err != nil {
+		a.emitRunProgress(tracker, docker.RunStepFailed, 0, err.Error())
+		a.telemetry.Report(telemetry.Event{Kind: telemetry.KindFailure, Operation: "run", Category: telemetry.Category(err)})
+		return err
+	}
+	return nil
+}
+
+// startMoodle starts the existing container or creates a new one, reporting each step to
+// tracker; the credential waiter reports the rest once it returns
+func (a *App) startMoodle(tracker *docker.RunTracker) error {
+	result, err := a.core.Start(core.StartHooks{
+		Step: func(step, message string) {
+			a.emitRunProgress(tracker, step, 0, message)
+		},
+		Pull: a.pullImageWithEvents,
+		// Raise the memory limit (or ask to) if the last run was OOM-killed
+		BeforeRestart: a.handleOOMKill,
+	})
+	if err != nil {
+		return err
 	}
+	// A new container may have been given another free host port
+	a.retargetTLSProxy()
 
-	// Wait for container to be ready and extract credentials
-	// Use the new method that only looks at logs since container start
-	go a.waitForContainerAndExtractCredentialsSince(containerID, startTime)
+	a.startSidecars(result.ContainerID)
 
+	// Wait for the container to be ready and extract credentials
+	utils.LogInfo("Waiting for container to be ready...")
+	a.startCredentialWait(result, tracker)
 	return nil
 }
 
 // StopMoodle stops the Moodle container
-func (a *App) StopMoodle() error {
+func (a *App) StopMoodle() (err error) {
+	defer a.recoverBinding("StopMoodle", &err)
 	utils.LogInfo("StopMoodle called")
 
-	if !a.fileManager.ContainerIDExists() {
-		utils.LogError("No container ID file found", nil)
-		return fmt.Errorf("no container ID found")
+	if err := a.checkWritable(); err != nil {
+		return err
 	}
 
-	containerID, err := a.fileManager.LoadContainerID()
+	// A deliberate stop must not be undone by the kiosk watchdog
+	a.watchdog.Suspend()
+	// Nothing is left to wait for once the container stops
+	a.tasks.cancel(taskGroupCredentials)
+
+	stopped, err := a.core.Stop(a.emitStopProgress)
 	if err != nil {
-		utils.LogError("Failed to load container ID", err)
-		return fmt.Errorf("failed to load container ID: %w", err)
+		return err
 	}
-
-	utils.LogInfo(fmt.Sprintf("Attempting to stop container: %s", containerID))
-
-	// Validate container exists
-	if err := a.dockerManager.ValidateContainerID(containerID); err != nil {
-		utils.LogError("Container validation failed", err)
-		return fmt.Errorf("container validation failed: %w", err)
+	if stopped {
+		a.stopSidecars()
 	}
+	return nil
+}
 
-	// Check if container is actually running
-	running, err := a.dockerManager.IsContainerRunning(containerID)
+// SetStopTimeout sets how many seconds Moodle gets to shut down before it is killed
+func (a *App) SetStopTimeout(seconds int) (err error) {
+	defer a.recoverBinding("SetStopTimeout", &err)
+	utils.LogInfo(fmt.Sprintf("SetStopTimeout called (seconds: %d)", seconds))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set stop timeout to %ds", seconds), func(s *storage.Settings) {
+		s.StopTimeoutSeconds = seconds
+	})
 	if err != nil {
-		utils.LogError("Failed to check container status", err)
-		// Still try to stop it anyway
-		utils.LogWarning("Attempting to stop container despite status check failure")
-	} else if !running {
-		utils.LogInfo("Container is already stopped")
-		return nil
+		utils.LogError("Failed to save stop timeout", err)
+		return fmt.Errorf("failed to save stop timeout: %w", err)
 	}
 
-	// Try graceful stop first
-	err = a.dockerManager.StopContainer(containerID)
+	a.dockerManager.SetStopTimeout(time.Duration(settings.StopTimeoutSeconds) * time.Second)
+	return nil
+}
+
+// SetAutoRestart runs the container with restart policy unless-stopped so it survives Docker restarts
+func (a *App) SetAutoRestart(enabled bool) (err error) {
+	defer a.recoverBinding("SetAutoRestart", &err)
+	utils.LogInfo(fmt.Sprintf("SetAutoRestart called (enabled: %v)", enabled))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set auto-restart to %v", enabled), func(s *storage.Settings) {
+		s.AutoRestart = enabled
+	})
 	if err != nil {
-		utils.LogError("Graceful stop failed, attempting force stop", err)
+		utils.LogError("Failed to save auto-restart setting", err)
+		return fmt.Errorf("failed to save auto-restart setting: %w", err)
+	}
 
-		// Try force stop as fallback
-		forceErr := a.dockerManager.ForceStopContainer(containerID)
-		if forceErr != nil {
-			utils.LogError("Force stop also failed", forceErr)
-			return fmt.Errorf("failed to stop container (graceful: %v, force: %v)", err, forceErr)
-		}
+	return a.applyRestartPolicy(settings)
+}
 
-		utils.LogWarning("Container force stopped successfully")
-		return nil
+// applyRestartPolicy sets the restart policy for new containers and updates the current one;
+// kiosk mode always restarts Moodle
+func (a *App) applyRestartPolicy(settings *storage.Settings) error {
+	policy := core.RestartPolicyFor(settings.AutoRestart || settings.Kiosk.Enabled)
+	if err := a.dockerManager.SetRestartPolicy(policy); err != nil {
+		return fmt.Errorf("failed to set restart policy: %w", err)
 	}
 
-	utils.LogInfo("Container stopped gracefully")
+	// Docker can change the policy of an existing container in place
+	if containerID, err := a.core.ContainerID(); err == nil {
+		if err := a.dockerManager.UpdateRestartPolicy(containerID, policy); err != nil {
+			return fmt.Errorf("failed to update container restart policy: %w", err)
+		}
+	}
 	return nil
 }
 
+// emitRunProgress sends moodle:run:progress for the step a start of Moodle has reached
+func (a *App) emitRunProgress(tracker *docker.RunTracker, step string, fraction float64, message string) {
+	progress := tracker.Step(step, fraction, message)
+	progress.Label = a.i18n.T(progress.Label)
+	utils.LogDebug(fmt.Sprintf("Run progress: %s (%.0f%%)", progress.Label, progress.Percentage))
+	a.emit("moodle:run:progress", progress)
+}
+
+// emitStopProgress forwards container stop stages to the frontend
+func (a *App) emitStopProgress(progress docker.StopProgress) {
+	if a.ctx != nil {
+		a.emit("moodle:stop:progress", progress)
+	}
+}
+
 // GetCredentials retrieves stored Moodle credentials
 // This function maintains compatibility with frontend while improving error handling
-func (a *App) GetCredentials() map[string]string {
+func (a *App) GetCredentials() *storage.Credentials {
+	defer a.recoverBinding("GetCredentials", nil)
 	creds, err := a.credentialManager.Load()
 	if err != nil {
 		// Log the error with proper context instead of silent failure
@@ -296,7 +997,7 @@ func (a *App) GetCredentials() map[string]string {
 
 		// Return default credentials but log the fallback
 		utils.LogWarning("Returning default credentials due to load failure")
-		return storage.DefaultCredentials().ToMap()
+		return storage.DefaultCredentials()
 	}
 
 	// Validate credentials before returning
@@ -308,170 +1009,4252 @@ func (a *App) GetCredentials() map[string]string {
 		utils.LogWarning("Returning potentially incomplete credentials due to validation failure")
 	}
 
-	return creds.ToMap()
+	return creds
 }
 
-// IsContainerReady checks if the container is ready
-func (a *App) IsContainerReady() bool {
-	utils.LogDebug("Frontend called IsContainerReady()")
-
-	// If we have existing credentials, check if Moodle is responding
-	if a.fileManager.ContainerIDExists() {
-		utils.LogDebug("Container exists, testing HTTP availability")
-		// For existing containers, test HTTP availability
-		if a.testMoodleHTTP() {
-			utils.LogDebug("HTTP test passed - container is ready")
-			return true
-		}
-		utils.LogDebug("HTTP test failed - container not ready yet")
-		return false
+// GetMaskedCredentials is GetCredentials with the password masked; the frontend shows these
+// and asks for the password itself only through RevealPassword or CopyCredentialToClipboard
+func (a *App) GetMaskedCredentials() *storage.MaskedCredentials {
+	defer a.recoverBinding("GetMaskedCredentials", nil)
+	creds, err := a.credentialManager.Load()
+	if err != nil {
+		utils.LogError("Failed to load credentials", errors.WrapWithContext(err, "failed to retrieve stored credentials"))
+		return storage.DefaultCredentials().Masked()
 	}
-
-	utils.LogDebug("No existing container, checking credentials file")
-	// Fallback: check if credentials file exists (for first runs)
-	result := a.credentialManager.Exists()
-	utils.LogDebug(fmt.Sprintf("Credentials file exists: %v", result))
-	return result
+	return creds.Masked()
 }
 
-// OpenBrowser opens the default browser to the Moodle URL
-func (a *App) OpenBrowser() error {
+// RevealPassword returns the stored admin password when the user asks to see it
+func (a *App) RevealPassword() (_ string, err error) {
+	defer a.recoverBinding("RevealPassword", &err)
+	utils.LogInfo("RevealPassword called")
+
 	creds, err := a.credentialManager.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load credentials: %w", err)
+		return "", fmt.Errorf("failed to load credentials: %w", err)
 	}
-
-	if creds.URL == "" {
-		return fmt.Errorf("no URL available")
+	if creds.Password == "" {
+		return "", fmt.Errorf("no password is stored yet: %w", errors.ErrInvalidState)
+	}
+	return creds.Password, nil
+}
+
+// clipboardClearDelay is how long a copied password stays on the clipboard
+const clipboardClearDelay = 45 * time.Second
+
+// CopyCredentialToClipboard copies "username", "password" or "url" to the system clipboard.
+// A copied password is cleared again after clipboardClearDelay unless something else was
+// copied since.
+func (a *App) CopyCredentialToClipboard(field string) (err error) {
+	defer a.recoverBinding("CopyCredentialToClipboard", &err)
+	utils.LogInfo(fmt.Sprintf("CopyCredentialToClipboard called (field: %s)", field))
+
+	if a.ctx == nil {
+		return fmt.Errorf("clipboard is not available: %w", errors.ErrInvalidState)
+	}
+	creds, err := a.credentialManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	value, ok := creds.ToMap()[field]
+	if !ok {
+		return errors.NewValidationError("field", "must be username, password or url", field)
+	}
+	if value == "" {
+		return fmt.Errorf("no %s is stored yet: %w", field, errors.ErrInvalidState)
+	}
+	if err := wailsruntime.ClipboardSetText(a.ctx, value); err != nil {
+		return fmt.Errorf("failed to copy %s to the clipboard: %w", field, err)
+	}
+
+	if field == "password" {
+		ctx := a.ctx
+		time.AfterFunc(clipboardClearDelay, func() {
+			if current, err := wailsruntime.ClipboardGetText(ctx); err == nil && current == value {
+				if err := wailsruntime.ClipboardSetText(ctx, ""); err != nil {
+					utils.LogWarning(fmt.Sprintf("Failed to clear the copied password: %v", err))
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// RefreshCredentials reconciles moodle.txt with the running container. The stored password and
+// the latest one in the logs are checked against the admin account, the one that works is
+// kept, and the stored URL is brought in line with the URL Moodle is published at.
+func (a *App) RefreshCredentials() (_ *CredentialRefresh, err error) {
+	defer a.recoverBinding("RefreshCredentials", &err)
+	utils.LogInfo("RefreshCredentials called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := a.credentialManager.Load()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("No usable stored credentials, relying on the logs: %v", err))
+		stored = &storage.Credentials{}
+	}
+
+	chunk, err := a.dockerManager.FetchContainerLogs(containerID, a.core.LogScanOptions(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container logs: %w", err)
+	}
+	logged := a.core.LogParser.ForImage(a.dockerManager.GetImageName()).ExtractCredentials(chunk.Logs)
+
+	var candidates []string
+	for _, password := range []string{stored.Password, logged.Password} {
+		if password != "" && !slices.Contains(candidates, password) {
+			candidates = append(candidates, password)
+		}
+	}
+	password, err := a.dockerManager.VerifyAdminPassword(containerID, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify admin password: %w", err)
 	}
 
-	// Use different commands based on the platform
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", creds.URL)
-	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", creds.URL)
-	case "linux":
-		cmd = exec.Command("xdg-open", creds.URL)
+	if err := a.ensurePublicURL(containerID); err != nil {
+		utils.LogError("Failed to update Moodle wwwroot", err)
+	}
+	result := &CredentialRefresh{
+		URL:              a.publicURL(),
+		PasswordVerified: password != "" && password == stored.Password,
+		PasswordUpdated:  password != "" && password != stored.Password,
+		URLUpdated:       stored.URL != a.publicURL(),
+		Drift:            password == "",
+	}
+
+	switch {
+	case result.Drift:
+		// Keep the stored password; it may still be what the user expects to reset to
+		password = stored.Password
+		result.Message = "Neither the stored password nor one in the container logs signs the admin in; it was changed inside Moodle"
+	case result.PasswordUpdated:
+		result.Message = "The admin password changed inside the container; the stored password was updated"
+	case result.URLUpdated:
+		result.Message = "The stored URL was updated"
 	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		result.Message = "Stored credentials match the container"
+	}
+
+	if result.PasswordUpdated || result.URLUpdated {
+		if err := a.credentialManager.Update(password, result.URL); err != nil {
+			return nil, fmt.Errorf("failed to save credentials: %w", err)
+		}
+	}
+
+	utils.LogInfo(result.Message)
+	if result.Drift || result.PasswordUpdated || result.URLUpdated {
+		kind := "credentials:refreshed"
+		if result.Drift {
+			kind = "credentials:drift"
+		}
+		if err := a.timeline.Add(kind, result.Message, map[string]string{"container": containerID}); err != nil {
+			utils.LogError("Failed to record credential refresh in timeline", err)
+		}
+	}
+	return result, nil
+}
+
+// IsContainerReady checks if the container is ready
+func (a *App) IsContainerReady() bool {
+	defer a.recoverBinding("IsContainerReady", nil)
+	utils.LogDebug("Frontend called IsContainerReady()")
+
+	// If we have existing credentials, check if Moodle is responding
+	if a.fileManager.ContainerIDExists() {
+		utils.LogDebug("Container exists, checking its health")
+		if a.isMoodleReady() {
+			utils.LogDebug("Health check passed - container is ready")
+			return true
+		}
+		utils.LogDebug("Health check failed - container not ready yet")
+		return false
+	}
+
+	utils.LogDebug("No existing container, checking credentials file")
+	// Fallback: check if credentials file exists (for first runs)
+	result := a.credentialManager.Exists()
+	utils.LogDebug(fmt.Sprintf("Credentials file exists: %v", result))
+	return result
+}
+
+// OpenBrowser opens the default browser to the Moodle URL
+func (a *App) OpenBrowser() (err error) {
+	defer a.recoverBinding("OpenBrowser", &err)
+	creds, err := a.credentialManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	if creds.URL == "" {
+		return fmt.Errorf("no URL available")
+	}
+
+	return a.openURL(creds.URL)
+}
+
+// OpenMailUI opens the mail catcher's web UI showing mail Moodle has sent
+func (a *App) OpenMailUI() (err error) {
+	defer a.recoverBinding("OpenMailUI", &err)
+	utils.LogInfo("OpenMailUI called")
+
+	if !a.dockerManager.GetMailCatcher() {
+		return fmt.Errorf("mail catcher is not enabled: %w", errors.ErrInvalidState)
+	}
+	return a.openURL(a.dockerManager.MailUIURL())
+}
+
+// OpenDataDirectory shows the folder holding settings, credentials and other app data
+func (a *App) OpenDataDirectory() (err error) {
+	defer a.recoverBinding("OpenDataDirectory", &err)
+	utils.LogInfo("OpenDataDirectory called")
+	return openDirectory(a.fileManager.DataDirectory())
+}
+
+// OpenLogsDirectory shows the folder holding moodle.log
+func (a *App) OpenLogsDirectory() (err error) {
+	defer a.recoverBinding("OpenLogsDirectory", &err)
+	utils.LogInfo("OpenLogsDirectory called")
+
+	dir, err := utils.LogDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to locate logs directory: %w", err)
+	}
+	return openDirectory(dir)
+}
+
+// openDirectory reveals dir in the platform's file manager
+func openDirectory(dir string) error {
+	if err := utils.OpenPath(dir); err != nil {
+		utils.LogError(fmt.Sprintf("Failed to open %s", dir), err)
+		return fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	return nil
+}
+
+// openURL opens url in the browser chosen in settings
+func (a *App) openURL(url string) error {
+	opts := utils.BrowserOptions{Browser: utils.BrowserDefault}
+	if settings, err := a.settingsManager.Load(); err == nil {
+		opts = utils.BrowserOptions{
+			Browser: settings.Browser.Browser,
+			Profile: settings.Browser.Profile,
+			Private: settings.Browser.Private,
+			Path:    settings.Browser.Path,
+		}
+	}
+
+	if err := utils.OpenURLInBrowser(url, opts); err != nil {
+		return fmt.Errorf("failed to open %s: %w", opts.Browser, err)
 	}
+	return nil
+}
 
-	utils.SetupCommandForPlatform(cmd)
-	return cmd.Start()
+// startCredentialWait replaces any running credential waiter with one for the started
+// container. Only a container this run created (result.Created) may be removed after a failed
+// install.
+func (a *App) startCredentialWait(result *core.StartResult, tracker *docker.RunTracker) {
+	a.tasks.cancel(taskGroupCredentials)
+	a.tasks.start(taskGroupCredentials, "credential wait for "+result.ContainerID, func(ctx context.Context) {
+		a.waitForContainerAndExtractCredentialsSince(ctx, result, tracker)
+	})
 }
 
-// waitForContainerAndExtractCredentialsSince waits for container startup and extracts credentials
-func (a *App) waitForContainerAndExtractCredentialsSince(containerID string, _ time.Time) {
+// waitForContainerAndExtractCredentialsSince waits until the container serves Moodle, reading
+// the login of a first-run install, and returns early once ctx is cancelled. The remaining
+// steps go to tracker.
+func (a *App) waitForContainerAndExtractCredentialsSince(ctx context.Context, result *core.StartResult, tracker *docker.RunTracker) {
 	utils.LogInfo("Starting to wait for container and extract credentials")
+	containerID := result.ContainerID
 	start := time.Now()
+	var lastProgress *docker.InstallProgress
 
-	// For subsequent runs, check if we already have credentials saved
-	existingCreds, err := a.credentialManager.Load()
-	hasExistingPassword := err == nil && existingCreds.Password != ""
-
-	if hasExistingPassword {
-		utils.LogInfo("Subsequent run - testing HTTP availability instead of parsing logs")
-		// For subsequent runs, reasonable timeout since container should start quickly
-		subsequentTimeout := 10 * time.Minute
-		for time.Since(start) < subsequentTimeout {
-			if a.testMoodleHTTP() {
-				utils.LogInfo("Container is ready - Moodle is responding on HTTP")
-				// Use existing password with default URL
-				if err := a.credentialManager.Update(existingCreds.Password, "http://localhost:8080"); err != nil {
-					updateErr := errors.WrapWithContext(err, "failed to update credentials during container ready check")
-					utils.LogError("Failed to update credentials", updateErr)
-					return
-				}
-				utils.LogInfo("Updated credentials with existing password")
-				return
-			}
+	ready, err := a.core.WaitForMoodle(ctx, result, core.ReadyHooks{
+		Step: func(step string, fraction float64, message string) {
+			a.emitRunProgress(tracker, step, fraction, message)
+		},
+		Progress: func(progress *docker.InstallProgress) {
+			lastProgress = a.emitInstallProgress(progress, lastProgress)
+		},
+		Ready: a.isMoodleReady,
+		PublicURL: func() (string, bool) {
+			return a.publicURL(), a.httpsPort() != 0
+		},
+		SeedProgress: a.emitSeedProgress,
+	})
+	// A stopped container must not be reported as a failed install
+	if ctx.Err() != nil {
+		utils.LogInfo(fmt.Sprintf("Stopped waiting for credentials of container %s", containerID))
+		return
+	}
+	if failure, ok := err.(*docker.InstallFailure); ok {
+		event := telemetry.Timed(telemetry.KindInstall, time.Since(start), failure)
+		event.Category = failure.Reason
+		a.telemetry.Report(event)
+		a.reportInstallFailure(containerID, failure)
+		a.emitRunProgress(tracker, docker.RunStepFailed, 0, failure.Message)
+		return
+	}
+	if err != nil {
+		utils.LogError("Moodle did not become ready", err)
+		a.emitRunProgress(tracker, docker.RunStepFailed, 0, err.Error())
+		return
+	}
+
+	if ready.PreviousURL != "" {
+		a.recordURLChange(ready.PreviousURL)
+	}
+	if ready.Installed {
+		utils.LogInfo("Credentials extracted and saved successfully")
+		a.telemetry.Report(telemetry.Timed(telemetry.KindInstall, time.Since(start), nil))
+	}
+	if ready.Seed != nil {
+		a.recordSeed(ready.Seed)
+	}
+}
+
+// reportInstallFailure records a failed first-run install, which core has already journaled
+// and cleaned up, in the timeline and sends moodle:install:failed with the captured log
+// excerpt so the startup screen can stop waiting
+func (a *App) reportInstallFailure(containerID string, failure *docker.InstallFailure) {
+	details := map[string]string{"container": containerID, "reason": failure.Reason}
+	if failure.Removed {
+		a.forgetRemovedContainer()
+		a.recordRemoval(containerID, false, nil, "its install failed")
+	}
+	if failure.LogFile != "" {
+		details["logFile"] = failure.LogFile
+	}
+	if err := a.timeline.Add("install:failed", failure.Message, details); err != nil {
+		utils.LogError("Failed to record install failure in timeline", err)
+	}
+	a.emit("moodle:install:failed", failure)
+	a.notify(notify.CategoryContainer, a.i18n.T("Moodle installation failed"), failure.Message)
+}
+
+// SetInstallCleanup sets what happens to a container whose first-run install failed: "remove"
+// deletes a container created for the install with its volumes, "keep" (the default) leaves it
+// for inspection
+func (a *App) SetInstallCleanup(policy string) (err error) {
+	defer a.recoverBinding("SetInstallCleanup", &err)
+	utils.LogInfo(fmt.Sprintf("SetInstallCleanup called (policy: %s)", policy))
+
+	if _, err := a.updateSettings(fmt.Sprintf("Set failed install cleanup to %s", policy), func(s *storage.Settings) {
+		s.InstallCleanup.Policy = policy
+	}); err != nil {
+		utils.LogError("Failed to save install cleanup policy", err)
+		return fmt.Errorf("failed to save install cleanup policy: %w", err)
+	}
+	return nil
+}
 
-			utils.LogDebug("Waiting for Moodle HTTP response...")
-			time.Sleep(2 * time.Second)
+// emitInstallProgress sends a moodle:install:progress event when the installation has moved on
+// since last; progress is never reported lower than before
+func (a *App) emitInstallProgress(progress, last *docker.InstallProgress) *docker.InstallProgress {
+	if last != nil {
+		if progress.Percentage <= last.Percentage && progress.Phase == last.Phase && progress.PluginsInstalled == last.PluginsInstalled {
+			return last
+		}
+		if progress.Percentage < last.Percentage {
+			progress.Percentage = last.Percentage
 		}
+	}
 
-		timeoutErr := errors.NewNetworkError("timeout", fmt.Errorf("timeout waiting for Moodle HTTP response after %v", subsequentTimeout))
-		utils.LogError("Timeout waiting for Moodle HTTP response", timeoutErr)
-		return
+	utils.LogDebug(fmt.Sprintf("Install progress: %s (%.0f%%)", progress.Label, progress.Percentage))
+	a.emit("moodle:install:progress", progress)
+	return progress
+}
+
+// SetLogScanWindow sets how much container log the credential scanner considers: the latest
+// tailLines lines, logged at most sinceMinutes before the scan starts (0 for no time limit)
+func (a *App) SetLogScanWindow(tailLines, sinceMinutes int) (err error) {
+	defer a.recoverBinding("SetLogScanWindow", &err)
+	utils.LogInfo(fmt.Sprintf("SetLogScanWindow called (tailLines: %d, sinceMinutes: %d)", tailLines, sinceMinutes))
+
+	_, err = a.updateSettings(fmt.Sprintf("Set log scan window to %d lines", tailLines), func(s *storage.Settings) {
+		s.LogScan.TailLines = tailLines
+		s.LogScan.SinceMinutes = sinceMinutes
+	})
+	if err != nil {
+		utils.LogError("Failed to save log scan window", err)
+		return fmt.Errorf("failed to save log scan window: %w", err)
+	}
+	return nil
+}
+
+// SetMaxInstallTime sets how many minutes a first-run install may take before it is reported
+// as failed; 0 waits indefinitely
+func (a *App) SetMaxInstallTime(minutes int) (err error) {
+	defer a.recoverBinding("SetMaxInstallTime", &err)
+	utils.LogInfo(fmt.Sprintf("SetMaxInstallTime called (minutes: %d)", minutes))
+
+	_, err = a.updateSettings(fmt.Sprintf("Set maximum install time to %d minutes", minutes), func(s *storage.Settings) {
+		s.LogScan.MaxInstallMinutes = minutes
+	})
+	if err != nil {
+		utils.LogError("Failed to save maximum install time", err)
+		return fmt.Errorf("failed to save maximum install time: %w", err)
 	}
+	return nil
+}
 
-	// First run - extract credentials from logs
-	utils.LogInfo("First run - extracting credentials from logs")
-	// For first runs, we don't set a timeout limit because Windows installations can take 20-30+ minutes
-	// The loop will continue indefinitely until credentials are found or the application is closed
+// SetBrowser selects the browser, profile and private mode OpenBrowser uses
+func (a *App) SetBrowser(browser, profile string, private bool, path string) (err error) {
+	defer a.recoverBinding("SetBrowser", &err)
+	utils.LogInfo(fmt.Sprintf("SetBrowser called (browser: %s, profile: %q, private: %v)", browser, profile, private))
 
-	logErrorCount := 0
-	maxLogErrors := 5 // Allow some log errors before increasing sleep time
+	_, err = a.updateSettings(fmt.Sprintf("Open Moodle in %s", browser), func(s *storage.Settings) {
+		s.Browser = storage.BrowserSettings{Browser: browser, Profile: profile, Private: private, Path: path}
+	})
+	if err != nil {
+		utils.LogError("Failed to save browser selection", err)
+		return fmt.Errorf("failed to save browser selection: %w", err)
+	}
+	return nil
+}
 
-	for {
-		logs, err := a.dockerManager.GetContainerLogs(containerID)
-		if err != nil {
-			logErrorCount++
-			utils.LogDebug(fmt.Sprintf("Error getting container logs (count: %d): %v", logErrorCount, err))
-
-			// If we have many consecutive log errors, increase sleep time to reduce spam
-			if logErrorCount > maxLogErrors {
-				utils.LogWarning("Multiple log errors detected, increasing poll interval")
-				time.Sleep(5 * time.Second)
-			} else {
-				time.Sleep(2 * time.Second)
-			}
+// GetInstalledBrowsers returns the browsers that can be selected on this machine
+func (a *App) GetInstalledBrowsers() []string {
+	defer a.recoverBinding("GetInstalledBrowsers", nil)
+	return utils.InstalledBrowsers()
+}
+
+// isMoodleReady reports whether Moodle serves requests. The container's Docker health check
+// decides when it has one; containers without one are probed over HTTP.
+func (a *App) isMoodleReady() bool {
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return a.core.ProbeHTTP()
+	}
+	health, err := a.dockerManager.GetContainerHealth(containerID)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to read container health, probing HTTP: %v", err))
+		return a.core.ProbeHTTP()
+	}
+	a.publishHealth(health)
+
+	if health.Status == docker.HealthNone {
+		return a.core.ProbeHTTP()
+	}
+	return health.Status == docker.HealthHealthy
+}
+
+// publishHealth sends moodle:health when the container's health status changes
+func (a *App) publishHealth(health *docker.HealthReport) {
+	a.core.RecordHealth(health.Status)
+
+	a.healthMu.Lock()
+	changed := a.lastHealth != health.Status
+	a.lastHealth = health.Status
+	a.healthMu.Unlock()
+
+	if changed {
+		utils.LogInfo(fmt.Sprintf("Moodle container health: %s", health.Status))
+		a.emit("moodle:health", health)
+	}
+}
+
+// GetContainerHealth returns the Docker health status of the Moodle container: starting,
+// healthy or unhealthy, none for containers without a health check, or stopped
+func (a *App) GetContainerHealth() (_ *docker.HealthReport, err error) {
+	defer a.recoverBinding("GetContainerHealth", &err)
+	utils.LogDebug("GetContainerHealth called")
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return &docker.HealthReport{Status: docker.HealthStopped}, nil
+	}
+	health, err := a.dockerManager.GetContainerHealth(containerID)
+	if err != nil {
+		utils.LogError("Failed to get container health", err)
+		return nil, fmt.Errorf("failed to get container health: %w", err)
+	}
+	a.publishHealth(health)
+	return health, nil
+}
+
+// publicURL returns the URL users should open, using the custom hostname and HTTPS when configured
+func (a *App) publicURL() string {
+	host := "localhost"
+	if a.hostname != "" {
+		host = a.hostname
+	}
+	if port := a.httpsPort(); port != 0 {
+		return fmt.Sprintf("https://%s:%d", host, port)
+	}
+	// Published ports of a remote daemon are only reachable on its host
+	if a.hostname == "" {
+		host = docker.DockerHostAddress()
+	}
+	return fmt.Sprintf("http://%s:%d", host, a.dockerManager.GetHostPort())
+}
+
+// ListOtherUsersContainers returns containers managed by other OS users on this Docker host
+func (a *App) ListOtherUsersContainers() (_ []docker.ManagedContainer, err error) {
+	defer a.recoverBinding("ListOtherUsersContainers", &err)
+	containers, err := a.dockerManager.ListManagedContainers()
+	if err != nil {
+		utils.LogError("Failed to list managed containers", err)
+		return nil, fmt.Errorf("failed to list managed containers: %w", err)
+	}
+
+	others := make([]docker.ManagedContainer, 0)
+	for _, container := range containers {
+		if container.User != a.dockerManager.GetUserName() {
+			others = append(others, container)
+		}
+	}
+	return others, nil
+}
+
+// ListOrphanedContainers returns this user's managed containers that are not tracked in container.id
+func (a *App) ListOrphanedContainers() (_ []docker.ManagedContainer, err error) {
+	defer a.recoverBinding("ListOrphanedContainers", &err)
+	knownID, _ := a.core.ContainerID()
+
+	orphans, err := a.dockerManager.FindOrphanedContainers(knownID)
+	if err != nil {
+		utils.LogError("Failed to look for orphaned containers", err)
+		return nil, fmt.Errorf("failed to look for orphaned containers: %w", err)
+	}
+	return orphans, nil
+}
+
+// InstanceInfo is a managed container annotated with its tags for the frontend
+type InstanceInfo struct {
+	docker.ManagedContainer
+	Tags    []string `json:"tags"`
+	Current bool     `json:"current"`
+}
+
+// ListInstances returns this user's instances whose name or tags match query
+// (see storage.MatchesQuery); an empty query returns every instance
+func (a *App) ListInstances(query string) (_ []InstanceInfo, err error) {
+	defer a.recoverBinding("ListInstances", &err)
+	containers, err := a.dockerManager.ListInstanceContainers()
+	if err != nil {
+		utils.LogError("Failed to list instances", err)
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	allTags, err := a.tagStore.All()
+	if err != nil {
+		utils.LogError("Failed to load instance tags", err)
+		return nil, fmt.Errorf("failed to load instance tags: %w", err)
+	}
+
+	current := a.dockerManager.GetInstanceName()
+	instances := make([]InstanceInfo, 0, len(containers))
+	for _, container := range containers {
+		tags := allTags[container.Instance]
+		if tags == nil {
+			tags = []string{}
+		}
+		if !storage.MatchesQuery(query, container.Instance, tags) {
 			continue
 		}
+		instances = append(instances, InstanceInfo{
+			ManagedContainer: container,
+			Tags:             tags,
+			Current:          container.Instance == current,
+		})
+	}
+	return instances, nil
+}
 
-		// Reset error count on successful log retrieval
-		logErrorCount = 0
+// SetInstanceTags replaces the tags of an instance, returning the tags as stored
+func (a *App) SetInstanceTags(instance string, tags []string) (_ []string, err error) {
+	defer a.recoverBinding("SetInstanceTags", &err)
+	utils.LogInfo(fmt.Sprintf("SetInstanceTags called for %s with: %v", instance, tags))
 
-		// First run - extract both password and URL from logs
-		creds := a.logParser.ExtractCredentials(logs)
-		utils.LogDebug(fmt.Sprintf("Credentials extracted - Password: %s, URL: %s",
-			maskPassword(creds.Password), creds.URL))
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
 
-		if creds.IsComplete() {
-			if err := a.credentialManager.Update(creds.Password, creds.URL); err != nil {
-				saveErr := errors.WrapWithContext(err, "failed to save extracted credentials (password: %s, url: %s)", maskPassword(creds.Password), creds.URL)
-				utils.LogError("Failed to save credentials", saveErr)
-				// Continue trying to extract and save credentials
-				time.Sleep(2 * time.Second)
-				continue
-			}
-			utils.LogInfo("Credentials extracted and saved successfully")
-			return
+	if instance != a.dockerManager.GetInstanceName() {
+		containers, err := a.dockerManager.ListInstanceContainers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+		known := false
+		for _, container := range containers {
+			known = known || container.Instance == instance
 		}
+		if !known {
+			return nil, fmt.Errorf("instance %s not found: %w", instance, errors.ErrContainerNotFound)
+		}
+	}
 
-		time.Sleep(2 * time.Second)
+	stored, err := a.tagStore.SetTags(instance, tags)
+	if err != nil {
+		utils.LogError("Failed to save instance tags", err)
+		return nil, fmt.Errorf("failed to save instance tags: %w", err)
 	}
+	return stored, nil
+}
 
-	// Note: This function now runs indefinitely for first runs until credentials are found
+// ClonedInstance describes an instance created from a snapshot
+type ClonedInstance struct {
+	ContainerID string `json:"containerId"`
+	Instance    string `json:"instance"`
+	Snapshot    string `json:"snapshot"`
+	HostPort    int    `json:"hostPort"`
+	URL         string `json:"url"`
 }
 
-// testMoodleHTTP tests if Moodle is responding on port 8080
-func (a *App) testMoodleHTTP() bool {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// SnapshotInstance commits the running container and copies its volumes into a named
+// snapshot that new instances can be cloned from
+func (a *App) SnapshotInstance(name string) (_ *docker.Snapshot, err error) {
+	defer a.recoverBinding("SnapshotInstance", &err)
+	utils.LogInfo(fmt.Sprintf("SnapshotInstance called with: %q", name))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Get("http://localhost:8080")
+	containerID, err := a.core.RunningContainerID()
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("cannot snapshot: %w", err)
+	}
+	if err := a.core.CheckExportQuota(); err != nil {
+		utils.LogError("Snapshot refused", err)
+		return nil, fmt.Errorf("cannot snapshot: %w", err)
+	}
+
+	op := a.journal.Begin(storage.OpSnapshotCreate, map[string]string{"container": containerID, "snapshot": name})
+	snapshot, err := a.dockerManager.CreateSnapshot(containerID, name)
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to create snapshot", err)
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Any HTTP response (even 500) means the server is up
-	return resp.StatusCode > 0
+	if err := a.timeline.Add("snapshot:created", fmt.Sprintf("Created snapshot %s", snapshot.Name), map[string]string{"image": snapshot.Image}); err != nil {
+		utils.LogError("Failed to record snapshot in timeline", err)
+	}
+	return snapshot, nil
 }
 
-// GetImageName returns the current Docker image name for the frontend
-func (a *App) GetImageName() string {
-	return a.dockerManager.GetImageName()
+// ListSnapshots returns this user's snapshots, newest first
+func (a *App) ListSnapshots() (_ []docker.Snapshot, err error) {
+	defer a.recoverBinding("ListSnapshots", &err)
+	snapshots, err := a.dockerManager.ListSnapshots()
+	if err != nil {
+		utils.LogError("Failed to list snapshots", err)
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// CloneSnapshot starts a new instance from a snapshot on a free host port, leaving the
+// current instance untouched
+func (a *App) CloneSnapshot(name, instance string) (_ *ClonedInstance, err error) {
+	defer a.recoverBinding("CloneSnapshot", &err)
+	utils.LogInfo(fmt.Sprintf("CloneSnapshot called for %q as instance %q", name, instance))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	reserved := []int{a.dockerManager.GetHostPort()}
+	containers, err := a.dockerManager.ListManagedContainers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list managed containers: %w", err)
+	}
+	for _, container := range containers {
+		reserved = append(reserved, container.HostPorts...)
+	}
+	port, err := docker.FindFreeHostPort(a.dockerManager.GetHostPort(), reserved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a port for the clone: %w", err)
+	}
+
+	op := a.journal.Begin(storage.OpSnapshotClone, map[string]string{"snapshot": name, "instance": instance})
+	container, err := a.dockerManager.CloneSnapshot(name, instance, port)
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to clone snapshot", err)
+		return nil, fmt.Errorf("failed to clone snapshot: %w", err)
+	}
+
+	host := a.hostname
+	if host == "" {
+		host = docker.DockerHostAddress()
+	}
+	clone := &ClonedInstance{
+		ContainerID: container.ID,
+		Instance:    container.Instance,
+		Snapshot:    name,
+		HostPort:    port,
+		URL:         fmt.Sprintf("http://%s:%d", host, port),
+	}
+
+	// The snapshot still carries the source instance's URL, which Moodle would redirect to
+	if err := a.dockerManager.ConfigureWWWRoot(container.ID, clone.URL, false); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to set the wwwroot of clone %s: %v", clone.Instance, err))
+	}
+
+	if err := a.timeline.Add("instance:cloned", fmt.Sprintf("Cloned snapshot %s into instance %s", name, clone.Instance), map[string]string{"id": container.ID, "url": clone.URL}); err != nil {
+		utils.LogError("Failed to record clone in timeline", err)
+	}
+	return clone, nil
+}
+
+// DeleteSnapshot removes a snapshot; instances cloned from it are kept
+func (a *App) DeleteSnapshot(name string) (err error) {
+	defer a.recoverBinding("DeleteSnapshot", &err)
+	utils.LogInfo(fmt.Sprintf("DeleteSnapshot called with: %q", name))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	op := a.journal.Begin(storage.OpSnapshotDelete, map[string]string{"snapshot": name})
+	err = a.dockerManager.DeleteSnapshot(name)
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to delete snapshot", err)
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	if err := a.timeline.Add("snapshot:deleted", fmt.Sprintf("Deleted snapshot %s", name), nil); err != nil {
+		utils.LogError("Failed to record snapshot deletion in timeline", err)
+	}
+	return nil
+}
+
+// InstanceImport describes an instance recreated from an instance archive
+type InstanceImport struct {
+	ContainerID string `json:"containerId"`
+	Image       string `json:"image"`
+	Volumes     int    `json:"volumes"`
+	// SourceInstance and ExportedAt identify where the archive came from
+	SourceInstance string    `json:"sourceInstance"`
+	ExportedAt     time.Time `json:"exportedAt"`
+}
+
+// ExportInstance writes the image reference, portable settings, volume data and admin login of
+// the current instance to a single archive a teammate can open with ImportInstance, reporting
+// progress through moodle:instance:export:progress events. An empty path opens a save dialog.
+func (a *App) ExportInstance(path string) (_ *docker.InstanceArchive, err error) {
+	defer a.recoverBinding("ExportInstance", &err)
+	utils.LogInfo(fmt.Sprintf("ExportInstance called (path: %s)", path))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		defaultName := fmt.Sprintf("%s-%s.moodle.tgz", a.dockerManager.GetInstanceName(), time.Now().Format("20060102"))
+		path, err = wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+			Title:           "Export Moodle instance",
+			DefaultFilename: defaultName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose export location: %w", err)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("instance export cancelled")
+		}
+	}
+
+	result, err := a.core.Backup(path, func(percentage float64, status string) {
+		a.emit("moodle:instance:export:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	})
+	if err != nil {
+		utils.LogError("Failed to export instance", err)
+		return nil, err
+	}
+
+	if err := a.timeline.Add("instance:exported", fmt.Sprintf("Exported instance %s to %s", result.Instance, result.Path), map[string]string{"bytes": fmt.Sprintf("%d", result.Bytes), "image": result.Image}); err != nil {
+		utils.LogError("Failed to record instance export in timeline", err)
+	}
+	return result, nil
+}
+
+// ImportInstance recreates an instance exported with ExportInstance: it selects the archive's
+// image and settings, restores its volumes into a new container and keeps its admin login.
+// Progress is reported through moodle:instance:import:progress events, then moodle:run:progress
+// while Moodle starts. An empty path opens a file picker.
+func (a *App) ImportInstance(path string) (_ *InstanceImport, err error) {
+	defer a.recoverBinding("ImportInstance", &err)
+	utils.LogInfo(fmt.Sprintf("ImportInstance called (path: %s)", path))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	// The import replaces the instance's container, so there must not be one
+	if a.fileManager.ContainerIDExists() {
+		return nil, fmt.Errorf("this instance already has a container; remove it or switch instance first: %w", errors.ErrInvalidState)
+	}
+	if existing, err := a.dockerManager.FindInstanceContainer(); err == nil && existing != nil {
+		return nil, fmt.Errorf("container %s already exists but is not tracked; adopt or remove it first: %w", existing.Name, errors.ErrInvalidState)
+	}
+
+	if path == "" {
+		var err error
+		path, err = wailsruntime.OpenFileDialog(a.ctx, wailsruntime.OpenDialogOptions{
+			Title: "Choose a Moodle instance archive",
+			Filters: []wailsruntime.FileFilter{
+				{DisplayName: "Moodle instance archives (*.moodle.tgz)", Pattern: "*.tgz"},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose instance archive: %w", err)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("instance import cancelled")
+		}
+	}
+
+	manifest, err := docker.ReadInstanceManifest(path)
+	if err != nil {
+		utils.LogError("Failed to read instance archive", err)
+		return nil, fmt.Errorf("failed to read instance archive: %w", err)
+	}
+
+	op := a.journal.Begin(storage.OpInstanceImport, map[string]string{"path": path, "image": manifest.Image})
+	result, err := a.importInstance(path, manifest)
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to import instance", err)
+		return nil, fmt.Errorf("failed to import instance: %w", err)
+	}
+
+	if err := a.timeline.Add("instance:imported", fmt.Sprintf("Imported instance %s from %s", manifest.Instance, path), map[string]string{"id": result.ContainerID, "image": result.Image}); err != nil {
+		utils.LogError("Failed to record instance import in timeline", err)
+	}
+	return result, nil
+}
+
+// importInstance restores the archive's volumes into a container of its image and settings.
+// The settings are only saved once the container exists; until then the Docker manager uses
+// them without touching settings.json, and a failed import leaves the instance as it was.
+func (a *App) importInstance(path string, manifest *docker.InstanceManifest) (*InstanceImport, error) {
+	report := func(percentage float64, status string) {
+		a.emit("moodle:instance:import:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	}
+
+	current, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	// Settings missing from the archive keep their current values
+	portable := current.Portable()
+	if len(manifest.Settings) > 0 {
+		if err := json.Unmarshal(manifest.Settings, &portable); err != nil {
+			return nil, fmt.Errorf("invalid settings in instance archive: %w", err)
+		}
+	}
+	staged := *current
+	staged.ApplyPortable(portable)
+	staged.SelectImage(manifest.Image)
+	if err := staged.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings in instance archive: %w", err)
+	}
+
+	report(5, "Applying settings")
+	a.core.Configure(&staged)
+	imported := false
+	defer func() {
+		if imported {
+			return
+		}
+		// The host port may have been moved and saved meanwhile; everything else is as before
+		if settings, err := a.settingsManager.Load(); err == nil {
+			a.core.Configure(settings)
+		} else {
+			a.core.Configure(current)
+		}
+		a.retargetTLSProxy()
+	}()
+
+	report(10, fmt.Sprintf("Checking image %s", manifest.Image))
+	imageExists, err := a.dockerManager.CheckImageExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check Docker image: %w", err)
+	}
+	if !imageExists {
+		report(10, fmt.Sprintf("Downloading image %s", manifest.Image))
+		if err := a.pullImageWithEvents(); err != nil {
+			return nil, fmt.Errorf("failed to pull image: %w", err)
+		}
+	}
+	// A moving tag may have been rebuilt since the export; the data usually still fits
+	if manifest.ImageDigest != "" {
+		if digests, err := a.dockerManager.GetLocalImageDigests(manifest.Image); err == nil && !slices.Contains(digests, manifest.ImageDigest) {
+			utils.LogWarning(fmt.Sprintf("Image %s differs from the exported build %s", manifest.Image, manifest.ImageDigest))
+		}
+	}
+
+	if err := a.core.ResolveHostPort(); err != nil {
+		return nil, fmt.Errorf("failed to find a free host port: %w", err)
+	}
+	a.retargetTLSProxy()
+
+	mounts, err := a.dockerManager.RestoreInstanceVolumes(path, manifest, func(percentage float64, status string) {
+		report(20+percentage*0.6, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report(85, "Creating the container")
+	startTime := time.Now()
+	containerID, err := a.dockerManager.RunContainerWithVolumes(mounts)
+	if err != nil {
+		a.dockerManager.RemoveVolumes(mounts)
+		return nil, fmt.Errorf("failed to run container: %w", err)
+	}
+	if err := a.fileManager.SaveContainerID(containerID); err != nil {
+		if _, removeErr := a.dockerManager.RemoveContainerAndVolumes(containerID); removeErr != nil {
+			utils.LogError("Failed to remove the container of the failed import", removeErr)
+		}
+		return nil, fmt.Errorf("failed to save container ID: %w", err)
+	}
+	imported = true
+
+	// The container was created from the staged settings, so they are kept even if saving
+	// them fails. Undo cannot take the container back, so it is not offered.
+	if settings, err := a.updateSettingsWithoutUndo(fmt.Sprintf("Import instance %s", manifest.Instance), func(s *storage.Settings) {
+		s.ApplyPortable(portable)
+		s.SelectImage(manifest.Image)
+	}); err != nil {
+		utils.LogError("Failed to save the imported settings", err)
+	} else {
+		a.applySettings(settings)
+	}
+
+	// With a saved password the credential wait only waits for Moodle to answer and points
+	// its wwwroot at this machine. Archives without one get a new password once Moodle answers.
+	resetPassword := manifest.Credentials.Password == ""
+	if !resetPassword {
+		if err := a.credentialManager.Update(manifest.Credentials.Password, a.publicURL()); err != nil {
+			utils.LogError("Failed to save imported credentials", err)
+		}
+	}
+	a.core.WriteStatus()
+
+	report(100, "Starting Moodle")
+	a.startSidecars(containerID)
+	// The container holds the imported site, so a failed start must never remove it
+	a.startCredentialWait(&core.StartResult{ContainerID: containerID, StartTime: startTime, ResetAdminPassword: resetPassword}, docker.NewRunTracker())
+
+	return &InstanceImport{
+		ContainerID:    containerID,
+		Image:          manifest.Image,
+		Volumes:        len(mounts),
+		SourceInstance: manifest.Instance,
+		ExportedAt:     manifest.CreatedAt,
+	}, nil
+}
+
+// SaveInstanceTemplate saves the configuration new containers get (image, port, environment,
+// sidecars, memory limit and site details) as a named template, replacing one of the same name.
+// seedSize (S, M or L) seeds demo data into instances created from it; empty seeds nothing.
+func (a *App) SaveInstanceTemplate(name, seedSize string) (_ *storage.InstanceTemplate, err error) {
+	defer a.recoverBinding("SaveInstanceTemplate", &err)
+	utils.LogInfo(fmt.Sprintf("SaveInstanceTemplate called (name: %q, seed: %q)", name, seedSize))
+
+	if seedSize != "" {
+		var err error
+		if seedSize, err = docker.ValidateDemoDataSize(seedSize); err != nil {
+			return nil, err
+		}
+	}
+
+	var template storage.InstanceTemplate
+	if _, err := a.updateSettings(fmt.Sprintf("Save template %s", name), func(s *storage.Settings) {
+		template = s.TemplateFromSettings(name, a.core.ConfiguredImage(), seedSize)
+		s.SaveTemplate(template)
+	}); err != nil {
+		utils.LogError("Failed to save template", err)
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+	return &template, nil
+}
+
+// ListInstanceTemplates returns the saved instance templates
+func (a *App) ListInstanceTemplates() (_ []storage.InstanceTemplate, err error) {
+	defer a.recoverBinding("ListInstanceTemplates", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	templates := settings.Templates
+	if templates == nil {
+		templates = []storage.InstanceTemplate{}
+	}
+	return templates, nil
+}
+
+// DeleteInstanceTemplate removes a saved template; instances created from it are not affected
+func (a *App) DeleteInstanceTemplate(name string) (err error) {
+	defer a.recoverBinding("DeleteInstanceTemplate", &err)
+	utils.LogInfo(fmt.Sprintf("DeleteInstanceTemplate called with: %q", name))
+
+	found := false
+	if _, err := a.updateSettings(fmt.Sprintf("Delete template %s", name), func(s *storage.Settings) {
+		found = s.DeleteTemplate(name)
+	}); err != nil {
+		utils.LogError("Failed to delete template", err)
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if !found {
+		return errors.NewValidationError("template", "no template with this name", name)
+	}
+	return nil
+}
+
+// CreateInstanceFromTemplate configures the instance from a saved template and starts it.
+// instance renames the instance when set. The current instance must not have a container yet,
+// as a template only applies to new containers.
+func (a *App) CreateInstanceFromTemplate(name, instance string) (err error) {
+	defer a.recoverBinding("CreateInstanceFromTemplate", &err)
+	utils.LogInfo(fmt.Sprintf("CreateInstanceFromTemplate called (template: %q, instance: %q)", name, instance))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	if a.fileManager.ContainerIDExists() {
+		return fmt.Errorf("this instance already has a container; remove it first: %w", errors.ErrInvalidState)
+	}
+
+	current, err := a.settingsManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	template, ok := current.Template(name)
+	if !ok {
+		return errors.NewValidationError("template", "no template with this name", name)
+	}
+
+	// Undo cannot take back the new instance, so it is not offered
+	settings, err := a.updateSettingsWithoutUndo(fmt.Sprintf("Create instance from template %s", name), func(s *storage.Settings) {
+		// The image selection belongs to the instance, so it is renamed first
+		if instance != "" {
+			s.InstanceName = instance
+		}
+		s.ApplyTemplate(template)
+	})
+	if err != nil {
+		utils.LogError("Failed to apply template", err)
+		return fmt.Errorf("failed to apply template: %w", err)
+	}
+	a.applySettings(settings)
+
+	if err := a.timeline.Add("template:applied", fmt.Sprintf("Creating instance %s from template %s", a.dockerManager.GetInstanceName(), name), map[string]string{"image": a.dockerManager.GetImageName()}); err != nil {
+		utils.LogError("Failed to record template in timeline", err)
+	}
+	return a.RunMoodle()
+}
+
+// AdoptContainer re-attaches the app to an orphaned container, replacing the tracked container ID
+func (a *App) AdoptContainer(containerID string) (err error) {
+	defer a.recoverBinding("AdoptContainer", &err)
+	utils.LogInfo(fmt.Sprintf("AdoptContainer called with: %s", containerID))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	orphan, err := a.findOrphan(containerID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.fileManager.SaveContainerID(orphan.ID); err != nil {
+		utils.LogError("Failed to save adopted container ID", err)
+		return fmt.Errorf("failed to save container ID: %w", err)
+	}
+
+	// Follow the port the adopted container actually publishes, also after a restart
+	if len(orphan.HostPorts) > 0 {
+		a.core.UseHostPort(orphan.HostPorts[0])
+		a.retargetTLSProxy()
+	}
+	a.core.WriteStatus()
+
+	if err := a.timeline.Add("container:adopted", fmt.Sprintf("Adopted container %s", orphan.Name), map[string]string{"id": orphan.ID}); err != nil {
+		utils.LogError("Failed to record adoption in timeline", err)
+	}
+	utils.LogInfo(fmt.Sprintf("Adopted container %s (%s)", orphan.Name, orphan.ID))
+	return nil
+}
+
+// RemoveOrphanedContainer deletes an orphaned container instead of adopting it
+func (a *App) RemoveOrphanedContainer(containerID string) (err error) {
+	defer a.recoverBinding("RemoveOrphanedContainer", &err)
+	utils.LogInfo(fmt.Sprintf("RemoveOrphanedContainer called with: %s", containerID))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	orphan, err := a.findOrphan(containerID)
+	if err != nil {
+		return err
+	}
+
+	op := a.journal.Begin(storage.OpContainerRemove, map[string]string{"container": orphan.ID, "name": orphan.Name})
+	err = a.dockerManager.RemoveContainer(orphan.ID, true)
+	op.Finish(err)
+	if err != nil {
+		return fmt.Errorf("failed to remove orphaned container: %w", err)
+	}
+
+	if err := a.timeline.Add("container:removed", fmt.Sprintf("Removed orphaned container %s", orphan.Name), map[string]string{"id": orphan.ID}); err != nil {
+		utils.LogError("Failed to record removal in timeline", err)
+	}
+	return nil
+}
+
+// ContainerRemoval is sent as moodle:container:removed once RemoveContainer has finished
+type ContainerRemoval struct {
+	ContainerID    string   `json:"containerId"`
+	VolumesRemoved []string `json:"volumesRemoved"`
+	// Reason is set when the app removed the container on its own, e.g. after a failed install
+	Reason string `json:"reason,omitempty"`
+}
+
+// RemoveContainer deletes the Moodle container and forgets it, so the next RunMoodle installs
+// a fresh site. With removeVolumes the database and moodledata volumes go too; otherwise
+// Docker keeps them. Snapshots are never removed.
+func (a *App) RemoveContainer(removeVolumes bool) (err error) {
+	defer a.recoverBinding("RemoveContainer", &err)
+	utils.LogInfo(fmt.Sprintf("RemoveContainer called (removeVolumes: %v)", removeVolumes))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return err
+	}
+
+	// Nothing may restart or wait for the container being removed
+	a.watchdog.Suspend()
+	a.tasks.cancel(taskGroupCredentials)
+	a.StopFollowingLogs()
+
+	_, err = a.removeContainer(containerID, removeVolumes, "")
+	return err
+}
+
+// removeContainer deletes the container, optionally with its volumes, and clears the state
+// kept for it. reason, if set, says why the app removed it on its own.
+func (a *App) removeContainer(containerID string, removeVolumes bool, reason string) (*ContainerRemoval, error) {
+	volumes, err := a.core.Remove(containerID, removeVolumes, reason)
+	if err != nil {
+		return nil, err
+	}
+	a.forgetRemovedContainer()
+	return a.recordRemoval(containerID, removeVolumes, volumes, reason), nil
+}
+
+// forgetRemovedContainer ends what the app ran for a container core removed
+func (a *App) forgetRemovedContainer() {
+	a.stopPHPLogWatch()
+	a.adminerInfo = nil
+	a.publishHealth(&docker.HealthReport{Status: docker.HealthStopped})
+}
+
+// recordRemoval adds a removed container to the timeline and sends moodle:container:removed
+func (a *App) recordRemoval(containerID string, removeVolumes bool, volumes []string, reason string) *ContainerRemoval {
+	removal := &ContainerRemoval{ContainerID: containerID, VolumesRemoved: append(make([]string, 0), volumes...), Reason: reason}
+	message := "Removed the Moodle container"
+	if removeVolumes {
+		message = fmt.Sprintf("Removed the Moodle container and %d volume(s)", len(removal.VolumesRemoved))
+	}
+	if reason != "" {
+		message = fmt.Sprintf("%s because %s", message, reason)
+	}
+	timelineDetails := map[string]string{"id": containerID, "volumes": strings.Join(removal.VolumesRemoved, ", ")}
+	if err := a.timeline.Add("container:removed", message, timelineDetails); err != nil {
+		utils.LogError("Failed to record removal in timeline", err)
+	}
+	a.emit("moodle:container:removed", removal)
+	utils.LogInfo(message)
+	return removal
+}
+
+// findOrphan returns the orphaned container with the given ID
+func (a *App) findOrphan(containerID string) (*docker.ManagedContainer, error) {
+	orphans, err := a.ListOrphanedContainers()
+	if err != nil {
+		return nil, err
+	}
+	for _, orphan := range orphans {
+		if orphan.ID == containerID || strings.HasPrefix(containerID, orphan.ID) {
+			found := orphan
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("container %s is not an orphaned container of this user: %w", containerID, errors.ErrContainerNotFound)
+}
+
+// EmergencyCleanup force-removes every container, volume, network and snapshot this app
+// created for the current user, across all instances and app versions, and forgets the
+// stored container and login. It is a last resort for a setup left inconsistent, e.g. by
+// mixing versions of the manager; the Moodle images are kept, so the next run starts fresh
+// without downloading again.
+func (a *App) EmergencyCleanup() (_ *docker.CleanupReport, err error) {
+	defer a.recoverBinding("EmergencyCleanup", &err)
+	utils.LogWarning("EmergencyCleanup called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	// Nothing may recreate or poll the containers while they are removed
+	a.watchdog.Suspend()
+	a.tasks.cancel(taskGroupCredentials)
+	a.StopFollowingLogs()
+
+	// The stored container may predate the labels
+	storedID, _ := a.core.ContainerID()
+	op := a.journal.Begin(storage.OpCleanup, nil)
+	report, err := a.dockerManager.EmergencyCleanup(storedID)
+	if err == nil && len(report.Failures) > 0 {
+		op.Finish(fmt.Errorf("%d resource(s) could not be removed", len(report.Failures)))
+	} else {
+		op.Finish(err)
+	}
+	if err != nil {
+		utils.LogError("Emergency cleanup failed", err)
+		return nil, fmt.Errorf("emergency cleanup failed: %w", err)
+	}
+
+	a.adminerInfo = nil
+	if err := a.fileManager.DeleteContainerID(); err != nil {
+		utils.LogError("Failed to delete container ID file", err)
+	}
+	if err := a.credentialManager.Clear(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to clear stored credentials: %v", err))
+	}
+	a.publishHealth(&docker.HealthReport{Status: docker.HealthStopped})
+	a.core.WriteStatus()
+
+	message := fmt.Sprintf("Emergency cleanup removed %d container(s), %d volume(s), %d network(s) and %d snapshot image(s)",
+		len(report.Containers), len(report.Volumes), len(report.Networks), len(report.Images))
+	if err := a.timeline.Add("cleanup:emergency", message, map[string]string{"failures": strings.Join(report.Failures, "; ")}); err != nil {
+		utils.LogError("Failed to record cleanup in timeline", err)
+	}
+	a.emit("moodle:cleanup:done", report)
+	return report, nil
+}
+
+// checkOrphanedContainers notifies the frontend about containers whose ID is not tracked
+func (a *App) checkOrphanedContainers() {
+	orphans, err := a.ListOrphanedContainers()
+	if err != nil || len(orphans) == 0 {
+		return
+	}
+
+	utils.LogWarning(fmt.Sprintf("Found %d orphaned container(s) for this user", len(orphans)))
+	a.emit("moodle:orphans:found", orphans)
+}
+
+// GetImageName returns the current Docker image name for the frontend
+func (a *App) GetImageName() string {
+	defer a.recoverBinding("GetImageName", nil)
+	return a.dockerManager.GetImageName()
+}
+
+// GetImageAdapter describes how the manager drives the current image
+func (a *App) GetImageAdapter() *docker.ImageAdapter {
+	defer a.recoverBinding("GetImageAdapter", nil)
+	return a.dockerManager.Adapter()
+}
+
+// ImageOption is a catalog entry annotated with local availability for the frontend
+type ImageOption struct {
+	storage.ImageCatalogEntry
+	Local    bool `json:"local"`
+	Selected bool `json:"selected"`
+}
+
+// ListAvailableImages returns the image catalog with local/selected flags
+func (a *App) ListAvailableImages() (_ []ImageOption, err error) {
+	defer a.recoverBinding("ListAvailableImages", &err)
+	utils.LogInfo("ListAvailableImages called")
+
+	entries, err := a.catalogManager.Load()
+	if err != nil {
+		utils.LogError("Failed to load image catalog", err)
+		return nil, fmt.Errorf("failed to load image catalog: %w", err)
+	}
+
+	// Local availability is informational; Docker being down shouldn't hide the catalog
+	localImages, err := a.dockerManager.ListLocalImages()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not list local images: %v", err))
+	}
+
+	current := a.dockerManager.GetImageName()
+	options := make([]ImageOption, 0, len(entries))
+	for _, entry := range entries {
+		option := ImageOption{ImageCatalogEntry: entry, Selected: docker.SameImage(entry.Image, current)}
+		for _, local := range localImages {
+			if docker.SameImage(local, entry.Image) {
+				option.Local = true
+				break
+			}
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// SelectImage chooses the catalog image used for new containers; it is pulled on demand by RunMoodle
+func (a *App) SelectImage(image string) (err error) {
+	defer a.recoverBinding("SelectImage", &err)
+	utils.LogInfo(fmt.Sprintf("SelectImage called with: %s", image))
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	if _, err := a.catalogManager.Find(image); err != nil {
+		utils.LogError("Selected image is not in the catalog", err)
+		return fmt.Errorf("cannot select image: %w", err)
+	}
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	adapter := docker.AdapterFor(image)
+	if missing := adapter.MissingEnv(settings.ContainerEnv); len(missing) > 0 {
+		return errors.NewValidationError("image", fmt.Sprintf("%s images need %s set in the container environment settings first", adapter.Name, strings.Join(missing, ", ")), image)
+	}
+
+	settings, err = a.updateSettings(fmt.Sprintf("Select image %s", image), func(s *storage.Settings) {
+		s.SelectImage(image)
+	})
+	if err != nil {
+		utils.LogError("Failed to save selected image", err)
+		return fmt.Errorf("failed to save selected image: %w", err)
+	}
+
+	a.dockerManager.SetImageName(settings.SelectedImage())
+
+	if a.fileManager.ContainerIDExists() {
+		utils.LogWarning("An existing container is still in use; the selected image applies to the next new container")
+	}
+	return nil
+}
+
+// ListAvailableImageTags returns the tags published for the repository of image (the selected
+// image when empty), served from the registry cache so it works offline
+func (a *App) ListAvailableImageTags(image string) (_ []string, err error) {
+	defer a.recoverBinding("ListAvailableImageTags", &err)
+	utils.LogInfo(fmt.Sprintf("ListAvailableImageTags called with: %q", image))
+
+	if image == "" {
+		image = a.dockerManager.GetImageName()
+	}
+	tags, err := a.dockerManager.ListImageTags(image)
+	if err != nil {
+		utils.LogError("Failed to list image tags", err)
+		return nil, fmt.Errorf("failed to list image tags: %w", err)
+	}
+	return tags, nil
+}
+
+// CheckForImageUpdate reports whether the registry has a newer build of the selected image
+func (a *App) CheckForImageUpdate() (_ *docker.ImageUpdateInfo, err error) {
+	defer a.recoverBinding("CheckForImageUpdate", &err)
+	utils.LogInfo("CheckForImageUpdate called")
+
+	info, err := a.dockerManager.CheckForNewerImage()
+	if err != nil {
+		utils.LogError("Image update check failed", err)
+		return nil, fmt.Errorf("failed to check for image updates: %w", err)
+	}
+
+	if pulled, ok := a.prePuller.PrePulled(info.Image); ok && pulled.Digest == info.RemoteDigest {
+		info.PrePulledAt = &pulled.PulledAt
+	}
+	return info, nil
+}
+
+// CheckAssetUpdates reports whether the signed asset channel has newer provisioning assets
+func (a *App) CheckAssetUpdates() (_ *bundle.UpdateInfo, err error) {
+	defer a.recoverBinding("CheckAssetUpdates", &err)
+	utils.LogInfo("CheckAssetUpdates called")
+
+	updater, err := bundle.NewUpdater(docker.NewHTTPClient(30 * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := updater.Check()
+	if err != nil {
+		utils.LogError("Asset update check failed", err)
+		return nil, fmt.Errorf("failed to check for asset updates: %w", err)
+	}
+	return info, nil
+}
+
+// UpdateAssets installs newer provisioning assets (compose templates, parser patterns, seed data)
+// after verifying the channel signature and every file's checksum
+func (a *App) UpdateAssets() (_ *bundle.UpdateInfo, err error) {
+	defer a.recoverBinding("UpdateAssets", &err)
+	utils.LogInfo("UpdateAssets called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	updater, err := bundle.NewUpdater(docker.NewHTTPClient(2 * time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	info, err := updater.Update()
+	if err != nil {
+		utils.LogError("Asset update failed", err)
+		return nil, fmt.Errorf("failed to update assets: %w", err)
+	}
+
+	// Pick up new credential patterns
+	a.core.LogParser = docker.NewLogParser()
+	if err := a.timeline.Add("assets:updated", fmt.Sprintf("Provisioning assets at version %d", info.InstalledVersion), nil); err != nil {
+		utils.LogError("Failed to record asset update in timeline", err)
+	}
+	return info, nil
+}
+
+// SetNightlyPrePull enables downloading new image builds between startHour and endHour (local time)
+func (a *App) SetNightlyPrePull(enabled bool, startHour, endHour int) (err error) {
+	defer a.recoverBinding("SetNightlyPrePull", &err)
+	utils.LogInfo(fmt.Sprintf("SetNightlyPrePull called (enabled: %v, window: %d-%d)", enabled, startHour, endHour))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set nightly pre-pull to %v", enabled), func(s *storage.Settings) {
+		s.PrePull = storage.PrePullSettings{Enabled: enabled, StartHour: startHour, EndHour: endHour}
+	})
+	if err != nil {
+		utils.LogError("Failed to save pre-pull settings", err)
+		return fmt.Errorf("failed to save pre-pull settings: %w", err)
+	}
+
+	a.applyPrePullSettings(settings.PrePull)
+	return nil
+}
+
+// GetPrePullStatus returns the nightly pre-pull schedule and the builds it downloaded
+func (a *App) GetPrePullStatus() docker.PrePullStatus {
+	defer a.recoverBinding("GetPrePullStatus", nil)
+	return a.prePuller.Status()
+}
+
+// applyPrePullSettings starts or stops the nightly pre-pull to match settings
+func (a *App) applyPrePullSettings(prePull storage.PrePullSettings) {
+	if !prePull.Enabled {
+		a.prePuller.Stop()
+		return
+	}
+	a.prePuller.Start(prePull.StartHour, prePull.EndHour)
+}
+
+// prePullImages lists the selected image and catalog images already present locally
+func (a *App) prePullImages() []string {
+	images := []string{a.dockerManager.GetImageName()}
+	entries, err := a.catalogManager.Load()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to load image catalog for pre-pull: %v", err))
+		return images
+	}
+
+	for _, entry := range entries {
+		if entry.Image == images[0] {
+			continue
+		}
+		// Only refresh images the user has used; new tags are pulled on demand
+		if _, err := a.dockerManager.GetLocalImageDigests(entry.Image); err == nil {
+			images = append(images, entry.Image)
+		}
+	}
+	return images
+}
+
+// onImagePrePulled records a pre-pulled build in the timeline and notifies the frontend
+func (a *App) onImagePrePulled(pulled docker.PrePulledImage) {
+	if err := a.timeline.Add("image:prepulled", fmt.Sprintf("Pre-pulled a new build of %s", pulled.Image), map[string]string{"digest": pulled.Digest}); err != nil {
+		utils.LogError("Failed to record pre-pull in timeline", err)
+	}
+	a.emit("docker:prepull:complete", pulled)
+	a.notify(notify.CategoryUpdate, a.i18n.T("New Moodle image downloaded"), a.i18n.Tf("A new build of %s is ready to use.", pulled.Image))
+}
+
+// UpdateImage pulls the latest build of the selected image; new containers will use it
+func (a *App) UpdateImage() (err error) {
+	defer a.recoverBinding("UpdateImage", &err)
+	utils.LogInfo("UpdateImage called")
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	update, err := a.core.UpdateImage(a.pullImageWithEvents)
+	if err != nil {
+		return err
+	}
+	if update.Changed() {
+		details := map[string]string{"image": update.Image, "before": strings.Join(update.Before, ", "), "after": strings.Join(update.After, ", ")}
+		if err := a.timeline.Add(storage.TimelineImageUpdated, fmt.Sprintf("Pulled a new build of %s", update.Image), details); err != nil {
+			utils.LogError("Failed to record image update in timeline", err)
+		}
+	}
+	return nil
+}
+
+// pullImageWithEvents pulls the selected image, forwarding progress to the frontend
+func (a *App) pullImageWithEvents() error {
+	start := time.Now()
+	op := a.journal.Begin(storage.OpImagePull, map[string]string{"image": a.dockerManager.GetImageName()})
+	err := a.dockerManager.PullImageWithDetail(a.onPullProgress)
+	op.Finish(err)
+	a.telemetry.Report(telemetry.Timed(telemetry.KindPull, time.Since(start), err))
+	return a.emitPullInterrupted(err)
+}
+
+// onPullProgress forwards a pull progress update to the frontend
+func (a *App) onPullProgress(snapshot docker.PullSnapshot) {
+	// The snapshot keeps percentage and status alongside per-layer rows, speed and ETA
+	a.emit("docker:pull:progress", snapshot)
+	utils.LogDebug(fmt.Sprintf("Pull progress: %.1f%% - %s (%s remaining, %s/s)", snapshot.Percentage, snapshot.Status,
+		docker.FormatBytes(uint64(snapshot.BytesRemaining)), docker.FormatBytes(uint64(snapshot.SpeedBytesPerSec))))
+}
+
+// emitPullInterrupted tells the frontend a failed pull can be resumed; it returns err unchanged
+func (a *App) emitPullInterrupted(err error) error {
+	if err == nil {
+		return nil
+	}
+	if snapshot, ok := a.dockerManager.InterruptedPull(); ok {
+		a.emit("docker:pull:interrupted", snapshot)
+	}
+	return err
+}
+
+// LoadImageFromFile restores the Moodle image from a tarball made with `docker save`, e.g. one
+// distributed on a USB stick, reporting progress through docker:load:progress events. An empty
+// path opens a file picker.
+func (a *App) LoadImageFromFile(path string) (_ *docker.ImageLoadResult, err error) {
+	defer a.recoverBinding("LoadImageFromFile", &err)
+	utils.LogInfo(fmt.Sprintf("LoadImageFromFile called (path: %s)", path))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		var err error
+		path, err = wailsruntime.OpenFileDialog(a.ctx, wailsruntime.OpenDialogOptions{
+			Title: "Choose a Moodle image file",
+			Filters: []wailsruntime.FileFilter{
+				{DisplayName: "Docker image archives (*.tar, *.tar.gz, *.tgz)", Pattern: "*.tar;*.tar.gz;*.tgz"},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose image file: %w", err)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("image load cancelled")
+		}
+	}
+
+	op := a.journal.Begin(storage.OpImageLoad, map[string]string{"path": path})
+	result, err := a.dockerManager.LoadImageFromFile(path, func(percentage float64, status string) {
+		a.emit("docker:load:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	})
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to load image from file", err)
+		return nil, fmt.Errorf("failed to load image from file: %w", err)
+	}
+
+	if !result.IncludesConfigured {
+		utils.LogWarning(fmt.Sprintf("Loaded file does not contain the configured image %s", a.dockerManager.GetImageName()))
+	}
+	if err := a.timeline.Add("image:loaded", fmt.Sprintf("Loaded %s from %s", strings.Join(result.Images, ", "), path), nil); err != nil {
+		utils.LogError("Failed to record image load in timeline", err)
+	}
+	return result, nil
+}
+
+// SaveImageToFile writes the Moodle image to a tarball that other machines can restore with
+// LoadImageFromFile, reporting progress through docker:save:progress events. A path ending in
+// .gz is compressed; an empty path opens a save dialog.
+func (a *App) SaveImageToFile(path string) (_ *docker.ImageSaveResult, err error) {
+	defer a.recoverBinding("SaveImageToFile", &err)
+	utils.LogInfo(fmt.Sprintf("SaveImageToFile called (path: %s)", path))
+
+	if path == "" {
+		imageName := a.dockerManager.GetImageName()
+		defaultName := strings.NewReplacer("/", "-", ":", "-").Replace(imageName) + ".tar.gz"
+		var err error
+		path, err = wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+			Title:           "Save Moodle image",
+			DefaultFilename: defaultName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose image file location: %w", err)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("image save cancelled")
+		}
+	}
+
+	op := a.journal.Begin(storage.OpImageSave, map[string]string{"image": a.dockerManager.GetImageName(), "path": path})
+	result, err := a.dockerManager.SaveImageToFile(path, func(percentage float64, status string) {
+		a.emit("docker:save:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	})
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to save image to file", err)
+		return nil, fmt.Errorf("failed to save image to file: %w", err)
+	}
+
+	if err := a.timeline.Add("image:saved", fmt.Sprintf("Saved %s to %s", result.Image, result.Path), map[string]string{"bytes": fmt.Sprintf("%d", result.Bytes)}); err != nil {
+		utils.LogError("Failed to record image save in timeline", err)
+	}
+	return result, nil
+}
+
+// GetDockerDiskUsage returns the disk space used by local Moodle image versions and this user's containers
+func (a *App) GetDockerDiskUsage() (_ *docker.DockerDiskUsage, err error) {
+	defer a.recoverBinding("GetDockerDiskUsage", &err)
+	utils.LogInfo("GetDockerDiskUsage called")
+
+	usage, err := a.dockerManager.GetDockerDiskUsage()
+	if err != nil {
+		utils.LogError("Failed to get Docker disk usage", err)
+		return nil, fmt.Errorf("failed to get Docker disk usage: %w", err)
+	}
+	return usage, nil
+}
+
+// CleanupUnused removes old Moodle image versions left behind by upgrades
+func (a *App) CleanupUnused() (_ *docker.CleanupResult, err error) {
+	defer a.recoverBinding("CleanupUnused", &err)
+	utils.LogInfo("CleanupUnused called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	result, err := a.dockerManager.CleanupUnused()
+	if err != nil {
+		utils.LogError("Failed to clean up unused images", err)
+		return nil, fmt.Errorf("failed to clean up unused images: %w", err)
+	}
+
+	if len(result.RemovedImages) > 0 {
+		message := fmt.Sprintf("Removed %d old image versions, reclaiming %s", len(result.RemovedImages), docker.FormatBytes(result.ReclaimedBytes))
+		if err := a.timeline.Add("image:cleanup", message, map[string]string{"images": strings.Join(result.RemovedImages, ", ")}); err != nil {
+			utils.LogError("Failed to record image cleanup in timeline", err)
+		}
+	}
+	return result, nil
+}
+
+// GetInterruptedPull returns the progress of a failed pull that ResumePull can continue, or nil
+func (a *App) GetInterruptedPull() *docker.PullSnapshot {
+	defer a.recoverBinding("GetInterruptedPull", nil)
+	snapshot, _ := a.dockerManager.InterruptedPull()
+	return snapshot
+}
+
+// ResumePull continues an image pull that failed after its automatic retries, keeping the
+// layers that were already downloaded
+func (a *App) ResumePull() (err error) {
+	defer a.recoverBinding("ResumePull", &err)
+	utils.LogInfo("ResumePull called")
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	op := a.journal.Begin(storage.OpImagePull, map[string]string{"image": a.dockerManager.GetImageName(), "resumed": "true"})
+	err = a.dockerManager.ResumePull(a.onPullProgress)
+	op.Finish(err)
+	if err := a.emitPullInterrupted(err); err != nil {
+		utils.LogError("Failed to resume image pull", err)
+		return fmt.Errorf("failed to resume image pull: %w", err)
+	}
+
+	utils.LogInfo("Interrupted image pull completed")
+	return nil
+}
+
+// DiscoverFleet finds other manager instances on the LAN and reports their status
+func (a *App) DiscoverFleet() (_ []fleet.Member, err error) {
+	defer a.recoverBinding("DiscoverFleet", &err)
+	utils.LogInfo("DiscoverFleet called")
+
+	controller, err := a.fleetController()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := controller.Discover()
+	if err != nil {
+		utils.LogError("Fleet discovery failed", err)
+		return nil, fmt.Errorf("failed to discover fleet: %w", err)
+	}
+	return members, nil
+}
+
+// FleetBroadcast sends start, stop or reset to every discovered fleet member
+func (a *App) FleetBroadcast(command string) (_ []fleet.CommandResult, err error) {
+	defer a.recoverBinding("FleetBroadcast", &err)
+	utils.LogInfo(fmt.Sprintf("FleetBroadcast called with command: %s", command))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	controller, err := a.fleetController()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := controller.Discover()
+	if err != nil {
+		utils.LogError("Fleet discovery failed", err)
+		return nil, fmt.Errorf("failed to discover fleet: %w", err)
+	}
+
+	results, err := controller.Broadcast(members, command)
+	if err != nil {
+		utils.LogError("Fleet broadcast failed", err)
+		return nil, fmt.Errorf("failed to broadcast %s: %w", command, err)
+	}
+	return results, nil
+}
+
+// fleetController returns a controller if fleet mode is enabled in settings
+func (a *App) fleetController() (*fleet.Controller, error) {
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	if !settings.Fleet.Enabled {
+		return nil, fmt.Errorf("fleet mode is not enabled")
+	}
+	token, err := a.fleetToken.Get()
+	if err != nil {
+		return nil, err
+	}
+	return fleet.NewController(token), nil
+}
+
+// SetFleetMode makes this machine a fleet member: other members with the same token discover it
+// over mDNS and can start, stop and reset its prototype through an API on apiPort, which listens
+// on the LAN. 0 keeps the current port. The token goes to the secret store rather than
+// settings.json; storage.MaskedPassword keeps the stored one and an empty token removes it.
+func (a *App) SetFleetMode(enabled bool, token string, apiPort int) (err error) {
+	defer a.recoverBinding("SetFleetMode", &err)
+	utils.LogInfo(fmt.Sprintf("SetFleetMode called (enabled: %v, port: %d)", enabled, apiPort))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set fleet mode to %v", enabled), func(s *storage.Settings) {
+		s.Fleet.Enabled = enabled
+		if token != storage.MaskedPassword {
+			s.Fleet.TokenConfigured = token != ""
+		}
+		if apiPort > 0 {
+			s.Fleet.APIPort = apiPort
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save fleet settings", err)
+		return fmt.Errorf("failed to save fleet settings: %w", err)
+	}
+	if token != storage.MaskedPassword {
+		if err := a.fleetToken.Set(token); err != nil {
+			utils.LogError("Failed to save the fleet token", err)
+			return fmt.Errorf("failed to save the fleet token: %w", err)
+		}
+	}
+
+	a.applyFleetSettings(settings.Fleet)
+	a.applySharingSettings(settings.Sharing)
+	return nil
+}
+
+// applyFleetSettings starts or stops the fleet member API to match settings
+func (a *App) applyFleetSettings(settings storage.FleetSettings) {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopFleetAPI()
+	if !settings.Enabled {
+		return
+	}
+
+	token, err := a.fleetToken.Get()
+	if err != nil {
+		utils.LogError("Failed to load the fleet token", err)
+		return
+	}
+	server := fleet.NewServer(fleetBackend{app: a}, token)
+	if err := server.Start(settings.APIPort); err != nil {
+		utils.LogError("Failed to start fleet member API", err)
+		return
+	}
+	a.fleetAPI = server
+}
+
+// stopFleetAPI stops the fleet member API if running; the caller holds serversMu
+func (a *App) stopFleetAPI() {
+	if a.fleetAPI != nil {
+		a.fleetAPI.Stop()
+		a.fleetAPI = nil
+	}
+}
+
+// fleetBackend adapts the App to the fleet member API
+type fleetBackend struct {
+	app *App
+}
+
+// Status reports the container state, URL and image
+func (b fleetBackend) Status() (*fleet.MemberStatus, error) {
+	status, err := b.app.core.Status()
+	if err != nil {
+		return nil, err
+	}
+	return &fleet.MemberStatus{State: status.State, URL: b.app.publicURL(), Image: b.app.dockerManager.GetImageName()}, nil
+}
+
+// Start starts the prototype; an already running one is not an error
+func (b fleetBackend) Start() error {
+	err := b.app.RunMoodle()
+	if errors.IsSpecificError(err, errors.ErrContainerRunning) {
+		return nil
+	}
+	return err
+}
+
+// Stop stops the prototype; a machine without a container has nothing to stop
+func (b fleetBackend) Stop() error {
+	if !b.app.fileManager.ContainerIDExists() {
+		return nil
+	}
+	return b.app.StopMoodle()
+}
+
+// Reset removes the container and its data and installs a fresh prototype, returning the
+// classroom machine to the state every other member is in
+func (b fleetBackend) Reset() error {
+	if b.app.fileManager.ContainerIDExists() {
+		if err := b.app.RemoveContainer(true); err != nil {
+			return err
+		}
+	}
+	return b.app.RunMoodle()
+}
+
+// SetLANSharing enables or disables advertising this prototype to colleagues on the LAN
+func (a *App) SetLANSharing(enabled bool, name string) (err error) {
+	defer a.recoverBinding("SetLANSharing", &err)
+	utils.LogInfo(fmt.Sprintf("SetLANSharing called (enabled: %v, name: %q)", enabled, name))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set LAN sharing to %v", enabled), func(s *storage.Settings) {
+		s.Sharing.Enabled = enabled
+		s.Sharing.Name = name
+	})
+	if err != nil {
+		utils.LogError("Failed to save sharing settings", err)
+		return fmt.Errorf("failed to save sharing settings: %w", err)
+	}
+
+	a.applySharingSettings(settings.Sharing)
+	return nil
+}
+
+// SetWakeProxy configures the wake-on-demand proxy that serves the shared URL. The passcode
+// goes to the secret store rather than settings.json; storage.MaskedPassword keeps the stored
+// one and an empty passcode removes it.
+func (a *App) SetWakeProxy(enabled bool, port int, passcode string) (err error) {
+	defer a.recoverBinding("SetWakeProxy", &err)
+	utils.LogInfo(fmt.Sprintf("SetWakeProxy called (enabled: %v, port: %d)", enabled, port))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set wake proxy to %v", enabled), func(s *storage.Settings) {
+		s.Sharing.WakeProxyEnabled = enabled
+		s.Sharing.WakeProxyPort = port
+	})
+	if err != nil {
+		utils.LogError("Failed to save wake proxy settings", err)
+		return fmt.Errorf("failed to save wake proxy settings: %w", err)
+	}
+	if passcode != storage.MaskedPassword {
+		if err := a.wakePasscode.Set(passcode); err != nil {
+			utils.LogError("Failed to save the wake passcode", err)
+			return fmt.Errorf("failed to save the wake passcode: %w", err)
+		}
+	}
+
+	a.applySharingSettings(settings.Sharing)
+	return nil
+}
+
+// SetDockerHost manages Moodle on a remote Docker daemon, addressed either by host
+// (tcp://host:2376, ssh://user@host) or by docker context; both empty use DOCKER_HOST/DOCKER_CONTEXT
+func (a *App) SetDockerHost(host, dockerContext string, tlsVerify bool, certPath string) (err error) {
+	defer a.recoverBinding("SetDockerHost", &err)
+	utils.LogInfo(fmt.Sprintf("SetDockerHost called (host: %q, context: %q, tls: %v)", host, dockerContext, tlsVerify))
+
+	settings, err := a.updateSettings("Change Docker host", func(s *storage.Settings) {
+		s.DockerHost = storage.DockerHostSettings{
+			Host:      host,
+			Context:   dockerContext,
+			TLSVerify: tlsVerify,
+			CertPath:  certPath,
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save Docker host settings", err)
+		return fmt.Errorf("failed to save Docker host settings: %w", err)
+	}
+
+	core.ApplyDockerHost(settings.DockerHost)
+	// The HTTPS and wake-on-demand proxies forward to the previous daemon's address
+	a.applySharingSettings(settings.Sharing)
+	if err := a.applyTLSSettings(settings.TLS); err != nil {
+		utils.LogWarning(fmt.Sprintf("HTTPS proxy not restarted: %v", err))
+	}
+	return nil
+}
+
+// SetProxySettings configures the HTTP(S) proxy used for connectivity checks, downloads and the container
+func (a *App) SetProxySettings(httpProxy, httpsProxy, noProxy string) (err error) {
+	defer a.recoverBinding("SetProxySettings", &err)
+	utils.LogInfo("SetProxySettings called")
+
+	settings, err := a.updateSettings("Change proxy settings", func(s *storage.Settings) {
+		s.Proxy = storage.ProxySettings{
+			HTTPProxy:  httpProxy,
+			HTTPSProxy: httpsProxy,
+			NoProxy:    noProxy,
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save proxy settings", err)
+		return fmt.Errorf("failed to save proxy settings: %w", err)
+	}
+
+	core.ApplyProxy(settings.Proxy)
+	return nil
+}
+
+// applySharingSettings starts or stops the mDNS advertisement and wake proxy to match settings.
+// A fleet member is advertised even when sharing is off, so that controllers find it.
+func (a *App) applySharingSettings(sharing storage.SharingSettings) {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopAdvertising()
+	a.stopWakeProxy()
+	if !sharing.Enabled && a.fleetAPI == nil {
+		return
+	}
+
+	ip, err := mdns.LocalIPv4()
+	if err != nil {
+		utils.LogError("Cannot share prototype without a LAN address", err)
+		return
+	}
+
+	// With the wake proxy on, visitors reach Moodle through the manager
+	port := a.dockerManager.GetHostPort()
+	if sharing.Enabled && sharing.WakeProxyEnabled {
+		if err := a.startWakeProxy(sharing); err != nil {
+			utils.LogError("Failed to start wake-on-demand proxy", err)
+		} else {
+			port = sharing.WakeProxyPort
+		}
+	}
+
+	name := sharing.Name
+	if name == "" {
+		name = a.advertisedHostName()
+	}
+
+	text := map[string]string{
+		fleet.TextName: name,
+		fleet.TextURL:  fmt.Sprintf("http://%s:%d", ip, port),
+	}
+	// Fleet controllers only list instances advertising their member API
+	if a.fleetAPI != nil {
+		text[fleet.TextAPIPort] = strconv.Itoa(a.fleetAPI.Port())
+	}
+	responder, err := mdns.NewResponder(mdns.Service{
+		Instance: name,
+		Port:     port,
+		Text:     text,
+	})
+	if err != nil {
+		utils.LogError("Failed to prepare mDNS advertisement", err)
+		return
+	}
+
+	if err := responder.Start(); err != nil {
+		utils.LogError("Failed to start mDNS advertisement", err)
+		return
+	}
+	a.advertiser = responder
+}
+
+// advertisedHostName names this machine in the mDNS advertisement: its host name without the
+// domain, or the OS user when the host name is unknown
+func (a *App) advertisedHostName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		utils.LogDebug(fmt.Sprintf("Advertising the OS user instead of the host name: %v", err))
+		return a.dockerManager.GetUserName()
+	}
+	host, _, _ = strings.Cut(host, ".")
+	return host
+}
+
+// KioskAPIInfo tells the booth's tools where the kiosk API listens and how to authenticate
+type KioskAPIInfo struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	Token   string `json:"token"`
+}
+
+// SetKioskMode turns unattended kiosk operation on or off. In kiosk mode Moodle starts with the
+// manager, is restarted by a watchdog after crashes or hangs, and a local API on 127.0.0.1
+// exposes only status, start and open-url. A token for that API is generated the first time
+// kiosk mode is enabled.
+func (a *App) SetKioskMode(enabled bool, apiPort int) (_ *KioskAPIInfo, err error) {
+	defer a.recoverBinding("SetKioskMode", &err)
+	utils.LogInfo(fmt.Sprintf("SetKioskMode called (enabled: %v, port: %d)", enabled, apiPort))
+
+	if enabled {
+		if _, err := loadOrCreateToken(a.kioskToken, kiosk.NewToken); err != nil {
+			utils.LogError("Failed to prepare the kiosk API token", err)
+			return nil, fmt.Errorf("failed to prepare the kiosk API token: %w", err)
+		}
+	}
+	settings, err := a.updateSettings(fmt.Sprintf("Set kiosk mode to %v", enabled), func(s *storage.Settings) {
+		s.Kiosk.Enabled = enabled
+		if apiPort > 0 {
+			s.Kiosk.APIPort = apiPort
+		}
+		if enabled {
+			s.Kiosk.TokenConfigured = true
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save kiosk settings", err)
+		return nil, fmt.Errorf("failed to save kiosk settings: %w", err)
+	}
+
+	a.applyKioskSettings(settings.Kiosk)
+	if err := a.applyRestartPolicy(settings); err != nil {
+		return nil, err
+	}
+	return a.kioskAPIInfo(settings.Kiosk)
+}
+
+// GetKioskAPIInfo returns the kiosk API address and token
+func (a *App) GetKioskAPIInfo() (_ *KioskAPIInfo, err error) {
+	defer a.recoverBinding("GetKioskAPIInfo", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return a.kioskAPIInfo(settings.Kiosk)
+}
+
+// kioskAPIInfo describes the kiosk API configured by settings, with the token from the secret store
+func (a *App) kioskAPIInfo(settings storage.KioskSettings) (*KioskAPIInfo, error) {
+	token, err := a.kioskToken.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &KioskAPIInfo{
+		Enabled: settings.Enabled,
+		URL:     fmt.Sprintf("http://127.0.0.1:%d", settings.APIPort),
+		Token:   token,
+	}, nil
+}
+
+// GetKioskStatus returns the prototype state and the watchdog's recovery count
+func (a *App) GetKioskStatus() kiosk.Status {
+	defer a.recoverBinding("GetKioskStatus", nil)
+	return kioskBackend{app: a}.Status()
+}
+
+// applyKioskSettings starts or stops the kiosk API and watchdog to match settings
+func (a *App) applyKioskSettings(settings storage.KioskSettings) {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopKiosk()
+	if !settings.Enabled {
+		return
+	}
+
+	a.watchdog.Start(time.Duration(settings.CheckIntervalSeconds) * time.Second)
+	token, err := a.kioskToken.Get()
+	if err != nil {
+		utils.LogError("Failed to load the kiosk API token", err)
+		return
+	}
+	if token == "" {
+		// Set by a release that made the token optional; turning kiosk mode on again generates one
+		utils.LogWarning("The kiosk API has no token and refuses every request; enable kiosk mode again to generate one")
+	}
+	server := kiosk.NewServer(kioskBackend{app: a}, token)
+	if err := server.Start(settings.APIPort); err != nil {
+		utils.LogError("Failed to start kiosk API", err)
+		return
+	}
+	a.kioskAPI = server
+}
+
+// stopKiosk stops the kiosk API and watchdog if running; the caller holds serversMu
+func (a *App) stopKiosk() {
+	a.watchdog.Stop()
+	if a.kioskAPI != nil {
+		a.kioskAPI.Stop()
+		a.kioskAPI = nil
+	}
+}
+
+// onKioskRecovery records an automatic restart by the kiosk watchdog
+func (a *App) onKioskRecovery(reason string, err error) {
+	message := fmt.Sprintf("Kiosk watchdog restarted Moodle: %s", reason)
+	if err != nil {
+		message = fmt.Sprintf("Kiosk watchdog could not restart Moodle (%s): %v", reason, err)
+	}
+	if timelineErr := a.timeline.Add("kiosk:recovery", message, nil); timelineErr != nil {
+		utils.LogError("Failed to record kiosk recovery in timeline", timelineErr)
+	}
+	a.emit("moodle:kiosk:recovery", map[string]any{"reason": reason, "success": err == nil})
+	if err != nil {
+		a.notify(notify.CategoryContainer, a.i18n.T("Moodle was restarted"), a.i18n.Tf("Kiosk watchdog could not restart Moodle (%s): %v", reason, err))
+	} else {
+		a.notify(notify.CategoryContainer, a.i18n.T("Moodle was restarted"), a.i18n.Tf("Kiosk watchdog restarted Moodle: %s", reason))
+	}
+}
+
+// kioskBackend adapts the App to the kiosk API and watchdog
+type kioskBackend struct {
+	app *App
+}
+
+// Status reports whether Moodle is running and answering
+func (b kioskBackend) Status() kiosk.Status {
+	status := kiosk.Status{State: "stopped", URL: b.app.publicURL()}
+	if b.Running() {
+		status.State = "starting"
+		if b.Ready() {
+			status.State = "running"
+		}
+	}
+	status.Recoveries, status.LastRecovery = b.app.watchdog.Recoveries()
+	return status
+}
+
+// Running reports whether the Moodle container is running
+func (b kioskBackend) Running() bool {
+	_, err := b.app.core.RunningContainerID()
+	return err == nil
+}
+
+// Ready reports whether Moodle is healthy
+func (b kioskBackend) Ready() bool {
+	return b.app.isMoodleReady()
+}
+
+// Start starts the prototype; an already running one is not an error
+func (b kioskBackend) Start() error {
+	err := b.app.RunMoodle()
+	if errors.IsSpecificError(err, errors.ErrContainerRunning) {
+		return nil
+	}
+	return err
+}
+
+// Restart stops a hung prototype and starts it again
+func (b kioskBackend) Restart() error {
+	if err := b.app.StopMoodle(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Stopping hung prototype failed: %v", err))
+	}
+	return b.Start()
+}
+
+// OpenURL opens Moodle in the local browser
+func (b kioskBackend) OpenURL() error {
+	return b.app.openURL(b.app.publicURL())
+}
+
+// ControlAPIInfo tells local tools where the control API listens and how to authenticate
+type ControlAPIInfo struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	Token   string `json:"token"`
+}
+
+// SetControlAPI turns the control API on or off. The API listens on 127.0.0.1:port and lets
+// local tools such as test harnesses provision and tear down Moodle; a token is generated the
+// first time it is enabled.
+func (a *App) SetControlAPI(enabled bool, port int) (_ *ControlAPIInfo, err error) {
+	defer a.recoverBinding("SetControlAPI", &err)
+	utils.LogInfo(fmt.Sprintf("SetControlAPI called (enabled: %v, port: %d)", enabled, port))
+
+	if enabled {
+		if _, err := loadOrCreateToken(a.controlToken, control.NewToken); err != nil {
+			utils.LogError("Failed to prepare the control API token", err)
+			return nil, fmt.Errorf("failed to prepare the control API token: %w", err)
+		}
+	}
+	settings, err := a.updateSettings(fmt.Sprintf("Set control API to %v", enabled), func(s *storage.Settings) {
+		s.ControlAPI.Enabled = enabled
+		if port > 0 {
+			s.ControlAPI.Port = port
+		}
+		if enabled {
+			s.ControlAPI.TokenConfigured = true
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save control API settings", err)
+		return nil, fmt.Errorf("failed to save control API settings: %w", err)
+	}
+
+	a.applyControlAPISettings(settings.ControlAPI)
+	return a.controlAPIInfo(settings.ControlAPI)
+}
+
+// RegenerateControlAPIToken replaces the control API token, locking out tools using the old one.
+// It cannot be undone, so a leaked token stays revoked.
+func (a *App) RegenerateControlAPIToken() (_ *ControlAPIInfo, err error) {
+	defer a.recoverBinding("RegenerateControlAPIToken", &err)
+	utils.LogInfo("RegenerateControlAPIToken called")
+
+	token, err := control.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.controlToken.Set(token); err != nil {
+		utils.LogError("Failed to save control API token", err)
+		return nil, fmt.Errorf("failed to save control API token: %w", err)
+	}
+	settings, err := a.updateSettingsWithoutUndo("Regenerate control API token", func(s *storage.Settings) {
+		s.ControlAPI.TokenConfigured = true
+	})
+	if err != nil {
+		utils.LogError("Failed to save control API settings", err)
+		return nil, fmt.Errorf("failed to save control API settings: %w", err)
+	}
+
+	a.applyControlAPISettings(settings.ControlAPI)
+	return a.controlAPIInfo(settings.ControlAPI)
+}
+
+// GetControlAPIInfo returns the control API address and token
+func (a *App) GetControlAPIInfo() (_ *ControlAPIInfo, err error) {
+	defer a.recoverBinding("GetControlAPIInfo", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return a.controlAPIInfo(settings.ControlAPI)
+}
+
+// controlAPIInfo describes the control API configured by settings, with the token from the
+// secret store
+func (a *App) controlAPIInfo(settings storage.ControlAPISettings) (*ControlAPIInfo, error) {
+	token, err := a.controlToken.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &ControlAPIInfo{
+		Enabled: settings.Enabled,
+		URL:     fmt.Sprintf("http://127.0.0.1:%d", settings.Port),
+		Token:   token,
+	}, nil
+}
+
+// loadOrCreateToken returns the API token kept in secrets, storing a new one from generate when
+// there is none yet
+func loadOrCreateToken(secrets *storage.SecretManager, generate func() (string, error)) (string, error) {
+	token, err := secrets.Get()
+	if err != nil || token != "" {
+		return token, err
+	}
+	if token, err = generate(); err != nil {
+		return "", err
+	}
+	if err := secrets.Set(token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// applyControlAPISettings starts or stops the control API to match settings
+func (a *App) applyControlAPISettings(settings storage.ControlAPISettings) {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopControlAPI()
+	if !settings.Enabled {
+		return
+	}
+
+	token, err := a.controlToken.Get()
+	if err != nil {
+		utils.LogError("Failed to load the control API token", err)
+		return
+	}
+	server := control.NewServer(controlBackend{app: a}, token)
+	if err := server.Start(settings.Port); err != nil {
+		utils.LogError("Failed to start control API", err)
+		return
+	}
+	a.controlAPI = server
+}
+
+// stopControlAPI stops the control API if running; the caller holds serversMu
+func (a *App) stopControlAPI() {
+	if a.controlAPI != nil {
+		a.controlAPI.Stop()
+		a.controlAPI = nil
+	}
+}
+
+// controlBackend adapts the App to the control API
+type controlBackend struct {
+	app *App
+}
+
+// Status reports the container state; Moodle is ready once it serves requests and its admin
+// login is known
+func (b controlBackend) Status() (*control.Status, error) {
+	status, err := b.app.core.Status()
+	if err != nil {
+		return nil, err
+	}
+	ready := status.State == core.StateRunning && status.HasPassword && b.app.isMoodleReady()
+	return &control.Status{Status: *status, Ready: ready}, nil
+}
+
+// Provision starts Moodle, from template when set; an already running one is not an error
+func (b controlBackend) Provision(template string) error {
+	if template != "" {
+		return b.app.CreateInstanceFromTemplate(template, "")
+	}
+	err := b.app.RunMoodle()
+	if errors.IsSpecificError(err, errors.ErrContainerRunning) {
+		return nil
+	}
+	return err
+}
+
+// Credentials returns the admin login once the install has finished
+func (b controlBackend) Credentials() (*control.Credentials, error) {
+	creds, err := b.app.credentialManager.Load()
+	if err != nil || creds.Password == "" {
+		return nil, fmt.Errorf("Moodle has not finished installing: %w", errors.ErrInvalidState)
+	}
+	return &control.Credentials{Username: creds.Username, Password: creds.Password, URL: creds.URL}, nil
+}
+
+// Stop stops Moodle
+func (b controlBackend) Stop() error {
+	if !b.app.fileManager.ContainerIDExists() {
+		return fmt.Errorf("no container to stop: %w", errors.ErrContainerNotFound)
+	}
+	return b.app.StopMoodle()
+}
+
+// Teardown removes the container; with no container there is nothing left to tear down
+func (b controlBackend) Teardown(removeVolumes bool) error {
+	if !b.app.fileManager.ContainerIDExists() {
+		return nil
+	}
+	return b.app.RemoveContainer(removeVolumes)
+}
+
+// wakeBackend adapts the App to the wake proxy's Backend interface
+type wakeBackend struct {
+	app *App
+}
+
+// IsReady reports whether Moodle is healthy
+func (b wakeBackend) IsReady() bool {
+	return b.app.isMoodleReady()
+}
+
+// Wake starts the prototype on behalf of a remote visitor
+func (b wakeBackend) Wake() error {
+	utils.LogInfo("Starting Moodle on behalf of a wake-on-demand request")
+	err := b.app.RunMoodle()
+	if errors.IsSpecificError(err, errors.ErrContainerRunning) {
+		return nil
+	}
+	return err
+}
+
+// IsStopped reports whether the Moodle container is missing or not running
+func (b wakeBackend) IsStopped() bool {
+	containerID, err := b.app.core.ContainerID()
+	if err != nil {
+		return true
+	}
+	running, err := b.app.dockerManager.IsContainerRunning(containerID)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to check whether the container is running: %v", err))
+		return false
+	}
+	return !running
+}
+
+// startWakeProxy serves the shared URL from the manager; the caller holds serversMu
+func (a *App) startWakeProxy(sharing storage.SharingSettings) error {
+	passcode, err := a.wakePasscode.Get()
+	if err != nil {
+		// Without the passcode the page still serves, but visitors cannot wake the prototype
+		utils.LogWarning(fmt.Sprintf("Failed to load the wake passcode: %v", err))
+	}
+	wakeProxy, err := proxy.NewWakeProxy(a.core.MoodleURL(), passcode, wakeBackend{app: a})
+	if err != nil {
+		return err
+	}
+	if err := wakeProxy.Start(fmt.Sprintf(":%d", sharing.WakeProxyPort)); err != nil {
+		return err
+	}
+	a.wakeProxy = wakeProxy
+	return nil
+}
+
+// SetHTTPS enables or disables serving Moodle over HTTPS through the bundled reverse proxy
+func (a *App) SetHTTPS(enabled bool, port int) (err error) {
+	defer a.recoverBinding("SetHTTPS", &err)
+	utils.LogInfo(fmt.Sprintf("SetHTTPS called (enabled: %v, port: %d)", enabled, port))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set HTTPS to %v", enabled), func(s *storage.Settings) {
+		s.TLS.Enabled = enabled
+		if port > 0 {
+			s.TLS.Port = port
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save HTTPS settings", err)
+		return fmt.Errorf("failed to save HTTPS settings: %w", err)
+	}
+
+	if err := a.applyTLSSettings(settings.TLS); err != nil {
+		return err
+	}
+
+	return a.syncPublicURL(enabled)
+}
+
+// SetHostname serves Moodle at a friendly name such as moodle.local by adding a hosts-file
+// entry (prompting for administrator rights) and updating Moodle's wwwroot. Empty reverts to localhost.
+func (a *App) SetHostname(hostname string) (err error) {
+	defer a.recoverBinding("SetHostname", &err)
+	utils.LogInfo(fmt.Sprintf("SetHostname called (hostname: %q)", hostname))
+
+	hostname = strings.ToLower(strings.TrimSpace(hostname))
+	previous := a.hostname
+
+	if hostname != "" {
+		if err := storage.ValidateHostname(hostname); err != nil {
+			return err
+		}
+		if err := utils.AddHostsEntry(hostname); err != nil {
+			utils.LogError("Failed to add hosts entry", err)
+			return fmt.Errorf("failed to add hosts entry: %w", err)
+		}
+	}
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set hostname to %q", hostname), func(s *storage.Settings) {
+		s.Hostname = hostname
+	})
+	if err != nil {
+		utils.LogError("Failed to save hostname", err)
+		return fmt.Errorf("failed to save hostname: %w", err)
+	}
+
+	return a.applyHostname(previous, settings)
+}
+
+// applyHostname moves Moodle from the previous hostname to the one in settings, whose hosts
+// entry must already exist
+func (a *App) applyHostname(previous string, settings *storage.Settings) error {
+	if previous != "" && previous != settings.Hostname {
+		if err := utils.RemoveHostsEntry(previous); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to remove hosts entry for %s: %v", previous, err))
+		}
+	}
+
+	a.hostname = settings.Hostname
+	// The certificate must cover the new name
+	if err := a.applyTLSSettings(settings.TLS); err != nil {
+		return err
+	}
+	return a.syncPublicURL(settings.TLS.Enabled)
+}
+
+// syncPublicURL points Moodle's wwwroot and the stored credentials URL at publicURL
+func (a *App) syncPublicURL(sslproxy bool) error {
+	// Moodle must know its public URL, otherwise it redirects back to the old one
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		utils.LogInfo("Moodle is not running; its wwwroot will be updated when it next starts")
+		return nil
+	}
+	if err := a.dockerManager.ConfigureWWWRoot(containerID, a.publicURL(), sslproxy); err != nil {
+		return fmt.Errorf("failed to update Moodle wwwroot: %w", err)
+	}
+
+	if creds, err := a.credentialManager.Load(); err == nil && creds.Password != "" {
+		if err := a.credentialManager.Update(creds.Password, a.publicURL()); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to update stored URL: %v", err))
+		}
+	}
+	a.core.WriteStatus()
+	return nil
+}
+
+// ensurePublicURL updates Moodle's wwwroot when it differs from publicURL, so links keep
+// working after the host port or hostname changed. Caches are only purged on a change.
+func (a *App) ensurePublicURL(containerID string) error {
+	previous, err := a.core.EnsurePublicURL(containerID, a.publicURL(), a.httpsPort() != 0)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		a.recordURLChange(previous)
+	}
+	return nil
+}
+
+// recordURLChange adds a change of Moodle's wwwroot to publicURL to the timeline
+func (a *App) recordURLChange(previous string) {
+	if err := a.timeline.Add("moodle:wwwroot", fmt.Sprintf("Moodle URL changed from %s to %s", previous, a.publicURL()), nil); err != nil {
+		utils.LogError("Failed to record wwwroot change in timeline", err)
+	}
+}
+
+// SetMailCatcher enables or disables capturing Moodle's outgoing mail in a local mail catcher
+func (a *App) SetMailCatcher(enabled bool) (err error) {
+	defer a.recoverBinding("SetMailCatcher", &err)
+	utils.LogInfo(fmt.Sprintf("SetMailCatcher called (enabled: %v)", enabled))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set mail catcher to %v", enabled), func(s *storage.Settings) {
+		s.Mail.Enabled = enabled
+	})
+	if err != nil {
+		utils.LogError("Failed to save mail settings", err)
+		return fmt.Errorf("failed to save mail settings: %w", err)
+	}
+	a.dockerManager.SetMailCatcher(settings.Mail.Enabled, settings.Mail.UIPort)
+	return a.applyMailCatcher(settings.Mail.Enabled)
+}
+
+// applyMailCatcher starts or disables the mail catcher of the running container
+func (a *App) applyMailCatcher(enabled bool) error {
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil
+	}
+	if enabled {
+		if err := a.dockerManager.StartMailCatcher(containerID); err != nil {
+			return fmt.Errorf("failed to start mail catcher: %w", err)
+		}
+		return nil
+	}
+	if err := a.dockerManager.DisableMailCatcher(containerID); err != nil {
+		return fmt.Errorf("failed to disable mail catcher: %w", err)
+	}
+	return nil
+}
+
+// SetSiteDetails sets the site full name, short name and admin email given to Moodle when its
+// install finishes. A running, installed Moodle is updated straight away. Empty values keep
+// what the installer chose.
+func (a *App) SetSiteDetails(fullName, shortName, adminEmail string) (err error) {
+	defer a.recoverBinding("SetSiteDetails", &err)
+	utils.LogInfo(fmt.Sprintf("SetSiteDetails called (fullName: %q, shortName: %q, adminEmail: %q)", fullName, shortName, adminEmail))
+
+	_, err = a.updateSettings("Change site details", func(s *storage.Settings) {
+		s.Site = storage.SiteSettings{
+			FullName:   strings.TrimSpace(fullName),
+			ShortName:  strings.TrimSpace(shortName),
+			AdminEmail: strings.TrimSpace(adminEmail),
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save site details", err)
+		return fmt.Errorf("failed to save site details: %w", err)
+	}
+	return a.applySiteDetails()
+}
+
+// applySiteDetails gives the saved site details to a running, installed Moodle
+func (a *App) applySiteDetails() error {
+	// Before the install finishes there is nothing to update; the waiter applies them then
+	if creds, err := a.credentialManager.Load(); err != nil || creds.Password == "" {
+		return nil
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil
+	}
+	return a.core.ApplySiteSettings(containerID)
+}
+
+// GetSiteDetails returns the configured site full name, short name and admin email
+func (a *App) GetSiteDetails() (_ *storage.SiteSettings, err error) {
+	defer a.recoverBinding("GetSiteDetails", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return &settings.Site, nil
+}
+
+// SetAdminer enables or disables the Adminer database UI next to Moodle
+func (a *App) SetAdminer(enabled bool) (err error) {
+	defer a.recoverBinding("SetAdminer", &err)
+	utils.LogInfo(fmt.Sprintf("SetAdminer called (enabled: %v)", enabled))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set Adminer to %v", enabled), func(s *storage.Settings) {
+		s.Adminer.Enabled = enabled
+	})
+	if err != nil {
+		utils.LogError("Failed to save Adminer settings", err)
+		return fmt.Errorf("failed to save Adminer settings: %w", err)
+	}
+	a.dockerManager.SetAdminer(settings.Adminer.Enabled, settings.Adminer.Port)
+	a.applyAdminer(settings.Adminer.Enabled)
+	return nil
+}
+
+// applyAdminer starts Adminer next to the running container, or stops it
+func (a *App) applyAdminer(enabled bool) {
+	if !enabled {
+		a.dockerManager.StopAdminer()
+		a.adminerInfo = nil
+		return
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return
+	}
+	if info, err := a.dockerManager.StartAdminer(containerID); err != nil {
+		utils.LogError("Failed to start Adminer", err)
+	} else {
+		a.adminerInfo = info
+	}
+}
+
+// GetAdminerInfo returns the Adminer URL and database login details while it is running
+func (a *App) GetAdminerInfo() (_ *docker.AdminerInfo, err error) {
+	defer a.recoverBinding("GetAdminerInfo", &err)
+	utils.LogInfo("GetAdminerInfo called")
+
+	if a.adminerInfo == nil {
+		return nil, fmt.Errorf("Adminer is not running: %w", errors.ErrInvalidState)
+	}
+	return a.adminerInfo, nil
+}
+
+// startSidecars starts the enabled companion containers and the PHP error log watch next to
+// Moodle
+func (a *App) startSidecars(containerID string) {
+	a.startPHPLogWatch(containerID)
+	a.adminerInfo = a.core.StartSidecars(containerID)
+}
+
+// stopSidecars removes companion containers and ends the PHP error log watch; they live only
+// as long as Moodle runs
+func (a *App) stopSidecars() {
+	a.stopPHPLogWatch()
+	a.core.StopSidecars()
+	a.adminerInfo = nil
+}
+
+// applyTLSSettings starts or stops the HTTPS proxy to match settings
+func (a *App) applyTLSSettings(tlsSettings storage.TLSSettings) error {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopTLSProxy()
+	if !tlsSettings.Enabled {
+		return nil
+	}
+
+	certFile := a.fileManager.DataFilePath(storage.TLSCertFile)
+	keyFile := a.fileManager.DataFilePath(storage.TLSKeyFile)
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if a.hostname != "" {
+		hosts = append([]string{a.hostname}, hosts...)
+	}
+	source, err := proxy.EnsureCertificate(certFile, keyFile, hosts)
+	if err != nil {
+		utils.LogError("Failed to prepare TLS certificate", err)
+		return fmt.Errorf("failed to prepare TLS certificate: %w", err)
+	}
+	utils.LogInfo(fmt.Sprintf("Using %s TLS certificate", source))
+
+	tlsProxy, err := proxy.NewTLSProxy(a.core.MoodleURL(), certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTPS proxy: %w", err)
+	}
+	if err := tlsProxy.Start(fmt.Sprintf(":%d", tlsSettings.Port)); err != nil {
+		utils.LogError("Failed to start HTTPS proxy", err)
+		return fmt.Errorf("failed to start HTTPS proxy: %w", err)
+	}
+
+	a.tlsMu.Lock()
+	a.tlsProxy = tlsProxy
+	a.tlsPort = tlsSettings.Port
+	a.tlsMu.Unlock()
+	return nil
+}
+
+// httpsPort returns the port the HTTPS proxy listens on, or 0 when it is not running
+func (a *App) httpsPort() int {
+	a.tlsMu.Lock()
+	defer a.tlsMu.Unlock()
+	if a.tlsProxy == nil {
+		return 0
+	}
+	return a.tlsPort
+}
+
+// retargetTLSProxy points a running HTTPS proxy at Moodle's current host port, which moves
+// when a container is adopted or a new one is given a free port
+func (a *App) retargetTLSProxy() {
+	a.tlsMu.Lock()
+	tlsProxy := a.tlsProxy
+	a.tlsMu.Unlock()
+	if tlsProxy == nil {
+		return
+	}
+	if err := tlsProxy.SetTarget(a.core.MoodleURL()); err != nil {
+		utils.LogError("Failed to retarget HTTPS proxy", err)
+	}
+}
+
+// stopTLSProxy stops the HTTPS proxy if it is running; the caller holds serversMu
+func (a *App) stopTLSProxy() {
+	a.tlsMu.Lock()
+	tlsProxy := a.tlsProxy
+	a.tlsProxy = nil
+	a.tlsMu.Unlock()
+	if tlsProxy != nil {
+		tlsProxy.Stop()
+	}
+}
+
+// stopWakeProxy shuts down the wake proxy if running; the caller holds serversMu
+func (a *App) stopWakeProxy() {
+	if a.wakeProxy != nil {
+		a.wakeProxy.Stop()
+		a.wakeProxy = nil
+	}
+}
+
+// stopAdvertising withdraws any active mDNS advertisement; the caller holds serversMu
+func (a *App) stopAdvertising() {
+	if a.advertiser != nil {
+		a.advertiser.Stop()
+		a.advertiser = nil
+	}
+}
+
+// stopServers stops every network server the manager runs, for shutdown
+func (a *App) stopServers() {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	a.stopKiosk()
+	a.stopControlAPI()
+	a.stopFleetAPI()
+	a.stopAdvertising()
+	a.stopWakeProxy()
+	a.stopTLSProxy()
+}
+
+// GetCronStatus returns the Moodle cron scheduler status for the frontend
+func (a *App) GetCronStatus() docker.CronStatus {
+	defer a.recoverBinding("GetCronStatus", nil)
+	status := a.cronScheduler.Status()
+
+	// Report the configured interval even while the scheduler is disabled
+	if settings, err := a.settingsManager.Load(); err == nil {
+		status.IntervalMinutes = settings.Cron.IntervalMinutes
+	}
+	return status
+}
+
+// SetCronSettings enables or disables scheduled cron and sets its interval
+func (a *App) SetCronSettings(enabled bool, intervalMinutes int) (err error) {
+	defer a.recoverBinding("SetCronSettings", &err)
+	utils.LogInfo(fmt.Sprintf("SetCronSettings called (enabled: %v, interval: %d min)", enabled, intervalMinutes))
+
+	settings, err := a.updateSettings(fmt.Sprintf("Set cron to %v every %d min", enabled, intervalMinutes), func(s *storage.Settings) {
+		s.Cron.Enabled = enabled
+		s.Cron.IntervalMinutes = intervalMinutes
+	})
+	if err != nil {
+		utils.LogError("Failed to save cron settings", err)
+		return fmt.Errorf("failed to save cron settings: %w", err)
+	}
+
+	a.applyCronSettings(settings.Cron)
+	return nil
+}
+
+// RunCronNow runs Moodle cron immediately in the current container
+func (a *App) RunCronNow() (err error) {
+	defer a.recoverBinding("RunCronNow", &err)
+	utils.LogInfo("RunCronNow called")
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := a.cronScheduler.RunNow(); err != nil {
+		utils.LogError("Manual cron run failed", err)
+		return fmt.Errorf("failed to run cron: %w", err)
+	}
+	return nil
+}
+
+// PurgeDemoUsers removes all seeded demo users and their data without resetting the instance
+func (a *App) PurgeDemoUsers() (_ *docker.PurgeResult, err error) {
+	defer a.recoverBinding("PurgeDemoUsers", &err)
+	utils.LogInfo("PurgeDemoUsers called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.dockerManager.PurgeDemoUsers(containerID)
+	if err != nil {
+		utils.LogError("Failed to purge demo users", err)
+		return nil, fmt.Errorf("failed to purge demo users: %w", err)
+	}
+	return result, nil
+}
+
+// SeedDemoData fills the running Moodle with generated courses, users and activities.
+// size is S, M or L; progress is reported through moodle:seed:progress events.
+func (a *App) SeedDemoData(size string) (_ *docker.SeedResult, err error) {
+	defer a.recoverBinding("SeedDemoData", &err)
+	utils.LogInfo(fmt.Sprintf("SeedDemoData called with size: %s", size))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	size, err = docker.ValidateDemoDataSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+	return a.seedDemoData(containerID, size)
+}
+
+// seedDemoData seeds demo data into the container, reporting progress through moodle:seed:progress
+func (a *App) seedDemoData(containerID, size string) (*docker.SeedResult, error) {
+	result, err := a.core.SeedDemoData(containerID, size, a.emitSeedProgress)
+	if err != nil {
+		return nil, err
+	}
+	a.recordSeed(result)
+	return result, nil
+}
+
+// emitSeedProgress sends moodle:seed:progress while demo data is seeded
+func (a *App) emitSeedProgress(percentage float64, status string) {
+	a.emit("moodle:seed:progress", map[string]any{
+		"percentage": percentage,
+		"status":     status,
+	})
+}
+
+// recordSeed adds seeded demo data to the timeline
+func (a *App) recordSeed(result *docker.SeedResult) {
+	if err := a.timeline.Add("demo:seeded", fmt.Sprintf("Seeded %s demo data (%d courses)", result.Size, result.Courses), map[string]string{"duration": result.Duration}); err != nil {
+		utils.LogError("Failed to record demo data in timeline", err)
+	}
+}
+
+// moodleAPI returns the web service client for the running container, logging in as the admin
+func (a *App) moodleAPI() (string, *moodle.Client, error) {
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return "", nil, err
+	}
+	creds, err := a.credentialManager.Load()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	if creds.Password == "" {
+		return "", nil, fmt.Errorf("admin credentials are not available yet")
+	}
+
+	a.moodleMu.Lock()
+	defer a.moodleMu.Unlock()
+
+	// A new container or password needs a new token
+	key := containerID + "\x00" + creds.Username + "\x00" + creds.Password
+	if a.moodleClient == nil || a.moodleClientKey != key {
+		a.moodleClient = moodle.NewClient(a.core.MoodleURL(), creds.Username, creds.Password)
+		a.moodleClientKey = key
+	}
+	return containerID, a.moodleClient, nil
+}
+
+// callMoodle runs fn with the web service client. Web services are off in a fresh Moodle, so
+// they are switched on and fn retried the first time Moodle refuses.
+func (a *App) callMoodle(fn func(client *moodle.Client) error) error {
+	containerID, client, err := a.moodleAPI()
+	if err != nil {
+		return err
+	}
+
+	err = fn(client)
+	if !moodle.IsWebServicesDisabled(err) {
+		return err
+	}
+
+	if err := a.checkWritable(); err != nil {
+		return fmt.Errorf("web services are disabled in Moodle: %w", err)
+	}
+	utils.LogInfo("Moodle web services are disabled; enabling them")
+	if err := a.dockerManager.EnableWebServices(containerID); err != nil {
+		return fmt.Errorf("failed to enable web services: %w", err)
+	}
+	return fn(client)
+}
+
+// GetSiteInfo returns the live status of the prototype for the dashboard: Moodle version,
+// site name, course and user counts, and when cron last ran
+func (a *App) GetSiteInfo() (_ *SiteStatus, err error) {
+	defer a.recoverBinding("GetSiteInfo", &err)
+	status := &SiteStatus{}
+	err = a.callMoodle(func(client *moodle.Client) error {
+		info, err := client.SiteInfo()
+		if err != nil {
+			return err
+		}
+		courses, err := client.Courses()
+		if err != nil {
+			return err
+		}
+		users, err := client.UserCount()
+		if err != nil {
+			return err
+		}
+
+		status.SiteName = info.SiteName
+		status.SiteURL = info.SiteURL
+		status.Release = info.Release
+		status.Version = info.Version
+		status.Courses = len(courses)
+		status.Users = users
+		return nil
+	})
+	if err != nil {
+		utils.LogError("Failed to get Moodle site info", err)
+		return nil, fmt.Errorf("failed to get site info: %w", err)
+	}
+
+	// Moodle records every cron run, including those started outside this app
+	if containerID, err := a.core.RunningContainerID(); err == nil {
+		if lastRun, err := a.dockerManager.LastCronStart(containerID); err == nil {
+			status.CronLastRun = lastRun
+		} else {
+			utils.LogWarning(fmt.Sprintf("Failed to read Moodle's last cron run: %v", err))
+		}
+	}
+	if scheduled := a.cronScheduler.Status().LastRun; scheduled.After(status.CronLastRun) {
+		status.CronLastRun = scheduled
+	}
+	return status, nil
+}
+
+// ListCourses returns the courses of the running Moodle, excluding the front page
+func (a *App) ListCourses() (_ []moodle.Course, err error) {
+	defer a.recoverBinding("ListCourses", &err)
+	var courses []moodle.Course
+	err = a.callMoodle(func(client *moodle.Client) error {
+		var err error
+		courses, err = client.Courses()
+		return err
+	})
+	if err != nil {
+		utils.LogError("Failed to list Moodle courses", err)
+		return nil, fmt.Errorf("failed to list courses: %w", err)
+	}
+	return courses, nil
+}
+
+// GetCourseEnrolments returns the participants of a course with their roles
+func (a *App) GetCourseEnrolments(courseID int) (_ []moodle.EnrolledUser, err error) {
+	defer a.recoverBinding("GetCourseEnrolments", &err)
+	var users []moodle.EnrolledUser
+	err = a.callMoodle(func(client *moodle.Client) error {
+		var err error
+		users, err = client.EnrolledUsers(courseID)
+		return err
+	})
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to list enrolments of course %d", courseID), err)
+		return nil, fmt.Errorf("failed to list enrolments: %w", err)
+	}
+	return users, nil
+}
+
+// InstallPlugin installs a plugin from a zip file path or moodle.org plugin name into the running container
+func (a *App) InstallPlugin(source string) (_ *docker.PluginInstallResult, err error) {
+	defer a.recoverBinding("InstallPlugin", &err)
+	utils.LogInfo(fmt.Sprintf("InstallPlugin called with source: %s", source))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	op := a.journal.Begin(storage.OpPluginInstall, map[string]string{"source": source})
+	result, err := a.dockerManager.InstallPlugin(containerID, source, func(percentage float64, status string) {
+		a.emit("moodle:plugin:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	})
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Plugin installation failed", err)
+		a.emit("moodle:plugin:failed", map[string]any{
+			"source": source,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("failed to install plugin: %w", err)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Plugin %s installed into %s", result.Component, result.Directory))
+	action := "Installed"
+	if result.Replaced {
+		action = "Replaced"
+	}
+	details := map[string]string{"component": result.Component, "source": source}
+	if err := a.timeline.Add(storage.TimelinePluginInstalled, fmt.Sprintf("%s plugin %s", action, result.Component), details); err != nil {
+		utils.LogError("Failed to record plugin install in timeline", err)
+	}
+
+	// Only fresh installs can be undone; the previous code of a replaced plugin is gone
+	if !result.Replaced {
+		component := result.Component
+		a.undo.push(fmt.Sprintf("Install plugin %s", component), func() error {
+			return a.dockerManager.UninstallPlugin(containerID, component)
+		})
+	}
+	return result, nil
+}
+
+// ExportForProduction writes deployment artifacts for handing the prototype to an ops team.
+// When outDir is empty the user is asked to choose a folder.
+func (a *App) ExportForProduction(outDir string) (_ *docker.ProductionExport, err error) {
+	defer a.recoverBinding("ExportForProduction", &err)
+	utils.LogInfo(fmt.Sprintf("ExportForProduction called with: %q", outDir))
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.core.CheckExportQuota(); err != nil {
+		utils.LogError("Production export refused", err)
+		return nil, fmt.Errorf("cannot export for production: %w", err)
+	}
+
+	if outDir == "" {
+		outDir, err = wailsruntime.OpenDirectoryDialog(a.ctx, wailsruntime.OpenDialogOptions{
+			Title:                "Choose a folder for the production export",
+			CanCreateDirectories: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose export folder: %w", err)
+		}
+		if outDir == "" {
+			return nil, fmt.Errorf("export cancelled")
+		}
+	}
+
+	op := a.journal.Begin(storage.OpExport, map[string]string{"directory": outDir})
+	result, err := a.dockerManager.ExportForProduction(containerID, outDir, func(percentage float64, status string) {
+		a.emit("moodle:export:progress", map[string]any{
+			"percentage": percentage,
+			"status":     status,
+		})
+	})
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Production export failed", err)
+		return nil, fmt.Errorf("failed to export for production: %w", err)
+	}
+
+	if err := a.timeline.Add("export:production", fmt.Sprintf("Exported prototype for production to %s", result.Directory), nil); err != nil {
+		utils.LogError("Failed to record export in timeline", err)
+	}
+	return result, nil
+}
+
+// ExportCompose writes a docker-compose.yml reproducing the current instance and its sidecars.
+// When outPath is empty the user is asked where to save it. Returns the written path.
+func (a *App) ExportCompose(outPath string) (_ string, err error) {
+	defer a.recoverBinding("ExportCompose", &err)
+	utils.LogInfo(fmt.Sprintf("ExportCompose called with: %q", outPath))
+
+	// Volumes are only known for an existing container; without one the file still
+	// reproduces image, ports, environment and sidecars
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Exporting compose file without volumes: %v", err))
+		containerID = ""
+	}
+
+	if outPath == "" {
+		outPath, err = wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+			Title:           "Save docker-compose.yml",
+			DefaultFilename: docker.ExportComposeFile,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to choose compose file location: %w", err)
+		}
+		if outPath == "" {
+			return "", fmt.Errorf("export cancelled")
+		}
+	}
+
+	if err := a.dockerManager.ExportCompose(containerID, outPath); err != nil {
+		utils.LogError("Compose export failed", err)
+		return "", fmt.Errorf("failed to export compose file: %w", err)
+	}
+
+	if err := a.timeline.Add("export:compose", fmt.Sprintf("Exported docker-compose.yml to %s", outPath), nil); err != nil {
+		utils.LogError("Failed to record export in timeline", err)
+	}
+	return outPath, nil
+}
+
+// StartRecording records backend events and docker commands, with secrets and personal
+// details removed, until StopRecording saves them as a trace for a bug report
+func (a *App) StartRecording() (err error) {
+	defer a.recoverBinding("StartRecording", &err)
+	utils.LogInfo("StartRecording called")
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+	if a.recorder.IsRecording() {
+		return fmt.Errorf("a session is already being recorded")
+	}
+	a.recorder.Start()
+	a.events.Subscribe(recorderSubscriberID, events.LevelDebug, a.recorder.RecordEvent)
+	docker.SetCommandObserver(a.recorder.RecordCommand)
+
+	a.emit("moodle:recording:started")
+	return nil
+}
+
+// IsRecording reports whether a session is being recorded
+func (a *App) IsRecording() bool {
+	defer a.recoverBinding("IsRecording", nil)
+	return a.recorder.IsRecording()
+}
+
+// StopRecording ends the recording and saves the trace to outPath, asking for a location
+// when it is empty. Cancelling the dialog discards the recording.
+func (a *App) StopRecording(outPath string) (_ string, err error) {
+	defer a.recoverBinding("StopRecording", &err)
+	utils.LogInfo("StopRecording called")
+
+	a.events.Unsubscribe(recorderSubscriberID)
+	docker.SetCommandObserver(nil)
+	trace := a.recorder.Stop()
+	if trace == nil {
+		return "", fmt.Errorf("no session is being recorded")
+	}
+	a.emit("moodle:recording:stopped")
+
+	if outPath == "" {
+		var err error
+		outPath, err = wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+			Title:           "Save session recording",
+			DefaultFilename: scenario.DefaultTraceFile,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to choose recording location: %w", err)
+		}
+		if outPath == "" {
+			return "", fmt.Errorf("recording discarded")
+		}
+	}
+
+	if err := trace.Save(outPath); err != nil {
+		utils.LogError("Failed to save session recording", err)
+		return "", fmt.Errorf("failed to save session recording: %w", err)
+	}
+
+	if err := a.timeline.Add("recording:saved", fmt.Sprintf("Saved session recording to %s", outPath), map[string]string{"entries": fmt.Sprintf("%d", len(trace.Entries))}); err != nil {
+		utils.LogError("Failed to record session recording in timeline", err)
+	}
+	return outPath, nil
+}
+
+// RunMoodleTests runs PHPUnit (and optionally Behat) for a plugin inside the container,
+// streaming output through moodle:tests:output events
+func (a *App) RunMoodleTests(component string, includeBehat bool) (_ []docker.TestRunResult, err error) {
+	defer a.recoverBinding("RunMoodleTests", &err)
+	utils.LogInfo(fmt.Sprintf("RunMoodleTests called (component: %s, behat: %v)", component, includeBehat))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.dockerManager.RunMoodleTests(containerID, component, includeBehat, func(suite, line string) {
+		a.emit("moodle:tests:output", map[string]any{
+			"suite": suite,
+			"line":  line,
+		})
+	})
+	if err != nil {
+		utils.LogError("Test run failed", err)
+		return results, fmt.Errorf("failed to run tests for %s: %w", component, err)
+	}
+
+	a.emit("moodle:tests:finished", results)
+	return results, nil
+}
+
+// FollowLogs streams the container's logs to the log viewer as moodle:logs:batch events, each
+// carrying the lines of one interval. The next batch is held until AckLogBatch confirms the
+// previous one, so a busy frontend receives fewer, larger batches; lines dropped meanwhile are
+// counted in the batch. tail is the number of existing lines replayed first.
+func (a *App) FollowLogs(tail int) (err error) {
+	defer a.recoverBinding("FollowLogs", &err)
+	utils.LogInfo(fmt.Sprintf("FollowLogs called (tail: %d)", tail))
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return err
+	}
+
+	// Only one follower at a time; following again restarts the stream
+	a.StopFollowingLogs()
+
+	batcher := events.NewBatcher(events.DefaultBatchInterval, events.DefaultMaxPendingLines, func(batch events.Batch) {
+		a.emit("moodle:logs:batch", batch)
+	})
+	follower, err := a.dockerManager.FollowContainerLogs(containerID, tail, batcher.Add)
+	if err != nil {
+		utils.LogError("Failed to follow container logs", err)
+		return fmt.Errorf("failed to follow container logs: %w", err)
+	}
+	batcher.Start()
+
+	a.logMu.Lock()
+	a.logFollower = follower
+	a.logBatcher = batcher
+	a.logMu.Unlock()
+
+	a.goReported("followLogs", func() {
+		<-follower.Done()
+		batcher.Stop()
+
+		a.logMu.Lock()
+		current := a.logFollower == follower
+		if current {
+			a.logFollower = nil
+			a.logBatcher = nil
+		}
+		a.logMu.Unlock()
+
+		// A follower replaced or stopped by the user ends silently
+		if current {
+			if err := follower.Err(); err != nil {
+				utils.LogDebug(fmt.Sprintf("Log stream ended: %v", err))
+			}
+			a.emit("moodle:logs:ended")
+		}
+	})
+	return nil
+}
+
+// GetRecentLogs returns the latest tail lines of the current container's logs, capped at
+// docker.DefaultMaxLogBytes; 0 uses docker.DefaultLogTail
+func (a *App) GetRecentLogs(tail int) (_ *docker.LogChunk, err error) {
+	defer a.recoverBinding("GetRecentLogs", &err)
+	utils.LogInfo(fmt.Sprintf("GetRecentLogs called (tail: %d)", tail))
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return nil, err
+	}
+	if tail < 0 {
+		tail = 0
+	}
+
+	chunk, err := a.dockerManager.FetchContainerLogs(containerID, docker.LogFetchOptions{Tail: tail})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	return chunk, nil
+}
+
+// AckLogBatch confirms the frontend has rendered a log batch, releasing the next one
+func (a *App) AckLogBatch(seq int64) {
+	defer a.recoverBinding("AckLogBatch", nil)
+	a.logMu.Lock()
+	batcher := a.logBatcher
+	a.logMu.Unlock()
+
+	if batcher != nil {
+		batcher.Ack(seq)
+	}
+}
+
+// StopFollowingLogs ends the log stream started by FollowLogs, if any
+func (a *App) StopFollowingLogs() {
+	defer a.recoverBinding("StopFollowingLogs", nil)
+	a.logMu.Lock()
+	follower := a.logFollower
+	a.logFollower = nil
+	a.logBatcher = nil
+	a.logMu.Unlock()
+
+	if follower != nil {
+		follower.Stop()
+	}
+}
+
+// applyCronSettings starts or stops the cron scheduler to match settings
+func (a *App) applyCronSettings(cron storage.CronSettings) {
+	if !cron.Enabled {
+		a.cronScheduler.Stop()
+		return
+	}
+	a.cronScheduler.Start(time.Duration(cron.IntervalMinutes) * time.Minute)
+}
+
+// GetContainerStats returns current resource usage of the running container
+func (a *App) GetContainerStats() (_ *docker.ContainerStats, err error) {
+	defer a.recoverBinding("GetContainerStats", &err)
+	utils.LogInfo("GetContainerStats called")
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := a.dockerManager.GetContainerStats(containerID)
+	if err != nil {
+		utils.LogError("Failed to get container stats", err)
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	return stats, nil
+}
+
+// SetResourceAlerts configures memory and disk usage alert thresholds in percent
+func (a *App) SetResourceAlerts(enabled bool, memoryPercent, diskPercent float64) (err error) {
+	defer a.recoverBinding("SetResourceAlerts", &err)
+	utils.LogInfo(fmt.Sprintf("SetResourceAlerts called (enabled: %v, memory: %.0f%%, disk: %.0f%%)", enabled, memoryPercent, diskPercent))
+
+	settings, err := a.updateSettings("Change resource alert thresholds", func(s *storage.Settings) {
+		s.Alerts.Enabled = enabled
+		s.Alerts.MemoryPercent = memoryPercent
+		s.Alerts.DiskPercent = diskPercent
+	})
+	if err != nil {
+		utils.LogError("Failed to save alert settings", err)
+		return fmt.Errorf("failed to save alert settings: %w", err)
+	}
+
+	a.applyAlertSettings(settings.Alerts)
+	return nil
+}
+
+// CheckOOMKill reports whether the container was OOM-killed and the suggested memory limit
+func (a *App) CheckOOMKill() (_ *docker.OOMRecommendation, err error) {
+	defer a.recoverBinding("CheckOOMKill", &err)
+	utils.LogInfo("CheckOOMKill called")
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	recommendation, err := a.dockerManager.CheckOOMKill(containerID)
+	if err != nil {
+		utils.LogError("Failed to check for OOM kill", err)
+		return nil, fmt.Errorf("failed to check for OOM kill: %w", err)
+	}
+	return recommendation, nil
+}
+
+// ApplyMemoryLimit sets the container memory limit in MB, updating the existing container if any
+func (a *App) ApplyMemoryLimit(limitMB int) (err error) {
+	defer a.recoverBinding("ApplyMemoryLimit", &err)
+	utils.LogInfo(fmt.Sprintf("ApplyMemoryLimit called (limit: %d MB)", limitMB))
+	return a.applyMemoryLimit(int64(limitMB)*1024*1024, "user")
+}
+
+// handleOOMKill applies or offers a higher memory limit when the container was OOM-killed
+func (a *App) handleOOMKill(containerID string) {
+	recommendation, err := a.dockerManager.CheckOOMKill(containerID)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to check for OOM kill: %v", err))
+		return
+	}
+	if !recommendation.OOMKilled {
+		return
+	}
+
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		settings = storage.DefaultSettings()
+	}
+
+	a.notify(notify.CategoryContainer, a.i18n.T("Moodle ran out of memory"), recommendation.Message)
+
+	if recommendation.CanIncrease && settings.Memory.AutoAdjustOnOOM {
+		utils.LogInfo(fmt.Sprintf("OOM kill detected, automatically applying: %s", recommendation.Message))
+		if err := a.applyMemoryLimit(recommendation.SuggestedLimitBytes, "automatic after OOM kill"); err != nil {
+			utils.LogError("Failed to apply suggested memory limit", err)
+		}
+		return
+	}
+
+	utils.LogWarning(fmt.Sprintf("OOM kill detected, awaiting user decision: %s", recommendation.Message))
+	if err := a.timeline.Add("oom:detected", recommendation.Message, nil); err != nil {
+		utils.LogError("Failed to record OOM kill in timeline", err)
+	}
+	if a.ctx != nil {
+		a.emit("moodle:oom:detected", recommendation)
+	}
+}
+
+// applyMemoryLimit saves the memory limit, applies it to the existing container and logs the decision
+func (a *App) applyMemoryLimit(bytes int64, reason string) error {
+	limitMB := int(bytes / (1024 * 1024))
+	if _, err := a.updateSettings(fmt.Sprintf("Set memory limit to %d MB", limitMB), func(s *storage.Settings) {
+		s.Memory.LimitMB = limitMB
+	}); err != nil {
+		utils.LogError("Failed to save memory settings", err)
+		return fmt.Errorf("failed to save memory settings: %w", err)
+	}
+	a.dockerManager.SetMemoryLimit(bytes)
+	if err := a.updateContainerMemoryLimit(bytes); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Memory limit set to %d MB (%s)", limitMB, reason)
+	utils.LogInfo(message)
+	if err := a.timeline.Add("memory:limit", message, map[string]string{"limitMB": fmt.Sprintf("%d", limitMB), "reason": reason}); err != nil {
+		utils.LogError("Failed to record memory limit change in timeline", err)
+	}
+	return nil
+}
+
+// updateContainerMemoryLimit applies a memory limit to the existing container; 0 (no limit)
+// applies when the container is recreated
+func (a *App) updateContainerMemoryLimit(bytes int64) error {
+	if containerID, err := a.core.ContainerID(); err == nil && bytes > 0 {
+		if err := a.dockerManager.UpdateMemoryLimit(containerID, bytes); err != nil {
+			return fmt.Errorf("failed to update container memory limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetContainerLogLevels sets Apache, PHP and Moodle debug verbosity for the container.
+// Levels are passed as environment variables, so they apply when the container is next created.
+func (a *App) SetContainerLogLevels(apache, php, moodle string) (err error) {
+	defer a.recoverBinding("SetContainerLogLevels", &err)
+	utils.LogInfo(fmt.Sprintf("SetContainerLogLevels called (apache: %q, php: %q, moodle: %q)", apache, php, moodle))
+
+	logging := storage.LoggingSettings{
+		ApacheLogLevel: apache,
+		PHPErrorLevel:  php,
+		MoodleDebug:    moodle,
+	}
+	if err := a.dockerManager.SetLogLevels(core.LogLevelsFromSettings(logging)); err != nil {
+		return fmt.Errorf("invalid log levels: %w", err)
+	}
+
+	if _, err := a.updateSettings("Change container log levels", func(s *storage.Settings) {
+		s.Logging = logging
+	}); err != nil {
+		utils.LogError("Failed to save logging settings", err)
+		return fmt.Errorf("failed to save logging settings: %w", err)
+	}
+
+	if a.fileManager.ContainerIDExists() {
+		utils.LogInfo("Container log levels saved; they take effect when the container is recreated")
+	}
+	return nil
+}
+
+// GetContainerLogLevels returns the configured container log verbosity
+func (a *App) GetContainerLogLevels() docker.LogLevels {
+	defer a.recoverBinding("GetContainerLogLevels", nil)
+	return a.dockerManager.GetLogLevels()
+}
+
+// SetContainerEnv replaces the extra environment variables passed to new Moodle containers
+func (a *App) SetContainerEnv(env map[string]string) (err error) {
+	defer a.recoverBinding("SetContainerEnv", &err)
+	utils.LogInfo(fmt.Sprintf("SetContainerEnv called (%d variables)", len(env)))
+
+	settings, err := a.updateSettings("Change container environment", func(s *storage.Settings) {
+		s.ContainerEnv = env
+	})
+	if err != nil {
+		utils.LogError("Failed to save container environment", err)
+		return fmt.Errorf("failed to save container environment: %w", err)
+	}
+	a.dockerManager.SetContainerEnv(settings.ContainerEnv)
+
+	if a.fileManager.ContainerIDExists() {
+		utils.LogInfo("Container environment saved; it takes effect when the container is recreated")
+	}
+	return nil
+}
+
+// EnableXdebug turns on step debugging in the running container and returns IDE connection details
+func (a *App) EnableXdebug() (_ *docker.XdebugInfo, err error) {
+	defer a.recoverBinding("EnableXdebug", &err)
+	utils.LogInfo("EnableXdebug called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := a.dockerManager.EnableXdebug(containerID)
+	if err != nil {
+		utils.LogError("Failed to enable Xdebug", err)
+		return nil, fmt.Errorf("failed to enable Xdebug: %w", err)
+	}
+	return info, nil
+}
+
+// DisableXdebug turns off step debugging in the running container
+func (a *App) DisableXdebug() (err error) {
+	defer a.recoverBinding("DisableXdebug", &err)
+	utils.LogInfo("DisableXdebug called")
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return err
+	}
+
+	if err := a.dockerManager.DisableXdebug(containerID); err != nil {
+		utils.LogError("Failed to disable Xdebug", err)
+		return fmt.Errorf("failed to disable Xdebug: %w", err)
+	}
+	return nil
+}
+
+// SetMoodleDebugMode switches Moodle's debugging level ("none" to "developer") in the running
+// site, reloading the web server when PHP settings change. The level is also saved as the
+// container log level, so a recreated container starts with it.
+func (a *App) SetMoodleDebugMode(level string) (_ *docker.DebugMode, err error) {
+	defer a.recoverBinding("SetMoodleDebugMode", &err)
+	utils.LogInfo(fmt.Sprintf("SetMoodleDebugMode called (level: %s)", level))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := a.dockerManager.SetMoodleDebugMode(containerID, level)
+	if err != nil {
+		utils.LogError("Failed to change Moodle debugging", err)
+		return nil, fmt.Errorf("failed to change Moodle debugging: %w", err)
+	}
+
+	// The level is already set in the running site, which undo would not change back
+	settings, err := a.updateSettingsWithoutUndo("Change Moodle debugging", func(s *storage.Settings) {
+		s.Logging.MoodleDebug = level
+	})
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to save Moodle debug level: %v", err))
+	} else if err := a.dockerManager.SetLogLevels(core.LogLevelsFromSettings(settings.Logging)); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to apply container log levels: %v", err))
+	}
+
+	if err := a.timeline.Add("debug:mode", fmt.Sprintf("Moodle debugging set to %s", level), map[string]string{"reloaded": fmt.Sprintf("%v", mode.Reloaded)}); err != nil {
+		utils.LogError("Failed to record debug mode change in timeline", err)
+	}
+	a.emit("moodle:debug:changed", mode)
+	return mode, nil
+}
+
+// GetMoodleDebugMode returns Moodle's debugging level in the running site
+func (a *App) GetMoodleDebugMode() (_ *docker.DebugMode, err error) {
+	defer a.recoverBinding("GetMoodleDebugMode", &err)
+	utils.LogInfo("GetMoodleDebugMode called")
+
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := a.dockerManager.GetMoodleDebugMode(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Moodle debugging: %w", err)
+	}
+	return mode, nil
+}
+
+// CaptureProfile profiles one page request with Xdebug and saves the cachegrind file
+// to the data directory. pageURL may be a path such as /course/view.php?id=2.
+func (a *App) CaptureProfile(pageURL string) (_ *docker.ProfileResult, err error) {
+	defer a.recoverBinding("CaptureProfile", &err)
+	utils.LogInfo(fmt.Sprintf("CaptureProfile called (url: %s)", pageURL))
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	if pageURL == "" || strings.HasPrefix(pageURL, "/") {
+		pageURL = a.publicURL() + pageURL
+	}
+
+	result, err := a.dockerManager.CaptureProfile(containerID, pageURL, a.fileManager.DataFilePath(storage.ProfilesDir))
+	if err != nil {
+		utils.LogError("Failed to capture profile", err)
+		return nil, fmt.Errorf("failed to capture profile: %w", err)
+	}
+	return result, nil
+}
+
+// CaptureScreenshots logs in to Moodle with a headless browser and saves the dashboard, site
+// home and recent course pages to the data directory for status reports and handover sheets.
+// Progress is emitted as moodle:screenshots:progress events.
+func (a *App) CaptureScreenshots() (_ *docker.ScreenshotResult, err error) {
+	defer a.recoverBinding("CaptureScreenshots", &err)
+	utils.LogInfo("CaptureScreenshots called")
+
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+	containerID, err := a.core.RunningContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := a.credentialManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	if creds.Password == "" {
+		return nil, fmt.Errorf("no Moodle login details available yet")
+	}
+	outDir := a.fileManager.DataFilePath(storage.ScreenshotsDir)
+	result, err := a.dockerManager.CaptureScreenshots(containerID, a.publicURL(), creds.Username, creds.Password, outDir, func(done, total int, page string) {
+		a.emit("moodle:screenshots:progress", map[string]any{
+			"done":  done,
+			"total": total,
+			"page":  page,
+		})
+	})
+	if err != nil {
+		utils.LogError("Failed to capture screenshots", err)
+		return nil, fmt.Errorf("failed to capture screenshots: %w", err)
+	}
+
+	if err := a.timeline.Add("screenshots:captured", fmt.Sprintf("Captured %d page screenshots", len(result.Screenshots)), map[string]string{"directory": result.Directory}); err != nil {
+		utils.LogError("Failed to record screenshots in timeline", err)
+	}
+	return result, nil
+}
+
+// GenerateChangeReport combines settings changes, plugin installs, image updates and container
+// events recorded since the given RFC 3339 time (the last 24 hours when empty), answering what
+// changed since a prototype last worked
+func (a *App) GenerateChangeReport(since string) (_ *storage.ChangeReport, err error) {
+	defer a.recoverBinding("GenerateChangeReport", &err)
+	utils.LogInfo(fmt.Sprintf("GenerateChangeReport called (since: %q)", since))
+
+	now := time.Now()
+	from := now.Add(-24 * time.Hour)
+	if since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q: %w", since, errors.ErrInvalidFormat)
+		}
+		from = parsed
+	}
+
+	entries, err := a.timeline.Entries()
+	if err != nil {
+		utils.LogError("Failed to load timeline", err)
+		return nil, fmt.Errorf("failed to load timeline: %w", err)
+	}
+	return storage.BuildChangeReport(entries, from, now), nil
+}
+
+// GetTimeline returns recorded notable events, oldest first
+func (a *App) GetTimeline() (_ []storage.TimelineEntry, err error) {
+	defer a.recoverBinding("GetTimeline", &err)
+	utils.LogInfo("GetTimeline called")
+
+	entries, err := a.timeline.Entries()
+	if err != nil {
+		utils.LogError("Failed to load timeline", err)
+		return nil, fmt.Errorf("failed to load timeline: %w", err)
+	}
+	return entries, nil
+}
+
+// GetOperationHistory returns up to limit recorded operations such as pulls and container
+// starts with their outcomes, newest first; limit <= 0 returns the whole journal
+func (a *App) GetOperationHistory(limit int) (_ []storage.OperationRecord, err error) {
+	defer a.recoverBinding("GetOperationHistory", &err)
+	utils.LogInfo(fmt.Sprintf("GetOperationHistory called (limit: %d)", limit))
+
+	history, err := a.journal.History(limit)
+	if err != nil {
+		utils.LogError("Failed to load operation history", err)
+		return nil, fmt.Errorf("failed to load operation history: %w", err)
+	}
+	return history, nil
+}
+
+// SetIdleStop enables stopping Moodle after minutes without HTTP traffic
+func (a *App) SetIdleStop(enabled bool, minutes int) (err error) {
+	defer a.recoverBinding("SetIdleStop", &err)
+	utils.LogInfo(fmt.Sprintf("SetIdleStop called (enabled: %v, minutes: %d)", enabled, minutes))
+
+	description := "Disable stopping Moodle when idle"
+	if enabled {
+		description = fmt.Sprintf("Stop Moodle after %d idle minutes", minutes)
+	}
+	settings, err := a.updateSettings(description, func(s *storage.Settings) {
+		s.IdleStop.Enabled = enabled
+		s.IdleStop.Minutes = minutes
+	})
+	if err != nil {
+		utils.LogError("Failed to save idle stop settings", err)
+		return fmt.Errorf("failed to save idle stop settings: %w", err)
+	}
+
+	a.applyIdleStopSettings(settings.IdleStop)
+	return nil
+}
+
+// applyIdleStopSettings starts or stops the idle monitor to match settings
+func (a *App) applyIdleStopSettings(idleStop storage.IdleStopSettings) {
+	if !idleStop.Enabled {
+		a.idleMonitor.Stop()
+		return
+	}
+	a.idleMonitor.Start(time.Duration(idleStop.Minutes)*time.Minute, docker.DefaultIdleCheckInterval)
+}
+
+// onIdle stops a container nobody has used for the configured period and tells the user how
+// to resume it. Kiosk booths are meant to stay up and are left running.
+func (a *App) onIdle(containerID string, idleFor time.Duration) {
+	if settings, err := a.settingsManager.Load(); err == nil && settings.Kiosk.Enabled {
+		utils.LogInfo("Kiosk mode is enabled; not stopping the idle prototype")
+		return
+	}
+	// A first-run install serves no traffic for a long time
+	if a.tasks.running(taskGroupCredentials) {
+		utils.LogInfo("Moodle is still starting; not stopping the idle prototype")
+		return
+	}
+
+	minutes := int(idleFor.Round(time.Minute).Minutes())
+	if err := a.StopMoodle(); err != nil {
+		utils.LogError("Failed to stop idle container", err)
+		return
+	}
+
+	message := fmt.Sprintf("Moodle was stopped after %d minutes without use", minutes)
+	if err := a.timeline.Add("container:idle-stopped", message, map[string]string{"container": containerID}); err != nil {
+		utils.LogError("Failed to record idle stop in timeline", err)
+	}
+	a.emit("moodle:idle:stopped", map[string]any{
+		"containerId": containerID,
+		"idleMinutes": minutes,
+	})
+	a.notify(notify.CategoryContainer, a.i18n.T("Moodle stopped while idle"), a.i18n.Tf("Moodle was stopped after %d minutes without use. Open Moodle Prototype Manager and click Resume to start it again.", minutes))
+}
+
+// SetHealthMonitor enables the background Docker and connectivity checks, run every
+// intervalSeconds
+func (a *App) SetHealthMonitor(enabled bool, intervalSeconds int) (err error) {
+	defer a.recoverBinding("SetHealthMonitor", &err)
+	utils.LogInfo(fmt.Sprintf("SetHealthMonitor called (enabled: %v, interval: %ds)", enabled, intervalSeconds))
+
+	description := "Disable background health checks"
+	if enabled {
+		description = fmt.Sprintf("Check health every %d seconds", intervalSeconds)
+	}
+	settings, err := a.updateSettings(description, func(s *storage.Settings) {
+		s.HealthMonitor.Enabled = enabled
+		s.HealthMonitor.IntervalSeconds = intervalSeconds
+	})
+	if err != nil {
+		utils.LogError("Failed to save health monitor settings", err)
+		return fmt.Errorf("failed to save health monitor settings: %w", err)
+	}
+
+	a.applyHealthMonitorSettings(settings.HealthMonitor)
+	return nil
+}
+
+// applyHealthMonitorSettings starts or stops the health monitor to match settings
+func (a *App) applyHealthMonitorSettings(healthMonitor storage.HealthMonitorSettings) {
+	if !healthMonitor.Enabled {
+		a.healthMonitor.Stop()
+		return
+	}
+	a.healthMonitor.Start(time.Duration(healthMonitor.IntervalSeconds) * time.Second)
+}
+
+// sampleHealth runs one round of the background health checks. The container is only
+// inspected while Docker answers.
+func (a *App) sampleHealth() docker.HealthSnapshot {
+	snapshot := docker.HealthSnapshot{
+		Docker:    docker.CheckDockerHealth(),
+		Internet:  docker.CheckInternetHealth(),
+		Container: docker.HealthStopped,
+		CheckedAt: time.Now(),
+	}
+	if !snapshot.Docker {
+		snapshot.Container = ""
+		return snapshot
+	}
+
+	containerID, err := a.core.ContainerID()
+	if err != nil {
+		return snapshot
+	}
+	health, err := a.dockerManager.GetContainerHealth(containerID)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Health monitor could not read container health: %v", err))
+		return snapshot
+	}
+	a.publishHealth(health)
+	snapshot.Container = health.Status
+	return snapshot
+}
+
+// onHealthChange tells the frontend about checks that changed mid-session, records them in the
+// timeline and raises an alert when Docker or the network is lost
+func (a *App) onHealthChange(change docker.HealthChange) {
+	current := change.Current
+	utils.LogInfo(fmt.Sprintf("Health changed (%s): docker=%v internet=%v container=%s",
+		strings.Join(change.Changed, ", "), current.Docker, current.Internet, current.Container))
+
+	if change.Has(docker.HealthCheckDocker) {
+		message := "Docker is available again"
+		if !current.Docker {
+			message = "Docker stopped responding"
+			a.notify(notify.CategoryAlert, a.i18n.T("Docker is not running"), a.i18n.T("Moodle cannot be managed until Docker is started again."))
+		}
+		if err := a.timeline.Add("health:docker", message, nil); err != nil {
+			utils.LogError("Failed to record Docker health in timeline", err)
+		}
+	}
+	if change.Has(docker.HealthCheckInternet) {
+		message := "Internet connection restored"
+		if !current.Internet {
+			message = "Internet connection lost"
+			a.notify(notify.CategoryAlert, a.i18n.T("Internet connection lost"), a.i18n.T("Downloading or updating the Moodle image will fail until the connection is back."))
+		}
+		if err := a.timeline.Add("health:internet", message, nil); err != nil {
+			utils.LogError("Failed to record internet health in timeline", err)
+		}
+	}
+
+	if a.ctx != nil {
+		a.emit("health:changed", change)
+	}
+}
+
+// applyAlertSettings starts or stops the stats collector to match settings
+func (a *App) applyAlertSettings(alerts storage.AlertSettings) {
+	if !alerts.Enabled {
+		a.statsCollector.Stop()
+		return
+	}
+	a.statsCollector.SetThresholds(docker.AlertThresholds{
+		MemoryPercent: alerts.MemoryPercent,
+		DiskPercent:   alerts.DiskPercent,
+	})
+	a.statsCollector.Start(time.Duration(alerts.IntervalSeconds) * time.Second)
+}
+
+// onResourceAlert records a threshold alert in the timeline and notifies the frontend
+func (a *App) onResourceAlert(alert docker.ResourceAlert) {
+	details := map[string]string{
+		"percent":   fmt.Sprintf("%.1f", alert.Percent),
+		"threshold": fmt.Sprintf("%.0f", alert.Threshold),
+	}
+	if err := a.timeline.Add("alert:"+alert.Kind, alert.Message, details); err != nil {
+		utils.LogError("Failed to record resource alert in timeline", err)
+	}
+
+	if a.ctx != nil {
+		a.emit("moodle:resource:alert", alert)
+	}
+	a.notify(notify.CategoryAlert, a.i18n.T("Moodle resource alert"), alert.Message)
+}
+
+// notify delivers a notification to the sinks configured for its category in the background
+func (a *App) notify(category, title, message string) {
+	notification := notify.Notification{Category: category, Title: title, Message: message, Time: time.Now()}
+	a.goReported("notify", func() {
+		if err := a.notifier.Notify(notification); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to deliver notification: %v", err))
+		}
+	})
+}
+
+// applyNotificationSettings configures the webhook and email sinks and the per-category routes
+func (a *App) applyNotificationSettings(notifications storage.NotificationSettings) {
+	smtp := notifications.SMTP
+	password, err := a.smtpPassword.Get()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to load the SMTP password: %v", err))
+	}
+	a.notifier.Register(notify.NewWebhookNotifier(notifications.WebhookURL))
+	a.notifier.Register(notify.NewEmailNotifier(notify.SMTPConfig{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: password,
+		From:     smtp.From,
+		To:       smtp.To,
+	}))
+	a.notifier.SetRoutes(notifications.Routes)
+}
+
+// migrateSecrets moves the SMTP password, wake passcode and API tokens that earlier versions
+// saved in settings.json into the secret store
+func (a *App) migrateSecrets(settings *storage.Settings) {
+	a.migrateSecret(settings, "SMTP password", a.smtpPassword, settings.Notifications.SMTP.Password,
+		func(s *storage.Settings) { s.Notifications.SMTP.Password = "" })
+	a.migrateSecret(settings, "wake passcode", a.wakePasscode, settings.Sharing.WakePasscode,
+		func(s *storage.Settings) { s.Sharing.WakePasscode = "" })
+	a.migrateSecret(settings, "fleet token", a.fleetToken, settings.Fleet.Token,
+		func(s *storage.Settings) { s.Fleet.Token, s.Fleet.TokenConfigured = "", true })
+	a.migrateSecret(settings, "kiosk API token", a.kioskToken, settings.Kiosk.Token,
+		func(s *storage.Settings) { s.Kiosk.Token, s.Kiosk.TokenConfigured = "", true })
+	a.migrateSecret(settings, "control API token", a.controlToken, settings.ControlAPI.Token,
+		func(s *storage.Settings) { s.ControlAPI.Token, s.ControlAPI.TokenConfigured = "", true })
+}
+
+// migrateSecret moves one secret into secrets, then removes it from settings with clear
+func (a *App) migrateSecret(settings *storage.Settings, name string, secrets *storage.SecretManager, secret string, clear func(*storage.Settings)) {
+	if secret == "" {
+		return
+	}
+	utils.LogInfo(fmt.Sprintf("Moving the %s from settings.json to secure storage", name))
+	if err := secrets.Set(secret); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to move the %s to secure storage: %v", name, err))
+		return
+	}
+	if _, err := a.settingsManager.Update(clear); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to remove the %s from settings.json: %v", name, err))
+		return
+	}
+	clear(settings)
+}
+
+// GetNotificationSettings returns the notification routes and sink configuration, with a
+// stored SMTP password replaced by storage.MaskedPassword
+func (a *App) GetNotificationSettings() (_ *storage.NotificationSettings, err error) {
+	defer a.recoverBinding("GetNotificationSettings", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	notifications := settings.Notifications
+	notifications.SMTP.Password = ""
+	if password, err := a.smtpPassword.Get(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to load the SMTP password: %v", err))
+	} else if password != "" {
+		notifications.SMTP.Password = storage.MaskedPassword
+	}
+	return &notifications, nil
+}
+
+// SetNotificationRoute selects the sinks (desktop, webhook, email or none) for a category
+func (a *App) SetNotificationRoute(category string, sinks []string) (err error) {
+	defer a.recoverBinding("SetNotificationRoute", &err)
+	utils.LogInfo(fmt.Sprintf("SetNotificationRoute called (category: %s, sinks: %v)", category, sinks))
+
+	settings, err := a.updateSettings("Change "+category+" notifications", func(s *storage.Settings) {
+		if s.Notifications.Routes == nil {
+			s.Notifications.Routes = make(map[string][]string)
+		}
+		s.Notifications.Routes[category] = sinks
+	})
+	if err != nil {
+		utils.LogError("Failed to save notification route", err)
+		return fmt.Errorf("failed to save notification route: %w", err)
+	}
+
+	a.applyNotificationSettings(settings.Notifications)
+	return nil
+}
+
+// SetWebhookNotifications sets the URL the webhook sink posts notifications to
+func (a *App) SetWebhookNotifications(webhookURL string) (err error) {
+	defer a.recoverBinding("SetWebhookNotifications", &err)
+	utils.LogInfo("SetWebhookNotifications called")
+
+	settings, err := a.updateSettings("Change notification webhook", func(s *storage.Settings) {
+		s.Notifications.WebhookURL = webhookURL
+	})
+	if err != nil {
+		utils.LogError("Failed to save notification webhook", err)
+		return fmt.Errorf("failed to save notification webhook: %w", err)
+	}
+
+	a.applyNotificationSettings(settings.Notifications)
+	return nil
+}
+
+// SetEmailNotifications sets the SMTP server and recipients of the email sink. The password
+// goes to the secret store rather than settings.json; storage.MaskedPassword, as returned by
+// GetNotificationSettings, keeps the stored one and an empty password removes it.
+func (a *App) SetEmailNotifications(smtp storage.SMTPSettings) (err error) {
+	defer a.recoverBinding("SetEmailNotifications", &err)
+	utils.LogInfo(fmt.Sprintf("SetEmailNotifications called (host: %s, recipients: %d)", smtp.Host, len(smtp.To)))
+
+	password := smtp.Password
+	smtp.Password = ""
+	settings, err := a.updateSettings("Change notification email", func(s *storage.Settings) {
+		s.Notifications.SMTP = smtp
+	})
+	if err != nil {
+		utils.LogError("Failed to save notification email settings", err)
+		return fmt.Errorf("failed to save notification email settings: %w", err)
+	}
+	if password != storage.MaskedPassword {
+		if err := a.smtpPassword.Set(password); err != nil {
+			utils.LogError("Failed to save the SMTP password", err)
+			return fmt.Errorf("failed to save the SMTP password: %w", err)
+		}
+	}
+
+	a.applyNotificationSettings(settings.Notifications)
+	return nil
+}
+
+// SendTestNotification sends a sample notification through the sinks routed for category
+func (a *App) SendTestNotification(category string) (err error) {
+	defer a.recoverBinding("SendTestNotification", &err)
+	utils.LogInfo(fmt.Sprintf("SendTestNotification called (category: %s)", category))
+
+	err = a.notifier.Notify(notify.Notification{
+		Category: category,
+		Title:    a.i18n.T("Test notification"),
+		Message:  a.i18n.Tf("Moodle Prototype Manager will deliver %s notifications here.", category),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send test notification: %w", err)
+	}
+	return nil
+}
+
+// SetTelemetry opts in to or out of anonymous usage and crash reports posted to endpoint. The
+// reports hold the OS and Docker version, pull and install durations, failure categories and
+// panics, never names, paths, URLs or credentials.
+func (a *App) SetTelemetry(enabled bool, endpoint string) (err error) {
+	defer a.recoverBinding("SetTelemetry", &err)
+	utils.LogInfo(fmt.Sprintf("SetTelemetry called (enabled: %v)", enabled))
+
+	installID, err := telemetry.NewInstallID()
+	if err != nil {
+		return err
+	}
+	wasEnabled := a.telemetry.Enabled()
+	settings, err := a.updateSettings(fmt.Sprintf("Set telemetry to %v", enabled), func(s *storage.Settings) {
+		s.Telemetry.Enabled = enabled
+		s.Telemetry.Endpoint = endpoint
+		if s.Telemetry.InstallID == "" {
+			s.Telemetry.InstallID = installID
+		}
+	})
+	if err != nil {
+		utils.LogError("Failed to save telemetry settings", err)
+		return fmt.Errorf("failed to save telemetry settings: %w", err)
+	}
+
+	a.applyTelemetrySettings(settings.Telemetry)
+	if enabled && !wasEnabled {
+		a.goReported("reportSession", a.reportSession)
+	}
+	return nil
+}
+
+// GetTelemetrySettings returns whether telemetry is enabled and where reports go
+func (a *App) GetTelemetrySettings() (_ *storage.TelemetrySettings, err error) {
+	defer a.recoverBinding("GetTelemetrySettings", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return &settings.Telemetry, nil
+}
+
+// applyTelemetrySettings turns reporting on or off to match settings
+func (a *App) applyTelemetrySettings(settings storage.TelemetrySettings) {
+	a.telemetry.Configure(settings.Enabled, settings.Endpoint, settings.InstallID)
+}
+
+// reportSession sends the session report with the Docker version, if telemetry is enabled
+func (a *App) reportSession() {
+	if !a.telemetry.Enabled() {
+		return
+	}
+	if version, err := docker.GetDockerVersion(); version != nil {
+		a.telemetry.SetDocker(version.Server, version.Platform)
+	} else {
+		utils.LogDebug(fmt.Sprintf("Docker version unknown for telemetry: %v", err))
+	}
+	a.telemetry.Report(telemetry.Event{Kind: telemetry.KindSession})
+}
+
+// LanguageInfo describes the language of notifications, progress labels and error suggestions
+type LanguageInfo struct {
+	Language string `json:"language"`
+	// Automatic is set while the language follows the system locale
+	Automatic bool     `json:"automatic"`
+	Supported []string `json:"supported"`
+}
+
+// SetLanguage selects the language of notifications, progress labels and error suggestions,
+// e.g. "es" or "de_DE". An empty language follows the system locale.
+func (a *App) SetLanguage(language string) (_ *LanguageInfo, err error) {
+	defer a.recoverBinding("SetLanguage", &err)
+	utils.LogInfo(fmt.Sprintf("SetLanguage called (language: %q)", language))
+
+	language = i18n.Normalize(language)
+	description := "Follow the system language"
+	if language != "" {
+		description = fmt.Sprintf("Change language to %s", language)
+	}
+	settings, err := a.updateSettings(description, func(s *storage.Settings) {
+		s.Language = language
+	})
+	if err != nil {
+		utils.LogError("Failed to save language", err)
+		return nil, fmt.Errorf("failed to save language: %w", err)
+	}
+
+	a.applyLanguageSettings(settings.Language)
+	info := a.languageInfo(settings.Language)
+	a.emit("app:language:changed", info)
+	return info, nil
+}
+
+// GetLanguage returns the language in use and the languages available
+func (a *App) GetLanguage() (_ *LanguageInfo, err error) {
+	defer a.recoverBinding("GetLanguage", &err)
+	settings, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return a.languageInfo(settings.Language), nil
+}
+
+// languageInfo describes the language in use for the saved language setting
+func (a *App) languageInfo(language string) *LanguageInfo {
+	return &LanguageInfo{Language: a.i18n.Language(), Automatic: language == "", Supported: i18n.Supported()}
+}
+
+// applyLanguageSettings selects the saved language, or the system locale's if none is saved
+func (a *App) applyLanguageSettings(language string) {
+	if language == "" {
+		language = i18n.Detect()
+	}
+	if err := a.i18n.SetLanguage(language); err != nil {
+		utils.LogWarning(fmt.Sprintf("Keeping language %s: %v", a.i18n.Language(), err))
+	}
 }
 
-// maskPassword masks password for logging
-func maskPassword(password string) string {
-	if len(password) > 4 {
-		return password[:2] + "****" + password[len(password)-2:]
+// serializeError serializes err for the frontend, with its suggestions in the user's language
+func (a *App) serializeError(err error) *errors.SerializedError {
+	serialized := errors.Serialize(err)
+	if serialized != nil {
+		serialized.Suggestions = a.i18n.Strings(serialized.Suggestions)
 	}
-	return "****"
+	return serialized
 }
@@ -214,11 +214,28 @@ build_app() {
         esac
     fi
     
+    # Stamp the version into the binary; it is recorded in the state files the app writes
+    VERSION=${VERSION:-$(grep -o '"productVersion": *"[^"]*"' wails.json | sed 's/.*"\([^"]*\)"$/\1/')}
+    GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+    BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    LDFLAGS="-X moodle-prototype-manager/buildinfo.Version=${VERSION} -X moodle-prototype-manager/buildinfo.Commit=${GIT_COMMIT} -X moodle-prototype-manager/buildinfo.BuildTime=${BUILD_TIME}"
+    print_info "Version ${VERSION} (${GIT_COMMIT})"
+
+    # Stamp the asset channel signing key; without it the app never updates its provisioning
+    # assets. Set ASSET_CHANNEL_PUBLIC_KEY or keep the base64 key in asset-channel.pub.
+    ASSET_CHANNEL_PUBLIC_KEY=${ASSET_CHANNEL_PUBLIC_KEY:-$(cat asset-channel.pub 2>/dev/null | tr -d '[:space:]')}
+    if [ -n "$ASSET_CHANNEL_PUBLIC_KEY" ]; then
+        LDFLAGS="${LDFLAGS} -X moodle-prototype-manager/bundle.PublicKey=${ASSET_CHANNEL_PUBLIC_KEY}"
+        print_status "Asset update channel key embedded"
+    else
+        print_warning "No asset channel key (ASSET_CHANNEL_PUBLIC_KEY or asset-channel.pub); asset updates are disabled in this build"
+    fi
+
     # Run Wails build
     if [ -z "$BUILD_ARGS" ]; then
-        wails build
+        wails build -ldflags "$LDFLAGS"
     else
-        wails build $BUILD_ARGS
+        wails build $BUILD_ARGS -ldflags "$LDFLAGS"
     fi
     
     print_status "Application built successfully"
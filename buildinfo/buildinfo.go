@@ -0,0 +1,43 @@
+// Package buildinfo holds the version details stamped into the binary at build time
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set by build.sh with -ldflags "-X moodle-prototype-manager/buildinfo.Version=..."; the
+// defaults identify a plain `go build` or `wails dev` binary
+var (
+	// Version is the app version; it defaults to productVersion in wails.json
+	Version = "1.0.0"
+	// Commit is the git commit the binary was built from
+	Commit = "unknown"
+	// BuildTime is when the binary was built, in RFC 3339
+	BuildTime = "unknown"
+)
+
+// wailsModule is the Wails module whose version is reported
+const wailsModule = "github.com/wailsapp/wails/v2"
+
+// GoVersion returns the Go toolchain the binary was built with
+func GoVersion() string {
+	return runtime.Version()
+}
+
+// WailsVersion returns the version of Wails linked into the binary, or "unknown"
+func WailsVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == wailsModule {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
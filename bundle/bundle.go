@@ -0,0 +1,301 @@
+// Package bundle keeps provisioning assets (compose templates, parser patterns, seed data)
+// updatable from a signed release channel, independent of app releases. Every asset has a
+// built-in default; a verified download overrides it.
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// ManifestFile lists the channel's assets with their checksums
+	ManifestFile = "manifest.json"
+	// SignatureFile holds the base64 ed25519 signature of ManifestFile
+	SignatureFile = "manifest.json.sig"
+	// maxAssetBytes guards against a compromised channel filling the disk
+	maxAssetBytes = 10 * 1024 * 1024
+)
+
+// Asset names used by the app
+const (
+	ComposeTemplate = "export/docker-compose.yml.tmpl"
+	EnvTemplate     = "export/moodle.env.example.tmpl"
+	// InstanceComposeTemplate reproduces the running instance rather than a production deployment
+	InstanceComposeTemplate = "export/instance-compose.yml.tmpl"
+	PasswordPattern         = "parser/password.regex"
+	URLPattern              = "parser/url.regex"
+	// SeedManifest maps demo data sizes to the test site generator's sizes and course counts
+	SeedManifest = "seed/demodata.json"
+)
+
+// ChannelURL is where manifest.json and the assets are published
+var ChannelURL = "https://github.com/khairu-aqsara/moodle-manager-prototype/releases/download/assets-latest"
+
+// PublicKey is the base64 ed25519 key the channel is signed with, set at build time
+// (-ldflags "-X moodle-prototype-manager/bundle.PublicKey=..."); empty disables updates
+var PublicKey = ""
+
+// ErrChannelNotConfigured is returned when the build has no channel signing key
+var ErrChannelNotConfigured = fmt.Errorf("asset update channel is not configured in this build")
+
+// ManifestEntry describes one asset in the manifest
+type ManifestEntry struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest is the signed list of assets published on the channel
+type Manifest struct {
+	Version   int             `json:"version"`
+	Published time.Time       `json:"published"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// UpdateInfo compares the installed assets with the channel
+type UpdateInfo struct {
+	InstalledVersion int       `json:"installedVersion"`
+	AvailableVersion int       `json:"availableVersion"`
+	UpdateAvailable  bool      `json:"updateAvailable"`
+	Published        time.Time `json:"published"`
+	Files            []string  `json:"files"`
+}
+
+var (
+	mu  sync.RWMutex
+	dir string
+)
+
+// SetDirectory sets where verified assets are installed
+func SetDirectory(directory string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dir = directory
+}
+
+// Text returns the installed override for name, or fallback when there is none
+func Text(name, fallback string) string {
+	mu.RLock()
+	directory := dir
+	mu.RUnlock()
+
+	if directory == "" {
+		return fallback
+	}
+	data, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+	if err != nil {
+		return fallback
+	}
+	return string(data)
+}
+
+// InstalledManifest returns the manifest of the installed assets, if any
+func InstalledManifest() (*Manifest, error) {
+	mu.RLock()
+	directory := dir
+	mu.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(directory, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "failed to parse installed manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// Updater fetches and installs assets from the channel
+type Updater struct {
+	client    *http.Client
+	baseURL   string
+	publicKey ed25519.PublicKey
+}
+
+// NewUpdater creates an updater for the configured channel
+func NewUpdater(client *http.Client) (*Updater, error) {
+	return newUpdater(client, ChannelURL, PublicKey)
+}
+
+// newUpdater creates an updater for an explicit channel and key
+func newUpdater(client *http.Client, baseURL, publicKey string) (*Updater, error) {
+	if publicKey == "" {
+		return nil, ErrChannelNotConfigured
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.NewValidationError("publicKey", "not a base64 ed25519 public key", publicKey)
+	}
+	return &Updater{client: client, baseURL: strings.TrimRight(baseURL, "/"), publicKey: key}, nil
+}
+
+// Check fetches and verifies the channel manifest and compares it with the installed one
+func (u *Updater) Check() (*UpdateInfo, *Manifest, error) {
+	manifestData, err := u.fetch(ManifestFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := u.fetch(SignatureFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, err := verifyManifest(manifestData, signature, u.publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &UpdateInfo{
+		AvailableVersion: manifest.Version,
+		Published:        manifest.Published,
+	}
+	for _, file := range manifest.Files {
+		info.Files = append(info.Files, file.Name)
+	}
+	if installed, err := InstalledManifest(); err == nil {
+		info.InstalledVersion = installed.Version
+	}
+	info.UpdateAvailable = manifest.Version > info.InstalledVersion
+	return info, manifest, nil
+}
+
+// Update installs the channel's assets if they are newer than the installed ones.
+// Every file is verified before any installed file is replaced.
+func (u *Updater) Update() (*UpdateInfo, error) {
+	info, manifest, err := u.Check()
+	if err != nil {
+		return nil, err
+	}
+	if !info.UpdateAvailable {
+		return info, nil
+	}
+
+	mu.RLock()
+	directory := dir
+	mu.RUnlock()
+	if directory == "" {
+		return nil, errors.WrapWithContext(errors.ErrInvalidState, "asset directory is not set")
+	}
+
+	staging := directory + ".staging"
+	os.RemoveAll(staging)
+	defer os.RemoveAll(staging)
+
+	for _, file := range manifest.Files {
+		if err := u.download(file, staging); err != nil {
+			return nil, err
+		}
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to encode manifest")
+	}
+	if err := os.WriteFile(filepath.Join(staging, ManifestFile), manifestData, 0644); err != nil {
+		return nil, errors.NewFileError("write", staging, err)
+	}
+
+	// Swap the verified set in; keep the old one until the swap succeeds
+	previous := directory + ".previous"
+	os.RemoveAll(previous)
+	if err := os.Rename(directory, previous); err != nil && !os.IsNotExist(err) {
+		return nil, errors.NewFileError("rename", directory, err)
+	}
+	if err := os.Rename(staging, directory); err != nil {
+		os.Rename(previous, directory)
+		return nil, errors.NewFileError("rename", staging, err)
+	}
+	os.RemoveAll(previous)
+
+	utils.LogInfo(fmt.Sprintf("Installed provisioning assets version %d (%d files)", manifest.Version, len(manifest.Files)))
+	info.InstalledVersion = manifest.Version
+	info.UpdateAvailable = false
+	return info, nil
+}
+
+// download fetches one asset into staging and checks its size and checksum
+func (u *Updater) download(file ManifestEntry, staging string) error {
+	if !validAssetName(file.Name) {
+		return errors.NewValidationError("manifest", "asset name is not a safe relative path", file.Name)
+	}
+
+	data, err := u.fetch(file.Name)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if int64(len(data)) != file.Size || !strings.EqualFold(hex.EncodeToString(sum[:]), file.SHA256) {
+		return errors.WrapWithContext(errors.ErrInvalidFormat, "checksum mismatch for %s", file.Name)
+	}
+
+	target := filepath.Join(staging, filepath.FromSlash(file.Name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(target), err)
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return errors.NewFileError("write", target, err)
+	}
+	return nil
+}
+
+// fetch downloads a channel file
+func (u *Updater) fetch(name string) ([]byte, error) {
+	fileURL := u.baseURL + "/" + name
+	resp, err := u.client.Get(fileURL)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("asset_fetch", fileURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewNetworkErrorWithURL("asset_fetch", fileURL, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes+1))
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("asset_fetch", fileURL, err)
+	}
+	if len(data) > maxAssetBytes {
+		return nil, errors.NewValidationError("asset", "exceeds the maximum asset size", name)
+	}
+	return data, nil
+}
+
+// verifyManifest checks the manifest signature before parsing it
+func verifyManifest(data, signature []byte, publicKey ed25519.PublicKey) (*Manifest, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(publicKey, data, sig) {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "asset manifest signature is invalid")
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "failed to parse asset manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// validAssetName rejects absolute paths, parent references and the manifest itself
+func validAssetName(name string) bool {
+	if name == "" || name == ManifestFile || strings.Contains(name, `\`) || path.IsAbs(name) {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean == name && clean != ".." && !strings.HasPrefix(clean, "../")
+}
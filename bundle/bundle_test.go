@@ -0,0 +1,119 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newChannel serves a signed manifest for files; tamper alters content after signing
+func newChannel(t *testing.T, files map[string]string, tamper bool) (*httptest.Server, string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	manifest := Manifest{Version: 2}
+	for name, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		manifest.Files = append(manifest.Files, ManifestEntry{Name: name, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(content))})
+	}
+	manifestData, _ := json.Marshal(manifest)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, manifestData))
+
+	served := map[string]string{ManifestFile: string(manifestData), SignatureFile: signature}
+	for name, content := range files {
+		if tamper {
+			content += "tampered"
+		}
+		served[name] = content
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := served[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, base64.StdEncoding.EncodeToString(publicKey)
+}
+
+func TestUpdateInstallsVerifiedAssets(t *testing.T) {
+	SetDirectory(filepath.Join(t.TempDir(), "assets"))
+	defer SetDirectory("")
+
+	server, key := newChannel(t, map[string]string{URLPattern: `Site URL:\s*(.+)`}, false)
+	updater, err := newUpdater(server.Client(), server.URL, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := updater.Update()
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if info.InstalledVersion != 2 || info.UpdateAvailable {
+		t.Errorf("Expected version 2 to be installed, got %+v", info)
+	}
+	if text := Text(URLPattern, "default"); text != `Site URL:\s*(.+)` {
+		t.Errorf("Expected installed override, got %q", text)
+	}
+	if text := Text(PasswordPattern, "default"); text != "default" {
+		t.Errorf("Expected fallback for missing asset, got %q", text)
+	}
+}
+
+func TestUpdateRejectsTamperedAssets(t *testing.T) {
+	SetDirectory(filepath.Join(t.TempDir(), "assets"))
+	defer SetDirectory("")
+
+	server, key := newChannel(t, map[string]string{URLPattern: "pattern"}, true)
+	updater, err := newUpdater(server.Client(), server.URL, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := updater.Update(); err == nil {
+		t.Fatal("Expected checksum mismatch to fail the update")
+	}
+	if text := Text(URLPattern, "default"); text != "default" {
+		t.Errorf("Expected nothing to be installed, got %q", text)
+	}
+}
+
+func TestUpdateRejectsWrongKey(t *testing.T) {
+	server, _ := newChannel(t, map[string]string{URLPattern: "pattern"}, false)
+	_, otherKey := newChannel(t, nil, false)
+
+	updater, err := newUpdater(server.Client(), server.URL, otherKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, err := updater.Check(); err == nil {
+		t.Error("Expected signature verification to fail")
+	}
+}
+
+func TestValidAssetName(t *testing.T) {
+	for _, name := range []string{"export/compose.tmpl", "parser/url.regex"} {
+		if !validAssetName(name) {
+			t.Errorf("Expected %q to be valid", name)
+		}
+	}
+	for _, name := range []string{"", "../evil", "/etc/passwd", "a/../../b", `a\b`, ManifestFile} {
+		if validAssetName(name) {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// backupCommand exports the instance to an archive the desktop app can import
+func (c *cli) backupCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backup [path]",
+		Short: "Export the instance, its volumes and credentials to an archive",
+		Long: "Export the instance, its volumes and credentials to an archive that the desktop app's Import can restore.\n" +
+			"The path defaults to <instance>-<date>.moodle.tgz in the current directory.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lock, err := c.lock()
+			if err != nil {
+				return err
+			}
+			defer c.unlock(lock)
+
+			path := fmt.Sprintf("%s-%s.moodle.tgz", c.service.Docker.GetInstanceName(), time.Now().Format("20060102"))
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			nextReport := 0.0
+			archive, err := c.service.Backup(path, func(percentage float64, status string) {
+				if percentage >= nextReport {
+					c.progress("%.0f%% %s", percentage, status)
+					nextReport = percentage + 10
+				}
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.stdout, archive.Path)
+			return nil
+		},
+	}
+}
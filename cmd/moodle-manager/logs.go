@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"moodle-prototype-manager/docker"
+
+	"github.com/spf13/cobra"
+)
+
+// logsCommand prints the Moodle container's logs, optionally following them
+func (c *cli) logsCommand() *cobra.Command {
+	var tail int
+	var follow bool
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the Moodle container's logs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			containerID, err := c.service.ContainerID()
+			if err != nil {
+				return err
+			}
+
+			if !follow {
+				chunk, err := c.service.Docker.FetchContainerLogs(containerID, docker.LogFetchOptions{Tail: tail})
+				if err != nil {
+					return fmt.Errorf("failed to get container logs: %w", err)
+				}
+				fmt.Fprint(c.stdout, chunk.Logs)
+				return nil
+			}
+
+			follower, err := c.service.Docker.FollowContainerLogs(containerID, tail, func(line string) {
+				fmt.Fprintln(c.stdout, line)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to follow container logs: %w", err)
+			}
+			select {
+			case <-cmd.Context().Done():
+				follower.Stop()
+				return nil
+			case <-follower.Done():
+				return follower.Err()
+			}
+		},
+	}
+	cmd.Flags().IntVar(&tail, "tail", 100, "number of lines to show from the end of the logs")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new log lines until interrupted")
+	return cmd
+}
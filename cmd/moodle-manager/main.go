@@ -0,0 +1,96 @@
+// Command moodle-manager drives the same Moodle container as the desktop app without its GUI,
+// for CI pipelines and scripts. Results go to stdout and progress to stderr.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// cli holds what every subcommand needs
+type cli struct {
+	verbose bool
+	// stdout is the real standard output; os.Stdout is redirected so library diagnostics
+	// cannot mix with command output
+	stdout  io.Writer
+	stderr  io.Writer
+	service *core.Service
+}
+
+func main() {
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr}
+
+	root := &cobra.Command{
+		Use:           "moodle-manager",
+		Short:         "Run the Moodle prototype container without the desktop app",
+		Version:       buildinfo.Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.setup()
+		},
+	}
+	root.PersistentFlags().BoolVarP(&c.verbose, "verbose", "v", false, "print diagnostic logging to stderr")
+	root.SetOut(c.stdout)
+	root.SetErr(c.stderr)
+	root.AddCommand(c.runCommand(), c.stopCommand(), c.statusCommand(), c.logsCommand(), c.backupCommand())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := root.ExecuteContext(ctx); err != nil {
+		fmt.Fprintf(c.stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// setup silences library output unless --verbose and configures the service from the files
+// the desktop app uses
+func (c *cli) setup() error {
+	if c.verbose {
+		os.Stdout = os.Stderr
+	} else {
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		os.Stdout = devNull
+	}
+	// No log file: a CI job's working directory should not gain a logs directory
+	c.service = core.NewService()
+	c.service.LoadAssets()
+	c.service.LoadImageConfig()
+	c.service.Configure(c.service.LoadSettings())
+	return nil
+}
+
+// lock takes the data directory lock for a command that changes the container, refusing while
+// the desktop app (or another command) holds it
+func (c *cli) lock() (*storage.InstanceLock, error) {
+	lock, err := storage.AcquireInstanceLock(c.service.Files.DataFilePath(storage.LockFile))
+	if err != nil {
+		return nil, fmt.Errorf("cannot change the container: %w", err)
+	}
+	return lock, nil
+}
+
+// unlock releases a lock taken by lock
+func (c *cli) unlock(lock *storage.InstanceLock) {
+	if err := lock.Release(); err != nil {
+		utils.LogError("Failed to release instance lock", err)
+	}
+}
+
+// progress prints a progress line to stderr
+func (c *cli) progress(format string, args ...any) {
+	fmt.Fprintf(c.stderr, "==> "+format+"\n", args...)
+}
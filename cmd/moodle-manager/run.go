@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/docker"
+
+	"github.com/spf13/cobra"
+)
+
+// runCommand starts Moodle and by default waits until it can be used
+func (c *cli) runCommand() *cobra.Command {
+	var wait, showPassword bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Start Moodle, creating and installing the container on first use",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lock, err := c.lock()
+			if err != nil {
+				return err
+			}
+			defer c.unlock(lock)
+
+			tracker := docker.NewRunTracker()
+			nextPull := 0.0
+			result, err := c.service.Start(core.StartHooks{
+				Step: func(step, message string) {
+					c.progress("%s", tracker.Step(step, 0, message).Label)
+				},
+				Pull: func() error {
+					return c.service.Docker.PullImageWithProgress(func(percentage float64, status string) {
+						if percentage >= nextPull {
+							c.progress("Downloading %.0f%% %s", percentage, status)
+							nextPull = percentage + 10
+						}
+					})
+				},
+			})
+			if err != nil {
+				return err
+			}
+			c.service.StartSidecars(result.ContainerID)
+			if !wait {
+				fmt.Fprintln(c.stdout, result.ContainerID)
+				return nil
+			}
+
+			ready, err := c.waitForMoodle(cmd, result, tracker, timeout)
+			if err != nil {
+				return err
+			}
+
+			creds := ready.Credentials
+			fmt.Fprintf(c.stdout, "URL:      %s\nUsername: %s\n", creds.URL, creds.Username)
+			if showPassword {
+				fmt.Fprintf(c.stdout, "Password: %s\n", creds.Password)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", true, "wait until Moodle is ready")
+	cmd.Flags().DurationVar(&timeout, "timeout", core.DefaultReadyTimeout, "how long to wait for an installed Moodle to answer")
+	cmd.Flags().BoolVar(&showPassword, "show-password", false, "print the admin password")
+	return cmd
+}
+
+// waitForMoodle follows the first-run install or waits for an installed Moodle to answer,
+// then points Moodle at its published URL and applies the site details and demo data from
+// settings, through the same pipeline as the desktop app
+func (c *cli) waitForMoodle(cmd *cobra.Command, result *core.StartResult, tracker *docker.RunTracker, timeout time.Duration) (*core.ReadyResult, error) {
+	nextSeed := 0.0
+	ready, err := c.service.WaitForMoodle(cmd.Context(), result, core.ReadyHooks{
+		Step: func(step string, fraction float64, message string) {
+			update := tracker.Step(step, fraction, message)
+			if message != "" && message != update.Label {
+				c.progress("%s: %s (%.0f%%)", update.Label, message, update.Percentage)
+				return
+			}
+			c.progress("%s", update.Label)
+		},
+		SeedProgress: func(percentage float64, status string) {
+			if percentage >= nextSeed {
+				c.progress("Seeding demo data %.0f%% %s", percentage, status)
+				nextSeed = percentage + 10
+			}
+		},
+		Timeout: timeout,
+	})
+	if failure, ok := err.(*docker.InstallFailure); ok {
+		if failure.LogFile != "" {
+			c.progress("The install log was saved to %s", failure.LogFile)
+		}
+		return nil, fmt.Errorf("Moodle installation failed: %w", err)
+	}
+	return ready, err
+}
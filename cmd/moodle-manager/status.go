@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// statusCommand prints the container state, as text or JSON for scripts
+func (c *cli) statusCommand() *cobra.Command {
+	var asJSON, cached bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether Moodle is running and where",
+		Long: "Show whether Moodle is running and where.\n" +
+			"With --cached the status is read from status.json in the data directory instead of Docker; " +
+			"it is as current as the last change the desktop app or this command made.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cached {
+				return c.printCachedStatus(asJSON)
+			}
+
+			status, err := c.service.Status()
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return c.printJSON(status)
+			}
+
+			fmt.Fprintf(c.stdout, "Instance:  %s\nImage:     %s\nState:     %s\n", status.Instance, status.Image, status.State)
+			if status.ContainerID != "" {
+				fmt.Fprintf(c.stdout, "Container: %s\n", status.ContainerID)
+			}
+			if status.Health != "" {
+				fmt.Fprintf(c.stdout, "Health:    %s\n", status.Health)
+			}
+			if status.URL != "" {
+				fmt.Fprintf(c.stdout, "URL:       %s\n", status.URL)
+			}
+			if status.Username != "" {
+				fmt.Fprintf(c.stdout, "Username:  %s\n", status.Username)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the status as JSON")
+	cmd.Flags().BoolVar(&cached, "cached", false, "read status.json instead of asking Docker")
+	return cmd
+}
+
+// printCachedStatus prints status.json
+func (c *cli) printCachedStatus(asJSON bool) error {
+	status, err := c.service.StatusFile.Load()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return c.printJSON(status)
+	}
+
+	fmt.Fprintf(c.stdout, "Instance:  %s\nImage:     %s\nState:     %s\n", status.Instance, status.Image, status.State)
+	if status.ImageDigest != "" {
+		fmt.Fprintf(c.stdout, "Digest:    %s\n", status.ImageDigest)
+	}
+	if status.ContainerID != "" {
+		fmt.Fprintf(c.stdout, "Container: %s\n", status.ContainerID)
+	}
+	if status.Health != "" && status.LastHealthCheck != nil {
+		fmt.Fprintf(c.stdout, "Health:    %s (checked %s)\n", status.Health, status.LastHealthCheck.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(c.stdout, "URL:       %s\nUpdated:   %s\n", status.URL, status.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// printJSON prints value as indented JSON
+func (c *cli) printJSON(value any) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
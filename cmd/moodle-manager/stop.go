@@ -0,0 +1,34 @@
+package main
+
+import (
+	"moodle-prototype-manager/docker"
+
+	"github.com/spf13/cobra"
+)
+
+// stopCommand stops Moodle the way the desktop app's Stop button does
+func (c *cli) stopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop Moodle, killing it if it does not shut down within the stop timeout",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lock, err := c.lock()
+			if err != nil {
+				return err
+			}
+			defer c.unlock(lock)
+
+			stopped, err := c.service.Stop(func(progress docker.StopProgress) {
+				c.progress("%s", progress.Message)
+			})
+			if err != nil {
+				return err
+			}
+			if !stopped {
+				c.progress("Moodle is already stopped")
+			}
+			return nil
+		},
+	}
+}
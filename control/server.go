@@ -0,0 +1,305 @@
+// Package control serves the manager's operations over a local HTTP API so other tools, such as
+// test harnesses that need a fresh Moodle, can provision and tear down the prototype. The API
+// listens on the loopback interface only and every request must carry the bearer token.
+package control
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/fleet"
+	"moodle-prototype-manager/utils"
+)
+
+// API paths; status and stop share the fleet API paths
+const (
+	ProvisionPath   = "/api/v1/provision"
+	TeardownPath    = "/api/v1/teardown"
+	CredentialsPath = "/api/v1/credentials"
+
+	// DefaultWaitTimeout bounds a provision request that waits for Moodle; a first-run install
+	// on a slow machine can take this long
+	DefaultWaitTimeout = 45 * time.Minute
+	// maxRequestBytes caps request bodies, which are small JSON documents
+	maxRequestBytes = 64 * 1024
+)
+
+// pollInterval is how often a waiting provision request checks Moodle; tests shorten it
+var pollInterval = 2 * time.Second
+
+// Status is the document served on the status endpoint
+type Status struct {
+	core.Status
+	// Ready is set once Moodle is healthy and its admin login is known
+	Ready bool `json:"ready"`
+}
+
+// Credentials is the admin login of the provisioned Moodle
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	URL      string `json:"url"`
+}
+
+// ProvisionRequest is the body of a provision request; an empty body starts Moodle as configured
+type ProvisionRequest struct {
+	// Template creates the instance from a saved template; the instance must have no container
+	Template string `json:"template,omitempty"`
+	// Wait holds the response until Moodle is ready, returning its admin login
+	Wait bool `json:"wait"`
+	// TimeoutSeconds bounds the wait; 0 uses DefaultWaitTimeout
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// ProvisionResponse reports the provisioned instance
+type ProvisionResponse struct {
+	Status
+	// Credentials is set when the request waited for Moodle
+	Credentials *Credentials `json:"credentials,omitempty"`
+}
+
+// TeardownRequest is the body of a teardown request
+type TeardownRequest struct {
+	// RemoveVolumes also deletes the Moodle data, so the next provision installs from scratch
+	RemoveVolumes bool `json:"removeVolumes"`
+}
+
+// Backend is the manager controlled through the API
+type Backend interface {
+	Status() (*Status, error)
+	// Provision starts Moodle, creating it from template when set; it returns before Moodle is ready
+	Provision(template string) error
+	Credentials() (*Credentials, error)
+	Stop() error
+	// Teardown removes the container, and with removeVolumes its data
+	Teardown(removeVolumes bool) error
+}
+
+// Server serves the control API on the loopback interface only
+type Server struct {
+	backend Backend
+	token   string
+	server  *http.Server
+}
+
+// NewServer creates a control API server; requests must send token as a bearer token, and
+// every request is refused while it is empty
+func NewServer(backend Backend, token string) *Server {
+	s := &Server{backend: backend, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(fleet.StatusPath, s.handle(http.MethodGet, s.status))
+	mux.HandleFunc(ProvisionPath, s.handle(http.MethodPost, s.provision))
+	mux.HandleFunc(CredentialsPath, s.handle(http.MethodGet, s.credentials))
+	mux.HandleFunc(fleet.StopPath, s.handle(http.MethodPost, s.stop))
+	mux.HandleFunc(TeardownPath, s.handle(http.MethodPost, s.teardown))
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// NewToken returns a random token for the API
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate control API token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Start listens on 127.0.0.1:port
+func (s *Server) Start(port int) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.NewNetworkError("control_listen", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			utils.LogError("Control API stopped", err)
+		}
+	}()
+	utils.LogInfo(fmt.Sprintf("Control API listening on http://%s", addr))
+	return nil
+}
+
+// Stop shuts the server down
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		utils.LogWarning(fmt.Sprintf("Control API shutdown: %v", err))
+	}
+}
+
+// ServeHTTP handles a request; used by tests without a listener
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
+}
+
+// handle checks the method and token before calling fn
+func (s *Server) handle(method string, fn func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	status, err := s.backend.Status()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) provision(w http.ResponseWriter, r *http.Request) {
+	var request ProvisionRequest
+	if err := decodeBody(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+	if request.TimeoutSeconds < 0 {
+		writeError(w, errors.NewValidationError("timeoutSeconds", "timeout cannot be negative", request.TimeoutSeconds))
+		return
+	}
+
+	utils.LogInfo(fmt.Sprintf("Control API provision request (template: %q, wait: %v)", request.Template, request.Wait))
+	if err := s.backend.Provision(request.Template); err != nil {
+		utils.LogError("Control API provision request failed", err)
+		writeError(w, err)
+		return
+	}
+	if !request.Wait {
+		status, err := s.backend.Status()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, ProvisionResponse{Status: *status})
+		return
+	}
+
+	timeout := DefaultWaitTimeout
+	if request.TimeoutSeconds > 0 {
+		timeout = time.Duration(request.TimeoutSeconds) * time.Second
+	}
+	status, err := s.waitForReady(r.Context(), timeout)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	creds, err := s.backend.Credentials()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProvisionResponse{Status: *status, Credentials: creds})
+}
+
+// waitForReady polls the backend until Moodle is ready, stops running or timeout passes
+func (s *Server) waitForReady(ctx context.Context, timeout time.Duration) (*Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.backend.Status()
+		if err != nil {
+			return nil, err
+		}
+		if status.Ready {
+			return status, nil
+		}
+		// A failed install is removed or left stopped; waiting longer would not help
+		if status.State != core.StateRunning {
+			return nil, fmt.Errorf("Moodle stopped before it was ready (state: %s): %w", status.State, errors.ErrInvalidState)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithCode(fmt.Errorf("Moodle was not ready within %v", timeout), errors.CodeTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) credentials(w http.ResponseWriter, r *http.Request) {
+	creds, err := s.backend.Credentials()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, creds)
+}
+
+func (s *Server) stop(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend.Stop(); err != nil {
+		utils.LogError("Control API stop request failed", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) teardown(w http.ResponseWriter, r *http.Request) {
+	var request TeardownRequest
+	if err := decodeBody(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.LogInfo(fmt.Sprintf("Control API teardown request (removeVolumes: %v)", request.RemoveVolumes))
+	if err := s.backend.Teardown(request.RemoveVolumes); err != nil {
+		utils.LogError("Control API teardown request failed", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeBody reads a JSON request body into value; an empty body leaves value unchanged
+func decodeBody(r *http.Request, value any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil && err != io.EOF {
+		return errors.NewValidationErrorWithCause("body", "request body is not valid JSON", nil, err)
+	}
+	return nil
+}
+
+// writeError writes err in the shape the desktop frontend receives errors
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, errors.HTTPStatus(err), errors.Serialize(err))
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to write control API response: %v", err))
+	}
+}
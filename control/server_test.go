@@ -0,0 +1,138 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/fleet"
+)
+
+type fakeManager struct {
+	state      string
+	readyAfter int
+	polls      int
+	templates  []string
+	teardowns  []bool
+}
+
+func (f *fakeManager) Status() (*Status, error) {
+	f.polls++
+	status := &Status{Status: core.Status{State: f.state}}
+	status.Ready = f.state == core.StateRunning && f.polls > f.readyAfter
+	return status, nil
+}
+func (f *fakeManager) Provision(template string) error {
+	if f.state == core.StateRunning && template != "" {
+		return errors.ErrInvalidState
+	}
+	f.templates = append(f.templates, template)
+	f.state = core.StateRunning
+	return nil
+}
+func (f *fakeManager) Credentials() (*Credentials, error) {
+	return &Credentials{Username: "admin", Password: "secret", URL: "http://localhost:8080"}, nil
+}
+func (f *fakeManager) Stop() error {
+	f.state = core.StateStopped
+	return nil
+}
+func (f *fakeManager) Teardown(removeVolumes bool) error {
+	f.teardowns = append(f.teardowns, removeVolumes)
+	f.state = core.StateNone
+	return nil
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	for _, server := range []*Server{NewServer(&fakeManager{}, "harness"), NewServer(&fakeManager{}, "")} {
+		for _, header := range []string{"", "Bearer ", "Bearer wrong", "harness"} {
+			req := httptest.NewRequest(http.MethodGet, fleet.StatusPath, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("Expected 401 for Authorization %q, got %d", header, rec.Code)
+			}
+		}
+	}
+}
+
+// shortenPollInterval makes waiting provision requests poll quickly for the rest of the test
+func shortenPollInterval(t *testing.T) {
+	t.Helper()
+	previous := pollInterval
+	pollInterval = time.Millisecond
+	t.Cleanup(func() { pollInterval = previous })
+}
+
+func TestProvisionAndTeardown(t *testing.T) {
+	shortenPollInterval(t)
+	manager := &fakeManager{state: core.StateNone, readyAfter: 2}
+	server := NewServer(manager, "harness")
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer harness")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(http.MethodPost, ProvisionPath, `{"template": "workshop", "wait": true}`)
+	var provisioned ProvisionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&provisioned); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected provisioning to succeed, got %d (err: %v)", rec.Code, err)
+	}
+	if !provisioned.Ready || provisioned.Credentials == nil || provisioned.Credentials.Password != "secret" {
+		t.Errorf("Expected a ready instance with its login, got %+v", provisioned)
+	}
+	if len(manager.templates) != 1 || manager.templates[0] != "workshop" {
+		t.Errorf("Expected the template to be used, got %v", manager.templates)
+	}
+
+	rec = request(http.MethodPost, ProvisionPath, `{"template": "workshop"}`)
+	var serialized errors.SerializedError
+	if err := json.NewDecoder(rec.Body).Decode(&serialized); err != nil || rec.Code != http.StatusConflict || serialized.Code != errors.CodeInvalidState {
+		t.Errorf("Expected a conflict for a second template instance, got %d %+v", rec.Code, serialized)
+	}
+	if rec := request(http.MethodPost, ProvisionPath, `{"unknown": true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown fields to be rejected, got %d", rec.Code)
+	}
+
+	if rec := request(http.MethodPost, TeardownPath, `{"removeVolumes": true}`); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected teardown to succeed, got %d", rec.Code)
+	}
+	if len(manager.teardowns) != 1 || !manager.teardowns[0] {
+		t.Errorf("Expected the volumes to be removed, got %v", manager.teardowns)
+	}
+	if rec := request(http.MethodGet, TeardownPath, ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET teardown to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestProvisionWaitEnds(t *testing.T) {
+	shortenPollInterval(t)
+	manager := &fakeManager{state: core.StateNone, readyAfter: 1000}
+	server := NewServer(manager, "harness")
+
+	req := httptest.NewRequest(http.MethodPost, ProvisionPath, strings.NewReader(`{"wait": true, "timeoutSeconds": 1}`))
+	req.Header.Set("Authorization", "Bearer harness")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected a timeout while Moodle never becomes ready, got %d", rec.Code)
+	}
+
+	// A failed install leaves the container stopped or removes it
+	manager.state = core.StateStopped
+	if _, err := server.waitForReady(req.Context(), time.Second); errors.CodeOf(err) != errors.CodeInvalidState {
+		t.Errorf("Expected a stopped instance to end the wait, got %v", err)
+	}
+}
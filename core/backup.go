@@ -0,0 +1,77 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/storage"
+)
+
+// Backup writes the instance's image reference, portable settings, volume data and admin user
+// name to an archive at path that ImportInstance can restore. The admin password stays out of
+// the archive; the importer sets a new one.
+func (s *Service) Backup(path string, progressCallback func(float64, string)) (*docker.InstanceArchive, error) {
+	containerID, err := s.ContainerID()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export: %w", err)
+	}
+	if err := s.CheckExportQuota(); err != nil {
+		return nil, fmt.Errorf("cannot export: %w", err)
+	}
+
+	// A site still installing has no admin to log in with
+	creds, err := s.Credentials.Load()
+	if err != nil || creds.Password == "" {
+		return nil, fmt.Errorf("cannot export before Moodle has finished installing: %w", errors.ErrInvalidState)
+	}
+
+	settings, err := s.Settings.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	portable, err := json.Marshal(settings.Portable())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	manifest := docker.InstanceManifest{
+		AppVersion:  buildinfo.Version,
+		Credentials: docker.InstanceCredentials{Username: creds.Username, URL: creds.URL},
+		Settings:    portable,
+	}
+
+	op := s.Journal.Begin(storage.OpInstanceExport, map[string]string{"container": containerID, "path": path})
+	result, err := s.Docker.ExportInstance(containerID, path, manifest, progressCallback)
+	op.Finish(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export instance: %w", err)
+	}
+	return result, nil
+}
+
+// CheckExportQuota refuses an export or snapshot that would push the current workspace over
+// its quota. The job is staged next to the volumes before it is copied out, so their
+// uncompressed size bounds the extra space it needs.
+func (s *Service) CheckExportQuota() error {
+	settings, err := s.Settings.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	quotaMB := settings.QuotasMB[s.Docker.GetInstanceName()]
+	if quotaMB == 0 {
+		return nil
+	}
+
+	container, err := s.Docker.FindInstanceContainer()
+	if err != nil || container == nil {
+		return err
+	}
+	usage, err := s.Docker.MeasureWorkspaceUsage(*container)
+	if err != nil {
+		return fmt.Errorf("failed to measure workspace disk usage: %w", err)
+	}
+	return docker.CheckQuota(*usage, uint64(quotaMB)*1024*1024, usage.VolumeBytes)
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/storage"
+)
+
+func TestBackupRespectsQuotaOfStoppedWorkspace(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("b", 64)
+	// A stopped container's volumes count too
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, DiskBytes: 600 * 1024 * 1024})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Settings.Update(func(s *storage.Settings) {
+		s.QuotasMB = map[string]int{fake.GetInstanceName(): 1000}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := service.Backup(t.TempDir()+"/instance.tar.gz", nil)
+	if !stderrors.Is(err, errors.ErrQuotaExceeded) {
+		t.Fatalf("Expected the export to be refused over quota, got %v", err)
+	}
+	if !stderrors.Is(service.CheckExportQuota(), errors.ErrQuotaExceeded) {
+		t.Error("Expected snapshots to be refused over quota as well")
+	}
+}
@@ -0,0 +1,391 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+)
+
+// StartHooks lets a caller report and take part in Start; nil hooks are skipped
+type StartHooks struct {
+	// Step reports each docker.RunStep* step reached, with detail such as the image name
+	Step func(step, message string)
+	// Pull downloads the missing image; nil pulls without progress
+	Pull func() error
+	// BeforeRestart runs before an existing, stopped container is started again
+	BeforeRestart func(containerID string)
+}
+
+// StartResult describes the container Start started
+type StartResult struct {
+	ContainerID string
+	// Created is set for a new container, which installs Moodle on its first start
+	Created bool
+	// StartTime is taken before the container started, so only newer logs are considered
+	StartTime time.Time
+	// ResetAdminPassword gives the admin a new password once Moodle answers, for a site
+	// restored from an archive without its password
+	ResetAdminPassword bool
+}
+
+// Start starts the existing container or creates a new one. Waiting for Moodle is left to the
+// caller.
+func (s *Service) Start(hooks StartHooks) (*StartResult, error) {
+	step := func(step, message string) {
+		if hooks.Step != nil {
+			hooks.Step(step, message)
+		}
+	}
+
+	// Check if container already exists
+	if s.Files.ContainerIDExists() {
+		containerID, err := s.Files.LoadContainerID()
+		if err == nil {
+			utils.LogInfo(fmt.Sprintf("Found existing container ID: %s", containerID))
+
+			// Try to start existing container
+			running, err := s.Docker.IsContainerRunning(containerID)
+			if err == nil {
+				if running {
+					utils.LogWarning("Container is already running")
+					return nil, errors.ErrContainerRunning
+				}
+				if hooks.BeforeRestart != nil {
+					hooks.BeforeRestart(containerID)
+				}
+
+				// Start existing container
+				utils.LogInfo("Starting existing container")
+				step(docker.RunStepStartingContainer, "")
+
+				// Record the time before starting to only look for new logs
+				startTime := time.Now()
+
+				op := s.Journal.Begin(storage.OpContainerStart, map[string]string{"container": containerID})
+				err := s.Docker.StartContainer(containerID)
+				op.Finish(err)
+				if err != nil {
+					return nil, fmt.Errorf("failed to start existing container: %w", err)
+				}
+				s.WriteStatus()
+				return &StartResult{ContainerID: containerID, StartTime: startTime}, nil
+			}
+			utils.LogWarning(fmt.Sprintf("Error checking container status: %v", err))
+		}
+	}
+
+	// First-time setup: check if image exists
+	utils.LogInfo("Checking if Docker image exists")
+	utils.LogInfo(fmt.Sprintf("Current image name: %s", s.Docker.GetImageName()))
+
+	// Ensure we have an image name
+	if s.Docker.GetImageName() == "" {
+		utils.LogError("No Docker image name configured", nil)
+		return nil, fmt.Errorf("no Docker image name configured - please check image.docker file")
+	}
+
+	step(docker.RunStepCheckingImage, s.Docker.GetImageName())
+	imageExists, err := s.Docker.CheckImageExists()
+	if err != nil {
+		utils.LogError("Failed to check image", err)
+		return nil, fmt.Errorf("failed to check Docker image: %w", err)
+	}
+
+	// Pull image if it doesn't exist
+	if !imageExists {
+		utils.LogInfo("Docker image not found, pulling with progress tracking...")
+		step(docker.RunStepPullingImage, s.Docker.GetImageName())
+
+		pull := hooks.Pull
+		if pull == nil {
+			pull = s.Docker.PullImage
+		}
+		if err := pull(); err != nil {
+			utils.LogError("Failed to pull image with progress", err)
+			return nil, fmt.Errorf("failed to pull image: %w", err)
+		}
+		utils.LogInfo("Docker image pulled successfully")
+	} else {
+		utils.LogInfo("Docker image already exists")
+	}
+
+	// Clear old credentials for new container
+	utils.LogInfo("Clearing old credentials for new container")
+	if err := s.Credentials.Clear(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to clear old credentials: %v", err))
+	}
+
+	// Don't start a duplicate next to a container we lost track of
+	knownID, _ := s.ContainerID()
+	if existing, err := s.Docker.FindInstanceContainer(); err == nil && existing != nil && (knownID == "" || !strings.HasPrefix(knownID, existing.ID)) {
+		utils.LogWarning(fmt.Sprintf("Found untracked container %s (%s) for this instance", existing.Name, existing.ID))
+		return nil, fmt.Errorf("container %s already exists but is not tracked; adopt or remove it first: %w", existing.Name, errors.ErrInvalidState)
+	}
+
+	// Avoid colliding with other OS users' containers on shared machines
+	if err := s.ResolveHostPort(); err != nil {
+		utils.LogError("Failed to find a free host port", err)
+		return nil, fmt.Errorf("failed to find a free host port: %w", err)
+	}
+
+	// Run new container
+	utils.LogInfo("Running new container")
+	step(docker.RunStepCreatingContainer, "")
+
+	// Record the time before starting to only look for new logs
+	startTime := time.Now()
+
+	op := s.Journal.Begin(storage.OpContainerCreate, map[string]string{"image": s.Docker.GetImageName()})
+	containerID, err := s.Docker.RunContainer()
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to run container", err)
+		return nil, fmt.Errorf("failed to run container: %w", err)
+	}
+	utils.LogInfo(fmt.Sprintf("Container started with ID: %s", containerID))
+
+	// Save container ID
+	if err := s.Files.SaveContainerID(containerID); err != nil {
+		utils.LogError("Failed to save container ID", err)
+		return nil, fmt.Errorf("failed to save container ID: %w", err)
+	}
+
+	s.WriteStatus()
+	step(docker.RunStepStartingContainer, "")
+	return &StartResult{ContainerID: containerID, Created: true, StartTime: startTime}, nil
+}
+
+// Stop stops the container, escalating from SIGTERM to SIGKILL after the stop timeout and
+// forcing it as a last resort. It reports false when the container was already stopped.
+func (s *Service) Stop(onProgress func(docker.StopProgress)) (bool, error) {
+	containerID, err := s.ContainerID()
+	if err != nil {
+		utils.LogError("Failed to find the container to stop", err)
+		return false, err
+	}
+
+	utils.LogInfo(fmt.Sprintf("Attempting to stop container: %s", containerID))
+
+	// Validate container exists
+	if err := s.Docker.ValidateContainerID(containerID); err != nil {
+		utils.LogError("Container validation failed", err)
+		return false, fmt.Errorf("container validation failed: %w", err)
+	}
+
+	// Check if container is actually running
+	running, err := s.Docker.IsContainerRunning(containerID)
+	if err != nil {
+		utils.LogError("Failed to check container status", err)
+		// Still try to stop it anyway
+		utils.LogWarning("Attempting to stop container despite status check failure")
+	} else if !running {
+		utils.LogInfo("Container is already stopped")
+		s.WriteStatus()
+		return false, nil
+	}
+
+	// SIGTERM, wait for the configured timeout, then SIGKILL
+	op := s.Journal.Begin(storage.OpContainerStop, map[string]string{"container": containerID})
+	defer s.WriteStatus()
+	err = s.Docker.StopContainerWithEscalation(containerID, onProgress)
+	if err != nil {
+		utils.LogError("Staged stop failed, attempting force stop", err)
+
+		// Try force stop as fallback
+		forceErr := s.Docker.ForceStopContainer(containerID)
+		if forceErr != nil {
+			utils.LogError("Force stop also failed", forceErr)
+			stopErr := fmt.Errorf("failed to stop container (staged: %v, force: %v)", err, forceErr)
+			op.Finish(stopErr)
+			return false, stopErr
+		}
+
+		utils.LogWarning("Container force stopped successfully")
+		op.Finish(nil)
+		return true, nil
+	}
+
+	op.Finish(nil)
+	utils.LogInfo("Container stopped")
+	return true, nil
+}
+
+// ImageUpdate describes a pull of the latest build of the selected image
+type ImageUpdate struct {
+	Image string
+	// Before and After are the image's local repo digests around the pull
+	Before []string
+	After  []string
+}
+
+// Changed reports whether the pull brought a new build
+func (u *ImageUpdate) Changed() bool {
+	return !slices.Equal(u.Before, u.After)
+}
+
+// UpdateImage pulls the latest build of the selected image with pull, nil pulling without
+// progress. Running containers keep their image; new containers use the new build.
+func (s *Service) UpdateImage(pull func() error) (*ImageUpdate, error) {
+	image := s.Docker.GetImageName()
+	update := &ImageUpdate{Image: image}
+	update.Before, _ = s.Docker.GetLocalImageDigests(image)
+
+	if pull == nil {
+		pull = s.Docker.PullImage
+	}
+	if err := pull(); err != nil {
+		utils.LogError("Failed to update image", err)
+		return nil, fmt.Errorf("failed to update image: %w", err)
+	}
+	update.After, _ = s.Docker.GetLocalImageDigests(image)
+	utils.LogInfo(fmt.Sprintf("Image %s updated (new build: %v)", image, update.Changed()))
+	return update, nil
+}
+
+// Remove deletes the container, optionally with its volumes, and forgets its ID, login and
+// companion containers. A container already deleted outside the manager is only forgotten.
+// reason, if set, says why the manager removed it on its own. It returns the removed volumes.
+func (s *Service) Remove(containerID string, removeVolumes bool, reason string) ([]string, error) {
+	details := map[string]string{"container": containerID, "volumes": fmt.Sprintf("%v", removeVolumes)}
+	if reason != "" {
+		details["reason"] = reason
+	}
+
+	var volumes []string
+	var err error
+	op := s.Journal.Begin(storage.OpContainerRemove, details)
+	if removeVolumes {
+		volumes, err = s.Docker.RemoveContainerAndVolumes(containerID)
+	} else {
+		err = s.Docker.RemoveContainer(containerID, true)
+	}
+	if errors.CodeOf(err) == errors.CodeContainerNotFound {
+		utils.LogWarning(fmt.Sprintf("Container %s no longer exists; clearing its state", containerID))
+		err = nil
+	}
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Failed to remove container", err)
+		return nil, fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	s.StopSidecars()
+	if err := s.Files.DeleteContainerID(); err != nil {
+		utils.LogError("Failed to delete container ID file", err)
+		return nil, fmt.Errorf("failed to delete container ID file: %w", err)
+	}
+	if err := s.Credentials.Clear(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to clear stored credentials: %v", err))
+	}
+	s.WriteStatus()
+	return volumes, nil
+}
+
+// LogScanOptions returns how much container log the credential scanner reads, from settings
+func (s *Service) LogScanOptions(scanStart time.Time) docker.LogFetchOptions {
+	scan := storage.DefaultSettings().LogScan
+	if settings, err := s.Settings.Load(); err == nil {
+		scan = settings.LogScan
+	} else {
+		utils.LogWarning(fmt.Sprintf("Using the default log scan window: %v", err))
+	}
+
+	options := docker.LogFetchOptions{Tail: scan.TailLines}
+	if scan.SinceMinutes > 0 {
+		options.Since = scanStart.Add(-time.Duration(scan.SinceMinutes) * time.Minute)
+	}
+	utils.LogDebug(fmt.Sprintf("Scanning the last %d log lines (since: %v) for credentials", options.Tail, options.Since))
+	return options
+}
+
+// MaxInstallWait returns how long a first-run install may take, from settings; 0 means no limit
+func (s *Service) MaxInstallWait() time.Duration {
+	minutes := storage.DefaultMaxInstallMinutes
+	if settings, err := s.Settings.Load(); err == nil {
+		minutes = settings.LogScan.MaxInstallMinutes
+	} else {
+		utils.LogWarning(fmt.Sprintf("Using the default maximum install time: %v", err))
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// WaitForInstall follows a new container's log until its first-run install prints the admin
+// login, which is saved with the local URL. A failed or timed-out install is returned as a
+// *docker.InstallFailure. onProgress receives each change of install progress.
+func (s *Service) WaitForInstall(ctx context.Context, containerID string, start time.Time, onProgress func(*docker.InstallProgress)) (*storage.Credentials, error) {
+	maxWait := s.MaxInstallWait()
+	cursor := s.Docker.NewLogCursor(containerID, s.LogScanOptions(start))
+	scan := s.LogParser.ForImage(s.Docker.GetImageName()).NewInstallScan()
+	if password, err := s.Docker.PresetPassword(containerID); err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not read the admin password set for the container: %v", err))
+	} else if password != "" {
+		scan.WithPreset(password, s.MoodleURL())
+	}
+	var last *docker.InstallProgress
+
+	for {
+		if maxWait > 0 && time.Since(start) > maxWait {
+			return nil, &docker.InstallFailure{
+				Reason:  docker.InstallFailureTimeout,
+				Message: fmt.Sprintf("Moodle installation did not finish within %v", maxWait),
+				Excerpt: docker.LastLogLines(scan.Recent(), 20),
+			}
+		}
+
+		logs, err := cursor.Next()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			scan.Add(logs)
+			if progress := scan.Progress(); onProgress != nil && (last == nil || progress.Phase != last.Phase || progress.Percentage > last.Percentage || progress.PluginsInstalled != last.PluginsInstalled) {
+				onProgress(progress)
+				last = progress
+			}
+
+			if creds := scan.Credentials(); creds.IsComplete() {
+				if err := s.Credentials.Update(creds.Password, s.MoodleURL()); err != nil {
+					return nil, fmt.Errorf("failed to save credentials: %w", err)
+				}
+				s.WriteStatus()
+				return s.Credentials.Load()
+			}
+			if failure := scan.Failure(); failure != nil {
+				return nil, failure
+			}
+		}
+
+		// Logs also fail once the container is gone
+		if failure, stateErr := s.Docker.CheckInstallContainer(containerID, scan.Recent()); stateErr == nil && failure != nil {
+			return nil, failure
+		}
+		if !sleepContext(ctx, 2*time.Second) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// WaitForReady waits until the container reports healthy, or answers HTTP when it has no
+// health check, for at most timeout
+func (s *Service) WaitForReady(ctx context.Context, containerID string, timeout time.Duration) error {
+	return waitUntil(ctx, timeout, func() bool { return s.moodleReady(containerID) })
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"context"
+	stderrors "errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const testImage = "wenkhairu/moodle-prototype:502-stable"
+
+// noCommands fails every helper program, so credentials go to the encrypted file rather than
+// the keychain of the machine running the tests
+type noCommands struct{}
+
+var errNoCommands = stderrors.New("commands are disabled in tests")
+
+func (noCommands) Run(cmd *exec.Cmd) error                      { return errNoCommands }
+func (noCommands) Output(cmd *exec.Cmd) ([]byte, error)         { return nil, errNoCommands }
+func (noCommands) CombinedOutput(cmd *exec.Cmd) ([]byte, error) { return nil, errNoCommands }
+func (noCommands) Start(cmd *exec.Cmd) error                    { return errNoCommands }
+
+// newTestService returns a service over a fake Docker client and a temporary data directory
+func newTestService(t *testing.T) (*Service, *docker.FakeClient) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	previous := utils.SetCommandRunner(noCommands{})
+	t.Cleanup(func() { utils.SetCommandRunner(previous) })
+
+	fake := docker.NewFakeClient(testImage)
+	return NewServiceWithDocker(fake), fake
+}
+
+func TestStartCreatesContainer(t *testing.T) {
+	service, fake := newTestService(t)
+
+	var steps []string
+	result, err := service.Start(StartHooks{Step: func(step, message string) { steps = append(steps, step) }})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !result.Created {
+		t.Error("Expected a new container to be reported as created")
+	}
+
+	calls := fake.Calls()
+	if !slices.Contains(calls, "PullImage") || !slices.Contains(calls, "RunContainer") {
+		t.Errorf("Expected the missing image to be pulled and a container run, got %v", calls)
+	}
+	if !slices.Equal(steps, []string{docker.RunStepCheckingImage, docker.RunStepPullingImage, docker.RunStepCreatingContainer, docker.RunStepStartingContainer}) {
+		t.Errorf("Unexpected steps: %v", steps)
+	}
+	if id, err := service.ContainerID(); err != nil || id != result.ContainerID {
+		t.Errorf("Expected container ID %s to be saved, got %q (%v)", result.ContainerID, id, err)
+	}
+	if container, _ := fake.Container(result.ContainerID); !container.Running {
+		t.Error("Expected the new container to be running")
+	}
+}
+
+func TestStartRestartsStoppedContainer(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("a", 64)
+	fake.AddImage(testImage)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+
+	var restarted string
+	result, err := service.Start(StartHooks{BeforeRestart: func(containerID string) { restarted = containerID }})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if result.Created || result.ContainerID != id || restarted != id {
+		t.Errorf("Expected container %s to be restarted, got %+v (hook saw %q)", id, result, restarted)
+	}
+	if slices.Contains(fake.Calls(), "RunContainer") {
+		t.Error("Expected no new container for a stopped one")
+	}
+	if container, _ := fake.Container(id); !container.Running {
+		t.Error("Expected the container to be running")
+	}
+
+	if _, err := service.Start(StartHooks{}); !stderrors.Is(err, errors.ErrContainerRunning) {
+		t.Errorf("Expected ErrContainerRunning for a running container, got %v", err)
+	}
+}
+
+func TestStartRefusesUntrackedContainer(t *testing.T) {
+	service, fake := newTestService(t)
+	fake.AddImage(testImage)
+	fake.AddContainer(docker.FakeContainer{ID: strings.Repeat("b", 64), Name: "moodle-tester-tester", Image: testImage})
+
+	if _, err := service.Start(StartHooks{}); !stderrors.Is(err, errors.ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState next to an untracked container, got %v", err)
+	}
+	if slices.Contains(fake.Calls(), "RunContainer") {
+		t.Error("Expected no duplicate container to be run")
+	}
+}
+
+func TestStop(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("c", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped, err := service.Stop(nil)
+	if err != nil || !stopped {
+		t.Fatalf("Expected the running container to stop, got %v (%v)", stopped, err)
+	}
+	if container, _ := fake.Container(id); container.Running {
+		t.Error("Expected the container to be stopped")
+	}
+
+	if stopped, err := service.Stop(nil); err != nil || stopped {
+		t.Errorf("Expected a stopped container to be left alone, got %v (%v)", stopped, err)
+	}
+}
+
+func TestStopFallsBackToForce(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("d", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	fake.FailOn("StopContainerWithEscalation", stderrors.New("daemon busy"))
+
+	if stopped, err := service.Stop(nil); err != nil || !stopped {
+		t.Fatalf("Expected a forced stop, got %v (%v)", stopped, err)
+	}
+	if container, _ := fake.Container(id); container.Running || container.ExitCode != 137 {
+		t.Errorf("Expected the container to be killed, got %+v", container)
+	}
+
+	fake.FailOn("ForceStopContainer", stderrors.New("daemon gone"))
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true})
+	if _, err := service.Stop(nil); err == nil {
+		t.Error("Expected an error when both stops fail")
+	}
+}
+
+func TestWaitForInstallSavesCredentials(t *testing.T) {
+	service, fake := newTestService(t)
+	fake.AddImage(testImage)
+	result, err := service.Start(StartHooks{})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	fake.AddLogs(result.ContainerID,
+		"== Setting up database ==",
+		"Installation completed successfully",
+		"Generated admin password: s3cret",
+		"Moodle is available at: http://localhost:8080",
+	)
+
+	var phases []string
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	creds, err := service.WaitForInstall(ctx, result.ContainerID, result.StartTime, func(progress *docker.InstallProgress) {
+		phases = append(phases, progress.Phase)
+	})
+	if err != nil {
+		t.Fatalf("WaitForInstall failed: %v", err)
+	}
+	if creds.Password != "s3cret" {
+		t.Errorf("Expected the logged password, got %q", creds.Password)
+	}
+	if len(phases) == 0 {
+		t.Error("Expected install progress to be reported")
+	}
+}
+
+func TestWaitForInstallUsesPresetPassword(t *testing.T) {
+	service, fake := newTestService(t)
+	fake.SetImageName("bitnami/moodle:4.5")
+	fake.AddImage("bitnami/moodle:4.5")
+	result, err := service.Start(StartHooks{})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	container, _ := fake.Container(result.ContainerID)
+	if container.AdminPassword == "" {
+		t.Fatal("Expected the bitnami container to be given an admin password")
+	}
+	fake.AddLogs(result.ContainerID,
+		"moodle 10:00:01.00 INFO  ==> Running Moodle install script",
+		"moodle 10:04:12.00 INFO  ==> ** Moodle setup finished! **",
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	creds, err := service.WaitForInstall(ctx, result.ContainerID, result.StartTime, nil)
+	if err != nil {
+		t.Fatalf("WaitForInstall failed: %v", err)
+	}
+	if creds.Password != container.AdminPassword {
+		t.Errorf("Expected the preset password %q, got %q", container.AdminPassword, creds.Password)
+	}
+}
+
+func TestWaitForInstallReportsExitedContainer(t *testing.T) {
+	service, fake := newTestService(t)
+	fake.AddImage(testImage)
+	result, err := service.Start(StartHooks{})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	fake.AddLogs(result.ContainerID, "Killed")
+	fake.AddContainer(docker.FakeContainer{ID: result.ContainerID, Image: testImage, ExitCode: 137, OOMKilled: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = service.WaitForInstall(ctx, result.ContainerID, result.StartTime, nil)
+	var failure *docker.InstallFailure
+	if !stderrors.As(err, &failure) || failure.Reason != docker.InstallFailureOOM {
+		t.Errorf("Expected an out-of-memory install failure, got %v", err)
+	}
+}
+
+func TestRemoveForgetsContainer(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("f", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true, Volumes: []string{"moodledata", "moodledb"}})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Credentials.Update("s3cret", "http://localhost:8080"); err != nil {
+		t.Fatal(err)
+	}
+
+	volumes, err := service.Remove(id, true, "")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if !slices.Equal(volumes, []string{"moodledata", "moodledb"}) {
+		t.Errorf("Expected the container's volumes to be removed, got %v", volumes)
+	}
+	if _, ok := fake.Container(id); ok {
+		t.Error("Expected the container to be removed")
+	}
+	if _, err := service.ContainerID(); err == nil {
+		t.Error("Expected the stored container ID to be deleted")
+	}
+	if creds, err := service.Credentials.Load(); err == nil && creds.Password != "" {
+		t.Error("Expected the stored login to be cleared")
+	}
+
+	// A container deleted outside the manager is only forgotten
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Remove(id, false, ""); err != nil {
+		t.Errorf("Expected a missing container to be forgotten, got %v", err)
+	}
+}
+
+func TestUpdateImage(t *testing.T) {
+	service, fake := newTestService(t)
+
+	update, err := service.UpdateImage(nil)
+	if err != nil {
+		t.Fatalf("UpdateImage failed: %v", err)
+	}
+	if !update.Changed() || update.Image != testImage {
+		t.Errorf("Expected a new build of %s, got %+v", testImage, update)
+	}
+
+	if update, err := service.UpdateImage(nil); err != nil || update.Changed() {
+		t.Errorf("Expected an up-to-date image to be unchanged, got %+v (%v)", update, err)
+	}
+
+	fake.FailOn("PullImage", stderrors.New("registry unreachable"))
+	if _, err := service.UpdateImage(nil); err == nil {
+		t.Error("Expected a failed pull to be reported")
+	}
+}
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+)
+
+// DefaultReadyTimeout is how long an installed Moodle may take to answer after a start
+const DefaultReadyTimeout = 10 * time.Minute
+
+// ReadyHooks lets a caller report and take part in WaitForMoodle; nil hooks are skipped
+type ReadyHooks struct {
+	// Step reports each docker.RunStep* step reached, with the fraction of it done
+	Step func(step string, fraction float64, message string)
+	// Progress receives each change of first-run install progress
+	Progress func(*docker.InstallProgress)
+	// Ready reports whether Moodle serves requests; nil uses the container health check
+	Ready func() bool
+	// PublicURL returns the URL users should open and whether it is served over HTTPS; nil
+	// uses MoodleURL
+	PublicURL func() (string, bool)
+	// SeedProgress receives the progress of demo data seeded after the install
+	SeedProgress func(percentage float64, status string)
+	// Timeout bounds the wait for an installed Moodle to answer; 0 uses DefaultReadyTimeout
+	Timeout time.Duration
+}
+
+// ReadyResult describes a Moodle WaitForMoodle saw become ready
+type ReadyResult struct {
+	Credentials *storage.Credentials
+	// Installed is set when the first-run install finished during the wait
+	Installed bool
+	// PreviousURL is the wwwroot Moodle had before it was pointed at the public URL; empty
+	// when it was already right
+	PreviousURL string
+	// Seed is the demo data seeded after the install, if settings asked for it
+	Seed *docker.SeedResult
+}
+
+// WaitForMoodle takes a started container to a usable Moodle: it follows the first-run
+// install until the login is known, or waits for an installed Moodle to answer, then points
+// Moodle's wwwroot at the public URL and saves it with the login. A fresh install also gets
+// the site details and demo data from settings. A failed install is returned as a
+// *docker.InstallFailure after the install cleanup policy was applied to it.
+func (s *Service) WaitForMoodle(ctx context.Context, result *StartResult, hooks ReadyHooks) (*ReadyResult, error) {
+	step := func(step string, fraction float64, message string) {
+		if hooks.Step != nil {
+			hooks.Step(step, fraction, message)
+		}
+	}
+	publicURL := func() (string, bool) {
+		if hooks.PublicURL != nil {
+			return hooks.PublicURL()
+		}
+		return s.MoodleURL(), false
+	}
+	containerID := result.ContainerID
+	ready := &ReadyResult{}
+
+	existing, err := s.Credentials.Load()
+	if (err == nil && existing.Password != "") || result.ResetAdminPassword {
+		utils.LogInfo("Subsequent run - waiting for Moodle to answer instead of parsing logs")
+		step(docker.RunStepWaitingForDatabase, 0, "")
+		isReady := hooks.Ready
+		if isReady == nil {
+			isReady = func() bool { return s.moodleReady(containerID) }
+		}
+		timeout := hooks.Timeout
+		if timeout <= 0 {
+			timeout = DefaultReadyTimeout
+		}
+		if err := waitUntil(ctx, timeout, isReady); err != nil {
+			return nil, err
+		}
+		utils.LogInfo("Container is ready - Moodle is healthy")
+	} else {
+		utils.LogInfo("First run - extracting credentials from logs")
+		if _, err := s.WaitForInstall(ctx, containerID, result.StartTime, func(progress *docker.InstallProgress) {
+			if hooks.Progress != nil {
+				hooks.Progress(progress)
+			}
+			installStep, fraction := docker.InstallRunStep(progress)
+			step(installStep, fraction, progress.Label)
+		}); err != nil {
+			if failure, ok := err.(*docker.InstallFailure); ok {
+				s.CleanupFailedInstall(containerID, result.Created, failure)
+			}
+			return nil, err
+		}
+		step(docker.RunStepExtractingCredentials, 0.5, "")
+		ready.Installed = true
+	}
+
+	// The image installs with a localhost URL on its default port, and the port or hostname
+	// may have changed while Moodle was stopped
+	url, https := publicURL()
+	if previous, err := s.EnsurePublicURL(containerID, url, https); err != nil {
+		utils.LogError("Failed to point Moodle at its public URL", err)
+	} else {
+		ready.PreviousURL = previous
+	}
+	if result.ResetAdminPassword {
+		password, err := s.Docker.ResetAdminPassword(containerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set a new admin password: %w", err)
+		}
+		if err := s.Credentials.Update(password, url); err != nil {
+			return nil, fmt.Errorf("failed to save the new admin password: %w", err)
+		}
+		utils.LogInfo("Gave the restored site's admin a new password")
+	}
+	if creds, err := s.Credentials.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	} else if err := s.Credentials.Update(creds.Password, url); err != nil {
+		return nil, fmt.Errorf("failed to update credentials: %w", err)
+	}
+	if ready.Installed {
+		if err := s.ApplySiteSettings(containerID); err != nil {
+			utils.LogError("Failed to apply site details after install", err)
+		}
+	}
+	s.WriteStatus()
+	step(docker.RunStepReady, 0, "")
+
+	if ready.Installed {
+		ready.Seed = s.seedAfterInstall(containerID, hooks.SeedProgress)
+	}
+	ready.Credentials, err = s.Credentials.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	return ready, nil
+}
+
+// StartSidecars starts the companion containers enabled in settings next to Moodle, returning
+// Adminer's login details when it was started
+func (s *Service) StartSidecars(containerID string) *docker.AdminerInfo {
+	if s.Docker.GetMailCatcher() {
+		if err := s.Docker.StartMailCatcher(containerID); err != nil {
+			utils.LogError("Failed to start mail catcher", err)
+		}
+	}
+	if !s.Docker.GetAdminer() {
+		return nil
+	}
+	info, err := s.Docker.StartAdminer(containerID)
+	if err != nil {
+		utils.LogError("Failed to start Adminer", err)
+		return nil
+	}
+	return info
+}
+
+// StopSidecars removes the companion containers; they live only as long as Moodle runs
+func (s *Service) StopSidecars() {
+	if s.Docker.GetMailCatcher() {
+		s.Docker.StopMailCatcher()
+	}
+	if s.Docker.GetAdminer() {
+		s.Docker.StopAdminer()
+	}
+}
+
+// EnsurePublicURL updates Moodle's wwwroot when it differs from url, so links keep working
+// after the host port or hostname changed. It returns the previous wwwroot when it changed.
+func (s *Service) EnsurePublicURL(containerID, url string, https bool) (string, error) {
+	current, err := s.Docker.GetWWWRoot(containerID)
+	if err != nil {
+		return "", err
+	}
+	if current == url {
+		return "", nil
+	}
+
+	utils.LogInfo(fmt.Sprintf("Moodle wwwroot is %s, updating it to %s", current, url))
+	if err := s.Docker.ConfigureWWWRoot(containerID, url, https); err != nil {
+		return "", fmt.Errorf("failed to update Moodle wwwroot: %w", err)
+	}
+	return current, nil
+}
+
+// ApplySiteSettings writes the site details from settings into Moodle
+func (s *Service) ApplySiteSettings(containerID string) error {
+	settings, err := s.Settings.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	site := settings.Site
+	if err := s.Docker.ConfigureSite(containerID, site.FullName, site.ShortName, site.AdminEmail); err != nil {
+		return fmt.Errorf("failed to configure site details: %w", err)
+	}
+	return nil
+}
+
+// SeedDemoData seeds demo data of the given size into the container
+func (s *Service) SeedDemoData(containerID, size string, onProgress func(percentage float64, status string)) (*docker.SeedResult, error) {
+	op := s.Journal.Begin(storage.OpDemoSeed, map[string]string{"container": containerID, "size": size})
+	result, err := s.Docker.SeedDemoData(containerID, size, onProgress)
+	op.Finish(err)
+	if err != nil {
+		utils.LogError("Demo data seeding failed", err)
+		return nil, fmt.Errorf("failed to seed demo data: %w", err)
+	}
+	return result, nil
+}
+
+// seedAfterInstall seeds the demo data a template asked for once a new install has finished.
+// The request is cleared first, so later installs of the instance start empty even when
+// seeding fails.
+func (s *Service) seedAfterInstall(containerID string, onProgress func(float64, string)) *docker.SeedResult {
+	settings, err := s.Settings.Load()
+	if err != nil || settings.SeedOnInstall == "" {
+		return nil
+	}
+	if _, err := s.Settings.Update(func(settings *storage.Settings) {
+		settings.SeedOnInstall = ""
+	}); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to clear the demo data request: %v", err))
+	}
+	utils.LogInfo(fmt.Sprintf("Seeding %s demo data after install", settings.SeedOnInstall))
+	result, err := s.SeedDemoData(containerID, settings.SeedOnInstall, onProgress)
+	if err != nil {
+		utils.LogError("Failed to seed demo data after install", err)
+		return nil
+	}
+	return result
+}
+
+// CleanupFailedInstall records a failed first-run install in the journal and removes the
+// half-provisioned container, with its volumes, when the install cleanup policy is "remove".
+// Only a container created by this run is removed: its volumes are new and hold nothing but
+// the failed install, while a container that existed before may hold a site. The container's
+// log is saved first; failure.LogFile and failure.Removed say what was done.
+func (s *Service) CleanupFailedInstall(containerID string, created bool, failure *docker.InstallFailure) {
+	utils.LogError("First-run installation failed", failure)
+	if failure.Excerpt != "" {
+		utils.LogDebug("Installation log excerpt:\n" + failure.Excerpt)
+	}
+	s.Journal.Begin(storage.OpMoodleInstall, map[string]string{"container": containerID, "reason": failure.Reason}).Finish(failure)
+
+	if !created {
+		utils.LogInfo(fmt.Sprintf("Keeping container %s of the failed install: it was not created by this run", containerID))
+		return
+	}
+	policy := storage.DefaultSettings().InstallCleanup.Policy
+	if settings, err := s.Settings.Load(); err == nil {
+		policy = settings.InstallCleanup.Policy
+	} else {
+		utils.LogWarning(fmt.Sprintf("Using the default install cleanup policy: %v", err))
+	}
+	if policy == storage.InstallCleanupKeep {
+		utils.LogInfo(fmt.Sprintf("Keeping container %s of the failed install", containerID))
+		return
+	}
+
+	logFile, err := s.saveFailedInstallLog(containerID)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to save the log of the failed install: %v", err))
+	} else {
+		failure.LogFile = logFile
+	}
+
+	if _, err := s.Remove(containerID, true, "its install failed"); err != nil {
+		utils.LogError("Failed to remove the container of the failed install", err)
+		return
+	}
+	failure.Removed = true
+}
+
+// saveFailedInstallLog writes the container's log next to moodle.log, returning its path
+func (s *Service) saveFailedInstallLog(containerID string) (string, error) {
+	chunk, err := s.Docker.FetchContainerLogs(containerID, docker.LogFetchOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+	dir, err := utils.LogDirectory()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the log directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("install-failure-%s.log", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(chunk.Logs), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	utils.LogInfo(fmt.Sprintf("Saved the log of the failed install to %s", path))
+	return path, nil
+}
+
+// moodleReady reports whether the container is healthy, or answers HTTP when it has no
+// health check
+func (s *Service) moodleReady(containerID string) bool {
+	health, err := s.Docker.GetContainerHealth(containerID)
+	if err == nil && health.Status == docker.HealthHealthy {
+		return true
+	}
+	return (err != nil || health.Status == docker.HealthNone) && s.ProbeHTTP()
+}
+
+// waitUntil polls ready every two seconds for at most timeout
+func waitUntil(ctx context.Context, timeout time.Duration, ready func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if ready() {
+			return nil
+		}
+		utils.LogDebug("Waiting for Moodle HTTP response...")
+		if !sleepContext(ctx, 2*time.Second) {
+			return ctx.Err()
+		}
+	}
+	return errors.NewNetworkError("timeout", fmt.Errorf("timeout waiting for Moodle HTTP response after %v", timeout))
+}
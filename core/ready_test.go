@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/storage"
+)
+
+const testPublicURL = "http://moodle.test:8080"
+
+// startInstall starts a new container whose log ends in lines, with t.Chdir keeping a saved
+// install log out of the source tree
+func startInstall(t *testing.T, service *Service, fake *docker.FakeClient, lines ...string) *StartResult {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	fake.AddImage(testImage)
+	result, err := service.Start(StartHooks{})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	fake.AddLogs(result.ContainerID, lines...)
+	return result
+}
+
+func waitForMoodle(t *testing.T, service *Service, result *StartResult) (*ReadyResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return service.WaitForMoodle(ctx, result, ReadyHooks{
+		PublicURL: func() (string, bool) { return testPublicURL, false },
+		Timeout:   5 * time.Second,
+	})
+}
+
+func TestWaitForMoodleFinishesInstall(t *testing.T) {
+	service, fake := newTestService(t)
+	if _, err := service.Settings.Update(func(s *storage.Settings) { s.SeedOnInstall = "S" }); err != nil {
+		t.Fatal(err)
+	}
+	result := startInstall(t, service, fake,
+		"Installation completed successfully",
+		"Generated admin password: s3cret",
+		"Moodle is available at: http://localhost:8080",
+	)
+
+	ready, err := waitForMoodle(t, service, result)
+	if err != nil {
+		t.Fatalf("WaitForMoodle failed: %v", err)
+	}
+	if !ready.Installed || ready.Seed == nil {
+		t.Errorf("Expected a finished, seeded install, got %+v", ready)
+	}
+	if ready.Credentials.Password != "s3cret" || ready.Credentials.URL != testPublicURL {
+		t.Errorf("Expected the logged password with the public URL, got %+v", ready.Credentials)
+	}
+	if container, _ := fake.Container(result.ContainerID); container.WWWRoot != testPublicURL {
+		t.Errorf("Expected wwwroot %s, got %q", testPublicURL, container.WWWRoot)
+	}
+	if calls := fake.Calls(); !slices.Contains(calls, "ConfigureSite") || !slices.Contains(calls, "SeedDemoData") {
+		t.Errorf("Expected the site details and demo data to be applied, got %v", calls)
+	}
+	settings, err := service.Settings.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.SeedOnInstall != "" {
+		t.Errorf("Expected the demo data request to be cleared after seeding, got %q", settings.SeedOnInstall)
+	}
+}
+
+func TestWaitForMoodleKeepsFailedInstall(t *testing.T) {
+	service, fake := newTestService(t)
+	result := startInstall(t, service, fake, "PHP Fatal error:  Allowed memory size exhausted")
+
+	_, err := waitForMoodle(t, service, result)
+	failure, ok := err.(*docker.InstallFailure)
+	if !ok {
+		t.Fatalf("Expected an install failure, got %v", err)
+	}
+	if failure.Removed {
+		t.Error("Expected the default policy to keep the container")
+	}
+	if _, ok := fake.Container(result.ContainerID); !ok {
+		t.Error("Expected the container of the failed install to be kept")
+	}
+}
+
+func TestWaitForMoodleRemovesOnlyCreatedContainers(t *testing.T) {
+	for _, created := range []bool{true, false} {
+		service, fake := newTestService(t)
+		if _, err := service.Settings.Update(func(s *storage.Settings) { s.InstallCleanup.Policy = storage.InstallCleanupRemove }); err != nil {
+			t.Fatal(err)
+		}
+		result := startInstall(t, service, fake, "PHP Fatal error:  Allowed memory size exhausted")
+		result.Created = created
+
+		_, err := waitForMoodle(t, service, result)
+		failure, ok := err.(*docker.InstallFailure)
+		if !ok {
+			t.Fatalf("Expected an install failure, got %v", err)
+		}
+		_, exists := fake.Container(result.ContainerID)
+		if failure.Removed != created || exists == created {
+			t.Errorf("created=%v: expected removal only for a created container, got removed=%v exists=%v", created, failure.Removed, exists)
+		}
+		if _, err := service.ContainerID(); (err == nil) == created {
+			t.Errorf("created=%v: unexpected stored container ID state (%v)", created, err)
+		}
+	}
+}
+
+func TestWaitForMoodleUpdatesWWWRootOnRestart(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("e", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true, Health: docker.HealthHealthy, WWWRoot: "http://localhost:8080"})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Credentials.Update("s3cret", "http://localhost:8080"); err != nil {
+		t.Fatal(err)
+	}
+
+	ready, err := waitForMoodle(t, service, &StartResult{ContainerID: id, StartTime: time.Now()})
+	if err != nil {
+		t.Fatalf("WaitForMoodle failed: %v", err)
+	}
+	if ready.Installed || ready.PreviousURL != "http://localhost:8080" || ready.Credentials.URL != testPublicURL {
+		t.Errorf("Expected the wwwroot of the installed site to move to %s, got %+v", testPublicURL, ready)
+	}
+	if slices.Contains(fake.Calls(), "SeedDemoData") {
+		t.Error("Expected no demo data for an installed site")
+	}
+}
+
+func TestWaitForMoodleResetsPasswordOfRestoredSite(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("d", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true, Health: docker.HealthHealthy, WWWRoot: testPublicURL})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+
+	ready, err := waitForMoodle(t, service, &StartResult{ContainerID: id, StartTime: time.Now(), ResetAdminPassword: true})
+	if err != nil {
+		t.Fatalf("WaitForMoodle failed: %v", err)
+	}
+	container, _ := fake.Container(id)
+	if ready.Installed || ready.Credentials.Password == "" || ready.Credentials.Password != container.AdminPassword {
+		t.Errorf("Expected the restored site to get a new saved password, got %+v", ready.Credentials)
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Reconciliation describes what Reconcile found and changed
+type Reconciliation struct {
+	// State is StateNone, StateRunning or StateStopped, or StateMissing when the stored
+	// container no longer exists
+	State       string `json:"state"`
+	ContainerID string `json:"containerId,omitempty"`
+	// HostPort is the port Moodle is published on
+	HostPort int `json:"hostPort"`
+	// PreviousPort is the configured port when the running container is published on another
+	// one; 0 when they match
+	PreviousPort int `json:"previousPort,omitempty"`
+	// URLUpdated is set when the stored login pointed at another URL
+	URLUpdated bool `json:"urlUpdated"`
+}
+
+// StateMissing is reported by Reconcile for a stored container that was deleted outside the manager
+const StateMissing = "missing"
+
+// Reconcile brings the stored state in line with Docker after the manager was closed: the ID
+// and login of a container deleted in the meantime are cleared, the port of a running
+// container is taken from Docker, and the stored login URL is updated to publicURL, or
+// MoodleURL when publicURL is nil. When Docker cannot be asked, nothing is changed and
+// status.json reports the state as unknown.
+func (s *Service) Reconcile(publicURL func() string) (*Reconciliation, error) {
+	if publicURL == nil {
+		publicURL = s.MoodleURL
+	}
+	result := &Reconciliation{State: StateNone, HostPort: s.Docker.GetHostPort()}
+
+	containerID, err := s.ContainerID()
+	if err != nil {
+		s.WriteStatus()
+		return result, nil
+	}
+	result.ContainerID = containerID
+
+	running, err := s.Docker.IsContainerRunning(containerID)
+	if errors.CodeOf(err) == errors.CodeContainerNotFound {
+		utils.LogWarning(fmt.Sprintf("Container %s no longer exists; clearing its state", containerID))
+		result.State = StateMissing
+		if err := s.Files.DeleteContainerID(); err != nil {
+			return nil, fmt.Errorf("failed to delete container ID file: %w", err)
+		}
+		if err := s.Credentials.Clear(); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to clear stored credentials: %v", err))
+		}
+		s.WriteStatus()
+		return result, nil
+	}
+	if err != nil {
+		s.WriteStatus()
+		return nil, fmt.Errorf("failed to check container status: %w", err)
+	}
+
+	if !running {
+		result.State = StateStopped
+		s.WriteStatus()
+		return result, nil
+	}
+	result.State = StateRunning
+
+	// The port is fixed when the container is created; settings changed since then only
+	// apply to the next container
+	if port := s.publishedPort(containerID); port != 0 && port != result.HostPort {
+		utils.LogWarning(fmt.Sprintf("Container is published on port %d, not the configured %d", port, result.HostPort))
+		result.PreviousPort, result.HostPort = result.HostPort, port
+		s.UseHostPort(port)
+	}
+
+	if creds, err := s.Credentials.Load(); err == nil && creds.Password != "" && creds.URL != publicURL() {
+		utils.LogInfo(fmt.Sprintf("Updating the stored Moodle URL from %s to %s", creds.URL, publicURL()))
+		if err := s.Credentials.Update(creds.Password, publicURL()); err != nil {
+			return nil, fmt.Errorf("failed to update credentials: %w", err)
+		}
+		result.URLUpdated = true
+	}
+
+	s.WriteStatus()
+	return result, nil
+}
+
+// publishedPort returns the host port the container is published on, or 0 when Docker does
+// not list one. A container publishing the configured port keeps it.
+func (s *Service) publishedPort(containerID string) int {
+	containers, err := s.Docker.ListManagedContainers()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not list managed containers for port check: %v", err))
+		return 0
+	}
+	for _, container := range containers {
+		if container.ID == "" || !strings.HasPrefix(containerID, container.ID) || len(container.HostPorts) == 0 {
+			continue
+		}
+		if slices.Contains(container.HostPorts, s.Docker.GetHostPort()) {
+			return s.Docker.GetHostPort()
+		}
+		return container.HostPorts[0]
+	}
+	return 0
+}
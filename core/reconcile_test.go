@@ -0,0 +1,81 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+)
+
+func TestReconcileClearsMissingContainer(t *testing.T) {
+	service, _ := newTestService(t)
+	if err := service.Files.SaveContainerID(strings.Repeat("e", 64)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Credentials.Update("secret", service.MoodleURL()); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.Reconcile(nil)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.State != StateMissing {
+		t.Errorf("Expected the container to be reported missing, got %q", result.State)
+	}
+	if service.Files.ContainerIDExists() || service.Credentials.Exists() {
+		t.Error("Expected the container ID and credentials to be cleared")
+	}
+}
+
+func TestReconcileAdoptsPublishedPort(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("f", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true, HostPort: 8090})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Credentials.Update("secret", service.MoodleURL()); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.Reconcile(nil)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.State != StateRunning || result.HostPort != 8090 || result.PreviousPort != 8080 || !result.URLUpdated {
+		t.Errorf("Expected the running container's port to be adopted, got %+v", result)
+	}
+	if settings, err := service.Settings.Load(); err != nil || settings.HostPort != 8090 {
+		t.Errorf("Expected port 8090 to be saved, got %+v (%v)", settings, err)
+	}
+	if creds, err := service.Credentials.Load(); err != nil || !strings.HasSuffix(creds.URL, ":8090") {
+		t.Errorf("Expected the login URL to use port 8090, got %+v (%v)", creds, err)
+	}
+
+	// A second pass finds nothing to change
+	if again, err := service.Reconcile(nil); err != nil || again.PreviousPort != 0 || again.URLUpdated {
+		t.Errorf("Expected no further changes, got %+v (%v)", again, err)
+	}
+}
+
+func TestReconcileKeepsStateWhenDockerFails(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("0", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	fake.FailOn("IsContainerRunning", errors.NewDockerError("inspect", errors.ErrDockerNotAvailable))
+
+	if _, err := service.Reconcile(nil); err == nil {
+		t.Error("Expected an error while Docker is down")
+	}
+	if !service.Files.ContainerIDExists() {
+		t.Error("Expected the container ID to be kept while Docker is down")
+	}
+	if status, err := service.StatusFile.Load(); err != nil || status.State != StateUnknown || status.ContainerID != id {
+		t.Errorf("Expected an unknown state for the kept container, got %+v (%v)", status, err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package core holds the manager logic shared by the desktop app and the moodle-manager CLI:
+// the Docker manager configured from settings, and the stored container ID, credentials and
+// operation journal that both drive.
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+)
+
+// FallbackImage is used when image.docker is missing or unreadable
+const FallbackImage = "wenkhairu/moodle-prototype:502-stable"
+
+// Service drives the Moodle container for one OS user and instance
+type Service struct {
+	Docker      docker.Client
+	Files       *storage.FileManager
+	Credentials *storage.CredentialManager
+	Settings    *storage.SettingsManager
+	Journal     *storage.Journal
+	LogParser   *docker.LogParser
+	// StatusFile mirrors the instance state in status.json
+	StatusFile *storage.StatusStore
+
+	// configuredImage is the image from image.docker, used unless the catalog selection overrides it
+	configuredImage string
+}
+
+// NewService creates a service over the default data directory
+func NewService() *Service {
+	return NewServiceWithDocker(docker.NewManager())
+}
+
+// NewServiceWithDocker creates a service that drives containers through client, e.g. a
+// docker.FakeClient in tests
+func NewServiceWithDocker(client docker.Client) *Service {
+	return &Service{
+		Docker:      client,
+		Files:       storage.NewFileManager(),
+		Credentials: storage.NewCredentialManager(),
+		Settings:    storage.NewSettingsManager(),
+		Journal:     storage.NewJournal(),
+		LogParser:   docker.NewLogParser(),
+		StatusFile:  storage.NewStatusStore(),
+	}
+}
+
+// LoadImageConfig reads image.docker, falling back to FallbackImage so the problem shows up
+// in the log rather than stopping the manager
+func (s *Service) LoadImageConfig() string {
+	imageName, err := s.Files.LoadImageName()
+	if err != nil {
+		utils.LogError("Failed to load image configuration", err)
+		utils.LogError("Cannot start application without valid image configuration", fmt.Errorf("image.docker file missing or unreadable"))
+		imageName = FallbackImage
+		utils.LogWarning(fmt.Sprintf("FALLBACK: Using default image '%s' - please create image.docker file with correct image name", imageName))
+	}
+	s.configuredImage = imageName
+	return imageName
+}
+
+// ConfiguredImage returns the image from image.docker
+func (s *Service) ConfiguredImage() string {
+	return s.configuredImage
+}
+
+// LoadSettings returns the saved settings, or the defaults when they cannot be read
+func (s *Service) LoadSettings() *storage.Settings {
+	settings, err := s.Settings.Load()
+	if err != nil {
+		// Settings from a newer app version are left untouched; saving them is refused too
+		utils.LogError("Failed to load settings, using defaults", err)
+		return storage.DefaultSettings()
+	}
+	return settings
+}
+
+// Configure pushes settings to the Docker manager and the docker CLI environment
+func (s *Service) Configure(settings *storage.Settings) {
+	imageName := s.configuredImage
+	// An image picked from the catalog takes precedence over image.docker
+	if selected := settings.SelectedImage(); selected != "" {
+		utils.LogInfo(fmt.Sprintf("Using image selected from catalog for instance %q: %s", settings.InstanceName, selected))
+		imageName = selected
+	}
+	s.Docker.SetImageName(imageName)
+	utils.LogInfo(fmt.Sprintf("Using Docker image: %s", imageName))
+
+	s.Docker.SetInstanceName(settings.InstanceName)
+	s.Docker.SetHostPort(settings.HostPort)
+	s.Docker.SetMemoryLimit(int64(settings.Memory.LimitMB) * 1024 * 1024)
+	s.Docker.SetStopTimeout(time.Duration(settings.StopTimeoutSeconds) * time.Second)
+	if err := s.Docker.SetRestartPolicy(RestartPolicyFor(settings.AutoRestart || settings.Kiosk.Enabled)); err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring invalid restart policy: %v", err))
+	}
+	if err := s.Docker.SetLogLevels(LogLevelsFromSettings(settings.Logging)); err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring invalid container log levels: %v", err))
+	}
+
+	s.Docker.SetContainerEnv(settings.ContainerEnv)
+	s.Docker.SetMailCatcher(settings.Mail.Enabled, settings.Mail.UIPort)
+	s.Docker.SetAdminer(settings.Adminer.Enabled, settings.Adminer.Port)
+	ApplyDockerHost(settings.DockerHost)
+	ApplyProxy(settings.Proxy)
+}
+
+// ContainerID returns the stored container ID
+func (s *Service) ContainerID() (string, error) {
+	if !s.Files.ContainerIDExists() {
+		return "", fmt.Errorf("no container ID found")
+	}
+
+	containerID, err := s.Files.LoadContainerID()
+	if err != nil {
+		return "", fmt.Errorf("failed to load container ID: %w", err)
+	}
+	return containerID, nil
+}
+
+// RunningContainerID returns the stored container ID, failing if the container is not running
+func (s *Service) RunningContainerID() (string, error) {
+	containerID, err := s.ContainerID()
+	if err != nil {
+		return "", err
+	}
+
+	running, err := s.Docker.IsContainerRunning(containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check container status: %w", err)
+	}
+	if !running {
+		return "", fmt.Errorf("container is not running")
+	}
+	return containerID, nil
+}
+
+// MoodleURL returns the local URL Moodle is published on
+func (s *Service) MoodleURL() string {
+	return fmt.Sprintf("http://%s:%d", docker.DockerHostAddress(), s.Docker.GetHostPort())
+}
+
+// ProbeHTTP reports whether Moodle answers on its published port
+func (s *Service) ProbeHTTP() bool {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	resp, err := client.Get(s.MoodleURL())
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	// Any HTTP response (even 500) means the server is up
+	return resp.StatusCode > 0
+}
+
+// ResolveHostPort picks a free host port for a new container, skipping ports
+// published by other users' managed containers, and persists the choice
+func (s *Service) ResolveHostPort() error {
+	reserved := make([]int, 0)
+	if containers, err := s.Docker.ListManagedContainers(); err == nil {
+		for _, container := range containers {
+			if container.User != s.Docker.GetUserName() {
+				reserved = append(reserved, container.HostPorts...)
+			}
+		}
+	} else {
+		utils.LogWarning(fmt.Sprintf("Could not list managed containers for port check: %v", err))
+	}
+
+	preferred := s.Docker.GetHostPort()
+	port, err := docker.FindFreeHostPort(preferred, reserved)
+	if err != nil {
+		return err
+	}
+
+	if port != preferred {
+		utils.LogWarning(fmt.Sprintf("Host port %d is taken, using %d instead", preferred, port))
+		s.UseHostPort(port)
+	}
+	return nil
+}
+
+// UseHostPort saves port as the host port and applies it, e.g. for a container that is
+// already published on it
+func (s *Service) UseHostPort(port int) {
+	if _, err := s.Settings.Update(func(settings *storage.Settings) {
+		settings.HostPort = port
+	}); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to persist host port %d: %v", port, err))
+	}
+	s.Docker.SetHostPort(port)
+}
+
+// RestartPolicyFor maps the auto-restart setting to a Docker restart policy
+func RestartPolicyFor(autoRestart bool) string {
+	if autoRestart {
+		return docker.RestartUnlessStopped
+	}
+	return docker.RestartNo
+}
+
+// LogLevelsFromSettings converts stored logging settings to Docker log levels
+func LogLevelsFromSettings(logging storage.LoggingSettings) docker.LogLevels {
+	return docker.LogLevels{
+		Apache: logging.ApacheLogLevel,
+		PHP:    logging.PHPErrorLevel,
+		Moodle: logging.MoodleDebug,
+	}
+}
+
+// ApplyDockerHost points docker commands at the configured daemon
+func ApplyDockerHost(dockerHost storage.DockerHostSettings) {
+	docker.SetDockerHostConfig(docker.DockerHostConfig{
+		Host:      dockerHost.Host,
+		Context:   dockerHost.Context,
+		TLSVerify: dockerHost.TLSVerify,
+		CertPath:  dockerHost.CertPath,
+	})
+}
+
+// ApplyProxy passes proxy settings to the Docker layer
+func ApplyProxy(proxy storage.ProxySettings) {
+	docker.SetProxyConfig(docker.ProxyConfig{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPSProxy,
+		NoProxy:    proxy.NoProxy,
+	})
+	if proxy.HTTPProxy != "" || proxy.HTTPSProxy != "" {
+		// Image pulls are performed by the daemon, which only honours its own proxy configuration
+		utils.LogInfo("Proxy configured; ensure Docker Desktop/daemon proxy settings match for image pulls")
+	}
+}
+
+// LoadAssets points the provisioning assets and registry cache at the data directory and
+// reloads the log patterns
+func (s *Service) LoadAssets() {
+	// Provisioning assets downloaded from the update channel override the built-in ones
+	bundle.SetDirectory(s.Files.DataFilePath(storage.AssetBundleDir))
+	s.Docker.SetRegistryCache(docker.NewRegistryCache(s.Files.DataFilePath(storage.RegistryCacheFile)))
+	s.LogParser = docker.NewLogParser()
+}
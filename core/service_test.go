@@ -0,0 +1,24 @@
+package core
+
+import (
+	"testing"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/storage"
+)
+
+func TestRestartPolicyFor(t *testing.T) {
+	if policy := RestartPolicyFor(true); policy != docker.RestartUnlessStopped {
+		t.Errorf("Expected auto-restart to map to %q, got %q", docker.RestartUnlessStopped, policy)
+	}
+	if policy := RestartPolicyFor(false); policy != docker.RestartNo {
+		t.Errorf("Expected no auto-restart to map to %q, got %q", docker.RestartNo, policy)
+	}
+}
+
+func TestLogLevelsFromSettings(t *testing.T) {
+	levels := LogLevelsFromSettings(storage.LoggingSettings{ApacheLogLevel: "debug", PHPErrorLevel: "E_ALL", MoodleDebug: "developer"})
+	if levels.Apache != "debug" || levels.PHP != "E_ALL" || levels.Moodle != "developer" {
+		t.Errorf("Unexpected log levels: %+v", levels)
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+)
+
+// Container states reported by Status
+const (
+	StateNone    = "none"
+	StateRunning = "running"
+	StateStopped = "stopped"
+	// StateUnknown is written to status.json when Docker cannot be asked
+	StateUnknown = "unknown"
+)
+
+// Status describes the instance and its container
+type Status struct {
+	Instance string `json:"instance"`
+	Image    string `json:"image"`
+	// ContainerID is empty when the instance has no container yet
+	ContainerID string `json:"containerId,omitempty"`
+	State       string `json:"state"`
+	// Health is the container's health check status, empty while it is not running
+	Health      string `json:"health,omitempty"`
+	URL         string `json:"url"`
+	Username    string `json:"username,omitempty"`
+	HasPassword bool   `json:"hasPassword"`
+}
+
+// Status reports the instance's container state, health and login
+func (s *Service) Status() (*Status, error) {
+	status := &Status{
+		Instance: s.Docker.GetInstanceName(),
+		Image:    s.Docker.GetImageName(),
+		State:    StateNone,
+		URL:      s.MoodleURL(),
+	}
+	if creds, err := s.Credentials.Load(); err == nil {
+		status.Username = creds.Username
+		status.HasPassword = creds.Password != ""
+		if creds.URL != "" {
+			status.URL = creds.URL
+		}
+	}
+
+	containerID, err := s.ContainerID()
+	if err != nil {
+		return status, nil
+	}
+	status.ContainerID = containerID
+
+	running, err := s.Docker.IsContainerRunning(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check container status: %w", err)
+	}
+	if !running {
+		status.State = StateStopped
+		return status, nil
+	}
+	status.State = StateRunning
+
+	if health, err := s.Docker.GetContainerHealth(containerID); err == nil {
+		status.Health = health.Status
+	} else {
+		utils.LogDebug(fmt.Sprintf("Failed to read container health: %v", err))
+		status.Health = docker.HealthNone
+	}
+	return status, nil
+}
+
+// WriteStatus refreshes status.json from Docker; failures are only logged, since the file is
+// a convenience for scripts. When Docker cannot be asked the state is written as unknown, so
+// scripts do not read the state of the last session.
+func (s *Service) WriteStatus() {
+	status, err := s.Status()
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Writing an unknown state to %s: %v", storage.StatusFile, err))
+		status = &Status{Instance: s.Docker.GetInstanceName(), Image: s.Docker.GetImageName(), State: StateUnknown, URL: s.MoodleURL()}
+		status.ContainerID, _ = s.ContainerID()
+	}
+
+	snapshot := storage.InstanceStatus{
+		Instance:    status.Instance,
+		State:       status.State,
+		ContainerID: status.ContainerID,
+		Port:        s.Docker.GetHostPort(),
+		URL:         status.URL,
+		Image:       status.Image,
+		Health:      status.Health,
+	}
+	if status.Health != "" {
+		checkedAt := time.Now().UTC()
+		snapshot.LastHealthCheck = &checkedAt
+	}
+	if digests, err := s.Docker.GetLocalImageDigests(status.Image); err == nil && len(digests) > 0 {
+		snapshot.ImageDigest = digests[0]
+	}
+	if err := s.StatusFile.Save(snapshot); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to write %s: %v", storage.StatusFile, err))
+	}
+}
+
+// RecordHealth records a health check in status.json. A change of health, such as a
+// container that stopped or crashed outside the app, rewrites the whole status from Docker.
+func (s *Service) RecordHealth(health string) {
+	changed, err := s.StatusFile.RecordHealth(health, time.Now().UTC())
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to write %s: %v", storage.StatusFile, err))
+	}
+	if changed {
+		s.WriteStatus()
+	}
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"moodle-prototype-manager/docker"
+)
+
+func TestRecordHealthRewritesStatusOnChange(t *testing.T) {
+	service, fake := newTestService(t)
+	id := strings.Repeat("f", 64)
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage, Running: true, Health: docker.HealthHealthy})
+	if err := service.Files.SaveContainerID(id); err != nil {
+		t.Fatal(err)
+	}
+	service.WriteStatus()
+	service.RecordHealth(docker.HealthHealthy)
+
+	// The container crashes outside the app
+	fake.AddContainer(docker.FakeContainer{ID: id, Image: testImage})
+	service.RecordHealth(docker.HealthStopped)
+
+	status, err := service.StatusFile.Load()
+	if err != nil {
+		t.Fatalf("Failed to load status: %v", err)
+	}
+	if status.State != StateStopped || status.LastHealthCheck != nil {
+		t.Errorf("Expected a stopped container without a health check, got %+v", status)
+	}
+}
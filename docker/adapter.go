@@ -0,0 +1,264 @@
+package docker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/errors"
+)
+
+// AdapterEnv names the environment variables an image reads for the settings the manager
+// passes to new containers. An empty name means the image has no such setting, and the
+// setting is left out.
+type AdapterEnv struct {
+	ApacheLogLevel    string `json:"apacheLogLevel,omitempty"`
+	PHPErrorReporting string `json:"phpErrorReporting,omitempty"`
+	PHPDisplayErrors  string `json:"phpDisplayErrors,omitempty"`
+	MoodleDebug       string `json:"moodleDebug,omitempty"`
+	// SMTPHosts takes "host:port", as Moodle's smtphosts setting does
+	SMTPHosts string `json:"smtpHosts,omitempty"`
+	// AdminUser and AdminPassword set the admin login for images that do not generate one;
+	// the manager then chooses the password itself instead of reading it from the logs
+	AdminUser     string `json:"adminUser,omitempty"`
+	AdminPassword string `json:"adminPassword,omitempty"`
+}
+
+// ImageAdapter holds what the manager needs to know about a family of Moodle images: where
+// Moodle lives in the container, how it is configured and how its first-run install reports
+// the login
+type ImageAdapter struct {
+	Name string `json:"name"`
+	// Images are image reference prefixes the adapter is used for, e.g. "bitnami/moodle"
+	Images []string `json:"images"`
+	// ContainerPort is the port the web server listens on inside the container
+	ContainerPort int `json:"containerPort"`
+	// MoodleDir is the Moodle code root and DataDir the moodledata directory
+	MoodleDir string `json:"moodleDir"`
+	DataDir   string `json:"dataDir"`
+	// HealthPath is requested by the health check added to images without a HEALTHCHECK
+	HealthPath string `json:"healthPath"`
+	// ErrorLogs are the PHP and Apache error log files, which are not part of docker logs
+	ErrorLogs []string   `json:"errorLogs"`
+	Env       AdapterEnv `json:"env"`
+	// RequiredEnv are variables the container environment settings must set before the image
+	// can install, e.g. the connection to a database the image does not bring
+	RequiredEnv []string `json:"requiredEnv,omitempty"`
+	// Credentials finds the generated login in the install logs; nil relies on the generic
+	// patterns
+	Credentials *CredentialPatternSet `json:"-"`
+	// Ready matches the log line an image that does not print its login writes once the
+	// install has finished
+	Ready *regexp.Regexp `json:"-"`
+}
+
+// DefaultAdapterName is the adapter used for images no adapter names
+const DefaultAdapterName = "moodle-prototype"
+
+// appliesTo reports whether the adapter is meant for image
+func (a *ImageAdapter) appliesTo(image string) bool {
+	image = strings.ToLower(strings.TrimPrefix(image, "docker.io/"))
+	for _, prefix := range a.Images {
+		if strings.HasPrefix(image, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingEnv returns the RequiredEnv variables env does not set
+func (a *ImageAdapter) MissingEnv(env map[string]string) []string {
+	var missing []string
+	for _, name := range a.RequiredEnv {
+		if strings.TrimSpace(env[name]) == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// PresetsPassword reports whether the manager sets the admin password of new containers
+func (a *ImageAdapter) PresetsPassword() bool {
+	return a.Env.AdminPassword != ""
+}
+
+var (
+	adaptersMu sync.RWMutex
+	// registeredAdapters are added with RegisterImageAdapter, newest first
+	registeredAdapters []*ImageAdapter
+)
+
+// RegisterImageAdapter adds an adapter for custom images. Registered adapters take precedence
+// over the built-in ones for the images they name, and their credential patterns are
+// registered with them.
+func RegisterImageAdapter(adapter ImageAdapter) error {
+	if adapter.Name == "" || len(adapter.Images) == 0 {
+		return errors.NewValidationError("adapter", "an image adapter needs a name and at least one image", adapter.Name)
+	}
+	if adapter.ContainerPort <= 0 || adapter.MoodleDir == "" {
+		return errors.NewValidationError("adapter", "an image adapter needs a container port and a Moodle directory", adapter.Name)
+	}
+	if adapter.Credentials != nil {
+		set := *adapter.Credentials
+		if len(set.Images) == 0 {
+			set.Images = adapter.Images
+		}
+		RegisterCredentialPatterns(set)
+	}
+
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	registeredAdapters = append([]*ImageAdapter{&adapter}, registeredAdapters...)
+	return nil
+}
+
+// builtinAdapters returns the adapters for the images the manager supports out of the box.
+// The first one is the default.
+func builtinAdapters() []*ImageAdapter {
+	prototype := []string{"wenkhairu/moodle-prototype"}
+	bitnami := []string{"bitnami/moodle", "bitnamilegacy/moodle"}
+	return []*ImageAdapter{
+		{
+			Name:          DefaultAdapterName,
+			Images:        prototype,
+			ContainerPort: MoodleContainerPort,
+			MoodleDir:     MoodleDir,
+			DataDir:       "/var/www/moodledata",
+			HealthPath:    "/",
+			ErrorLogs:     []string{"/var/log/apache2/error.log", "/var/log/php_errors.log"},
+			Env: AdapterEnv{
+				ApacheLogLevel:    EnvApacheLogLevel,
+				PHPErrorReporting: EnvPHPErrorReporting,
+				PHPDisplayErrors:  EnvPHPDisplayErrors,
+				MoodleDebug:       EnvMoodleDebug,
+				SMTPHosts:         EnvMoodleSMTPHosts,
+			},
+			Credentials: builtinPatternSet("moodle-prototype"),
+		},
+		{
+			// bitnami/moodle installs with a login taken from its environment and brings no
+			// database, so it cannot start until the container environment settings point it
+			// at one
+			Name:          "bitnami",
+			Images:        bitnami,
+			ContainerPort: 8080,
+			MoodleDir:     "/opt/bitnami/moodle",
+			DataDir:       "/bitnami/moodledata",
+			HealthPath:    "/login/index.php",
+			ErrorLogs:     []string{"/opt/bitnami/apache/logs/error_log"},
+			Env: AdapterEnv{
+				AdminUser:     "MOODLE_USERNAME",
+				AdminPassword: "MOODLE_PASSWORD",
+			},
+			RequiredEnv: []string{"MOODLE_DATABASE_HOST", "MOODLE_DATABASE_USER", "MOODLE_DATABASE_PASSWORD", "MOODLE_DATABASE_NAME"},
+			Ready:       regexp.MustCompile(`(?i)\*\* Moodle setup finished! \*\*`),
+		},
+	}
+}
+
+// builtinPatternSet returns the built-in credential pattern set with the given name
+func builtinPatternSet(name string) *CredentialPatternSet {
+	for _, set := range builtinPatternSets() {
+		if set.Name == name {
+			return set
+		}
+	}
+	return nil
+}
+
+// AdapterFor returns the adapter for image: a registered one naming it, then a built-in one,
+// then the default
+func AdapterFor(image string) *ImageAdapter {
+	adaptersMu.RLock()
+	all := append(append([]*ImageAdapter{}, registeredAdapters...), builtinAdapters()...)
+	adaptersMu.RUnlock()
+
+	for _, adapter := range all {
+		if image != "" && adapter.appliesTo(image) {
+			return adapter
+		}
+	}
+	return builtinAdapters()[0]
+}
+
+// Adapter returns the adapter for the manager's image
+func (m *Manager) Adapter() *ImageAdapter {
+	return AdapterFor(m.imageName)
+}
+
+// moodleDir returns the Moodle code root in the manager's image
+func (m *Manager) moodleDir() string {
+	return m.Adapter().MoodleDir
+}
+
+// adapterArgs returns the `docker run` flags setting the admin login of images that take it
+// from the environment. A password in the container environment settings is used as is;
+// otherwise password is preset, or a new one generated when it is empty.
+func (m *Manager) adapterArgs(adapter *ImageAdapter, password string) ([]string, error) {
+	if !adapter.PresetsPassword() {
+		return nil, nil
+	}
+	args := make([]string, 0, 4)
+	if adapter.Env.AdminUser != "" {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", adapter.Env.AdminUser, "admin"))
+	}
+	if _, ok := m.containerEnv[adapter.Env.AdminPassword]; !ok {
+		if password == "" {
+			var err error
+			if password, err = generatePassword(); err != nil {
+				return nil, errors.WrapWithContext(err, "failed to generate the admin password")
+			}
+		}
+		args = append(args, "-e", fmt.Sprintf("%s=%s", adapter.Env.AdminPassword, password))
+	}
+	return args, nil
+}
+
+// PresetPassword returns the admin password the manager gave a container whose image takes it
+// from the environment, read back from the container so it survives a restart of the manager.
+// It is empty for images that generate their own.
+func (m *Manager) PresetPassword(containerID string) (string, error) {
+	adapter := m.Adapter()
+	if !adapter.PresetsPassword() {
+		return "", nil
+	}
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return "", errors.WrapWithContext(err, "invalid container ID provided to PresetPassword")
+	}
+
+	output, err := GetDockerCommand("inspect", "--format", "{{json .Config.Env}}", containerID).CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("inspect", containerID, err).WithOutput(string(output))
+		return "", errors.WrapWithContext(dockerErr, "failed to read the container environment")
+	}
+	var env []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &env); err != nil {
+		return "", errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected container environment: %v", err)
+	}
+	return envValue(env, adapter.Env.AdminPassword), nil
+}
+
+// envValue returns the last value of name in a NAME=value list, as the process sees it
+func envValue(env []string, name string) string {
+	value := ""
+	for _, entry := range env {
+		if key, val, ok := strings.Cut(entry, "="); ok && key == name {
+			value = val
+		}
+	}
+	return value
+}
+
+// generatePassword returns a random password Moodle's default password policy accepts
+func generatePassword() (string, error) {
+	raw := make([]byte, 9)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	// Digits, upper and lower case letters and a symbol satisfy every rule of the policy
+	return "Mp-" + hex.EncodeToString(raw) + "X7", nil
+}
@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAdapterFor(t *testing.T) {
+	cases := map[string]string{
+		"wenkhairu/moodle-prototype:502-stable": DefaultAdapterName,
+		"docker.io/bitnami/moodle:4.5":          "bitnami",
+		"bitnamilegacy/moodle:4.3.0":            "bitnami",
+		"registry.example.com/custom/moodle:1":  DefaultAdapterName,
+		"":                                      DefaultAdapterName,
+	}
+	for image, expected := range cases {
+		if adapter := AdapterFor(image); adapter.Name != expected {
+			t.Errorf("AdapterFor(%q) = %s, expected %s", image, adapter.Name, expected)
+		}
+	}
+}
+
+func TestRegisterImageAdapter(t *testing.T) {
+	t.Cleanup(func() {
+		adaptersMu.Lock()
+		registeredAdapters = nil
+		adaptersMu.Unlock()
+		patternSetsMu.Lock()
+		registeredPatternSets = nil
+		patternSetsMu.Unlock()
+	})
+
+	if err := RegisterImageAdapter(ImageAdapter{Name: "incomplete"}); err == nil {
+		t.Error("Expected an adapter without images to be rejected")
+	}
+	err := RegisterImageAdapter(ImageAdapter{
+		Name:          "custom",
+		Images:        []string{"registry.example.com/custom/moodle"},
+		ContainerPort: 80,
+		MoodleDir:     "/srv/moodle",
+	})
+	if err != nil {
+		t.Fatalf("RegisterImageAdapter failed: %v", err)
+	}
+
+	manager := &Manager{imageName: "registry.example.com/custom/moodle:1"}
+	if dir := manager.moodleDir(); dir != "/srv/moodle" {
+		t.Errorf("Expected the registered Moodle directory, got %s", dir)
+	}
+	if command := healthCheckCommand(manager.Adapter()); !strings.Contains(command, "localhost:80") {
+		t.Errorf("Expected the health check to use the registered port: %s", command)
+	}
+}
+
+func TestAdapterEnvArgs(t *testing.T) {
+	manager := &Manager{
+		imageName:   "bitnami/moodle:4.5",
+		logLevels:   LogLevels{Apache: "debug", Moodle: "developer"},
+		mailCatcher: true,
+	}
+	if args := manager.logLevelArgs(); len(args) != 0 {
+		t.Errorf("Expected no log level variables for bitnami, got %v", args)
+	}
+	if args := manager.mailArgs(); len(args) != 0 {
+		t.Errorf("Expected no SMTP variable for bitnami, got %v", args)
+	}
+
+	args, err := manager.adapterArgs(manager.Adapter(), "")
+	if err != nil {
+		t.Fatalf("adapterArgs failed: %v", err)
+	}
+	if !slices.Contains(args, "MOODLE_USERNAME=admin") {
+		t.Errorf("Expected the admin user to be set, got %v", args)
+	}
+	if !slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "MOODLE_PASSWORD=") }) {
+		t.Errorf("Expected a generated admin password, got %v", args)
+	}
+
+	// A password chosen in the container environment settings is kept
+	manager.SetContainerEnv(map[string]string{"MOODLE_PASSWORD": "chosen"})
+	args, _ = manager.adapterArgs(manager.Adapter(), "")
+	if slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "MOODLE_PASSWORD=") }) {
+		t.Errorf("Expected the configured password to be kept, got %v", args)
+	}
+
+	manager.imageName = "wenkhairu/moodle-prototype:502-stable"
+	if args := manager.logLevelArgs(); !slices.Contains(args, EnvApacheLogLevel+"=debug") {
+		t.Errorf("Expected the prototype log level variables, got %v", args)
+	}
+}
+
+func TestMissingEnv(t *testing.T) {
+	adapter := AdapterFor("bitnami/moodle:4.5")
+	env := map[string]string{"MOODLE_DATABASE_HOST": "mariadb", "MOODLE_DATABASE_USER": " "}
+	if missing := adapter.MissingEnv(env); !slices.Equal(missing, []string{"MOODLE_DATABASE_USER", "MOODLE_DATABASE_PASSWORD", "MOODLE_DATABASE_NAME"}) {
+		t.Errorf("Expected the unset database variables, got %v", missing)
+	}
+	if missing := AdapterFor("wenkhairu/moodle-prototype:502-stable").MissingEnv(nil); len(missing) != 0 {
+		t.Errorf("Expected the prototype image to need no variables, got %v", missing)
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "MOODLE_PASSWORD=first", "MOODLE_PASSWORD=a=b"}
+	if value := envValue(env, "MOODLE_PASSWORD"); value != "a=b" {
+		t.Errorf("Expected the last value, got %q", value)
+	}
+	if value := envValue(env, "MISSING"); value != "" {
+		t.Errorf("Expected no value, got %q", value)
+	}
+}
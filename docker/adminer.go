@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"fmt"
+	"net/url"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// AdminerImage is the database admin UI run alongside Moodle
+	AdminerImage = "adminer:latest"
+	// AdminerContainerPort is the port Adminer listens on inside its container
+	AdminerContainerPort = 8080
+	// DefaultAdminerPort is the host port Adminer is published on
+	DefaultAdminerPort = 8081
+)
+
+// AdminerInfo tells the user where Adminer is and which database to log in to
+type AdminerInfo struct {
+	URL      string `json:"url"`
+	Server   string `json:"server"`
+	Driver   string `json:"driver"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+}
+
+// AdminerContainerName returns the Adminer container name for an instance
+func AdminerContainerName(instance string) string {
+	return ContainerName(instance) + "-adminer"
+}
+
+// SetAdminer enables running Adminer alongside the Moodle container
+func (m *Manager) SetAdminer(enabled bool, port int) {
+	if port <= 0 {
+		port = DefaultAdminerPort
+	}
+	m.adminer = enabled
+	m.adminerPort = port
+}
+
+// GetAdminer reports whether the Adminer sidecar is enabled
+func (m *Manager) GetAdminer() bool {
+	return m.adminer
+}
+
+// StartAdminer runs Adminer on the instance network, pre-filled with Moodle's database details
+func (m *Manager) StartAdminer(containerID string) (*AdminerInfo, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to StartAdminer")
+	}
+
+	info := &AdminerInfo{Server: moodleHostAlias, Driver: "server"}
+	// Before Moodle is installed there is no config.php; Adminer still starts with a blank login
+	if site, err := m.GetSiteConfig(containerID); err == nil {
+		info.Database = site.DBName
+		info.Username = site.DBUser
+		if site.DBType == "pgsql" {
+			info.Driver = "pgsql"
+		}
+		// A database on the Moodle container is reached through its network alias
+		if !isLocalDBHost(site.DBHost) {
+			info.Server = site.DBHost
+		}
+	} else {
+		utils.LogWarning(fmt.Sprintf("Starting Adminer without database defaults: %v", err))
+	}
+
+	network, err := m.joinInstanceNetwork(containerID)
+	if err != nil {
+		return nil, err
+	}
+	name := AdminerContainerName(m.GetInstanceName())
+	utils.LogInfo(fmt.Sprintf("Starting Adminer %s", name))
+	GetDockerCommand("rm", "-f", name).CombinedOutput()
+
+	cmd := GetDockerCommand("run", "-d", "--name", name,
+		"--network", network,
+		"-p", fmt.Sprintf("%d:%d", m.adminerPortOrDefault(), AdminerContainerPort),
+		"-e", "ADMINER_DEFAULT_SERVER="+info.Server,
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		AdminerImage)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", AdminerImage, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to start Adminer")
+	}
+
+	info.URL = m.adminerURL(info)
+	return info, nil
+}
+
+// StopAdminer removes the Adminer container
+func (m *Manager) StopAdminer() {
+	name := AdminerContainerName(m.GetInstanceName())
+	if output, err := GetDockerCommand("rm", "-f", name).CombinedOutput(); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to remove Adminer %s: %v (%s)", name, err, output))
+	}
+}
+
+// adminerURL builds a login URL with the driver, server, user and database filled in
+func (m *Manager) adminerURL(info *AdminerInfo) string {
+	query := url.Values{}
+	query.Set(info.Driver, info.Server)
+	if info.Username != "" {
+		query.Set("username", info.Username)
+	}
+	if info.Database != "" {
+		query.Set("db", info.Database)
+	}
+	return fmt.Sprintf("http://%s:%d/?%s", DockerHostAddress(), m.adminerPortOrDefault(), query.Encode())
+}
+
+// adminerPortOrDefault returns the configured Adminer host port
+func (m *Manager) adminerPortOrDefault() int {
+	if m.adminerPort <= 0 {
+		return DefaultAdminerPort
+	}
+	return m.adminerPort
+}
+
+// isLocalDBHost reports whether Moodle's database runs inside the Moodle container
+func isLocalDBHost(host string) bool {
+	return host == "" || host == "localhost" || host == "127.0.0.1"
+}
@@ -0,0 +1,19 @@
+package docker
+
+import "testing"
+
+func TestAdminerURL(t *testing.T) {
+	manager := NewManager()
+	manager.SetAdminer(true, 0)
+
+	url := manager.adminerURL(&AdminerInfo{Driver: "pgsql", Server: "moodle", Database: "moodle", Username: "moodleuser"})
+	expected := "http://localhost:8081/?db=moodle&pgsql=moodle&username=moodleuser"
+	if url != expected {
+		t.Errorf("Expected %s, got %s", expected, url)
+	}
+
+	url = manager.adminerURL(&AdminerInfo{Driver: "server", Server: "moodle"})
+	if url != "http://localhost:8081/?server=moodle" {
+		t.Errorf("Expected blank login URL, got %s", url)
+	}
+}
@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+)
+
+// verifyAdminPasswordScript checks candidate passwords against the main admin's password hash.
+// The candidates arrive as a base64-encoded JSON array; the index of the first match is printed.
+const verifyAdminPasswordScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+
+$candidates = json_decode(base64_decode('%s'), true);
+$admin = get_admin();
+if (!$admin) {
+    fwrite(STDERR, "no admin user found\n");
+    exit(1);
+}
+foreach ($candidates as $index => $password) {
+    if (validate_internal_user_password($admin, $password)) {
+        echo "MATCH:" . $index . "\n";
+        exit(0);
+    }
+}
+echo "NOMATCH\n";
+`
+
+// resetAdminPasswordScript sets the main admin's password to the base64-encoded one given
+const resetAdminPasswordScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+
+$admin = get_admin();
+if (!$admin) {
+    fwrite(STDERR, "no admin user found\n");
+    exit(1);
+}
+if (!update_internal_user_password($admin, base64_decode('%s'))) {
+    fwrite(STDERR, "failed to update the admin password\n");
+    exit(1);
+}
+echo "RESET\n";
+`
+
+// VerifyAdminPassword returns the first of candidates that is the admin account's current
+// password, or "" when none is
+func (m *Manager) VerifyAdminPassword(containerID string, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(candidates)
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to encode candidate passwords")
+	}
+	output, err := m.RunPHPScript(containerID, fmt.Sprintf(verifyAdminPasswordScript, base64.StdEncoding.EncodeToString(encoded)))
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to verify the admin password")
+	}
+
+	index, err := parsePasswordMatch(output, len(candidates))
+	if err != nil || index < 0 {
+		return "", err
+	}
+	return candidates[index], nil
+}
+
+// parsePasswordMatch reads the MATCH:<index> or NOMATCH line printed by
+// verifyAdminPasswordScript; -1 means no candidate matched
+func parsePasswordMatch(output string, candidates int) (int, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "NOMATCH" {
+			return -1, nil
+		}
+		value, found := strings.CutPrefix(line, "MATCH:")
+		if !found {
+			continue
+		}
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= candidates {
+			break
+		}
+		return index, nil
+	}
+	return -1, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while verifying the admin password: %s", LastLogLines(output, 5))
+}
+
+// ResetAdminPassword gives the admin account a new random password and returns it, e.g. for
+// a site restored from an archive that does not carry its password. The password is piped to
+// PHP on stdin, so it never shows up in a process list.
+func (m *Manager) ResetAdminPassword(containerID string) (string, error) {
+	password, err := generatePassword()
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to generate an admin password")
+	}
+	output, err := m.RunPHPScript(containerID, fmt.Sprintf(resetAdminPasswordScript, base64.StdEncoding.EncodeToString([]byte(password))))
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to reset the admin password")
+	}
+	if !strings.Contains(output, "RESET") {
+		return "", errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while resetting the admin password: %s", LastLogLines(output, 5))
+	}
+	return password, nil
+}
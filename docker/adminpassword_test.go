@@ -0,0 +1,17 @@
+package docker
+
+import "testing"
+
+func TestParsePasswordMatch(t *testing.T) {
+	if index, err := parsePasswordMatch("PHP Notice: debug\nMATCH:1\n", 2); err != nil || index != 1 {
+		t.Errorf("Expected candidate 1, got %d (%v)", index, err)
+	}
+	if index, err := parsePasswordMatch("NOMATCH\n", 2); err != nil || index != -1 {
+		t.Errorf("Expected no match, got %d (%v)", index, err)
+	}
+	for _, output := range []string{"MATCH:5\n", "PHP Fatal error: config.php missing"} {
+		if _, err := parsePasswordMatch(output, 2); err == nil {
+			t.Errorf("Expected %q to be rejected", output)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// CleanupReport lists what EmergencyCleanup removed, by ID or name
+type CleanupReport struct {
+	Containers []string `json:"containers"`
+	Volumes    []string `json:"volumes"`
+	Networks   []string `json:"networks"`
+	// Images are snapshot images, which are useless once their volumes are gone
+	Images []string `json:"images"`
+	// Failures describes what could not be removed
+	Failures []string `json:"failures"`
+}
+
+// Removed returns how many resources were removed
+func (r *CleanupReport) Removed() int {
+	return len(r.Containers) + len(r.Volumes) + len(r.Networks) + len(r.Images)
+}
+
+// EmergencyCleanup force-removes every container, volume, network and snapshot image this
+// app labelled for the current user, whichever version of the app created them: Moodle
+// containers of every instance, sidecars, test browsers and snapshots. Releases before the
+// labels ran the image without a name or labels, so unlabelled containers of the manager's
+// image and the tracked containerIDs, e.g. the stored container ID, are removed as well.
+// Containers go first so their volumes and networks are no longer in use. Other users'
+// labelled resources and the Moodle images are kept. Resources that cannot be removed are
+// listed in Failures; an error is only returned when Docker cannot be asked at all.
+func (m *Manager) EmergencyCleanup(containerIDs ...string) (*CleanupReport, error) {
+	report := &CleanupReport{
+		Containers: make([]string, 0),
+		Volumes:    make([]string, 0),
+		Networks:   make([]string, 0),
+		Images:     make([]string, 0),
+		Failures:   make([]string, 0),
+	}
+
+	labelled := func(list ...string) func() ([]string, error) {
+		return func() ([]string, error) { return m.listLabelled(list) }
+	}
+	steps := []struct {
+		kind    string
+		list    func() ([]string, error)
+		remove  []string
+		removed *[]string
+	}{
+		{"container", func() ([]string, error) { return m.listCleanupContainers(containerIDs) }, []string{"rm", "-f", "-v"}, &report.Containers},
+		{"network", labelled("network", "ls", "-q"), []string{"network", "rm"}, &report.Networks},
+		{"volume", labelled("volume", "ls", "-q"), []string{"volume", "rm", "-f"}, &report.Volumes},
+		{"image", labelled("image", "ls", "-q"), []string{"image", "rm", "-f"}, &report.Images},
+	}
+	for i, step := range steps {
+		ids, err := step.list()
+		if err != nil {
+			// Without the container list nothing is known to be safe to remove
+			if i == 0 {
+				return nil, err
+			}
+			report.Failures = append(report.Failures, fmt.Sprintf("could not list %ss: %v", step.kind, err))
+			continue
+		}
+
+		for _, id := range ids {
+			output, err := GetDockerCommand(append(append([]string{}, step.remove...), id)...).CombinedOutput()
+			if err != nil {
+				dockerErr := errors.NewDockerError(step.kind+"_rm", err).WithOutput(string(output))
+				utils.LogError(fmt.Sprintf("Failed to remove %s %s", step.kind, id), dockerErr)
+				report.Failures = append(report.Failures, fmt.Sprintf("%s %s: %s", step.kind, id, strings.TrimSpace(string(output))))
+				continue
+			}
+			*step.removed = append(*step.removed, id)
+		}
+	}
+
+	utils.LogInfo(fmt.Sprintf("Emergency cleanup removed %d container(s), %d volume(s), %d network(s) and %d image(s); %d failure(s)",
+		len(report.Containers), len(report.Volumes), len(report.Networks), len(report.Images), len(report.Failures)))
+	return report, nil
+}
+
+// listLabelled runs a docker ls command filtered to this app and user, returning the IDs or
+// names it prints
+func (m *Manager) listLabelled(list []string) ([]string, error) {
+	args := append(append([]string{}, list...),
+		"--filter", fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		"--filter", fmt.Sprintf("label=%s=%s", LabelUser, m.userName),
+	)
+	output, err := GetDockerCommand(args...).CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError(strings.Join(list[:2], "_"), err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list labelled resources")
+	}
+	return parseIDList(string(output)), nil
+}
+
+// listCleanupContainers returns the labelled containers of this app and user, the unlabelled
+// containers of the manager's image and those of containerIDs that exist, by short ID
+func (m *Manager) listCleanupContainers(containerIDs []string) ([]string, error) {
+	ids, err := m.listLabelled([]string{"container", "ls", "-a", "-q"})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.imageName != "" {
+		format := fmt.Sprintf("{{.ID}}\t{{.Label %q}}", LabelApp)
+		output, err := GetDockerCommand("container", "ls", "-a", "--filter", "ancestor="+m.imageName, "--format", format).CombinedOutput()
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Could not list unlabelled containers of %s: %v (%s)", m.imageName, err, strings.TrimSpace(string(output))))
+		} else {
+			ids = append(ids, parseUnlabelledIDs(string(output))...)
+		}
+	}
+
+	for _, id := range containerIDs {
+		if id == "" {
+			continue
+		}
+		output, err := GetDockerCommand("container", "ls", "-a", "-q", "--filter", "id="+id).CombinedOutput()
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Could not look up container %s: %v (%s)", id, err, strings.TrimSpace(string(output))))
+			continue
+		}
+		ids = append(ids, parseIDList(string(output))...)
+	}
+	return parseIDList(strings.Join(ids, "\n")), nil
+}
+
+// parseUnlabelledIDs returns the IDs of "ID<tab>app label" lines whose app label is empty
+func parseUnlabelledIDs(output string) []string {
+	ids := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		id, label, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if id != "" && strings.TrimSpace(label) == "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseIDList splits docker ls -q output into IDs, dropping blanks and the repeats an image
+// with several tags produces
+func parseIDList(output string) []string {
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		id := strings.TrimSpace(line)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,25 @@
+package docker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseIDList(t *testing.T) {
+	// An image with two tags is listed twice
+	ids := parseIDList("3f1c2a9b7d4e\n\n  9a8b7c6d5e4f \n3f1c2a9b7d4e\n")
+	if !slices.Equal(ids, []string{"3f1c2a9b7d4e", "9a8b7c6d5e4f"}) {
+		t.Errorf("Unexpected IDs: %v", ids)
+	}
+	if ids := parseIDList(""); len(ids) != 0 {
+		t.Errorf("Expected no IDs from empty output, got %v", ids)
+	}
+}
+
+func TestParseUnlabelledIDs(t *testing.T) {
+	// The baseline release ran containers without labels; labelled ones may be another user's
+	output := "3f1c2a9b7d4e\t\n9a8b7c6d5e4f\tmoodle-prototype-manager\n\n1b2c3d4e5f6a\n"
+	if ids := parseUnlabelledIDs(output); !slices.Equal(ids, []string{"3f1c2a9b7d4e", "1b2c3d4e5f6a"}) {
+		t.Errorf("Unexpected IDs: %v", ids)
+	}
+}
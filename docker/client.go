@@ -0,0 +1,68 @@
+package docker
+
+import "time"
+
+// Client is the part of Manager the core service and the CLI drive the Moodle container
+// through. Manager implements it with the docker CLI; FakeClient implements it in memory so
+// start, stop and install flows can be tested without a Docker daemon.
+type Client interface {
+	// Configuration
+	GetImageName() string
+	SetImageName(imageName string)
+	GetInstanceName() string
+	SetInstanceName(instance string)
+	GetHostPort() int
+	SetHostPort(port int)
+	GetUserName() string
+	SetMemoryLimit(bytes int64)
+	SetStopTimeout(timeout time.Duration)
+	SetRestartPolicy(policy string) error
+	SetLogLevels(levels LogLevels) error
+	SetContainerEnv(env map[string]string)
+	SetMailCatcher(enabled bool, uiPort int)
+	GetMailCatcher() bool
+	SetAdminer(enabled bool, port int)
+	GetAdminer() bool
+	SetRegistryCache(cache *RegistryCache)
+
+	// Images
+	CheckImageExists() (bool, error)
+	PullImage() error
+	PullImageWithProgress(progressCallback func(float64, string)) error
+	GetLocalImageDigests(image string) ([]string, error)
+
+	// Container lifecycle
+	RunContainer() (string, error)
+	StartContainer(containerID string) error
+	StopContainerWithEscalation(containerID string, progressCallback func(StopProgress)) error
+	ForceStopContainer(containerID string) error
+	ValidateContainerID(containerID string) error
+	IsContainerRunning(containerID string) (bool, error)
+	GetContainerHealth(containerID string) (*HealthReport, error)
+	CheckInstallContainer(containerID, logs string) (*InstallFailure, error)
+	PresetPassword(containerID string) (string, error)
+	ResetAdminPassword(containerID string) (string, error)
+	FindInstanceContainer() (*ManagedContainer, error)
+	ListManagedContainers() ([]ManagedContainer, error)
+	MeasureWorkspaceUsage(container ManagedContainer) (*WorkspaceUsage, error)
+	RemoveContainer(containerID string, force bool) error
+	RemoveContainerAndVolumes(containerID string) ([]string, error)
+
+	// Sidecars
+	StartMailCatcher(containerID string) error
+	StopMailCatcher()
+	StartAdminer(containerID string) (*AdminerInfo, error)
+	StopAdminer()
+
+	// Logs
+	FetchContainerLogs(containerID string, opts LogFetchOptions) (*LogChunk, error)
+	NewLogCursor(containerID string, initial LogFetchOptions) *LogCursor
+	FollowContainerLogs(containerID string, tail int, onLine func(string)) (*LogFollower, error)
+
+	// Moodle inside the container
+	GetWWWRoot(containerID string) (string, error)
+	ConfigureWWWRoot(containerID, wwwroot string, sslproxy bool) error
+	ConfigureSite(containerID, fullName, shortName, adminEmail string) error
+	SeedDemoData(containerID, size string, progressCallback func(float64, string)) (*SeedResult, error)
+	ExportInstance(containerID, path string, manifest InstanceManifest, progressCallback func(float64, string)) (*InstanceArchive, error)
+}
@@ -0,0 +1,260 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// Command is a docker CLI invocation that records its duration, exit code and output size
+// in the performance stats when it runs. It is used like an *exec.Cmd. Run, Output and
+// CombinedOutput go through utils.Commands like every other program the app runs; Start and
+// Wait, used to stream a command's output, run it directly.
+type Command struct {
+	*exec.Cmd
+	kind    string
+	started time.Time
+	output  atomic.Int64
+}
+
+// Run runs the command and waits for it to finish
+func (c *Command) Run() error {
+	c.countWriters()
+	c.started = time.Now()
+	err := utils.Commands().Run(c.Cmd)
+	c.record(err)
+	return err
+}
+
+// Start starts the command without waiting for it
+func (c *Command) Start() error {
+	c.countWriters()
+	c.started = time.Now()
+	if err := c.Cmd.Start(); err != nil {
+		c.record(err)
+		return err
+	}
+	return nil
+}
+
+// Wait waits for a started command to exit
+func (c *Command) Wait() error {
+	err := c.Cmd.Wait()
+	c.record(err)
+	return err
+}
+
+// Output runs the command and returns its standard output
+func (c *Command) Output() ([]byte, error) {
+	c.started = time.Now()
+	output, err := utils.Commands().Output(c.Cmd)
+	c.output.Add(int64(len(output)))
+	c.record(err)
+	return output, err
+}
+
+// CombinedOutput runs the command and returns its standard output and error
+func (c *Command) CombinedOutput() ([]byte, error) {
+	c.started = time.Now()
+	output, err := utils.Commands().CombinedOutput(c.Cmd)
+	c.output.Add(int64(len(output)))
+	c.record(err)
+	return output, err
+}
+
+// countWriters wraps the command's writers to count its output
+func (c *Command) countWriters() {
+	// A writer shared by stdout and stderr must stay shared: exec only serialises writes to it
+	// when both fields hold the same value
+	shared := c.Stdout != nil && sameWriter(c.Stdout, c.Stderr)
+	c.Stdout = c.countingWriter(c.Stdout)
+	if shared {
+		c.Stderr = c.Stdout
+	} else {
+		c.Stderr = c.countingWriter(c.Stderr)
+	}
+}
+
+// countingWriter counts what the command writes to w. Pipes from StdoutPipe and StderrPipe
+// are left alone: exec closes its end after start, so it cannot be wrapped.
+func (c *Command) countingWriter(w io.Writer) io.Writer {
+	switch w.(type) {
+	case nil, *os.File, *countWriter:
+		return w
+	}
+	return &countWriter{w: w, n: &c.output}
+}
+
+// record adds the finished invocation to the performance stats
+func (c *Command) record(err error) {
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	commandStats.record(c.kind, time.Since(c.started), exitCode, c.output.Load())
+}
+
+// sameWriter compares writers without panicking on uncomparable types
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// countWriter counts the bytes written through it
+type countWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+// managementCommands are docker commands whose subcommand names the operation, e.g. `image inspect`
+var managementCommands = map[string]bool{
+	"builder": true, "buildx": true, "compose": true, "container": true, "context": true,
+	"image": true, "network": true, "system": true, "volume": true,
+}
+
+// commandKind names the operation of a docker invocation for grouping stats, e.g. "ps" or "image inspect"
+func commandKind(args []string) string {
+	if len(args) == 0 {
+		return "docker"
+	}
+	if managementCommands[args[0]] && len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		return args[0] + " " + args[1]
+	}
+	return args[0]
+}
+
+// CommandStats summarises the invocations of one kind of docker command
+type CommandStats struct {
+	Command     string `json:"command"`
+	Count       int    `json:"count"`
+	Failures    int    `json:"failures"`
+	TotalMs     int64  `json:"totalMs"`
+	AverageMs   int64  `json:"averageMs"`
+	MaxMs       int64  `json:"maxMs"`
+	LastMs      int64  `json:"lastMs"`
+	OutputBytes int64  `json:"outputBytes"`
+	// ExitCodes counts invocations per exit code; -1 means the command could not be started
+	ExitCodes map[int]int `json:"exitCodes"`
+}
+
+// PerformanceStats is the timing of docker commands since startup or the last reset
+type PerformanceStats struct {
+	Since         time.Time `json:"since"`
+	TotalCommands int       `json:"totalCommands"`
+	TotalMs       int64     `json:"totalMs"`
+	// Commands is sorted by total time, slowest first
+	Commands []CommandStats `json:"commands"`
+}
+
+// performanceStats collects CommandStats per command kind
+type performanceStats struct {
+	mu       sync.Mutex
+	since    time.Time
+	commands map[string]*CommandStats
+}
+
+var commandStats = &performanceStats{since: time.Now(), commands: make(map[string]*CommandStats)}
+
+// record adds one invocation
+func (s *performanceStats) record(kind string, duration time.Duration, exitCode int, outputBytes int64) {
+	ms := duration.Milliseconds()
+	utils.LogDebug(fmt.Sprintf("docker %s took %dms (exit code %d, %d bytes of output)", kind, ms, exitCode, outputBytes))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.commands[kind]
+	if !ok {
+		stats = &CommandStats{Command: kind, ExitCodes: make(map[int]int)}
+		s.commands[kind] = stats
+	}
+	stats.Count++
+	if exitCode != 0 {
+		stats.Failures++
+	}
+	stats.TotalMs += ms
+	stats.AverageMs = stats.TotalMs / int64(stats.Count)
+	if ms > stats.MaxMs {
+		stats.MaxMs = ms
+	}
+	stats.LastMs = ms
+	stats.OutputBytes += outputBytes
+	stats.ExitCodes[exitCode]++
+}
+
+// snapshot copies the collected stats
+func (s *performanceStats) snapshot() PerformanceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := PerformanceStats{Since: s.since, Commands: make([]CommandStats, 0, len(s.commands))}
+	for _, stats := range s.commands {
+		copied := *stats
+		copied.ExitCodes = make(map[int]int, len(stats.ExitCodes))
+		for code, count := range stats.ExitCodes {
+			copied.ExitCodes[code] = count
+		}
+		result.Commands = append(result.Commands, copied)
+		result.TotalCommands += stats.Count
+		result.TotalMs += stats.TotalMs
+	}
+	sort.Slice(result.Commands, func(i, j int) bool {
+		if result.Commands[i].TotalMs != result.Commands[j].TotalMs {
+			return result.Commands[i].TotalMs > result.Commands[j].TotalMs
+		}
+		return result.Commands[i].Command < result.Commands[j].Command
+	})
+	return result
+}
+
+// GetPerformanceStats returns the timing of docker commands since startup or the last reset
+func GetPerformanceStats() PerformanceStats {
+	return commandStats.snapshot()
+}
+
+// ResetPerformanceStats clears the collected docker command timings
+func ResetPerformanceStats() {
+	commandStats.mu.Lock()
+	defer commandStats.mu.Unlock()
+	commandStats.since = time.Now()
+	commandStats.commands = make(map[string]*CommandStats)
+}
+
+// LogPerformanceSummary writes the slowest docker command kinds to the log, for diagnostics
+func LogPerformanceSummary(limit int) {
+	stats := GetPerformanceStats()
+	if stats.TotalCommands == 0 {
+		return
+	}
+	utils.LogInfo(fmt.Sprintf("Docker command timings since %s: %d commands, %dms total",
+		stats.Since.Format(time.RFC3339), stats.TotalCommands, stats.TotalMs))
+	for i, command := range stats.Commands {
+		if i == limit {
+			break
+		}
+		utils.LogInfo(fmt.Sprintf("  docker %s: %d runs, %d failed, avg %dms, max %dms, %s output",
+			command.Command, command.Count, command.Failures, command.AverageMs, command.MaxMs, FormatBytes(uint64(command.OutputBytes))))
+	}
+}
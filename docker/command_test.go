@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+func TestCommandKind(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"ps", "-aq", "--no-trunc"}, "ps"},
+		{[]string{"image", "inspect", "--format", "{{.Id}}", "moodle"}, "image inspect"},
+		{[]string{"system", "df"}, "system df"},
+		{[]string{"image", "--help"}, "image"},
+		{[]string{"exec", "moodle", "php", "admin/cli/cron.php"}, "exec"},
+		{nil, "docker"},
+	}
+	for _, c := range cases {
+		if got := commandKind(c.args); got != c.want {
+			t.Errorf("commandKind(%v) = %q, want %q", c.args, got, c.want)
+		}
+	}
+}
+
+func TestPerformanceStatsSortsSlowestFirst(t *testing.T) {
+	stats := &performanceStats{commands: make(map[string]*CommandStats)}
+	stats.record("ps", 10*time.Millisecond, 0, 100)
+	stats.record("ps", 30*time.Millisecond, 1, 0)
+	stats.record("image inspect", 200*time.Millisecond, 0, 2048)
+
+	snapshot := stats.snapshot()
+	if snapshot.TotalCommands != 3 || len(snapshot.Commands) != 2 {
+		t.Fatalf("Unexpected totals: %+v", snapshot)
+	}
+	if snapshot.Commands[0].Command != "image inspect" {
+		t.Errorf("Expected the slowest command first, got %+v", snapshot.Commands)
+	}
+	ps := snapshot.Commands[1]
+	if ps.AverageMs != 20 || ps.MaxMs != 30 || ps.Failures != 1 || ps.ExitCodes[1] != 1 || ps.OutputBytes != 100 {
+		t.Errorf("Unexpected ps stats: %+v", ps)
+	}
+}
+
+func TestDockerCommandsUseCommandRunner(t *testing.T) {
+	runner := &recordingRunner{}
+	previous := utils.SetCommandRunner(runner)
+	defer utils.SetCommandRunner(previous)
+
+	output, err := GetDockerCommand("version", "--format", "{{.Server.Version}}").Output()
+	if err != nil || string(output) != "27.0.3\n" {
+		t.Fatalf("Expected the runner's output, got %q (%v)", output, err)
+	}
+	if len(runner.commands) != 1 || runner.commands[0][1] != "version" {
+		t.Errorf("Expected the docker command to go through the runner, got %v", runner.commands)
+	}
+}
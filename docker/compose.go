@@ -0,0 +1,227 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// instanceComposeTemplate reproduces the instance as the manager runs it. Service names
+// match the network aliases, so sidecars reach each other exactly as they do in the app.
+const instanceComposeTemplate = `# Generated by Moodle Prototype Manager on {{.Generated}}
+# Reproduces instance {{quote .Instance}}, including its admin password and proxy settings.
+services:
+  moodle:
+    image: {{quote .Image}}
+    container_name: {{quote .ContainerName}}
+    ports:
+      - "{{.HostPort}}:{{.ContainerPort}}"
+    restart: {{quote .Restart}}
+    stop_grace_period: {{.StopGracePeriod}}
+{{- if .MemoryLimit}}
+    mem_limit: {{.MemoryLimit}}
+{{- end}}
+    extra_hosts:
+      - "{{.HostGateway}}:host-gateway"
+{{- if .Env}}
+    environment:
+{{- range .Env}}
+      - {{quote .}}
+{{- end}}
+{{- end}}
+{{- if .Volumes}}
+    volumes:
+{{- range .Volumes}}
+      - {{quote .}}
+{{- end}}
+{{- end}}
+{{- if .Mail}}
+
+  mail:
+    image: {{quote .MailImage}}
+    ports:
+      - "{{.MailUIPort}}:{{.MailUIContainerPort}}"
+{{- end}}
+{{- if .Adminer}}
+
+  adminer:
+    image: {{quote .AdminerImage}}
+    ports:
+      - "{{.AdminerPort}}:{{.AdminerContainerPort}}"
+    environment:
+      - "ADMINER_DEFAULT_SERVER=moodle"
+{{- end}}
+{{- if .NamedVolumes}}
+
+volumes:
+{{- range .NamedVolumes}}
+  {{.}}:
+    external: true
+{{- end}}
+{{- end}}
+`
+
+// containerMount mirrors the parts of `docker inspect` .Mounts we export
+type containerMount struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+}
+
+// composeData feeds instanceComposeTemplate
+type composeData struct {
+	Generated            string
+	Instance             string
+	Image                string
+	ContainerName        string
+	HostPort             int
+	ContainerPort        int
+	Restart              string
+	StopGracePeriod      string
+	MemoryLimit          string
+	HostGateway          string
+	Env                  []string
+	Volumes              []string
+	NamedVolumes         []string
+	Mail                 bool
+	MailImage            string
+	MailUIPort           int
+	MailUIContainerPort  int
+	Adminer              bool
+	AdminerImage         string
+	AdminerPort          int
+	AdminerContainerPort int
+}
+
+// ExportCompose writes a docker-compose.yml reproducing the instance: image, ports,
+// environment, limits, sidecars and, when containerID is set, its volumes and the admin
+// password it was given
+func (m *Manager) ExportCompose(containerID, outPath string) error {
+	if err := errors.ValidateFilePath("outPath", outPath); err != nil {
+		return err
+	}
+
+	var mounts []containerMount
+	var password string
+	if containerID != "" {
+		var err error
+		if mounts, err = m.containerMounts(containerID); err != nil {
+			return err
+		}
+		if password, err = m.PresetPassword(containerID); err != nil {
+			return err
+		}
+	}
+
+	content, err := m.renderCompose(mounts, password)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(outPath), err)
+	}
+	if err := os.WriteFile(outPath, []byte(content), 0644); err != nil {
+		return errors.NewFileError("write", outPath, err)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Compose file for instance %s written to %s", m.GetInstanceName(), outPath))
+	return nil
+}
+
+// renderCompose renders the compose file for the manager's current configuration. The
+// environment is the one RunContainerWithVolumes passes, with password as the preset admin
+// password of images that take it (a new one when empty).
+func (m *Manager) renderCompose(mounts []containerMount, password string) (string, error) {
+	envArgs, err := m.moodleEnvArgs(m.Adapter(), password)
+	if err != nil {
+		return "", err
+	}
+	data := composeData{
+		Generated:            time.Now().Format(time.RFC3339),
+		Instance:             m.GetInstanceName(),
+		Image:                m.imageName,
+		ContainerName:        ContainerName(m.GetInstanceName()),
+		HostPort:             m.hostPort,
+		ContainerPort:        m.Adapter().ContainerPort,
+		Restart:              m.GetRestartPolicy(),
+		StopGracePeriod:      fmt.Sprintf("%ds", int(m.GetStopTimeout().Seconds())),
+		HostGateway:          HostGatewayName,
+		Env:                  envFromArgs(envArgs),
+		Mail:                 m.mailCatcher,
+		MailImage:            MailCatcherImage,
+		MailUIPort:           m.mailUIPortOrDefault(),
+		MailUIContainerPort:  MailUIContainerPort,
+		Adminer:              m.adminer,
+		AdminerImage:         AdminerImage,
+		AdminerPort:          m.adminerPortOrDefault(),
+		AdminerContainerPort: AdminerContainerPort,
+	}
+	if m.memoryLimit > 0 {
+		data.MemoryLimit = fmt.Sprintf("%dm", m.memoryLimit/(1024*1024))
+	}
+
+	for _, mount := range mounts {
+		switch mount.Type {
+		case "volume":
+			data.Volumes = append(data.Volumes, mount.Name+":"+mount.Destination)
+			data.NamedVolumes = append(data.NamedVolumes, mount.Name)
+		case "bind":
+			data.Volumes = append(data.Volumes, mount.Source+":"+mount.Destination)
+		}
+	}
+
+	tmpl, err := template.New("compose").Funcs(template.FuncMap{"quote": strconv.Quote}).
+		Parse(bundle.Text(bundle.InstanceComposeTemplate, instanceComposeTemplate))
+	if err != nil {
+		return "", errors.WrapWithContext(err, "invalid compose template")
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", errors.WrapWithContext(err, "failed to render compose file")
+	}
+	return out.String(), nil
+}
+
+// containerMounts returns the volumes and bind mounts of a container
+func (m *Manager) containerMounts(containerID string) ([]containerMount, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to containerMounts")
+	}
+
+	cmd := GetDockerCommand("inspect", "--format", "{{json .Mounts}}", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("inspect", containerID, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to read container mounts")
+	}
+
+	var mounts []containerMount
+	if err := json.Unmarshal(output, &mounts); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected mounts output: %v", err)
+	}
+	return mounts, nil
+}
+
+// envFromArgs extracts KEY=VALUE pairs from `-e` flags
+func envFromArgs(args []string) []string {
+	env := make([]string, 0)
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-e" {
+			env = append(env, args[i+1])
+			i++
+		}
+	}
+	return env
+}
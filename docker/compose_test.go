@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderCompose(t *testing.T) {
+	manager := NewManager()
+	manager.SetImageName("wenkhairu/moodle-prototype:502-stable")
+	manager.SetInstanceName("demo")
+	manager.SetHostPort(8085)
+	manager.SetMemoryLimit(2048 * 1024 * 1024)
+	manager.SetMailCatcher(true, 0)
+	if err := manager.SetLogLevels(LogLevels{Moodle: "developer"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	SetProxyConfig(ProxyConfig{HTTPProxy: "http://proxy.example.com:3128"})
+	defer SetProxyConfig(ProxyConfig{})
+
+	content, err := manager.renderCompose([]containerMount{
+		{Type: "volume", Name: "moodledata", Destination: "/var/www/moodledata"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		`image: "wenkhairu/moodle-prototype:502-stable"`,
+		`container_name: "moodle-prototype-demo"`,
+		`- "8085:8080"`,
+		`mem_limit: 2048m`,
+		`- "MOODLE_DEBUG=developer"`,
+		`- "MOODLE_SMTP_HOSTS=mail:1025"`,
+		`- "HTTP_PROXY=http://proxy.example.com:3128"`,
+		`- "moodledata:/var/www/moodledata"`,
+		"  mail:\n",
+		"  moodledata:\n    external: true",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in compose file:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(content, "adminer:") {
+		t.Error("Expected no adminer service when it is disabled")
+	}
+}
+
+func TestRenderComposePresetsAdapterLogin(t *testing.T) {
+	manager := NewManager()
+	manager.SetImageName("bitnami/moodle:4.5")
+
+	content, err := manager.renderCompose(nil, "Kept-Pa55word")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{`- "MOODLE_USERNAME=admin"`, `- "MOODLE_PASSWORD=Kept-Pa55word"`} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in compose file:\n%s", expected, content)
+		}
+	}
+}
+
+func TestEnvFromArgs(t *testing.T) {
+	env := envFromArgs([]string{"-e", "A=1", "--memory", "5", "-e", "B=2"})
+	if len(env) != 2 || env[0] != "A=1" || env[1] != "B=2" {
+		t.Errorf("Unexpected env: %v", env)
+	}
+}
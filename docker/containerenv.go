@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// SetContainerEnv sets extra environment variables passed to new containers
+func (m *Manager) SetContainerEnv(env map[string]string) {
+	m.containerEnv = make(map[string]string, len(env))
+	for name, value := range env {
+		m.containerEnv[name] = value
+	}
+}
+
+// moodleEnvArgs returns the `-e` flags of the Moodle container: the proxy, log levels, mail
+// catcher, the admin login of images that take it and, last so they can override the rest, the
+// extra environment. RunContainerWithVolumes and the exported compose file both build the
+// environment here, so they cannot drift apart. password is passed to adapterArgs.
+func (m *Manager) moodleEnvArgs(adapter *ImageAdapter, password string) ([]string, error) {
+	loginArgs, err := m.adapterArgs(adapter, password)
+	if err != nil {
+		return nil, err
+	}
+	// Let Moodle reach the internet (plugin installs, hub registration) through the proxy
+	return slices.Concat(containerProxyArgs(), m.logLevelArgs(), m.mailArgs(), loginArgs, m.containerEnvArgs()), nil
+}
+
+// containerEnvArgs returns the `docker run` arguments for the extra environment, sorted by name
+// so the command line is stable
+func (m *Manager) containerEnvArgs() []string {
+	names := make([]string, 0, len(m.containerEnv))
+	for name := range m.containerEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", name, m.containerEnv[name]))
+	}
+	return args
+}
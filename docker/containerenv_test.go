@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestContainerEnvArgs(t *testing.T) {
+	manager := NewManager()
+	if args := manager.containerEnvArgs(); len(args) != 0 {
+		t.Errorf("Expected no arguments without extra environment, got %v", args)
+	}
+
+	env := map[string]string{"MOODLE_LANG": "de", "A_FLAG": "1"}
+	manager.SetContainerEnv(env)
+	env["MOODLE_LANG"] = "fr"
+
+	want := []string{"-e", "A_FLAG=1", "-e", "MOODLE_LANG=de"}
+	if args := manager.containerEnvArgs(); !slices.Equal(args, want) {
+		t.Errorf("Expected %v, got %v", want, args)
+	}
+}
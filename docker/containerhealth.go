@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Health statuses reported for the Moodle container
+const (
+	// HealthStarting, HealthHealthy and HealthUnhealthy are Docker's own health states
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	// HealthNone means the container has no health check, e.g. one created by an older version
+	HealthNone = "none"
+	// HealthStopped means the container is not running
+	HealthStopped = "stopped"
+)
+
+// Settings of the health check added to images that do not define a HEALTHCHECK
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+	healthCheckRetries  = 3
+	// healthCheckStartPeriod covers a first-run install; failures during it are not counted
+	healthCheckStartPeriod = 60 * time.Minute
+)
+
+// healthCheckCommand succeeds once the web server answers the adapter's health path with any
+// HTTP status, like the readiness check it replaces. PHP is always present in a Moodle image,
+// unlike curl or wget.
+func healthCheckCommand(adapter *ImageAdapter) string {
+	return fmt.Sprintf(
+		`php -r '@file_get_contents("http://localhost:%d%s"); exit(empty($http_response_header) ? 1 : 0);'`,
+		adapter.ContainerPort, adapter.HealthPath)
+}
+
+// ContainerHealth mirrors the State.Health section of `docker inspect`
+type ContainerHealth struct {
+	Status        string           `json:"Status"`
+	FailingStreak int              `json:"FailingStreak"`
+	Log           []HealthLogEntry `json:"Log"`
+}
+
+// HealthLogEntry is one run of the health check command
+type HealthLogEntry struct {
+	Start    time.Time `json:"Start"`
+	End      time.Time `json:"End"`
+	ExitCode int       `json:"ExitCode"`
+	Output   string    `json:"Output"`
+}
+
+// HealthReport is the container health shown in the UI
+type HealthReport struct {
+	Status        string `json:"status"`
+	FailingStreak int    `json:"failingStreak"`
+	// LastOutput is the output of the latest health check run
+	LastOutput string    `json:"lastOutput,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt,omitempty"`
+}
+
+// imageHealthcheck mirrors Config.Healthcheck of `docker image inspect`
+type imageHealthcheck struct {
+	Test []string `json:"Test"`
+}
+
+// ImageHasHealthcheck reports whether the image defines its own HEALTHCHECK
+func (m *Manager) ImageHasHealthcheck(image string) (bool, error) {
+	cmd := GetDockerCommand("image", "inspect", "--format", "{{json .Config.Healthcheck}}", image)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, errors.NewDockerErrorWithImage("image_inspect", image, err).WithOutput(string(output))
+	}
+	return parseImageHealthcheck(string(output))
+}
+
+// parseImageHealthcheck reads the healthcheck inspect output; "null" and NONE mean none
+func parseImageHealthcheck(output string) (bool, error) {
+	output = strings.TrimSpace(output)
+	if output == "" || output == "null" {
+		return false, nil
+	}
+	var check imageHealthcheck
+	if err := json.Unmarshal([]byte(output), &check); err != nil {
+		return false, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected healthcheck output: %v", err)
+	}
+	return len(check.Test) > 0 && check.Test[0] != "NONE", nil
+}
+
+// healthCheckArgs returns `docker run` flags adding our health check when the image has none
+func (m *Manager) healthCheckArgs() []string {
+	hasHealthcheck, err := m.ImageHasHealthcheck(m.imageName)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not read the image health check, adding our own: %v", err))
+	}
+	if hasHealthcheck {
+		utils.LogDebug(fmt.Sprintf("Using the HEALTHCHECK defined by %s", m.imageName))
+		return nil
+	}
+	return []string{
+		"--health-cmd", healthCheckCommand(m.Adapter()),
+		"--health-interval", healthCheckInterval.String(),
+		"--health-timeout", healthCheckTimeout.String(),
+		"--health-retries", fmt.Sprintf("%d", healthCheckRetries),
+		"--health-start-period", healthCheckStartPeriod.String(),
+	}
+}
+
+// GetContainerHealth returns the Docker health status of a container
+func (m *Manager) GetContainerHealth(containerID string) (*HealthReport, error) {
+	state, err := m.InspectContainerState(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return healthReport(state), nil
+}
+
+// healthReport summarises a container state for the UI
+func healthReport(state *ContainerState) *HealthReport {
+	if !state.Running {
+		return &HealthReport{Status: HealthStopped}
+	}
+	if state.Health == nil || state.Health.Status == "" {
+		return &HealthReport{Status: HealthNone}
+	}
+
+	report := &HealthReport{Status: state.Health.Status, FailingStreak: state.Health.FailingStreak}
+	if n := len(state.Health.Log); n > 0 {
+		last := state.Health.Log[n-1]
+		report.LastOutput = strings.TrimSpace(last.Output)
+		report.CheckedAt = last.End
+	}
+	return report
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseImageHealthcheck(t *testing.T) {
+	cases := []struct {
+		output string
+		want   bool
+	}{
+		{"null\n", false},
+		{"", false},
+		{`{"Test":["NONE"]}`, false},
+		{`{"Test":["CMD-SHELL","curl -f http://localhost/ || exit 1"],"Interval":30000000000}`, true},
+	}
+	for _, c := range cases {
+		got, err := parseImageHealthcheck(c.output)
+		if err != nil {
+			t.Fatalf("parseImageHealthcheck(%q) failed: %v", c.output, err)
+		}
+		if got != c.want {
+			t.Errorf("parseImageHealthcheck(%q) = %v, want %v", c.output, got, c.want)
+		}
+	}
+
+	if _, err := parseImageHealthcheck("not json"); err == nil {
+		t.Error("expected an error for malformed output")
+	}
+}
+
+func TestHealthReport(t *testing.T) {
+	if report := healthReport(&ContainerState{Running: false}); report.Status != HealthStopped {
+		t.Errorf("stopped container reported %s", report.Status)
+	}
+	if report := healthReport(&ContainerState{Running: true}); report.Status != HealthNone {
+		t.Errorf("container without health check reported %s", report.Status)
+	}
+
+	checked := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	state := &ContainerState{Running: true, Health: &ContainerHealth{
+		Status:        HealthUnhealthy,
+		FailingStreak: 4,
+		Log: []HealthLogEntry{
+			{ExitCode: 0, Output: "ok"},
+			{ExitCode: 1, Output: "connection refused\n", End: checked},
+		},
+	}}
+	report := healthReport(state)
+	if report.Status != HealthUnhealthy || report.FailingStreak != 4 || report.LastOutput != "connection refused" || !report.CheckedAt.Equal(checked) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/bundle"
+)
+
+// CredentialPatternSet recognises the admin password and site URL in the first-run logs of a
+// family of images. Each pattern's first capture group is the value.
+type CredentialPatternSet struct {
+	Name string
+	// Images are image reference prefixes the set is preferred for, e.g. "bitnami/moodle" or
+	// "wenkhairu/moodle-prototype:4"; a set without images is only used as a fallback
+	Images   []string
+	Password []*regexp.Regexp
+	URL      []*regexp.Regexp
+}
+
+// appliesTo reports whether the set is meant for image
+func (set *CredentialPatternSet) appliesTo(image string) bool {
+	image = strings.ToLower(strings.TrimPrefix(image, "docker.io/"))
+	for _, prefix := range set.Images {
+		if strings.HasPrefix(image, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Built-in credential patterns. The prototype image's patterns can be replaced through the
+// asset channel (bundle.PasswordPattern and bundle.URLPattern).
+const (
+	// The prototype image prints "Generated admin password: " or, in older builds, "Password: "
+	// at the start of a line
+	defaultPasswordPattern = `(?mi)^\s*(?:Generated admin password|Password):[ \t]*(\S.*?)\s*$`
+	defaultURLPattern      = `(?mi)Moodle is available at:[ \t]*(\S+)`
+)
+
+// ansiEscapeRegex matches terminal colour and cursor sequences, which bitnami images and many
+// entrypoint scripts write into their logs
+var ansiEscapeRegex = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]")
+
+// StripANSI removes terminal escape sequences from log output
+func StripANSI(logs string) string {
+	if !strings.Contains(logs, "\x1b") {
+		return logs
+	}
+	return ansiEscapeRegex.ReplaceAllString(logs, "")
+}
+
+var (
+	patternSetsMu sync.RWMutex
+	// registeredPatternSets are added with RegisterCredentialPatterns, newest first
+	registeredPatternSets []*CredentialPatternSet
+)
+
+// RegisterCredentialPatterns adds a pattern set for custom images. Registered sets take
+// precedence over the built-in ones for the images they name.
+func RegisterCredentialPatterns(set CredentialPatternSet) {
+	patternSetsMu.Lock()
+	defer patternSetsMu.Unlock()
+	registeredPatternSets = append([]*CredentialPatternSet{&set}, registeredPatternSets...)
+}
+
+// builtinPatternSets returns the built-in sets, compiling the prototype image's patterns from
+// the installed assets
+func builtinPatternSets() []*CredentialPatternSet {
+	return []*CredentialPatternSet{
+		{
+			Name:     "moodle-prototype",
+			Images:   []string{"wenkhairu/moodle-prototype"},
+			Password: []*regexp.Regexp{compilePattern(bundle.PasswordPattern, defaultPasswordPattern)},
+			URL:      []*regexp.Regexp{compilePattern(bundle.URLPattern, defaultURLPattern)},
+		},
+		{
+			// Phrasings common in custom entrypoints; tried for every image after its own set
+			Name: "generic",
+			Password: []*regexp.Regexp{
+				regexp.MustCompile(`(?mi)^\s*(?:Generated )?admin(?:istrator)? password(?: is)?\s*[:=][ \t]*(\S+)\s*$`),
+				regexp.MustCompile(`(?mi)^\s*(?:Generated admin password|Password):[ \t]*(\S.*?)\s*$`),
+			},
+			URL: []*regexp.Regexp{
+				regexp.MustCompile(`(?mi)(?:Moodle is available at|Moodle URL|Site URL|wwwroot)\s*[:=][ \t]*(https?://\S+)`),
+			},
+		},
+	}
+}
+
+// patternSetsFor orders the pattern sets for image: registered and built-in sets naming the
+// image first, then the fallbacks
+func patternSetsFor(image string) []*CredentialPatternSet {
+	patternSetsMu.RLock()
+	all := append(append([]*CredentialPatternSet{}, registeredPatternSets...), builtinPatternSets()...)
+	patternSetsMu.RUnlock()
+
+	var preferred, fallback []*CredentialPatternSet
+	for _, set := range all {
+		if image != "" && set.appliesTo(image) {
+			preferred = append(preferred, set)
+		} else if len(set.Images) == 0 || image == "" {
+			fallback = append(fallback, set)
+		}
+	}
+	return append(preferred, fallback...)
+}
+
+// latestMatch returns the capture of whichever pattern matches latest in logs, so a value
+// printed again by a reinstall wins over the first one
+func latestMatch(patterns []*regexp.Regexp, logs string) string {
+	value, position := "", -1
+	for _, pattern := range patterns {
+		matches := pattern.FindAllStringSubmatchIndex(logs, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		last := matches[len(matches)-1]
+		if len(last) < 4 || last[2] < 0 || last[0] <= position {
+			continue
+		}
+		value, position = strings.TrimSpace(logs[last[2]:last[3]]), last[0]
+	}
+	return value
+}
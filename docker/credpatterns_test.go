@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestExtractCredentialsFixtures(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		image    string
+		password string
+		url      string
+	}{
+		{"prototype-reinstall.log", "wenkhairu/moodle-prototype:502-stable", "Zx8#kQ2m!vR4", "http://localhost:8080"},
+		{"prototype-legacy.log", "wenkhairu/moodle-prototype:401-stable", "N3w-Passw0rd", "http://localhost:9090/"},
+		{"custom-entrypoint.log", "registry.example.test/school/moodle:latest", "Cust0m-Secret", "http://moodle.local:8000"},
+		// An unknown image still falls back to the generic phrasings
+		{"prototype-reinstall.log", "", "Zx8#kQ2m!vR4", "http://localhost:8080"},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", "logs", tt.fixture))
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		creds := NewLogParser().ForImage(tt.image).ExtractCredentials(string(data))
+		if creds.Password != tt.password || creds.URL != tt.url {
+			t.Errorf("%s (%s): expected %q at %q, got %q at %q", tt.fixture, tt.image, tt.password, tt.url, creds.Password, creds.URL)
+		}
+	}
+}
+
+func TestExtractCredentialsIgnoresLookalikes(t *testing.T) {
+	logs := "WARN ==> You set the environment variable ALLOW_EMPTY_PASSWORD=yes.\n" +
+		"mysql_password is set from the environment\n" +
+		"Checking the database password: ok?\n"
+	if creds := NewLogParser().ExtractCredentials(logs); creds.Password != "" {
+		t.Errorf("Expected no password, got %q", creds.Password)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	if got := StripANSI("\x1b[38;5;6mmoodle \x1b[0m\x1b[1mINFO \x1b[0m ==> done\x1b[K"); got != "moodle INFO  ==> done" {
+		t.Errorf("Expected escape sequences removed, got %q", got)
+	}
+}
+
+func TestRegisterCredentialPatterns(t *testing.T) {
+	RegisterCredentialPatterns(CredentialPatternSet{
+		Name:     "test",
+		Images:   []string{"example/moodle:5"},
+		Password: []*regexp.Regexp{regexp.MustCompile(`(?m)^secret=(\S+)$`)},
+		URL:      []*regexp.Regexp{regexp.MustCompile(`(?m)^url=(\S+)$`)},
+	})
+	defer func() { registeredPatternSets = nil }()
+
+	logs := "secret=abc\nurl=http://example.test\nPassword: generic\n"
+	if creds := NewLogParser().ForImage("example/moodle:5.0").ExtractCredentials(logs); creds.Password != "abc" || creds.URL != "http://example.test" {
+		t.Errorf("Expected the registered set to win for its image, got %+v", creds)
+	}
+	if creds := NewLogParser().ForImage("example/moodle:4.5").ExtractCredentials(logs); creds.Password != "generic" {
+		t.Errorf("Expected other versions to use the generic set, got %+v", creds)
+	}
+}
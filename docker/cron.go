@@ -0,0 +1,187 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// CronScript is the Moodle cron entry point relative to MoodleDir
+const CronScript = "admin/cli/cron.php"
+
+// ErrCronRunning is returned for a cron run requested while another one is still in progress
+var ErrCronRunning = fmt.Errorf("moodle cron is already running: %w", errors.ErrOperationInProgress)
+
+// CronStatus describes the scheduler state and the outcome of the last run
+type CronStatus struct {
+	Enabled         bool      `json:"enabled"`
+	IntervalMinutes int       `json:"intervalMinutes"`
+	Running         bool      `json:"running"`
+	LastRun         time.Time `json:"lastRun"`
+	LastDuration    string    `json:"lastDuration"`
+	LastSuccess     bool      `json:"lastSuccess"`
+	LastError       string    `json:"lastError"`
+}
+
+// CronScheduler periodically runs Moodle cron inside the container
+type CronScheduler struct {
+	manager     *Manager
+	containerID func() (string, error)
+
+	mu       sync.Mutex
+	status   CronStatus
+	stopChan chan struct{}
+	runMu    sync.Mutex
+}
+
+// NewCronScheduler creates a scheduler; containerID resolves the current container on every tick
+func NewCronScheduler(manager *Manager, containerID func() (string, error)) *CronScheduler {
+	return &CronScheduler{
+		manager:     manager,
+		containerID: containerID,
+	}
+}
+
+// Start begins running cron every interval, replacing any previous schedule
+func (cs *CronScheduler) Start(interval time.Duration) {
+	cs.Stop()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	stopChan := make(chan struct{})
+	cs.stopChan = stopChan
+	cs.status.Enabled = true
+	cs.status.IntervalMinutes = int(interval / time.Minute)
+
+	utils.LogInfo(fmt.Sprintf("Starting Moodle cron scheduler (interval: %v)", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				cs.tick()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the scheduler; a run already in progress is allowed to finish
+func (cs *CronScheduler) Stop() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.stopChan != nil {
+		close(cs.stopChan)
+		cs.stopChan = nil
+		utils.LogInfo("Moodle cron scheduler stopped")
+	}
+	cs.status.Enabled = false
+}
+
+// Status returns a copy of the current scheduler status
+func (cs *CronScheduler) Status() CronStatus {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.status
+}
+
+// LastCronStart returns when Moodle cron last started, whoever ran it; zero if it never ran
+func (m *Manager) LastCronStart(containerID string) (time.Time, error) {
+	output, err := m.RunMoodleCLI(containerID, cfgScript, "--name=lastcronstart")
+	if err != nil {
+		// cfg.php exits non-zero for a setting that was never written
+		if strings.TrimSpace(output) == "" {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return parseCronTimestamp(output), nil
+}
+
+// parseCronTimestamp reads a Unix timestamp setting, treating anything else as never
+func parseCronTimestamp(output string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// RunNow executes cron immediately, regardless of the schedule
+func (cs *CronScheduler) RunNow() error {
+	containerID, err := cs.containerID()
+	if err != nil {
+		return err
+	}
+	return cs.run(containerID)
+}
+
+// tick runs cron if the container is currently up
+func (cs *CronScheduler) tick() {
+	containerID, err := cs.containerID()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Skipping scheduled cron run: %v", err))
+		return
+	}
+
+	running, err := cs.manager.IsContainerRunning(containerID)
+	if err != nil || !running {
+		utils.LogDebug("Skipping scheduled cron run: container is not running")
+		return
+	}
+
+	err = cs.run(containerID)
+	if errors.IsSpecificError(err, ErrCronRunning) {
+		utils.LogWarning("Moodle cron is already running, skipping overlapping run")
+	} else if err != nil {
+		utils.LogError("Scheduled Moodle cron run failed", err)
+	}
+}
+
+// run executes a single cron pass, refusing with ErrCronRunning while another is in progress
+func (cs *CronScheduler) run(containerID string) error {
+	if !cs.runMu.TryLock() {
+		return ErrCronRunning
+	}
+	defer cs.runMu.Unlock()
+
+	cs.mu.Lock()
+	cs.status.Running = true
+	cs.mu.Unlock()
+
+	start := time.Now()
+	utils.LogDebug("Running Moodle cron")
+	output, err := cs.manager.RunMoodleCLI(containerID, CronScript)
+	duration := time.Since(start)
+
+	cs.mu.Lock()
+	cs.status.Running = false
+	cs.status.LastRun = start
+	cs.status.LastDuration = duration.Round(time.Millisecond).String()
+	cs.status.LastSuccess = err == nil
+	if err != nil {
+		cs.status.LastError = err.Error()
+	} else {
+		cs.status.LastError = ""
+	}
+	cs.mu.Unlock()
+
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Cron output: %s", output))
+		return err
+	}
+
+	utils.LogInfo(fmt.Sprintf("Moodle cron completed in %v", duration.Round(time.Millisecond)))
+	return nil
+}
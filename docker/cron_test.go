@@ -0,0 +1,138 @@
+package docker
+
+import (
+	stderrors "errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// cronRunner answers docker commands for a running container and counts cron runs. While
+// release is set, each cron run blocks until it is closed.
+type cronRunner struct {
+	utils.ExecRunner
+	mu      sync.Mutex
+	runs    int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *cronRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	args := cmd.Args[1:]
+	switch args[0] {
+	case "inspect":
+		return []byte("true\n"), nil
+	case "exec":
+		if !strings.HasSuffix(strings.Join(args, " "), CronScript) {
+			return nil, nil
+		}
+		r.mu.Lock()
+		r.runs++
+		started, release := r.started, r.release
+		r.mu.Unlock()
+		if started != nil {
+			started <- struct{}{}
+		}
+		if release != nil {
+			<-release
+		}
+	}
+	return nil, nil
+}
+
+func (r *cronRunner) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs
+}
+
+func newCronScheduler(t *testing.T, runner *cronRunner) *CronScheduler {
+	t.Helper()
+	previous := utils.SetCommandRunner(runner)
+	t.Cleanup(func() { utils.SetCommandRunner(previous) })
+
+	containerID := strings.Repeat("c", 64)
+	return NewCronScheduler(NewManager(), func() (string, error) { return containerID, nil })
+}
+
+func TestParseCronTimestamp(t *testing.T) {
+	if got := parseCronTimestamp("1772355600\n"); !got.Equal(time.Unix(1772355600, 0)) {
+		t.Errorf("unexpected time %v", got)
+	}
+	for _, output := range []string{"", "0", "never"} {
+		if got := parseCronTimestamp(output); !got.IsZero() {
+			t.Errorf("parseCronTimestamp(%q) = %v, want zero", output, got)
+		}
+	}
+}
+
+func TestCronSchedulerRunsEveryInterval(t *testing.T) {
+	runner := &cronRunner{started: make(chan struct{}, 100)}
+	scheduler := newCronScheduler(t, runner)
+
+	scheduler.Start(10 * time.Millisecond)
+	defer scheduler.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runner.started:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected scheduled run %d", i+1)
+		}
+	}
+
+	status := scheduler.Status()
+	if !status.Enabled || status.LastRun.IsZero() {
+		t.Errorf("Expected an enabled scheduler with a last run, got %+v", status)
+	}
+}
+
+func TestCronSchedulerRejectsOverlappingRuns(t *testing.T) {
+	runner := &cronRunner{started: make(chan struct{}), release: make(chan struct{})}
+	scheduler := newCronScheduler(t, runner)
+
+	done := make(chan error)
+	go func() { done <- scheduler.RunNow() }()
+	<-runner.started
+
+	err := scheduler.RunNow()
+	if !stderrors.Is(err, ErrCronRunning) {
+		t.Errorf("Expected ErrCronRunning while a run is in progress, got %v", err)
+	}
+	if errors.CodeOf(err) != errors.CodeOperationInProgress {
+		t.Errorf("Expected code %s, got %s", errors.CodeOperationInProgress, errors.CodeOf(err))
+	}
+
+	close(runner.release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the first run to succeed, got %v", err)
+	}
+	if runs := runner.count(); runs != 1 {
+		t.Errorf("Expected 1 cron run, got %d", runs)
+	}
+}
+
+func TestCronSchedulerStop(t *testing.T) {
+	runner := &cronRunner{started: make(chan struct{}, 100)}
+	scheduler := newCronScheduler(t, runner)
+
+	scheduler.Start(5 * time.Millisecond)
+	<-runner.started
+	scheduler.Stop()
+
+	// A tick already under way may still finish; none may start afterwards
+	time.Sleep(10 * time.Millisecond)
+	runs := runner.count()
+	time.Sleep(30 * time.Millisecond)
+	if after := runner.count(); after != runs {
+		t.Errorf("Expected no runs after Stop, got %d more", after-runs)
+	}
+	if scheduler.Status().Enabled {
+		t.Error("Expected the scheduler to report disabled after Stop")
+	}
+}
@@ -0,0 +1,143 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// debugIniName is the PHP ini file that shows errors in pages while developer debugging is on
+const debugIniName = "zz-moodle-debug.ini"
+
+// moodleDebugValues are the values of $CFG->debug for MoodleDebugLevels, as Moodle's DEBUG_*
+// constants define them
+var moodleDebugValues = map[string]int{
+	"none":      0,
+	"minimal":   5,     // E_ERROR | E_PARSE
+	"normal":    15,    // E_ERROR | E_PARSE | E_WARNING | E_NOTICE
+	"all":       30719, // E_ALL & ~E_STRICT
+	"developer": 32767, // E_ALL | E_STRICT
+}
+
+// debugIni shows every PHP error in the page, including those raised before Moodle's own
+// error handling is set up
+const debugIni = `display_errors=On
+display_startup_errors=On
+error_reporting=E_ALL
+`
+
+// DebugMode is Moodle's debugging configuration in a running site
+type DebugMode struct {
+	// Level is one of MoodleDebugLevels, or "custom" for a value set outside the manager
+	Level string `json:"level"`
+	Value int    `json:"value"`
+	// Display shows debugging messages in pages as well as the logs
+	Display bool `json:"display"`
+	// Reloaded is set when the web server was restarted to apply PHP settings
+	Reloaded bool `json:"reloaded"`
+}
+
+// debugLevelFor returns the MoodleDebugLevels name of a $CFG->debug value
+func debugLevelFor(value int) string {
+	for level, candidate := range moodleDebugValues {
+		if candidate == value {
+			return level
+		}
+	}
+	return "custom"
+}
+
+// SetMoodleDebugMode changes Moodle's debugging level in the running site through its config
+// CLI. Messages are displayed in pages at the "all" and "developer" levels; developer mode
+// also makes PHP show every error, and the web server is reloaded when that changes.
+func (m *Manager) SetMoodleDebugMode(containerID, level string) (*DebugMode, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to SetMoodleDebugMode")
+	}
+	value, ok := moodleDebugValues[level]
+	if !ok {
+		return nil, errors.NewValidationError("level", fmt.Sprintf("must be one of %q", MoodleDebugLevels[1:]), level)
+	}
+
+	mode := &DebugMode{Level: level, Value: value, Display: level == "all" || level == "developer"}
+	display := "0"
+	if mode.Display {
+		display = "1"
+	}
+	if _, err := m.RunMoodleCLI(containerID, cfgScript, "--name=debug", "--set="+strconv.Itoa(value)); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to set Moodle debugging")
+	}
+	if _, err := m.RunMoodleCLI(containerID, cfgScript, "--name=debugdisplay", "--set="+display); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to set Moodle debug display")
+	}
+
+	iniDir, err := m.phpScanDir(containerID)
+	if err != nil {
+		return nil, err
+	}
+	iniPath := iniDir + "/" + debugIniName
+	_, statErr := m.ExecInContainer(containerID, "test", "-f", iniPath)
+	hadIni := statErr == nil
+
+	switch {
+	case level == "developer" && !hadIni:
+		if err := m.writePHPIni(containerID, debugIniName, debugIni); err != nil {
+			return nil, err
+		}
+		mode.Reloaded = true
+	case level != "developer" && hadIni:
+		if _, err := m.ExecInContainer(containerID, "rm", "-f", iniPath); err != nil {
+			return nil, errors.WrapWithContext(err, "failed to remove PHP debug configuration")
+		}
+		mode.Reloaded = true
+	}
+	if mode.Reloaded {
+		if err := m.reloadWebServer(containerID); err != nil {
+			return nil, err
+		}
+	}
+
+	utils.LogInfo(fmt.Sprintf("Moodle debugging set to %s (debug=%d, display=%v, reloaded=%v)", level, value, mode.Display, mode.Reloaded))
+	return mode, nil
+}
+
+// GetMoodleDebugMode reads Moodle's debugging configuration from the running site
+func (m *Manager) GetMoodleDebugMode(containerID string) (*DebugMode, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to GetMoodleDebugMode")
+	}
+
+	output, err := m.RunMoodleCLI(containerID, cfgScript, "--name=debug")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to read Moodle debugging")
+	}
+	value, err := parseConfigInt(output)
+	if err != nil {
+		return nil, err
+	}
+	displayOutput, err := m.RunMoodleCLI(containerID, cfgScript, "--name=debugdisplay")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to read Moodle debug display")
+	}
+	display, _ := parseConfigInt(displayOutput)
+
+	return &DebugMode{Level: debugLevelFor(value), Value: value, Display: display != 0}, nil
+}
+
+// parseConfigInt reads the integer cfg.php prints for a setting; an unset setting prints
+// nothing and counts as 0
+func parseConfigInt(output string) (int, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(last)
+	if err != nil {
+		return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected Moodle setting value: %s", last)
+	}
+	return value, nil
+}
@@ -0,0 +1,38 @@
+package docker
+
+import "testing"
+
+func TestDebugLevelFor(t *testing.T) {
+	for _, level := range MoodleDebugLevels[1:] {
+		if got := debugLevelFor(moodleDebugValues[level]); got != level {
+			t.Errorf("debugLevelFor(%d) = %s, expected %s", moodleDebugValues[level], got, level)
+		}
+	}
+	if got := debugLevelFor(2047); got != "custom" {
+		t.Errorf("Expected an unknown value to be custom, got %s", got)
+	}
+}
+
+func TestParseConfigInt(t *testing.T) {
+	cases := map[string]int{
+		"32767\n":                 32767,
+		"":                        0,
+		"Notice: something\n15\n": 15,
+	}
+	for output, expected := range cases {
+		value, err := parseConfigInt(output)
+		if err != nil || value != expected {
+			t.Errorf("parseConfigInt(%q) = %d, %v; expected %d", output, value, err, expected)
+		}
+	}
+	if _, err := parseConfigInt("not a number"); err == nil {
+		t.Error("Expected an error for non-numeric output")
+	}
+}
+
+func TestSetMoodleDebugModeValidatesLevel(t *testing.T) {
+	manager := NewManager()
+	if _, err := manager.SetMoodleDebugMode("abc123def456", "verbose"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// MakeTestSiteScript is Moodle's test site generator, relative to MoodleDir
+const MakeTestSiteScript = "admin/tool/generator/cli/maketestsite.php"
+
+// Demo data sizes offered by SeedDemoData
+const (
+	DemoDataSmall  = "S"
+	DemoDataMedium = "M"
+	DemoDataLarge  = "L"
+)
+
+// demoDataSite is what one of our sizes asks of the test site generator
+type demoDataSite struct {
+	GeneratorSize string `json:"generatorSize"`
+	Courses       int    `json:"courses"`
+}
+
+// defaultDemoDataSites maps our sizes to the generator's site sizes and the number of courses
+// each creates. The generator's own L and above take hours and gigabytes, far beyond a prototype.
+var defaultDemoDataSites = map[string]demoDataSite{
+	DemoDataSmall:  {"XS", 3},
+	DemoDataMedium: {"S", 12},
+	DemoDataLarge:  {"M", 73},
+}
+
+// generatorSizeRegex matches the site sizes maketestsite.php accepts
+var generatorSizeRegex = regexp.MustCompile(`^(XS|S|M|L|XL|XXL)$`)
+
+// demoDataSiteFor returns the generator settings for a validated size. The asset channel can
+// replace them (bundle.SeedManifest) when a Moodle release changes what the generator creates;
+// an unreadable or incomplete entry falls back to the built-in one.
+func demoDataSiteFor(size string) demoDataSite {
+	site := defaultDemoDataSites[size]
+	manifest := bundle.Text(bundle.SeedManifest, "")
+	if manifest == "" {
+		return site
+	}
+
+	var sites map[string]demoDataSite
+	if err := json.Unmarshal([]byte(manifest), &sites); err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring invalid seed asset %s: %v", bundle.SeedManifest, err))
+		return site
+	}
+	if override, ok := sites[size]; ok && generatorSizeRegex.MatchString(override.GeneratorSize) && override.Courses > 0 {
+		return override
+	}
+	return site
+}
+
+// generatorCourseRegex matches the generator's "Creating course testcourse_12" progress lines
+var generatorCourseRegex = regexp.MustCompile(`Creating course\W+(testcourse_\d+)`)
+
+// SeedResult reports what SeedDemoData created
+type SeedResult struct {
+	Size     string `json:"size"`
+	Courses  int    `json:"courses"`
+	Duration string `json:"duration"`
+}
+
+// ValidateDemoDataSize checks a size name, returning it normalised to upper case
+func ValidateDemoDataSize(size string) (string, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if _, ok := defaultDemoDataSites[size]; !ok {
+		return "", errors.NewValidationError("size", "must be S, M or L", size)
+	}
+	return size, nil
+}
+
+// SeedDemoData fills Moodle with courses, users, enrolments and activities using the test site
+// generator. Users are named tool_generator_<n> and are removed by PurgeDemoUsers.
+func (m *Manager) SeedDemoData(containerID, size string, progressCallback func(float64, string)) (*SeedResult, error) {
+	size, err := ValidateDemoDataSize(size)
+	if err != nil {
+		return nil, err
+	}
+	site := demoDataSiteFor(size)
+
+	utils.LogInfo(fmt.Sprintf("Seeding %s demo data (generator size %s) into container %s", size, site.GeneratorSize, containerID))
+	if progressCallback != nil {
+		progressCallback(0, fmt.Sprintf("Creating %d courses", site.Courses))
+	}
+
+	start := time.Now()
+	tracker := newSeedTracker(site.Courses)
+	var output strings.Builder
+	err = m.ExecStream(containerID, func(line string) {
+		output.WriteString(line + "\n")
+		if course, created := tracker.observe(line); created && progressCallback != nil {
+			progressCallback(tracker.percentage(), fmt.Sprintf("Creating %s (%d of %d)", course, len(tracker.seen), site.Courses))
+		}
+	}, PHPBinary, MakeTestSiteScript, "--size="+site.GeneratorSize, "--fixeddataset", "--bypasscheck")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "demo data generator failed: %s", LastLogLines(output.String(), 5))
+	}
+
+	if progressCallback != nil {
+		progressCallback(100, "Demo data created")
+	}
+	result := &SeedResult{
+		Size:     size,
+		Courses:  len(tracker.seen),
+		Duration: time.Since(start).Round(time.Second).String(),
+	}
+	utils.LogInfo(fmt.Sprintf("Seeded %d demo courses in %s", result.Courses, result.Duration))
+	return result, nil
+}
+
+// seedTracker counts the courses the generator has started on
+type seedTracker struct {
+	expected int
+	seen     map[string]bool
+}
+
+func newSeedTracker(expected int) *seedTracker {
+	return &seedTracker{expected: expected, seen: make(map[string]bool)}
+}
+
+// observe reports the course a generator line starts, once per course
+func (st *seedTracker) observe(line string) (string, bool) {
+	match := generatorCourseRegex.FindStringSubmatch(line)
+	if match == nil || st.seen[match[1]] {
+		return "", false
+	}
+	st.seen[match[1]] = true
+	return match[1], true
+}
+
+// percentage estimates progress from the courses started; the last one is still running,
+// so it stays below 100 until the generator exits
+func (st *seedTracker) percentage() float64 {
+	if st.expected <= 0 {
+		return 0
+	}
+	done := float64(len(st.seen)-1) / float64(st.expected) * 100
+	if done < 0 {
+		return 0
+	}
+	if done > 99 {
+		return 99
+	}
+	return done
+}
@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"moodle-prototype-manager/bundle"
+)
+
+func TestValidateDemoDataSize(t *testing.T) {
+	if size, err := ValidateDemoDataSize(" m "); err != nil || size != DemoDataMedium {
+		t.Errorf("Expected M, got %q (%v)", size, err)
+	}
+	for _, size := range []string{"", "XL", "huge"} {
+		if _, err := ValidateDemoDataSize(size); err == nil {
+			t.Errorf("Expected %q to be rejected", size)
+		}
+	}
+}
+
+func TestDemoDataSiteFromAsset(t *testing.T) {
+	directory := t.TempDir()
+	bundle.SetDirectory(directory)
+	defer bundle.SetDirectory("")
+
+	manifest := `{"S": {"generatorSize": "S", "courses": 10}, "M": {"generatorSize": "huge", "courses": 5}}`
+	if err := os.MkdirAll(filepath.Join(directory, "seed"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, filepath.FromSlash(bundle.SeedManifest)), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if site := demoDataSiteFor(DemoDataSmall); site.GeneratorSize != "S" || site.Courses != 10 {
+		t.Errorf("Expected the asset to replace the small size, got %+v", site)
+	}
+	if site := demoDataSiteFor(DemoDataMedium); site != defaultDemoDataSites[DemoDataMedium] {
+		t.Errorf("Expected an invalid entry to fall back to the built-in one, got %+v", site)
+	}
+	if site := demoDataSiteFor(DemoDataLarge); site != defaultDemoDataSites[DemoDataLarge] {
+		t.Errorf("Expected a missing entry to fall back to the built-in one, got %+v", site)
+	}
+}
+
+func TestSeedTracker(t *testing.T) {
+	tracker := newSeedTracker(4)
+
+	lines := []string{
+		"Checking developer mode... done",
+		"Creating course testcourse_1 (XS)",
+		"Creating users (1): done (0.1s)",
+		"Creating course testcourse_1 (XS)",
+		"Creating course testcourse_2 (XS)",
+	}
+	created := 0
+	for _, line := range lines {
+		if _, ok := tracker.observe(line); ok {
+			created++
+		}
+	}
+	if created != 2 {
+		t.Fatalf("Expected each course to be counted once, got %d", created)
+	}
+	if got := tracker.percentage(); got != 25 {
+		t.Errorf("Expected 25%% with the second course in progress, got %v", got)
+	}
+
+	// The generator may create more courses than expected; progress never reaches 100 early
+	for _, course := range []string{"testcourse_3", "testcourse_4", "testcourse_5", "testcourse_6"} {
+		tracker.observe("Creating course " + course)
+	}
+	if got := tracker.percentage(); got != 99 {
+		t.Errorf("Expected progress capped at 99, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// GeneratorUserPrefix marks accounts created by Moodle's test data generator (see SeedDemoData).
+// Only these are purged: accounts made by hand are never matched, whatever their name.
+const GeneratorUserPrefix = "tool_generator_"
+
+// PurgeResult reports which demo users were removed
+type PurgeResult struct {
+	Count     int      `json:"count"`
+	Usernames []string `json:"usernames"`
+}
+
+// purgeDemoUsersScript deletes each generated user's data through the privacy API
+// and then removes the account itself. The placeholder is the user name prefix.
+// Output lines are "PURGED:<username>" followed by a final "TOTAL:<n>".
+const purgeDemoUsersScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+require_once($CFG->dirroot . '/user/lib.php');
+
+$users = $DB->get_records_select('user',
+    $DB->sql_like('username', ':generator') . ' AND deleted = 0',
+    ['generator' => $DB->sql_like_escape('%s') . '%%']);
+
+$manager = new \core_privacy\manager();
+$count = 0;
+foreach ($users as $user) {
+    $collection = $manager->get_contexts_for_userid($user->id);
+    $approved = new \core_privacy\local\request\contextlist_collection($user->id);
+    foreach ($collection as $contextlist) {
+        $approved->add_contextlist(new \core_privacy\local\request\approved_contextlist(
+            $user, $contextlist->get_component(), $contextlist->get_contextids()));
+    }
+    $manager->delete_data_for_user($approved);
+    delete_user($user);
+    echo "PURGED:" . $user->username . "\n";
+    $count++;
+}
+echo "TOTAL:" . $count . "\n";
+`
+
+// PurgeDemoUsers removes the users seeded by SeedDemoData and their data from Moodle
+func (m *Manager) PurgeDemoUsers(containerID string) (*PurgeResult, error) {
+	utils.LogInfo(fmt.Sprintf("Purging demo users (prefix %q) from container %s", GeneratorUserPrefix, containerID))
+
+	output, err := m.RunPHPScript(containerID, fmt.Sprintf(purgeDemoUsersScript, GeneratorUserPrefix))
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to purge demo users")
+	}
+
+	result, err := parsePurgeOutput(output)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "unexpected output from demo user purge")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Purged %d demo users", result.Count))
+	return result, nil
+}
+
+// parsePurgeOutput reads the PURGED/TOTAL lines written by the purge script
+func parsePurgeOutput(output string) (*PurgeResult, error) {
+	result := &PurgeResult{Usernames: make([]string, 0)}
+	sawTotal := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "PURGED:"):
+			result.Usernames = append(result.Usernames, strings.TrimPrefix(line, "PURGED:"))
+		case strings.HasPrefix(line, "TOTAL:"):
+			total, err := strconv.Atoi(strings.TrimPrefix(line, "TOTAL:"))
+			if err != nil {
+				return nil, errors.NewValidationErrorWithCause("total", "invalid purge total", line, err)
+			}
+			result.Count = total
+			sawTotal = true
+		}
+	}
+
+	if !sawTotal {
+		return nil, errors.NewValidationError("output", "purge script did not report a total", output)
+	}
+	return result, nil
+}
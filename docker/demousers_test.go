@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestParsePurgeOutput(t *testing.T) {
+	output := "PURGED:tool_generator_000001\nPURGED:tool_generator_000002\nTOTAL:2\n"
+
+	result, err := parsePurgeOutput(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Count != 2 || len(result.Usernames) != 2 {
+		t.Errorf("Expected 2 purged users, got count=%d usernames=%v", result.Count, result.Usernames)
+	}
+
+	if _, err := parsePurgeOutput("PHP Fatal error: something broke"); err == nil {
+		t.Error("Expected error when total is missing")
+	}
+}
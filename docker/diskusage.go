@@ -0,0 +1,248 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// ImageDiskUsage is one local version of the Moodle image
+type ImageDiskUsage struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository"`
+	// Tag is "<none>" for versions whose tag moved to a newer build during an upgrade
+	Tag     string `json:"tag"`
+	Created string `json:"created"`
+	Bytes   uint64 `json:"bytes"`
+	// Current is the version new containers are created from
+	Current bool `json:"current"`
+	// InUse is set when any container, including other users', was created from this version
+	InUse bool `json:"inUse"`
+}
+
+// ContainerDiskUsage is the disk space used by one of this user's Moodle containers
+type ContainerDiskUsage struct {
+	Name string `json:"name"`
+	WorkspaceUsage
+}
+
+// DockerDiskUsage is the disk space used by this manager's images, containers and volumes
+type DockerDiskUsage struct {
+	Images         []ImageDiskUsage     `json:"images"`
+	Containers     []ContainerDiskUsage `json:"containers"`
+	ImageBytes     uint64               `json:"imageBytes"`
+	ContainerBytes uint64               `json:"containerBytes"`
+	VolumeBytes    uint64               `json:"volumeBytes"`
+	TotalBytes     uint64               `json:"totalBytes"`
+	// ReclaimableBytes is what CleanupUnused would free. Image versions share layers, so
+	// the space actually freed may be smaller.
+	ReclaimableBytes uint64 `json:"reclaimableBytes"`
+}
+
+// CleanupResult reports the outcome of CleanupUnused
+type CleanupResult struct {
+	RemovedImages  []string `json:"removedImages"`
+	ReclaimedBytes uint64   `json:"reclaimedBytes"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// GetDockerDiskUsage reports the disk space used by local versions of the Moodle image and by
+// this user's Moodle containers. Other images, containers and volumes on the machine are not
+// included; `docker system df` covers those.
+func (m *Manager) GetDockerDiskUsage() (*DockerDiskUsage, error) {
+	images, err := m.listImageVersions()
+	if err != nil {
+		return nil, err
+	}
+	usage := &DockerDiskUsage{Images: images, Containers: make([]ContainerDiskUsage, 0)}
+	for _, image := range images {
+		usage.ImageBytes += image.Bytes
+		if removableImage(image) {
+			usage.ReclaimableBytes += image.Bytes
+		}
+	}
+
+	containers, err := m.ListInstanceContainers()
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		workspace, err := m.GetWorkspaceUsage(container)
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Skipping disk usage of %s: %v", container.Name, err))
+			continue
+		}
+		usage.Containers = append(usage.Containers, ContainerDiskUsage{Name: container.Name, WorkspaceUsage: *workspace})
+		usage.ContainerBytes += workspace.ContainerBytes
+		usage.VolumeBytes += workspace.VolumeBytes
+	}
+
+	usage.TotalBytes = usage.ImageBytes + usage.ContainerBytes + usage.VolumeBytes
+	return usage, nil
+}
+
+// CleanupUnused removes old versions of the Moodle image left behind by upgrades, keeping the
+// current version and any version a container (of any user) was created from
+func (m *Manager) CleanupUnused() (*CleanupResult, error) {
+	images, err := m.listImageVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CleanupResult{RemovedImages: make([]string, 0)}
+	for _, image := range images {
+		if !removableImage(image) {
+			continue
+		}
+
+		output, err := GetDockerCommand("rmi", image.ID).CombinedOutput()
+		if err != nil {
+			dockerErr := errors.NewDockerErrorWithImage("rmi", image.ID, err).WithOutput(string(output))
+			utils.LogWarning(fmt.Sprintf("Failed to remove old image %s: %v", shortImageID(image.ID), dockerErr))
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", shortImageID(image.ID), strings.TrimSpace(string(output))))
+			continue
+		}
+		result.RemovedImages = append(result.RemovedImages, fmt.Sprintf("%s:%s (%s)", image.Repository, image.Tag, shortImageID(image.ID)))
+		result.ReclaimedBytes += image.Bytes
+	}
+
+	utils.LogInfo(fmt.Sprintf("Removed %d old image versions, about %s reclaimed", len(result.RemovedImages), FormatBytes(result.ReclaimedBytes)))
+	return result, nil
+}
+
+// removableImage reports whether CleanupUnused may remove an image version
+func removableImage(image ImageDiskUsage) bool {
+	return !image.Current && !image.InUse
+}
+
+// listImageVersions lists local images of the configured image's repository
+func (m *Manager) listImageVersions() ([]ImageDiskUsage, error) {
+	if m.imageName == "" {
+		return nil, errors.NewValidationError("imageName", "no image name set in Docker manager", "")
+	}
+	repository := imageRepository(m.imageName)
+
+	cmd := GetDockerCommand("images", "--no-trunc", "--format", "{{.ID}}\t{{.Repository}}\t{{.Tag}}\t{{.CreatedSince}}", repository)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("images", repository, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list local image versions")
+	}
+	images := parseImageVersions(string(output))
+	if len(images) == 0 {
+		return images, nil
+	}
+
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		ids = append(ids, image.ID)
+	}
+	sizes, err := imageSizes(ids)
+	if err != nil {
+		return nil, err
+	}
+	used, err := containerImageIDs()
+	if err != nil {
+		return nil, err
+	}
+	current := ""
+	if output, err := GetDockerCommand("image", "inspect", "--format", "{{.Id}}", m.imageName).CombinedOutput(); err == nil {
+		current = strings.TrimSpace(string(output))
+	}
+
+	for i := range images {
+		images[i].Bytes = sizes[images[i].ID]
+		images[i].InUse = used[images[i].ID]
+		images[i].Current = images[i].ID == current
+	}
+	return images, nil
+}
+
+// parseImageVersions parses `docker images` lines of ID, repository, tag and age
+func parseImageVersions(output string) []ImageDiskUsage {
+	images := make([]ImageDiskUsage, 0)
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		// An image tagged twice is listed twice but only stored once
+		if seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		images = append(images, ImageDiskUsage{ID: fields[0], Repository: fields[1], Tag: fields[2], Created: fields[3]})
+	}
+	return images
+}
+
+// imageSizes returns the size in bytes of each image ID
+func imageSizes(ids []string) (map[string]uint64, error) {
+	args := append([]string{"image", "inspect", "--format", "{{.Id}}\t{{.Size}}"}, ids...)
+	output, err := GetDockerCommand(args...).CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("image_inspect", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to read image sizes")
+	}
+
+	sizes := make(map[string]uint64, len(ids))
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		id, size, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if bytes, err := strconv.ParseUint(strings.TrimSpace(size), 10, 64); err == nil {
+			sizes[id] = bytes
+		}
+	}
+	return sizes, nil
+}
+
+// containerImageIDs returns the IDs of images any container on the daemon was created from
+func containerImageIDs() (map[string]bool, error) {
+	output, err := GetDockerCommand("ps", "-aq", "--no-trunc").CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("ps", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list containers")
+	}
+	containers := strings.Fields(string(output))
+	used := make(map[string]bool)
+	if len(containers) == 0 {
+		return used, nil
+	}
+
+	args := append([]string{"inspect", "--format", "{{.Image}}"}, containers...)
+	output, err = GetDockerCommand(args...).CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("inspect", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to read container images")
+	}
+	for _, id := range strings.Fields(string(output)) {
+		used[id] = true
+	}
+	return used, nil
+}
+
+// imageRepository strips the tag and digest from an image name
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// shortImageID abbreviates a sha256 image ID as docker does
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,38 @@
+package docker
+
+import "testing"
+
+func TestImageRepository(t *testing.T) {
+	cases := map[string]string{
+		"wenkhairu/moodle-prototype:502-stable":      "wenkhairu/moodle-prototype",
+		"localhost:5000/moodle:dev":                  "localhost:5000/moodle",
+		"localhost:5000/moodle":                      "localhost:5000/moodle",
+		"wenkhairu/moodle-prototype@sha256:0123abcd": "wenkhairu/moodle-prototype",
+	}
+	for image, want := range cases {
+		if got := imageRepository(image); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestParseImageVersions(t *testing.T) {
+	output := "sha256:aaa\twenkhairu/moodle-prototype\t502-stable\t2 days ago\n" +
+		"sha256:aaa\twenkhairu/moodle-prototype\tlatest\t2 days ago\n" +
+		"sha256:bbb\twenkhairu/moodle-prototype\t<none>\t3 weeks ago\n"
+
+	images := parseImageVersions(output)
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 distinct images, got %+v", images)
+	}
+	if images[1].Tag != "<none>" || images[1].Created != "3 weeks ago" {
+		t.Errorf("Unexpected untagged image: %+v", images[1])
+	}
+
+	if removableImage(ImageDiskUsage{Current: true}) || removableImage(ImageDiskUsage{InUse: true}) {
+		t.Error("Expected current and in-use images to be kept")
+	}
+	if !removableImage(images[1]) {
+		t.Error("Expected an unused old version to be removable")
+	}
+}
@@ -0,0 +1,116 @@
+package docker
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+)
+
+const (
+	// MoodleDir is the Moodle code root inside the prototype image; other images' roots come
+	// from their adapter
+	MoodleDir = "/var/www/html"
+	// PHPBinary is the PHP CLI used to run Moodle admin scripts
+	PHPBinary = "php"
+)
+
+// ExecInContainer runs a command inside a running container and returns its output
+func (m *Manager) ExecInContainer(containerID string, command ...string) (string, error) {
+	// Validate container ID
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return "", errors.WrapWithContext(err, "invalid container ID provided to ExecInContainer")
+	}
+
+	if len(command) == 0 {
+		return "", errors.NewValidationError("command", "no command provided to ExecInContainer", nil)
+	}
+
+	args := append([]string{"exec", containerID}, command...)
+	cmd := GetDockerCommand(args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("exec", containerID, err).WithOutput(string(output))
+		return string(output), errors.WrapWithContext(dockerErr, "failed to execute command in container")
+	}
+
+	return string(output), nil
+}
+
+// ExecInContainerWithEnv runs a command inside a running container with extra environment
+// variables. Only the names go on the docker command line; the values reach docker through its
+// own environment, so secrets never show in a process list on the host or in the container.
+func (m *Manager) ExecInContainerWithEnv(containerID string, env map[string]string, command ...string) (string, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return "", errors.WrapWithContext(err, "invalid container ID provided to ExecInContainerWithEnv")
+	}
+
+	if len(command) == 0 {
+		return "", errors.NewValidationError("command", "no command provided to ExecInContainerWithEnv", nil)
+	}
+
+	cmd := GetDockerCommand(execEnvArgs(containerID, env, command)...)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	for name, value := range env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("exec", containerID, err).WithOutput(string(output))
+		return string(output), errors.WrapWithContext(dockerErr, "failed to execute command in container")
+	}
+
+	return string(output), nil
+}
+
+// execEnvArgs returns the `docker exec` arguments naming each variable of env without its value
+func execEnvArgs(containerID string, env map[string]string, command []string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []string{"exec"}
+	for _, name := range names {
+		args = append(args, "-e", name)
+	}
+	args = append(args, containerID)
+	return append(args, command...)
+}
+
+// RunMoodleCLI runs a Moodle admin CLI script (relative to the Moodle code root) inside the container
+func (m *Manager) RunMoodleCLI(containerID, script string, args ...string) (string, error) {
+	if err := errors.ValidateNotEmpty("script", script); err != nil {
+		return "", errors.WrapWithContext(err, "invalid script provided to RunMoodleCLI")
+	}
+
+	command := append([]string{PHPBinary, m.moodleDir() + "/" + script}, args...)
+	return m.ExecInContainer(containerID, command...)
+}
+
+// RunPHPScript pipes a PHP script to the container's PHP CLI via stdin.
+// The script runs from the Moodle code root so it can require config.php directly.
+func (m *Manager) RunPHPScript(containerID, script string) (string, error) {
+	// Validate container ID
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return "", errors.WrapWithContext(err, "invalid container ID provided to RunPHPScript")
+	}
+
+	if err := errors.ValidateNotEmpty("script", script); err != nil {
+		return "", errors.WrapWithContext(err, "invalid script provided to RunPHPScript")
+	}
+
+	cmd := GetDockerCommand("exec", "-i", "-w", m.moodleDir(), containerID, PHPBinary)
+	cmd.Stdin = strings.NewReader(script)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("exec_php", containerID, err).WithOutput(string(output))
+		return string(output), errors.WrapWithContext(dockerErr, "failed to run PHP script in container")
+	}
+
+	return string(output), nil
+}
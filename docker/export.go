@@ -0,0 +1,437 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Production export artifact names
+const (
+	ExportComposeFile   = "docker-compose.yml"
+	ExportEnvFile       = "moodle.env"
+	ExportDatabaseFile  = "database.sql.gz"
+	ExportDataFile      = "moodledata.tar.gz"
+	ExportPluginsFile   = "plugins.tar.gz"
+	ExportChecklistFile = "CHECKLIST.md"
+
+	// exportStagingDir holds artifacts inside the container before they are copied out
+	exportStagingDir = "/tmp/moodle-prototype-export"
+)
+
+// siteConfigScript prints the settings from config.php needed for an export
+const siteConfigScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+echo 'CONFIG:' . json_encode([
+    'wwwroot'  => $CFG->wwwroot,
+    'dataroot' => $CFG->dataroot,
+    'dbtype'   => $CFG->dbtype,
+    'dbhost'   => $CFG->dbhost,
+    'dbname'   => $CFG->dbname,
+    'dbuser'   => $CFG->dbuser,
+    'dbpass'   => $CFG->dbpass,
+    'prefix'   => $CFG->prefix,
+    'release'  => $CFG->release,
+]) . "\n";
+`
+
+// addonPluginsScript prints the component and directory, relative to the Moodle code root, of
+// every plugin that is not part of the Moodle release
+const addonPluginsScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+foreach (core_plugin_manager::instance()->get_plugins() as $plugins) {
+    foreach ($plugins as $plugin) {
+        if (!$plugin->is_standard() && !empty($plugin->rootdir)) {
+            echo 'PLUGIN:' . $plugin->component . "\t" . substr($plugin->rootdir, strlen($CFG->dirroot) + 1) . "\n";
+        }
+    }
+}
+`
+
+// addonPlugin is a plugin installed into the prototype on top of the Moodle release
+type addonPlugin struct {
+	Component string
+	Dir       string
+}
+
+// SiteConfig holds the config.php values of the prototype site
+type SiteConfig struct {
+	WWWRoot  string `json:"wwwroot"`
+	DataRoot string `json:"dataroot"`
+	DBType   string `json:"dbtype"`
+	DBHost   string `json:"dbhost"`
+	DBName   string `json:"dbname"`
+	DBUser   string `json:"dbuser"`
+	DBPass   string `json:"-"`
+	Prefix   string `json:"prefix"`
+	Release  string `json:"release"`
+}
+
+// UnmarshalJSON reads dbpass, which is deliberately never serialised back out
+func (c *SiteConfig) UnmarshalJSON(data []byte) error {
+	type plain SiteConfig
+	var raw struct {
+		plain
+		DBPass string `json:"dbpass"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = SiteConfig(raw.plain)
+	c.DBPass = raw.DBPass
+	return nil
+}
+
+// ProductionExport describes the artifacts produced for an ops hand-over
+type ProductionExport struct {
+	Directory string     `json:"directory"`
+	Files     []string   `json:"files"`
+	Checklist []string   `json:"checklist"`
+	Site      SiteConfig `json:"site"`
+	// Plugins are the add-on plugins archived in ExportPluginsFile
+	Plugins []string `json:"plugins,omitempty"`
+}
+
+// productionChecklist lists what must change before the prototype runs in production
+var productionChecklist = []string{
+	"Change the admin password and every demo account password (the prototype credentials are not secret)",
+	"Set wwwroot to the production URL; the prototype uses a localhost address",
+	"Set a strong database password in moodle.env and restrict database access to the Moodle host",
+	"Schedule Moodle cron (admin/cli/cron.php) every minute on the server",
+	"Terminate HTTPS in front of Moodle and set sslproxy if a reverse proxy is used",
+	"Configure outgoing mail (SMTP) and the noreply address",
+	"Remove demo users, test courses and any plugins installed only for the prototype",
+	"Set debugging to NONE and disable debugdisplay",
+	"Set up backups for the database and moodledata",
+}
+
+const composeTemplate = `# Generated by Moodle Prototype Manager on {{.Generated}}
+# Moodle {{.Site.Release}}
+services:
+  db:
+    image: {{.DBImage}}
+    restart: unless-stopped
+    env_file: moodle.env
+    volumes:
+      - db:/var/lib/{{.DBVolumeDir}}
+      - ./database.sql.gz:/docker-entrypoint-initdb.d/database.sql.gz:ro
+
+  moodle:
+    image: {{.Image}}
+    restart: unless-stopped
+    env_file: moodle.env
+    depends_on:
+      - db
+    ports:
+      - "80:{{.ContainerPort}}"
+    volumes:
+      - moodledata:{{.Site.DataRoot}}
+
+volumes:
+  db:
+  moodledata:
+`
+
+const envTemplate = `# Fill in every value before deploying; docker-compose.yml reads this file.
+# Production URL (replaces {{.Site.WWWRoot}})
+MOODLE_WWWROOT=https://moodle.example.com
+
+# Database ({{.Site.DBType}}); the prototype dump is loaded on first start
+MOODLE_DB_TYPE={{.Site.DBType}}
+MOODLE_DB_HOST=db
+MOODLE_DB_NAME={{.Site.DBName}}
+MOODLE_DB_USER={{.Site.DBUser}}
+MOODLE_DB_PASSWORD=change-me
+MOODLE_DB_PREFIX={{.Site.Prefix}}
+{{if .Postgres}}POSTGRES_DB={{.Site.DBName}}
+POSTGRES_USER={{.Site.DBUser}}
+POSTGRES_PASSWORD=change-me
+{{else}}MARIADB_DATABASE={{.Site.DBName}}
+MARIADB_USER={{.Site.DBUser}}
+MARIADB_PASSWORD=change-me
+MARIADB_ROOT_PASSWORD=change-me-too
+{{end}}
+# Moodle data directory inside the container (restore moodledata.tar.gz here)
+MOODLE_DATAROOT={{.Site.DataRoot}}
+`
+
+// GetSiteConfig reads the site configuration from config.php
+func (m *Manager) GetSiteConfig(containerID string) (*SiteConfig, error) {
+	output, err := m.RunPHPScript(containerID, siteConfigScript)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to read Moodle configuration")
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if payload, ok := strings.CutPrefix(strings.TrimSpace(line), "CONFIG:"); ok {
+			var config SiteConfig
+			if err := json.Unmarshal([]byte(payload), &config); err != nil {
+				return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected Moodle configuration output: %v", err)
+			}
+			// config.php may leave the data root to the image's setup scripts
+			if config.DataRoot == "" {
+				config.DataRoot = m.Adapter().DataDir
+			}
+			return &config, nil
+		}
+	}
+	return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "Moodle configuration not found in output: %s", output)
+}
+
+// ExportForProduction writes a compose file, database dump, moodledata archive, documented
+// environment and hand-over checklist to outDir
+func (m *Manager) ExportForProduction(containerID, outDir string, progressCallback func(float64, string)) (*ProductionExport, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to ExportForProduction")
+	}
+	if err := errors.ValidateFilePath("outDir", outDir); err != nil {
+		return nil, err
+	}
+
+	report := func(percentage float64, status string) {
+		utils.LogInfo(fmt.Sprintf("Production export: %s", status))
+		if progressCallback != nil {
+			progressCallback(percentage, status)
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, errors.NewFileError("create", outDir, err)
+	}
+
+	report(5, "Reading site configuration")
+	site, err := m.GetSiteConfig(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.ExecInContainer(containerID, "mkdir", "-p", exportStagingDir); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to prepare export directory in container")
+	}
+	defer m.ExecInContainer(containerID, "rm", "-rf", exportStagingDir)
+
+	report(15, "Dumping database")
+	if err := m.dumpDatabase(containerID, site, exportStagingDir+"/"+ExportDatabaseFile); err != nil {
+		return nil, err
+	}
+
+	report(45, "Archiving moodledata")
+	archive := fmt.Sprintf("tar -czf %s -C %s .", shellQuote(exportStagingDir+"/"+ExportDataFile), shellQuote(site.DataRoot))
+	if _, err := m.ExecInContainer(containerID, "sh", "-c", archive); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to archive moodledata")
+	}
+
+	report(60, "Archiving add-on plugins")
+	plugins, err := m.archiveAddonPlugins(containerID, exportStagingDir+"/"+ExportPluginsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	report(75, "Copying artifacts from the container")
+	if err := m.CopyFromContainer(containerID, exportStagingDir+"/.", outDir); err != nil {
+		return nil, err
+	}
+
+	report(90, "Writing compose file and documentation")
+	checklist := exportChecklist(m.moodleDir(), plugins)
+	if err := writeExportDocuments(outDir, m.imageName, m.Adapter().ContainerPort, site, checklist); err != nil {
+		return nil, err
+	}
+
+	result := &ProductionExport{
+		Directory: outDir,
+		Files:     []string{ExportComposeFile, ExportEnvFile, ExportDatabaseFile, ExportDataFile, ExportChecklistFile},
+		Checklist: checklist,
+		Site:      *site,
+	}
+	for _, plugin := range plugins {
+		result.Plugins = append(result.Plugins, plugin.Component)
+	}
+	if len(plugins) > 0 {
+		result.Files = append(result.Files, ExportPluginsFile)
+	}
+	report(100, fmt.Sprintf("Exported to %s", outDir))
+	return result, nil
+}
+
+// dumpDatabase writes a gzipped SQL dump of the Moodle database inside the container
+func (m *Manager) dumpDatabase(containerID string, site *SiteConfig, target string) error {
+	passwordEnv, script, err := databaseDumpScript(site, target)
+	if err != nil {
+		return err
+	}
+
+	// The password goes through the environment so it appears in no process list
+	env := map[string]string{passwordEnv: site.DBPass}
+	if _, err := m.ExecInContainerWithEnv(containerID, env, "sh", "-c", script); err != nil {
+		return errors.WrapWithContext(err, "failed to dump %s database", site.DBType)
+	}
+	return nil
+}
+
+// databaseDumpScript returns the environment variable the dump tool reads the password from
+// and the shell script dumping the site's database to target
+func databaseDumpScript(site *SiteConfig, target string) (string, string, error) {
+	var passwordEnv, dump string
+	switch site.DBType {
+	case "pgsql":
+		passwordEnv = "PGPASSWORD"
+		dump = fmt.Sprintf("pg_dump -h %s -U %s %s", shellQuote(site.DBHost), shellQuote(site.DBUser), shellQuote(site.DBName))
+	case "mysqli", "mariadb", "auroramysql":
+		passwordEnv = "MYSQL_PWD"
+		dump = fmt.Sprintf("mysqldump --single-transaction -h %s -u %s %s", shellQuote(site.DBHost), shellQuote(site.DBUser), shellQuote(site.DBName))
+	default:
+		return "", "", errors.NewValidationError("dbtype", "database type is not supported for export", site.DBType)
+	}
+
+	// Dump to a plain file first so a failed dump is not hidden by gzip succeeding
+	plain := strings.TrimSuffix(target, ".gz")
+	return passwordEnv, fmt.Sprintf("%s > %s && gzip -f %s", dump, shellQuote(plain), shellQuote(plain)), nil
+}
+
+// archiveAddonPlugins archives the code of the add-on plugins inside the container, which the
+// production image does not have. Nothing is written when there are none.
+func (m *Manager) archiveAddonPlugins(containerID, target string) ([]addonPlugin, error) {
+	output, err := m.RunPHPScript(containerID, addonPluginsScript)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to list add-on plugins")
+	}
+	plugins := parseAddonPlugins(output)
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+
+	dirs := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		dirs[i] = shellQuote(plugin.Dir)
+	}
+	archive := fmt.Sprintf("tar -czf %s -C %s %s", shellQuote(target), shellQuote(m.moodleDir()), strings.Join(dirs, " "))
+	if _, err := m.ExecInContainer(containerID, "sh", "-c", archive); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to archive add-on plugins")
+	}
+	return plugins, nil
+}
+
+// parseAddonPlugins reads the PLUGIN: lines printed by addonPluginsScript, skipping any
+// directory outside the Moodle code root
+func parseAddonPlugins(output string) []addonPlugin {
+	var plugins []addonPlugin
+	for _, line := range strings.Split(output, "\n") {
+		payload, ok := strings.CutPrefix(strings.TrimSpace(line), "PLUGIN:")
+		if !ok {
+			continue
+		}
+		component, dir, ok := strings.Cut(payload, "\t")
+		if !ok || dir == "" || path.IsAbs(dir) || strings.HasPrefix(path.Clean(dir), "..") {
+			continue
+		}
+		plugins = append(plugins, addonPlugin{Component: component, Dir: path.Clean(dir)})
+	}
+	return plugins
+}
+
+// exportChecklist returns productionChecklist, with the steps restoring the add-on plugins
+// when there are any
+func exportChecklist(moodleDir string, plugins []addonPlugin) []string {
+	checklist := slices.Clone(productionChecklist)
+	if len(plugins) == 0 {
+		return checklist
+	}
+	components := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		components[i] = plugin.Component
+	}
+	return append(checklist, fmt.Sprintf("Extract %s into %s of the Moodle image and run admin/cli/upgrade.php; the production image lacks the add-on plugins %s",
+		ExportPluginsFile, moodleDir, strings.Join(components, ", ")))
+}
+
+// CopyFromContainer copies a container file or directory to a host path
+func (m *Manager) CopyFromContainer(containerID, containerPath, hostPath string) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to CopyFromContainer")
+	}
+
+	cmd := GetDockerCommand("cp", containerID+":"+containerPath, hostPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("cp", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to copy %s from container", containerPath)
+	}
+	return nil
+}
+
+// writeExportDocuments renders the compose file, environment file and checklist
+func writeExportDocuments(outDir, image string, containerPort int, site *SiteConfig, checklistItems []string) error {
+	postgres := site.DBType == "pgsql"
+	data := map[string]any{
+		"Generated":     time.Now().Format(time.RFC3339),
+		"Image":         image,
+		"ContainerPort": containerPort,
+		"Site":          site,
+		"Postgres":      postgres,
+		"DBImage":       "mariadb:10.11",
+		"DBVolumeDir":   "mysql",
+	}
+	if postgres {
+		data["DBImage"] = "postgres:16"
+		data["DBVolumeDir"] = "postgresql/data"
+	}
+
+	templates := map[string]string{
+		ExportComposeFile: bundle.Text(bundle.ComposeTemplate, composeTemplate),
+		ExportEnvFile:     bundle.Text(bundle.EnvTemplate, envTemplate),
+	}
+	for name, text := range templates {
+		if err := renderTemplate(filepath.Join(outDir, name), text, data); err != nil {
+			return err
+		}
+	}
+
+	var checklist strings.Builder
+	checklist.WriteString("# Production hand-over checklist\n\n")
+	fmt.Fprintf(&checklist, "Exported from the prototype at %s (Moodle %s).\n\n", site.WWWRoot, site.Release)
+	for _, item := range checklistItems {
+		fmt.Fprintf(&checklist, "- [ ] %s\n", item)
+	}
+	checklistPath := filepath.Join(outDir, ExportChecklistFile)
+	if err := os.WriteFile(checklistPath, []byte(checklist.String()), 0644); err != nil {
+		return errors.NewFileError("write", checklistPath, err)
+	}
+	return nil
+}
+
+// renderTemplate executes a text template into a file
+func renderTemplate(path, text string, data any) error {
+	tmpl, err := template.New(filepath.Base(path)).Parse(text)
+	if err != nil {
+		return errors.WrapWithContext(err, "invalid template for %s", path)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.NewFileError("create", path, err)
+	}
+	defer file.Close()
+
+	if err := tmpl.Execute(file, data); err != nil {
+		return errors.NewFileError("write", path, err)
+	}
+	return nil
+}
+
+// shellQuote single-quotes a value for sh -c
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
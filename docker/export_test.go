@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSiteConfigKeepsPasswordPrivate(t *testing.T) {
+	var config SiteConfig
+	if err := json.Unmarshal([]byte(`{"dbtype":"mariadb","dbname":"moodle","dbpass":"secret"}`), &config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.DBPass != "secret" || config.DBName != "moodle" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	encoded, _ := json.Marshal(config)
+	if strings.Contains(string(encoded), "secret") {
+		t.Errorf("Password must not be serialised: %s", encoded)
+	}
+}
+
+func TestWriteExportDocuments(t *testing.T) {
+	dir := t.TempDir()
+	site := &SiteConfig{WWWRoot: "http://localhost:8080", DataRoot: "/var/www/moodledata", DBType: "pgsql", DBName: "moodle", DBUser: "moodle", Release: "5.0"}
+
+	checklist := exportChecklist("/var/www/html", []addonPlugin{{Component: "mod_hvp", Dir: "mod/hvp"}})
+	if err := writeExportDocuments(dir, "wenkhairu/moodle-prototype:502-stable", 8443, site, checklist); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	compose, err := os.ReadFile(filepath.Join(dir, ExportComposeFile))
+	if err != nil {
+		t.Fatalf("Compose file not written: %v", err)
+	}
+	for _, expected := range []string{"postgres:16", "wenkhairu/moodle-prototype:502-stable", "moodledata:/var/www/moodledata", `"80:8443"`, "env_file: " + ExportEnvFile} {
+		if !strings.Contains(string(compose), expected) {
+			t.Errorf("Expected %q in compose file:\n%s", expected, compose)
+		}
+	}
+
+	env, _ := os.ReadFile(filepath.Join(dir, ExportEnvFile))
+	if !strings.Contains(string(env), "POSTGRES_PASSWORD=change-me") {
+		t.Errorf("Expected postgres variables in env file:\n%s", env)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, ExportChecklistFile))
+	if err != nil {
+		t.Fatalf("Checklist not written: %v", err)
+	}
+	if !strings.Contains(string(written), ExportPluginsFile) || !strings.Contains(string(written), "mod_hvp") {
+		t.Errorf("Expected the add-on plugins in the checklist:\n%s", written)
+	}
+}
+
+func TestDatabaseDumpScriptKeepsPasswordOutOfArguments(t *testing.T) {
+	for dbType, expected := range map[string]string{"pgsql": "PGPASSWORD", "mariadb": "MYSQL_PWD"} {
+		site := &SiteConfig{DBType: dbType, DBHost: "db", DBName: "moodle", DBUser: "moodle", DBPass: "s3cret"}
+		passwordEnv, script, err := databaseDumpScript(site, "/tmp/export/database.sql.gz")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dbType, err)
+		}
+		if passwordEnv != expected {
+			t.Errorf("%s: expected the password in %s, got %s", dbType, expected, passwordEnv)
+		}
+
+		args := execEnvArgs(strings.Repeat("a", 64), map[string]string{passwordEnv: site.DBPass}, []string{"sh", "-c", script})
+		if strings.Contains(strings.Join(args, " "), "s3cret") {
+			t.Errorf("%s: password in docker arguments: %q", dbType, args)
+		}
+		if args[1] != "-e" || args[2] != expected {
+			t.Errorf("%s: expected -e %s before the container, got %q", dbType, expected, args)
+		}
+	}
+}
+
+func TestParseAddonPlugins(t *testing.T) {
+	output := "PHP Notice: ignored\nPLUGIN:mod_hvp\tmod/hvp\nPLUGIN:local_bad\t../../etc\nPLUGIN:theme_moove\ttheme/moove\n"
+	plugins := parseAddonPlugins(output)
+	if len(plugins) != 2 || plugins[0] != (addonPlugin{Component: "mod_hvp", Dir: "mod/hvp"}) || plugins[1].Component != "theme_moove" {
+		t.Errorf("Unexpected plugins: %+v", plugins)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if quoted := shellQuote("it's"); quoted != `'it'\''s'` {
+		t.Errorf("Unexpected quoting: %s", quoted)
+	}
+}
@@ -0,0 +1,723 @@
+package docker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+)
+
+// FakeContainer is a container of a FakeClient
+type FakeContainer struct {
+	ID       string
+	Name     string
+	Image    string
+	Instance string
+	User     string
+	Running  bool
+	ExitCode int
+	// OOMKilled makes CheckInstallContainer report an out-of-memory install failure
+	OOMKilled bool
+	// Health is the health check status; empty means the container has none
+	Health  string
+	WWWRoot string
+	// HostPort is the published Moodle port, listed while the container runs
+	HostPort int
+	// AdminPassword is the login set through the environment, for images whose adapter
+	// presets it
+	AdminPassword string
+	// Volumes are the names of the volumes RemoveContainerAndVolumes removes with it
+	Volumes []string
+	// DiskBytes is the size MeasureWorkspaceUsage reports for the container and its volumes
+	DiskBytes uint64
+}
+
+// fakeLogLine is a log line with the time it was written
+type fakeLogLine struct {
+	logged time.Time
+	text   string
+}
+
+// FakeClient is an in-memory Client for tests of code that drives Docker. Images and
+// containers are kept in maps, log lines are timestamped as they are added, and every call is
+// recorded so tests can check which operations ran and in what order.
+type FakeClient struct {
+	mu sync.Mutex
+
+	imageName    string
+	instanceName string
+	hostPort     int
+	userName     string
+	stopTimeout  time.Duration
+	mailCatcher  bool
+	adminer      bool
+
+	images     map[string]bool
+	containers map[string]*FakeContainer
+	logs       map[string][]fakeLogLine
+	lastLogged time.Time
+	failures   map[string]error
+	calls      []string
+}
+
+// NewFakeClient creates a fake with no images or containers
+func NewFakeClient(imageName string) *FakeClient {
+	return &FakeClient{
+		imageName:   imageName,
+		hostPort:    DefaultHostPort,
+		userName:    "tester",
+		stopTimeout: DefaultStopTimeout,
+		images:      make(map[string]bool),
+		containers:  make(map[string]*FakeContainer),
+		logs:        make(map[string][]fakeLogLine),
+		failures:    make(map[string]error),
+	}
+}
+
+// AddImage makes image available locally
+func (f *FakeClient) AddImage(image string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.images[image] = true
+}
+
+// AddContainer adds a container, e.g. one that exists before the test starts
+func (f *FakeClient) AddContainer(container FakeContainer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if container.Instance == "" {
+		container.Instance = f.instance()
+	}
+	if container.User == "" {
+		container.User = f.userName
+	}
+	f.containers[container.ID] = &container
+}
+
+// Container returns a copy of the container with the given ID
+func (f *FakeClient) Container(containerID string) (FakeContainer, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	container, ok := f.containers[containerID]
+	if !ok {
+		return FakeContainer{}, false
+	}
+	return *container, true
+}
+
+// AddLogs appends lines to a container's log
+func (f *FakeClient) AddLogs(containerID string, lines ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, line := range lines {
+		// Keep timestamps strictly increasing, as cursors read lines logged after the last one
+		logged := time.Now()
+		if !logged.After(f.lastLogged) {
+			logged = f.lastLogged.Add(time.Microsecond)
+		}
+		f.lastLogged = logged
+		f.logs[containerID] = append(f.logs[containerID], fakeLogLine{logged: logged, text: line})
+	}
+}
+
+// FailOn makes every later call of method return err; a nil err removes the failure
+func (f *FakeClient) FailOn(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// Calls returns the methods called so far, in order. Configuration getters and setters are
+// not recorded.
+func (f *FakeClient) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// call records method and returns the failure configured for it; f.mu must be held
+func (f *FakeClient) call(method string) error {
+	f.calls = append(f.calls, method)
+	return f.failures[method]
+}
+
+// container returns the container with the given ID or a not-found error; f.mu must be held
+func (f *FakeClient) container(containerID string) (*FakeContainer, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, err
+	}
+	container, ok := f.containers[containerID]
+	if !ok {
+		return nil, errors.NewDockerErrorWithContainer("inspect", containerID, errors.ErrContainerNotFound)
+	}
+	return container, nil
+}
+
+func (f *FakeClient) GetImageName() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.imageName
+}
+
+func (f *FakeClient) SetImageName(imageName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.imageName = imageName
+}
+
+func (f *FakeClient) GetInstanceName() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.instance()
+}
+
+func (f *FakeClient) SetInstanceName(instance string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if instance != "" {
+		instance = sanitizeName(instance)
+	}
+	f.instanceName = instance
+}
+
+// instance returns the instance name, which defaults to the user name; f.mu must be held
+func (f *FakeClient) instance() string {
+	if f.instanceName == "" {
+		return f.userName
+	}
+	return f.instanceName
+}
+
+func (f *FakeClient) GetHostPort() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hostPort
+}
+
+func (f *FakeClient) SetHostPort(port int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hostPort = port
+}
+
+func (f *FakeClient) GetUserName() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.userName
+}
+
+func (f *FakeClient) SetMemoryLimit(bytes int64) {}
+
+func (f *FakeClient) SetStopTimeout(timeout time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopTimeout = timeout
+}
+
+func (f *FakeClient) SetRestartPolicy(policy string) error { return nil }
+
+func (f *FakeClient) SetLogLevels(levels LogLevels) error { return nil }
+
+func (f *FakeClient) SetContainerEnv(env map[string]string) {}
+
+func (f *FakeClient) SetMailCatcher(enabled bool, uiPort int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mailCatcher = enabled
+}
+
+func (f *FakeClient) GetMailCatcher() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mailCatcher
+}
+
+func (f *FakeClient) SetAdminer(enabled bool, port int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.adminer = enabled
+}
+
+func (f *FakeClient) GetAdminer() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.adminer
+}
+
+func (f *FakeClient) SetRegistryCache(cache *RegistryCache) {}
+
+func (f *FakeClient) CheckImageExists() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CheckImageExists"); err != nil {
+		return false, err
+	}
+	return f.images[f.imageName], nil
+}
+
+func (f *FakeClient) PullImage() error {
+	return f.PullImageWithProgress(nil)
+}
+
+func (f *FakeClient) PullImageWithProgress(progressCallback func(float64, string)) error {
+	f.mu.Lock()
+	if err := f.call("PullImage"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	f.images[f.imageName] = true
+	f.mu.Unlock()
+
+	if progressCallback != nil {
+		progressCallback(100, "Download complete")
+	}
+	return nil
+}
+
+func (f *FakeClient) GetLocalImageDigests(image string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetLocalImageDigests"); err != nil {
+		return nil, err
+	}
+	if !f.images[image] {
+		return nil, nil
+	}
+	return []string{image + "@sha256:" + strings.Repeat("0", 64)}, nil
+}
+
+// RunContainer creates and starts a container of the image; its log is empty until AddLogs
+func (f *FakeClient) RunContainer() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("RunContainer"); err != nil {
+		return "", err
+	}
+	if !f.images[f.imageName] {
+		return "", errors.NewDockerErrorWithImage("run", f.imageName, errors.ErrImageNotFound)
+	}
+
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	container := &FakeContainer{
+		ID:       hex.EncodeToString(id),
+		Name:     fmt.Sprintf("moodle-%s-%s", f.userName, f.instance()),
+		Image:    f.imageName,
+		Instance: f.instance(),
+		User:     f.userName,
+		Running:  true,
+		HostPort: f.hostPort,
+	}
+	if AdapterFor(f.imageName).PresetsPassword() {
+		password, err := generatePassword()
+		if err != nil {
+			return "", err
+		}
+		container.AdminPassword = password
+	}
+	f.containers[container.ID] = container
+	return container.ID, nil
+}
+
+func (f *FakeClient) StartContainer(containerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("StartContainer"); err != nil {
+		return err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return err
+	}
+	container.Running = true
+	container.ExitCode = 0
+	return nil
+}
+
+func (f *FakeClient) StopContainerWithEscalation(containerID string, progressCallback func(StopProgress)) error {
+	f.mu.Lock()
+	if err := f.call("StopContainerWithEscalation"); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	container.Running = false
+	timeout := f.stopTimeout
+	f.mu.Unlock()
+
+	if progressCallback != nil {
+		progressCallback(StopProgress{Stage: StopStageTerminating, Timeout: timeout.Seconds(), Message: "sending SIGTERM"})
+	}
+	return nil
+}
+
+func (f *FakeClient) ForceStopContainer(containerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ForceStopContainer"); err != nil {
+		return err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return err
+	}
+	container.Running = false
+	container.ExitCode = 137
+	return nil
+}
+
+func (f *FakeClient) ValidateContainerID(containerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ValidateContainerID"); err != nil {
+		return err
+	}
+	_, err := f.container(containerID)
+	return err
+}
+
+func (f *FakeClient) IsContainerRunning(containerID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("IsContainerRunning"); err != nil {
+		return false, err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return false, err
+	}
+	return container.Running, nil
+}
+
+func (f *FakeClient) GetContainerHealth(containerID string) (*HealthReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetContainerHealth"); err != nil {
+		return nil, err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	status := container.Health
+	if status == "" {
+		status = HealthNone
+	}
+	return &HealthReport{Status: status, CheckedAt: time.Now()}, nil
+}
+
+func (f *FakeClient) CheckInstallContainer(containerID, logs string) (*InstallFailure, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CheckInstallContainer"); err != nil {
+		return nil, err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if container.Running {
+		return nil, nil
+	}
+	failure := &InstallFailure{
+		Reason:   InstallFailureExited,
+		Message:  fmt.Sprintf("The Moodle container stopped during installation (exit code %d)", container.ExitCode),
+		Excerpt:  LastLogLines(logs, installExcerptLines),
+		ExitCode: container.ExitCode,
+	}
+	if container.OOMKilled {
+		failure.Reason = InstallFailureOOM
+	}
+	return failure, nil
+}
+
+func (f *FakeClient) PresetPassword(containerID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("PresetPassword"); err != nil {
+		return "", err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return "", err
+	}
+	return container.AdminPassword, nil
+}
+
+// ResetAdminPassword sets a fixed new password, stored as the container's AdminPassword
+func (f *FakeClient) ResetAdminPassword(containerID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ResetAdminPassword"); err != nil {
+		return "", err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return "", err
+	}
+	container.AdminPassword = "Reset-Pa55word"
+	return container.AdminPassword, nil
+}
+
+// FindInstanceContainer returns the first container, by ID, of the current user and instance
+func (f *FakeClient) FindInstanceContainer() (*ManagedContainer, error) {
+	containers, err := f.ListManagedContainers()
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, container := range containers {
+		if container.User == f.userName && container.Instance == f.instance() {
+			return &container, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeClient) ListManagedContainers() ([]ManagedContainer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListManagedContainers"); err != nil {
+		return nil, err
+	}
+	containers := make([]ManagedContainer, 0, len(f.containers))
+	for _, container := range f.containers {
+		state, ports := "exited", []int{}
+		if container.Running {
+			state = "running"
+			if container.HostPort != 0 {
+				ports = append(ports, container.HostPort)
+			}
+		}
+		containers = append(containers, ManagedContainer{
+			ID:        container.ID[:12],
+			Name:      container.Name,
+			User:      container.User,
+			Instance:  container.Instance,
+			State:     state,
+			HostPorts: ports,
+		})
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].ID < containers[j].ID })
+	return containers, nil
+}
+
+func (f *FakeClient) MeasureWorkspaceUsage(container ManagedContainer) (*WorkspaceUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("MeasureWorkspaceUsage"); err != nil {
+		return nil, err
+	}
+	for id, candidate := range f.containers {
+		if strings.HasPrefix(id, container.ID) {
+			return &WorkspaceUsage{Instance: candidate.Instance, VolumeBytes: candidate.DiskBytes, TotalBytes: candidate.DiskBytes}, nil
+		}
+	}
+	return nil, errors.NewDockerErrorWithContainer("inspect", container.ID, errors.ErrContainerNotFound)
+}
+
+func (f *FakeClient) RemoveContainer(containerID string, force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("RemoveContainer"); err != nil {
+		return err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return err
+	}
+	if container.Running && !force {
+		return errors.NewDockerErrorWithContainer("rm", containerID, errors.ErrContainerRunning)
+	}
+	delete(f.containers, containerID)
+	delete(f.logs, containerID)
+	return nil
+}
+
+func (f *FakeClient) RemoveContainerAndVolumes(containerID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("RemoveContainerAndVolumes"); err != nil {
+		return nil, err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.containers, containerID)
+	delete(f.logs, containerID)
+	return append([]string{}, container.Volumes...), nil
+}
+
+func (f *FakeClient) StartMailCatcher(containerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("StartMailCatcher"); err != nil {
+		return err
+	}
+	_, err := f.container(containerID)
+	return err
+}
+
+func (f *FakeClient) StopMailCatcher() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.call("StopMailCatcher")
+}
+
+func (f *FakeClient) StartAdminer(containerID string) (*AdminerInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("StartAdminer"); err != nil {
+		return nil, err
+	}
+	if _, err := f.container(containerID); err != nil {
+		return nil, err
+	}
+	return &AdminerInfo{URL: fmt.Sprintf("http://localhost:%d", DefaultAdminerPort), Server: moodleHostAlias, Driver: "server"}, nil
+}
+
+func (f *FakeClient) StopAdminer() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.call("StopAdminer")
+}
+
+// FetchContainerLogs returns the container's log lines, honouring Tail, Since and Timestamps
+func (f *FakeClient) FetchContainerLogs(containerID string, opts LogFetchOptions) (*LogChunk, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("FetchContainerLogs"); err != nil {
+		return nil, err
+	}
+	if _, err := f.container(containerID); err != nil {
+		return nil, err
+	}
+
+	var lines []fakeLogLine
+	for _, line := range f.logs[containerID] {
+		if opts.Since.IsZero() || line.logged.After(opts.Since) {
+			lines = append(lines, line)
+		}
+	}
+	tail := opts.Tail
+	if tail == 0 {
+		tail = DefaultLogTail
+	}
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+
+	var out strings.Builder
+	for _, line := range lines {
+		if opts.Timestamps {
+			out.WriteString(line.logged.UTC().Format(time.RFC3339Nano) + " ")
+		}
+		out.WriteString(line.text + "\n")
+	}
+	return &LogChunk{Logs: out.String(), TotalBytes: int64(out.Len())}, nil
+}
+
+func (f *FakeClient) NewLogCursor(containerID string, initial LogFetchOptions) *LogCursor {
+	return &LogCursor{fetcher: f, containerID: containerID, initial: initial}
+}
+
+// FollowContainerLogs replays the last tail lines to onLine; the returned stream has already
+// ended, as the fake does not produce new output on its own
+func (f *FakeClient) FollowContainerLogs(containerID string, tail int, onLine func(string)) (*LogFollower, error) {
+	chunk, err := f.FetchContainerLogs(containerID, LogFetchOptions{Tail: max(tail, 1)})
+	if err != nil {
+		return nil, err
+	}
+	if tail > 0 {
+		for _, line := range strings.Split(strings.TrimSuffix(chunk.Logs, "\n"), "\n") {
+			onLine(line)
+		}
+	}
+	follower := &LogFollower{stop: func() {}, done: make(chan struct{})}
+	close(follower.done)
+	return follower, nil
+}
+
+func (f *FakeClient) GetWWWRoot(containerID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetWWWRoot"); err != nil {
+		return "", err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return "", err
+	}
+	return container.WWWRoot, nil
+}
+
+func (f *FakeClient) ConfigureWWWRoot(containerID, wwwroot string, sslproxy bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ConfigureWWWRoot"); err != nil {
+		return err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return err
+	}
+	container.WWWRoot = wwwroot
+	return nil
+}
+
+func (f *FakeClient) ConfigureSite(containerID, fullName, shortName, adminEmail string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ConfigureSite"); err != nil {
+		return err
+	}
+	_, err := f.container(containerID)
+	return err
+}
+
+func (f *FakeClient) SeedDemoData(containerID, size string, progressCallback func(float64, string)) (*SeedResult, error) {
+	size, err := ValidateDemoDataSize(size)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("SeedDemoData"); err != nil {
+		return nil, err
+	}
+	if _, err := f.container(containerID); err != nil {
+		return nil, err
+	}
+	return &SeedResult{Size: size, Duration: "0s"}, nil
+}
+
+// ExportInstance returns the archive it would have written, without writing a file
+func (f *FakeClient) ExportInstance(containerID, path string, manifest InstanceManifest, progressCallback func(float64, string)) (*InstanceArchive, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ExportInstance"); err != nil {
+		return nil, err
+	}
+	container, err := f.container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return &InstanceArchive{Path: path, Image: container.Image, Instance: container.Instance}, nil
+}
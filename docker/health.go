@@ -14,8 +14,13 @@ import (
 
 // HealthStatus represents the health check results
 type HealthStatus struct {
-	Docker   bool `json:"docker"`
-	Internet bool `json:"internet"`
+	Docker    bool           `json:"docker"`
+	Internet  bool           `json:"internet"`
+	DiskSpace bool           `json:"diskSpace"`
+	Memory    bool           `json:"memory"`
+	Resources ResourceReport `json:"resources"`
+	// Virtualization only carries results on Windows
+	Virtualization *VirtualizationStatus `json:"virtualization"`
 }
 
 // CheckDockerHealth verifies Docker is installed and available
@@ -41,9 +46,7 @@ func CheckDockerHealth() bool {
 	utils.LogDebug(fmt.Sprintf("Found Docker at: %s", dockerPath))
 	
 	// Test the Docker executable
-	cmd := exec.CommandContext(ctx, dockerPath, "--version")
-	utils.SetupCommandForPlatform(cmd)
-	err = cmd.Run()
+	err = utils.Commands().Run(exec.CommandContext(ctx, dockerPath, "--version"))
 	
 	if err != nil {
 		utils.LogError(fmt.Sprintf("Docker health check failed using %s", dockerPath), err)
@@ -54,13 +57,19 @@ func CheckDockerHealth() bool {
 	return true
 }
 
-// CheckInternetHealth verifies internet connectivity using ping
+// CheckInternetHealth verifies internet connectivity over HTTPS, falling back to ping
 func CheckInternetHealth() bool {
 	utils.LogDebug("Starting Internet health check...")
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
+	// HTTPS works behind corporate proxies where ICMP is usually blocked
+	if CheckHTTPSReachability(ctx) {
+		utils.LogDebug("Internet health check passed using HTTPS reachability")
+		return true
+	}
+	
 	// Try multiple methods to check internet connectivity
 	targets := []string{"8.8.8.8", "1.1.1.1"} // Google DNS and Cloudflare DNS
 	
@@ -104,8 +113,7 @@ func checkPingConnectivity(ctx context.Context, target string) bool {
 	}
 	
 	utils.LogDebug(fmt.Sprintf("Trying ping: %s", strings.Join(cmd.Args, " ")))
-	utils.SetupCommandForPlatform(cmd)
-	err := cmd.Run()
+	err := utils.Commands().Run(cmd)
 	
 	if err != nil {
 		utils.LogDebug(fmt.Sprintf("Ping failed for %s: %v", target, err))
@@ -122,9 +130,7 @@ func checkWindowsConnectivity(ctx context.Context) bool {
 	
 	for _, target := range targets {
 		utils.LogDebug(fmt.Sprintf("Trying nslookup: %s", target))
-		cmd := exec.CommandContext(ctx, "nslookup", target)
-		utils.SetupCommandForPlatform(cmd)
-		err := cmd.Run()
+		err := utils.Commands().Run(exec.CommandContext(ctx, "nslookup", target))
 		
 		if err == nil {
 			utils.LogDebug(fmt.Sprintf("nslookup successful for: %s", target))
@@ -135,9 +141,7 @@ func checkWindowsConnectivity(ctx context.Context) bool {
 	
 	// Try using telnet as a last resort
 	utils.LogDebug("Trying telnet connectivity check...")
-	cmd := exec.CommandContext(ctx, "telnet", "8.8.8.8", "53")
-	utils.SetupCommandForPlatform(cmd)
-	err := cmd.Run()
+	err := utils.Commands().Run(exec.CommandContext(ctx, "telnet", "8.8.8.8", "53"))
 	
 	if err == nil {
 		utils.LogDebug("Telnet connectivity check passed")
@@ -148,18 +152,30 @@ func checkWindowsConnectivity(ctx context.Context) bool {
 	return false
 }
 
-// PerformHealthChecks runs all health checks
+// PerformHealthChecks runs all health checks, estimating disk needs for an image that is not yet pulled
 func PerformHealthChecks() HealthStatus {
+	return PerformHealthChecksForImage("")
+}
+
+// PerformHealthChecksForImage runs all health checks, sizing the disk check for imageName
+func PerformHealthChecksForImage(imageName string) HealthStatus {
 	utils.LogInfo("=== Starting Health Checks ===")
 	
 	dockerHealth := CheckDockerHealth()
 	internetHealth := CheckInternetHealth()
+	resources := CheckResources(imageName)
+	virtualization := CheckVirtualization()
 	
 	status := HealthStatus{
-		Docker:   dockerHealth,
-		Internet: internetHealth,
+		Docker:         dockerHealth,
+		Internet:       internetHealth,
+		DiskSpace:      resources.DiskOK,
+		Memory:         resources.MemoryOK,
+		Resources:      resources,
+		Virtualization: virtualization,
 	}
 	
-	utils.LogInfo(fmt.Sprintf("Health check results: Docker=%t, Internet=%t", dockerHealth, internetHealth))
+	utils.LogInfo(fmt.Sprintf("Health check results: Docker=%t, Internet=%t, DiskSpace=%t, Memory=%t, Virtualization=%t",
+		dockerHealth, internetHealth, resources.DiskOK, resources.MemoryOK, virtualization.OK()))
 	return status
 }
\ No newline at end of file
@@ -1,7 +1,11 @@
 package docker
 
 import (
+	"context"
+	"os/exec"
 	"testing"
+
+	"moodle-prototype-manager/utils"
 )
 
 func TestPerformHealthChecks(t *testing.T) {
@@ -33,4 +37,48 @@ func TestCheckInternetHealth(t *testing.T) {
 	
 	// Test should not fail even if Internet is not available
 	// This is just to verify the function doesn't panic
-}
\ No newline at end of file
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:             "512 B",
+		2048:            "2.0 KB",
+		4 * 1024 * 1024: "4.0 MB",
+		2 * gib:         "2.0 GB",
+	}
+
+	for input, expected := range tests {
+		if result := FormatBytes(input); result != expected {
+			t.Errorf("FormatBytes(%d) = %s, expected %s", input, result, expected)
+		}
+	}
+}
+
+// recordingRunner answers commands without running them
+type recordingRunner struct {
+	utils.ExecRunner
+	commands [][]string
+}
+
+func (r *recordingRunner) Run(cmd *exec.Cmd) error {
+	r.commands = append(r.commands, cmd.Args)
+	return nil
+}
+
+func (r *recordingRunner) Output(cmd *exec.Cmd) ([]byte, error) {
+	r.commands = append(r.commands, cmd.Args)
+	return []byte("27.0.3\n"), nil
+}
+
+func TestCheckPingConnectivityUsesCommandRunner(t *testing.T) {
+	runner := &recordingRunner{}
+	previous := utils.SetCommandRunner(runner)
+	defer utils.SetCommandRunner(previous)
+
+	if !checkPingConnectivity(context.Background(), "192.0.2.1") {
+		t.Error("Expected a successful ping")
+	}
+	if len(runner.commands) != 1 || runner.commands[0][0] != "ping" || runner.commands[0][len(runner.commands[0])-1] != "192.0.2.1" {
+		t.Errorf("Expected one ping of the target, got %v", runner.commands)
+	}
+}
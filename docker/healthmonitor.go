@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// DefaultHealthMonitorInterval is how often the health monitor checks when no interval is set
+const DefaultHealthMonitorInterval = time.Minute
+
+// Checks the health monitor watches, as named in HealthChange.Changed
+const (
+	HealthCheckDocker    = "docker"
+	HealthCheckInternet  = "internet"
+	HealthCheckContainer = "container"
+)
+
+// HealthSnapshot is the result of one round of background health checks
+type HealthSnapshot struct {
+	Docker   bool `json:"docker"`
+	Internet bool `json:"internet"`
+	// Container is the Moodle container's health status; empty when Docker cannot be reached
+	Container string    `json:"container"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthChange describes checks whose result differs from the previous round
+type HealthChange struct {
+	Previous HealthSnapshot `json:"previous"`
+	Current  HealthSnapshot `json:"current"`
+	// Changed names the checks that changed: docker, internet or container
+	Changed []string `json:"changed"`
+}
+
+// Has reports whether check is among the changed checks
+func (c HealthChange) Has(check string) bool {
+	for _, changed := range c.Changed {
+		if changed == check {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthMonitor runs the health checks on an interval and reports changes, so losing Docker or
+// connectivity mid-session is noticed without the frontend asking
+type HealthMonitor struct {
+	check    func() HealthSnapshot
+	onChange func(HealthChange)
+
+	mu       sync.Mutex
+	last     *HealthSnapshot
+	stopChan chan struct{}
+}
+
+// NewHealthMonitor creates a monitor running check on every tick
+func NewHealthMonitor(check func() HealthSnapshot, onChange func(HealthChange)) *HealthMonitor {
+	return &HealthMonitor{check: check, onChange: onChange}
+}
+
+// Start checks every interval, replacing any previous schedule. The first round only records
+// the baseline.
+func (hm *HealthMonitor) Start(interval time.Duration) {
+	hm.Stop()
+	if interval <= 0 {
+		interval = DefaultHealthMonitorInterval
+	}
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	hm.last = nil
+	stopChan := make(chan struct{})
+	hm.stopChan = stopChan
+
+	utils.LogInfo(fmt.Sprintf("Starting health monitor (every %v)", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		hm.tick()
+		for {
+			select {
+			case <-ticker.C:
+				hm.tick()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts monitoring
+func (hm *HealthMonitor) Stop() {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if hm.stopChan != nil {
+		close(hm.stopChan)
+		hm.stopChan = nil
+		utils.LogInfo("Health monitor stopped")
+	}
+}
+
+// Last returns the latest snapshot, and false before the first round has finished
+func (hm *HealthMonitor) Last() (HealthSnapshot, bool) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if hm.last == nil {
+		return HealthSnapshot{}, false
+	}
+	return *hm.last, true
+}
+
+// tick runs one round of checks and reports a change
+func (hm *HealthMonitor) tick() {
+	if change, changed := hm.observe(hm.check()); changed && hm.onChange != nil {
+		hm.onChange(change)
+	}
+}
+
+// observe records a snapshot, returning how it differs from the previous one
+func (hm *HealthMonitor) observe(current HealthSnapshot) (HealthChange, bool) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	previous := hm.last
+	hm.last = &current
+	if previous == nil {
+		return HealthChange{}, false
+	}
+
+	change := HealthChange{Previous: *previous, Current: current, Changed: make([]string, 0)}
+	if previous.Docker != current.Docker {
+		change.Changed = append(change.Changed, HealthCheckDocker)
+	}
+	if previous.Internet != current.Internet {
+		change.Changed = append(change.Changed, HealthCheckInternet)
+	}
+	// The container cannot be inspected without Docker; that is reported as a Docker change
+	if previous.Docker && current.Docker && previous.Container != current.Container {
+		change.Changed = append(change.Changed, HealthCheckContainer)
+	}
+	return change, len(change.Changed) > 0
+}
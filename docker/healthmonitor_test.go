@@ -0,0 +1,33 @@
+package docker
+
+import "testing"
+
+func TestHealthMonitorObserve(t *testing.T) {
+	monitor := NewHealthMonitor(nil, nil)
+
+	if _, changed := monitor.observe(HealthSnapshot{Docker: true, Internet: true, Container: HealthHealthy}); changed {
+		t.Fatal("The first round should only record the baseline")
+	}
+	if _, changed := monitor.observe(HealthSnapshot{Docker: true, Internet: true, Container: HealthHealthy}); changed {
+		t.Fatal("Unchanged results should not be reported")
+	}
+
+	change, changed := monitor.observe(HealthSnapshot{Docker: false, Internet: true})
+	if !changed || !change.Has(HealthCheckDocker) || change.Has(HealthCheckContainer) {
+		t.Errorf("Expected only the Docker loss to be reported, got %+v", change)
+	}
+
+	change, changed = monitor.observe(HealthSnapshot{Docker: true, Internet: false, Container: HealthStopped})
+	if !changed || !change.Has(HealthCheckDocker) || !change.Has(HealthCheckInternet) {
+		t.Errorf("Expected Docker and internet changes, got %+v", change)
+	}
+
+	change, _ = monitor.observe(HealthSnapshot{Docker: true, Internet: false, Container: HealthUnhealthy})
+	if len(change.Changed) != 1 || !change.Has(HealthCheckContainer) || change.Previous.Container != HealthStopped {
+		t.Errorf("Expected a container change from stopped, got %+v", change)
+	}
+
+	if last, ok := monitor.Last(); !ok || last.Container != HealthUnhealthy {
+		t.Errorf("Expected the latest snapshot, got %+v", last)
+	}
+}
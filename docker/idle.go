@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// DefaultIdleCheckInterval is how often the idle monitor samples container traffic
+const DefaultIdleCheckInterval = time.Minute
+
+// idleNoiseBytes is the traffic per sample still counted as idle, e.g. ARP and mail sidecar
+// chatter. A single page view moves far more than this.
+const idleNoiseBytes = 8 * 1024
+
+// ContainerTrafficBytes returns the bytes received and sent on the container's network
+// interfaces other than loopback, so that in-container health checks do not count as traffic
+func (m *Manager) ContainerTrafficBytes(containerID string) (int64, error) {
+	output, err := m.ExecInContainer(containerID, "cat", "/proc/net/dev")
+	if err != nil {
+		return 0, errors.WrapWithContext(err, "failed to read container network counters")
+	}
+	return parseNetDev(output)
+}
+
+// parseNetDev sums the receive and transmit bytes of /proc/net/dev, skipping lo
+func parseNetDev(output string) (int64, error) {
+	var total int64
+	interfaces := 0
+	for _, line := range strings.Split(output, "\n") {
+		name, counters, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(counters)
+		// Header lines contain "|" instead of an interface name and counters
+		if name == "lo" || strings.Contains(name, "|") || len(fields) < 9 {
+			continue
+		}
+		received, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected network counters for %s: %v", name, err)
+		}
+		sent, err := strconv.ParseInt(fields[8], 10, 64)
+		if err != nil {
+			return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected network counters for %s: %v", name, err)
+		}
+		total += received + sent
+		interfaces++
+	}
+	if interfaces == 0 {
+		return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "no network interfaces in container")
+	}
+	return total, nil
+}
+
+// IdleMonitor watches the running container's network traffic and reports when nobody has
+// used Moodle for the configured period, so it can be stopped to free laptop resources
+type IdleMonitor struct {
+	manager     *Manager
+	containerID func() (string, error)
+	onIdle      func(containerID string, idleFor time.Duration)
+	now         func() time.Time
+
+	mu           sync.Mutex
+	timeout      time.Duration
+	watching     string
+	lastBytes    int64
+	lastActivity time.Time
+	stopChan     chan struct{}
+}
+
+// NewIdleMonitor creates a monitor; containerID resolves the running container on every tick
+func NewIdleMonitor(manager *Manager, containerID func() (string, error), onIdle func(containerID string, idleFor time.Duration)) *IdleMonitor {
+	return &IdleMonitor{
+		manager:     manager,
+		containerID: containerID,
+		onIdle:      onIdle,
+		now:         time.Now,
+	}
+}
+
+// Start checks for traffic every interval and calls onIdle once nothing has been seen for
+// timeout, replacing any previous schedule
+func (im *IdleMonitor) Start(timeout, interval time.Duration) {
+	im.Stop()
+	if interval <= 0 {
+		interval = DefaultIdleCheckInterval
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	im.timeout = timeout
+	im.watching = ""
+	stopChan := make(chan struct{})
+	im.stopChan = stopChan
+
+	utils.LogInfo(fmt.Sprintf("Starting idle monitor (stop after %v without traffic)", timeout))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				im.tick()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts monitoring
+func (im *IdleMonitor) Stop() {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if im.stopChan != nil {
+		close(im.stopChan)
+		im.stopChan = nil
+		utils.LogInfo("Idle monitor stopped")
+	}
+}
+
+// tick samples traffic if a container is running
+func (im *IdleMonitor) tick() {
+	containerID, err := im.containerID()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Skipping idle check: %v", err))
+		return
+	}
+
+	traffic, err := im.manager.ContainerTrafficBytes(containerID)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to sample container traffic: %v", err))
+		return
+	}
+
+	if idleFor, idle := im.observe(containerID, traffic); idle {
+		utils.LogInfo(fmt.Sprintf("No traffic to container %s for %v", containerID, idleFor.Round(time.Minute)))
+		if im.onIdle != nil {
+			im.onIdle(containerID, idleFor)
+		}
+	}
+}
+
+// observe records a traffic sample and reports whether the container has been idle for the
+// timeout. A new container, or one whose counters reset on restart, starts a fresh period.
+func (im *IdleMonitor) observe(containerID string, traffic int64) (time.Duration, bool) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	now := im.now()
+	if containerID != im.watching || traffic < im.lastBytes || traffic-im.lastBytes > idleNoiseBytes {
+		im.watching = containerID
+		im.lastActivity = now
+	}
+	im.lastBytes = traffic
+
+	idleFor := now.Sub(im.lastActivity)
+	if im.timeout <= 0 || idleFor < im.timeout {
+		return idleFor, false
+	}
+	// Report once per idle period
+	im.lastActivity = now
+	return idleFor, true
+}
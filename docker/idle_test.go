@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNetDev(t *testing.T) {
+	output := `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 9000000    1000    0    0    0     0          0         0  9000000    1000    0    0    0     0       0          0
+  eth0:   12000      40    0    0    0     0          0         0    30000      35    0    0    0     0       0          0
+  eth1:     500       5    0    0    0     0          0         0      700       6    0    0    0     0       0          0
+`
+	total, err := parseNetDev(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if total != 12000+30000+500+700 {
+		t.Errorf("Expected loopback to be skipped, got %d", total)
+	}
+
+	if _, err := parseNetDev("lo: 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16\n"); err == nil {
+		t.Error("Expected an error without external interfaces")
+	}
+}
+
+func TestIdleMonitorObserve(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	monitor := NewIdleMonitor(nil, nil, nil)
+	monitor.now = func() time.Time { return now }
+	monitor.timeout = 30 * time.Minute
+
+	if _, idle := monitor.observe("abc", 1000); idle {
+		t.Fatal("First sample should start an idle period, not end one")
+	}
+
+	// Background noise does not count as activity
+	now = now.Add(20 * time.Minute)
+	monitor.observe("abc", 2000)
+
+	// A page view resets the period
+	now = now.Add(5 * time.Minute)
+	if _, idle := monitor.observe("abc", 200000); idle {
+		t.Fatal("Traffic should count as activity")
+	}
+	now = now.Add(29 * time.Minute)
+	if _, idle := monitor.observe("abc", 200100); idle {
+		t.Fatal("Idle period should restart after traffic")
+	}
+
+	now = now.Add(2 * time.Minute)
+	idleFor, idle := monitor.observe("abc", 200200)
+	if !idle || idleFor != 31*time.Minute {
+		t.Fatalf("Expected idle after 31 minutes, got idle=%v after %v", idle, idleFor)
+	}
+
+	// Only reported once per idle period
+	now = now.Add(time.Minute)
+	if _, idle := monitor.observe("abc", 200200); idle {
+		t.Error("Idle should be reported once")
+	}
+
+	// A different container starts a fresh period
+	now = now.Add(40 * time.Minute)
+	if _, idle := monitor.observe("def", 10); idle {
+		t.Error("A new container should not be idle immediately")
+	}
+}
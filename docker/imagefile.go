@@ -0,0 +1,242 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// fileProgressInterval limits how often image load and save progress is reported
+const fileProgressInterval = 250 * time.Millisecond
+
+// ImageLoadResult describes images restored from a tarball
+type ImageLoadResult struct {
+	// Images lists loaded image references, or image IDs for untagged images
+	Images []string `json:"images"`
+	Bytes  int64    `json:"bytes"`
+	// IncludesConfigured reports whether the configured Moodle image was among them
+	IncludesConfigured bool `json:"includesConfigured"`
+}
+
+// LoadImageFromFile restores images from a tarball created by `docker save` (optionally gzip,
+// bzip2 or xz compressed), so the image can be distributed on a USB stick instead of pulled.
+// It is equivalent to `docker load -i`, but streams the file through stdin so progress can be
+// reported as the share of the file read.
+func (m *Manager) LoadImageFromFile(path string, progressCallback func(float64, string)) (*ImageLoadResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileError("stat", path, err)
+	}
+	if info.IsDir() {
+		return nil, errors.NewValidationError("path", "expected an image tarball, got a directory", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.NewFileError("open", path, err)
+	}
+	defer file.Close()
+
+	utils.LogInfo(fmt.Sprintf("Loading Docker image from %s (%s)", path, FormatBytes(uint64(info.Size()))))
+
+	reader := newProgressReader(file, info.Size(), func(percentage float64) {
+		if progressCallback != nil {
+			progressCallback(percentage, fmt.Sprintf("Loading image (%.0f%%)", percentage))
+		}
+	})
+
+	var output bytes.Buffer
+	cmd := GetDockerCommand("load")
+	cmd.Stdin = reader
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		dockerErr := errors.NewDockerError("load", err).WithOutput(output.String())
+		return nil, errors.WrapWithContext(dockerErr, "failed to load image from %s", path)
+	}
+
+	result := &ImageLoadResult{Images: parseLoadedImages(output.String()), Bytes: info.Size()}
+	for _, image := range result.Images {
+		if m.imageName != "" && image == m.imageName {
+			result.IncludesConfigured = true
+		}
+	}
+	if len(result.Images) == 0 {
+		utils.LogWarning(fmt.Sprintf("docker load reported no images: %s", strings.TrimSpace(output.String())))
+	}
+
+	if progressCallback != nil {
+		progressCallback(100, "Image loaded")
+	}
+	utils.LogInfo(fmt.Sprintf("Loaded images from %s: %s", path, strings.Join(result.Images, ", ")))
+	return result, nil
+}
+
+// ImageSaveResult describes an image written to a tarball
+type ImageSaveResult struct {
+	Image string `json:"image"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// SaveImageToFile writes the configured image to a tarball with `docker save`, so one machine
+// can pull once and share the image with a lab through LoadImageFromFile. Paths ending in .gz
+// or .tgz are gzip compressed. Progress is estimated from the image size.
+func (m *Manager) SaveImageToFile(path string, progressCallback func(float64, string)) (*ImageSaveResult, error) {
+	if m.imageName == "" {
+		return nil, errors.NewValidationError("imageName", "no image name set in Docker manager", "")
+	}
+	size, err := imageSize(m.imageName)
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("inspect", m.imageName, err)
+		return nil, errors.WrapWithContext(dockerErr, "image must be pulled before it can be saved")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Saving Docker image %s to %s (about %s)", m.imageName, path, FormatBytes(size)))
+
+	// Write next to the destination and rename on success, so a failed save leaves no partial file
+	partial := path + ".partial"
+	file, err := os.Create(partial)
+	if err != nil {
+		return nil, errors.NewFileError("create", partial, err)
+	}
+	defer os.Remove(partial)
+
+	if err := m.writeImageTarball(file, compressedPath(path), int64(size), progressCallback); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, errors.NewFileError("write", partial, err)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return nil, errors.NewFileError("rename", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileError("stat", path, err)
+	}
+	if progressCallback != nil {
+		progressCallback(100, "Image saved")
+	}
+	utils.LogInfo(fmt.Sprintf("Saved %s to %s (%s)", m.imageName, path, FormatBytes(uint64(info.Size()))))
+	return &ImageSaveResult{Image: m.imageName, Path: path, Bytes: info.Size()}, nil
+}
+
+// writeImageTarball streams `docker save` output into out, optionally gzip compressed
+func (m *Manager) writeImageTarball(out io.Writer, compress bool, size int64, progressCallback func(float64, string)) error {
+	var stderr bytes.Buffer
+	cmd := GetDockerCommand("save", m.imageName)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.NewDockerErrorWithImage("save_setup", m.imageName, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.NewDockerErrorWithImage("save_start", m.imageName, err)
+	}
+
+	reader := newProgressReader(stdout, size, func(percentage float64) {
+		if progressCallback != nil {
+			progressCallback(percentage, fmt.Sprintf("Saving image (%.0f%%)", percentage))
+		}
+	})
+
+	var copyErr error
+	if compress {
+		gz := gzip.NewWriter(out)
+		_, copyErr = io.Copy(gz, reader)
+		if closeErr := gz.Close(); copyErr == nil {
+			copyErr = closeErr
+		}
+	} else {
+		_, copyErr = io.Copy(out, reader)
+	}
+	if copyErr != nil {
+		// Unblock docker save before waiting for it
+		io.Copy(io.Discard, stdout)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("save", m.imageName, err).WithOutput(stderr.String())
+		return errors.WrapWithContext(dockerErr, "docker save failed")
+	}
+	if copyErr != nil {
+		return errors.WrapWithContext(copyErr, "failed to write image file")
+	}
+	return nil
+}
+
+// compressedPath reports whether an image file path asks for gzip compression
+func compressedPath(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// parseLoadedImages extracts image references from `docker load` output
+func parseLoadedImages(output string) []string {
+	images := make([]string, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		for _, prefix := range []string{"Loaded image:", "Loaded image ID:"} {
+			if strings.HasPrefix(line, prefix) {
+				images = append(images, strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+				break
+			}
+		}
+	}
+	return images
+}
+
+// progressReader reports the share of a stream read so far, at most every fileProgressInterval
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	onUpdate func(percentage float64)
+
+	mu       sync.Mutex
+	read     int64
+	reported time.Time
+	now      func() time.Time
+}
+
+// newProgressReader wraps reader, whose length is total bytes
+func newProgressReader(reader io.Reader, total int64, onUpdate func(float64)) *progressReader {
+	return &progressReader{reader: reader, total: total, onUpdate: onUpdate, now: time.Now}
+}
+
+// Read reads from the underlying reader and reports progress
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.mu.Lock()
+	r.read += int64(n)
+	now := r.now()
+	report := r.total > 0 && (now.Sub(r.reported) >= fileProgressInterval || err == io.EOF)
+	if report {
+		r.reported = now
+	}
+	percentage := 0.0
+	if r.total > 0 {
+		// Work remains after the last byte (unpacking, flushing the file), so stop short of 100
+		percentage = math.Min(float64(r.read)/float64(r.total)*99, 99)
+	}
+	r.mu.Unlock()
+
+	if report {
+		r.onUpdate(percentage)
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLoadedImages(t *testing.T) {
+	output := "Loaded image: wenkhairu/moodle-prototype:502-stable\nLoaded image ID: sha256:0123abcd\n"
+	images := parseLoadedImages(output)
+	if len(images) != 2 || images[0] != "wenkhairu/moodle-prototype:502-stable" || images[1] != "sha256:0123abcd" {
+		t.Errorf("Unexpected images: %v", images)
+	}
+	if images := parseLoadedImages("open /tmp/x.tar: no such file"); len(images) != 0 {
+		t.Errorf("Expected no images, got %v", images)
+	}
+}
+
+func TestCompressedPath(t *testing.T) {
+	for path, want := range map[string]bool{"moodle.tar": false, "moodle.tar.gz": true, "MOODLE.TGZ": true} {
+		if got := compressedPath(path); got != want {
+			t.Errorf("compressedPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var updates []float64
+	clock := time.Now()
+	reader := newProgressReader(strings.NewReader(strings.Repeat("x", 100)), 100, func(percentage float64) {
+		updates = append(updates, percentage)
+	})
+	reader.now = func() time.Time {
+		clock = clock.Add(fileProgressInterval)
+		return clock
+	}
+
+	buf := make([]byte, 50)
+	for {
+		if _, err := reader.Read(buf); err == io.EOF {
+			break
+		}
+	}
+
+	if len(updates) < 2 || updates[0] != 49.5 || updates[len(updates)-1] != 99 {
+		t.Errorf("Expected progress to climb to 99%%, got %v", updates)
+	}
+}
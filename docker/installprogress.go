@@ -0,0 +1,298 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Moodle installation phases, in the order a first run goes through them
+const (
+	InstallPhaseStarting = "starting"
+	InstallPhaseDatabase = "database"
+	InstallPhaseTables   = "tables"
+	InstallPhasePlugins  = "plugins"
+	InstallPhaseAdmin    = "admin"
+	InstallPhaseComplete = "complete"
+)
+
+// ExpectedPluginCount approximates how many plugins a standard Moodle install sets up; it
+// scales progress through the plugin phase, which is most of the install time
+const ExpectedPluginCount = 450
+
+// InstallProgress is how far a first-run Moodle installation has got according to its logs
+type InstallProgress struct {
+	Phase string `json:"phase"`
+	// Label describes the phase for the user
+	Label      string  `json:"label"`
+	Percentage float64 `json:"percentage"`
+	// PluginsInstalled counts the plugins seen in the scanned logs
+	PluginsInstalled int    `json:"pluginsInstalled"`
+	LastPlugin       string `json:"lastPlugin,omitempty"`
+}
+
+// installPhase recognises the start of a phase and spans part of the progress bar
+type installPhase struct {
+	name    string
+	label   string
+	start   float64
+	end     float64
+	pattern *regexp.Regexp
+}
+
+// installPhases are checked against every log line; a phase is only entered after the ones before it
+var installPhases = []installPhase{
+	{InstallPhaseStarting, "Starting the installation", 0, 5, nil},
+	{InstallPhaseDatabase, "Creating the database", 5, 15,
+		regexp.MustCompile(`(?i)(creat(e|ing) (the )?database|waiting for (the )?database|connecting to (the )?database|database (server )?is (ready|up))`)},
+	{InstallPhaseTables, "Installing database tables", 15, 35,
+		regexp.MustCompile(`(?i)(== setting up database ==|^\s*-->\s*system\s*$|installing (core )?tables)`)},
+	{InstallPhasePlugins, "Installing plugins", 35, 90, pluginLinePattern},
+	{InstallPhaseAdmin, "Setting up the administrator account", 90, 99,
+		regexp.MustCompile(`(?i)(setting up admin|admin(istrator)? (user|account) (created|setup)|generated admin password|upgrade_noncore)`)},
+	{InstallPhaseComplete, "Installation complete", 100, 100,
+		regexp.MustCompile(`(?i)(installation completed successfully|moodle is available at)`)},
+}
+
+// pluginLinePattern matches the "-->mod_forum" lines Moodle's CLI installer prints per plugin
+var pluginLinePattern = regexp.MustCompile(`^\s*-->\s*([a-z]+_[a-z0-9_]+)\s*$`)
+
+// ParseInstallProgress estimates installation progress from first-run container logs. Phases
+// only move forward, so a late line matching an earlier phase does not move progress back.
+func (lp *LogParser) ParseInstallProgress(logs string) *InstallProgress {
+	tracker := newInstallTracker()
+	scanner := bufio.NewScanner(strings.NewReader(logs))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		tracker.observe(scanner.Text())
+	}
+	return tracker.progress()
+}
+
+// installTracker follows the install phase and the plugins installed, line by line
+type installTracker struct {
+	current    int
+	plugins    map[string]bool
+	lastPlugin string
+}
+
+func newInstallTracker() *installTracker {
+	return &installTracker{plugins: make(map[string]bool)}
+}
+
+// observe advances the tracker with one log line
+func (t *installTracker) observe(line string) {
+	if matches := pluginLinePattern.FindStringSubmatch(line); len(matches) > 1 {
+		t.plugins[matches[1]] = true
+		t.lastPlugin = matches[1]
+	}
+	for i := len(installPhases) - 1; i > t.current; i-- {
+		if installPhases[i].pattern.MatchString(line) {
+			t.current = i
+			break
+		}
+	}
+}
+
+// progress reports the lines observed so far as an InstallProgress
+func (t *installTracker) progress() *InstallProgress {
+	phase := installPhases[t.current]
+	progress := &InstallProgress{
+		Phase:            phase.name,
+		Label:            phase.label,
+		Percentage:       phase.start,
+		PluginsInstalled: len(t.plugins),
+		LastPlugin:       t.lastPlugin,
+	}
+	if phase.name == InstallPhasePlugins {
+		fraction := float64(len(t.plugins)) / ExpectedPluginCount
+		if fraction > 0.99 {
+			fraction = 0.99
+		}
+		progress.Percentage = phase.start + (phase.end-phase.start)*fraction
+		progress.Label = fmt.Sprintf("Installing plugins (%d installed)", len(t.plugins))
+	}
+	return progress
+}
+
+// Reasons a first-run install is reported as failed
+const (
+	InstallFailureLogError = "log_error"
+	InstallFailureOOM      = "oom_killed"
+	InstallFailureExited   = "exited"
+	InstallFailureTimeout  = "timeout"
+)
+
+// installExcerptLines is how many log lines around a failure are captured for the user
+const installExcerptLines = 20
+
+// InstallFailure describes why a first-run install will not finish
+type InstallFailure struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// Excerpt holds the log lines around the error, or the last lines when there is no error line
+	Excerpt  string `json:"excerpt"`
+	ExitCode int    `json:"exitCode,omitempty"`
+	// LogFile is where the container's log was saved before the container was removed
+	LogFile string `json:"logFile,omitempty"`
+	// Removed is set when the install cleanup policy removed the container and its volumes
+	Removed bool `json:"removed,omitempty"`
+}
+
+func (f *InstallFailure) Error() string {
+	return f.Message
+}
+
+// fatalInstallPattern matches lines that end the install: a PHP fatal error, a database
+// driver Moodle cannot use, or the image reporting that it gave up. Each must start the line,
+// after an optional [timestamp], so a message merely quoting one does not match. Moodle's
+// "!!! error !!!" banners and database connection errors are left out on purpose: the image
+// waits for the database and reinstalls after a failed write, so the install often goes on,
+// and when it does not the container exits or the install times out.
+var fatalInstallPattern = regexp.MustCompile(`(?i)^\s*(?:\[[^\]]*\]\s*)?(?:PHP Fatal error:|Error: Database driver problem|(?:Moodle )?installation failed)`)
+
+// DetectInstallFailure looks for a fatal installer error in first-run logs
+func (lp *LogParser) DetectInstallFailure(logs string) *InstallFailure {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	for i, line := range lines {
+		if !fatalInstallPattern.MatchString(line) {
+			continue
+		}
+		start := max(i-installExcerptLines/2, 0)
+		end := min(i+installExcerptLines/2, len(lines))
+		return &InstallFailure{
+			Reason:  InstallFailureLogError,
+			Message: fmt.Sprintf("Moodle installation failed: %s", strings.TrimSpace(line)),
+			Excerpt: strings.Join(lines[start:end], "\n"),
+		}
+	}
+	return nil
+}
+
+// CheckInstallContainer reports a failure when the installing container has stopped, e.g.
+// because it was OOM-killed; a running container returns nil
+func (m *Manager) CheckInstallContainer(containerID, logs string) (*InstallFailure, error) {
+	state, err := m.InspectContainerState(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Running {
+		return nil, nil
+	}
+
+	failure := &InstallFailure{
+		Reason:   InstallFailureExited,
+		Message:  fmt.Sprintf("The Moodle container stopped during installation (exit code %d)", state.ExitCode),
+		Excerpt:  LastLogLines(logs, installExcerptLines),
+		ExitCode: state.ExitCode,
+	}
+	if state.OOMKilled {
+		failure.Reason = InstallFailureOOM
+		failure.Message = "The Moodle container ran out of memory during installation; raise its memory limit and try again"
+	}
+	return failure, nil
+}
+
+// LastLogLines returns the last n lines of logs
+func LastLogLines(logs string, n int) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// installScanRecentLines is how many of the latest lines an InstallScan keeps for excerpts
+const installScanRecentLines = 200
+
+// InstallScan parses first-run logs as they are read, so each line is parsed once however long
+// the install runs. Feed it the new output of a LogCursor on every poll.
+type InstallScan struct {
+	parser  *LogParser
+	tracker *installTracker
+	creds   CredentialInfo
+	failure *InstallFailure
+	recent  []string
+	// preset is the login the manager set through the environment, reported once the image
+	// logs that its install finished
+	preset   CredentialInfo
+	finished bool
+}
+
+// NewInstallScan starts a scan of a first-run install
+func (lp *LogParser) NewInstallScan() *InstallScan {
+	return &InstallScan{parser: lp, tracker: newInstallTracker()}
+}
+
+// WithPreset makes the scan report password and url once the install has finished, for
+// images that take their login from the environment instead of printing one
+func (s *InstallScan) WithPreset(password, url string) *InstallScan {
+	s.preset = CredentialInfo{Password: password, URL: url}
+	return s
+}
+
+// Add parses log output logged since the previous call
+func (s *InstallScan) Add(logs string) {
+	logs = strings.TrimRight(StripANSI(logs), "\n")
+	if logs == "" {
+		return
+	}
+	lines := strings.Split(logs, "\n")
+
+	// Like ExtractCredentials, the latest value wins
+	creds := s.parser.ExtractCredentials(logs)
+	if creds.Password != "" {
+		s.creds.Password = creds.Password
+	}
+	if creds.URL != "" {
+		s.creds.URL = creds.URL
+	}
+	if s.parser.ready != nil && s.parser.ready.MatchString(logs) {
+		s.finished = true
+	}
+
+	for _, line := range lines {
+		s.tracker.observe(line)
+	}
+
+	// Lines kept from earlier output give a failure near the start of this output its context
+	if s.failure == nil {
+		context := s.recent[max(len(s.recent)-installExcerptLines/2, 0):]
+		s.failure = s.parser.DetectInstallFailure(strings.Join(append(append([]string{}, context...), lines...), "\n"))
+	}
+
+	s.recent = append(s.recent, lines...)
+	if len(s.recent) > installScanRecentLines {
+		s.recent = append([]string{}, s.recent[len(s.recent)-installScanRecentLines:]...)
+	}
+}
+
+// Credentials returns the password and URL found so far
+func (s *InstallScan) Credentials() *CredentialInfo {
+	creds := s.creds
+	if s.finished && s.preset.Password != "" {
+		if creds.Password == "" {
+			creds.Password = s.preset.Password
+		}
+		if creds.URL == "" {
+			creds.URL = s.preset.URL
+		}
+	}
+	return &creds
+}
+
+// Progress returns how far the install has got
+func (s *InstallScan) Progress() *InstallProgress {
+	return s.tracker.progress()
+}
+
+// Failure returns the first fatal installer error seen, or nil
+func (s *InstallScan) Failure() *InstallFailure {
+	return s.failure
+}
+
+// Recent returns the latest lines read, for failure excerpts
+func (s *InstallScan) Recent() string {
+	return strings.Join(s.recent, "\n")
+}
@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseInstallProgressPhases(t *testing.T) {
+	parser := NewLogParser()
+
+	steps := []struct {
+		logs  string
+		phase string
+	}{
+		{"Starting Moodle container...", InstallPhaseStarting},
+		{"Waiting for database connection...\nCreating database moodle", InstallPhaseDatabase},
+		{"== Setting up database ==\n-->System\n++ Success ++", InstallPhaseTables},
+		{"-->antivirus_clamav\n++ Success ++\n-->mod_forum\n++ Success ++", InstallPhasePlugins},
+		{"Generated admin password: secret", InstallPhaseAdmin},
+		{"Installation completed successfully.\nMoodle is available at: http://localhost:8080", InstallPhaseComplete},
+	}
+
+	var logs strings.Builder
+	previous := -1.0
+	for _, step := range steps {
+		logs.WriteString(step.logs + "\n")
+		progress := parser.ParseInstallProgress(logs.String())
+		if progress.Phase != step.phase {
+			t.Fatalf("after %q: phase = %s, want %s", step.logs, progress.Phase, step.phase)
+		}
+		if progress.Percentage <= previous {
+			t.Errorf("after %q: percentage %.1f did not advance past %.1f", step.logs, progress.Percentage, previous)
+		}
+		previous = progress.Percentage
+	}
+
+	final := parser.ParseInstallProgress(logs.String())
+	if final.Percentage != 100 || final.PluginsInstalled != 2 || final.LastPlugin != "mod_forum" {
+		t.Errorf("unexpected final progress: %+v", final)
+	}
+}
+
+func TestParseInstallProgressNeverMovesBack(t *testing.T) {
+	parser := NewLogParser()
+
+	logs := "-->System\n-->mod_assign\n-->mod_quiz\nWaiting for database connection...\n"
+	progress := parser.ParseInstallProgress(logs)
+	if progress.Phase != InstallPhasePlugins {
+		t.Fatalf("phase = %s, want %s", progress.Phase, InstallPhasePlugins)
+	}
+	if progress.Percentage <= 35 || progress.Percentage >= 90 {
+		t.Errorf("plugin phase percentage %.1f outside its range", progress.Percentage)
+	}
+}
+
+func TestDetectInstallFailure(t *testing.T) {
+	parser := NewLogParser()
+
+	if failure := parser.DetectInstallFailure("-->System\n++ Success ++\n-->mod_forum\n"); failure != nil {
+		t.Fatalf("unexpected failure in healthy logs: %+v", failure)
+	}
+
+	logs := "== Setting up database ==\n-->System\nPHP Fatal error:  Allowed memory size of 134217728 bytes exhausted in /var/www/html/lib/dml/moodle_database.php on line 1432\nmore info\n"
+	failure := parser.DetectInstallFailure(logs)
+	if failure == nil {
+		t.Fatal("expected a failure for a PHP fatal error")
+	}
+	if failure.Reason != InstallFailureLogError || !strings.Contains(failure.Message, "Allowed memory size") {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+	if !strings.Contains(failure.Excerpt, "-->System") || !strings.Contains(failure.Excerpt, "more info") {
+		t.Errorf("excerpt should include surrounding lines, got %q", failure.Excerpt)
+	}
+
+	// Errors the installer recovers from, or that only quote a fatal message, are not failures
+	for _, line := range []string{
+		"!!! Error reading from database !!!",
+		"Could not connect to the database, retrying in 5 seconds",
+		"Debug info: dml_connection_exception thrown while waiting for the database",
+		"Searching the docs for 'PHP Fatal error:' returned 3 pages",
+	} {
+		if failure := parser.DetectInstallFailure("-->System\n" + line + "\n"); failure != nil {
+			t.Errorf("unexpected failure for %q: %+v", line, failure)
+		}
+	}
+}
+
+// The prototype image resets the database and reinstalls after a failed write; the install
+// succeeds and must not be reported as failed, which would remove the container
+func TestInstallScanRecoversFromReinstall(t *testing.T) {
+	logs, err := os.ReadFile(filepath.Join("testdata", "logs", "prototype-reinstall.log"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	scan := NewLogParser().ForImage("wenkhairu/moodle-prototype:502-stable").NewInstallScan()
+	for _, line := range strings.SplitAfter(string(logs), "\n") {
+		scan.Add(line)
+	}
+	if failure := scan.Failure(); failure != nil {
+		t.Fatalf("reinstall reported as failed: %+v", failure)
+	}
+	if creds := scan.Credentials(); creds.Password != "Zx8#kQ2m!vR4" {
+		t.Errorf("expected the password from the reinstall, got %+v", creds)
+	}
+}
+
+func TestLastLogLines(t *testing.T) {
+	if got := LastLogLines("a\nb\nc\n", 2); got != "b\nc" {
+		t.Errorf("LastLogLines = %q, want %q", got, "b\nc")
+	}
+	if got := LastLogLines("a", 5); got != "a" {
+		t.Errorf("LastLogLines = %q, want %q", got, "a")
+	}
+}
+
+func TestInstallScanAcrossReads(t *testing.T) {
+	scan := NewLogParser().NewInstallScan()
+
+	scan.Add("== Setting up database ==\n-->System\n-->mod_forum\n")
+	scan.Add("-->mod_quiz\nGenerated admin password: secret\n")
+	if creds := scan.Credentials(); creds.Password != "secret" || creds.IsComplete() {
+		t.Fatalf("Expected only the password so far, got %+v", creds)
+	}
+	if progress := scan.Progress(); progress.PluginsInstalled != 2 || progress.Phase != InstallPhaseAdmin {
+		t.Errorf("Expected plugins from both reads to count, got %+v", progress)
+	}
+
+	scan.Add("Moodle is available at: http://localhost:8080\n")
+	if creds := scan.Credentials(); !creds.IsComplete() || creds.Password != "secret" {
+		t.Errorf("Expected credentials from separate reads to combine, got %+v", creds)
+	}
+	if scan.Failure() != nil {
+		t.Errorf("Expected no failure, got %v", scan.Failure())
+	}
+}
+
+func TestInstallScanFailureExcerpt(t *testing.T) {
+	scan := NewLogParser().NewInstallScan()
+	scan.Add("line 1\nline 2\n")
+	scan.Add("PHP Fatal error: Allowed memory size exhausted\n")
+
+	failure := scan.Failure()
+	if failure == nil {
+		t.Fatal("Expected the fatal error to be detected")
+	}
+	if !strings.Contains(failure.Excerpt, "line 2") {
+		t.Errorf("Expected earlier lines in the excerpt, got %q", failure.Excerpt)
+	}
+}
@@ -0,0 +1,420 @@
+package docker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// InstanceArchiveFormat is the version of the instance archive layout written by ExportInstance
+const InstanceArchiveFormat = 1
+
+// Entries of an instance archive, a gzipped tarball with the manifest first
+const (
+	instanceManifestEntry = "manifest.json"
+	instanceVolumesDir    = "volumes/"
+)
+
+// InstanceCredentials are the admin login shipped with an instance archive. Archives do not
+// carry the password any more, since anyone holding the file could read it; the importer gets
+// a new one. Archives of older versions still have it.
+type InstanceCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	URL      string `json:"url"`
+}
+
+// InstanceVolume is one of the container's volumes, stored in the archive as a tarball
+type InstanceVolume struct {
+	Destination string `json:"destination"`
+	Entry       string `json:"entry"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// InstanceManifest describes an exported instance. The caller fills in the credentials and
+// settings; ExportInstance records the image and volumes.
+type InstanceManifest struct {
+	Format     int       `json:"format"`
+	CreatedAt  time.Time `json:"createdAt"`
+	AppVersion string    `json:"appVersion,omitempty"`
+	Instance   string    `json:"instance"`
+	Image      string    `json:"image"`
+	// ImageDigest identifies the exact build the instance ran, when it came from a registry
+	ImageDigest string              `json:"imageDigest,omitempty"`
+	Volumes     []InstanceVolume    `json:"volumes"`
+	Credentials InstanceCredentials `json:"credentials"`
+	// Settings are the app's portable settings, opaque to this package
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// InstanceArchive summarises a written instance archive
+type InstanceArchive struct {
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Image    string `json:"image"`
+	Volumes  int    `json:"volumes"`
+	Instance string `json:"instance"`
+}
+
+// VolumeMount mounts a named volume into a new container
+type VolumeMount struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+}
+
+// ExportInstance writes the container's image reference and volume data, with manifest's
+// credentials and settings, to a single archive at path. The container is paused while its
+// volumes are read so the database and files agree.
+func (m *Manager) ExportInstance(containerID, path string, manifest InstanceManifest, progressCallback func(float64, string)) (*InstanceArchive, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to ExportInstance")
+	}
+	if err := errors.ValidateFilePath("path", path); err != nil {
+		return nil, err
+	}
+
+	report := func(percentage float64, status string) {
+		utils.LogInfo(fmt.Sprintf("Instance export: %s", status))
+		if progressCallback != nil {
+			progressCallback(percentage, status)
+		}
+	}
+
+	report(5, "Reading container configuration")
+	image, err := m.containerImage(containerID)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := m.containerMounts(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Format = InstanceArchiveFormat
+	manifest.CreatedAt = time.Now().UTC()
+	manifest.Instance = m.GetInstanceName()
+	manifest.Image = image
+	manifest.Volumes = make([]InstanceVolume, 0)
+	if digests, err := m.GetLocalImageDigests(image); err == nil && len(digests) > 0 {
+		manifest.ImageDigest = digests[0]
+	}
+
+	volumes := make([]containerMount, 0, len(mounts))
+	for _, mount := range mounts {
+		if mount.Type != "volume" {
+			// Bind mounts live on the host and are not the instance's to hand over
+			utils.LogWarning(fmt.Sprintf("Instance export does not include bind mount %s", mount.Destination))
+			continue
+		}
+		volumes = append(volumes, mount)
+	}
+
+	running, err := m.IsContainerRunning(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if running && len(volumes) > 0 {
+		if err := m.pauseContainer(containerID); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := m.unpauseContainer(containerID); err != nil {
+				utils.LogError("Failed to resume container after instance export", err)
+			}
+		}()
+	}
+
+	files := make([]string, 0, len(volumes))
+	defer func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}()
+	for i, volume := range volumes {
+		report(10+50*float64(i)/float64(len(volumes)), fmt.Sprintf("Reading volume %s", volume.Destination))
+		file, size, err := m.readVolume(image, volume.Name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		manifest.Volumes = append(manifest.Volumes, InstanceVolume{
+			Destination: volume.Destination,
+			Entry:       fmt.Sprintf("%s%d.tar", instanceVolumesDir, i),
+			Bytes:       size,
+		})
+	}
+
+	report(60, "Writing archive")
+	size, err := writeInstanceArchive(path, &manifest, files, func(percentage float64) {
+		report(60+percentage*0.39, fmt.Sprintf("Writing archive (%.0f%%)", percentage))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report(100, fmt.Sprintf("Exported to %s", path))
+	return &InstanceArchive{Path: path, Bytes: size, Image: image, Volumes: len(manifest.Volumes), Instance: manifest.Instance}, nil
+}
+
+// ReadInstanceManifest reads the manifest at the start of an instance archive
+func ReadInstanceManifest(path string) (*InstanceManifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.NewFileError("open", path, err)
+	}
+	defer file.Close()
+
+	reader, err := openInstanceArchive(file)
+	if err != nil {
+		return nil, err
+	}
+	return readManifestEntry(reader)
+}
+
+// RestoreInstanceVolumes creates a volume per archived volume and unpacks its data, using the
+// manifest's image as the helper. The returned mounts are for RunContainerWithVolumes.
+func (m *Manager) RestoreInstanceVolumes(path string, manifest *InstanceManifest, progressCallback func(float64, string)) ([]VolumeMount, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileError("stat", path, err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.NewFileError("open", path, err)
+	}
+	defer file.Close()
+
+	progress := newProgressReader(file, info.Size(), func(percentage float64) {
+		if progressCallback != nil {
+			progressCallback(percentage, fmt.Sprintf("Restoring volumes (%.0f%%)", percentage))
+		}
+	})
+	reader, err := openInstanceArchive(progress)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readManifestEntry(reader); err != nil {
+		return nil, err
+	}
+
+	destinations := make(map[string]string, len(manifest.Volumes))
+	for _, volume := range manifest.Volumes {
+		destinations[volume.Entry] = volume.Destination
+	}
+
+	instance := m.GetInstanceName()
+	stamp := time.Now().Format("20060102150405")
+	mounts := make([]VolumeMount, 0, len(manifest.Volumes))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			m.RemoveVolumes(mounts)
+			return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "failed to read instance archive: %v", err)
+		}
+		destination, ok := destinations[header.Name]
+		if !ok {
+			continue
+		}
+
+		name := fmt.Sprintf("%s-import-%s-%d", ContainerName(instance), stamp, len(mounts))
+		mounts = append(mounts, VolumeMount{Name: name, Destination: destination})
+		if err := m.writeVolume(manifest.Image, name, reader); err != nil {
+			m.RemoveVolumes(mounts)
+			return nil, err
+		}
+		utils.LogInfo(fmt.Sprintf("Restored volume %s for %s", name, destination))
+	}
+
+	if len(mounts) != len(manifest.Volumes) {
+		m.RemoveVolumes(mounts)
+		return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "instance archive holds %d of %d volumes", len(mounts), len(manifest.Volumes))
+	}
+	return mounts, nil
+}
+
+// containerImage returns the image reference a container was created from
+func (m *Manager) containerImage(containerID string) (string, error) {
+	cmd := GetDockerCommand("inspect", "--format", "{{.Config.Image}}", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("inspect", containerID, err).WithOutput(string(output))
+		return "", errors.WrapWithContext(dockerErr, "failed to read container image")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// readVolume tars the contents of volume into a temporary file, returning its path and size
+func (m *Manager) readVolume(image, volume string) (string, int64, error) {
+	file, err := os.CreateTemp("", "moodle-volume-*.tar")
+	if err != nil {
+		return "", 0, errors.NewFileError("create", os.TempDir(), err)
+	}
+	defer file.Close()
+
+	var stderr strings.Builder
+	cmd := GetDockerCommand("run", "--rm",
+		"--user", "root",
+		"--entrypoint", "tar",
+		"-v", fmt.Sprintf("%s:%s:ro", volume, copyFromDir),
+		image, "-C", copyFromDir, "-cf", "-", ".")
+	cmd.Stdout = file
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(file.Name())
+		dockerErr := errors.NewDockerErrorWithImage("run", image, err).WithOutput(stderr.String())
+		return "", 0, errors.WrapWithContext(dockerErr, "failed to read volume %s", volume)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, errors.NewFileError("stat", file.Name(), err)
+	}
+	return file.Name(), info.Size(), nil
+}
+
+// writeVolume creates volume (labelled as this instance's) and unpacks the tarball data into it
+func (m *Manager) writeVolume(image, volume string, data io.Reader) error {
+	if err := m.createVolume(volume, map[string]string{LabelInstance: m.GetInstanceName()}); err != nil {
+		return err
+	}
+
+	var output strings.Builder
+	cmd := GetDockerCommand("run", "--rm", "-i",
+		"--user", "root",
+		"--entrypoint", "tar",
+		"-v", fmt.Sprintf("%s:%s", volume, copyToDir),
+		image, "-C", copyToDir, "-xf", "-")
+	cmd.Stdin = data
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", image, err).WithOutput(output.String())
+		return errors.WrapWithContext(dockerErr, "failed to restore volume %s", volume)
+	}
+	return nil
+}
+
+// RemoveVolumes deletes volumes restored for an import that did not finish
+func (m *Manager) RemoveVolumes(mounts []VolumeMount) {
+	for _, mount := range mounts {
+		if output, err := GetDockerCommand("volume", "rm", mount.Name).CombinedOutput(); err != nil && !volumeMissing(string(output)) {
+			utils.LogWarning(fmt.Sprintf("Failed to remove volume %s: %v", mount.Name, err))
+		}
+	}
+}
+
+// writeInstanceArchive writes the manifest and the volume tarballs in files, in the order of
+// manifest.Volumes, to a gzipped tarball at path. The archive holds the admin password, so
+// only the current user may read it.
+func writeInstanceArchive(path string, manifest *InstanceManifest, files []string, onProgress func(float64)) (int64, error) {
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, errors.WrapWithContext(err, "failed to encode instance manifest")
+	}
+
+	var total int64
+	for _, volume := range manifest.Volumes {
+		total += volume.Bytes
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, errors.NewFileError("create", filepath.Dir(path), err)
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, errors.NewFileError("create", path, err)
+	}
+
+	gz := gzip.NewWriter(out)
+	archive := tar.NewWriter(gz)
+	write := func() error {
+		if err := archive.WriteHeader(&tar.Header{Name: instanceManifestEntry, Mode: 0600, Size: int64(len(encoded)), ModTime: manifest.CreatedAt}); err != nil {
+			return err
+		}
+		if _, err := archive.Write(encoded); err != nil {
+			return err
+		}
+
+		var written int64
+		for i, volume := range manifest.Volumes {
+			if err := archive.WriteHeader(&tar.Header{Name: volume.Entry, Mode: 0600, Size: volume.Bytes, ModTime: manifest.CreatedAt}); err != nil {
+				return err
+			}
+			in, err := os.Open(files[i])
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(archive, in)
+			in.Close()
+			if err != nil {
+				return err
+			}
+			written += volume.Bytes
+			if onProgress != nil && total > 0 {
+				onProgress(float64(written) / float64(total) * 100)
+			}
+		}
+		if err := archive.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+
+	if err := write(); err != nil {
+		out.Close()
+		os.Remove(path)
+		return 0, errors.NewFileError("write", path, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path)
+		return 0, errors.NewFileError("write", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, errors.NewFileError("stat", path, err)
+	}
+	return info.Size(), nil
+}
+
+// openInstanceArchive reads an instance archive's tarball
+func openInstanceArchive(r io.Reader) (*tar.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "not an instance archive: %v", err)
+	}
+	return tar.NewReader(gz), nil
+}
+
+// readManifestEntry reads the manifest, which must be the archive's first entry
+func readManifestEntry(reader *tar.Reader) (*InstanceManifest, error) {
+	header, err := reader.Next()
+	if err != nil || header.Name != instanceManifestEntry {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "not an instance archive: %s is missing", instanceManifestEntry)
+	}
+
+	var manifest InstanceManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "invalid instance manifest: %v", err)
+	}
+	if manifest.Format < 1 || manifest.Format > InstanceArchiveFormat {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "instance archive format %d is not supported; update Moodle Prototype Manager", manifest.Format)
+	}
+	if manifest.Image == "" {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "instance manifest names no image")
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInstanceArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	volume := filepath.Join(dir, "volume.tar")
+	if err := os.WriteFile(volume, []byte("moodledata"), 0600); err != nil {
+		t.Fatalf("Failed to write volume: %v", err)
+	}
+
+	manifest := &InstanceManifest{
+		Format:      InstanceArchiveFormat,
+		CreatedAt:   time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Instance:    "alice",
+		Image:       "moodlehq/moodle-php-apache:8.2",
+		Volumes:     []InstanceVolume{{Destination: "/var/www/moodledata", Entry: "volumes/0.tar", Bytes: 10}},
+		Credentials: InstanceCredentials{Username: "admin", Password: "secret", URL: "http://localhost:8080"},
+		Settings:    json.RawMessage(`{"memory":{"limitMB":2048}}`),
+	}
+	path := filepath.Join(dir, "quiz.moodle-instance")
+	if _, err := writeInstanceArchive(path, manifest, []string{volume}, nil); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat archive: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the archive holding the admin password to be private, got %v", info.Mode().Perm())
+	}
+
+	read, err := ReadInstanceManifest(path)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if read.Image != manifest.Image || read.Credentials != manifest.Credentials || len(read.Volumes) != 1 {
+		t.Errorf("Manifest did not round-trip: %+v", read)
+	}
+	var settings struct {
+		Memory struct {
+			LimitMB int `json:"limitMB"`
+		} `json:"memory"`
+	}
+	if err := json.Unmarshal(read.Settings, &settings); err != nil || settings.Memory.LimitMB != 2048 {
+		t.Errorf("Expected settings to be kept, got %s", read.Settings)
+	}
+}
+
+func TestReadInstanceManifestRejects(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(plain, []byte("not an archive"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := ReadInstanceManifest(plain); err == nil {
+		t.Error("Expected a plain file to be rejected")
+	}
+
+	newer := filepath.Join(dir, "newer.moodle-instance")
+	writeTestArchive(t, newer, `{"format": 99, "image": "moodle:latest"}`)
+	if _, err := ReadInstanceManifest(newer); err == nil {
+		t.Error("Expected a newer archive format to be rejected")
+	}
+
+	noImage := filepath.Join(dir, "noimage.moodle-instance")
+	writeTestArchive(t, noImage, `{"format": 1}`)
+	if _, err := ReadInstanceManifest(noImage); err == nil {
+		t.Error("Expected a manifest without an image to be rejected")
+	}
+}
+
+// writeTestArchive writes an archive holding only the given manifest
+func writeTestArchive(t *testing.T, path, manifest string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	if err := archive.WriteHeader(&tar.Header{Name: instanceManifestEntry, Mode: 0600, Size: int64(len(manifest))}); err != nil {
+		t.Fatalf("Failed to write header: %v", err)
+	}
+	if _, err := io.WriteString(archive, manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	archive.Close()
+	gz.Close()
+}
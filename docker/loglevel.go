@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"fmt"
+
+	"moodle-prototype-manager/errors"
+)
+
+// Environment variables the prototype image reads to set log verbosity at container start
+const (
+	EnvApacheLogLevel    = "APACHE_LOG_LEVEL"
+	EnvPHPErrorReporting = "PHP_ERROR_REPORTING"
+	EnvPHPDisplayErrors  = "PHP_DISPLAY_ERRORS"
+	EnvMoodleDebug       = "MOODLE_DEBUG"
+)
+
+// Supported verbosity values; empty keeps the image default
+var (
+	ApacheLogLevels   = []string{"", "error", "warn", "notice", "info", "debug"}
+	PHPErrorLevels    = []string{"", "errors", "warnings", "all"}
+	MoodleDebugLevels = []string{"", "none", "minimal", "normal", "all", "developer"}
+)
+
+// phpErrorReporting maps PHP levels to error_reporting expressions
+var phpErrorReporting = map[string]string{
+	"errors":   "E_ERROR | E_PARSE",
+	"warnings": "E_ALL & ~E_NOTICE & ~E_DEPRECATED & ~E_STRICT",
+	"all":      "E_ALL",
+}
+
+// LogLevels selects container log verbosity for Apache, PHP and Moodle
+type LogLevels struct {
+	Apache string `json:"apache"`
+	PHP    string `json:"php"`
+	Moodle string `json:"moodle"`
+}
+
+// Validate checks every level against the values the image supports
+func (l LogLevels) Validate() error {
+	multiErr := errors.NewMultiError("log level validation")
+	check := func(field, value string, allowed []string) {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return
+			}
+		}
+		multiErr.Add(errors.NewValidationError(field, fmt.Sprintf("must be one of %q", allowed[1:]), value))
+	}
+
+	check("apache", l.Apache, ApacheLogLevels)
+	check("php", l.PHP, PHPErrorLevels)
+	check("moodle", l.Moodle, MoodleDebugLevels)
+	return multiErr.ToError()
+}
+
+// SetLogLevels sets the log verbosity passed to new containers
+func (m *Manager) SetLogLevels(levels LogLevels) error {
+	if err := levels.Validate(); err != nil {
+		return err
+	}
+	m.logLevels = levels
+	return nil
+}
+
+// GetLogLevels returns the log verbosity passed to new containers
+func (m *Manager) GetLogLevels() LogLevels {
+	return m.logLevels
+}
+
+// logLevelArgs returns `-e` flags for the configured log verbosity
+func (m *Manager) logLevelArgs() []string {
+	args := make([]string, 0)
+	env := m.Adapter().Env
+	add := func(name, value string) {
+		if name != "" && value != "" {
+			args = append(args, "-e", fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+
+	add(env.ApacheLogLevel, m.logLevels.Apache)
+	if m.logLevels.PHP != "" {
+		add(env.PHPErrorReporting, phpErrorReporting[m.logLevels.PHP])
+		// Show errors in the page only at the most verbose level
+		if m.logLevels.PHP == "all" {
+			add(env.PHPDisplayErrors, "On")
+		}
+	}
+	add(env.MoodleDebug, m.logLevels.Moodle)
+	return args
+}
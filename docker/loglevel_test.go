@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogLevelArgs(t *testing.T) {
+	manager := NewManager()
+	if args := manager.logLevelArgs(); len(args) != 0 {
+		t.Errorf("Expected no args for default levels, got %v", args)
+	}
+
+	if err := manager.SetLogLevels(LogLevels{Apache: "debug", PHP: "all", Moodle: "developer"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	joined := strings.Join(manager.logLevelArgs(), " ")
+	for _, expected := range []string{"APACHE_LOG_LEVEL=debug", "PHP_ERROR_REPORTING=E_ALL", "PHP_DISPLAY_ERRORS=On", "MOODLE_DEBUG=developer"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Expected %q in args: %s", expected, joined)
+		}
+	}
+
+	if err := manager.SetLogLevels(LogLevels{Apache: "loud"}); err == nil {
+		t.Error("Expected validation error for unsupported Apache level")
+	}
+}
@@ -1,8 +1,19 @@
 package docker
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/bundle"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
 )
 
 // CredentialInfo holds extracted credential information
@@ -13,33 +24,53 @@ type CredentialInfo struct {
 
 // LogParser handles parsing container logs for credentials
 type LogParser struct {
-	passwordRegex *regexp.Regexp
-	urlRegex      *regexp.Regexp
+	image string
+	sets  []*CredentialPatternSet
+	// ready matches the install-finished line of images whose login the manager sets
+	ready *regexp.Regexp
 }
 
-// NewLogParser creates a new log parser
+// NewLogParser creates a log parser trying every known pattern set
 func NewLogParser() *LogParser {
-	return &LogParser{
-		// Match both patterns: "Password: " and "Generated admin password: "
-		passwordRegex: regexp.MustCompile(`(?:Generated admin password:|Password:)\s*(.+)`),
-		urlRegex:      regexp.MustCompile(`Moodle is available at:\s*(.+)`),
+	return &LogParser{sets: patternSetsFor("")}
+}
+
+// ForImage returns a parser that prefers the pattern sets for image, falling back to the
+// generic phrasings when they find nothing
+func (lp *LogParser) ForImage(image string) *LogParser {
+	return &LogParser{image: image, sets: patternSetsFor(image), ready: AdapterFor(image).Ready}
+}
+
+// compilePattern compiles the installed pattern asset, falling back to the built-in one
+func compilePattern(asset, fallback string) *regexp.Regexp {
+	pattern := strings.TrimSpace(bundle.Text(asset, fallback))
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring invalid pattern asset %s: %v", asset, err))
+		return regexp.MustCompile(fallback)
 	}
+	return compiled
 }
 
-// ExtractCredentials parses container logs to extract admin credentials
+// ExtractCredentials parses container logs to extract admin credentials. Escape sequences are
+// removed first, and the latest occurrence of each value is used, so a password printed again
+// after a reinstall replaces the earlier one. Each value comes from the first pattern set that
+// finds it.
 func (lp *LogParser) ExtractCredentials(logs string) *CredentialInfo {
 	creds := &CredentialInfo{}
-	
-	// Extract password
-	if matches := lp.passwordRegex.FindStringSubmatch(logs); len(matches) > 1 {
-		creds.Password = strings.TrimSpace(matches[1])
-	}
-	
-	// Extract URL
-	if matches := lp.urlRegex.FindStringSubmatch(logs); len(matches) > 1 {
-		creds.URL = strings.TrimSpace(matches[1])
+	logs = StripANSI(logs)
+
+	for _, set := range lp.sets {
+		if creds.Password == "" {
+			creds.Password = latestMatch(set.Password, logs)
+		}
+		if creds.URL == "" {
+			creds.URL = latestMatch(set.URL, logs)
+		}
+		if creds.IsComplete() {
+			break
+		}
 	}
-	
 	return creds
 }
 
@@ -56,4 +87,290 @@ func (ci *CredentialInfo) HasPassword() bool {
 // HasURL checks if URL is extracted
 func (ci *CredentialInfo) HasURL() bool {
 	return ci.URL != ""
-}
\ No newline at end of file
+}
+
+// LogFollower streams a container's logs until stopped or the container exits
+type LogFollower struct {
+	// stop ends the stream early
+	stop func()
+	done chan struct{}
+	err  error
+}
+
+// FollowContainerLogs runs `docker logs -f`, calling onLine for each stdout and stderr line.
+// tail limits the backlog replayed before following; 0 or less starts from new output.
+func (m *Manager) FollowContainerLogs(containerID string, tail int, onLine func(string)) (*LogFollower, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to FollowContainerLogs")
+	}
+	if tail < 0 {
+		tail = 0
+	}
+
+	cmd := GetDockerCommand("logs", "-f", "--tail", strconv.Itoa(tail), containerID)
+	follower, err := followCommand(cmd, containerID, "logs_follow", onLine)
+	if err != nil {
+		return nil, err
+	}
+	utils.LogDebug(fmt.Sprintf("Following logs of container %s", containerID))
+	return follower, nil
+}
+
+// followCommand starts cmd and calls onLine for each line it writes to stdout or stderr until
+// it exits or the follower is stopped. op names the command in errors.
+func followCommand(cmd *Command, containerID, op string, onLine func(string)) (*LogFollower, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.NewDockerErrorWithContainer(op+"_setup", containerID, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, errors.NewDockerErrorWithContainer(op+"_setup", containerID, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.NewDockerErrorWithContainer(op, containerID, err)
+	}
+
+	follower := &LogFollower{
+		stop: func() {
+			if cmd.Process != nil {
+				// Ignore errors; the process may already have exited with the container
+				cmd.Process.Kill()
+			}
+		},
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(follower.done)
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		scan := func(reader io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(reader)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				mu.Lock()
+				onLine(scanner.Text())
+				mu.Unlock()
+			}
+		}
+		wg.Add(2)
+		go scan(stdout)
+		go scan(stderr)
+		wg.Wait()
+
+		if err := cmd.Wait(); err != nil {
+			follower.err = errors.NewDockerErrorWithContainer(op, containerID, err)
+		}
+	}()
+	return follower, nil
+}
+
+// Done is closed once the log stream has ended
+func (f *LogFollower) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns why the stream ended; only valid after Done is closed
+func (f *LogFollower) Err() error {
+	return f.err
+}
+
+// Stop ends the log stream and waits for the last lines to be delivered
+func (f *LogFollower) Stop() {
+	f.stop()
+	<-f.done
+}
+
+const (
+	// DefaultLogTail is how many of the latest log lines a fetch returns when no tail is given
+	DefaultLogTail = 5000
+	// DefaultMaxLogBytes caps the log output a fetch holds in memory; month-old containers
+	// can have hundreds of MB of logs
+	DefaultMaxLogBytes = 8 * 1024 * 1024
+)
+
+// LogFetchOptions bounds a log fetch
+type LogFetchOptions struct {
+	// Tail is the number of latest lines to fetch; 0 uses DefaultLogTail and a negative value
+	// fetches all lines, still capped by MaxBytes
+	Tail int
+	// MaxBytes keeps only the last MaxBytes of output; 0 uses DefaultMaxLogBytes
+	MaxBytes int
+	// Since limits the fetch to lines logged after this time when set
+	Since time.Time
+	// Timestamps prefixes each line with the time docker logged it
+	Timestamps bool
+}
+
+// LogChunk is the bounded end of a container's logs
+type LogChunk struct {
+	Logs string `json:"logs"`
+	// Truncated is set when older output was dropped to stay within MaxBytes
+	Truncated bool `json:"truncated"`
+	// TotalBytes is the size of the output docker streamed, including dropped bytes
+	TotalBytes int64 `json:"totalBytes"`
+}
+
+// FetchContainerLogs returns the end of a container's stdout and stderr. The output is streamed
+// through a buffer that keeps only the last MaxBytes, so memory stays bounded however large
+// the logs are.
+func (m *Manager) FetchContainerLogs(containerID string, opts LogFetchOptions) (*LogChunk, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to FetchContainerLogs")
+	}
+	if opts.Tail == 0 {
+		opts.Tail = DefaultLogTail
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxLogBytes
+	}
+
+	args := []string{"logs"}
+	if opts.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since", opts.Since.Format(time.RFC3339Nano))
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	args = append(args, containerID)
+
+	var buffer *tailBuffer
+	err := errors.Retry(errors.DefaultRetryPolicy, func() error {
+		buffer = newTailBuffer(opts.MaxBytes)
+		cmd := GetDockerCommand(args...)
+		// Docker logs may output to stderr on some platforms, especially Windows
+		cmd.Stdout = buffer
+		cmd.Stderr = buffer
+		if err := cmd.Run(); err != nil {
+			return errors.NewDockerErrorWithContainer("logs", containerID, err).WithOutput(buffer.String())
+		}
+		return nil
+	}, logRetry("logs"))
+	if err != nil {
+		utils.LogError("Docker logs command failed", err)
+		return nil, errors.WrapWithContext(err, "failed to retrieve container logs")
+	}
+
+	chunk := &LogChunk{Logs: buffer.String(), Truncated: buffer.Truncated(), TotalBytes: buffer.total}
+	if chunk.Truncated {
+		utils.LogDebug(fmt.Sprintf("Kept the last %s of %s of logs from container %s",
+			FormatBytes(uint64(len(chunk.Logs))), FormatBytes(uint64(chunk.TotalBytes)), containerID))
+	}
+	return chunk, nil
+}
+
+// LogCursor reads a container's logs incrementally: each Next returns only the lines logged
+// since the previous call, so polling a container with megabytes of logs does not fetch and
+// parse them all again. The position is the daemon's own timestamp of the last line read, so
+// it is not affected by the clock of a remote host.
+type LogCursor struct {
+	fetcher     logFetcher
+	containerID string
+	initial     LogFetchOptions
+	last        time.Time
+}
+
+// logFetcher fetches bounded container logs, e.g. Manager
+type logFetcher interface {
+	FetchContainerLogs(containerID string, opts LogFetchOptions) (*LogChunk, error)
+}
+
+// NewLogCursor creates a cursor whose first read returns the window described by initial
+func (m *Manager) NewLogCursor(containerID string, initial LogFetchOptions) *LogCursor {
+	return &LogCursor{fetcher: m, containerID: containerID, initial: initial}
+}
+
+// Next returns the lines logged since the previous read
+func (c *LogCursor) Next() (string, error) {
+	opts := c.initial
+	if !c.last.IsZero() {
+		opts = LogFetchOptions{Tail: -1, MaxBytes: c.initial.MaxBytes, Since: c.last}
+	}
+	opts.Timestamps = true
+
+	chunk, err := c.fetcher.FetchContainerLogs(c.containerID, opts)
+	if err != nil {
+		return "", err
+	}
+	logs, last := stripLogTimestamps(chunk.Logs, c.last)
+	c.last = last
+	return logs, nil
+}
+
+// stripLogTimestamps removes the timestamp `docker logs --timestamps` puts before each line.
+// Docker's --since is inclusive, so lines not logged after since were read before and are
+// dropped. It returns the latest timestamp seen.
+func stripLogTimestamps(logs string, since time.Time) (string, time.Time) {
+	var out strings.Builder
+	last := since
+	for _, line := range strings.SplitAfter(logs, "\n") {
+		if line == "" {
+			continue
+		}
+		stamp, text, found := strings.Cut(line, " ")
+		logged, err := time.Parse(time.RFC3339Nano, stamp)
+		if !found || err != nil {
+			// Not a timestamped line, e.g. the tail of a line split by truncation
+			out.WriteString(line)
+			continue
+		}
+		if !logged.After(since) {
+			continue
+		}
+		if logged.After(last) {
+			last = logged
+		}
+		out.WriteString(text)
+	}
+	return out.String(), last
+}
+
+// tailBuffer is a writer keeping only the last max bytes written to it
+type tailBuffer struct {
+	max   int
+	data  []byte
+	total int64
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	// One byte more than the limit is kept to tell whether the kept output starts a line
+	keep := b.max + 1
+	if len(p) >= keep {
+		b.data = append(b.data[:0], p[len(p)-keep:]...)
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	// Compact once the buffer holds twice the limit, so trimming stays amortised
+	if len(b.data) >= 2*keep {
+		b.data = append(b.data[:0], b.data[len(b.data)-keep:]...)
+	}
+	return len(p), nil
+}
+
+// Truncated reports whether output was dropped
+func (b *tailBuffer) Truncated() bool {
+	return b.total > int64(b.max)
+}
+
+// String returns the kept output, starting at a line boundary when older output was dropped
+func (b *tailBuffer) String() string {
+	data := b.data
+	if len(data) <= b.max {
+		return string(data)
+	}
+	data = data[len(data)-b.max-1:]
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return string(data)
+}
@@ -1,7 +1,9 @@
 package docker
 
 import (
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestLogParser(t *testing.T) {
@@ -48,4 +50,70 @@ Container ready for connections.
 	}
 	
 	t.Logf("Log parser tests completed successfully")
-}
\ No newline at end of file
+}
+
+func TestTailBufferKeepsLastBytes(t *testing.T) {
+	buffer := newTailBuffer(16)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(buffer, "line %02d\n", i)
+	}
+
+	if !buffer.Truncated() || buffer.total != 800 {
+		t.Fatalf("Expected 800 bytes written and truncation, got %d", buffer.total)
+	}
+	if got := buffer.String(); got != "line 98\nline 99\n" {
+		t.Errorf("Expected output to start at a line boundary, got %q", got)
+	}
+	if len(buffer.data) > 34 {
+		t.Errorf("Expected memory bounded to twice the limit, holding %d bytes", len(buffer.data))
+	}
+
+	small := newTailBuffer(1024)
+	small.Write([]byte("short log\n"))
+	if small.Truncated() || small.String() != "short log\n" {
+		t.Errorf("Expected short output untouched, got %q", small.String())
+	}
+}
+
+func TestStripLogTimestamps(t *testing.T) {
+	logs := "2025-03-01T10:00:00.100000000Z Installing\n" +
+		"2025-03-01T10:00:00.200000000Z Generated admin password: secret\n" +
+		"2025-03-01T10:00:00.200000000Z \n" +
+		"2025-03-01T10:00:01.000000000Z Moodle is available at: http://localhost:8080\n"
+
+	got, last := stripLogTimestamps(logs, time.Time{})
+	want := "Installing\nGenerated admin password: secret\n\nMoodle is available at: http://localhost:8080\n"
+	if got != want {
+		t.Errorf("Expected timestamps removed, got %q", got)
+	}
+	if !last.Equal(time.Date(2025, 3, 1, 10, 0, 1, 0, time.UTC)) {
+		t.Errorf("Expected the last timestamp to be kept, got %v", last)
+	}
+
+	// --since is inclusive, so the line at the cursor is returned again and must be dropped
+	again, next := stripLogTimestamps("2025-03-01T10:00:01.000000000Z Moodle is available at: http://localhost:8080\n"+
+		"2025-03-01T10:00:02.000000000Z Container ready\n", last)
+	if again != "Container ready\n" || !next.After(last) {
+		t.Errorf("Expected only the new line, got %q (cursor %v)", again, next)
+	}
+}
+
+func TestLogCursorReadsNewLines(t *testing.T) {
+	fake := NewFakeClient("moodle:test")
+	id := "0123456789ab"
+	fake.AddContainer(FakeContainer{ID: id, Running: true})
+	fake.AddLogs(id, "Installing", "Generated admin password: secret")
+
+	cursor := fake.NewLogCursor(id, LogFetchOptions{Tail: 1})
+	if logs, err := cursor.Next(); err != nil || logs != "Generated admin password: secret\n" {
+		t.Fatalf("Expected the initial tail, got %q (%v)", logs, err)
+	}
+	if logs, err := cursor.Next(); err != nil || logs != "" {
+		t.Errorf("Expected nothing new, got %q (%v)", logs, err)
+	}
+
+	fake.AddLogs(id, "Moodle is available at: http://localhost:8080")
+	if logs, err := cursor.Next(); err != nil || logs != "Moodle is available at: http://localhost:8080\n" {
+		t.Errorf("Expected only the new line, got %q (%v)", logs, err)
+	}
+}
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"fmt"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// MailCatcherImage captures outgoing mail and shows it in a web UI
+	MailCatcherImage = "axllent/mailpit:latest"
+	// MailSMTPPort is the port the mail catcher accepts SMTP on
+	MailSMTPPort = 1025
+	// MailUIContainerPort is the mail catcher's web UI port inside its container
+	MailUIContainerPort = 8025
+	// DefaultMailUIPort is the host port the mail UI is published on
+	DefaultMailUIPort = 8025
+	// EnvMoodleSMTPHosts points the image's Moodle install at an SMTP server
+	EnvMoodleSMTPHosts = "MOODLE_SMTP_HOSTS"
+	// mailHostAlias is the name Moodle uses to reach the mail catcher on the instance network
+	mailHostAlias = "mail"
+	// moodleHostAlias is the name sidecars use to reach Moodle on the instance network
+	moodleHostAlias = "moodle"
+	// cfgScript is Moodle's CLI for reading and writing config settings, relative to MoodleDir
+	cfgScript = "admin/cli/cfg.php"
+)
+
+// MailCatcherContainerName returns the mail catcher container name for an instance
+func MailCatcherContainerName(instance string) string {
+	return ContainerName(instance) + "-mail"
+}
+
+// InstanceNetworkName returns the docker network shared by an instance and its sidecars
+func InstanceNetworkName(instance string) string {
+	return ContainerName(instance) + "-net"
+}
+
+// SetMailCatcher enables routing Moodle mail to the mail catcher sidecar
+func (m *Manager) SetMailCatcher(enabled bool, uiPort int) {
+	if uiPort <= 0 {
+		uiPort = DefaultMailUIPort
+	}
+	m.mailCatcher = enabled
+	m.mailUIPort = uiPort
+}
+
+// GetMailCatcher reports whether the mail catcher sidecar is enabled
+func (m *Manager) GetMailCatcher() bool {
+	return m.mailCatcher
+}
+
+// MailUIURL returns the host URL of the mail catcher web UI
+func (m *Manager) MailUIURL() string {
+	return fmt.Sprintf("http://%s:%d", DockerHostAddress(), m.mailUIPortOrDefault())
+}
+
+// mailArgs returns `docker run` flags pointing Moodle's SMTP at the catcher
+func (m *Manager) mailArgs() []string {
+	name := m.Adapter().Env.SMTPHosts
+	if !m.mailCatcher || name == "" {
+		return nil
+	}
+	return []string{"-e", fmt.Sprintf("%s=%s:%d", name, mailHostAlias, MailSMTPPort)}
+}
+
+// StartMailCatcher runs the mail catcher on the instance network and points Moodle's SMTP at it
+func (m *Manager) StartMailCatcher(containerID string) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to StartMailCatcher")
+	}
+
+	network, err := m.joinInstanceNetwork(containerID)
+	if err != nil {
+		return err
+	}
+	name := MailCatcherContainerName(m.GetInstanceName())
+	utils.LogInfo(fmt.Sprintf("Starting mail catcher %s", name))
+	GetDockerCommand("rm", "-f", name).CombinedOutput()
+
+	cmd := GetDockerCommand("run", "-d", "--name", name,
+		"--network", network, "--network-alias", mailHostAlias,
+		"-p", fmt.Sprintf("%d:%d", m.mailUIPortOrDefault(), MailUIContainerPort),
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		MailCatcherImage)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", MailCatcherImage, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to start mail catcher")
+	}
+
+	// Containers created before the catcher was enabled never saw the env var
+	smtpHost := fmt.Sprintf("%s:%d", mailHostAlias, MailSMTPPort)
+	if _, err := m.RunMoodleCLI(containerID, cfgScript, "--name=smtphosts", "--set="+smtpHost); err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not set Moodle SMTP host yet (is Moodle installed?): %v", err))
+	}
+	return nil
+}
+
+// joinInstanceNetwork connects Moodle to the network it shares with its sidecars and returns its name
+func (m *Manager) joinInstanceNetwork(containerID string) (string, error) {
+	network := InstanceNetworkName(m.GetInstanceName())
+	if err := m.EnsureNetwork(network); err != nil {
+		return "", err
+	}
+	if err := m.ConnectNetwork(network, containerID, moodleHostAlias); err != nil {
+		return "", err
+	}
+	return network, nil
+}
+
+// StopMailCatcher removes the mail catcher container; captured mail is discarded
+func (m *Manager) StopMailCatcher() {
+	name := MailCatcherContainerName(m.GetInstanceName())
+	if output, err := GetDockerCommand("rm", "-f", name).CombinedOutput(); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to remove mail catcher %s: %v (%s)", name, err, output))
+	}
+}
+
+// DisableMailCatcher removes the mail catcher and restores Moodle's default mail delivery
+func (m *Manager) DisableMailCatcher(containerID string) error {
+	m.StopMailCatcher()
+	if _, err := m.RunMoodleCLI(containerID, cfgScript, "--name=smtphosts", "--unset"); err != nil {
+		return errors.WrapWithContext(err, "failed to reset Moodle SMTP host")
+	}
+	return nil
+}
+
+// mailUIPortOrDefault returns the configured mail UI port
+func (m *Manager) mailUIPortOrDefault() int {
+	if m.mailUIPort <= 0 {
+		return DefaultMailUIPort
+	}
+	return m.mailUIPort
+}
@@ -0,0 +1,31 @@
+package docker
+
+import "testing"
+
+func TestMailCatcherNaming(t *testing.T) {
+	if name := MailCatcherContainerName("alice"); name != "moodle-prototype-alice-mail" {
+		t.Errorf("Unexpected mail catcher name: %s", name)
+	}
+	if network := InstanceNetworkName("alice"); network != "moodle-prototype-alice-net" {
+		t.Errorf("Unexpected network name: %s", network)
+	}
+}
+
+func TestMailUIURL(t *testing.T) {
+	manager := NewManager()
+	if args := manager.mailArgs(); len(args) != 0 {
+		t.Errorf("Expected no mail args when disabled, got %v", args)
+	}
+	if args := manager.networkArgs(); len(args) != 0 {
+		t.Errorf("Expected no network args without sidecars, got %v", args)
+	}
+
+	manager.SetMailCatcher(true, 0)
+	if url := manager.MailUIURL(); url != "http://localhost:8025" {
+		t.Errorf("Expected default UI port, got %s", url)
+	}
+	manager.SetMailCatcher(true, 9025)
+	if url := manager.MailUIURL(); url != "http://localhost:9025" {
+		t.Errorf("Expected configured UI port, got %s", url)
+	}
+}
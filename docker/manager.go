@@ -3,24 +3,72 @@ package docker
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"moodle-prototype-manager/errors"
 	"moodle-prototype-manager/utils"
 )
 
-const (
-	ContainerPort = "8080:8080"
-)
-
 // Manager handles Docker container operations
 type Manager struct{
 	imageName string
+	userName  string
+	// instanceName names the container and its instance label; empty uses userName
+	instanceName string
+	hostPort  int
+	// memoryLimit caps container memory in bytes; 0 leaves it to Docker
+	memoryLimit int64
+	logLevels   LogLevels
+	// containerEnv adds variables to new containers, after the app's own so it can override them
+	containerEnv map[string]string
+	stopTimeout time.Duration
+	// restartPolicy is passed to `docker run --restart`; empty means no restart
+	restartPolicy string
+	// mailCatcher routes Moodle mail to a sidecar whose UI is published on mailUIPort
+	mailCatcher bool
+	mailUIPort  int
+	// adminer runs a database admin UI published on adminerPort
+	adminer     bool
+	adminerPort int
+	// registryCache serves registry metadata offline; nil queries the registry directly
+	registryCache *RegistryCache
+	// interruptedPull keeps the progress of a pull that gave up so it can be resumed
+	pullMu          sync.Mutex
+	interruptedPull *interruptedPull
+}
+
+// interruptedPull is a pull that still failed after its automatic retries
+type interruptedPull struct {
+	image    string
+	progress *PullProgress
 }
 
 // NewManager creates a new Docker manager
 func NewManager() *Manager {
-	return &Manager{}
+	userName := CurrentUser()
+	return &Manager{
+		userName: userName,
+		hostPort: PreferredHostPort(userName),
+	}
+}
+
+// SetHostPort sets the host port new containers publish Moodle on
+func (m *Manager) SetHostPort(port int) {
+	if port <= 0 {
+		port = PreferredHostPort(m.userName)
+	}
+	m.hostPort = port
+}
+
+// GetHostPort returns the host port new containers publish Moodle on
+func (m *Manager) GetHostPort() int {
+	return m.hostPort
+}
+
+// GetUserName returns the OS user this manager namespaces containers by
+func (m *Manager) GetUserName() string {
+	return m.userName
 }
 
 // SetImageName sets the Docker image name to use
@@ -44,36 +92,70 @@ func (m *Manager) CheckImageExists() (bool, error) {
 		return false, errors.WrapWithContext(err, "invalid image name in Docker manager")
 	}
 
-	cmd := GetDockerCommand("images", "--format", "{{.Repository}}:{{.Tag}}")
-	output, err := cmd.CombinedOutput()
+	images, err := m.ListLocalImages()
 	if err != nil {
-		dockerErr := errors.NewDockerErrorWithImage("check", m.imageName, err).WithOutput(string(output))
-		return false, errors.WrapWithContext(dockerErr, "failed to execute docker images command")
+		return false, errors.WrapWithContext(err, "failed to check image %s", m.imageName)
 	}
 
-	exists := strings.Contains(string(output), m.imageName)
+	exists := containsImage(images, m.imageName)
 	utils.LogDebug(fmt.Sprintf("Image check - looking for: %s, exists: %v", m.imageName, exists))
 	return exists, nil
 }
 
+// ListLocalImages returns the repository:tag names of all locally available images
+func (m *Manager) ListLocalImages() ([]string, error) {
+	cmd := GetDockerCommand("images", "--format", "{{.Repository}}:{{.Tag}}")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("images", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to execute docker images command")
+	}
+
+	images := make([]string, 0)
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			images = append(images, line)
+		}
+	}
+	return images, nil
+}
+
+// containsImage reports whether imageName is in a list of repository:tag names
+func containsImage(images []string, imageName string) bool {
+	for _, image := range images {
+		if image == imageName {
+			return true
+		}
+	}
+	return false
+}
+
 // PullImage downloads the Moodle Docker image
 func (m *Manager) PullImage() error {
 	if m.imageName == "" {
 		return errors.NewValidationError("imageName", "no image name set in Docker manager", "")
 	}
+	return pullImage(m.imageName)
+}
 
+// pullImage pulls image, retrying transient registry failures
+func pullImage(image string) error {
 	// Validate image name format
-	if err := errors.ValidateImageName(m.imageName); err != nil {
+	if err := errors.ValidateImageName(image); err != nil {
 		return errors.WrapWithContext(err, "invalid image name for pull operation")
 	}
 
-	utils.LogInfo(fmt.Sprintf("Pulling Docker image: %s", m.imageName))
-	cmd := GetDockerCommand("pull", m.imageName)
-
-	output, err := cmd.CombinedOutput()
+	utils.LogInfo(fmt.Sprintf("Pulling Docker image: %s", image))
+	err := errors.Retry(errors.PullRetryPolicy, func() error {
+		cmd := GetDockerCommand("pull", image)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return errors.NewDockerErrorWithImage("pull", image, err).WithOutput(string(output))
+		}
+		return nil
+	}, logRetry("pull"))
 	if err != nil {
-		dockerErr := errors.NewDockerErrorWithImage("pull", m.imageName, err).WithOutput(string(output))
-		return errors.WrapWithContext(dockerErr, "failed to pull Docker image")
+		return errors.WrapWithContext(err, "failed to pull Docker image")
 	}
 	return nil
 }
@@ -91,6 +173,88 @@ func (m *Manager) PullImageWithProgress(progressCallback func(float64, string))
 
 	utils.LogInfo(fmt.Sprintf("Pulling Docker image with progress: %s", m.imageName))
 
+	progress := NewPullProgress()
+	if progressCallback != nil {
+		progress.AddCallback(progressCallback)
+	}
+	return m.pullWithRetry(progress)
+}
+
+// PullImageWithDetail downloads the Docker image, reporting per-layer progress, speed and ETA
+func (m *Manager) PullImageWithDetail(detailCallback func(PullSnapshot)) error {
+	if m.imageName == "" {
+		return errors.NewValidationError("imageName", "no image name set in Docker manager", "")
+	}
+	if err := errors.ValidateImageName(m.imageName); err != nil {
+		return errors.WrapWithContext(err, "invalid image name for pull with progress operation")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Pulling Docker image with detailed progress: %s", m.imageName))
+
+	progress := NewPullProgress()
+	if detailCallback != nil {
+		progress.AddDetailCallback(detailCallback)
+	}
+	return m.pullWithRetry(progress)
+}
+
+// ResumePull continues the last interrupted pull of the current image. Docker keeps the
+// layers that finished downloading, so only the rest is fetched and progress picks up where
+// it stopped.
+func (m *Manager) ResumePull(detailCallback func(PullSnapshot)) error {
+	m.pullMu.Lock()
+	interrupted := m.interruptedPull
+	m.pullMu.Unlock()
+
+	if interrupted == nil || interrupted.image != m.imageName {
+		return errors.NewValidationError("pull", "no interrupted pull to resume", m.imageName)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Resuming interrupted pull of %s", m.imageName))
+	interrupted.progress.clearCallbacks()
+	if detailCallback != nil {
+		interrupted.progress.AddDetailCallback(detailCallback)
+	}
+	return m.pullWithRetry(interrupted.progress)
+}
+
+// InterruptedPull returns the progress of an interrupted pull of the current image, if any
+func (m *Manager) InterruptedPull() (*PullSnapshot, bool) {
+	m.pullMu.Lock()
+	interrupted := m.interruptedPull
+	m.pullMu.Unlock()
+
+	if interrupted == nil || interrupted.image != m.imageName {
+		return nil, false
+	}
+	snapshot := interrupted.progress.Snapshot()
+	return &snapshot, true
+}
+
+// pullWithRetry pulls the image, retrying transient failures with the same progress tracker so
+// layers finished by earlier attempts stay complete. A pull that still fails can be resumed.
+func (m *Manager) pullWithRetry(progress *PullProgress) error {
+	image := m.imageName
+	err := errors.Retry(errors.PullRetryPolicy, func() error {
+		return m.pullWithProgressOnce(progress)
+	}, func(attempt int, delay time.Duration, err error) {
+		logRetry("pull")(attempt, delay, err)
+		progress.Retrying(attempt+1, errors.PullRetryPolicy.MaxAttempts(err), delay)
+	})
+
+	m.pullMu.Lock()
+	defer m.pullMu.Unlock()
+	switch {
+	case err == nil:
+		m.interruptedPull = nil
+	case errors.ClassifyError(err) != errors.ClassPermanent:
+		m.interruptedPull = &interruptedPull{image: image, progress: progress}
+	}
+	return err
+}
+
+// pullWithProgressOnce runs a single docker pull attempt, feeding its output to progress
+func (m *Manager) pullWithProgressOnce(progress *PullProgress) error {
 	// Create command but don't run it yet
 	cmd := GetDockerCommand("pull", m.imageName)
 
@@ -114,12 +278,6 @@ func (m *Manager) PullImageWithProgress(progressCallback func(float64, string))
 		return errors.WrapWithContext(dockerErr, "failed to start docker pull command")
 	}
 
-	// Create progress tracker
-	progress := NewPullProgress()
-	if progressCallback != nil {
-		progress.AddCallback(progressCallback)
-	}
-
 	// Process output in separate goroutines
 	errChan := make(chan error, 2)
 
@@ -141,16 +299,17 @@ func (m *Manager) PullImageWithProgress(progressCallback func(float64, string))
 		}
 	}()
 
-	// Wait for command to complete
-	cmdErr := cmd.Wait()
-
-	// Wait for both stream processors to complete
+	// Both streams must be read to the end before Wait closes the pipes, or the error Docker
+	// prints last is lost
 	streamErr1 := <-errChan
 	streamErr2 := <-errChan
+	cmdErr := cmd.Wait()
 
 	// Check for errors
 	if cmdErr != nil {
-		dockerErr := errors.NewDockerErrorWithImage("pull", m.imageName, cmdErr)
+		// The daemon's error message decides whether to retry
+		output := strings.TrimSpace(fmt.Sprintf("%s %v %v", progress.LastError(), streamErr1, streamErr2))
+		dockerErr := errors.NewDockerErrorWithImage("pull", m.imageName, classifyPullFailure(cmdErr, output)).WithOutput(output)
 		return errors.WrapWithContext(dockerErr, "docker pull command failed")
 	}
 
@@ -166,8 +325,37 @@ func (m *Manager) PullImageWithProgress(progressCallback func(float64, string))
 	return nil
 }
 
+// Fragments of docker pull errors that the generic retry markers do not cover
+var (
+	pullNetworkMarkers  = []string{"no such host", "server misbehaving", "network is unreachable", "no route to host", "dial tcp"}
+	pullNotFoundMarkers = []string{"manifest unknown", "not found", "pull access denied", "repository does not exist"}
+)
+
+// classifyPullFailure wraps the error of a failed docker pull in the errors sentinel its
+// output names, so a registry that cannot be reached is retried and a missing image is not
+func classifyPullFailure(cmdErr error, output string) error {
+	text := strings.ToLower(output)
+	for _, marker := range pullNotFoundMarkers {
+		if strings.Contains(text, marker) {
+			return fmt.Errorf("%w: %v", errors.ErrImageNotFound, cmdErr)
+		}
+	}
+	for _, marker := range pullNetworkMarkers {
+		if strings.Contains(text, marker) {
+			return fmt.Errorf("%w: %v", errors.ErrNetworkUnavailable, cmdErr)
+		}
+	}
+	return cmdErr
+}
+
 // RunContainer starts a new Moodle container
 func (m *Manager) RunContainer() (string, error) {
+	return m.RunContainerWithVolumes(nil)
+}
+
+// RunContainerWithVolumes creates and starts a new container with existing volumes mounted,
+// such as those restored from an instance archive
+func (m *Manager) RunContainerWithVolumes(volumes []VolumeMount) (string, error) {
 	if m.imageName == "" {
 		return "", errors.NewValidationError("imageName", "no image name set in Docker manager", "")
 	}
@@ -177,8 +365,35 @@ func (m *Manager) RunContainer() (string, error) {
 		return "", errors.WrapWithContext(err, "invalid image name for run container operation")
 	}
 
-	utils.LogInfo(fmt.Sprintf("Running container from image: %s", m.imageName))
-	cmd := GetDockerCommand("run", "-d", "-p", ContainerPort, m.imageName)
+	adapter := m.Adapter()
+	utils.LogInfo(fmt.Sprintf("Running container from image: %s (%s adapter)", m.imageName, adapter.Name))
+	args := []string{"run", "-d",
+		"--name", ContainerName(m.GetInstanceName()),
+		"-p", fmt.Sprintf("%d:%d", m.hostPort, adapter.ContainerPort),
+	}
+	// Labels let orphaned containers be found with `docker ps --filter label=...`
+	labels := m.ContainerLabels()
+	for _, key := range []string{LabelApp, LabelUser, LabelInstance} {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	args = append(args, m.memoryArgs()...)
+	args = append(args, m.restartArgs()...)
+	args = append(args, m.healthCheckArgs()...)
+	// Lets Xdebug in the container connect back to the IDE on the host
+	args = append(args, hostGatewayArgs()...)
+	// Sidecars reach Moodle by name on a dedicated bridge network
+	args = append(args, m.networkArgs()...)
+	envArgs, err := m.moodleEnvArgs(adapter, "")
+	if err != nil {
+		return "", err
+	}
+	args = append(args, envArgs...)
+	for _, volume := range volumes {
+		args = append(args, "-v", fmt.Sprintf("%s:%s", volume.Name, volume.Destination))
+	}
+	args = append(args, m.imageName)
+
+	cmd := GetDockerCommand(args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		dockerErr := errors.NewDockerErrorWithImage("run", m.imageName, err).WithOutput(string(output))
@@ -203,12 +418,17 @@ func (m *Manager) StartContainer(containerID string) error {
 		return errors.WrapWithContext(err, "invalid container ID provided to StartContainer")
 	}
 
-	cmd := GetDockerCommand("start", containerID)
-	output, err := cmd.CombinedOutput()
+	err := errors.Retry(errors.DefaultRetryPolicy, func() error {
+		cmd := GetDockerCommand("start", containerID)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return errors.NewDockerErrorWithContainer("start", containerID, err).WithOutput(string(output))
+		}
+		return nil
+	}, logRetry("start"))
 	if err != nil {
-		dockerErr := errors.NewDockerErrorWithContainer("start", containerID, err).WithOutput(string(output))
-		utils.LogError("Docker start command failed", dockerErr)
-		return errors.WrapWithContext(dockerErr, "failed to start existing container")
+		utils.LogError("Docker start command failed", err)
+		return errors.WrapWithContext(err, "failed to start existing container")
 	}
 	return nil
 }
@@ -220,8 +440,9 @@ func (m *Manager) StopContainer(containerID string) error {
 		return errors.WrapWithContext(err, "invalid container ID provided to StopContainer")
 	}
 
-	cmd := GetDockerCommand("stop", containerID)
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"stop"}, m.stopTimeoutArgs()...)
+	cmd := GetDockerCommand(append(args, containerID)...)
+	output, err := runWithTimeout(cmd, m.GetStopTimeout()+commandGrace)
 	if err != nil {
 		dockerErr := errors.NewDockerErrorWithContainer("stop", containerID, err).WithOutput(string(output))
 		utils.LogError("Docker stop command failed", dockerErr)
@@ -248,54 +469,29 @@ func (m *Manager) IsContainerRunning(containerID string) (bool, error) {
 	return strings.TrimSpace(string(output)) == "true", nil
 }
 
-// GetContainerLogs retrieves logs from a container
+// GetContainerLogs retrieves the latest DefaultLogTail lines of a container's logs, capped at
+// DefaultMaxLogBytes
 func (m *Manager) GetContainerLogs(containerID string) (string, error) {
-	// Validate container ID
-	if err := errors.ValidateContainerID(containerID); err != nil {
-		return "", errors.WrapWithContext(err, "invalid container ID provided to GetContainerLogs")
-	}
-
-	cmd := GetDockerCommand("logs", containerID)
-
-	// Use CombinedOutput to capture both stdout and stderr
-	// Docker logs may output to stderr on some platforms, especially Windows
-	output, err := cmd.CombinedOutput()
+	chunk, err := m.FetchContainerLogs(containerID, LogFetchOptions{})
 	if err != nil {
-		dockerErr := errors.NewDockerErrorWithContainer("logs", containerID, err).WithOutput(string(output))
-		utils.LogError("Docker logs command failed", dockerErr)
-		return "", errors.WrapWithContext(dockerErr, "failed to retrieve container logs")
+		return "", err
 	}
-
-	return string(output), nil
+	return chunk.Logs, nil
 }
 
-// GetContainerLogsSince retrieves logs from a container since a specific time
+// GetContainerLogsSince retrieves logs from a container since a specific time, capped at
+// DefaultMaxLogBytes
 func (m *Manager) GetContainerLogsSince(containerID string, since time.Time) (string, error) {
-	// Validate container ID
-	if err := errors.ValidateContainerID(containerID); err != nil {
-		return "", errors.WrapWithContext(err, "invalid container ID provided to GetContainerLogsSince")
-	}
-
 	// Validate time parameter
 	if since.IsZero() {
 		return "", errors.NewValidationError("since", "since time cannot be zero", since)
 	}
 
-	// Format time for docker logs --since flag
-	// Docker accepts RFC3339 format
-	sinceStr := since.Format(time.RFC3339)
-
-	cmd := GetDockerCommand("logs", "--since", sinceStr, containerID)
-
-	// Use CombinedOutput to capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
+	chunk, err := m.FetchContainerLogs(containerID, LogFetchOptions{Tail: -1, Since: since})
 	if err != nil {
-		dockerErr := errors.NewDockerErrorWithContainer("logs_since", containerID, err).WithOutput(string(output))
-		utils.LogError("Docker logs --since command failed", dockerErr)
-		return "", errors.WrapWithContext(dockerErr, "failed to retrieve container logs since %s", sinceStr)
+		return "", err
 	}
-
-	return string(output), nil
+	return chunk.Logs, nil
 }
 
 
@@ -336,4 +532,11 @@ func (m *Manager) ForceStopContainer(containerID string) error {
 		return errors.WrapWithContext(dockerErr, "failed to force stop container")
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// logRetry returns a retry callback that logs transient Docker failures
+func logRetry(operation string) func(int, time.Duration, error) {
+	return func(attempt int, delay time.Duration, err error) {
+		utils.LogWarning(fmt.Sprintf("Docker %s failed (attempt %d), retrying in %v: %v", operation, attempt, delay, err))
+	}
+}
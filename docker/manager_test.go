@@ -0,0 +1,29 @@
+package docker
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"moodle-prototype-manager/errors"
+)
+
+func TestClassifyPullFailure(t *testing.T) {
+	exitErr := stderrors.New("exit status 1")
+	cases := []struct {
+		output string
+		want   errors.RetryClass
+	}{
+		{`Error response from daemon: Get "https://registry-1.docker.io/v2/": dial tcp: lookup registry-1.docker.io: no such host`, errors.ClassTransient},
+		{"Error response from daemon: Get \"https://registry-1.docker.io/v2/\": net/http: TLS handshake timeout", errors.ClassTransient},
+		{"Error response from daemon: toomanyrequests: You have reached your pull rate limit", errors.ClassRateLimited},
+		{"Error response from daemon: manifest for wenkhairu/moodle-prototype:999 not found: manifest unknown", errors.ClassPermanent},
+		{"Error response from daemon: pull access denied for wenkhairu/missing", errors.ClassPermanent},
+		{"", errors.ClassPermanent},
+	}
+	for _, c := range cases {
+		err := errors.NewDockerErrorWithImage("pull", "wenkhairu/moodle-prototype:502-stable", classifyPullFailure(exitErr, c.output)).WithOutput(c.output)
+		if got := errors.ClassifyError(errors.WrapWithContext(err, "docker pull command failed")); got != c.want {
+			t.Errorf("%q: expected class %v, got %v", c.output, c.want, got)
+		}
+	}
+}
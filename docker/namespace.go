@@ -0,0 +1,361 @@
+package docker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// ContainerNamePrefix is prepended to every container name this app creates
+	ContainerNamePrefix = "moodle-prototype"
+	// LabelApp marks containers managed by this app
+	LabelApp = "app"
+	// AppLabelValue is the value of LabelApp on managed containers
+	AppLabelValue = "moodle-prototype-manager"
+	// LabelUser records which OS user created the container
+	LabelUser = "user"
+	// LabelInstance records the instance name, which also forms the container name
+	LabelInstance = "instance"
+
+	// DefaultHostPort is the preferred host port for the Moodle web server
+	DefaultHostPort = 8080
+	// MoodleContainerPort is the port Moodle listens on inside the container
+	MoodleContainerPort = 8080
+	// maxPortSearch bounds how far above the preferred port we look for a free one
+	maxPortSearch = 100
+	// userPortSlots is how many host port ranges users are spread over, maxPortSearch apart
+	// so that each user's search for a free port stays within their own range
+	userPortSlots = 10
+)
+
+var (
+	unsafeNameChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+	hostPortRegex   = regexp.MustCompile(`:(\d+)->`)
+)
+
+// ManagedContainer describes a container created by any user's copy of this app
+type ManagedContainer struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	User      string `json:"user"`
+	Instance  string `json:"instance"`
+	State     string `json:"state"`
+	HostPorts []int  `json:"hostPorts"`
+}
+
+// CurrentUser returns the OS user name sanitised for use in container names and labels
+func CurrentUser() string {
+	name := ""
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if name == "" {
+		name = os.Getenv("USER")
+	}
+	if name == "" {
+		name = os.Getenv("USERNAME")
+	}
+	return sanitizeName(name)
+}
+
+// PreferredHostPort returns the host port a user's Moodle is published on by default. Users
+// sharing a machine get different ranges (8080, 8180, ... 8980), so their instances do not
+// compete for the same port.
+func PreferredHostPort(userName string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(sanitizeName(userName)))
+	return DefaultHostPort + int(hash.Sum32()%userPortSlots)*maxPortSearch
+}
+
+// sanitizeName lower-cases a name and strips characters Docker does not allow
+func sanitizeName(name string) string {
+	// Windows user names may include the domain (DOMAIN\user)
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = unsafeNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// ContainerName returns the deterministic container name for an instance (moodle-prototype-<instance>)
+func ContainerName(instance string) string {
+	return fmt.Sprintf("%s-%s", ContainerNamePrefix, sanitizeName(instance))
+}
+
+// SetInstanceName sets the instance name used for the container name and label; empty uses the OS user
+func (m *Manager) SetInstanceName(instance string) {
+	if instance == "" {
+		m.instanceName = ""
+		return
+	}
+	m.instanceName = sanitizeName(instance)
+}
+
+// GetInstanceName returns the instance name, which defaults to the OS user
+func (m *Manager) GetInstanceName() string {
+	if m.instanceName == "" {
+		return m.userName
+	}
+	return m.instanceName
+}
+
+// ContainerLabels returns the labels applied to containers created by this manager
+func (m *Manager) ContainerLabels() map[string]string {
+	return map[string]string{
+		LabelApp:      AppLabelValue,
+		LabelUser:     m.userName,
+		LabelInstance: m.GetInstanceName(),
+	}
+}
+
+// FindInstanceContainer looks up this manager's container by its labels, returning nil if there is none.
+// It works even when container.id has been lost.
+func (m *Manager) FindInstanceContainer() (*ManagedContainer, error) {
+	containers, err := m.listContainers(
+		fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		fmt.Sprintf("label=%s=%s", LabelInstance, m.GetInstanceName()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, nil
+	}
+	return &containers[0], nil
+}
+
+// ListManagedContainers returns all containers labelled as managed by this app, for every user
+func (m *Manager) ListManagedContainers() ([]ManagedContainer, error) {
+	return m.listContainers(fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue))
+}
+
+// listContainers runs docker ps -a with the given filters and parses the result
+func (m *Manager) listContainers(filters ...string) ([]ManagedContainer, error) {
+	format := fmt.Sprintf("{{.ID}}\t{{.Names}}\t{{.Label %q}}\t{{.State}}\t{{.Ports}}\t{{.Label %q}}", LabelUser, LabelInstance)
+	args := []string{"ps", "-a"}
+	for _, filter := range filters {
+		args = append(args, "--filter", filter)
+	}
+	args = append(args, "--format", format)
+
+	cmd := GetDockerCommand(args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("ps", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list managed containers")
+	}
+
+	return parseManagedContainers(string(output)), nil
+}
+
+// parseManagedContainers parses tab-separated docker ps output
+func parseManagedContainers(output string) []ManagedContainer {
+	containers := make([]ManagedContainer, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		for len(fields) < 6 {
+			fields = append(fields, "")
+		}
+
+		containers = append(containers, ManagedContainer{
+			ID:        strings.TrimSpace(fields[0]),
+			Name:      strings.TrimSpace(fields[1]),
+			User:      strings.TrimSpace(fields[2]),
+			State:     strings.TrimSpace(fields[3]),
+			HostPorts: parseHostPorts(fields[4]),
+			Instance:  strings.TrimSpace(fields[5]),
+		})
+	}
+	return containers
+}
+
+// parseHostPorts extracts published host ports from a docker ps Ports column
+func parseHostPorts(ports string) []int {
+	result := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, match := range hostPortRegex.FindAllStringSubmatch(ports, -1) {
+		port, err := strconv.Atoi(match[1])
+		if err == nil && !seen[port] {
+			seen[port] = true
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// FindFreeHostPort returns preferred if it is free, otherwise the next port that is
+// neither reserved nor bound on the host
+func FindFreeHostPort(preferred int, reserved []int) (int, error) {
+	taken := make(map[int]bool, len(reserved))
+	for _, port := range reserved {
+		taken[port] = true
+	}
+
+	for port := preferred; port < preferred+maxPortSearch && port <= 65535; port++ {
+		if taken[port] {
+			continue
+		}
+		// Ports of a remote daemon cannot be probed from here; Docker reports conflicts on run
+		if IsRemoteDockerHost() || isPortFree(port) {
+			return port, nil
+		}
+	}
+
+	return 0, errors.WrapWithContext(errors.ErrPortConflict, "no free host port found between %d and %d", preferred, preferred+maxPortSearch-1)
+}
+
+// isPortFree checks whether a TCP port can be bound on the host
+func isPortFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Host port %d is in use: %v", port, err))
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// ListInstanceContainers returns this user's Moodle containers, one per instance
+func (m *Manager) ListInstanceContainers() ([]ManagedContainer, error) {
+	containers, err := m.listContainers(
+		fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		fmt.Sprintf("label=%s=%s", LabelUser, m.userName),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return instanceContainers(containers), nil
+}
+
+// instanceContainers keeps the Moodle containers of a container list. Helper containers (e.g.
+// the Behat browser) have no instance label, and neither do Moodle containers created before
+// instances existed; those are named after their user, whose instance they belong to.
+func instanceContainers(containers []ManagedContainer) []ManagedContainer {
+	instances := make([]ManagedContainer, 0, len(containers))
+	for _, container := range containers {
+		if container.Instance == "" && container.User != "" && container.Name == ContainerName(container.User) {
+			container.Instance = sanitizeName(container.User)
+		}
+		if container.Instance != "" {
+			instances = append(instances, container)
+		}
+	}
+	return instances
+}
+
+// FindOrphanedContainers returns this user's managed containers other than knownID,
+// e.g. when container.id was lost or deleted
+func (m *Manager) FindOrphanedContainers(knownID string) ([]ManagedContainer, error) {
+	containers, err := m.ListInstanceContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]ManagedContainer, 0)
+	for _, container := range containers {
+		if knownID != "" && sameContainerID(container.ID, knownID) {
+			continue
+		}
+		orphans = append(orphans, container)
+	}
+	return orphans, nil
+}
+
+// sameContainerID compares full and short container IDs
+func sameContainerID(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a != "" && strings.HasPrefix(b, a)
+}
+
+// RemoveContainer deletes a container, stopping it first when force is set
+func (m *Manager) RemoveContainer(containerID string, force bool) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to RemoveContainer")
+	}
+
+	args := []string{"rm"}
+	if force {
+		args = append(args, "-f")
+	}
+	cmd := GetDockerCommand(append(args, containerID)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("rm", containerID, err).WithOutput(string(output))
+		utils.LogError("Docker rm command failed", dockerErr)
+		return errors.WrapWithContext(dockerErr, "failed to remove container")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Container %s removed", containerID))
+	return nil
+}
+
+// RemoveContainerAndVolumes force-removes a container together with the volumes it mounts,
+// returning the names of the volumes removed. Volumes another container still uses are kept.
+func (m *Manager) RemoveContainerAndVolumes(containerID string) ([]string, error) {
+	// A missing container is reported by rm below
+	mounts, err := m.containerMounts(containerID)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not list the volumes of container %s: %v", containerID, err))
+	}
+
+	// -v removes anonymous volumes with the container; named ones are removed afterwards
+	cmd := GetDockerCommand("rm", "-f", "-v", containerID)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("rm", containerID, err).WithOutput(string(output))
+		utils.LogError("Docker rm command failed", dockerErr)
+		return nil, errors.WrapWithContext(dockerErr, "failed to remove container")
+	}
+	utils.LogInfo(fmt.Sprintf("Container %s removed with its anonymous volumes", containerID))
+
+	removed := make([]string, 0)
+	var firstErr error
+	for _, mount := range mounts {
+		if mount.Type != "volume" || mount.Name == "" {
+			continue
+		}
+		output, err := GetDockerCommand("volume", "rm", mount.Name).CombinedOutput()
+		switch {
+		case err == nil, volumeMissing(string(output)):
+			removed = append(removed, mount.Name)
+		case volumeInUse(string(output)):
+			utils.LogWarning(fmt.Sprintf("Keeping volume %s, another container still uses it", mount.Name))
+		default:
+			dockerErr := errors.NewDockerError("volume_rm", err).WithOutput(string(output))
+			utils.LogError(fmt.Sprintf("Failed to remove volume %s", mount.Name), dockerErr)
+			if firstErr == nil {
+				firstErr = errors.WrapWithContext(dockerErr, "failed to remove volume %s", mount.Name)
+			}
+		}
+	}
+	return removed, firstErr
+}
+
+// volumeMissing reports whether `docker volume rm` failed because the volume is already gone
+func volumeMissing(output string) bool {
+	return strings.Contains(strings.ToLower(output), "no such volume")
+}
+
+// volumeInUse reports whether `docker volume rm` refused because a container uses the volume
+func volumeInUse(output string) bool {
+	return strings.Contains(strings.ToLower(output), "volume is in use")
+}
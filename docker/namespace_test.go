@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainerName(t *testing.T) {
+	tests := map[string]string{
+		"alice":           "moodle-prototype-alice",
+		"CORP\\Bob.Smith": "moodle-prototype-bob.smith",
+		"jöhn doe":        "moodle-prototype-j-hn-doe",
+		"":                "moodle-prototype-default",
+	}
+
+	for input, expected := range tests {
+		if name := ContainerName(input); name != expected {
+			t.Errorf("ContainerName(%q) = %s, expected %s", input, name, expected)
+		}
+	}
+}
+
+func TestParseManagedContainers(t *testing.T) {
+	output := "abc123def456\tmoodle-prototype-alice\talice\trunning\t0.0.0.0:8080->8080/tcp, :::8080->8080/tcp\talice\n" +
+		"def456abc123\tmoodle-prototype-bob\tbob\texited\t\n"
+
+	containers := parseManagedContainers(output)
+	if len(containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(containers))
+	}
+
+	if containers[0].User != "alice" || containers[0].Instance != "alice" || len(containers[0].HostPorts) != 1 || containers[0].HostPorts[0] != 8080 {
+		t.Errorf("Unexpected first container: %+v", containers[0])
+	}
+
+	if containers[1].State != "exited" || len(containers[1].HostPorts) != 0 {
+		t.Errorf("Unexpected second container: %+v", containers[1])
+	}
+}
+
+func TestInstanceContainersIncludesPreNamespaceContainers(t *testing.T) {
+	containers := instanceContainers([]ManagedContainer{
+		{ID: "abc123def456", Name: "moodle-prototype-alice", User: "alice"},
+		{ID: "def456abc123", Name: "moodle-prototype-selenium", User: "alice"},
+		{ID: "0123456789ab", Name: "moodle-prototype-workshop", User: "alice", Instance: "workshop"},
+	})
+	if len(containers) != 2 || containers[0].Instance != "alice" || containers[1].Instance != "workshop" {
+		t.Errorf("Expected the unlabelled Moodle container in alice's instance and no helper, got %+v", containers)
+	}
+}
+
+func TestPreferredHostPort(t *testing.T) {
+	ports := make(map[int]bool)
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		port := PreferredHostPort(name)
+		if port != PreferredHostPort(strings.ToUpper(name)) {
+			t.Errorf("Expected %s to get the same port whatever the case, got %d", name, port)
+		}
+		if port < DefaultHostPort || port >= DefaultHostPort+userPortSlots*maxPortSearch || (port-DefaultHostPort)%maxPortSearch != 0 {
+			t.Errorf("Unexpected port %d for %s", port, name)
+		}
+		ports[port] = true
+	}
+	if len(ports) < 2 {
+		t.Errorf("Expected users to be spread over different ports, got %v", ports)
+	}
+}
+
+func TestSameContainerID(t *testing.T) {
+	full := "abc123def4567890abc123def4567890abc123def4567890abc123def4567890"
+	if !sameContainerID("abc123def456", full) || !sameContainerID(full, "abc123def456") {
+		t.Error("Expected short and full IDs of the same container to match")
+	}
+	if sameContainerID("def456abc123", full) || sameContainerID("", full) {
+		t.Error("Expected different IDs not to match")
+	}
+}
+
+func TestVolumeRemovalOutput(t *testing.T) {
+	if !volumeMissing("Error response from daemon: get 3f1c...: no such volume") {
+		t.Error("Expected a missing volume to be recognised")
+	}
+	inUse := "Error response from daemon: remove moodledata: volume is in use - [abc123def456]"
+	if !volumeInUse(inUse) || volumeMissing(inUse) {
+		t.Error("Expected a volume in use to be recognised")
+	}
+}
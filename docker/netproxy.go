@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// reachabilityTargets are HTTPS endpoints used to verify connectivity; any HTTP
+// response (even 401) proves we can reach the internet through the proxy
+var reachabilityTargets = []string{
+	"https://registry-1.docker.io/v2/",
+	"https://www.google.com/generate_204",
+}
+
+// ProxyConfig holds explicit proxy settings; empty fields fall back to the environment
+type ProxyConfig struct {
+	HTTPProxy  string `json:"httpProxy"`
+	HTTPSProxy string `json:"httpsProxy"`
+	NoProxy    string `json:"noProxy"`
+}
+
+// IsSet reports whether any explicit proxy is configured
+func (p ProxyConfig) IsSet() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != ""
+}
+
+var (
+	proxyMu     sync.RWMutex
+	proxyConfig ProxyConfig
+)
+
+// SetProxyConfig sets the proxy used for HTTP clients and passed to docker commands and containers
+func SetProxyConfig(config ProxyConfig) {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	proxyConfig = config
+
+	if config.IsSet() {
+		utils.LogInfo(fmt.Sprintf("Using proxy configuration: http=%s https=%s no_proxy=%s",
+			redactProxy(config.HTTPProxy), redactProxy(config.HTTPSProxy), config.NoProxy))
+	}
+}
+
+// GetProxyConfig returns the current proxy configuration
+func GetProxyConfig() ProxyConfig {
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	return proxyConfig
+}
+
+// NewHTTPClient returns an HTTP client that honours the configured proxy and HTTP(S)_PROXY
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyForRequest
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// proxyForRequest picks the proxy for a request, preferring explicit settings over the environment
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	config := GetProxyConfig()
+	if !config.IsSet() {
+		return http.ProxyFromEnvironment(req)
+	}
+
+	if bypassProxy(req.URL.Hostname(), config.NoProxy) {
+		return nil, nil
+	}
+
+	proxy := config.HTTPProxy
+	if req.URL.Scheme == "https" && config.HTTPSProxy != "" {
+		proxy = config.HTTPSProxy
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	return url.Parse(proxy)
+}
+
+// bypassProxy reports whether host matches a NO_PROXY entry
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(host)
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case host == strings.TrimPrefix(entry, "."):
+			return true
+		case strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")):
+			return true
+		}
+	}
+	return false
+}
+
+// proxyEnv returns proxy environment variables (both cases, as tools disagree) for child processes
+func proxyEnv() []string {
+	config := GetProxyConfig()
+	if !config.IsSet() {
+		return nil
+	}
+
+	env := make([]string, 0, 6)
+	add := func(name, value string) {
+		if value != "" {
+			env = append(env, name+"="+value, strings.ToLower(name)+"="+value)
+		}
+	}
+	add("HTTP_PROXY", config.HTTPProxy)
+	add("HTTPS_PROXY", config.HTTPSProxy)
+	add("NO_PROXY", config.NoProxy)
+	return env
+}
+
+// applyProxyEnv adds the proxy variables to a docker CLI invocation's environment
+func applyProxyEnv(env []string) []string {
+	extra := proxyEnv()
+	if len(extra) == 0 {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	return append(env, extra...)
+}
+
+// containerProxyArgs returns `-e` flags passing the proxy into the Moodle container
+func containerProxyArgs() []string {
+	args := make([]string, 0)
+	for _, variable := range proxyEnv() {
+		args = append(args, "-e", variable)
+	}
+	return args
+}
+
+// CheckHTTPSReachability verifies internet access over HTTPS, honouring proxies
+func CheckHTTPSReachability(ctx context.Context) bool {
+	client := NewHTTPClient(10 * time.Second)
+
+	for _, target := range reachabilityTargets {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+		if err != nil {
+			continue
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			utils.LogDebug(fmt.Sprintf("HTTPS reachability check failed for %s: %v", target, err))
+			continue
+		}
+		resp.Body.Close()
+
+		utils.LogDebug(fmt.Sprintf("HTTPS reachability check passed for %s (status %d)", target, resp.StatusCode))
+		return true
+	}
+	return false
+}
+
+// redactProxy hides credentials embedded in a proxy URL for logging
+func redactProxy(proxy string) string {
+	parsed, err := url.Parse(proxy)
+	if err != nil || parsed.User == nil {
+		return proxy
+	}
+	parsed.User = url.User("****")
+	return parsed.String()
+}
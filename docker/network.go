@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// EnsureNetwork creates a labelled bridge network if it does not exist yet
+func (m *Manager) EnsureNetwork(name string) error {
+	if err := errors.ValidateNotEmpty("network", name); err != nil {
+		return errors.WrapWithContext(err, "invalid network name provided to EnsureNetwork")
+	}
+
+	if _, err := GetDockerCommand("network", "inspect", name).CombinedOutput(); err == nil {
+		return nil
+	}
+
+	cmd := GetDockerCommand("network", "create", "--driver", "bridge",
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		name)
+	output, err := cmd.CombinedOutput()
+	// Another caller may have created it between inspect and create
+	if err != nil && !strings.Contains(string(output), "already exists") {
+		dockerErr := errors.NewDockerError("network_create", err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to create network %s", name)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Docker network %s ready", name))
+	return nil
+}
+
+// RemoveNetwork deletes a network; a network that no longer exists is not an error
+func (m *Manager) RemoveNetwork(name string) error {
+	cmd := GetDockerCommand("network", "rm", name)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(strings.ToLower(string(output)), "not found") {
+			return nil
+		}
+		dockerErr := errors.NewDockerError("network_rm", err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to remove network %s", name)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Docker network %s removed", name))
+	return nil
+}
+
+// ConnectNetwork attaches a container to a network under the given aliases
+func (m *Manager) ConnectNetwork(network, containerID string, aliases ...string) error {
+	args := []string{"network", "connect"}
+	for _, alias := range aliases {
+		args = append(args, "--alias", alias)
+	}
+	args = append(args, network, containerID)
+
+	output, err := GetDockerCommand(args...).CombinedOutput()
+	if err != nil && !strings.Contains(string(output), "already exists") {
+		dockerErr := errors.NewDockerErrorWithContainer("network_connect", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to connect container to network %s", network)
+	}
+	return nil
+}
+
+// DisconnectNetwork detaches a container from a network, ignoring containers that are not attached
+func (m *Manager) DisconnectNetwork(network, containerID string) {
+	if output, err := GetDockerCommand("network", "disconnect", network, containerID).CombinedOutput(); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to disconnect %s from %s: %v (%s)", containerID, network, err, output))
+	}
+}
+
+// networkArgs returns `docker run` flags joining the instance network when a sidecar needs it
+func (m *Manager) networkArgs() []string {
+	if !m.mailCatcher && !m.adminer {
+		return nil
+	}
+
+	network := InstanceNetworkName(m.GetInstanceName())
+	if err := m.EnsureNetwork(network); err != nil {
+		utils.LogWarning(fmt.Sprintf("Starting Moodle without the sidecar network: %v", err))
+		return nil
+	}
+	return []string{"--network", network, "--network-alias", moodleHostAlias}
+}
@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// memoryLimitStep rounds suggested limits to a friendly value
+	memoryLimitStep = 256 * 1024 * 1024
+	// dockerReservedBytes is left for the Docker VM itself when suggesting a limit
+	dockerReservedBytes = 512 * 1024 * 1024
+)
+
+// ContainerState mirrors the State section of `docker inspect`
+type ContainerState struct {
+	Status     string    `json:"Status"`
+	Running    bool      `json:"Running"`
+	OOMKilled  bool      `json:"OOMKilled"`
+	ExitCode   int       `json:"ExitCode"`
+	Error      string    `json:"Error"`
+	StartedAt  time.Time `json:"StartedAt"`
+	FinishedAt time.Time `json:"FinishedAt"`
+	// Health is nil when the container has no health check
+	Health *ContainerHealth `json:"Health"`
+}
+
+// OOMRecommendation describes an OOM kill and the memory limit suggested to avoid it
+type OOMRecommendation struct {
+	OOMKilled           bool   `json:"oomKilled"`
+	CurrentLimitBytes   int64  `json:"currentLimitBytes"`
+	SuggestedLimitBytes int64  `json:"suggestedLimitBytes"`
+	DockerMemoryBytes   int64  `json:"dockerMemoryBytes"`
+	CanIncrease         bool   `json:"canIncrease"`
+	Message             string `json:"message"`
+}
+
+// SetMemoryLimit sets the memory limit for new containers in bytes (0 means no limit)
+func (m *Manager) SetMemoryLimit(bytes int64) {
+	if bytes < 0 {
+		bytes = 0
+	}
+	m.memoryLimit = bytes
+}
+
+// memoryArgs returns the `docker run` flags for the configured memory limit
+func (m *Manager) memoryArgs() []string {
+	if m.memoryLimit <= 0 {
+		return nil
+	}
+	return []string{"--memory", fmt.Sprintf("%d", m.memoryLimit)}
+}
+
+// InspectContainerState returns the state of a container, including whether it was OOM-killed
+func (m *Manager) InspectContainerState(containerID string) (*ContainerState, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to InspectContainerState")
+	}
+
+	cmd := GetDockerCommand("inspect", "--format", "{{json .State}}", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.NewDockerErrorWithContainer("inspect", containerID, err).WithOutput(string(output))
+	}
+
+	var state ContainerState
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &state); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected container state output: %v", err)
+	}
+	return &state, nil
+}
+
+// containerMemoryLimit returns the memory limit of an existing container in bytes (0 means none)
+func (m *Manager) containerMemoryLimit(containerID string) (int64, error) {
+	cmd := GetDockerCommand("inspect", "--format", "{{.HostConfig.Memory}}", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, errors.NewDockerErrorWithContainer("inspect", containerID, err).WithOutput(string(output))
+	}
+
+	var limit int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &limit); err != nil {
+		return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected memory limit output: %v", err)
+	}
+	return limit, nil
+}
+
+// CheckOOMKill reports whether the container was OOM-killed and suggests a higher memory limit
+func (m *Manager) CheckOOMKill(containerID string) (*OOMRecommendation, error) {
+	state, err := m.InspectContainerState(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if !state.OOMKilled {
+		return &OOMRecommendation{}, nil
+	}
+
+	utils.LogWarning(fmt.Sprintf("Container %s was OOM-killed (exit code %d)", containerID, state.ExitCode))
+
+	current, err := m.containerMemoryLimit(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := ResourceReport{}
+	checkMemory(&report)
+
+	recommendation := SuggestMemoryLimit(current, int64(report.MemoryBytes))
+	recommendation.OOMKilled = true
+	return &recommendation, nil
+}
+
+// SuggestMemoryLimit doubles the current limit within what Docker has available
+func SuggestMemoryLimit(current, dockerMemory int64) OOMRecommendation {
+	recommendation := OOMRecommendation{
+		CurrentLimitBytes: current,
+		DockerMemoryBytes: dockerMemory,
+	}
+
+	// Without a container limit the kill came from the Docker VM running out of memory
+	if current <= 0 {
+		recommendation.Message = fmt.Sprintf("Moodle ran out of memory with no container limit; increase Docker's memory (currently %s) in Docker Desktop > Settings > Resources",
+			FormatBytes(uint64(dockerMemory)))
+		return recommendation
+	}
+
+	suggested := current * 2
+	if suggested < int64(MinDockerMemoryBytes) {
+		suggested = int64(MinDockerMemoryBytes)
+	}
+	if dockerMemory > 0 && suggested > dockerMemory-dockerReservedBytes {
+		suggested = dockerMemory - dockerReservedBytes
+	}
+	suggested = suggested / memoryLimitStep * memoryLimitStep
+
+	if suggested <= current {
+		recommendation.Message = fmt.Sprintf("Moodle ran out of memory at its %s limit and Docker has no more to give (%s); increase Docker's memory allocation",
+			FormatBytes(uint64(current)), FormatBytes(uint64(dockerMemory)))
+		return recommendation
+	}
+
+	recommendation.SuggestedLimitBytes = suggested
+	recommendation.CanIncrease = true
+	recommendation.Message = fmt.Sprintf("Moodle ran out of memory at its %s limit; raising the limit to %s should prevent this",
+		FormatBytes(uint64(current)), FormatBytes(uint64(suggested)))
+	return recommendation
+}
+
+// UpdateMemoryLimit changes the memory limit of an existing container in place.
+// The Moodle site lives in the container, so updating is preferred over recreating it.
+func (m *Manager) UpdateMemoryLimit(containerID string, bytes int64) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to UpdateMemoryLimit")
+	}
+	if bytes <= 0 {
+		return errors.NewValidationError("memoryLimit", "must be greater than zero", bytes)
+	}
+
+	// Unlimited swap avoids docker rejecting a limit above the previous swap limit
+	cmd := GetDockerCommand("update", "--memory", fmt.Sprintf("%d", bytes), "--memory-swap", "-1", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("update", containerID, err).WithOutput(string(output))
+		utils.LogError("Docker update command failed", dockerErr)
+		return errors.WrapWithContext(dockerErr, "failed to update container memory limit")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Container %s memory limit set to %s", containerID, FormatBytes(uint64(bytes))))
+	return nil
+}
@@ -0,0 +1,36 @@
+package docker
+
+import "testing"
+
+func TestSuggestMemoryLimit(t *testing.T) {
+	const gb = int64(gib)
+
+	tests := []struct {
+		name        string
+		current     int64
+		docker      int64
+		canIncrease bool
+		suggested   int64
+	}{
+		{"no container limit", 0, 8 * gb, false, 0},
+		{"doubles limit", 2 * gb, 8 * gb, true, 4 * gb},
+		{"raises to minimum", 512 * 1024 * 1024, 8 * gb, true, 2 * gb},
+		{"capped by docker memory", 3*gb + 512*1024*1024, 4 * gb, false, 0},
+		{"capped but still higher", 2 * gb, 4 * gb, true, 3*gb + 512*1024*1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestMemoryLimit(tt.current, tt.docker)
+			if got.CanIncrease != tt.canIncrease {
+				t.Errorf("CanIncrease = %v, want %v (%s)", got.CanIncrease, tt.canIncrease, got.Message)
+			}
+			if got.SuggestedLimitBytes != tt.suggested {
+				t.Errorf("SuggestedLimitBytes = %d, want %d", got.SuggestedLimitBytes, tt.suggested)
+			}
+			if got.Message == "" {
+				t.Error("Expected a message")
+			}
+		})
+	}
+}
@@ -1,16 +1,33 @@
 package docker
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	
+	"moodle-prototype-manager/errors"
 	"moodle-prototype-manager/utils"
 )
 
 var dockerPath string
 
+var (
+	commandObserverMu sync.RWMutex
+	// commandObserver is told about every docker CLI invocation, e.g. by the session recorder
+	commandObserver func(args []string)
+)
+
+// SetCommandObserver registers a function called with the arguments of every docker command
+// as it is created; nil removes it
+func SetCommandObserver(observer func(args []string)) {
+	commandObserverMu.Lock()
+	defer commandObserverMu.Unlock()
+	commandObserver = observer
+}
+
 // FindDockerPath attempts to locate the Docker executable
 func FindDockerPath() (string, error) {
 	// If we already found it, return cached path
@@ -131,6 +148,16 @@ func (e *DockerNotFoundError) Error() string {
 	return result
 }
 
+// Remediation returns the installation steps for the frontend
+func (e *DockerNotFoundError) Remediation() []string {
+	return e.Suggestions
+}
+
+// ErrorCode reports Docker as not installed
+func (e *DockerNotFoundError) ErrorCode() errors.Code {
+	return errors.CodeDockerNotInstalled
+}
+
 // fileExists checks if a file exists at the given path
 func fileExists(path string) bool {
 	info, err := os.Stat(path)
@@ -140,21 +167,31 @@ func fileExists(path string) bool {
 	return !info.IsDir()
 }
 
-// GetDockerCommand returns a command configured with the correct Docker path
-func GetDockerCommand(args ...string) *exec.Cmd {
+// GetDockerCommand returns a command configured with the correct Docker path. Its duration,
+// exit code and output size are recorded in the performance stats when it runs, and it runs
+// through utils.Commands like the helper programs. Pulls, exports and test runs can take far
+// longer than a helper program, so docker commands are bounded by their context rather than
+// the runner's timeout.
+func GetDockerCommand(args ...string) *Command {
 	dockerBinary, err := FindDockerPath()
 	if err != nil {
 		// Fallback to "docker" and let it fail with a more specific error
 		dockerBinary = "docker"
 	}
 	
-	cmd := exec.Command(dockerBinary, args...)
+	commandObserverMu.RLock()
+	observer := commandObserver
+	commandObserverMu.RUnlock()
+	if observer != nil {
+		observer(args)
+	}
+
+	cmd := exec.CommandContext(context.Background(), dockerBinary, args...)
 	// Apply platform-specific configuration (Windows console hiding, etc.)
 	utils.SetupCommandForPlatform(cmd)
-	return cmd
+	// Pass configured proxies to the CLI (the daemon uses its own proxy settings for pulls)
+	cmd.Env = applyProxyEnv(cmd.Env)
+	// Select a remote daemon or context when one is configured
+	cmd.Env = applyDockerHostEnv(cmd.Env)
+	return &Command{Cmd: cmd, kind: commandKind(args)}
 }
-
-// ResetDockerPath clears the cached Docker path (useful for testing)
-func ResetDockerPath() {
-	dockerPath = ""
-}
\ No newline at end of file
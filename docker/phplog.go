@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Levels of PHP error log entries
+const (
+	PHPLevelFatal      = "fatal"
+	PHPLevelParse      = "parse"
+	PHPLevelWarning    = "warning"
+	PHPLevelNotice     = "notice"
+	PHPLevelDeprecated = "deprecated"
+	// PHPLevelError is an Apache error not raised by PHP, e.g. a missing file
+	PHPLevelError = "error"
+	// PHPLevelInfo is any other line, such as a stack trace frame or an Apache notice
+	PHPLevelInfo = "info"
+)
+
+// PHPLogEntry is one line of the container's PHP or Apache error log
+type PHPLogEntry struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	// File and Line locate the error when the message names them
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	Raw  string `json:"raw"`
+}
+
+// Fatal reports whether the entry stopped a request: fatal and parse errors, and exceptions
+// Moodle's handler could not deal with
+func (e *PHPLogEntry) Fatal() bool {
+	return e.Level == PHPLevelFatal || e.Level == PHPLevelParse
+}
+
+var (
+	// phpErrorRegex matches the "PHP Fatal error:  message" prefix PHP writes to error logs
+	phpErrorRegex = regexp.MustCompile(`PHP ((?:Catchable |Recoverable )?[Ff]atal error|Parse error|Warning|Notice|Deprecated|Strict Standards):\s+(.*)$`)
+	// phpLocationRegex matches " in /path/file.php on line 12" or " in /path/file.php:12"
+	phpLocationRegex = regexp.MustCompile(` in (/\S+?\.php)(?: on line |:)(\d+)`)
+	// apacheLevelRegex matches the "[module:level]" field of Apache 2.4 error log lines
+	apacheLevelRegex = regexp.MustCompile(`\[(?:[a-z_]+:)?(emerg|alert|crit|error|warn|notice|info|debug)\]`)
+	// moodleExceptionRegex matches exceptions logged by Moodle's default handler, which ends
+	// the request just like a PHP fatal error
+	moodleExceptionRegex = regexp.MustCompile(`Default exception handler:\s+(.*)$`)
+)
+
+// ParsePHPLogLine classifies an error log line. It returns nil for blank lines.
+func ParsePHPLogLine(line string) *PHPLogEntry {
+	line = strings.TrimRight(StripANSI(line), "\r")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	entry := &PHPLogEntry{Level: PHPLevelInfo, Message: strings.TrimSpace(line), Raw: line}
+
+	if match := phpErrorRegex.FindStringSubmatch(line); match != nil {
+		entry.Message = strings.TrimSpace(match[2])
+		switch kind := strings.ToLower(match[1]); {
+		case strings.Contains(kind, "fatal"):
+			entry.Level = PHPLevelFatal
+		case kind == "parse error":
+			entry.Level = PHPLevelParse
+		case kind == "warning":
+			entry.Level = PHPLevelWarning
+		case kind == "notice":
+			entry.Level = PHPLevelNotice
+		default:
+			entry.Level = PHPLevelDeprecated
+		}
+	} else if match := moodleExceptionRegex.FindStringSubmatch(line); match != nil {
+		entry.Level, entry.Message = PHPLevelFatal, strings.TrimSpace(match[1])
+	} else if match := apacheLevelRegex.FindStringSubmatch(line); match != nil {
+		switch match[1] {
+		case "emerg", "alert", "crit", "error":
+			entry.Level = PHPLevelError
+		case "warn":
+			entry.Level = PHPLevelWarning
+		}
+	}
+
+	if match := phpLocationRegex.FindStringSubmatch(line); match != nil {
+		entry.File = match[1]
+		entry.Line, _ = strconv.Atoi(match[2])
+	}
+	return entry
+}
+
+// phpLogScript follows the given error logs from inside the container. Logs linked to the
+// container's stdout or stderr, as the official PHP images do, are skipped because docker
+// logs already shows them; the others are followed even before they exist, since PHP creates
+// them on the first error.
+const phpLogScript = `set --
+for f in %s; do
+  [ -L "$f" ] && continue
+  set -- "$@" "$f"
+done
+[ $# -gt 0 ] || exit 3
+exec tail -q -n %d -F "$@" 2>/dev/null`
+
+// FollowPHPErrorLog tails the PHP and Apache error logs named by the image's adapter, which
+// are separate from the container's stdout, calling onLine for each line. tail is the number
+// of existing lines replayed first.
+func (m *Manager) FollowPHPErrorLog(containerID string, tail int, onLine func(string)) (*LogFollower, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to FollowPHPErrorLog")
+	}
+	paths := m.Adapter().ErrorLogs
+	if len(paths) == 0 {
+		return nil, errors.NewValidationError("errorLogs", "the image adapter names no error logs", m.Adapter().Name)
+	}
+	if tail < 0 {
+		tail = 0
+	}
+
+	quoted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		quoted = append(quoted, shellQuote(path))
+	}
+	script := fmt.Sprintf(phpLogScript, strings.Join(quoted, " "), tail)
+	follower, err := followCommand(GetDockerCommand("exec", containerID, "sh", "-c", script), containerID, "php_log_follow", onLine)
+	if err != nil {
+		return nil, err
+	}
+	utils.LogDebug(fmt.Sprintf("Following PHP error logs of container %s: %s", containerID, strings.Join(paths, ", ")))
+	return follower, nil
+}
@@ -0,0 +1,74 @@
+package docker
+
+import "testing"
+
+func TestParsePHPLogLine(t *testing.T) {
+	cases := []struct {
+		line    string
+		level   string
+		message string
+		file    string
+		lineNo  int
+	}{
+		{
+			line:    "[Tue Oct 13 10:00:00.123456 2026] [php:error] [pid 123] [client 172.17.0.1:50312] PHP Fatal error:  Uncaught Error: Call to undefined function local_demo_missing() in /var/www/html/local/demo/lib.php:12",
+			level:   PHPLevelFatal,
+			message: "Uncaught Error: Call to undefined function local_demo_missing() in /var/www/html/local/demo/lib.php:12",
+			file:    "/var/www/html/local/demo/lib.php",
+			lineNo:  12,
+		},
+		{
+			line:    "[13-Oct-2026 10:00:00 UTC] PHP Parse error:  syntax error, unexpected token \"}\" in /var/www/html/mod/demo/view.php on line 40",
+			level:   PHPLevelParse,
+			message: "syntax error, unexpected token \"}\" in /var/www/html/mod/demo/view.php on line 40",
+			file:    "/var/www/html/mod/demo/view.php",
+			lineNo:  40,
+		},
+		{
+			line:    "[13-Oct-2026 10:00:01 UTC] PHP Warning:  Undefined variable $course in /var/www/html/blocks/demo/block_demo.php on line 7",
+			level:   PHPLevelWarning,
+			message: "Undefined variable $course in /var/www/html/blocks/demo/block_demo.php on line 7",
+			file:    "/var/www/html/blocks/demo/block_demo.php",
+			lineNo:  7,
+		},
+		{
+			line:    "PHP Deprecated:  Creation of dynamic property block_demo::$x is deprecated in /var/www/html/blocks/demo/block_demo.php on line 9",
+			level:   PHPLevelDeprecated,
+			message: "Creation of dynamic property block_demo::$x is deprecated in /var/www/html/blocks/demo/block_demo.php on line 9",
+			file:    "/var/www/html/blocks/demo/block_demo.php",
+			lineNo:  9,
+		},
+		{
+			line:    "[Tue Oct 13 10:00:02.000000 2026] [php:notice] [pid 124] Default exception handler: Invalid course module ID Debug: coursemodule",
+			level:   PHPLevelFatal,
+			message: "Invalid course module ID Debug: coursemodule",
+		},
+		{
+			line:    "[Tue Oct 13 10:00:03.000000 2026] [core:error] [pid 125] [client 172.17.0.1:50320] AH00128: File does not exist: /var/www/html/favicon.ico",
+			level:   PHPLevelError,
+			message: "[Tue Oct 13 10:00:03.000000 2026] [core:error] [pid 125] [client 172.17.0.1:50320] AH00128: File does not exist: /var/www/html/favicon.ico",
+		},
+		{
+			line:    "PHP Stack trace:",
+			level:   PHPLevelInfo,
+			message: "PHP Stack trace:",
+		},
+	}
+
+	for _, tc := range cases {
+		entry := ParsePHPLogLine(tc.line)
+		if entry == nil {
+			t.Fatalf("Expected an entry for %q", tc.line)
+		}
+		if entry.Level != tc.level || entry.Message != tc.message || entry.File != tc.file || entry.Line != tc.lineNo {
+			t.Errorf("ParsePHPLogLine(%q) = %+v", tc.line, entry)
+		}
+		if entry.Fatal() != (tc.level == PHPLevelFatal || tc.level == PHPLevelParse) {
+			t.Errorf("Unexpected Fatal() for %q", tc.line)
+		}
+	}
+
+	if entry := ParsePHPLogLine("   "); entry != nil {
+		t.Errorf("Expected nil for a blank line, got %+v", entry)
+	}
+}
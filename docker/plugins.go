@@ -0,0 +1,386 @@
+package docker
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// PluginDirectoryURL is the moodle.org plugin directory listing used to resolve plugin names
+const PluginDirectoryURL = "https://download.moodle.org/api/1.3/pluglist.php"
+
+// UpgradeScript is the Moodle CLI upgrade entry point relative to MoodleDir
+const UpgradeScript = "admin/cli/upgrade.php"
+
+// UninstallScript is the Moodle CLI plugin uninstaller relative to MoodleDir
+const UninstallScript = "admin/cli/uninstall_plugins.php"
+
+// pluginTypeDirs maps Moodle plugin types to their install directory relative to MoodleDir
+var pluginTypeDirs = map[string]string{
+	"mod":              "mod",
+	"block":            "blocks",
+	"local":            "local",
+	"auth":             "auth",
+	"enrol":            "enrol",
+	"filter":           "filter",
+	"format":           "course/format",
+	"theme":            "theme",
+	"tool":             "admin/tool",
+	"report":           "report",
+	"gradereport":      "grade/report",
+	"gradeexport":      "grade/export",
+	"gradeimport":      "grade/import",
+	"qtype":            "question/type",
+	"qbehaviour":       "question/behaviour",
+	"qformat":          "question/format",
+	"repository":       "repository",
+	"portfolio":        "portfolio",
+	"availability":     "availability/condition",
+	"atto":             "lib/editor/atto/plugins",
+	"tiny":             "lib/editor/tiny/plugins",
+	"editor":           "lib/editor",
+	"message":          "message/output",
+	"assignsubmission": "mod/assign/submission",
+	"assignfeedback":   "mod/assign/feedback",
+	"quiz":             "mod/quiz/report",
+	"quizaccess":       "mod/quiz/accessrule",
+	"datafield":        "mod/data/field",
+	"customfield":      "customfield/field",
+	"profilefield":     "user/profile/field",
+}
+
+var componentRegex = regexp.MustCompile(`\$plugin->component\s*=\s*['"]([a-z0-9_]+)['"]`)
+
+// PluginInstallResult describes a completed plugin installation
+type PluginInstallResult struct {
+	Component string `json:"component"`
+	Directory string `json:"directory"`
+	Output    string `json:"output"`
+	// Replaced is true when an existing version of the plugin was overwritten
+	Replaced bool `json:"replaced"`
+}
+
+// InstallPlugin installs a plugin from a local zip file or a moodle.org plugin name
+// (e.g. "mod_hvp") into the container and runs the Moodle CLI upgrade.
+func (m *Manager) InstallPlugin(containerID, source string, progressCallback func(float64, string)) (*PluginInstallResult, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to InstallPlugin")
+	}
+	if err := errors.ValidateNotEmpty("source", source); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid plugin source provided to InstallPlugin")
+	}
+
+	report := func(percentage float64, status string) {
+		utils.LogInfo(fmt.Sprintf("Plugin install: %s", status))
+		if progressCallback != nil {
+			progressCallback(percentage, status)
+		}
+	}
+
+	zipPath := source
+	if !strings.HasSuffix(strings.ToLower(source), ".zip") {
+		report(5, fmt.Sprintf("Looking up %s in the Moodle plugins directory", source))
+		downloaded, err := downloadPlugin(source)
+		if err != nil {
+			return nil, errors.WrapWithContext(err, "failed to download plugin %s", source)
+		}
+		defer os.Remove(downloaded)
+		zipPath = downloaded
+	}
+
+	report(20, "Extracting plugin archive")
+	workDir, err := os.MkdirTemp("", "moodle-plugin-")
+	if err != nil {
+		return nil, errors.NewFileError("create", os.TempDir(), err)
+	}
+	defer os.RemoveAll(workDir)
+
+	pluginDir, component, err := extractPluginZip(zipPath, workDir)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to read plugin archive %s", zipPath)
+	}
+
+	targetDir, err := m.pluginInstallDir(component)
+	if err != nil {
+		return nil, err
+	}
+
+	report(40, fmt.Sprintf("Copying %s into the container", component))
+
+	_, existsErr := m.ExecInContainer(containerID, "test", "-d", targetDir)
+	replaced := existsErr == nil
+
+	// docker cp nests the folder if the target exists, so the new version is copied next to
+	// the previous one and only swapped in once the copy succeeded
+	stagingDir := targetDir + ".installing"
+	if _, err := m.ExecInContainer(containerID, "rm", "-rf", stagingDir); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to clear staging directory of %s", component)
+	}
+	if err := m.CopyToContainer(containerID, pluginDir, stagingDir); err != nil {
+		m.removePluginDir(containerID, stagingDir)
+		return nil, errors.WrapWithContext(err, "failed to copy plugin %s into container", component)
+	}
+	if err := m.swapPluginDir(containerID, stagingDir, targetDir, replaced); err != nil {
+		m.removePluginDir(containerID, stagingDir)
+		return nil, errors.WrapWithContext(err, "failed to replace previous version of %s", component)
+	}
+
+	report(60, "Running Moodle upgrade")
+	output, err := m.RunMoodleCLI(containerID, UpgradeScript, "--non-interactive")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "Moodle upgrade failed while installing %s", component)
+	}
+
+	report(100, fmt.Sprintf("Installed %s", component))
+	return &PluginInstallResult{
+		Component: component,
+		Directory: targetDir,
+		Output:    output,
+		Replaced:  replaced,
+	}, nil
+}
+
+// swapPluginDir moves stagingDir to targetDir. A previous version is moved aside first and
+// put back if the new one cannot take its place.
+func (m *Manager) swapPluginDir(containerID, stagingDir, targetDir string, replace bool) error {
+	previousDir := targetDir + ".previous"
+	if replace {
+		if _, err := m.ExecInContainer(containerID, "rm", "-rf", previousDir); err != nil {
+			return err
+		}
+		if _, err := m.ExecInContainer(containerID, "mv", targetDir, previousDir); err != nil {
+			return err
+		}
+	}
+
+	if _, err := m.ExecInContainer(containerID, "mv", stagingDir, targetDir); err != nil {
+		if replace {
+			if _, restoreErr := m.ExecInContainer(containerID, "mv", previousDir, targetDir); restoreErr != nil {
+				utils.LogError(fmt.Sprintf("Failed to restore previous version in %s", targetDir), restoreErr)
+			}
+		}
+		return err
+	}
+
+	if replace {
+		m.removePluginDir(containerID, previousDir)
+	}
+	return nil
+}
+
+// removePluginDir removes a leftover plugin directory, logging a failure
+func (m *Manager) removePluginDir(containerID, dir string) {
+	if _, err := m.ExecInContainer(containerID, "rm", "-rf", dir); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to remove %s from the container: %v", dir, err))
+	}
+}
+
+// UninstallPlugin uninstalls a plugin through Moodle and removes its code from the container
+func (m *Manager) UninstallPlugin(containerID, component string) error {
+	targetDir, err := m.pluginInstallDir(component)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(fmt.Sprintf("Uninstalling plugin %s", component))
+	if output, err := m.RunMoodleCLI(containerID, UninstallScript, "--plugins="+component, "--run"); err != nil {
+		utils.LogDebug(fmt.Sprintf("Uninstall output: %s", output))
+		return errors.WrapWithContext(err, "Moodle failed to uninstall %s", component)
+	}
+
+	if _, err := m.ExecInContainer(containerID, "rm", "-rf", targetDir); err != nil {
+		return errors.WrapWithContext(err, "failed to remove %s from the container", component)
+	}
+	return nil
+}
+
+// CopyToContainer copies a host file or directory to a path inside the container
+func (m *Manager) CopyToContainer(containerID, hostPath, containerPath string) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to CopyToContainer")
+	}
+
+	cmd := GetDockerCommand("cp", hostPath, containerID+":"+containerPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("cp", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to copy %s into container", hostPath)
+	}
+	return nil
+}
+
+// PluginInstallDir returns the absolute container directory for a frankenstyle component
+func PluginInstallDir(component string) (string, error) {
+	parts := strings.SplitN(component, "_", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", errors.NewValidationError("component", "not a valid frankenstyle plugin name", component)
+	}
+
+	typeDir, ok := pluginTypeDirs[parts[0]]
+	if !ok {
+		return "", errors.NewValidationError("component", fmt.Sprintf("unsupported plugin type %q", parts[0]), component)
+	}
+
+	return path.Join(MoodleDir, typeDir, parts[1]), nil
+}
+
+// pluginInstallDir returns PluginInstallDir under the Moodle code root of the manager's image
+func (m *Manager) pluginInstallDir(component string) (string, error) {
+	dir, err := PluginInstallDir(component)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(m.moodleDir(), strings.TrimPrefix(dir, MoodleDir)), nil
+}
+
+// extractPluginZip unpacks a plugin archive and returns the plugin's root folder and component name
+func extractPluginZip(zipPath, destDir string) (string, string, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", "", errors.NewFileError("open", zipPath, err)
+	}
+	defer reader.Close()
+
+	rootDir := ""
+	component := ""
+
+	for _, file := range reader.File {
+		name := filepath.Clean(file.Name)
+		if strings.HasPrefix(name, "..") || filepath.IsAbs(name) {
+			return "", "", errors.NewValidationError("archive", "archive contains unsafe path", file.Name)
+		}
+
+		target := filepath.Join(destDir, name)
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", "", errors.NewFileError("create", target, err)
+			}
+			continue
+		}
+
+		if err := extractZipFile(file, target); err != nil {
+			return "", "", err
+		}
+
+		// The plugin root is the folder holding version.php closest to the archive root
+		if filepath.Base(name) == "version.php" {
+			dir := filepath.Dir(name)
+			if rootDir == "" || len(dir) < len(rootDir) {
+				data, err := os.ReadFile(target)
+				if err != nil {
+					return "", "", errors.NewFileError("read", target, err)
+				}
+				if matches := componentRegex.FindSubmatch(data); len(matches) > 1 {
+					rootDir = dir
+					component = string(matches[1])
+				}
+			}
+		}
+	}
+
+	if component == "" {
+		return "", "", errors.NewValidationError("archive", "no version.php declaring $plugin->component found", zipPath)
+	}
+
+	return filepath.Join(destDir, rootDir), component, nil
+}
+
+// extractZipFile writes a single archive entry to target
+func extractZipFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(target), err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return errors.NewFileError("read", file.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.NewFileError("write", target, err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return errors.NewFileError("write", target, err)
+	}
+	return nil
+}
+
+// pluginList mirrors the parts of the moodle.org plugin directory response we need
+type pluginList struct {
+	Plugins []struct {
+		Component string `json:"component"`
+		Versions  []struct {
+			DownloadURL string `json:"downloadurl"`
+		} `json:"versions"`
+	} `json:"plugins"`
+}
+
+// downloadPlugin resolves a component name against moodle.org and downloads its latest zip
+func downloadPlugin(component string) (string, error) {
+	client := NewHTTPClient(5 * time.Minute)
+
+	resp, err := client.Get(PluginDirectoryURL)
+	if err != nil {
+		return "", errors.NewNetworkErrorWithURL("plugin_lookup", PluginDirectoryURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.NewNetworkErrorWithURL("plugin_lookup", PluginDirectoryURL, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	var list pluginList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", errors.NewNetworkErrorWithURL("plugin_lookup", PluginDirectoryURL, err)
+	}
+
+	downloadURL := ""
+	for _, plugin := range list.Plugins {
+		if plugin.Component == component && len(plugin.Versions) > 0 {
+			// Versions are listed oldest first
+			downloadURL = plugin.Versions[len(plugin.Versions)-1].DownloadURL
+			break
+		}
+	}
+	if downloadURL == "" {
+		return "", errors.NewValidationError("component", "plugin not found in the Moodle plugins directory", component)
+	}
+
+	zipResp, err := client.Get(downloadURL)
+	if err != nil {
+		return "", errors.NewNetworkErrorWithURL("plugin_download", downloadURL, err)
+	}
+	defer zipResp.Body.Close()
+
+	if zipResp.StatusCode != http.StatusOK {
+		return "", errors.NewNetworkErrorWithURL("plugin_download", downloadURL, fmt.Errorf("unexpected status %s", zipResp.Status))
+	}
+
+	tmp, err := os.CreateTemp("", component+"-*.zip")
+	if err != nil {
+		return "", errors.NewFileError("create", os.TempDir(), err)
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, zipResp.Body); err != nil {
+		os.Remove(tmp.Name())
+		return "", errors.NewFileError("write", tmp.Name(), err)
+	}
+
+	return tmp.Name(), nil
+}
@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"archive/zip"
+	stderrors "errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"moodle-prototype-manager/utils"
+)
+
+func TestPluginInstallDir(t *testing.T) {
+	tests := map[string]string{
+		"mod_hvp":         MoodleDir + "/mod/hvp",
+		"block_xp":        MoodleDir + "/blocks/xp",
+		"tool_objectfs":   MoodleDir + "/admin/tool/objectfs",
+		"qtype_ordering":  MoodleDir + "/question/type/ordering",
+		"local_my_plugin": MoodleDir + "/local/my_plugin",
+	}
+
+	for component, expected := range tests {
+		dir, err := PluginInstallDir(component)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", component, err)
+			continue
+		}
+		if dir != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, component, dir)
+		}
+	}
+
+	for _, invalid := range []string{"hvp", "mod_", "unknown_thing"} {
+		if _, err := PluginInstallDir(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+// writePluginZip writes a mod_hvp plugin archive to zipPath
+func writePluginZip(t *testing.T, zipPath string) {
+	t.Helper()
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	writer := zip.NewWriter(file)
+	entries := map[string]string{
+		"hvp/version.php":         "<?php\n$plugin->component = 'mod_hvp';\n",
+		"hvp/lib.php":             "<?php\n",
+		"hvp/classes/version.php": "<?php\n// not a plugin version file\n",
+	}
+	for name, content := range entries {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	writer.Close()
+	file.Close()
+}
+
+func TestExtractPluginZip(t *testing.T) {
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "plugin.zip")
+	writePluginZip(t, zipPath)
+
+	pluginDir, component, err := extractPluginZip(zipPath, filepath.Join(tmpDir, "out"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if component != "mod_hvp" {
+		t.Errorf("Expected component mod_hvp, got %s", component)
+	}
+	if filepath.Base(pluginDir) != "hvp" {
+		t.Errorf("Expected plugin dir hvp, got %s", pluginDir)
+	}
+}
+
+// pluginRunner answers the docker commands of InstallPlugin for an installed plugin, failing
+// docker cp when failCopy is set
+type pluginRunner struct {
+	utils.ExecRunner
+	failCopy bool
+	execs    []string
+}
+
+func (r *pluginRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	args := cmd.Args[1:]
+	switch args[0] {
+	case "exec":
+		r.execs = append(r.execs, strings.Join(args[2:], " "))
+	case "cp":
+		if r.failCopy {
+			return []byte("no space left on device"), stderrors.New("exit status 1")
+		}
+	}
+	return nil, nil
+}
+
+func TestInstallPluginKeepsPreviousVersionUntilCopied(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "plugin.zip")
+	writePluginZip(t, zipPath)
+	containerID := strings.Repeat("a", 64)
+	target := MoodleDir + "/mod/hvp"
+
+	runner := &pluginRunner{failCopy: true}
+	previous := utils.SetCommandRunner(runner)
+	defer utils.SetCommandRunner(previous)
+
+	manager := NewManager()
+	if _, err := manager.InstallPlugin(containerID, zipPath, nil); err == nil {
+		t.Fatal("Expected the failed copy to be reported")
+	}
+	for _, command := range runner.execs {
+		if strings.HasSuffix(command, " "+target) && !strings.HasPrefix(command, "test -d") {
+			t.Errorf("Expected the installed version to be left alone, got %q", command)
+		}
+	}
+
+	runner.failCopy = false
+	runner.execs = nil
+	result, err := manager.InstallPlugin(containerID, zipPath, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	swap := []string{
+		"mv " + target + " " + target + ".previous",
+		"mv " + target + ".installing " + target,
+		"rm -rf " + target + ".previous",
+	}
+	start := slices.Index(runner.execs, swap[0])
+	if !result.Replaced || start < 0 || start+len(swap) > len(runner.execs) || !slices.Equal(runner.execs[start:start+len(swap)], swap) {
+		t.Errorf("Expected the new version to be swapped in, got %v", runner.execs)
+	}
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"moodle-prototype-manager/utils"
+)
+
+// Results of a single preflight check
+const (
+	PreflightPass = "pass"
+	PreflightWarn = "warn"
+	PreflightFail = "fail"
+)
+
+// Names of the checks in a preflight report
+const (
+	PreflightDocker         = "docker"
+	PreflightImage          = "image"
+	PreflightDisk           = "disk"
+	PreflightMemory         = "memory"
+	PreflightPort           = "port"
+	PreflightNetwork        = "network"
+	PreflightVirtualization = "virtualization"
+	PreflightDataDirectory  = "dataDirectory"
+)
+
+// PreflightCheck is the outcome of one check run before starting Moodle
+type PreflightCheck struct {
+	Name   string `json:"name"`
+	Label  string `json:"label"`
+	Status string `json:"status"`
+	// Message explains a warning or failure and what to do about it
+	Message string `json:"message"`
+}
+
+// PreflightReport collects the preflight checks; Moodle can be started unless one failed
+type PreflightReport struct {
+	Checks []PreflightCheck `json:"checks"`
+	// Status is the worst status of any check
+	Status string `json:"status"`
+	CanRun bool   `json:"canRun"`
+}
+
+// Add records a check, updating the overall status
+func (r *PreflightReport) Add(name, label, status, message string) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Label: label, Status: status, Message: message})
+	if preflightSeverity(status) > preflightSeverity(r.Status) {
+		r.Status = status
+	}
+	r.CanRun = r.Status != PreflightFail
+}
+
+// Failures returns the messages of the failed checks
+func (r *PreflightReport) Failures() []string {
+	failures := make([]string, 0)
+	for _, check := range r.Checks {
+		if check.Status == PreflightFail {
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Label, check.Message))
+		}
+	}
+	return failures
+}
+
+// NewPreflightReport creates an empty report, which passes until a check says otherwise
+func NewPreflightReport() *PreflightReport {
+	return &PreflightReport{Checks: make([]PreflightCheck, 0), Status: PreflightPass, CanRun: true}
+}
+
+// preflightSeverity orders check results from pass to fail
+func preflightSeverity(status string) int {
+	switch status {
+	case PreflightWarn:
+		return 1
+	case PreflightFail:
+		return 2
+	}
+	return 0
+}
+
+// CheckHostPortAvailable reports whether port can be published. Ports of a remote daemon cannot
+// be probed from here, so they are assumed free.
+func CheckHostPortAvailable(port int) bool {
+	return IsRemoteDockerHost() || isPortFree(port)
+}
+
+// PreflightResources adds the disk, memory and virtualization checks for imageName
+func PreflightResources(report *PreflightReport, imageName string) {
+	resources := CheckResources(imageName)
+	switch {
+	case resources.DiskOK:
+		report.Add(PreflightDisk, "Disk space", PreflightPass, fmt.Sprintf("%s free", FormatBytes(resources.DiskFreeBytes)))
+	case resources.DiskMessage == diskUnknownMessage:
+		report.Add(PreflightDisk, "Disk space", PreflightWarn, resources.DiskMessage)
+	default:
+		report.Add(PreflightDisk, "Disk space", PreflightFail, resources.DiskMessage)
+	}
+
+	switch {
+	case resources.MemoryOK && resources.MemoryBytes >= resources.MemoryRecommendedBytes:
+		report.Add(PreflightMemory, "Docker memory", PreflightPass, fmt.Sprintf("%s available to Docker", FormatBytes(resources.MemoryBytes)))
+	case resources.MemoryOK || resources.MemoryBytes == 0:
+		report.Add(PreflightMemory, "Docker memory", PreflightWarn, resources.MemoryMessage)
+	default:
+		report.Add(PreflightMemory, "Docker memory", PreflightFail, resources.MemoryMessage)
+	}
+
+	if virtualization := CheckVirtualization(); virtualization.Applicable {
+		if virtualization.OK() {
+			report.Add(PreflightVirtualization, "Virtualization", PreflightPass, "WSL2 and virtualization are ready")
+		} else {
+			report.Add(PreflightVirtualization, "Virtualization", PreflightFail, strings.Join(virtualization.Hints, "; "))
+		}
+	}
+	utils.LogDebug(fmt.Sprintf("Resource preflight: disk ok=%v, memory ok=%v", resources.DiskOK, resources.MemoryOK))
+}
@@ -0,0 +1,25 @@
+package docker
+
+import "testing"
+
+func TestPreflightReportStatus(t *testing.T) {
+	report := NewPreflightReport()
+	if !report.CanRun || report.Status != PreflightPass {
+		t.Fatalf("Expected an empty report to pass, got %+v", report)
+	}
+
+	report.Add(PreflightDocker, "Docker", PreflightPass, "Docker is running")
+	report.Add(PreflightImage, "Moodle image", PreflightWarn, "will be downloaded")
+	if !report.CanRun || report.Status != PreflightWarn {
+		t.Errorf("Expected a warning not to block running, got %+v", report)
+	}
+
+	report.Add(PreflightPort, "Host port", PreflightFail, "Port 8080 is used by another program")
+	report.Add(PreflightDisk, "Disk space", PreflightPass, "20 GB free")
+	if report.CanRun || report.Status != PreflightFail {
+		t.Errorf("Expected a failure to block running, got %+v", report)
+	}
+	if failures := report.Failures(); len(failures) != 1 || failures[0] != "Host port: Port 8080 is used by another program" {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+}
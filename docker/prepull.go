@@ -0,0 +1,265 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// PrePullCheckInterval is how often the scheduler checks whether it is inside its window
+	PrePullCheckInterval = 15 * time.Minute
+	// DefaultPrePullStartHour and DefaultPrePullEndHour bound the nightly window (local time)
+	DefaultPrePullStartHour = 1
+	DefaultPrePullEndHour   = 5
+)
+
+// PrePulledImage records an image build downloaded ahead of time
+type PrePulledImage struct {
+	Image    string    `json:"image"`
+	Digest   string    `json:"digest"`
+	PulledAt time.Time `json:"pulledAt"`
+}
+
+// PrePullStatus reports the nightly pre-pull schedule and its results
+type PrePullStatus struct {
+	Enabled         bool             `json:"enabled"`
+	WindowStartHour int              `json:"windowStartHour"`
+	WindowEndHour   int              `json:"windowEndHour"`
+	LastAttempt     time.Time        `json:"lastAttempt"`
+	LastSkipReason  string           `json:"lastSkipReason"`
+	PrePulled       []PrePulledImage `json:"prePulled"`
+}
+
+// prePullState is what the scheduler keeps across restarts
+type prePullState struct {
+	// LastRunDay is the night of the last run, so a restart inside the window does not pull again
+	LastRunDay string                    `json:"lastRunDay"`
+	Pulled     map[string]PrePulledImage `json:"pulled"`
+}
+
+// PrePullScheduler pulls newer builds of images overnight so upgrading during the day is instant.
+// The builds it pulled are saved to a state file, so they are neither forgotten nor pulled
+// again after a restart.
+type PrePullScheduler struct {
+	manager  *Manager
+	path     string
+	images   func() []string
+	onPulled func(PrePulledImage)
+
+	mu         sync.Mutex
+	status     PrePullStatus
+	pulled     map[string]PrePulledImage
+	lastRunDay string
+	stopChan   chan struct{}
+}
+
+// NewPrePullScheduler creates a scheduler keeping its state at path; images lists the
+// candidates on every run
+func NewPrePullScheduler(manager *Manager, path string, images func() []string, onPulled func(PrePulledImage)) *PrePullScheduler {
+	ps := &PrePullScheduler{
+		manager:  manager,
+		path:     path,
+		images:   images,
+		onPulled: onPulled,
+		pulled:   make(map[string]PrePulledImage),
+	}
+	ps.load()
+	return ps
+}
+
+// load reads the saved state; a missing or unreadable file starts empty
+func (ps *PrePullScheduler) load() {
+	data, err := os.ReadFile(ps.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarning(fmt.Sprintf("Failed to read pre-pull state %s: %v", ps.path, err))
+		}
+		return
+	}
+	var state prePullState
+	if err := json.Unmarshal(data, &state); err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring corrupted pre-pull state %s: %v", ps.path, err))
+		return
+	}
+	ps.lastRunDay = state.LastRunDay
+	for image, pulled := range state.Pulled {
+		ps.pulled[image] = pulled
+	}
+}
+
+// saveLocked writes the state file
+func (ps *PrePullScheduler) saveLocked() error {
+	data, err := json.MarshalIndent(prePullState{LastRunDay: ps.lastRunDay, Pulled: ps.pulled}, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode pre-pull state")
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(ps.path), err)
+	}
+	if err := os.WriteFile(ps.path, data, 0644); err != nil {
+		return errors.NewFileError("write", ps.path, err)
+	}
+	return nil
+}
+
+// Start checks every PrePullCheckInterval and pulls once per night between startHour and endHour
+func (ps *PrePullScheduler) Start(startHour, endHour int) {
+	ps.Stop()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	stopChan := make(chan struct{})
+	ps.stopChan = stopChan
+	ps.status.Enabled = true
+	ps.status.WindowStartHour = startHour
+	ps.status.WindowEndHour = endHour
+
+	utils.LogInfo(fmt.Sprintf("Starting nightly image pre-pull (window: %02d:00-%02d:00)", startHour, endHour))
+
+	go func() {
+		ticker := time.NewTicker(PrePullCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				ps.tick(now)
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the scheduler; a pull already in progress is allowed to finish
+func (ps *PrePullScheduler) Stop() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.stopChan != nil {
+		close(ps.stopChan)
+		ps.stopChan = nil
+		utils.LogInfo("Nightly image pre-pull stopped")
+	}
+	ps.status.Enabled = false
+}
+
+// Status returns a copy of the scheduler status
+func (ps *PrePullScheduler) Status() PrePullStatus {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	status := ps.status
+	status.PrePulled = make([]PrePulledImage, 0, len(ps.pulled))
+	for _, pulled := range ps.pulled {
+		status.PrePulled = append(status.PrePulled, pulled)
+	}
+	return status
+}
+
+// PrePulled returns the latest pre-pulled build of image, if any
+func (ps *PrePullScheduler) PrePulled(image string) (PrePulledImage, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	pulled, ok := ps.pulled[image]
+	return pulled, ok
+}
+
+// tick runs the nightly pull when inside the window and conditions allow
+func (ps *PrePullScheduler) tick(now time.Time) {
+	ps.mu.Lock()
+	inside := inPrePullWindow(now.Hour(), ps.status.WindowStartHour, ps.status.WindowEndHour)
+	// A window spanning midnight belongs to the night it started on
+	day := now.Add(-time.Duration(ps.status.WindowStartHour) * time.Hour).Format("2006-01-02")
+	done := ps.lastRunDay == day
+	ps.mu.Unlock()
+
+	if !inside || done {
+		return
+	}
+
+	if reason := prePullBlocker(); reason != "" {
+		utils.LogDebug(fmt.Sprintf("Skipping nightly pre-pull: %s", reason))
+		ps.mu.Lock()
+		ps.status.LastAttempt = now
+		ps.status.LastSkipReason = reason
+		ps.mu.Unlock()
+		return
+	}
+
+	ps.mu.Lock()
+	ps.lastRunDay = day
+	ps.status.LastAttempt = now
+	ps.status.LastSkipReason = ""
+	if err := ps.saveLocked(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to save pre-pull state: %v", err))
+	}
+	ps.mu.Unlock()
+
+	ps.run()
+}
+
+// run pulls every candidate image whose registry build is neither present locally nor
+// already pre-pulled
+func (ps *PrePullScheduler) run() {
+	for _, image := range ps.images() {
+		// The nightly run must see the registry's current build, not a cached digest
+		info, err := ps.manager.checkForNewerImage(image, true)
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Pre-pull update check failed for %s: %v", image, err))
+			continue
+		}
+		if !info.UpdateAvailable {
+			continue
+		}
+		if previous, ok := ps.PrePulled(image); ok && previous.Digest == info.RemoteDigest {
+			utils.LogDebug(fmt.Sprintf("Skipping pre-pull of %s: build %s was already pulled", image, info.RemoteDigest))
+			continue
+		}
+
+		if err := pullImage(image); err != nil {
+			utils.LogError(fmt.Sprintf("Nightly pre-pull of %s failed", image), err)
+			continue
+		}
+
+		pulled := PrePulledImage{Image: image, Digest: info.RemoteDigest, PulledAt: time.Now()}
+		ps.mu.Lock()
+		ps.pulled[image] = pulled
+		if err := ps.saveLocked(); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to save pre-pull state: %v", err))
+		}
+		ps.mu.Unlock()
+
+		utils.LogInfo(fmt.Sprintf("Pre-pulled %s (%s)", image, info.RemoteDigest))
+		if ps.onPulled != nil {
+			ps.onPulled(pulled)
+		}
+	}
+}
+
+// prePullBlocker returns why pulling now would be unwelcome, or "" if it is fine
+func prePullBlocker() string {
+	if onAC, err := utils.OnACPower(); err == nil && !onAC {
+		return "running on battery"
+	}
+	if metered, err := utils.IsMeteredNetwork(); err == nil && metered {
+		return "network connection is metered"
+	}
+	return ""
+}
+
+// inPrePullWindow reports whether hour falls in [start, end), wrapping past midnight
+func inPrePullWindow(hour, start, end int) bool {
+	if start <= end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
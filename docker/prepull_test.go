@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInPrePullWindow(t *testing.T) {
+	tests := []struct {
+		hour, start, end int
+		expected         bool
+	}{
+		{1, 1, 5, true},
+		{4, 1, 5, true},
+		{5, 1, 5, false},
+		{0, 1, 5, false},
+		{23, 22, 3, true},
+		{2, 22, 3, true},
+		{12, 22, 3, false},
+	}
+
+	for _, test := range tests {
+		if got := inPrePullWindow(test.hour, test.start, test.end); got != test.expected {
+			t.Errorf("inPrePullWindow(%d, %d, %d) = %v, expected %v", test.hour, test.start, test.end, got, test.expected)
+		}
+	}
+}
+
+func TestPrePullStateSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prepull-state.json")
+	scheduler := NewPrePullScheduler(nil, path, nil, nil)
+	pulled := PrePulledImage{Image: "wenkhairu/moodle-prototype:502-stable", Digest: "sha256:abc", PulledAt: time.Now().UTC().Truncate(time.Second)}
+
+	scheduler.mu.Lock()
+	scheduler.lastRunDay = "2026-03-01"
+	scheduler.pulled[pulled.Image] = pulled
+	if err := scheduler.saveLocked(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scheduler.mu.Unlock()
+
+	restarted := NewPrePullScheduler(nil, path, nil, nil)
+	if got, ok := restarted.PrePulled(pulled.Image); !ok || got.Digest != pulled.Digest || !got.PulledAt.Equal(pulled.PulledAt) {
+		t.Errorf("Expected the pre-pulled build to be remembered, got %+v (%v)", got, ok)
+	}
+	if restarted.lastRunDay != "2026-03-01" {
+		t.Errorf("Expected the last run night to be remembered, got %q", restarted.lastRunDay)
+	}
+}
@@ -0,0 +1,181 @@
+package docker
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// ProfileOutputDir is where Xdebug writes cachegrind files inside the container
+	ProfileOutputDir = "/tmp/moodle-profiles"
+	// ProfileRequestTimeout bounds how long a profiled page may take; profiling slows PHP down
+	ProfileRequestTimeout = 5 * time.Minute
+	// xdebugProfileIniName sorts after the debug ini so its xdebug.mode wins
+	xdebugProfileIniName = "zzz-moodle-xdebug-profile.ini"
+)
+
+// ProfileResult describes a captured profile of one page request
+type ProfileResult struct {
+	URL        string        `json:"url"`
+	StatusCode int           `json:"statusCode"`
+	Duration   time.Duration `json:"duration"`
+	File       string        `json:"file"`
+	SizeBytes  int64         `json:"sizeBytes"`
+}
+
+// CaptureProfile requests pageURL with the Xdebug profiler enabled and copies the
+// resulting cachegrind file to outDir. The profiler is switched off again afterwards.
+func (m *Manager) CaptureProfile(containerID, pageURL, outDir string) (*ProfileResult, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to CaptureProfile")
+	}
+
+	target, err := url.Parse(pageURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.NewValidationError("url", "must be an absolute http(s) URL", pageURL)
+	}
+	if !isLoopbackHost(target.Hostname()) {
+		return nil, errors.NewValidationError("url", "profiling is limited to the local prototype", pageURL)
+	}
+
+	if _, err := m.ensureXdebugInstalled(containerID); err != nil {
+		return nil, err
+	}
+
+	iniDir, err := m.phpScanDir(containerID)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := m.ExecInContainer(containerID, "test", "-f", iniDir+"/"+xdebugIniName)
+	debugEnabled := statErr == nil
+
+	// Apache runs as www-data, so the output directory must be writable by it
+	prepare := fmt.Sprintf("rm -rf %s && mkdir -p %s && chmod 777 %s", ProfileOutputDir, ProfileOutputDir, ProfileOutputDir)
+	if _, err := m.ExecInContainer(containerID, "sh", "-c", prepare); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to prepare profile output directory")
+	}
+	if err := m.writePHPIni(containerID, xdebugProfileIniName, profilerIni(debugEnabled)); err != nil {
+		return nil, err
+	}
+	defer m.disableProfiler(containerID, iniDir)
+	if err := m.reloadWebServer(containerID); err != nil {
+		return nil, err
+	}
+
+	query := target.Query()
+	query.Set("XDEBUG_PROFILE", "1")
+	target.RawQuery = query.Encode()
+
+	utils.LogInfo(fmt.Sprintf("Capturing profile of %s", pageURL))
+	start := time.Now()
+	resp, err := profileHTTPClient().Get(target.String())
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("profile_request", pageURL, err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	duration := time.Since(start)
+
+	output, err := m.ExecInContainer(containerID, "ls", "-t", ProfileOutputDir)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to list profile output")
+	}
+	profileName := newestProfile(output)
+	if profileName == "" {
+		return nil, errors.WrapWithContext(errors.ErrInvalidState, "Xdebug did not write a profile for %s", pageURL)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, errors.NewFileError("create", outDir, err)
+	}
+	hostFile := filepath.Join(outDir, profileName)
+	if err := m.CopyFromContainer(containerID, ProfileOutputDir+"/"+profileName, hostFile); err != nil {
+		return nil, err
+	}
+
+	result := &ProfileResult{
+		URL:        pageURL,
+		StatusCode: resp.StatusCode,
+		Duration:   duration,
+		File:       hostFile,
+	}
+	if info, err := os.Stat(hostFile); err == nil {
+		result.SizeBytes = info.Size()
+	}
+
+	utils.LogInfo(fmt.Sprintf("Profile of %s (%d in %v) saved to %s", pageURL, resp.StatusCode, duration, hostFile))
+	return result, nil
+}
+
+// disableProfiler removes the profiler configuration, leaving step debugging as it was
+func (m *Manager) disableProfiler(containerID, iniDir string) {
+	if _, err := m.ExecInContainer(containerID, "rm", "-f", iniDir+"/"+xdebugProfileIniName); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to remove profiler configuration: %v", err))
+		return
+	}
+	if err := m.reloadWebServer(containerID); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to reload Apache after profiling: %v", err))
+	}
+}
+
+// profilerIni renders the profiler configuration, keeping debug mode when it is enabled
+func profilerIni(debugEnabled bool) string {
+	mode := "profile"
+	if debugEnabled {
+		mode = "debug,profile"
+	}
+	lines := []string{
+		"xdebug.mode=" + mode,
+		"xdebug.start_with_request=trigger",
+		"xdebug.output_dir=" + ProfileOutputDir,
+		"xdebug.profiler_output_name=cachegrind.out.%t.%R",
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// newestProfile picks the first cachegrind file from `ls -t` output
+func newestProfile(listing string) string {
+	for _, name := range strings.Split(listing, "\n") {
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(name, "cachegrind.out.") {
+			return name
+		}
+	}
+	return ""
+}
+
+// profileHTTPClient requests local pages directly, accepting the HTTPS proxy's self-signed certificate
+func profileHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Timeout: ProfileRequestTimeout, Transport: transport}
+}
+
+// isLoopbackHost reports whether host resolves only to loopback addresses
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip == nil || !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
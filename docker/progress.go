@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"moodle-prototype-manager/utils"
 )
@@ -27,21 +29,53 @@ type ProgressDetail struct {
 	Total   int64 `json:"total"`
 }
 
+// speedWindow is how far back download samples are kept when computing the transfer speed
+const speedWindow = 5 * time.Second
+
 // LayerProgress tracks progress for a single layer
 type LayerProgress struct {
-	ID              string
-	Status          string
-	DownloadCurrent int64
-	DownloadTotal   int64
-	ExtractCurrent  int64
-	ExtractTotal    int64
+	ID              string `json:"id"`
+	Status          string `json:"status"`
+	DownloadCurrent int64  `json:"downloadCurrent"`
+	DownloadTotal   int64  `json:"downloadTotal"`
+	ExtractCurrent  int64  `json:"extractCurrent"`
+	ExtractTotal    int64  `json:"extractTotal"`
+}
+
+// PullSnapshot is a detailed pull progress update: one row per layer plus aggregate
+// download speed, bytes remaining and ETA
+type PullSnapshot struct {
+	// Percentage is -1 for status-only updates, as with the simple progress callback
+	Percentage float64         `json:"percentage"`
+	Status     string          `json:"status"`
+	Layers     []LayerProgress `json:"layers"`
+	// DownloadedBytes and TotalBytes cover layers whose size Docker has reported so far
+	DownloadedBytes  int64   `json:"downloadedBytes"`
+	TotalBytes       int64   `json:"totalBytes"`
+	BytesRemaining   int64   `json:"bytesRemaining"`
+	SpeedBytesPerSec float64 `json:"speedBytesPerSec"`
+	// ETASeconds is -1 while the speed is unknown
+	ETASeconds int64     `json:"etaSeconds"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// downloadSample records downloaded bytes at a point in time for speed calculation
+type downloadSample struct {
+	at    time.Time
+	bytes int64
 }
 
 // PullProgress manages overall pull progress
 type PullProgress struct {
-	layers    map[string]*LayerProgress
-	mu        sync.RWMutex
-	callbacks []func(float64, string)
+	layers          map[string]*LayerProgress
+	order           []string
+	samples         []downloadSample
+	now             func() time.Time
+	mu              sync.RWMutex
+	callbacks       []func(float64, string)
+	detailCallbacks []func(PullSnapshot)
+	// lastError is the most recent error Docker printed, used to decide whether to retry
+	lastError string
 }
 
 // NewPullProgress creates a new progress tracker
@@ -49,6 +83,7 @@ func NewPullProgress() *PullProgress {
 	return &PullProgress{
 		layers:    make(map[string]*LayerProgress),
 		callbacks: make([]func(float64, string), 0),
+		now:       time.Now,
 	}
 }
 
@@ -59,6 +94,58 @@ func (p *PullProgress) AddCallback(callback func(float64, string)) {
 	p.callbacks = append(p.callbacks, callback)
 }
 
+// AddDetailCallback registers a callback receiving per-layer progress with speed and ETA
+func (p *PullProgress) AddDetailCallback(callback func(PullSnapshot)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.detailCallbacks = append(p.detailCallbacks, callback)
+}
+
+// clearCallbacks removes all callbacks, e.g. before a resumed pull reports to a new listener
+func (p *PullProgress) clearCallbacks() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.callbacks = nil
+	p.detailCallbacks = nil
+}
+
+// Retrying reports that the pull failed and attempt (of maxAttempts) starts after delay.
+// Layers finished by earlier attempts stay complete; interrupted layers restart.
+func (p *PullProgress) Retrying(attempt, maxAttempts int, delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Interrupted layers restart from zero bytes, which would skew the speed
+	p.samples = nil
+	p.lastError = ""
+	p.notifyCallbacks(-1, fmt.Sprintf("Download interrupted, retrying (attempt %d/%d) in %s", attempt, maxAttempts, delay.Round(time.Second)))
+}
+
+// Snapshot returns the current progress
+func (p *PullProgress) Snapshot() PullSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshot(p.calculateOverallProgress(), p.getOverallStatus())
+}
+
+// LastError returns the most recent error line Docker printed during the pull
+func (p *PullProgress) LastError() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lastError
+}
+
+// layer returns the progress of a layer, adding it in the order Docker reported it
+func (p *PullProgress) layer(id string) *LayerProgress {
+	layer, exists := p.layers[id]
+	if !exists {
+		layer = &LayerProgress{ID: id}
+		p.layers[id] = layer
+		p.order = append(p.order, id)
+	}
+	return layer
+}
+
 // ProcessStream reads and processes Docker output stream
 func (p *PullProgress) ProcessStream(reader io.Reader) error {
 	scanner := bufio.NewScanner(reader)
@@ -129,18 +216,14 @@ func (p *PullProgress) processPlainTextLine(line string) {
 
 	// Skip non-layer lines
 	if layerID == "" {
+		if strings.Contains(strings.ToLower(line), "error") {
+			p.lastError = line
+		}
 		utils.LogDebug(fmt.Sprintf("Non-layer Docker output: %s", line))
 		return
 	}
 
-	// Get or create layer progress
-	layer, exists := p.layers[layerID]
-	if !exists {
-		layer = &LayerProgress{
-			ID: layerID,
-		}
-		p.layers[layerID] = layer
-	}
+	layer := p.layer(layerID)
 
 	// Parse the status
 	if strings.Contains(line, "Pulling fs layer") {
@@ -148,6 +231,10 @@ func (p *PullProgress) processPlainTextLine(line string) {
 	} else if strings.Contains(line, "Waiting") {
 		layer.Status = "Waiting"
 	} else if strings.Contains(line, "Already exists") {
+		// A layer pulled by an earlier attempt is reported as present on retry; keep it counted
+		if layer.Status == "Pull complete" {
+			return
+		}
 		layer.Status = "Already exists"
 		// Set as complete
 		layer.DownloadCurrent = 1
@@ -232,6 +319,7 @@ func (p *PullProgress) processEvent(event *DockerPullEvent) error {
 
 	// Check for errors
 	if event.Error != "" {
+		p.lastError = event.Error
 		return fmt.Errorf("docker error: %s", event.Error)
 	}
 
@@ -243,13 +331,11 @@ func (p *PullProgress) processEvent(event *DockerPullEvent) error {
 		return nil
 	}
 
-	// Get or create layer progress
-	layer, exists := p.layers[event.ID]
-	if !exists {
-		layer = &LayerProgress{
-			ID: event.ID,
-		}
-		p.layers[event.ID] = layer
+	layer := p.layer(event.ID)
+
+	// A layer pulled by an earlier attempt is reported as present on retry; keep it counted
+	if event.Status == "Already exists" && layer.Status == "Pull complete" {
+		return nil
 	}
 
 	// Update layer status
@@ -481,4 +567,57 @@ func (p *PullProgress) notifyCallbacks(percentage float64, status string) {
 	for _, callback := range p.callbacks {
 		callback(percentage, status)
 	}
+	if len(p.detailCallbacks) > 0 {
+		snapshot := p.snapshot(percentage, status)
+		for _, callback := range p.detailCallbacks {
+			callback(snapshot)
+		}
+	}
+}
+
+// snapshot builds a detailed progress update; the caller holds the lock
+func (p *PullProgress) snapshot(percentage float64, status string) PullSnapshot {
+	snapshot := PullSnapshot{
+		Percentage: percentage,
+		Status:     status,
+		Layers:     make([]LayerProgress, 0, len(p.order)),
+		ETASeconds: -1,
+		Timestamp:  p.now(),
+	}
+
+	for _, id := range p.order {
+		layer := p.layers[id]
+		snapshot.Layers = append(snapshot.Layers, *layer)
+		// Cached layers and placeholder sizes (1/1) are not downloaded
+		if layer.Status != "Already exists" && layer.DownloadTotal > 1 {
+			snapshot.DownloadedBytes += layer.DownloadCurrent
+			snapshot.TotalBytes += layer.DownloadTotal
+		}
+	}
+	snapshot.BytesRemaining = snapshot.TotalBytes - snapshot.DownloadedBytes
+
+	snapshot.SpeedBytesPerSec = p.recordSample(snapshot.Timestamp, snapshot.DownloadedBytes)
+	if snapshot.SpeedBytesPerSec > 0 {
+		snapshot.ETASeconds = int64(math.Ceil(float64(snapshot.BytesRemaining) / snapshot.SpeedBytesPerSec))
+	} else if snapshot.TotalBytes > 0 && snapshot.BytesRemaining == 0 {
+		snapshot.ETASeconds = 0
+	}
+	return snapshot
+}
+
+// recordSample adds a download sample and returns the average speed over speedWindow
+func (p *PullProgress) recordSample(at time.Time, bytes int64) float64 {
+	p.samples = append(p.samples, downloadSample{at: at, bytes: bytes})
+
+	cutoff := at.Add(-speedWindow)
+	for len(p.samples) > 2 && p.samples[1].at.Before(cutoff) {
+		p.samples = p.samples[1:]
+	}
+
+	first := p.samples[0]
+	elapsed := at.Sub(first.at).Seconds()
+	if elapsed <= 0 || bytes <= first.bytes {
+		return 0
+	}
+	return float64(bytes-first.bytes) / elapsed
 }
\ No newline at end of file
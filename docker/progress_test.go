@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPullProgressSnapshot(t *testing.T) {
+	progress := NewPullProgress()
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	progress.now = func() time.Time { return clock }
+
+	var last PullSnapshot
+	progress.AddDetailCallback(func(snapshot PullSnapshot) { last = snapshot })
+
+	stream := []string{
+		`{"status":"Already exists","id":"aaaaaaaaaaaa"}`,
+		`{"status":"Downloading","id":"bbbbbbbbbbbb","progressDetail":{"current":0,"total":4000}}`,
+		`{"status":"Downloading","id":"cccccccccccc","progressDetail":{"current":0,"total":6000}}`,
+	}
+	if err := progress.ProcessStream(strings.NewReader(strings.Join(stream, "\n"))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clock = clock.Add(2 * time.Second)
+	if err := progress.ProcessStream(strings.NewReader(`{"status":"Downloading","id":"bbbbbbbbbbbb","progressDetail":{"current":2000,"total":4000}}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(last.Layers) != 3 || last.Layers[0].ID != "aaaaaaaaaaaa" || last.Layers[2].ID != "cccccccccccc" {
+		t.Fatalf("Expected layers in reported order, got %+v", last.Layers)
+	}
+	if last.TotalBytes != 10000 || last.DownloadedBytes != 2000 || last.BytesRemaining != 8000 {
+		t.Errorf("Unexpected byte counts: %+v", last)
+	}
+	if last.SpeedBytesPerSec != 1000 {
+		t.Errorf("Expected 1000 B/s, got %v", last.SpeedBytesPerSec)
+	}
+	if last.ETASeconds != 8 {
+		t.Errorf("Expected ETA of 8s, got %d", last.ETASeconds)
+	}
+}
+
+func TestPullProgressUnknownSpeed(t *testing.T) {
+	progress := NewPullProgress()
+	var last PullSnapshot
+	progress.AddDetailCallback(func(snapshot PullSnapshot) { last = snapshot })
+
+	if err := progress.ProcessStream(strings.NewReader(`{"status":"Pulling fs layer","id":"bbbbbbbbbbbb"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if last.ETASeconds != -1 || last.SpeedBytesPerSec != 0 {
+		t.Errorf("Expected unknown ETA before any download, got %+v", last)
+	}
+}
+
+func TestPullProgressRetryKeepsCompletedLayers(t *testing.T) {
+	progress := NewPullProgress()
+	var statuses []string
+	var last PullSnapshot
+	progress.AddDetailCallback(func(snapshot PullSnapshot) {
+		statuses = append(statuses, snapshot.Status)
+		last = snapshot
+	})
+
+	firstAttempt := []string{
+		"aaaaaaaaaaaa: Pull complete",
+		"bbbbbbbbbbbb: Downloading  2MB/8MB",
+		"error pulling image configuration: read tcp: connection reset by peer",
+	}
+	if err := progress.ProcessStream(strings.NewReader(strings.Join(firstAttempt, "\n"))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(progress.LastError(), "connection reset") {
+		t.Errorf("Expected the daemon error to be kept for retry classification, got %q", progress.LastError())
+	}
+
+	progress.Retrying(2, 4, 2*time.Second)
+	if got := statuses[len(statuses)-1]; !strings.Contains(got, "retrying (attempt 2/4)") {
+		t.Errorf("Expected a retry status, got %q", got)
+	}
+
+	if err := progress.ProcessStream(strings.NewReader("aaaaaaaaaaaa: Already exists\nbbbbbbbbbbbb: Downloading  1MB/8MB")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if last.Layers[0].Status != "Pull complete" {
+		t.Errorf("Expected the layer pulled before the retry to stay complete, got %q", last.Layers[0].Status)
+	}
+	if progress.LastError() != "" {
+		t.Errorf("Expected the previous attempt's error to be cleared, got %q", progress.LastError())
+	}
+}
@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// QuotaCleanupSuggestions are offered when a workspace is over its disk quota
+var QuotaCleanupSuggestions = []string{
+	"Purge Moodle caches (Site administration > Development > Purge caches)",
+	"Purge demo users and their files",
+	"Delete old course backups from the Moodle file areas",
+	"Remove orphaned containers of this workspace",
+	"Recreate the container to discard temporary files in its writable layer",
+}
+
+// WorkspaceUsage is the disk space used by an instance's container and its volumes
+type WorkspaceUsage struct {
+	Instance       string `json:"instance"`
+	ContainerBytes uint64 `json:"containerBytes"`
+	VolumeBytes    uint64 `json:"volumeBytes"`
+	TotalBytes     uint64 `json:"totalBytes"`
+	// Partial is set when volumes could not be measured, e.g. because the container is stopped
+	Partial bool `json:"partial"`
+}
+
+// QuotaStatus compares a workspace's disk usage with its quota for the health report
+type QuotaStatus struct {
+	WorkspaceUsage
+	QuotaBytes  uint64   `json:"quotaBytes"`
+	OverQuota   bool     `json:"overQuota"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// GetWorkspaceUsage measures the container's writable layer and, while it is running, its volumes
+func (m *Manager) GetWorkspaceUsage(container ManagedContainer) (*WorkspaceUsage, error) {
+	if err := errors.ValidateContainerID(container.ID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to GetWorkspaceUsage")
+	}
+
+	cmd := GetDockerCommand("inspect", "--size", "--format", "{{.SizeRw}}", container.ID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("inspect", container.ID, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to read container size")
+	}
+	containerBytes, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected container size %q", strings.TrimSpace(string(output)))
+	}
+
+	usage := &WorkspaceUsage{Instance: container.Instance, ContainerBytes: containerBytes}
+	if container.State != "running" {
+		usage.Partial = true
+	} else if usage.VolumeBytes, err = m.volumeBytes(container.ID); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to measure volumes of %s: %v", container.Name, err))
+		usage.Partial = true
+	}
+
+	usage.TotalBytes = usage.ContainerBytes + usage.VolumeBytes
+	return usage, nil
+}
+
+// MeasureWorkspaceUsage is GetWorkspaceUsage that also measures the volumes of a stopped
+// container, through a throwaway container of its image. It is slower, so it is kept for jobs
+// the quota guards rather than the health report.
+func (m *Manager) MeasureWorkspaceUsage(container ManagedContainer) (*WorkspaceUsage, error) {
+	usage, err := m.GetWorkspaceUsage(container)
+	if err != nil || !usage.Partial || container.State == "running" {
+		return usage, err
+	}
+
+	volumeBytes, err := m.stoppedVolumeBytes(container.ID)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to measure volumes of stopped container %s", container.Name)
+	}
+	usage.VolumeBytes = volumeBytes
+	usage.TotalBytes = usage.ContainerBytes + volumeBytes
+	usage.Partial = false
+	return usage, nil
+}
+
+// volumeBytes sums the size of the container's mounted volumes, measured from inside it
+func (m *Manager) volumeBytes(containerID string) (uint64, error) {
+	destinations, err := m.volumeDestinations(containerID)
+	if err != nil || len(destinations) == 0 {
+		return 0, err
+	}
+
+	output, err := m.ExecInContainer(containerID, append([]string{"du", "-sk"}, destinations...)...)
+	if err != nil {
+		return 0, err
+	}
+	return parseDiskUsage(output), nil
+}
+
+// stoppedVolumeBytes sums the size of a stopped container's volumes from a throwaway
+// container of the same image that mounts them
+func (m *Manager) stoppedVolumeBytes(containerID string) (uint64, error) {
+	destinations, err := m.volumeDestinations(containerID)
+	if err != nil || len(destinations) == 0 {
+		return 0, err
+	}
+	image, err := m.containerImage(containerID)
+	if err != nil {
+		return 0, err
+	}
+
+	args := []string{"run", "--rm", "--user", "root", "--entrypoint", "du", "--volumes-from", containerID + ":ro", image, "-sk"}
+	cmd := GetDockerCommand(append(args, destinations...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", image, err).WithOutput(string(output))
+		return 0, errors.WrapWithContext(dockerErr, "failed to measure volumes")
+	}
+	return parseDiskUsage(string(output)), nil
+}
+
+// volumeDestinations returns where the container's volumes are mounted
+func (m *Manager) volumeDestinations(containerID string) ([]string, error) {
+	mounts, err := m.containerMounts(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		destinations = append(destinations, mount.Destination)
+	}
+	return destinations, nil
+}
+
+// parseDiskUsage sums the kilobyte counts of `du -sk` output lines, returning bytes
+func parseDiskUsage(output string) uint64 {
+	var total uint64
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if kb, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			total += kb * 1024
+		}
+	}
+	return total
+}
+
+// EvaluateQuota builds the health report entry for a workspace; quotaBytes 0 means unlimited
+func EvaluateQuota(usage WorkspaceUsage, quotaBytes uint64) QuotaStatus {
+	status := QuotaStatus{WorkspaceUsage: usage, QuotaBytes: quotaBytes}
+	status.OverQuota = quotaBytes > 0 && usage.TotalBytes > quotaBytes
+	if status.OverQuota {
+		status.Suggestions = QuotaCleanupSuggestions
+	}
+	return status
+}
+
+// CheckQuota refuses a job that would write extraBytes into a workspace beyond its quota
+func CheckQuota(usage WorkspaceUsage, quotaBytes, extraBytes uint64) error {
+	if quotaBytes == 0 || usage.TotalBytes+extraBytes <= quotaBytes {
+		return nil
+	}
+	return errors.WrapWithContext(errors.ErrQuotaExceeded, "workspace %s uses %s of its %s quota; this job needs about %s more",
+		usage.Instance, FormatBytes(usage.TotalBytes), FormatBytes(quotaBytes), FormatBytes(extraBytes))
+}
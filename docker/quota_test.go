@@ -0,0 +1,35 @@
+package docker
+
+import (
+	"testing"
+
+	"moodle-prototype-manager/errors"
+)
+
+func TestParseDiskUsage(t *testing.T) {
+	output := "1024\t/var/www/moodledata\n2048\t/var/lib/mysql\n"
+	if got := parseDiskUsage(output); got != 3072*1024 {
+		t.Errorf("Expected %d bytes, got %d", 3072*1024, got)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	usage := WorkspaceUsage{Instance: "acme", TotalBytes: 900}
+
+	if status := EvaluateQuota(usage, 1000); status.OverQuota || len(status.Suggestions) != 0 {
+		t.Errorf("Expected workspace within quota, got %+v", status)
+	}
+	if status := EvaluateQuota(usage, 800); !status.OverQuota || len(status.Suggestions) == 0 {
+		t.Errorf("Expected workspace over quota with suggestions, got %+v", status)
+	}
+	if status := EvaluateQuota(usage, 0); status.OverQuota {
+		t.Error("Expected no quota to mean unlimited")
+	}
+
+	if err := CheckQuota(usage, 1000, 100); err != nil {
+		t.Errorf("Expected job to fit, got: %v", err)
+	}
+	if err := CheckQuota(usage, 1000, 101); !errors.IsSpecificError(err, errors.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got: %v", err)
+	}
+}
@@ -0,0 +1,395 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	registryTimeout   = 15 * time.Second
+	// maxRegistryTags is the page size requested from the tags list endpoint
+	maxRegistryTags = 1000
+)
+
+// manifestAcceptTypes lists the manifest formats we accept, index types first so the
+// returned digest matches the RepoDigest Docker records for multi-arch images
+var manifestAcceptTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// ImageReference is a parsed image name
+type ImageReference struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+// String returns the reference in registry/repository:tag form
+func (r ImageReference) String() string {
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// ImageUpdateInfo reports whether the registry has a newer image than the local copy
+type ImageUpdateInfo struct {
+	Image           string    `json:"image"`
+	LocalDigest     string    `json:"localDigest"`
+	RemoteDigest    string    `json:"remoteDigest"`
+	UpdateAvailable bool      `json:"updateAvailable"`
+	CheckedAt       time.Time `json:"checkedAt"`
+	// PrePulledAt is set when the nightly pre-pull already downloaded the current registry build
+	PrePulledAt *time.Time `json:"prePulledAt,omitempty"`
+}
+
+// dockerHubAliases are the names docker accepts for the Docker Hub registry
+var dockerHubAliases = map[string]bool{"docker.io": true, "index.docker.io": true, dockerHubRegistry: true}
+
+// ParseImageReference splits an image name into registry, repository and tag
+func ParseImageReference(image string) (ImageReference, error) {
+	if err := errors.ValidateImageName(image); err != nil {
+		return ImageReference{}, err
+	}
+
+	ref := ImageReference{Registry: dockerHubRegistry, Tag: "latest"}
+	name := image
+
+	// A first path component with a dot, colon or "localhost" is a registry host; Docker Hub's
+	// aliases all mean its registry
+	if i := strings.Index(name, "/"); i > 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			if dockerHubAliases[first] {
+				ref.Registry = dockerHubRegistry
+			}
+			name = name[i+1:]
+		}
+	}
+
+	// Strip any digest, then split the tag off the last path component
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// NormalizeImageReference returns image in the short form docker prints, so that references
+// naming the same image compare equal: the Docker Hub registry and its library/ prefix are
+// dropped and a missing tag becomes latest. Digest references and invalid names are only
+// trimmed.
+func NormalizeImageReference(image string) string {
+	image = strings.TrimSpace(image)
+	if strings.Contains(image, "@") {
+		return image
+	}
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return image
+	}
+	if ref.Registry == dockerHubRegistry {
+		return strings.TrimPrefix(ref.Repository, "library/") + ":" + ref.Tag
+	}
+	return ref.Registry + "/" + ref.Repository + ":" + ref.Tag
+}
+
+// SameImage reports whether two references name the same image
+func SameImage(a, b string) bool {
+	return strings.EqualFold(NormalizeImageReference(a), NormalizeImageReference(b))
+}
+
+// GetLocalImageDigests returns the repository digests recorded for a local image
+func (m *Manager) GetLocalImageDigests(image string) ([]string, error) {
+	cmd := GetDockerCommand("image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("image_inspect", image, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to inspect local image")
+	}
+
+	var repoDigests []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &repoDigests); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to parse image digests for %s", image)
+	}
+
+	digests := make([]string, 0, len(repoDigests))
+	for _, repoDigest := range repoDigests {
+		if i := strings.Index(repoDigest, "@"); i >= 0 {
+			digests = append(digests, repoDigest[i+1:])
+		}
+	}
+	return digests, nil
+}
+
+// GetRemoteImageDigest asks the registry for the current manifest digest of an image
+func GetRemoteImageDigest(image string) (string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, ref.Tag)
+	client := NewHTTPClient(registryTimeout)
+
+	resp, err := headManifest(client, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	// Registries that require auth answer 401 with a Bearer challenge; anonymous tokens suffice for public images
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := fetchRegistryToken(client, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = headManifest(client, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.NewNetworkErrorWithURL("manifest", manifestURL, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.NewNetworkErrorWithURL("manifest", manifestURL, fmt.Errorf("registry did not return a content digest"))
+	}
+	return digest, nil
+}
+
+// headManifest issues a HEAD request for a manifest
+func headManifest(client *http.Client, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("manifest", manifestURL, err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("manifest", manifestURL, err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// ListRemoteTags returns the tags the registry publishes for an image's repository, sorted
+func ListRemoteTags(image string) ([]string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsURL := fmt.Sprintf("https://%s/v2/%s/tags/list?n=%d", ref.Registry, ref.Repository, maxRegistryTags)
+	client := NewHTTPClient(registryTimeout)
+
+	resp, err := getTags(client, tagsURL, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		token, err := fetchRegistryToken(client, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = getTags(client, tagsURL, token); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewNetworkErrorWithURL("tags", tagsURL, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.NewNetworkErrorWithURL("tags", tagsURL, err)
+	}
+
+	tags := body.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// getTags issues a GET request for a repository's tag list; the caller closes the body
+func getTags(client *http.Client, tagsURL, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, tagsURL, nil)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("tags", tagsURL, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("tags", tagsURL, err)
+	}
+	return resp, nil
+}
+
+// fetchRegistryToken obtains an anonymous bearer token from a WWW-Authenticate challenge
+func fetchRegistryToken(client *http.Client, challenge string) (string, error) {
+	params := parseAuthChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", errors.NewNetworkError("registry_auth", fmt.Errorf("unsupported auth challenge: %s", challenge))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, realm, nil)
+	if err != nil {
+		return "", errors.NewNetworkErrorWithURL("registry_auth", realm, err)
+	}
+	query := req.URL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope := params["scope"]; scope != "" {
+		query.Set("scope", scope)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.NewNetworkErrorWithURL("registry_auth", realm, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.NewNetworkErrorWithURL("registry_auth", realm, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.NewNetworkErrorWithURL("registry_auth", realm, err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseAuthChallenge parses `Bearer realm="...",service="...",scope="..."`
+func parseAuthChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	challenge = strings.TrimSpace(challenge)
+	if i := strings.Index(challenge, " "); i >= 0 {
+		challenge = challenge[i+1:]
+	}
+
+	for _, part := range strings.Split(challenge, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return params
+}
+
+// SetRegistryCache routes tag lists and update checks through a registry metadata cache
+func (m *Manager) SetRegistryCache(cache *RegistryCache) {
+	m.registryCache = cache
+}
+
+// ListImageTags returns the tags published for image's repository, from the cache when one is set
+func (m *Manager) ListImageTags(image string) ([]string, error) {
+	if m.registryCache == nil {
+		return ListRemoteTags(image)
+	}
+	tags, _, err := m.registryCache.Tags(image)
+	return tags, err
+}
+
+// CheckForNewerImage compares the local image digest with the registry's current manifest.
+// With a registry cache the answer may come from the cache; CheckedAt tells how old it is.
+func (m *Manager) CheckForNewerImage() (*ImageUpdateInfo, error) {
+	if m.imageName == "" {
+		return nil, errors.NewValidationError("imageName", "no image name set in Docker manager", "")
+	}
+	return m.checkForNewerImage(m.imageName, false)
+}
+
+// remoteDigest returns the registry digest of image, bypassing the cache freshness check when fresh is set
+func (m *Manager) remoteDigest(image string, fresh bool) (string, time.Time, error) {
+	switch {
+	case m.registryCache == nil:
+		digest, err := GetRemoteImageDigest(image)
+		return digest, time.Now(), err
+	case fresh:
+		digest, err := m.registryCache.RefreshDigest(image)
+		return digest, time.Now(), err
+	default:
+		return m.registryCache.Digest(image)
+	}
+}
+
+// checkForNewerImage compares image's local digests with the registry's current manifest;
+// fresh queries the registry even when a cached digest is available
+func (m *Manager) checkForNewerImage(image string, fresh bool) (*ImageUpdateInfo, error) {
+	info := &ImageUpdateInfo{Image: image}
+
+	remoteDigest, checkedAt, err := m.remoteDigest(image, fresh)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to fetch registry digest for %s", image)
+	}
+	info.RemoteDigest = remoteDigest
+	info.CheckedAt = checkedAt
+
+	localDigests, err := m.GetLocalImageDigests(image)
+	if err != nil {
+		// No local copy yet: the registry image is "newer" than nothing
+		utils.LogDebug(fmt.Sprintf("No local digest for %s: %v", image, err))
+		info.UpdateAvailable = true
+		return info, nil
+	}
+
+	info.UpdateAvailable = true
+	for _, digest := range localDigests {
+		if info.LocalDigest == "" {
+			info.LocalDigest = digest
+		}
+		if digest == remoteDigest {
+			info.LocalDigest = digest
+			info.UpdateAvailable = false
+			break
+		}
+	}
+
+	utils.LogInfo(fmt.Sprintf("Image update check for %s: local=%s remote=%s update=%v",
+		image, info.LocalDigest, info.RemoteDigest, info.UpdateAvailable))
+	return info, nil
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := map[string]ImageReference{
+		"wenkhairu/moodle-prototype:502-stable": {Registry: dockerHubRegistry, Repository: "wenkhairu/moodle-prototype", Tag: "502-stable"},
+		"nginx":                                 {Registry: dockerHubRegistry, Repository: "library/nginx", Tag: "latest"},
+		"ghcr.io/org/moodle:5.0":                {Registry: "ghcr.io", Repository: "org/moodle", Tag: "5.0"},
+		"localhost:5000/moodle":                 {Registry: "localhost:5000", Repository: "moodle", Tag: "latest"},
+		"docker.io/nginx:1.27":                  {Registry: dockerHubRegistry, Repository: "library/nginx", Tag: "1.27"},
+	}
+
+	for image, expected := range tests {
+		ref, err := ParseImageReference(image)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", image, err)
+			continue
+		}
+		if ref != expected {
+			t.Errorf("ParseImageReference(%s) = %+v, expected %+v", image, ref, expected)
+		}
+	}
+}
+
+func TestNormalizeImageReference(t *testing.T) {
+	tests := map[string]string{
+		"wenkhairu/moodle-prototype":                      "wenkhairu/moodle-prototype:latest",
+		"docker.io/wenkhairu/moodle-prototype:502-stable": "wenkhairu/moodle-prototype:502-stable",
+		"docker.io/library/nginx":                         "nginx:latest",
+		" ghcr.io/org/moodle:5.0 ":                        "ghcr.io/org/moodle:5.0",
+		"nginx@sha256:abc":                                "nginx@sha256:abc",
+	}
+	for image, expected := range tests {
+		if got := NormalizeImageReference(image); got != expected {
+			t.Errorf("NormalizeImageReference(%q) = %q, expected %q", image, got, expected)
+		}
+	}
+	if !SameImage("moodlehq/moodle", "docker.io/moodlehq/moodle:latest") || SameImage("moodlehq/moodle:4.4", "moodlehq/moodle:4.5") {
+		t.Error("Expected SameImage to compare normalized references")
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	challenge := `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`
+	params := parseAuthChallenge(challenge)
+
+	if params["realm"] != "https://auth.docker.io/token" {
+		t.Errorf("Unexpected realm: %s", params["realm"])
+	}
+	if params["scope"] != "repository:library/nginx:pull" {
+		t.Errorf("Unexpected scope: %s", params["scope"])
+	}
+}
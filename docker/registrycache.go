@@ -0,0 +1,194 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// RegistryCacheTTL is how long cached registry metadata is served without refreshing it
+const RegistryCacheTTL = 6 * time.Hour
+
+// Registry cache key prefixes
+const (
+	cacheKeyTags   = "tags:"
+	cacheKeyDigest = "digest:"
+)
+
+// registryCacheEntry is one cached tag list or manifest digest
+type registryCacheEntry struct {
+	Tags      []string  `json:"tags,omitempty"`
+	Digest    string    `json:"digest,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// RegistryCache is a read-through cache of registry tag lists and manifest digests persisted
+// as JSON. Cached values are returned immediately; once older than the TTL they are refreshed
+// in the background, and they keep being served while the registry is unreachable.
+type RegistryCache struct {
+	path        string
+	ttl         time.Duration
+	fetchTags   func(image string) ([]string, error)
+	fetchDigest func(image string) (string, error)
+
+	mu         sync.Mutex
+	entries    map[string]registryCacheEntry
+	loaded     bool
+	refreshing map[string]bool
+}
+
+// NewRegistryCache creates a cache stored at path that queries the image's registry
+func NewRegistryCache(path string) *RegistryCache {
+	return &RegistryCache{
+		path:        path,
+		ttl:         RegistryCacheTTL,
+		fetchTags:   ListRemoteTags,
+		fetchDigest: GetRemoteImageDigest,
+		refreshing:  make(map[string]bool),
+	}
+}
+
+// Tags returns the repository tags of image and when they were fetched from the registry
+func (c *RegistryCache) Tags(image string) ([]string, time.Time, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	entry, err := c.get(cacheKeyTags+ref.Registry+"/"+ref.Repository, func() (registryCacheEntry, error) {
+		tags, err := c.fetchTags(image)
+		return registryCacheEntry{Tags: tags}, err
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return entry.Tags, entry.FetchedAt, nil
+}
+
+// Digest returns the registry manifest digest of image and when it was fetched
+func (c *RegistryCache) Digest(image string) (string, time.Time, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	entry, err := c.get(cacheKeyDigest+ref.String(), c.digestFetcher(image))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return entry.Digest, entry.FetchedAt, nil
+}
+
+// RefreshDigest fetches the digest of image from the registry now, updating the cache
+func (c *RegistryCache) RefreshDigest(image string) (string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := c.fetch(cacheKeyDigest+ref.String(), c.digestFetcher(image))
+	if err != nil {
+		return "", err
+	}
+	return entry.Digest, nil
+}
+
+// digestFetcher wraps fetchDigest as a cache entry loader
+func (c *RegistryCache) digestFetcher(image string) func() (registryCacheEntry, error) {
+	return func() (registryCacheEntry, error) {
+		digest, err := c.fetchDigest(image)
+		return registryCacheEntry{Digest: digest}, err
+	}
+}
+
+// get returns the cached entry for key, fetching it on a miss and refreshing it
+// in the background when it is older than the TTL
+func (c *RegistryCache) get(key string, fetch func() (registryCacheEntry, error)) (registryCacheEntry, error) {
+	c.mu.Lock()
+	c.loadLocked()
+	entry, ok := c.entries[key]
+	stale := ok && time.Since(entry.FetchedAt) > c.ttl
+	if stale && !c.refreshing[key] {
+		c.refreshing[key] = true
+		go c.refresh(key, fetch)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		return entry, nil
+	}
+	return c.fetch(key, fetch)
+}
+
+// refresh re-fetches a stale entry, keeping the cached value when the registry is unreachable
+func (c *RegistryCache) refresh(key string, fetch func() (registryCacheEntry, error)) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+
+	if _, err := c.fetch(key, fetch); err != nil {
+		utils.LogDebug(fmt.Sprintf("Keeping cached registry metadata for %s: %v", key, err))
+	}
+}
+
+// fetch queries the registry and stores the result
+func (c *RegistryCache) fetch(key string, fetch func() (registryCacheEntry, error)) (registryCacheEntry, error) {
+	entry, err := fetch()
+	if err != nil {
+		return registryCacheEntry{}, err
+	}
+	entry.FetchedAt = time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	c.entries[key] = entry
+	if err := c.saveLocked(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to save registry cache: %v", err))
+	}
+	return entry, nil
+}
+
+// loadLocked reads the cache file on first use; a missing or unreadable file starts empty
+func (c *RegistryCache) loadLocked() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]registryCacheEntry)
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarning(fmt.Sprintf("Failed to read registry cache %s: %v", c.path, err))
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		utils.LogWarning(fmt.Sprintf("Ignoring corrupted registry cache %s: %v", c.path, err))
+		c.entries = make(map[string]registryCacheEntry)
+	}
+}
+
+// saveLocked writes the cache file
+func (c *RegistryCache) saveLocked() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode registry cache")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(c.path), err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return errors.NewFileError("write", c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistryCacheServesOffline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry-cache.json")
+	image := "wenkhairu/moodle-prototype:502-stable"
+
+	online := NewRegistryCache(path)
+	online.fetchTags = func(string) ([]string, error) { return []string{"404-stable", "502-stable"}, nil }
+	online.fetchDigest = func(string) (string, error) { return "sha256:abc", nil }
+	if _, _, err := online.Tags(image); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, err := online.Digest(image); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A new cache on the same file answers without reaching the registry
+	offline := NewRegistryCache(path)
+	offline.fetchTags = func(string) ([]string, error) { return nil, fmt.Errorf("offline") }
+	offline.fetchDigest = func(string) (string, error) { return "", fmt.Errorf("offline") }
+
+	tags, _, err := offline.Tags("wenkhairu/moodle-prototype:404-stable")
+	if err != nil || len(tags) != 2 {
+		t.Errorf("Expected cached tags for the repository, got %v (err: %v)", tags, err)
+	}
+	if digest, _, err := offline.Digest(image); err != nil || digest != "sha256:abc" {
+		t.Errorf("Expected cached digest, got %q (err: %v)", digest, err)
+	}
+	if _, _, err := offline.Digest("wenkhairu/moodle-prototype:403-stable"); err == nil {
+		t.Error("Expected an error for an image that was never cached")
+	}
+}
+
+func TestRegistryCacheRefreshesStaleEntries(t *testing.T) {
+	cache := NewRegistryCache(filepath.Join(t.TempDir(), "registry-cache.json"))
+	cache.ttl = 0
+	var fetches atomic.Int32
+	cache.fetchDigest = func(string) (string, error) {
+		return fmt.Sprintf("sha256:%d", fetches.Add(1)), nil
+	}
+
+	image := "wenkhairu/moodle-prototype:502-stable"
+	if digest, _, _ := cache.Digest(image); digest != "sha256:1" {
+		t.Fatalf("Expected first fetch, got %q", digest)
+	}
+
+	// A stale entry is served immediately while it is refreshed in the background
+	if digest, _, _ := cache.Digest(image); digest != "sha256:1" {
+		t.Errorf("Expected stale digest to be served, got %q", digest)
+	}
+	refreshing := func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return len(cache.refreshing) > 0
+	}
+	deadline := time.Now().Add(time.Second)
+	for refreshing() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if fetches.Load() < 2 {
+		t.Error("Expected a background refresh of the stale entry")
+	}
+}
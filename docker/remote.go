@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/utils"
+)
+
+// DockerHostConfig selects the daemon the docker CLI talks to; empty fields fall back to
+// DOCKER_HOST, DOCKER_CONTEXT, DOCKER_TLS_VERIFY and DOCKER_CERT_PATH from the environment
+type DockerHostConfig struct {
+	// Host is a daemon address such as tcp://build-box:2376 or ssh://user@build-box
+	Host string `json:"host"`
+	// Context is a docker context name; it cannot be combined with Host
+	Context   string `json:"context"`
+	TLSVerify bool   `json:"tlsVerify"`
+	// CertPath is the directory holding ca.pem, cert.pem and key.pem for TLS
+	CertPath string `json:"certPath"`
+}
+
+// IsSet reports whether an explicit daemon is configured
+func (c DockerHostConfig) IsSet() bool {
+	return c.Host != "" || c.Context != ""
+}
+
+var (
+	dockerHostMu     sync.RWMutex
+	dockerHostConfig DockerHostConfig
+	// dockerHostAddr caches the resolved address published ports are reachable at
+	dockerHostAddr string
+)
+
+// SetDockerHostConfig sets the daemon used by docker commands
+func SetDockerHostConfig(config DockerHostConfig) {
+	dockerHostMu.Lock()
+	defer dockerHostMu.Unlock()
+	dockerHostConfig = config
+	dockerHostAddr = ""
+
+	if config.IsSet() {
+		utils.LogInfo(fmt.Sprintf("Using Docker daemon: host=%s context=%s tls=%v", config.Host, config.Context, config.TLSVerify))
+	}
+}
+
+// GetDockerHostConfig returns the configured daemon
+func GetDockerHostConfig() DockerHostConfig {
+	dockerHostMu.RLock()
+	defer dockerHostMu.RUnlock()
+	return dockerHostConfig
+}
+
+// dockerHostEnv returns the environment variables selecting the configured daemon
+func dockerHostEnv() []string {
+	config := GetDockerHostConfig()
+	if !config.IsSet() {
+		return nil
+	}
+
+	// Clear the other selector: the CLI refuses DOCKER_HOST and DOCKER_CONTEXT together
+	env := []string{"DOCKER_HOST=" + config.Host, "DOCKER_CONTEXT="}
+	if config.Host == "" {
+		env = []string{"DOCKER_HOST=", "DOCKER_CONTEXT=" + config.Context}
+	}
+	if config.TLSVerify {
+		env = append(env, "DOCKER_TLS_VERIFY=1")
+	}
+	if config.CertPath != "" {
+		env = append(env, "DOCKER_CERT_PATH="+config.CertPath)
+	}
+	return env
+}
+
+// applyDockerHostEnv adds the daemon selection to a docker CLI invocation's environment
+func applyDockerHostEnv(env []string) []string {
+	extra := dockerHostEnv()
+	if len(extra) == 0 {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	return append(env, extra...)
+}
+
+// DockerHostAddress returns the host name or IP at which ports published by the daemon are
+// reachable: "localhost" for a local daemon, otherwise the remote daemon's host
+func DockerHostAddress() string {
+	dockerHostMu.RLock()
+	cached := dockerHostAddr
+	dockerHostMu.RUnlock()
+	if cached != "" {
+		return cached
+	}
+
+	address := hostFromEndpoint(dockerEndpoint())
+	dockerHostMu.Lock()
+	dockerHostAddr = address
+	dockerHostMu.Unlock()
+
+	if address != "localhost" {
+		utils.LogInfo(fmt.Sprintf("Docker daemon is remote; published ports are reached at %s", address))
+	}
+	return address
+}
+
+// IsRemoteDockerHost reports whether the daemon runs on another machine
+func IsRemoteDockerHost() bool {
+	return DockerHostAddress() != "localhost"
+}
+
+// dockerEndpoint returns the daemon endpoint the CLI will use
+func dockerEndpoint() string {
+	config := GetDockerHostConfig()
+	if config.Host != "" {
+		return config.Host
+	}
+	if config.Context == "" {
+		if host := os.Getenv("DOCKER_HOST"); host != "" {
+			return host
+		}
+	}
+
+	// The selected (or current) context may itself point at a remote daemon
+	args := []string{"context", "inspect", "--format", "{{.Endpoints.docker.Host}}"}
+	if config.Context != "" {
+		args = append(args, config.Context)
+	}
+	output, err := GetDockerCommand(args...).CombinedOutput()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Could not inspect docker context, assuming a local daemon: %v", err))
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// hostFromEndpoint extracts the host of a daemon endpoint; local sockets and pipes map to localhost
+func hostFromEndpoint(endpoint string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" {
+		return "localhost"
+	}
+	switch parsed.Scheme {
+	case "tcp", "ssh", "http", "https":
+	default:
+		return "localhost"
+	}
+
+	host := parsed.Hostname()
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return "localhost"
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		// IPv6 literals need brackets in URLs
+		return "[" + host + "]"
+	}
+	return host
+}
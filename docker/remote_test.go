@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostFromEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"":                               "localhost",
+		"unix:///var/run/docker.sock":    "localhost",
+		"npipe:////./pipe/docker_engine": "localhost",
+		"tcp://127.0.0.1:2375":           "localhost",
+		"tcp://build-box.lan:2376":       "build-box.lan",
+		"ssh://deploy@10.0.0.5":          "10.0.0.5",
+		"tcp://[fd00::5]:2376":           "[fd00::5]",
+	}
+	for endpoint, expected := range tests {
+		if got := hostFromEndpoint(endpoint); got != expected {
+			t.Errorf("hostFromEndpoint(%q) = %q, expected %q", endpoint, got, expected)
+		}
+	}
+}
+
+func TestDockerHostEnv(t *testing.T) {
+	defer SetDockerHostConfig(DockerHostConfig{})
+
+	SetDockerHostConfig(DockerHostConfig{Host: "tcp://build-box:2376", TLSVerify: true, CertPath: "/certs"})
+	env := strings.Join(dockerHostEnv(), " ")
+	for _, expected := range []string{"DOCKER_HOST=tcp://build-box:2376", "DOCKER_CONTEXT=", "DOCKER_TLS_VERIFY=1", "DOCKER_CERT_PATH=/certs"} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected %s in %q", expected, env)
+		}
+	}
+
+	SetDockerHostConfig(DockerHostConfig{Context: "lab"})
+	if env := dockerHostEnv(); len(env) != 2 || env[0] != "DOCKER_HOST=" || env[1] != "DOCKER_CONTEXT=lab" {
+		t.Errorf("Unexpected context env: %v", env)
+	}
+
+	SetDockerHostConfig(DockerHostConfig{})
+	if env := dockerHostEnv(); env != nil {
+		t.Errorf("Expected no env without a configured daemon, got %v", env)
+	}
+}
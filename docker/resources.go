@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	gib = 1024 * 1024 * 1024
+
+	// EstimatedImageBytes approximates download plus extracted size when the image is not present yet
+	EstimatedImageBytes uint64 = 4 * gib
+	// VolumeHeadroomBytes is the free space Moodle data and the database need to grow into
+	VolumeHeadroomBytes uint64 = 2 * gib
+	// MinDockerMemoryBytes is the least memory Docker needs for Moodle and its database
+	MinDockerMemoryBytes uint64 = 2 * gib
+	// RecommendedDockerMemoryBytes avoids database OOM kills during install
+	RecommendedDockerMemoryBytes uint64 = 4 * gib
+)
+
+// diskUnknownMessage is the disk message when free space could not be read
+const diskUnknownMessage = "Could not determine free disk space"
+
+// ResourceReport details disk and memory preflight results with the thresholds used
+type ResourceReport struct {
+	DiskPath               string `json:"diskPath"`
+	DiskFreeBytes          uint64 `json:"diskFreeBytes"`
+	DiskRequiredBytes      uint64 `json:"diskRequiredBytes"`
+	DiskOK                 bool   `json:"diskOk"`
+	DiskMessage            string `json:"diskMessage"`
+	MemoryBytes            uint64 `json:"memoryBytes"`
+	MemoryRequiredBytes    uint64 `json:"memoryRequiredBytes"`
+	MemoryRecommendedBytes uint64 `json:"memoryRecommendedBytes"`
+	MemoryOK               bool   `json:"memoryOk"`
+	MemoryMessage          string `json:"memoryMessage"`
+}
+
+// CheckResources verifies free disk for the image and volumes and the memory allocated to Docker
+func CheckResources(imageName string) ResourceReport {
+	report := ResourceReport{
+		MemoryRequiredBytes:    MinDockerMemoryBytes,
+		MemoryRecommendedBytes: RecommendedDockerMemoryBytes,
+	}
+
+	checkDisk(&report, imageName)
+	checkMemory(&report)
+
+	utils.LogInfo(fmt.Sprintf("Resource check: disk %s free (need %s) ok=%v, docker memory %s (need %s) ok=%v",
+		FormatBytes(report.DiskFreeBytes), FormatBytes(report.DiskRequiredBytes), report.DiskOK,
+		FormatBytes(report.MemoryBytes), FormatBytes(report.MemoryRequiredBytes), report.MemoryOK))
+	return report
+}
+
+// checkDisk fills in the disk fields of the report
+func checkDisk(report *ResourceReport, imageName string) {
+	report.DiskRequiredBytes = VolumeHeadroomBytes
+	if imageName != "" {
+		if size, err := imageSize(imageName); err == nil {
+			utils.LogDebug(fmt.Sprintf("Image %s already present (%s), only volume headroom required", imageName, FormatBytes(size)))
+		} else {
+			report.DiskRequiredBytes += EstimatedImageBytes
+		}
+	} else {
+		report.DiskRequiredBytes += EstimatedImageBytes
+	}
+
+	report.DiskPath = dockerDataPath()
+	free, err := utils.FreeDiskSpace(report.DiskPath)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to read free disk space for %s", report.DiskPath), err)
+		report.DiskMessage = diskUnknownMessage
+		return
+	}
+
+	report.DiskFreeBytes = free
+	report.DiskOK = free >= report.DiskRequiredBytes
+	if !report.DiskOK {
+		report.DiskMessage = fmt.Sprintf("Only %s free on %s; free up at least %s before starting Moodle",
+			FormatBytes(free), report.DiskPath, FormatBytes(report.DiskRequiredBytes-free))
+	}
+}
+
+// checkMemory fills in the memory fields of the report
+func checkMemory(report *ResourceReport) {
+	cmd := GetDockerCommand("info", "--format", "{{.MemTotal}}")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("docker info failed during memory check: %v", err))
+		report.MemoryMessage = "Could not read Docker memory allocation (is Docker running?)"
+		return
+	}
+
+	memory, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		report.MemoryMessage = "Could not parse Docker memory allocation"
+		return
+	}
+
+	report.MemoryBytes = memory
+	report.MemoryOK = memory >= MinDockerMemoryBytes
+	switch {
+	case !report.MemoryOK:
+		report.MemoryMessage = fmt.Sprintf("Docker has only %s of memory; increase it to at least %s (Docker Desktop: Settings > Resources)",
+			FormatBytes(memory), FormatBytes(MinDockerMemoryBytes))
+	case memory < RecommendedDockerMemoryBytes:
+		report.MemoryMessage = fmt.Sprintf("Docker has %s of memory; %s is recommended to avoid database crashes during install",
+			FormatBytes(memory), FormatBytes(RecommendedDockerMemoryBytes))
+	}
+}
+
+// dockerDataPath returns the host path whose volume stores Docker data
+func dockerDataPath() string {
+	// On native Linux the daemon root is on the host; Docker Desktop keeps it inside its VM,
+	// whose disk image lives under the user's home directory
+	cmd := GetDockerCommand("info", "--format", "{{.DockerRootDir}}")
+	if output, err := cmd.CombinedOutput(); err == nil {
+		rootDir := strings.TrimSpace(string(output))
+		if info, err := os.Stat(rootDir); err == nil && info.IsDir() {
+			return rootDir
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return "."
+}
+
+// imageSize returns the size of a local image in bytes
+func imageSize(imageName string) (uint64, error) {
+	cmd := GetDockerCommand("image", "inspect", "--format", "{{.Size}}", imageName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+}
+
+// FormatBytes renders a byte count using binary units
+func FormatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
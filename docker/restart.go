@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"fmt"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Restart policies supported for the Moodle container
+const (
+	RestartNo            = "no"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// SetRestartPolicy sets the restart policy for new containers
+func (m *Manager) SetRestartPolicy(policy string) error {
+	if err := validateRestartPolicy(policy); err != nil {
+		return err
+	}
+	m.restartPolicy = policy
+	return nil
+}
+
+// GetRestartPolicy returns the restart policy for new containers
+func (m *Manager) GetRestartPolicy() string {
+	if m.restartPolicy == "" {
+		return RestartNo
+	}
+	return m.restartPolicy
+}
+
+// UpdateRestartPolicy applies a restart policy to an existing container
+func (m *Manager) UpdateRestartPolicy(containerID, policy string) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to UpdateRestartPolicy")
+	}
+	if err := validateRestartPolicy(policy); err != nil {
+		return err
+	}
+
+	cmd := GetDockerCommand("update", "--restart", policy, containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("update", containerID, err).WithOutput(string(output))
+		utils.LogError("Docker update command failed", dockerErr)
+		return errors.WrapWithContext(dockerErr, "failed to update container restart policy")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Container %s restart policy set to %s", containerID, policy))
+	return nil
+}
+
+// restartArgs returns the `docker run` flags for the configured restart policy
+func (m *Manager) restartArgs() []string {
+	return []string{"--restart", m.GetRestartPolicy()}
+}
+
+// validateRestartPolicy checks the policy is one we support
+func validateRestartPolicy(policy string) error {
+	if policy != RestartNo && policy != RestartUnlessStopped {
+		return errors.NewValidationError("restartPolicy", fmt.Sprintf("must be %q or %q", RestartNo, RestartUnlessStopped), policy)
+	}
+	return nil
+}
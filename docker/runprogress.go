@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"sync"
+	"time"
+)
+
+// Steps of starting Moodle, in the order RunMoodle goes through them. Restarting an existing
+// container skips the image and create steps; only first runs install and extract credentials.
+const (
+	RunStepCheckingImage         = "checking_image"
+	RunStepPullingImage          = "pulling_image"
+	RunStepCreatingContainer     = "creating_container"
+	RunStepStartingContainer     = "starting_container"
+	RunStepWaitingForDatabase    = "waiting_for_database"
+	RunStepInstalling            = "installing"
+	RunStepExtractingCredentials = "extracting_credentials"
+	RunStepReady                 = "ready"
+	RunStepFailed                = "failed"
+)
+
+// RunProgress reports the lifecycle step RunMoodle has reached
+type RunProgress struct {
+	Step string `json:"step"`
+	// Label describes the step for the user
+	Label string `json:"label"`
+	// Percentage is the progress of the whole start, not of the step
+	Percentage float64 `json:"percentage"`
+	Elapsed    float64 `json:"elapsedSeconds"`
+	// Message carries detail such as the install phase or why the start failed
+	Message string `json:"message,omitempty"`
+}
+
+// runStep spans part of the overall progress bar
+type runStep struct {
+	label string
+	start float64
+	end   float64
+}
+
+// runSteps gives each step its share of the progress bar; the install is most of a first run
+var runSteps = map[string]runStep{
+	RunStepCheckingImage:         {"Checking the Docker image", 0, 5},
+	RunStepPullingImage:          {"Downloading the Docker image", 5, 30},
+	RunStepCreatingContainer:     {"Creating the container", 30, 35},
+	RunStepStartingContainer:     {"Starting the container", 35, 40},
+	RunStepWaitingForDatabase:    {"Waiting for the database", 40, 50},
+	RunStepInstalling:            {"Installing Moodle", 50, 90},
+	RunStepExtractingCredentials: {"Extracting the login details", 90, 98},
+	RunStepReady:                 {"Moodle is ready", 100, 100},
+	RunStepFailed:                {"Starting Moodle failed", 0, 0},
+}
+
+// RunTracker turns lifecycle steps into progress for the whole start. Progress never moves
+// back, so a restart that skips steps jumps ahead instead.
+type RunTracker struct {
+	mu      sync.Mutex
+	started time.Time
+	last    RunProgress
+	now     func() time.Time
+}
+
+// NewRunTracker starts tracking a start of Moodle
+func NewRunTracker() *RunTracker {
+	return &RunTracker{started: time.Now(), now: time.Now}
+}
+
+// Step reports step with fraction (0 to 1) of it done. A failure keeps the progress reached.
+func (t *RunTracker) Step(step string, fraction float64, message string) RunProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info := runSteps[step]
+	fraction = min(max(fraction, 0), 1)
+	percentage := info.start + (info.end-info.start)*fraction
+	if step == RunStepFailed || percentage < t.last.Percentage {
+		percentage = t.last.Percentage
+	}
+
+	t.last = RunProgress{
+		Step:       step,
+		Label:      info.label,
+		Percentage: percentage,
+		Elapsed:    t.now().Sub(t.started).Seconds(),
+		Message:    message,
+	}
+	return t.last
+}
+
+// InstallRunStep places first-run install progress among the run steps: the phases up to
+// creating the database are waiting for it, the rest is installing, and a finished install
+// leaves the credentials to extract
+func InstallRunStep(progress *InstallProgress) (string, float64) {
+	databaseEnd := installPhaseEnd(InstallPhaseDatabase)
+	switch progress.Phase {
+	case InstallPhaseStarting, InstallPhaseDatabase:
+		return RunStepWaitingForDatabase, progress.Percentage / databaseEnd
+	case InstallPhaseComplete:
+		return RunStepExtractingCredentials, 0
+	}
+	return RunStepInstalling, (progress.Percentage - databaseEnd) / (100 - databaseEnd)
+}
+
+// installPhaseEnd returns the install percentage at which phase ends
+func installPhaseEnd(phase string) float64 {
+	for _, p := range installPhases {
+		if p.name == phase {
+			return p.end
+		}
+	}
+	return 0
+}
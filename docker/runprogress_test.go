@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunTrackerSteps(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	tracker := NewRunTracker()
+	tracker.started = start
+	tracker.now = func() time.Time { return start.Add(90 * time.Second) }
+
+	if progress := tracker.Step(RunStepCheckingImage, 0, ""); progress.Percentage != 0 || progress.Label == "" {
+		t.Errorf("Unexpected first step: %+v", progress)
+	}
+
+	// Installing is scaled into its share of the whole start
+	progress := tracker.Step(RunStepInstalling, 0.5, "Installing plugins")
+	if progress.Percentage != 70 || progress.Elapsed != 90 || progress.Message != "Installing plugins" {
+		t.Errorf("Unexpected install step: %+v", progress)
+	}
+
+	// A late database line must not move progress back
+	if progress := tracker.Step(RunStepWaitingForDatabase, 0, ""); progress.Percentage != 70 {
+		t.Errorf("Expected progress to stay at 70%%, got %+v", progress)
+	}
+
+	if progress := tracker.Step(RunStepFailed, 0, "timeout"); progress.Percentage != 70 || progress.Step != RunStepFailed {
+		t.Errorf("Expected a failure to keep the progress reached, got %+v", progress)
+	}
+
+	if progress := tracker.Step(RunStepReady, 0, ""); progress.Percentage != 100 {
+		t.Errorf("Expected ready to be 100%%, got %+v", progress)
+	}
+}
+
+func TestInstallRunStep(t *testing.T) {
+	tests := []struct {
+		progress InstallProgress
+		step     string
+		fraction float64
+	}{
+		{InstallProgress{Phase: InstallPhaseStarting, Percentage: 0}, RunStepWaitingForDatabase, 0},
+		{InstallProgress{Phase: InstallPhaseDatabase, Percentage: 15}, RunStepWaitingForDatabase, 1},
+		{InstallProgress{Phase: InstallPhasePlugins, Percentage: 57.5}, RunStepInstalling, 0.5},
+		{InstallProgress{Phase: InstallPhaseComplete, Percentage: 100}, RunStepExtractingCredentials, 0},
+	}
+	for _, tt := range tests {
+		step, fraction := InstallRunStep(&tt.progress)
+		if step != tt.step || fraction != tt.fraction {
+			t.Errorf("InstallRunStep(%s at %.1f%%) = %s, %.2f; want %s, %.2f", tt.progress.Phase, tt.progress.Percentage, step, fraction, tt.step, tt.fraction)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// MaxScreenshotCourses bounds how many courses are captured, most recently modified first
+	MaxScreenshotCourses = 10
+	// screenshotWindowSize is the browser viewport used for every capture
+	screenshotWindowSize = "1366,900"
+	// webDriverPort is the port Selenium serves the WebDriver API on inside its container
+	webDriverPort = 4444
+	// webDriverReadyTimeout bounds how long the headless browser may take to start
+	webDriverReadyTimeout = 90 * time.Second
+	// webDriverElementKey identifies element references in W3C WebDriver responses
+	webDriverElementKey = "element-6066-11e4-a52e-4f735466cecf"
+	// dockerHostGateway is how the headless browser reaches ports published on the Docker host
+	dockerHostGateway = "host.docker.internal"
+)
+
+// Screenshot is one captured page
+type Screenshot struct {
+	Page string `json:"page"`
+	URL  string `json:"url"`
+	File string `json:"file,omitempty"`
+	// Error explains why the page could not be captured; the other pages are still taken
+	Error string `json:"error,omitempty"`
+}
+
+// ScreenshotResult lists the pages captured into one directory
+type ScreenshotResult struct {
+	Directory   string       `json:"directory"`
+	CapturedAt  time.Time    `json:"capturedAt"`
+	Screenshots []Screenshot `json:"screenshots"`
+}
+
+// screenshotPage is a page to capture, relative to the site URL
+type screenshotPage struct {
+	name string
+	path string
+}
+
+// listCoursesScript prints "COURSE:<id>:<shortname>" for visible courses, newest changes first
+const listCoursesScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+$courses = $DB->get_records_select('course', 'id <> :siteid AND visible = 1', ['siteid' => SITEID],
+    'timemodified DESC', 'id, shortname', 0, %d);
+foreach ($courses as $course) {
+    echo "COURSE:" . $course->id . ":" . $course->shortname . "\n";
+}
+`
+
+var screenshotSlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ScreenshotBrowserName returns the headless browser container name for an instance
+func ScreenshotBrowserName(instance string) string {
+	return ContainerName(instance) + "-screenshots"
+}
+
+// CaptureScreenshots logs in to Moodle at siteURL with a headless browser and saves the
+// dashboard, site home, course overview and recent course pages as PNG files in a new
+// timestamped directory under outDir. progress, if set, is called before each page.
+func (m *Manager) CaptureScreenshots(containerID, siteURL, username, password, outDir string, progress func(done, total int, page string)) (*ScreenshotResult, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to CaptureScreenshots")
+	}
+	site, err := url.Parse(siteURL)
+	if err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Host == "" {
+		return nil, errors.NewValidationError("url", "must be an absolute http(s) URL", siteURL)
+	}
+	if username == "" || password == "" {
+		return nil, errors.NewValidationError("credentials", "Moodle login details are required to capture screenshots", username)
+	}
+	siteURL = strings.TrimRight(siteURL, "/")
+
+	pages := []screenshotPage{
+		{name: "dashboard", path: "/my/"},
+		{name: "site-home", path: "/?redirect=0"},
+		{name: "my-courses", path: "/my/courses.php"},
+	}
+	if courses, err := m.RunPHPScript(containerID, fmt.Sprintf(listCoursesScript, MaxScreenshotCourses)); err == nil {
+		pages = append(pages, parseCoursePages(courses)...)
+	} else {
+		utils.LogWarning(fmt.Sprintf("Capturing screenshots without course pages: %v", err))
+	}
+
+	capturedAt := time.Now()
+	dir := filepath.Join(outDir, capturedAt.Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.NewFileError("create", dir, err)
+	}
+
+	name := ScreenshotBrowserName(m.GetInstanceName())
+	endpoint, err := m.startScreenshotBrowser(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if output, err := GetDockerCommand("rm", "-f", name).CombinedOutput(); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to remove headless browser %s: %v (%s)", name, err, output))
+		}
+	}()
+
+	driver := newWebDriver(endpoint)
+	if err := driver.waitReady(webDriverReadyTimeout); err != nil {
+		return nil, err
+	}
+	if err := driver.newSession(screenshotBrowserArgs(site.Hostname(), IsRemoteDockerHost())); err != nil {
+		return nil, err
+	}
+	defer driver.quit()
+
+	if err := driver.login(siteURL, username, password); err != nil {
+		return nil, err
+	}
+
+	result := &ScreenshotResult{Directory: dir, CapturedAt: capturedAt, Screenshots: make([]Screenshot, 0, len(pages))}
+	captured := 0
+	for i, page := range pages {
+		if progress != nil {
+			progress(i, len(pages), page.name)
+		}
+		shot := Screenshot{Page: page.name, URL: siteURL + page.path}
+		file := filepath.Join(dir, fmt.Sprintf("%02d-%s.png", i+1, page.name))
+		if err := driver.capture(shot.URL, file); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to capture %s: %v", shot.URL, err))
+			shot.Error = err.Error()
+		} else {
+			shot.File = file
+			captured++
+		}
+		result.Screenshots = append(result.Screenshots, shot)
+	}
+	if progress != nil {
+		progress(len(pages), len(pages), "")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Captured %d of %d screenshots into %s", captured, len(pages), dir))
+	return result, nil
+}
+
+// startScreenshotBrowser runs a headless Chrome container and returns its WebDriver endpoint
+func (m *Manager) startScreenshotBrowser(name string) (string, error) {
+	utils.LogInfo(fmt.Sprintf("Starting headless browser %s", name))
+	// Ignore errors; a browser left over from an interrupted capture is replaced
+	GetDockerCommand("rm", "-f", name).CombinedOutput()
+
+	cmd := GetDockerCommand("run", "-d", "--name", name,
+		"--shm-size", "2g",
+		"--add-host", dockerHostGateway+":host-gateway",
+		"-p", strconv.Itoa(webDriverPort),
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		SeleniumImage)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", SeleniumImage, err).WithOutput(string(output))
+		return "", errors.WrapWithContext(dockerErr, "failed to start headless browser for screenshots")
+	}
+
+	output, err = GetDockerCommand("port", name, fmt.Sprintf("%d/tcp", webDriverPort)).CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("port", name, err).WithOutput(string(output))
+		return "", errors.WrapWithContext(dockerErr, "failed to find the headless browser's port")
+	}
+	port, err := parsePublishedPort(string(output))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s:%d", DockerHostAddress(), port), nil
+}
+
+// screenshotBrowserArgs returns the Chrome flags for capturing. A local daemon's published
+// ports are on the Docker host, so the site's host name is mapped to the host gateway.
+func screenshotBrowserArgs(siteHost string, remoteDaemon bool) []string {
+	args := []string{"--headless=new", "--window-size=" + screenshotWindowSize, "--hide-scrollbars", "--ignore-certificate-errors"}
+	if !remoteDaemon {
+		args = append(args, fmt.Sprintf("--host-resolver-rules=MAP %s %s", siteHost, dockerHostGateway))
+	}
+	return args
+}
+
+// parseCoursePages reads the COURSE lines printed by listCoursesScript
+func parseCoursePages(output string) []screenshotPage {
+	pages := make([]screenshotPage, 0)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(fields) != 3 || fields[0] != "COURSE" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		slug := strings.Trim(screenshotSlugRegex.ReplaceAllString(strings.ToLower(fields[2]), "-"), "-")
+		name := fmt.Sprintf("course-%d", id)
+		if slug != "" {
+			name += "-" + slug
+		}
+		pages = append(pages, screenshotPage{name: name, path: fmt.Sprintf("/course/view.php?id=%d", id)})
+	}
+	return pages
+}
+
+// parsePublishedPort reads the host port from `docker port` output such as "0.0.0.0:49153"
+func parsePublishedPort(output string) (int, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		index := strings.LastIndex(line, ":")
+		if index < 0 {
+			continue
+		}
+		if port, err := strconv.Atoi(line[index+1:]); err == nil && port > 0 {
+			return port, nil
+		}
+	}
+	return 0, errors.WrapWithContext(errors.ErrInvalidFormat, "no published port in %q", strings.TrimSpace(output))
+}
+
+// webDriver is a minimal W3C WebDriver client for the headless browser
+type webDriver struct {
+	endpoint string
+	session  string
+	client   *http.Client
+}
+
+// newWebDriver creates a client for the WebDriver API at endpoint
+func newWebDriver(endpoint string) *webDriver {
+	return &webDriver{endpoint: endpoint, client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// waitReady polls the status endpoint until the browser accepts sessions
+func (d *webDriver) waitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var status struct {
+			Ready bool `json:"ready"`
+		}
+		err := d.call(http.MethodGet, "/status", nil, &status)
+		if err == nil && status.Ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = errors.ErrConnectionTimeout
+			}
+			return errors.WrapWithContext(err, "headless browser did not become ready within %s", timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// newSession starts Chrome with args
+func (d *webDriver) newSession(args []string) error {
+	request := map[string]any{
+		"capabilities": map[string]any{
+			"alwaysMatch": map[string]any{
+				"browserName":         "chrome",
+				"acceptInsecureCerts": true,
+				"goog:chromeOptions":  map[string]any{"args": args},
+			},
+		},
+	}
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := d.call(http.MethodPost, "/session", request, &session); err != nil {
+		return errors.WrapWithContext(err, "failed to start a browser session")
+	}
+	d.session = session.SessionID
+	return nil
+}
+
+// quit ends the browser session
+func (d *webDriver) quit() {
+	if d.session == "" {
+		return
+	}
+	if err := d.call(http.MethodDelete, "/session/"+d.session, nil, nil); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to end browser session: %v", err))
+	}
+	d.session = ""
+}
+
+// login signs in through Moodle's login form
+func (d *webDriver) login(siteURL, username, password string) error {
+	if err := d.navigate(siteURL + "/login/index.php"); err != nil {
+		return errors.WrapWithContext(err, "failed to open the Moodle login page")
+	}
+	for selector, text := range map[string]string{"#username": username, "#password": password} {
+		element, err := d.find(selector)
+		if err != nil {
+			return errors.WrapWithContext(err, "login form field %s not found", selector)
+		}
+		if err := d.call(http.MethodPost, d.sessionPath("/element/"+element+"/value"), map[string]string{"text": text}, nil); err != nil {
+			return errors.WrapWithContext(err, "failed to fill in the login form")
+		}
+	}
+	button, err := d.find("#loginbtn")
+	if err != nil {
+		return errors.WrapWithContext(err, "login button not found")
+	}
+	if err := d.call(http.MethodPost, d.sessionPath("/element/"+button+"/click"), map[string]string{}, nil); err != nil {
+		return errors.WrapWithContext(err, "failed to submit the login form")
+	}
+
+	var current string
+	if err := d.call(http.MethodGet, d.sessionPath("/url"), nil, &current); err != nil {
+		return errors.WrapWithContext(err, "failed to read the page after login")
+	}
+	if strings.Contains(current, "/login/index.php") {
+		return errors.WrapWithContext(errors.ErrInvalidInput, "Moodle rejected the saved login details")
+	}
+	return nil
+}
+
+// capture opens pageURL and writes a PNG screenshot of the viewport to file
+func (d *webDriver) capture(pageURL, file string) error {
+	if err := d.navigate(pageURL); err != nil {
+		return err
+	}
+	var encoded string
+	if err := d.call(http.MethodGet, d.sessionPath("/screenshot"), nil, &encoded); err != nil {
+		return errors.WrapWithContext(err, "failed to take screenshot")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to decode screenshot")
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return errors.NewFileError("write", file, err)
+	}
+	return nil
+}
+
+// navigate loads pageURL, returning once the page has finished loading
+func (d *webDriver) navigate(pageURL string) error {
+	return d.call(http.MethodPost, d.sessionPath("/url"), map[string]string{"url": pageURL}, nil)
+}
+
+// find returns the reference of the element matching a CSS selector
+func (d *webDriver) find(selector string) (string, error) {
+	var element map[string]string
+	request := map[string]string{"using": "css selector", "value": selector}
+	if err := d.call(http.MethodPost, d.sessionPath("/element"), request, &element); err != nil {
+		return "", err
+	}
+	return element[webDriverElementKey], nil
+}
+
+// sessionPath prefixes path with the current session
+func (d *webDriver) sessionPath(path string) string {
+	return "/session/" + d.session + path
+}
+
+// call sends a WebDriver command and decodes the "value" of the response into result
+func (d *webDriver) call(method, path string, request, result any) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return errors.WrapWithContext(err, "failed to encode WebDriver request")
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, d.endpoint+path, body)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to create WebDriver request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.WrapWithContext(err, "WebDriver request %s %s failed", method, path)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return errors.WrapWithContext(err, "failed to decode WebDriver response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(envelope.Value, &failure)
+		return errors.WrapWithContext(errors.ErrServiceUnavailable, "WebDriver %s: %s", failure.Error, failure.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Value, result); err != nil {
+		return errors.WrapWithContext(err, "unexpected WebDriver response")
+	}
+	return nil
+}
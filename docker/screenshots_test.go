@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCoursePages(t *testing.T) {
+	output := "COURSE:4:Intro to Biology\nnoise\nCOURSE:x:bad\nCOURSE:7:\n"
+	pages := parseCoursePages(output)
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 course pages, got %+v", pages)
+	}
+	if pages[0].name != "course-4-intro-to-biology" || pages[0].path != "/course/view.php?id=4" {
+		t.Errorf("Unexpected first page: %+v", pages[0])
+	}
+	if pages[1].name != "course-7" {
+		t.Errorf("Expected a course without short name to use its ID, got %q", pages[1].name)
+	}
+}
+
+func TestParsePublishedPort(t *testing.T) {
+	port, err := parsePublishedPort("0.0.0.0:49153\n[::]:49153\n")
+	if err != nil || port != 49153 {
+		t.Errorf("Expected port 49153, got %d (err: %v)", port, err)
+	}
+	if _, err := parsePublishedPort(""); err == nil {
+		t.Error("Expected an error without a published port")
+	}
+}
+
+func TestScreenshotBrowserArgs(t *testing.T) {
+	local := strings.Join(screenshotBrowserArgs("moodle.local", false), " ")
+	if !strings.Contains(local, "--host-resolver-rules=MAP moodle.local host.docker.internal") {
+		t.Errorf("Expected a local site to be mapped to the host gateway, got %s", local)
+	}
+	if remote := strings.Join(screenshotBrowserArgs("build-box", true), " "); strings.Contains(remote, "host-resolver-rules") {
+		t.Errorf("Expected a remote daemon's host to be reached directly, got %s", remote)
+	}
+}
+
+func TestWebDriverCapture(t *testing.T) {
+	png := []byte("\x89PNG fake")
+	visited := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/session/s1/url" && r.Method == http.MethodPost:
+			var request map[string]string
+			json.NewDecoder(r.Body).Decode(&request)
+			visited = append(visited, request["url"])
+			w.Write([]byte(`{"value":null}`))
+		case r.URL.Path == "/session/s1/screenshot":
+			w.Write([]byte(`{"value":"` + base64.StdEncoding.EncodeToString(png) + `"}`))
+		case r.URL.Path == "/session/s1/element":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"value":{"error":"no such element","message":"missing"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"value":{"error":"unknown command","message":"` + r.URL.Path + `"}}`))
+		}
+	}))
+	defer server.Close()
+
+	driver := newWebDriver(server.URL)
+	driver.session = "s1"
+	file := filepath.Join(t.TempDir(), "dashboard.png")
+	if err := driver.capture("http://localhost:8080/my/", file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != string(png) {
+		t.Errorf("Expected the decoded screenshot to be saved, got %q", data)
+	}
+	if len(visited) != 1 || visited[0] != "http://localhost:8080/my/" {
+		t.Errorf("Expected the page to be opened first, got %v", visited)
+	}
+
+	if _, err := driver.find("#username"); err == nil || !strings.Contains(err.Error(), "no such element") {
+		t.Errorf("Expected the WebDriver error to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// configureSiteScript renames the front page course and sets the admin's email; values arrive
+// base64-encoded and empty values are left unchanged
+const configureSiteScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+
+$fullname = base64_decode('%s');
+$shortname = base64_decode('%s');
+$adminemail = base64_decode('%s');
+
+$site = $DB->get_record('course', ['id' => SITEID], 'id, fullname, shortname', MUST_EXIST);
+if ($fullname !== '') {
+    $site->fullname = $fullname;
+}
+if ($shortname !== '') {
+    $site->shortname = $shortname;
+}
+$DB->update_record('course', $site);
+
+if ($adminemail !== '') {
+    $DB->set_field('user', 'email', $adminemail, ['id' => get_admin()->id]);
+}
+
+purge_all_caches();
+echo "CONFIGURED\n";
+`
+
+// ConfigureSite sets the site full name, short name and admin email of an installed Moodle;
+// empty values are left as they are
+func (m *Manager) ConfigureSite(containerID, fullName, shortName, adminEmail string) error {
+	if fullName == "" && shortName == "" && adminEmail == "" {
+		return nil
+	}
+
+	encode := func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) }
+	script := fmt.Sprintf(configureSiteScript, encode(fullName), encode(shortName), encode(adminEmail))
+	output, err := m.RunPHPScript(containerID, script)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to configure site details")
+	}
+	if !strings.Contains(output, "CONFIGURED") {
+		return errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while configuring site details: %s", output)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Site details configured in container %s", containerID))
+	return nil
+}
@@ -0,0 +1,465 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// SnapshotImagePrefix names snapshot images, moodle-prototype-snapshot-<user>:<snapshot>
+	SnapshotImagePrefix = ContainerNamePrefix + "-snapshot"
+	// LabelSnapshot holds the snapshot name on snapshot images and volumes
+	LabelSnapshot = "snapshot"
+	// LabelSnapshotSource records the instance a snapshot was taken from
+	LabelSnapshotSource = "snapshot.source"
+	// LabelSnapshotDestination records where a snapshot volume is mounted in the container
+	LabelSnapshotDestination = "snapshot.destination"
+
+	// Mount points of the volume copy helper
+	copyFromDir = "/snapshot-from"
+	copyToDir   = "/snapshot-to"
+)
+
+// Snapshot is a committed instance that new instances can be cloned from
+type Snapshot struct {
+	Name           string    `json:"name"`
+	Image          string    `json:"image"`
+	SourceInstance string    `json:"sourceInstance"`
+	Created        time.Time `json:"created"`
+	SizeBytes      int64     `json:"sizeBytes"`
+}
+
+// snapshotVolume is a copy of one of the source container's volumes
+type snapshotVolume struct {
+	Name        string
+	Destination string
+}
+
+// snapshotImageInspect mirrors the parts of `docker image inspect` we read
+type snapshotImageInspect struct {
+	ID      string    `json:"Id"`
+	Created time.Time `json:"Created"`
+	Size    int64     `json:"Size"`
+	Config  struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// SnapshotImage returns the image a snapshot is committed to
+func (m *Manager) SnapshotImage(name string) string {
+	return fmt.Sprintf("%s-%s:%s", SnapshotImagePrefix, m.userName, sanitizeName(name))
+}
+
+// snapshotVolumeName names the copy of the container's index-th volume
+func (m *Manager) snapshotVolumeName(name string, index int) string {
+	return fmt.Sprintf("%s-%s-%s-%d", SnapshotImagePrefix, m.userName, sanitizeName(name), index)
+}
+
+// CreateSnapshot commits the container and copies its volumes into a named snapshot. The
+// container is paused meanwhile so the image and the volumes (e.g. the database) agree.
+func (m *Manager) CreateSnapshot(containerID, name string) (*Snapshot, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to CreateSnapshot")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.NewValidationError("name", "snapshot name is required", name)
+	}
+	name = sanitizeName(name)
+
+	existing, err := m.findSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.NewValidationError("name", "a snapshot with this name already exists", name)
+	}
+
+	mounts, err := m.containerMounts(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.pauseContainer(containerID); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := m.unpauseContainer(containerID); err != nil {
+			utils.LogError("Failed to resume container after snapshot", err)
+		}
+	}()
+
+	image := m.SnapshotImage(name)
+	args := []string{"commit", "--pause=false"}
+	for _, label := range [][2]string{
+		{LabelApp, AppLabelValue},
+		{LabelUser, m.userName},
+		{LabelSnapshot, name},
+		{LabelSnapshotSource, m.GetInstanceName()},
+	} {
+		args = append(args, "--change", fmt.Sprintf("LABEL %s=%q", label[0], label[1]))
+	}
+	args = append(args, containerID, image)
+
+	utils.LogInfo(fmt.Sprintf("Committing container %s to %s", containerID, image))
+	cmd := GetDockerCommand(args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("commit", containerID, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to commit container")
+	}
+
+	index := 0
+	for _, mount := range mounts {
+		if mount.Type != "volume" {
+			// Bind mounts live on the host and are shared rather than copied
+			utils.LogWarning(fmt.Sprintf("Snapshot %s does not include bind mount %s", name, mount.Destination))
+			continue
+		}
+		volume := m.snapshotVolumeName(name, index)
+		index++
+		labels := map[string]string{LabelSnapshot: name, LabelSnapshotDestination: mount.Destination}
+		if err := m.copyVolume(image, mount.Name, volume, labels); err != nil {
+			m.removeSnapshotData(name, image)
+			return nil, err
+		}
+	}
+
+	snapshot, err := m.findSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, errors.WrapWithContext(errors.ErrImageNotFound, "snapshot image %s missing after commit", image)
+	}
+	utils.LogInfo(fmt.Sprintf("Snapshot %s created with %d volume(s)", name, index))
+	return snapshot, nil
+}
+
+// ListSnapshots returns this user's snapshots, newest first
+func (m *Manager) ListSnapshots() ([]Snapshot, error) {
+	return m.listSnapshots(fmt.Sprintf("label=%s", LabelSnapshot))
+}
+
+// findSnapshot returns the named snapshot, or nil if there is none
+func (m *Manager) findSnapshot(name string) (*Snapshot, error) {
+	snapshots, err := m.listSnapshots(fmt.Sprintf("label=%s=%s", LabelSnapshot, sanitizeName(name)))
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return &snapshots[0], nil
+}
+
+// listSnapshots lists this user's snapshot images matching filter
+func (m *Manager) listSnapshots(filter string) ([]Snapshot, error) {
+	cmd := GetDockerCommand("image", "ls", "-q", "--no-trunc",
+		"--filter", fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		"--filter", fmt.Sprintf("label=%s=%s", LabelUser, m.userName),
+		"--filter", filter)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("image_ls", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list snapshots")
+	}
+
+	ids := uniqueLines(string(output))
+	if len(ids) == 0 {
+		return []Snapshot{}, nil
+	}
+
+	cmd = GetDockerCommand(append([]string{"image", "inspect"}, ids...)...)
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("image_inspect", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to inspect snapshots")
+	}
+	return m.parseSnapshots(output)
+}
+
+// parseSnapshots reads `docker image inspect` output, newest snapshot first
+func (m *Manager) parseSnapshots(output []byte) ([]Snapshot, error) {
+	var images []snapshotImageInspect
+	if err := json.Unmarshal(output, &images); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected image inspect output: %v", err)
+	}
+
+	snapshots := make([]Snapshot, 0, len(images))
+	for _, image := range images {
+		name := image.Config.Labels[LabelSnapshot]
+		if name == "" {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:           name,
+			Image:          m.SnapshotImage(name),
+			SourceInstance: image.Config.Labels[LabelSnapshotSource],
+			Created:        image.Created,
+			SizeBytes:      image.Size,
+		})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Created.After(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+// uniqueLines returns the distinct non-empty lines of output in order
+func uniqueLines(output string) []string {
+	seen := make(map[string]bool)
+	lines := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// CloneSnapshot runs a new instance from a snapshot on hostPort, giving it its own copies of
+// the snapshot volumes. The clone keeps the snapshot's wwwroot until the caller updates it.
+func (m *Manager) CloneSnapshot(name, instance string, hostPort int) (*ManagedContainer, error) {
+	if strings.TrimSpace(instance) == "" {
+		return nil, errors.NewValidationError("instance", "instance name is required", instance)
+	}
+	instance = sanitizeName(instance)
+	if instance == m.GetInstanceName() {
+		return nil, errors.NewValidationError("instance", "must differ from the current instance", instance)
+	}
+
+	snapshot, err := m.findSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, errors.WrapWithContext(errors.ErrImageNotFound, "snapshot %s not found", name)
+	}
+
+	existing, err := m.listContainers(
+		fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		fmt.Sprintf("label=%s=%s", LabelInstance, instance),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, errors.NewValidationError("instance", "an instance with this name already exists", instance)
+	}
+
+	volumes, err := m.snapshotVolumes(snapshot.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"run", "-d",
+		"--name", ContainerName(instance),
+		"-p", fmt.Sprintf("%d:%d", hostPort, m.Adapter().ContainerPort),
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		"--label", fmt.Sprintf("%s=%s", LabelInstance, instance),
+	}
+	// A clone that fails partway leaves nothing behind: no copied volumes, no container
+	var copied []VolumeMount
+	cloned := false
+	defer func() {
+		if !cloned {
+			m.removeFailedClone(instance, copied)
+		}
+	}()
+
+	for i, volume := range volumes {
+		target := VolumeMount{Name: fmt.Sprintf("%s-data-%d", ContainerName(instance), i), Destination: volume.Destination}
+		labels := map[string]string{LabelInstance: instance}
+		// Listed before copying, as copyVolume creates the volume first
+		copied = append(copied, target)
+		if err := m.copyVolume(snapshot.Image, volume.Name, target.Name, labels); err != nil {
+			return nil, err
+		}
+		args = append(args, "-v", fmt.Sprintf("%s:%s", target.Name, target.Destination))
+	}
+	// The health check is part of the committed image; sidecars stay with the source instance
+	args = append(args, containerProxyArgs()...)
+	args = append(args, m.memoryArgs()...)
+	args = append(args, m.logLevelArgs()...)
+	args = append(args, m.restartArgs()...)
+	args = append(args, hostGatewayArgs()...)
+	args = append(args, snapshot.Image)
+
+	utils.LogInfo(fmt.Sprintf("Cloning snapshot %s into instance %s on port %d", snapshot.Name, instance, hostPort))
+	cmd := GetDockerCommand(args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", snapshot.Image, err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to run cloned instance")
+	}
+
+	containerID := strings.TrimSpace(string(output))
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "Docker returned invalid container ID: %s", containerID)
+	}
+
+	cloned = true
+	utils.LogInfo(fmt.Sprintf("Instance %s cloned from snapshot %s: %s", instance, snapshot.Name, containerID))
+	return &ManagedContainer{
+		ID:        containerID,
+		Name:      ContainerName(instance),
+		User:      m.userName,
+		Instance:  instance,
+		State:     "running",
+		HostPorts: []int{hostPort},
+	}, nil
+}
+
+// removeFailedClone removes what a failed CloneSnapshot created: the container `docker run`
+// may have created before failing to start it, then the copied volumes it would hold on to
+func (m *Manager) removeFailedClone(instance string, volumes []VolumeMount) {
+	containers, err := m.listContainers(
+		fmt.Sprintf("label=%s=%s", LabelApp, AppLabelValue),
+		fmt.Sprintf("label=%s=%s", LabelInstance, instance),
+	)
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to look for the container of failed clone %s: %v", instance, err))
+	}
+	for _, container := range containers {
+		if output, err := GetDockerCommand("rm", "-f", container.ID).CombinedOutput(); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to remove container %s of failed clone %s: %v: %s", container.ID, instance, err, strings.TrimSpace(string(output))))
+		}
+	}
+	m.RemoveVolumes(volumes)
+	utils.LogInfo(fmt.Sprintf("Removed %d volumes of failed clone %s", len(volumes), instance))
+}
+
+// DeleteSnapshot removes a snapshot image and its volumes; instances cloned from it keep
+// their own copies
+func (m *Manager) DeleteSnapshot(name string) error {
+	snapshot, err := m.findSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return errors.WrapWithContext(errors.ErrImageNotFound, "snapshot %s not found", name)
+	}
+
+	if err := m.removeSnapshotData(snapshot.Name, snapshot.Image); err != nil {
+		return err
+	}
+	utils.LogInfo(fmt.Sprintf("Snapshot %s deleted", snapshot.Name))
+	return nil
+}
+
+// removeSnapshotData removes the volumes and image of a snapshot, returning the first failure
+func (m *Manager) removeSnapshotData(name, image string) error {
+	var firstErr error
+	volumes, err := m.snapshotVolumes(name)
+	if err != nil {
+		firstErr = err
+	}
+	for _, volume := range volumes {
+		cmd := GetDockerCommand("volume", "rm", volume.Name)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			dockerErr := errors.NewDockerError("volume_rm", err).WithOutput(string(output))
+			utils.LogError(fmt.Sprintf("Failed to remove snapshot volume %s", volume.Name), dockerErr)
+			if firstErr == nil {
+				firstErr = errors.WrapWithContext(dockerErr, "failed to remove snapshot volume")
+			}
+		}
+	}
+
+	cmd := GetDockerCommand("image", "rm", image)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("image_rm", image, err).WithOutput(string(output))
+		if firstErr == nil {
+			firstErr = errors.WrapWithContext(dockerErr, "failed to remove snapshot image")
+		}
+	}
+	return firstErr
+}
+
+// snapshotVolumes returns the volume copies belonging to a snapshot
+func (m *Manager) snapshotVolumes(name string) ([]snapshotVolume, error) {
+	cmd := GetDockerCommand("volume", "ls",
+		"--filter", fmt.Sprintf("label=%s=%s", LabelUser, m.userName),
+		"--filter", fmt.Sprintf("label=%s=%s", LabelSnapshot, sanitizeName(name)),
+		"--format", fmt.Sprintf("{{.Name}}\t{{.Label %q}}", LabelSnapshotDestination))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerError("volume_ls", err).WithOutput(string(output))
+		return nil, errors.WrapWithContext(dockerErr, "failed to list snapshot volumes")
+	}
+	return parseSnapshotVolumes(string(output)), nil
+}
+
+// parseSnapshotVolumes parses tab-separated name and destination lines, sorted by name
+func parseSnapshotVolumes(output string) []snapshotVolume {
+	volumes := make([]snapshotVolume, 0)
+	for _, line := range strings.Split(output, "\n") {
+		name, destination, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || name == "" || destination == "" {
+			continue
+		}
+		volumes = append(volumes, snapshotVolume{Name: name, Destination: destination})
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes
+}
+
+// copyVolume creates the volume to (labelled as ours) and copies the contents of from into it,
+// using image as the helper so no extra image has to be pulled
+func (m *Manager) copyVolume(image, from, to string, labels map[string]string) error {
+	if err := m.createVolume(to, labels); err != nil {
+		return err
+	}
+
+	cmd := GetDockerCommand("run", "--rm",
+		"--user", "root",
+		"--entrypoint", "cp",
+		"-v", fmt.Sprintf("%s:%s:ro", from, copyFromDir),
+		"-v", fmt.Sprintf("%s:%s", to, copyToDir),
+		image, "-a", copyFromDir+"/.", copyToDir+"/")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", image, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to copy volume %s to %s", from, to)
+	}
+	utils.LogDebug(fmt.Sprintf("Copied volume %s to %s", from, to))
+	return nil
+}
+
+// createVolume creates a volume labelled as ours, with the extra labels
+func (m *Manager) createVolume(name string, labels map[string]string) error {
+	args := []string{"volume", "create",
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+	}
+	for key, value := range labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, value))
+	}
+	cmd := GetDockerCommand(append(args, name)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerError("volume_create", err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to create volume %s", name)
+	}
+	return nil
+}
+
+// pauseContainer freezes a container's processes
+func (m *Manager) pauseContainer(containerID string) error {
+	cmd := GetDockerCommand("pause", containerID)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("pause", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to pause container")
+	}
+	return nil
+}
+
+// unpauseContainer resumes a paused container
+func (m *Manager) unpauseContainer(containerID string) error {
+	cmd := GetDockerCommand("unpause", containerID)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("unpause", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to resume container")
+	}
+	return nil
+}
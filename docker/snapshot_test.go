@@ -0,0 +1,115 @@
+package docker
+
+import (
+	stderrors "errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"moodle-prototype-manager/utils"
+)
+
+func TestSnapshotImage(t *testing.T) {
+	manager := NewManager()
+	manager.userName = "alice"
+
+	if got := manager.SnapshotImage("Quiz Demo"); got != "moodle-prototype-snapshot-alice:quiz-demo" {
+		t.Errorf("unexpected snapshot image %q", got)
+	}
+	if got := manager.snapshotVolumeName("baseline", 1); got != "moodle-prototype-snapshot-alice-baseline-1" {
+		t.Errorf("unexpected snapshot volume %q", got)
+	}
+}
+
+func TestParseSnapshots(t *testing.T) {
+	manager := NewManager()
+	manager.userName = "alice"
+
+	output := `[
+  {"Id": "sha256:aaa", "Created": "2026-03-01T09:00:00Z", "Size": 1200,
+   "Config": {"Labels": {"app": "moodle-prototype-manager", "snapshot": "baseline", "snapshot.source": "alice"}}},
+  {"Id": "sha256:bbb", "Created": "2026-03-02T09:00:00Z", "Size": 1300,
+   "Config": {"Labels": {"app": "moodle-prototype-manager", "snapshot": "with-quiz", "snapshot.source": "demo"}}},
+  {"Id": "sha256:ccc", "Created": "2026-03-03T09:00:00Z", "Size": 1,
+   "Config": {"Labels": null}}
+]`
+	snapshots, err := manager.parseSnapshots([]byte(output))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected images without a snapshot label to be skipped, got %d", len(snapshots))
+	}
+	if snapshots[0].Name != "with-quiz" || snapshots[1].Name != "baseline" {
+		t.Errorf("Expected newest first, got %s then %s", snapshots[0].Name, snapshots[1].Name)
+	}
+	if snapshots[1].Image != "moodle-prototype-snapshot-alice:baseline" || snapshots[1].SourceInstance != "alice" || snapshots[1].SizeBytes != 1200 {
+		t.Errorf("Unexpected snapshot: %+v", snapshots[1])
+	}
+
+	if _, err := manager.parseSnapshots([]byte("not json")); err == nil {
+		t.Error("Expected an error for malformed output")
+	}
+}
+
+func TestParseSnapshotVolumes(t *testing.T) {
+	output := "moodle-prototype-snapshot-alice-baseline-1\t/var/www/moodledata\n" +
+		"moodle-prototype-snapshot-alice-baseline-0\t/var/lib/postgresql/data\n" +
+		"unlabelled\t\n\n"
+
+	volumes := parseSnapshotVolumes(output)
+	if len(volumes) != 2 {
+		t.Fatalf("Expected 2 volumes, got %+v", volumes)
+	}
+	if volumes[0].Destination != "/var/lib/postgresql/data" || volumes[1].Destination != "/var/www/moodledata" {
+		t.Errorf("Expected volumes sorted by name, got %+v", volumes)
+	}
+}
+
+// cloneRunner answers the docker commands of CloneSnapshot, failing the second volume copy
+type cloneRunner struct {
+	utils.ExecRunner
+	copies  int
+	removed []string
+}
+
+func (r *cloneRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	args := cmd.Args[1:]
+	switch {
+	case slices.Equal(args[:2], []string{"image", "ls"}):
+		return []byte("sha256:aaa\n"), nil
+	case slices.Equal(args[:2], []string{"image", "inspect"}):
+		return []byte(`[{"Id": "sha256:aaa", "Created": "2026-03-01T09:00:00Z", "Size": 1200,
+  "Config": {"Labels": {"app": "moodle-prototype-manager", "snapshot": "baseline", "snapshot.source": "alice"}}}]`), nil
+	case slices.Equal(args[:2], []string{"volume", "ls"}):
+		return []byte("snap-1\t/var/www/moodledata\nsnap-2\t/var/www/html\n"), nil
+	case slices.Equal(args[:2], []string{"volume", "rm"}):
+		r.removed = append(r.removed, args[2])
+		return nil, nil
+	case args[0] == "run" && slices.Contains(args, "cp"):
+		r.copies++
+		if r.copies == 2 {
+			return []byte("cp: no space left on device"), stderrors.New("exit status 1")
+		}
+	}
+	return nil, nil
+}
+
+func TestCloneSnapshotRemovesCopiedVolumesOnFailure(t *testing.T) {
+	runner := &cloneRunner{}
+	previous := utils.SetCommandRunner(runner)
+	defer utils.SetCommandRunner(previous)
+
+	manager := NewManager()
+	manager.userName = "alice"
+	manager.SetImageName("wenkhairu/moodle-prototype:502-stable")
+
+	if _, err := manager.CloneSnapshot("baseline", "copy", 8090); err == nil || !strings.Contains(err.Error(), "failed to copy volume") {
+		t.Fatalf("Expected the failed copy to be reported, got %v", err)
+	}
+	want := []string{"moodle-prototype-copy-data-0", "moodle-prototype-copy-data-1"}
+	if !slices.Equal(runner.removed, want) {
+		t.Errorf("Expected both copied volumes to be removed, got %v", runner.removed)
+	}
+}
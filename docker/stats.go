@@ -0,0 +1,294 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Default alert thresholds in percent
+const (
+	DefaultMemoryAlertPercent = 90
+	DefaultDiskAlertPercent   = 95
+	// alertRearmMargin is how far usage must drop below a threshold before it can alert again
+	alertRearmMargin = 5
+)
+
+// Resource alert kinds
+const (
+	AlertMemory = "memory"
+	AlertDisk   = "disk"
+)
+
+var statsSizePattern = regexp.MustCompile(`^([\d.]+)\s*([A-Za-z]*)$`)
+
+// ContainerStats is a point-in-time resource usage sample for a container
+type ContainerStats struct {
+	CPUPercent       float64   `json:"cpuPercent"`
+	MemoryUsageBytes int64     `json:"memoryUsageBytes"`
+	MemoryLimitBytes int64     `json:"memoryLimitBytes"`
+	MemoryPercent    float64   `json:"memoryPercent"`
+	DiskUsedBytes    int64     `json:"diskUsedBytes"`
+	DiskTotalBytes   int64     `json:"diskTotalBytes"`
+	DiskPercent      float64   `json:"diskPercent"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// AlertThresholds configures when resource alerts fire
+type AlertThresholds struct {
+	MemoryPercent float64 `json:"memoryPercent"`
+	DiskPercent   float64 `json:"diskPercent"`
+}
+
+// ResourceAlert is raised when usage crosses a threshold
+type ResourceAlert struct {
+	Kind      string    `json:"kind"`
+	Percent   float64   `json:"percent"`
+	Threshold float64   `json:"threshold"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// dockerStatsLine mirrors the fields of `docker stats --format '{{json .}}'`
+type dockerStatsLine struct {
+	CPUPerc  string `json:"CPUPerc"`
+	MemUsage string `json:"MemUsage"`
+	MemPerc  string `json:"MemPerc"`
+}
+
+// GetContainerStats samples CPU, memory and filesystem usage of a container
+func (m *Manager) GetContainerStats(containerID string) (*ContainerStats, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to GetContainerStats")
+	}
+
+	cmd := GetDockerCommand("stats", "--no-stream", "--format", "{{json .}}", containerID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.NewDockerErrorWithContainer("stats", containerID, err).WithOutput(string(output))
+	}
+
+	stats, err := parseDockerStats(output)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to parse stats for container %s", containerID)
+	}
+
+	// Filesystem usage of the Moodle code and data directories
+	if dfOutput, err := m.ExecInContainer(containerID, "df", "-Pk", m.moodleDir()); err == nil {
+		if used, total, ok := parseDiskFree(dfOutput); ok {
+			stats.DiskUsedBytes = used
+			stats.DiskTotalBytes = total
+			stats.DiskPercent = percentOf(used, total)
+		}
+	} else {
+		utils.LogDebug(fmt.Sprintf("Failed to read container disk usage: %v", err))
+	}
+
+	return stats, nil
+}
+
+// parseDockerStats decodes a single `docker stats` JSON line
+func parseDockerStats(output []byte) (*ContainerStats, error) {
+	var line dockerStatsLine
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &line); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected docker stats output: %v", err)
+	}
+
+	stats := &ContainerStats{Timestamp: time.Now()}
+	stats.CPUPercent = parsePercent(line.CPUPerc)
+	stats.MemoryPercent = parsePercent(line.MemPerc)
+
+	// MemUsage looks like "512.3MiB / 1.944GiB"
+	if parts := strings.Split(line.MemUsage, "/"); len(parts) == 2 {
+		stats.MemoryUsageBytes = parseStatsSize(parts[0])
+		stats.MemoryLimitBytes = parseStatsSize(parts[1])
+	}
+	if stats.MemoryPercent == 0 {
+		stats.MemoryPercent = percentOf(stats.MemoryUsageBytes, stats.MemoryLimitBytes)
+	}
+
+	return stats, nil
+}
+
+// parseStatsSize converts docker stats sizes such as "1.944GiB" or "12kB" to bytes
+func parseStatsSize(value string) int64 {
+	matches := statsSizePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0
+	}
+
+	unit := strings.ToUpper(strings.Replace(matches[2], "i", "", 1))
+	if unit == "B" {
+		unit = ""
+	}
+	return parseSize(matches[1], unit)
+}
+
+// parsePercent converts "12.34%" to 12.34
+func parsePercent(value string) float64 {
+	percent, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	return percent
+}
+
+// parseDiskFree reads used and total bytes from `df -Pk` output
+func parseDiskFree(output string) (used, total int64, ok bool) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, 0, false
+	}
+
+	totalKB, err1 := strconv.ParseInt(fields[1], 10, 64)
+	usedKB, err2 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return usedKB * 1024, totalKB * 1024, true
+}
+
+// percentOf returns part as a percentage of whole
+func percentOf(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// StatsCollector periodically samples container stats and raises threshold alerts
+type StatsCollector struct {
+	manager     *Manager
+	containerID func() (string, error)
+	onAlert     func(ResourceAlert)
+
+	mu         sync.Mutex
+	thresholds AlertThresholds
+	alerting   map[string]bool
+	stopChan   chan struct{}
+}
+
+// NewStatsCollector creates a collector; containerID resolves the running container on every tick
+func NewStatsCollector(manager *Manager, containerID func() (string, error), onAlert func(ResourceAlert)) *StatsCollector {
+	return &StatsCollector{
+		manager:     manager,
+		containerID: containerID,
+		onAlert:     onAlert,
+		thresholds: AlertThresholds{
+			MemoryPercent: DefaultMemoryAlertPercent,
+			DiskPercent:   DefaultDiskAlertPercent,
+		},
+		alerting: make(map[string]bool),
+	}
+}
+
+// SetThresholds updates the alert thresholds
+func (sc *StatsCollector) SetThresholds(thresholds AlertThresholds) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.thresholds = thresholds
+	sc.alerting = make(map[string]bool)
+}
+
+// Start begins sampling every interval, replacing any previous schedule
+func (sc *StatsCollector) Start(interval time.Duration) {
+	sc.Stop()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	stopChan := make(chan struct{})
+	sc.stopChan = stopChan
+
+	utils.LogInfo(fmt.Sprintf("Starting resource stats collector (interval: %v)", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sc.tick()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts sampling
+func (sc *StatsCollector) Stop() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.stopChan != nil {
+		close(sc.stopChan)
+		sc.stopChan = nil
+		utils.LogInfo("Resource stats collector stopped")
+	}
+}
+
+// tick samples stats if a container is running
+func (sc *StatsCollector) tick() {
+	containerID, err := sc.containerID()
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Skipping stats sample: %v", err))
+		return
+	}
+
+	stats, err := sc.manager.GetContainerStats(containerID)
+	if err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to sample container stats: %v", err))
+		return
+	}
+
+	for _, alert := range sc.record(stats) {
+		utils.LogWarning(alert.Message)
+		if sc.onAlert != nil {
+			sc.onAlert(alert)
+		}
+	}
+}
+
+// record checks a sample and returns alerts for newly crossed thresholds
+func (sc *StatsCollector) record(stats *ContainerStats) []ResourceAlert {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	alerts := make([]ResourceAlert, 0)
+	check := func(kind string, percent, threshold float64, message string) {
+		if threshold <= 0 {
+			return
+		}
+		switch {
+		case percent >= threshold && !sc.alerting[kind]:
+			sc.alerting[kind] = true
+			alerts = append(alerts, ResourceAlert{
+				Kind:      kind,
+				Percent:   percent,
+				Threshold: threshold,
+				Message:   fmt.Sprintf(message, percent, threshold),
+				Timestamp: stats.Timestamp,
+			})
+		case percent < threshold-alertRearmMargin:
+			sc.alerting[kind] = false
+		}
+	}
+
+	check(AlertMemory, stats.MemoryPercent, sc.thresholds.MemoryPercent,
+		"Container memory usage is %.1f%% of its limit (threshold %.0f%%); MariaDB may be OOM-killed")
+	check(AlertDisk, stats.DiskPercent, sc.thresholds.DiskPercent,
+		"Container disk usage is %.1f%% (threshold %.0f%%)")
+
+	return alerts
+}
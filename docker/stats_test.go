@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDockerStats(t *testing.T) {
+	output := []byte(`{"CPUPerc":"3.25%","MemUsage":"512MiB / 2GiB","MemPerc":"25.00%","Name":"moodle"}`)
+
+	stats, err := parseDockerStats(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if stats.CPUPercent != 3.25 {
+		t.Errorf("Expected CPU 3.25, got %v", stats.CPUPercent)
+	}
+	if stats.MemoryUsageBytes != 512*1024*1024 {
+		t.Errorf("Unexpected memory usage: %d", stats.MemoryUsageBytes)
+	}
+	if stats.MemoryLimitBytes != 2*1024*1024*1024 {
+		t.Errorf("Unexpected memory limit: %d", stats.MemoryLimitBytes)
+	}
+	if stats.MemoryPercent != 25 {
+		t.Errorf("Expected memory 25%%, got %v", stats.MemoryPercent)
+	}
+}
+
+func TestParseDiskFree(t *testing.T) {
+	output := "Filesystem     1024-blocks    Used Available Capacity Mounted on\noverlay           1000000  960000     40000      96% /\n"
+
+	used, total, ok := parseDiskFree(output)
+	if !ok {
+		t.Fatal("Expected df output to parse")
+	}
+	if used != 960000*1024 || total != 1000000*1024 {
+		t.Errorf("Unexpected values: used=%d total=%d", used, total)
+	}
+}
+
+func TestStatsCollectorAlertsOncePerCrossing(t *testing.T) {
+	collector := NewStatsCollector(nil, nil, nil)
+
+	sample := func(memory float64) []ResourceAlert {
+		return collector.record(&ContainerStats{MemoryPercent: memory, Timestamp: time.Now()})
+	}
+
+	if alerts := sample(50); len(alerts) != 0 {
+		t.Errorf("Expected no alerts below threshold, got %d", len(alerts))
+	}
+	if alerts := sample(93); len(alerts) != 1 || alerts[0].Kind != AlertMemory {
+		t.Fatalf("Expected a memory alert, got %+v", alerts)
+	}
+	if alerts := sample(95); len(alerts) != 0 {
+		t.Errorf("Expected no repeat alert while still above threshold, got %d", len(alerts))
+	}
+	sample(80)
+	if alerts := sample(92); len(alerts) != 1 {
+		t.Errorf("Expected alert to re-arm after usage dropped, got %d", len(alerts))
+	}
+}
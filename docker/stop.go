@@ -0,0 +1,159 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// DefaultStopTimeout matches docker's own grace period before SIGKILL
+const DefaultStopTimeout = 10 * time.Second
+
+const (
+	// killWaitTimeout bounds the wait for the container to exit after SIGKILL
+	killWaitTimeout = 10 * time.Second
+	// stopPollInterval is how often the container state is checked while stopping
+	stopPollInterval = 500 * time.Millisecond
+	// commandGrace is added to CLI deadlines so a hung daemon cannot block shutdown
+	commandGrace = 15 * time.Second
+)
+
+// Stop escalation stages
+const (
+	StopStageTerminating = "terminating"
+	StopStageWaiting     = "waiting"
+	StopStageKilling     = "killing"
+	StopStageStopped     = "stopped"
+)
+
+// StopProgress reports the current stage of a staged container stop
+type StopProgress struct {
+	Stage   string  `json:"stage"`
+	Elapsed float64 `json:"elapsedSeconds"`
+	Timeout float64 `json:"timeoutSeconds"`
+	Message string  `json:"message"`
+}
+
+// SetStopTimeout sets how long containers get to shut down before being killed
+func (m *Manager) SetStopTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	m.stopTimeout = timeout
+}
+
+// GetStopTimeout returns the grace period before containers are killed
+func (m *Manager) GetStopTimeout() time.Duration {
+	if m.stopTimeout <= 0 {
+		return DefaultStopTimeout
+	}
+	return m.stopTimeout
+}
+
+// StopContainerWithEscalation sends SIGTERM, waits for the stop timeout, then sends SIGKILL.
+// progressCallback, if not nil, receives each stage so the UI can show shutdown progress.
+func (m *Manager) StopContainerWithEscalation(containerID string, progressCallback func(StopProgress)) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to StopContainerWithEscalation")
+	}
+
+	timeout := m.GetStopTimeout()
+	start := time.Now()
+	report := func(stage, message string) {
+		utils.LogInfo(fmt.Sprintf("Stopping container %s: %s", containerID, message))
+		if progressCallback != nil {
+			progressCallback(StopProgress{
+				Stage:   stage,
+				Elapsed: time.Since(start).Seconds(),
+				Timeout: timeout.Seconds(),
+				Message: message,
+			})
+		}
+	}
+
+	report(StopStageTerminating, "sending SIGTERM")
+	if err := m.signalContainer(containerID, "SIGTERM"); err != nil {
+		utils.LogWarning(fmt.Sprintf("SIGTERM failed, escalating: %v", err))
+	} else if m.waitForExit(containerID, timeout, func(elapsed time.Duration) {
+		report(StopStageWaiting, fmt.Sprintf("waiting for shutdown (%ds of %ds)", int(elapsed.Seconds()), int(timeout.Seconds())))
+	}) {
+		report(StopStageStopped, "container stopped gracefully")
+		return nil
+	}
+
+	report(StopStageKilling, fmt.Sprintf("container did not stop within %v, sending SIGKILL", timeout))
+	if err := m.signalContainer(containerID, "SIGKILL"); err != nil {
+		return errors.WrapWithContext(err, "failed to kill container after stop timeout")
+	}
+	if !m.waitForExit(containerID, killWaitTimeout, nil) {
+		return errors.WrapWithContext(errors.ErrOperationInProgress, "container %s is still running after SIGKILL", containerID)
+	}
+
+	report(StopStageStopped, "container killed")
+	return nil
+}
+
+// signalContainer sends a signal to the container's main process
+func (m *Manager) signalContainer(containerID, signal string) error {
+	cmd := GetDockerCommand("kill", "--signal", signal, containerID)
+	output, err := runWithTimeout(cmd, commandGrace)
+	if err != nil {
+		return errors.NewDockerErrorWithContainer("kill", containerID, err).WithOutput(string(output))
+	}
+	return nil
+}
+
+// waitForExit polls until the container stops or timeout elapses, reporting elapsed time each second
+func (m *Manager) waitForExit(containerID string, timeout time.Duration, onTick func(time.Duration)) bool {
+	start := time.Now()
+	lastReport := time.Duration(0)
+
+	for {
+		running, err := m.IsContainerRunning(containerID)
+		if err == nil && !running {
+			return true
+		}
+
+		elapsed := time.Since(start)
+		if elapsed >= timeout {
+			return false
+		}
+		if onTick != nil && elapsed-lastReport >= time.Second {
+			lastReport = elapsed
+			onTick(elapsed)
+		}
+		time.Sleep(stopPollInterval)
+	}
+}
+
+// stopTimeoutArgs returns the `docker stop -t` flags for the configured timeout
+func (m *Manager) stopTimeoutArgs() []string {
+	return []string{"-t", strconv.Itoa(int(m.GetStopTimeout().Seconds()))}
+}
+
+// runWithTimeout runs a command, killing it if it does not finish within timeout
+func runWithTimeout(cmd *Command, timeout time.Duration) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return output.Bytes(), err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return output.Bytes(), errors.WrapWithContext(errors.ErrConnectionTimeout, "docker command did not finish within %v", timeout)
+	}
+}
@@ -0,0 +1,265 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// PHPUnitInitScript prepares the PHPUnit test environment, relative to MoodleDir
+	PHPUnitInitScript = "admin/tool/phpunit/cli/init.php"
+	// BehatInitScript prepares the Behat test environment, relative to MoodleDir
+	BehatInitScript = "admin/tool/behat/cli/init.php"
+
+	// SeleniumImage provides the headless browser used by Behat
+	SeleniumImage = "selenium/standalone-chrome:latest"
+	// SeleniumContainerName is the name of the headless browser container
+	SeleniumContainerName = ContainerNamePrefix + "-selenium"
+	// TestNetworkName connects the Moodle and Selenium containers for Behat
+	TestNetworkName = ContainerNamePrefix + "-tests"
+	// behatHostAlias is the name Selenium uses to reach Moodle on the test network
+	behatHostAlias = "moodle-behat"
+)
+
+// Test suites
+const (
+	SuitePHPUnit = "phpunit"
+	SuiteBehat   = "behat"
+)
+
+var phpunitSummaryRegex = regexp.MustCompile(`^(OK \(.*\)|Tests: \d+.*|No tests executed!)$`)
+
+// testConfigScript adds the PHPUnit and Behat settings to config.php if they are missing
+const testConfigScript = `<?php
+$file = 'config.php';
+$config = file_get_contents($file);
+$marker = "require_once(__DIR__ . '/lib/setup.php');";
+$settings = [
+    'phpunit_prefix'   => "\$CFG->phpunit_prefix = 'phpu_';",
+    'phpunit_dataroot' => "\$CFG->phpunit_dataroot = '/var/www/phpunitdata';",
+    'behat_prefix'     => "\$CFG->behat_prefix = 'bht_';",
+    'behat_dataroot'   => "\$CFG->behat_dataroot = '/var/www/behatdata';",
+    'behat_wwwroot'    => "\$CFG->behat_wwwroot = 'http://%s:%d';",
+    'behat_profiles'   => "\$CFG->behat_profiles = ['default' => ['browser' => 'chrome', 'wd_host' => 'http://%s:4444/wd/hub']];",
+];
+$added = '';
+foreach ($settings as $name => $line) {
+    if (strpos($config, '$CFG->' . $name) === false) {
+        $added .= $line . "\n";
+    }
+}
+if ($added !== '') {
+    if (strpos($config, $marker) === false) {
+        fwrite(STDERR, "setup.php include not found in config.php\n");
+        exit(1);
+    }
+    file_put_contents($file, str_replace($marker, $added . $marker, $config));
+}
+echo "CONFIGURED\n";
+`
+
+// TestRunResult summarises a PHPUnit or Behat run
+type TestRunResult struct {
+	Component string `json:"component"`
+	Suite     string `json:"suite"`
+	Passed    bool   `json:"passed"`
+	Summary   string `json:"summary"`
+	Duration  string `json:"duration"`
+	Output    string `json:"output"`
+}
+
+// RunMoodleTests initialises and runs PHPUnit (and optionally Behat) for a plugin component.
+// Every output line is passed to outputCallback as it is produced.
+func (m *Manager) RunMoodleTests(containerID, component string, includeBehat bool, outputCallback func(suite, line string)) ([]TestRunResult, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to RunMoodleTests")
+	}
+	if _, err := PluginInstallDir(component); err != nil {
+		return nil, err
+	}
+
+	if err := m.prepareTestEnvironment(containerID); err != nil {
+		return nil, err
+	}
+
+	results := make([]TestRunResult, 0, 2)
+
+	phpunit, err := m.runTestSuite(containerID, component, SuitePHPUnit, outputCallback,
+		[]string{PHPBinary, m.moodleDir() + "/" + PHPUnitInitScript},
+		[]string{m.moodleDir() + "/vendor/bin/phpunit", "--testsuite", component + "_testsuite"})
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, *phpunit)
+
+	if includeBehat {
+		if err := m.startSelenium(containerID); err != nil {
+			return results, err
+		}
+		defer m.stopSelenium(containerID)
+
+		behat, err := m.runTestSuite(containerID, component, SuiteBehat, outputCallback,
+			[]string{PHPBinary, m.moodleDir() + "/" + BehatInitScript},
+			[]string{PHPBinary, m.moodleDir() + "/admin/tool/behat/cli/run.php", "--tags=@" + component})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *behat)
+	}
+
+	return results, nil
+}
+
+// prepareTestEnvironment adds test settings to config.php and installs dev dependencies if needed
+func (m *Manager) prepareTestEnvironment(containerID string) error {
+	script := fmt.Sprintf(testConfigScript, behatHostAlias, m.Adapter().ContainerPort, SeleniumContainerName)
+	if output, err := m.RunPHPScript(containerID, script); err != nil || !strings.Contains(output, "CONFIGURED") {
+		if err == nil {
+			err = errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output: %s", output)
+		}
+		return errors.WrapWithContext(err, "failed to add test settings to config.php")
+	}
+
+	// PHPUnit and Behat come from composer dev dependencies, which release images may not ship
+	if _, err := m.ExecInContainer(containerID, "test", "-x", m.moodleDir()+"/vendor/bin/phpunit"); err != nil {
+		utils.LogInfo("Installing Moodle composer dev dependencies")
+		if _, err := m.ExecInContainer(containerID, "sh", "-c", "cd "+m.moodleDir()+" && composer install --no-interaction"); err != nil {
+			return errors.WrapWithContext(err, "PHPUnit is not installed and composer install failed")
+		}
+	}
+	return nil
+}
+
+// runTestSuite initialises a suite and runs it, streaming output
+func (m *Manager) runTestSuite(containerID, component, suite string, outputCallback func(suite, line string), initCommand, runCommand []string) (*TestRunResult, error) {
+	forward := func(line string) {
+		if outputCallback != nil {
+			outputCallback(suite, line)
+		}
+	}
+
+	forward(fmt.Sprintf("Initialising %s environment (this can take several minutes the first time)", suite))
+	if err := m.ExecStream(containerID, forward, initCommand...); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to initialise %s", suite)
+	}
+
+	start := time.Now()
+	var output strings.Builder
+	summary := ""
+	runErr := m.ExecStream(containerID, func(line string) {
+		output.WriteString(line + "\n")
+		if phpunitSummaryRegex.MatchString(strings.TrimSpace(line)) || strings.Contains(line, "scenarios (") {
+			summary = strings.TrimSpace(line)
+		}
+		forward(line)
+	}, runCommand...)
+
+	// A failing test run exits non-zero; only treat it as an error if nothing ran
+	if runErr != nil && output.Len() == 0 {
+		return nil, errors.WrapWithContext(runErr, "failed to run %s", suite)
+	}
+
+	result := &TestRunResult{
+		Component: component,
+		Suite:     suite,
+		Passed:    runErr == nil,
+		Summary:   summary,
+		Duration:  time.Since(start).Round(time.Second).String(),
+		Output:    output.String(),
+	}
+	utils.LogInfo(fmt.Sprintf("%s run for %s finished (passed: %v): %s", suite, component, result.Passed, summary))
+	return result, nil
+}
+
+// ExecStream runs a command in the container from the Moodle code root, calling lineCallback for each output line
+func (m *Manager) ExecStream(containerID string, lineCallback func(string), command ...string) error {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return errors.WrapWithContext(err, "invalid container ID provided to ExecStream")
+	}
+	if len(command) == 0 {
+		return errors.NewValidationError("command", "no command provided to ExecStream", nil)
+	}
+
+	args := append([]string{"exec", "-w", m.moodleDir(), containerID}, command...)
+	cmd := GetDockerCommand(args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.NewDockerErrorWithContainer("exec_setup", containerID, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.NewDockerErrorWithContainer("exec_setup", containerID, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errors.NewDockerErrorWithContainer("exec_start", containerID, err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	scan := func(reader io.Reader) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			mu.Lock()
+			lineCallback(scanner.Text())
+			mu.Unlock()
+		}
+	}
+	wg.Add(2)
+	go scan(stdout)
+	go scan(stderr)
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return errors.NewDockerErrorWithContainer("exec", containerID, err)
+	}
+	return nil
+}
+
+// startSelenium runs a headless Chrome container on a network shared with Moodle
+func (m *Manager) startSelenium(containerID string) error {
+	utils.LogInfo("Starting headless browser for Behat")
+
+	if err := m.EnsureNetwork(TestNetworkName); err != nil {
+		return err
+	}
+	if err := m.ConnectNetwork(TestNetworkName, containerID, behatHostAlias); err != nil {
+		return err
+	}
+	// Ignore errors; a browser left over from an interrupted run is replaced
+	GetDockerCommand("rm", "-f", SeleniumContainerName).CombinedOutput()
+
+	cmd := GetDockerCommand("run", "-d", "--name", SeleniumContainerName,
+		"--network", TestNetworkName, "--shm-size", "2g",
+		"--label", fmt.Sprintf("%s=%s", LabelApp, AppLabelValue),
+		"--label", fmt.Sprintf("%s=%s", LabelUser, m.userName),
+		SeleniumImage)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		dockerErr := errors.NewDockerErrorWithImage("run", SeleniumImage, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to start headless browser for Behat")
+	}
+	return nil
+}
+
+// stopSelenium removes the headless browser and disconnects Moodle from the test network
+func (m *Manager) stopSelenium(containerID string) {
+	if output, err := GetDockerCommand("rm", "-f", SeleniumContainerName).CombinedOutput(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to remove headless browser container: %v (%s)", err, output))
+	}
+	m.DisconnectNetwork(TestNetworkName, containerID)
+	if err := m.RemoveNetwork(TestNetworkName); err != nil {
+		utils.LogDebug(fmt.Sprintf("Test network kept: %v", err))
+	}
+}
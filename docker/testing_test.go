@@ -0,0 +1,18 @@
+package docker
+
+import "testing"
+
+func TestPHPUnitSummaryRegex(t *testing.T) {
+	tests := map[string]bool{
+		"OK (12 tests, 30 assertions)":                     true,
+		"Tests: 5, Assertions: 10, Failures: 1.":           true,
+		"No tests executed!":                               true,
+		"PHPUnit 9.6.13 by Sebastian Bergmann and others.": false,
+	}
+
+	for line, expected := range tests {
+		if got := phpunitSummaryRegex.MatchString(line); got != expected {
+			t.Errorf("Match(%q) = %v, expected %v", line, got, expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"encoding/json"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+)
+
+// DockerVersion identifies the Docker client and the daemon it talks to
+type DockerVersion struct {
+	Client string `json:"client"`
+	// Server fields are empty when the daemon is not reachable
+	Server     string `json:"server,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	OS         string `json:"os,omitempty"`
+	Arch       string `json:"arch,omitempty"`
+	// Platform is the daemon's product name, e.g. "Docker Desktop 4.30.0 (149282)"
+	Platform string `json:"platform,omitempty"`
+}
+
+// dockerVersionOutput mirrors the fields of `docker version --format '{{json .}}'`
+type dockerVersionOutput struct {
+	Client *struct {
+		Version string `json:"Version"`
+	} `json:"Client"`
+	Server *struct {
+		Version    string `json:"Version"`
+		APIVersion string `json:"ApiVersion"`
+		Os         string `json:"Os"`
+		Arch       string `json:"Arch"`
+		Platform   struct {
+			Name string `json:"Name"`
+		} `json:"Platform"`
+	} `json:"Server"`
+}
+
+// GetDockerVersion returns the client and daemon versions. When the daemon is down Docker
+// still prints the client part, which is returned together with the error.
+func GetDockerVersion() (*DockerVersion, error) {
+	cmd := GetDockerCommand("version", "--format", "{{json .}}")
+	output, err := cmd.Output()
+	version, parseErr := parseDockerVersion(output)
+	if err != nil {
+		return version, errors.NewDockerError("version", err).WithOutput(string(output))
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return version, nil
+}
+
+// parseDockerVersion decodes `docker version` JSON output
+func parseDockerVersion(output []byte) (*DockerVersion, error) {
+	var raw dockerVersionOutput
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &raw); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected docker version output: %v", err)
+	}
+
+	version := &DockerVersion{}
+	if raw.Client != nil {
+		version.Client = raw.Client.Version
+	}
+	if raw.Server != nil {
+		version.Server = raw.Server.Version
+		version.APIVersion = raw.Server.APIVersion
+		version.OS = raw.Server.Os
+		version.Arch = raw.Server.Arch
+		version.Platform = raw.Server.Platform.Name
+	}
+	return version, nil
+}
@@ -0,0 +1,30 @@
+package docker
+
+import "testing"
+
+func TestParseDockerVersion(t *testing.T) {
+	output := []byte(`{"Client":{"Version":"26.1.1","ApiVersion":"1.45","Os":"darwin"},"Server":{"Platform":{"Name":"Docker Desktop 4.30.0 (149282)"},"Version":"26.1.1","ApiVersion":"1.45","Os":"linux","Arch":"arm64"}}`)
+
+	version, err := parseDockerVersion(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := DockerVersion{Client: "26.1.1", Server: "26.1.1", APIVersion: "1.45", OS: "linux", Arch: "arm64", Platform: "Docker Desktop 4.30.0 (149282)"}
+	if *version != want {
+		t.Errorf("Expected %+v, got %+v", want, *version)
+	}
+}
+
+func TestParseDockerVersionWithoutDaemon(t *testing.T) {
+	version, err := parseDockerVersion([]byte(`{"Client":{"Version":"26.1.1"},"Server":null}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version.Client != "26.1.1" || version.Server != "" {
+		t.Errorf("Expected only the client version, got %+v", version)
+	}
+
+	if _, err := parseDockerVersion([]byte("Cannot connect to the Docker daemon")); err == nil {
+		t.Error("Expected an error for non-JSON output")
+	}
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+)
+
+// VirtualizationStatus reports Windows WSL2 and hardware virtualization prerequisites
+type VirtualizationStatus struct {
+	// Applicable is false on platforms where these checks do not apply
+	Applicable            bool     `json:"applicable"`
+	WSLInstalled          bool     `json:"wslInstalled"`
+	WSL2Default           bool     `json:"wsl2Default"`
+	DockerDistroOnWSL2    bool     `json:"dockerDistroOnWsl2"`
+	VirtualizationEnabled bool     `json:"virtualizationEnabled"`
+	HypervisorPresent     bool     `json:"hypervisorPresent"`
+	Hints                 []string `json:"hints"`
+}
+
+// OK reports whether every prerequisite is satisfied (always true when not applicable)
+func (v *VirtualizationStatus) OK() bool {
+	if v == nil || !v.Applicable {
+		return true
+	}
+	return v.WSLInstalled && v.DockerDistroOnWSL2 && (v.VirtualizationEnabled || v.HypervisorPresent)
+}
+
+// addHints fills in remediation hints for failed checks
+func (v *VirtualizationStatus) addHints() {
+	v.Hints = make([]string, 0)
+	if !v.VirtualizationEnabled && !v.HypervisorPresent {
+		v.Hints = append(v.Hints, "Enable hardware virtualization (Intel VT-x / AMD-V) in your BIOS/UEFI settings")
+	}
+	if !v.WSLInstalled {
+		v.Hints = append(v.Hints, "Install WSL2 by running 'wsl --install' in an administrator PowerShell, then restart")
+	} else if !v.WSL2Default {
+		v.Hints = append(v.Hints, "Set WSL2 as the default by running 'wsl --set-default-version 2'")
+	}
+	if v.WSLInstalled && !v.DockerDistroOnWSL2 {
+		v.Hints = append(v.Hints, "Enable 'Use the WSL 2 based engine' in Docker Desktop settings and restart Docker Desktop")
+	}
+}
+
+// decodeWSLOutput converts wsl.exe output, which is UTF-16LE on most Windows builds, to a string
+func decodeWSLOutput(output []byte) string {
+	if len(output) >= 2 && bytes.Count(output, []byte{0}) >= len(output)/4 {
+		if len(output)%2 != 0 {
+			output = output[:len(output)-1]
+		}
+		units := make([]uint16, 0, len(output)/2)
+		for i := 0; i+1 < len(output); i += 2 {
+			units = append(units, uint16(output[i])|uint16(output[i+1])<<8)
+		}
+		return strings.TrimPrefix(string(utf16.Decode(units)), "\ufeff")
+	}
+	return string(output)
+}
+
+// parseWSLDefaultVersion reads "Default Version: 2" from `wsl --status`
+func parseWSLDefaultVersion(status string) int {
+	for _, line := range strings.Split(status, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(strings.ToLower(line), "default version:") {
+			version := strings.TrimSpace(line[len("default version:"):])
+			if version == "2" {
+				return 2
+			}
+			if version == "1" {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// parseWSLDistroVersions parses `wsl -l -v` into a distro name -> WSL version map
+func parseWSLDistroVersions(list string) map[string]string {
+	distros := make(map[string]string)
+	for i, line := range strings.Split(list, "\n") {
+		if i == 0 {
+			continue // header: NAME STATE VERSION
+		}
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if len(fields) >= 3 {
+			distros[strings.ToLower(fields[0])] = fields[len(fields)-1]
+		}
+	}
+	return distros
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package docker
+
+// CheckVirtualization is a no-op outside Windows, where WSL2 is not involved
+func CheckVirtualization() *VirtualizationStatus {
+	return &VirtualizationStatus{Applicable: false, Hints: make([]string, 0)}
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestDecodeWSLOutput(t *testing.T) {
+	// "Default Version: 2" encoded as UTF-16LE, as printed by wsl.exe
+	text := "Default Version: 2\r\n"
+	encoded := make([]byte, 0, len(text)*2)
+	for _, r := range text {
+		encoded = append(encoded, byte(r), 0)
+	}
+
+	decoded := decodeWSLOutput(encoded)
+	if parseWSLDefaultVersion(decoded) != 2 {
+		t.Errorf("Expected default version 2 from %q", decoded)
+	}
+
+	if parseWSLDefaultVersion("Default Version: 1") != 1 {
+		t.Error("Expected default version 1 from plain output")
+	}
+}
+
+func TestParseWSLDistroVersions(t *testing.T) {
+	list := "  NAME                   STATE           VERSION\n" +
+		"* Ubuntu                 Running         2\n" +
+		"  docker-desktop         Running         2\n" +
+		"  legacy                 Stopped         1\n"
+
+	distros := parseWSLDistroVersions(list)
+	if distros["docker-desktop"] != "2" || distros["ubuntu"] != "2" || distros["legacy"] != "1" {
+		t.Errorf("Unexpected distros: %v", distros)
+	}
+}
+
+func TestVirtualizationHints(t *testing.T) {
+	status := &VirtualizationStatus{Applicable: true}
+	status.addHints()
+
+	if status.OK() {
+		t.Error("Status with nothing installed should not be OK")
+	}
+	if len(status.Hints) != 2 {
+		t.Errorf("Expected virtualization and WSL install hints, got %v", status.Hints)
+	}
+}
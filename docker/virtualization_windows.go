@@ -0,0 +1,67 @@
+//go:build windows
+// +build windows
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// virtualizationQuery reads firmware virtualization and hypervisor presence via CIM
+const virtualizationQuery = "$p = Get-CimInstance Win32_Processor | Select-Object -First 1; " +
+	"$c = Get-CimInstance Win32_ComputerSystem; " +
+	"Write-Output \"firmware=$($p.VirtualizationFirmwareEnabled)\"; " +
+	"Write-Output \"hypervisor=$($c.HypervisorPresent)\""
+
+// CheckVirtualization verifies WSL2 and virtualization prerequisites for Docker Desktop
+func CheckVirtualization() *VirtualizationStatus {
+	utils.LogDebug("Starting WSL2/virtualization checks...")
+	status := &VirtualizationStatus{Applicable: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	if output, err := runHidden(ctx, "wsl", "--status"); err == nil {
+		status.WSLInstalled = true
+		status.WSL2Default = parseWSLDefaultVersion(decodeWSLOutput(output)) == 2
+	} else {
+		utils.LogDebug(fmt.Sprintf("wsl --status failed: %v", err))
+	}
+
+	if status.WSLInstalled {
+		if output, err := runHidden(ctx, "wsl", "-l", "-v"); err == nil {
+			distros := parseWSLDistroVersions(decodeWSLOutput(output))
+			status.DockerDistroOnWSL2 = distros["docker-desktop"] == "2"
+		}
+	}
+
+	if output, err := runHidden(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", virtualizationQuery); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.EqualFold(line, "firmware=True"):
+				status.VirtualizationEnabled = true
+			case strings.EqualFold(line, "hypervisor=True"):
+				status.HypervisorPresent = true
+			}
+		}
+	} else {
+		utils.LogDebug(fmt.Sprintf("Virtualization query failed: %v", err))
+	}
+
+	status.addHints()
+	utils.LogInfo(fmt.Sprintf("Virtualization check: WSL=%v WSL2Default=%v DockerOnWSL2=%v VT=%v Hypervisor=%v",
+		status.WSLInstalled, status.WSL2Default, status.DockerDistroOnWSL2, status.VirtualizationEnabled, status.HypervisorPresent))
+	return status
+}
+
+// runHidden runs a command without flashing a console window
+func runHidden(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return utils.Commands().Output(exec.CommandContext(ctx, name, args...))
+}
@@ -0,0 +1,42 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// enableWebServicesScript switches on web services, the REST protocol and the mobile app
+// service, which is the one service site admins may get a token for with their password
+const enableWebServicesScript = `<?php
+define('CLI_SCRIPT', true);
+require('config.php');
+
+set_config('enablewebservices', 1);
+set_config('enablemobilewebservice', 1);
+
+$protocols = empty($CFG->webserviceprotocols) ? [] : explode(',', $CFG->webserviceprotocols);
+if (!in_array('rest', $protocols)) {
+    $protocols[] = 'rest';
+    set_config('webserviceprotocols', implode(',', $protocols));
+}
+$DB->set_field('external_services', 'enabled', 1, ['shortname' => 'moodle_mobile_app']);
+
+echo "ENABLED\n";
+`
+
+// EnableWebServices prepares Moodle for the web service client in the moodle package
+func (m *Manager) EnableWebServices(containerID string) error {
+	output, err := m.RunPHPScript(containerID, enableWebServicesScript)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to enable web services")
+	}
+	if !strings.Contains(output, "ENABLED") {
+		return errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while enabling web services: %s", output)
+	}
+
+	utils.LogInfo(fmt.Sprintf("Web services enabled in container %s", containerID))
+	return nil
+}
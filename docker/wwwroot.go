@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// PurgeCachesScript clears Moodle caches, relative to MoodleDir
+const PurgeCachesScript = "admin/cli/purge_caches.php"
+
+// configureWWWRootScript rewrites wwwroot and sslproxy in config.php; values arrive base64-encoded
+const configureWWWRootScript = `<?php
+$file = 'config.php';
+$config = file_get_contents($file);
+$wwwroot = base64_decode('%s');
+$sslproxy = %t;
+
+$config = preg_replace_callback('/\$CFG->wwwroot\s*=\s*[^;]+;/', function() use ($wwwroot) {
+    return '$CFG->wwwroot = ' . var_export($wwwroot, true) . ';';
+}, $config, 1, $count);
+if (!$count) {
+    fwrite(STDERR, "wwwroot not found in config.php\n");
+    exit(1);
+}
+
+$config = preg_replace('/^\$CFG->sslproxy\s*=.*;\R/m', '', $config);
+if ($sslproxy) {
+    $marker = "require_once(__DIR__ . '/lib/setup.php');";
+    $config = str_replace($marker, "\$CFG->sslproxy = true;\n" . $marker, $config);
+}
+
+file_put_contents($file, $config);
+echo "CONFIGURED\n";
+`
+
+// readWWWRootScript prints the wwwroot from config.php without bootstrapping the rest of Moodle
+const readWWWRootScript = `<?php
+define('CLI_SCRIPT', true);
+define('ABORT_AFTER_CONFIG', true);
+require('config.php');
+echo "WWWROOT:" . $CFG->wwwroot . "\n";
+`
+
+// GetWWWRoot returns the wwwroot Moodle currently generates its links from
+func (m *Manager) GetWWWRoot(containerID string) (string, error) {
+	output, err := m.RunPHPScript(containerID, readWWWRootScript)
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to read wwwroot from config.php")
+	}
+	return parseWWWRoot(output)
+}
+
+// parseWWWRoot extracts the WWWROOT: line printed by readWWWRootScript
+func parseWWWRoot(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "WWWROOT:"); ok && value != "" {
+			return strings.TrimRight(value, "/"), nil
+		}
+	}
+	return "", errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while reading wwwroot: %s", output)
+}
+
+// ConfigureWWWRoot sets Moodle's wwwroot (and sslproxy for HTTPS behind a proxy) and purges caches
+func (m *Manager) ConfigureWWWRoot(containerID, wwwroot string, sslproxy bool) error {
+	parsed, err := url.Parse(wwwroot)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.NewValidationError("wwwroot", "must be an absolute http(s) URL", wwwroot)
+	}
+	wwwroot = strings.TrimRight(wwwroot, "/")
+
+	script := fmt.Sprintf(configureWWWRootScript, base64.StdEncoding.EncodeToString([]byte(wwwroot)), sslproxy)
+	output, err := m.RunPHPScript(containerID, script)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to update wwwroot in config.php")
+	}
+	if !strings.Contains(output, "CONFIGURED") {
+		return errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected output while updating wwwroot: %s", output)
+	}
+
+	if _, err := m.RunMoodleCLI(containerID, PurgeCachesScript); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to purge caches after wwwroot change: %v", err))
+	}
+
+	utils.LogInfo(fmt.Sprintf("Moodle wwwroot set to %s (sslproxy: %v)", wwwroot, sslproxy))
+	return nil
+}
@@ -0,0 +1,14 @@
+package docker
+
+import "testing"
+
+func TestParseWWWRoot(t *testing.T) {
+	got, err := parseWWWRoot("PHP Notice: something\nWWWROOT:http://localhost:8080/\n")
+	if err != nil || got != "http://localhost:8080" {
+		t.Errorf("parseWWWRoot = %q, %v", got, err)
+	}
+
+	if _, err := parseWWWRoot("PHP Fatal error: config.php missing"); err == nil {
+		t.Error("expected an error without a WWWROOT line")
+	}
+}
@@ -0,0 +1,218 @@
+package docker
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// XdebugPort is the Xdebug 3 default port IDEs listen on
+	XdebugPort = 9003
+	// XdebugIDEKey is the session key IDEs should filter on
+	XdebugIDEKey = "MOODLE"
+	// HostGatewayName resolves to the host from inside the container
+	HostGatewayName = "host.docker.internal"
+	// xdebugIniName is the PHP ini file written to the container's scan directory
+	xdebugIniName = "zz-moodle-xdebug.ini"
+	// xdebugLoadIniName loads the extension when the manager installed it, so images
+	// that already ship Xdebug do not load it twice
+	xdebugLoadIniName = "zz-moodle-xdebug-load.ini"
+)
+
+// XdebugInfo tells the IDE how to accept connections from the container
+type XdebugInfo struct {
+	Enabled    bool   `json:"enabled"`
+	Version    string `json:"version"`
+	ClientHost string `json:"clientHost"`
+	Port       int    `json:"port"`
+	IDEKey     string `json:"ideKey"`
+	// ServerPath is the container path to map onto the local plugin checkout
+	ServerPath string `json:"serverPath"`
+}
+
+// hostGatewayArgs maps host.docker.internal on Linux, where Docker does not provide it
+func hostGatewayArgs() []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	return []string{"--add-host", HostGatewayName + ":host-gateway"}
+}
+
+// EnableXdebug installs Xdebug if needed, points it at the host and reloads Apache
+func (m *Manager) EnableXdebug(containerID string) (*XdebugInfo, error) {
+	if err := errors.ValidateContainerID(containerID); err != nil {
+		return nil, errors.WrapWithContext(err, "invalid container ID provided to EnableXdebug")
+	}
+
+	version, err := m.ensureXdebugInstalled(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientHost := m.resolveClientHost(containerID)
+	if err := m.writePHPIni(containerID, xdebugIniName, xdebugIni(clientHost)); err != nil {
+		return nil, err
+	}
+	if err := m.reloadWebServer(containerID); err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo(fmt.Sprintf("Xdebug %s enabled (client_host: %s, port: %d)", version, clientHost, XdebugPort))
+	return &XdebugInfo{
+		Enabled:    true,
+		Version:    version,
+		ClientHost: clientHost,
+		Port:       XdebugPort,
+		IDEKey:     XdebugIDEKey,
+		ServerPath: m.moodleDir(),
+	}, nil
+}
+
+// DisableXdebug turns off step debugging, leaving the extension installed
+func (m *Manager) DisableXdebug(containerID string) error {
+	iniDir, err := m.phpScanDir(containerID)
+	if err != nil {
+		return err
+	}
+	if _, err := m.ExecInContainer(containerID, "rm", "-f", iniDir+"/"+xdebugIniName); err != nil {
+		return errors.WrapWithContext(err, "failed to remove Xdebug configuration")
+	}
+	return m.reloadWebServer(containerID)
+}
+
+// ensureXdebugInstalled installs Xdebug via pecl when it is missing and returns its version
+func (m *Manager) ensureXdebugInstalled(containerID string) (string, error) {
+	if version, err := m.xdebugVersion(containerID); err == nil {
+		return version, nil
+	}
+
+	utils.LogInfo("Xdebug not present in the container, installing via pecl")
+	if output, err := m.ExecInContainer(containerID, "sh", "-c", "pecl install xdebug"); err != nil {
+		utils.LogDebug(fmt.Sprintf("pecl output: %s", output))
+		return "", errors.WrapWithContext(err, "failed to install Xdebug; the image may lack build tools")
+	}
+	// Loading the extension is required before php -r can report its version
+	if err := m.writePHPIni(containerID, xdebugLoadIniName, "zend_extension=xdebug\n"); err != nil {
+		return "", err
+	}
+	version, err := m.xdebugVersion(containerID)
+	if err != nil {
+		return "", errors.WrapWithContext(err, "Xdebug was installed but PHP cannot load it")
+	}
+	return version, nil
+}
+
+// xdebugVersion returns the loaded Xdebug version, failing if the extension is not loaded
+func (m *Manager) xdebugVersion(containerID string) (string, error) {
+	output, err := m.ExecInContainer(containerID, PHPBinary, "-r", "echo phpversion('xdebug');")
+	version := strings.TrimSpace(output)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return "", errors.WrapWithContext(errors.ErrInvalidState, "Xdebug extension is not loaded")
+	}
+	return version, nil
+}
+
+// writePHPIni replaces one of the manager's ini files in PHP's scan directory with contents
+func (m *Manager) writePHPIni(containerID, name, contents string) error {
+	iniDir, err := m.phpScanDir(containerID)
+	if err != nil {
+		return err
+	}
+
+	cmd := GetDockerCommand("exec", "-i", containerID, "sh", "-c", "cat > "+iniDir+"/"+name)
+	cmd.Stdin = strings.NewReader(contents)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		dockerErr := errors.NewDockerErrorWithContainer("exec", containerID, err).WithOutput(string(output))
+		return errors.WrapWithContext(dockerErr, "failed to write PHP configuration %s", name)
+	}
+	return nil
+}
+
+// xdebugIni renders the step-debugging configuration
+func xdebugIni(clientHost string) string {
+	lines := []string{
+		"xdebug.mode=debug",
+		"xdebug.start_with_request=trigger",
+		"xdebug.client_host=" + clientHost,
+		fmt.Sprintf("xdebug.client_port=%d", XdebugPort),
+		"xdebug.idekey=" + XdebugIDEKey,
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// phpScanDir returns the directory PHP scans for additional ini files
+func (m *Manager) phpScanDir(containerID string) (string, error) {
+	output, err := m.ExecInContainer(containerID, PHPBinary, "-r", "echo PHP_CONFIG_FILE_SCAN_DIR;")
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to locate PHP configuration directory")
+	}
+	// The constant may list several directories separated by the path separator
+	dir := strings.TrimSpace(strings.Split(strings.TrimSpace(output), ":")[0])
+	if dir == "" {
+		return "", errors.WrapWithContext(errors.ErrInvalidState, "PHP has no additional ini scan directory")
+	}
+	return dir, nil
+}
+
+// resolveClientHost finds the address the container can reach the host on
+func (m *Manager) resolveClientHost(containerID string) string {
+	if _, err := m.ExecInContainer(containerID, "getent", "hosts", HostGatewayName); err == nil {
+		return HostGatewayName
+	}
+
+	// Containers created without the host-gateway mapping can still reach the bridge gateway
+	output, err := m.ExecInContainer(containerID, "cat", "/proc/net/route")
+	if err == nil {
+		if gateway, err := parseDefaultGateway(output); err == nil {
+			return gateway
+		}
+	}
+
+	utils.LogWarning(fmt.Sprintf("Could not resolve host gateway, falling back to %s", HostGatewayName))
+	return HostGatewayName
+}
+
+// parseDefaultGateway extracts the default route's gateway from /proc/net/route
+func parseDefaultGateway(routes string) (string, error) {
+	for _, line := range strings.Split(routes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			return "", errors.WrapWithContext(errors.ErrInvalidFormat, "invalid gateway %q", fields[2])
+		}
+		// The kernel prints the address in host (little-endian) byte order
+		return net.IPv4(raw[3], raw[2], raw[1], raw[0]).String(), nil
+	}
+	return "", errors.WrapWithContext(errors.ErrInvalidFormat, "no default route found")
+}
+
+// reloadScript gracefully restarts whichever server runs PHP in the container: Apache as
+// Debian ships it, Apache from bitnami or upstream, or PHP-FPM
+const reloadScript = `if command -v apache2ctl >/dev/null 2>&1; then exec apache2ctl -k graceful; fi
+for ctl in apachectl /opt/bitnami/apache/bin/apachectl; do
+  if command -v "$ctl" >/dev/null 2>&1; then exec "$ctl" -k graceful; fi
+done
+if pkill -USR2 -o php-fpm 2>/dev/null; then exit 0; fi
+echo "no Apache or PHP-FPM found" >&2
+exit 3`
+
+// reloadWebServer gracefully restarts the web server so PHP picks up ini changes
+func (m *Manager) reloadWebServer(containerID string) error {
+	if _, err := m.ExecInContainer(containerID, "sh", "-c", reloadScript); err != nil {
+		return errors.WrapWithContext(err, "failed to reload the web server")
+	}
+	return nil
+}
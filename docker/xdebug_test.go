@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultGateway(t *testing.T) {
+	routes := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t00000000\t010011AC\t0003\t0\t0\t0\t00000000\n" +
+		"eth0\t000011AC\t00000000\t0001\t0\t0\t0\t0000FFFF\n"
+
+	gateway, err := parseDefaultGateway(routes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gateway != "172.17.0.1" {
+		t.Errorf("Expected 172.17.0.1, got %s", gateway)
+	}
+
+	if _, err := parseDefaultGateway("Iface\tDestination\tGateway\n"); err == nil {
+		t.Error("Expected error when there is no default route")
+	}
+}
+
+func TestXdebugIni(t *testing.T) {
+	ini := xdebugIni("host.docker.internal")
+	for _, expected := range []string{"xdebug.mode=debug", "xdebug.client_host=host.docker.internal", "xdebug.client_port=9003", "xdebug.idekey=MOODLE"} {
+		if !strings.Contains(ini, expected) {
+			t.Errorf("Expected %q in ini: %s", expected, ini)
+		}
+	}
+}
+
+func TestProfilerIni(t *testing.T) {
+	if ini := profilerIni(false); !strings.Contains(ini, "xdebug.mode=profile\n") {
+		t.Errorf("Expected profile-only mode, got %q", ini)
+	}
+	if ini := profilerIni(true); !strings.Contains(ini, "xdebug.mode=debug,profile\n") {
+		t.Errorf("Expected debugging to stay enabled, got %q", ini)
+	}
+}
+
+func TestNewestProfile(t *testing.T) {
+	listing := "cachegrind.out.1700000002._course_view_php\ncachegrind.out.1700000001._index_php\n"
+	if name := newestProfile(listing); name != "cachegrind.out.1700000002._course_view_php" {
+		t.Errorf("Expected newest profile, got %q", name)
+	}
+	if name := newestProfile(""); name != "" {
+		t.Errorf("Expected no profile, got %q", name)
+	}
+}
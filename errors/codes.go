@@ -0,0 +1,238 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Code is a stable, machine-readable error identifier the frontend maps to localized,
+// actionable messages. Codes are part of the frontend contract: add new ones, never rename.
+type Code string
+
+// Error codes surfaced to the frontend
+const (
+	CodeUnknown               Code = "UNKNOWN"
+	CodeDockerNotInstalled    Code = "DOCKER_NOT_INSTALLED"
+	CodeDockerNotRunning      Code = "DOCKER_NOT_RUNNING"
+	CodeDockerPermission      Code = "DOCKER_PERMISSION_DENIED"
+	CodeImageNotFound         Code = "IMAGE_NOT_FOUND"
+	CodePullAuthFailed        Code = "PULL_AUTH_FAILED"
+	CodeContainerNotFound     Code = "CONTAINER_NOT_FOUND"
+	CodeContainerRunning      Code = "CONTAINER_ALREADY_RUNNING"
+	CodeContainerNotRunning   Code = "CONTAINER_NOT_RUNNING"
+	CodePortInUse             Code = "PORT_IN_USE"
+	CodeFileNotFound          Code = "FILE_NOT_FOUND"
+	CodeFilePermission        Code = "FILE_PERMISSION_DENIED"
+	CodeFileCorrupted         Code = "FILE_CORRUPTED"
+	CodeConfigInvalid         Code = "CONFIG_INVALID"
+	CodeInvalidInput          Code = "INVALID_INPUT"
+	CodeNetworkUnavailable    Code = "NETWORK_UNAVAILABLE"
+	CodeTimeout               Code = "TIMEOUT"
+	CodeServiceUnavailable    Code = "SERVICE_UNAVAILABLE"
+	CodeNotInitialized        Code = "NOT_INITIALIZED"
+	CodeOperationInProgress   Code = "OPERATION_IN_PROGRESS"
+	CodeInvalidState          Code = "INVALID_STATE"
+	CodeQuotaExceeded         Code = "QUOTA_EXCEEDED"
+	CodeReadOnly              Code = "READ_ONLY_INSTANCE"
+	CodeStateFromNewerVersion Code = "STATE_FROM_NEWER_VERSION"
+	// CodeInternal is a bug in the manager, such as a recovered panic
+	CodeInternal Code = "INTERNAL_ERROR"
+)
+
+// Coder is implemented by errors that know their own code, e.g. error types of other packages
+type Coder interface {
+	ErrorCode() Code
+}
+
+// CodedError attaches an explicit code to an error
+type CodedError struct {
+	Code       Code
+	Underlying error
+}
+
+func (e *CodedError) Error() string {
+	return e.Underlying.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Underlying
+}
+
+// ErrorCode returns the attached code
+func (e *CodedError) ErrorCode() Code {
+	return e.Code
+}
+
+// WithCode attaches code to err; it takes precedence over codes derived from wrapped errors
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Underlying: err}
+}
+
+// FromPanic turns the value recovered from a panic in operation into a CodeInternal error
+func FromPanic(operation string, recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return WithCode(fmt.Errorf("%s failed unexpectedly: %w", operation, err), CodeInternal)
+	}
+	return WithCode(fmt.Errorf("%s failed unexpectedly: %v", operation, recovered), CodeInternal)
+}
+
+// sentinelCodes maps the package's sentinel errors to codes, checked in order
+var sentinelCodes = []struct {
+	err  error
+	code Code
+}{
+	{ErrDockerNotAvailable, CodeDockerNotRunning},
+	{ErrDockerPermission, CodeDockerPermission},
+	{ErrImageNotFound, CodeImageNotFound},
+	{ErrContainerNotFound, CodeContainerNotFound},
+	{ErrContainerRunning, CodeContainerRunning},
+	{ErrContainerNotRunning, CodeContainerNotRunning},
+	{ErrPortConflict, CodePortInUse},
+	{ErrFileNotFound, CodeFileNotFound},
+	{ErrDirectoryNotFound, CodeFileNotFound},
+	{ErrFilePermission, CodeFilePermission},
+	{ErrFileCorrupted, CodeFileCorrupted},
+	{ErrConfigInvalid, CodeConfigInvalid},
+	{ErrInvalidInput, CodeInvalidInput},
+	{ErrMissingRequired, CodeInvalidInput},
+	{ErrInvalidFormat, CodeInvalidInput},
+	{ErrInvalidContainerID, CodeInvalidInput},
+	{ErrInvalidImageName, CodeInvalidInput},
+	{ErrNetworkUnavailable, CodeNetworkUnavailable},
+	{ErrConnectionTimeout, CodeTimeout},
+	{context.DeadlineExceeded, CodeTimeout},
+	{ErrServiceUnavailable, CodeServiceUnavailable},
+	{ErrAppNotInitialized, CodeNotInitialized},
+	{ErrOperationInProgress, CodeOperationInProgress},
+	{ErrInvalidState, CodeInvalidState},
+	{ErrQuotaExceeded, CodeQuotaExceeded},
+	{exec.ErrNotFound, CodeDockerNotInstalled},
+	{os.ErrNotExist, CodeFileNotFound},
+	{os.ErrPermission, CodeFilePermission},
+}
+
+// outputCodes recognises docker CLI failures by their output, checked in order for errors
+// that mention docker
+var outputCodes = []struct {
+	needles []string
+	code    Code
+}{
+	{[]string{"cannot connect to the docker daemon", "error during connect", "docker daemon is not running", "is the docker daemon running"}, CodeDockerNotRunning},
+	{[]string{"permission denied while trying to connect"}, CodeDockerPermission},
+	{[]string{"port is already allocated", "address already in use", "only one usage of each socket address"}, CodePortInUse},
+	{[]string{"pull access denied", "unauthorized", "authentication required", "denied: requested access"}, CodePullAuthFailed},
+	{[]string{"manifest unknown", "no such image"}, CodeImageNotFound},
+	// docker inspect without --type says "No such object"; the app only inspects containers that way
+	{[]string{"no such container", "no such object"}, CodeContainerNotFound},
+}
+
+// CodeOf derives the code of err: an explicit code wins, then docker CLI output, then wrapped
+// sentinel errors and error types. Unrecognised errors are CodeUnknown.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+
+	text := strings.ToLower(err.Error())
+	if dockerErr, ok := GetDockerError(err); ok {
+		text += "\n" + strings.ToLower(dockerErr.Output)
+	}
+	if strings.Contains(text, "docker") {
+		for _, candidate := range outputCodes {
+			for _, needle := range candidate.needles {
+				if strings.Contains(text, needle) {
+					return candidate.code
+				}
+			}
+		}
+	}
+
+	for _, candidate := range sentinelCodes {
+		if errors.Is(err, candidate.err) {
+			return candidate.code
+		}
+	}
+
+	switch {
+	case IsValidationError(err):
+		return CodeInvalidInput
+	case IsNetworkError(err):
+		return CodeNetworkUnavailable
+	}
+	return CodeUnknown
+}
+
+// SerializedError is the shape in which errors reach the frontend: a stable code, the full
+// message for logs and fallbacks, remediation steps, and the context of the innermost typed error
+type SerializedError struct {
+	Code        Code              `json:"code"`
+	Message     string            `json:"message"`
+	Suggestions []string          `json:"suggestions,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// HTTPStatus maps err to the HTTP status the local APIs report with its Serialize form
+func HTTPStatus(err error) int {
+	switch CodeOf(err) {
+	case CodeInvalidInput, CodeConfigInvalid:
+		return http.StatusBadRequest
+	case CodeContainerNotFound:
+		return http.StatusNotFound
+	case CodeContainerRunning, CodeInvalidState, CodeOperationInProgress, CodeReadOnly:
+		return http.StatusConflict
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	case CodeDockerNotRunning, CodeServiceUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// Serialize converts err for the frontend; it is installed as the bindings' error formatter
+func Serialize(err error) *SerializedError {
+	if err == nil {
+		return nil
+	}
+
+	serialized := &SerializedError{Code: CodeOf(err), Message: err.Error(), Suggestions: SuggestionsOf(err)}
+	details := make(map[string]string)
+	if dockerErr, ok := GetDockerError(err); ok {
+		details["operation"] = dockerErr.Operation
+		setDetail(details, "image", dockerErr.ImageName)
+		setDetail(details, "container", dockerErr.ContainerID)
+	}
+	if fileErr, ok := GetFileError(err); ok {
+		details["path"] = fileErr.Path
+	}
+	if validationErr, ok := GetValidationError(err); ok {
+		details["field"] = validationErr.Field
+		details["reason"] = validationErr.Reason
+	}
+	if networkErr, ok := GetNetworkError(err); ok {
+		setDetail(details, "url", networkErr.URL)
+	}
+	if len(details) > 0 {
+		serialized.Details = details
+	}
+	return serialized
+}
+
+// setDetail records value under key when it is set
+func setDetail(details map[string]string, key, value string) {
+	if value != "" {
+		details[key] = value
+	}
+}
@@ -39,6 +39,7 @@ var (
 	ErrAppNotInitialized    = errors.New("application not properly initialized")
 	ErrOperationInProgress  = errors.New("operation already in progress")
 	ErrInvalidState         = errors.New("invalid application state")
+	ErrQuotaExceeded        = errors.New("storage quota exceeded")
 )
 
 // Custom error types for enhanced context
@@ -51,6 +52,8 @@ type DockerError struct {
 	Command     string
 	Output      string
 	Underlying  error
+	// Suggestions are remediation steps shown to the user
+	Suggestions []string
 }
 
 func (e *DockerError) Error() string {
@@ -77,6 +80,7 @@ type FileError struct {
 	Operation string // e.g., "read", "write", "delete", "create"
 	Path      string
 	Underlying error
+	Suggestions []string
 }
 
 func (e *FileError) Error() string {
@@ -93,6 +97,7 @@ type ValidationError struct {
 	Value   interface{}
 	Reason  string
 	Underlying error
+	Suggestions []string
 }
 
 func (e *ValidationError) Error() string {
@@ -111,6 +116,7 @@ type NetworkError struct {
 	Operation string // e.g., "connect", "download", "health_check"
 	URL       string
 	Underlying error
+	Suggestions []string
 }
 
 func (e *NetworkError) Error() string {
@@ -267,4 +267,83 @@ func TestSpecificErrors(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestCodeOf(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"sentinel", WrapWithContext(ErrContainerNotRunning, "failed to stop"), CodeContainerNotRunning},
+		{"port output", NewDockerErrorWithImage("run", "moodle", fmt.Errorf("exit status 125")).WithOutput("Bind for 0.0.0.0:8080 failed: port is already allocated"), CodePortInUse},
+		{"pull auth", NewDockerErrorWithImage("pull", "private/moodle", fmt.Errorf("exit status 1")).WithOutput("Error response from daemon: pull access denied for private/moodle"), CodePullAuthFailed},
+		{"missing container", NewDockerErrorWithContainer("inspect", "abc123def456", fmt.Errorf("exit status 1")).WithOutput("Error: No such object: abc123def456"), CodeContainerNotFound},
+		{"daemon down", fmt.Errorf("docker info: Cannot connect to the Docker daemon at unix:///var/run/docker.sock"), CodeDockerNotRunning},
+		{"validation", NewValidationError("hostPort", "out of range", 0), CodeInvalidInput},
+		{"explicit code wins", WithCode(fmt.Errorf("wrapped: %w", ErrInvalidState), CodeReadOnly), CodeReadOnly},
+		{"unrelated unauthorized", fmt.Errorf("webhook returned 401 Unauthorized"), CodeUnknown},
+		{"unknown", fmt.Errorf("something odd"), CodeUnknown},
+	}
+	for _, c := range cases {
+		if got := CodeOf(c.err); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	if Serialize(nil) != nil {
+		t.Error("Expected nil for no error")
+	}
+
+	err := fmt.Errorf("failed to start Moodle: %w", NewDockerErrorWithContainer("start", "abc123def456", ErrContainerNotFound))
+	serialized := Serialize(err)
+	if serialized.Code != CodeContainerNotFound || serialized.Message != err.Error() {
+		t.Errorf("Unexpected code or message: %+v", serialized)
+	}
+	if serialized.Details["operation"] != "start" || serialized.Details["container"] != "abc123def456" {
+		t.Errorf("Expected docker context in details, got %v", serialized.Details)
+	}
+}
+
+func TestFromPanic(t *testing.T) {
+	err := FromPanic("RunMoodle", "assignment to entry in nil map")
+	if CodeOf(err) != CodeInternal || err.Error() != "RunMoodle failed unexpectedly: assignment to entry in nil map" {
+		t.Errorf("Unexpected error: %v (%s)", err, CodeOf(err))
+	}
+
+	// A panic with an error keeps it in the chain
+	err = FromPanic("StopMoodle", ErrContainerNotFound)
+	if CodeOf(err) != CodeInternal || !errors.Is(err, ErrContainerNotFound) {
+		t.Errorf("Expected a CodeInternal error wrapping the panic value, got %v (%s)", err, CodeOf(err))
+	}
+}
+
+func TestSuggestionsOf(t *testing.T) {
+	if SuggestionsOf(nil) != nil {
+		t.Error("Expected no suggestions without an error")
+	}
+
+	// Steps from every level of the chain are collected, outermost first and deduplicated
+	inner := NewDockerErrorWithImage("pull", "private/moodle", fmt.Errorf("exit status 1")).
+		WithSuggestions("Log in to the registry", "Check the image name")
+	err := WithSuggestions(fmt.Errorf("failed to update image: %w", inner), "Check the image name", "Retry later")
+	steps := SuggestionsOf(err)
+	expected := []string{"Check the image name", "Retry later", "Log in to the registry"}
+	if strings.Join(steps, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, steps)
+	}
+
+	// Errors without their own steps fall back to the defaults for their code
+	if steps := SuggestionsOf(WrapWithContext(ErrPortConflict, "failed to start")); len(steps) == 0 {
+		t.Error("Expected default suggestions for a port conflict")
+	}
+	if steps := SuggestionsOf(fmt.Errorf("something odd")); len(steps) != 0 {
+		t.Errorf("Expected no suggestions for an unknown error, got %v", steps)
+	}
+
+	if serialized := Serialize(err); len(serialized.Suggestions) != 3 {
+		t.Errorf("Expected suggestions in the serialized error, got %+v", serialized)
+	}
+}
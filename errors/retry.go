@@ -0,0 +1,167 @@
+package errors
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// RetryClass groups errors by how they should be retried
+type RetryClass int
+
+const (
+	// ClassPermanent errors will not succeed on retry (bad input, missing image, ...)
+	ClassPermanent RetryClass = iota
+	// ClassTransient errors are short-lived daemon or network hiccups
+	ClassTransient
+	// ClassRateLimited errors need a longer back-off (e.g. Docker Hub pull limits)
+	ClassRateLimited
+)
+
+// Output fragments that identify transient and rate-limited Docker failures
+var (
+	transientMarkers = []string{
+		"connection refused",
+		"connection reset",
+		"i/o timeout",
+		"tls handshake timeout",
+		"timeout exceeded",
+		"unexpected eof",
+		"error during connect",
+		"is the docker daemon running",
+		"502 bad gateway",
+		"503 service unavailable",
+		"temporary failure in name resolution",
+	}
+	rateLimitMarkers = []string{
+		"toomanyrequests",
+		"429 too many requests",
+		"rate limit",
+	}
+)
+
+// Backoff describes exponential back-off for one error class
+type Backoff struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+}
+
+// Delay returns the wait before the given retry (1 for the first retry)
+func (b Backoff) Delay(retry int) time.Duration {
+	delay := float64(b.InitialDelay)
+	for i := 1; i < retry; i++ {
+		delay *= b.Multiplier
+	}
+	if max := float64(b.MaxDelay); b.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+	return time.Duration(delay)
+}
+
+// RetryPolicy holds per-error-class back-off settings
+type RetryPolicy struct {
+	Transient   Backoff
+	RateLimited Backoff
+}
+
+// MaxAttempts returns how many attempts the policy allows for err's class; permanent errors get one
+func (p RetryPolicy) MaxAttempts(err error) int {
+	switch ClassifyError(err) {
+	case ClassTransient:
+		return p.Transient.MaxAttempts
+	case ClassRateLimited:
+		return p.RateLimited.MaxAttempts
+	}
+	return 1
+}
+
+// DefaultRetryPolicy suits quick daemon calls such as start and logs
+var DefaultRetryPolicy = RetryPolicy{
+	Transient:   Backoff{MaxAttempts: 3, InitialDelay: 500 * time.Millisecond, MaxDelay: 4 * time.Second, Multiplier: 2},
+	RateLimited: Backoff{MaxAttempts: 1},
+}
+
+// PullRetryPolicy suits image pulls, which hit registries and their rate limits
+var PullRetryPolicy = RetryPolicy{
+	Transient:   Backoff{MaxAttempts: 4, InitialDelay: 2 * time.Second, MaxDelay: 30 * time.Second, Multiplier: 2},
+	RateLimited: Backoff{MaxAttempts: 3, InitialDelay: 30 * time.Second, MaxDelay: 2 * time.Minute, Multiplier: 2},
+}
+
+// retrySleep is replaced in tests
+var retrySleep = time.Sleep
+
+// ClassifyError decides whether an error is worth retrying
+func ClassifyError(err error) RetryClass {
+	if err == nil {
+		return ClassPermanent
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ClassPermanent
+	}
+	if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrContainerNotFound) || errors.Is(err, ErrDockerPermission) {
+		return ClassPermanent
+	}
+	if errors.Is(err, ErrConnectionTimeout) || errors.Is(err, ErrNetworkUnavailable) || errors.Is(err, ErrServiceUnavailable) {
+		return ClassTransient
+	}
+
+	text := strings.ToLower(err.Error())
+	if dockerErr, ok := GetDockerError(err); ok {
+		text += " " + strings.ToLower(dockerErr.Output)
+	}
+
+	for _, marker := range rateLimitMarkers {
+		if strings.Contains(text, marker) {
+			return ClassRateLimited
+		}
+	}
+	for _, marker := range transientMarkers {
+		if strings.Contains(text, marker) {
+			return ClassTransient
+		}
+	}
+
+	if IsNetworkError(err) {
+		return ClassTransient
+	}
+	return ClassPermanent
+}
+
+// Retry runs fn until it succeeds, fails permanently or exhausts the policy for the error's class.
+// onRetry, if not nil, is called before each wait.
+func Retry(policy RetryPolicy, fn func() error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	attempts := map[RetryClass]int{}
+
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		class := ClassifyError(err)
+		var backoff Backoff
+		switch class {
+		case ClassTransient:
+			backoff = policy.Transient
+		case ClassRateLimited:
+			backoff = policy.RateLimited
+		default:
+			return err
+		}
+
+		attempts[class]++
+		if attempts[class] >= backoff.MaxAttempts {
+			return err
+		}
+
+		delay := backoff.Delay(attempts[class])
+		if onRetry != nil {
+			onRetry(attempts[class], delay, err)
+		}
+		retrySleep(delay)
+	}
+}
@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RetryClass
+	}{
+		{"Validation", NewValidationError("imageName", "too short", "ab"), ClassPermanent},
+		{"ImageNotFound", WrapWithContext(ErrImageNotFound, "pull"), ClassPermanent},
+		{"DaemonDown", NewDockerError("start", fmt.Errorf("exit status 1")).WithOutput("error during connect: is the docker daemon running?"), ClassTransient},
+		{"RateLimited", NewDockerError("pull", fmt.Errorf("exit status 1")).WithOutput("toomanyrequests: You have reached your pull rate limit"), ClassRateLimited},
+		{"Network", NewNetworkError("download", fmt.Errorf("dial tcp")), ClassTransient},
+		{"Unknown", fmt.Errorf("something else"), ClassPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var slept []time.Duration
+	retrySleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { retrySleep = time.Sleep }()
+
+	policy := RetryPolicy{
+		Transient: Backoff{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2},
+	}
+	transient := NewNetworkError("connect", fmt.Errorf("connection refused"))
+
+	t.Run("SucceedsAfterTransientFailures", func(t *testing.T) {
+		slept = nil
+		calls := 0
+		err := Retry(policy, func() error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return nil
+		}, nil)
+
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if len(slept) != 2 || slept[0] != time.Second || slept[1] != 2*time.Second {
+			t.Errorf("Unexpected back-off delays: %v", slept)
+		}
+	})
+
+	t.Run("GivesUpAfterMaxAttempts", func(t *testing.T) {
+		calls := 0
+		err := Retry(policy, func() error {
+			calls++
+			return transient
+		}, nil)
+
+		if err == nil || calls != 3 {
+			t.Errorf("Expected failure after 3 calls, got %d calls (err: %v)", calls, err)
+		}
+	})
+
+	t.Run("MaxAttemptsByClass", func(t *testing.T) {
+		if got := policy.MaxAttempts(transient); got != 3 {
+			t.Errorf("Expected 3 attempts for a transient error, got %d", got)
+		}
+		if got := policy.MaxAttempts(ErrImageNotFound); got != 1 {
+			t.Errorf("Expected a single attempt for a permanent error, got %d", got)
+		}
+	})
+
+	t.Run("PermanentNotRetried", func(t *testing.T) {
+		calls := 0
+		Retry(policy, func() error {
+			calls++
+			return ErrImageNotFound
+		}, nil)
+
+		if calls != 1 {
+			t.Errorf("Expected a single call for permanent error, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package errors
+
+import "errors"
+
+// Remediable is implemented by errors that carry user-facing remediation steps
+type Remediable interface {
+	Remediation() []string
+}
+
+// SuggestedError attaches remediation steps to an error
+type SuggestedError struct {
+	Suggestions []string
+	Underlying  error
+}
+
+func (e *SuggestedError) Error() string {
+	return e.Underlying.Error()
+}
+
+func (e *SuggestedError) Unwrap() error {
+	return e.Underlying
+}
+
+// Remediation returns the attached steps
+func (e *SuggestedError) Remediation() []string {
+	return e.Suggestions
+}
+
+// WithSuggestions attaches remediation steps to err, shown before any steps of wrapped errors
+func WithSuggestions(err error, steps ...string) error {
+	if err == nil || len(steps) == 0 {
+		return err
+	}
+	return &SuggestedError{Suggestions: steps, Underlying: err}
+}
+
+// Remediation returns the steps attached to the Docker error
+func (e *DockerError) Remediation() []string {
+	return e.Suggestions
+}
+
+// WithSuggestions attaches remediation steps to the Docker error
+func (e *DockerError) WithSuggestions(steps ...string) *DockerError {
+	e.Suggestions = append(e.Suggestions, steps...)
+	return e
+}
+
+// Remediation returns the steps attached to the file error
+func (e *FileError) Remediation() []string {
+	return e.Suggestions
+}
+
+// WithSuggestions attaches remediation steps to the file error
+func (e *FileError) WithSuggestions(steps ...string) *FileError {
+	e.Suggestions = append(e.Suggestions, steps...)
+	return e
+}
+
+// Remediation returns the steps attached to the validation error
+func (e *ValidationError) Remediation() []string {
+	return e.Suggestions
+}
+
+// WithSuggestions attaches remediation steps to the validation error
+func (e *ValidationError) WithSuggestions(steps ...string) *ValidationError {
+	e.Suggestions = append(e.Suggestions, steps...)
+	return e
+}
+
+// Remediation returns the steps attached to the network error
+func (e *NetworkError) Remediation() []string {
+	return e.Suggestions
+}
+
+// WithSuggestions attaches remediation steps to the network error
+func (e *NetworkError) WithSuggestions(steps ...string) *NetworkError {
+	e.Suggestions = append(e.Suggestions, steps...)
+	return e
+}
+
+// defaultSuggestions are offered for a code when no error in the chain carries its own steps
+var defaultSuggestions = map[Code][]string{
+	CodeDockerNotInstalled: {
+		"Install Docker Desktop from https://www.docker.com/products/docker-desktop",
+		"Restart the application after installing Docker",
+	},
+	CodeDockerNotRunning: {
+		"Start Docker Desktop and wait until it reports that it is running",
+		"Run the health check again",
+	},
+	CodeDockerPermission: {
+		"On Linux, add your user to the docker group and log in again",
+		"Make sure Docker Desktop is running under your account",
+	},
+	CodeImageNotFound: {
+		"Check the image name and tag in image.docker",
+		"Choose an image from the catalog",
+	},
+	CodePullAuthFailed: {
+		"Log in to the registry with docker login",
+		"Check that the image name in image.docker is correct",
+	},
+	CodeContainerNotFound: {
+		"Run Moodle to create a new container",
+	},
+	CodeContainerNotRunning: {
+		"Start Moodle first",
+	},
+	CodePortInUse: {
+		"Close the program that is using the port",
+		"Choose a different host port in settings",
+	},
+	CodeFilePermission: {
+		"Check that you can write to the ~/.moodle-prototype-manager folder",
+	},
+	CodeNetworkUnavailable: {
+		"Check your internet connection",
+		"Configure a proxy in settings if your network requires one",
+	},
+	CodeTimeout: {
+		"Try again in a moment",
+	},
+	CodeOperationInProgress: {
+		"Wait for the running operation to finish",
+	},
+	CodeQuotaExceeded: {
+		"Free up space in the workspace or raise its quota in settings",
+	},
+	CodeReadOnly: {
+		"Close the other Moodle Prototype Manager window, then restart this one",
+	},
+	CodeStateFromNewerVersion: {
+		"Update Moodle Prototype Manager on this machine",
+	},
+	CodeInternal: {
+		"Try again; if the problem persists, restart the application",
+		"Report the problem with the log file from the logs folder",
+	},
+}
+
+// SuggestionsOf collects the remediation steps of every error in err's chain, outermost first
+// and without duplicates, falling back to the defaults for its code
+func SuggestionsOf(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	steps := make([]string, 0)
+	seen := make(map[string]bool)
+	walkChain(err, func(e error) {
+		remediable, ok := e.(Remediable)
+		if !ok {
+			return
+		}
+		for _, step := range remediable.Remediation() {
+			if !seen[step] {
+				seen[step] = true
+				steps = append(steps, step)
+			}
+		}
+	})
+
+	if len(steps) == 0 {
+		return defaultSuggestions[CodeOf(err)]
+	}
+	return steps
+}
+
+// walkChain calls visit for err and every error it wraps, depth first
+func walkChain(err error, visit func(error)) {
+	if err == nil {
+		return
+	}
+	visit(err)
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range wrapped.Unwrap() {
+			walkChain(inner, visit)
+		}
+	default:
+		walkChain(errors.Unwrap(err), visit)
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchInterval is how often queued lines are delivered as one event
+	DefaultBatchInterval = 200 * time.Millisecond
+	// DefaultMaxPendingLines bounds the lines queued while the consumer lags
+	DefaultMaxPendingLines = 5000
+	// ackTimeout resumes delivery when a consumer never acknowledges, e.g. after a window reload
+	ackTimeout = 5 * time.Second
+)
+
+// Batch is a group of lines delivered in one event
+type Batch struct {
+	Seq   int64    `json:"seq"`
+	Lines []string `json:"lines"`
+	// Dropped counts lines discarded since the previous batch because the consumer lagged
+	Dropped int `json:"dropped"`
+}
+
+// Batcher turns a chatty line stream into one event per interval. Only one batch is in
+// flight until the consumer acknowledges it; meanwhile lines queue up to maxPending and the
+// oldest are dropped, so a slow consumer sees fewer, larger batches instead of a backlog.
+type Batcher struct {
+	interval   time.Duration
+	maxPending int
+	deliver    func(Batch)
+
+	mu       sync.Mutex
+	pending  []string
+	dropped  int
+	seq      int64
+	inFlight bool
+	sentAt   time.Time
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewBatcher creates a batcher delivering batches through deliver
+func NewBatcher(interval time.Duration, maxPending int, deliver func(Batch)) *Batcher {
+	if interval <= 0 {
+		interval = DefaultBatchInterval
+	}
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPendingLines
+	}
+	return &Batcher{
+		interval:   interval,
+		maxPending: maxPending,
+		deliver:    deliver,
+	}
+}
+
+// Start delivers queued lines every interval until Stop
+func (b *Batcher) Start() {
+	b.mu.Lock()
+	if b.stopChan != nil {
+		b.mu.Unlock()
+		return
+	}
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	b.stopChan = stopChan
+	b.done = done
+	b.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				b.tick(now, false)
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends delivery, flushing any queued lines regardless of acknowledgements
+func (b *Batcher) Stop() {
+	b.mu.Lock()
+	stopChan, done := b.stopChan, b.done
+	b.stopChan = nil
+	b.mu.Unlock()
+
+	if stopChan != nil {
+		close(stopChan)
+		<-done
+	}
+	b.tick(time.Now(), true)
+}
+
+// Add queues a line, dropping the oldest queued line when the queue is full
+func (b *Batcher) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) >= b.maxPending {
+		b.pending = b.pending[1:]
+		b.dropped++
+	}
+	b.pending = append(b.pending, line)
+}
+
+// Ack marks batch seq as handled so the next one can be delivered
+func (b *Batcher) Ack(seq int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq == b.seq {
+		b.inFlight = false
+	}
+}
+
+// tick delivers queued lines unless the previous batch is still unacknowledged
+func (b *Batcher) tick(now time.Time, force bool) {
+	b.mu.Lock()
+	if b.inFlight && !force && now.Sub(b.sentAt) < ackTimeout {
+		b.mu.Unlock()
+		return
+	}
+	if len(b.pending) == 0 && b.dropped == 0 {
+		b.mu.Unlock()
+		return
+	}
+
+	b.seq++
+	batch := Batch{Seq: b.seq, Lines: b.pending, Dropped: b.dropped}
+	b.pending = nil
+	b.dropped = 0
+	b.inFlight = true
+	b.sentAt = now
+	b.mu.Unlock()
+
+	b.deliver(batch)
+}
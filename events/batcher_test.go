@@ -0,0 +1,60 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatcherWaitsForAck(t *testing.T) {
+	batches := make([]Batch, 0)
+	batcher := NewBatcher(time.Hour, 3, func(batch Batch) {
+		batches = append(batches, batch)
+	})
+	now := time.Now()
+
+	batcher.Add("one")
+	batcher.Add("two")
+	batcher.tick(now, false)
+	if len(batches) != 1 || len(batches[0].Lines) != 2 {
+		t.Fatalf("Expected one batch of two lines, got %+v", batches)
+	}
+
+	// Unacknowledged: lines queue up and the oldest are dropped beyond the limit
+	for _, line := range []string{"a", "b", "c", "d"} {
+		batcher.Add(line)
+	}
+	batcher.tick(now.Add(time.Second), false)
+	if len(batches) != 1 {
+		t.Fatalf("Expected no delivery before the ack, got %+v", batches)
+	}
+
+	batcher.Ack(batches[0].Seq)
+	batcher.tick(now.Add(2*time.Second), false)
+	if len(batches) != 2 {
+		t.Fatalf("Expected a second batch after the ack, got %+v", batches)
+	}
+	if second := batches[1]; second.Dropped != 1 || len(second.Lines) != 3 || second.Lines[0] != "b" {
+		t.Errorf("Expected lines b-d with one dropped, got %+v", second)
+	}
+
+	// A consumer that never acknowledges does not stall delivery forever
+	batcher.Add("late")
+	batcher.tick(now.Add(2*time.Second+ackTimeout), false)
+	if len(batches) != 3 {
+		t.Errorf("Expected delivery to resume after the ack timeout, got %+v", batches)
+	}
+}
+
+func TestBatcherStopFlushes(t *testing.T) {
+	delivered := 0
+	batcher := NewBatcher(time.Hour, 0, func(batch Batch) {
+		delivered += len(batch.Lines)
+	})
+	batcher.Start()
+	batcher.Add("last words")
+	batcher.Stop()
+
+	if delivered != 1 {
+		t.Errorf("Expected queued line to be flushed on stop, got %d", delivered)
+	}
+}
@@ -0,0 +1,224 @@
+// Package events routes backend events to subscribers at the verbosity each one asked for,
+// so minimal UIs such as the tray icon are not flooded with progress and debug traffic.
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"moodle-prototype-manager/utils"
+)
+
+// Level is how much detail a subscriber wants; each level includes the ones below it
+type Level int
+
+const (
+	// LevelState carries lifecycle changes, alerts and final results only
+	LevelState Level = iota
+	// LevelProgress adds progress updates for long-running operations
+	LevelProgress
+	// LevelDebug adds line-by-line output and diagnostics
+	LevelDebug
+)
+
+var levelNames = map[Level]string{
+	LevelState:    "state",
+	LevelProgress: "progress",
+	LevelDebug:    "debug",
+}
+
+// String returns the level's name as accepted by ParseLevel
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel converts "state", "progress" or "debug" to a Level
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return LevelState, fmt.Errorf("unknown event level %q (expected state, progress or debug)", name)
+}
+
+// Handler receives events a subscriber asked for
+type Handler func(topic string, data ...interface{})
+
+// queueSize is how many events a subscriber may fall behind before newer ones are dropped
+const queueSize = 256
+
+type event struct {
+	topic string
+	data  []interface{}
+}
+
+// queue delivers events to one handler in order on its own goroutine, so a slow handler
+// only delays itself
+type queue struct {
+	name    string
+	events  chan event
+	done    chan struct{}
+	dropped int
+}
+
+func newQueue(name string, handler Handler) *queue {
+	q := &queue{name: name, events: make(chan event, queueSize), done: make(chan struct{})}
+	go func() {
+		defer close(q.done)
+		for e := range q.events {
+			q.deliver(handler, e)
+		}
+	}()
+	return q
+}
+
+// deliver calls handler, keeping a panicking handler from ending the queue
+func (q *queue) deliver(handler Handler, e event) {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(fmt.Sprintf("Event subscriber %s panicked on %s", q.name, e.topic), fmt.Errorf("%v", r))
+		}
+	}()
+	handler(e.topic, e.data...)
+}
+
+// push queues e without waiting; it is dropped while the queue is full. The caller holds the
+// bus lock, which serializes pushes with close.
+func (q *queue) push(e event) {
+	select {
+	case q.events <- e:
+		if q.dropped > 0 {
+			utils.LogWarning(fmt.Sprintf("Event subscriber %s dropped %d events while it lagged", q.name, q.dropped))
+			q.dropped = 0
+		}
+	default:
+		q.dropped++
+	}
+}
+
+// close stops the queue once the queued events are delivered
+func (q *queue) close() {
+	close(q.events)
+}
+
+type subscriber struct {
+	level Level
+	// queue is nil for frontend windows, which share the bus's frontend queue
+	queue *queue
+}
+
+// Bus delivers published events to subscribers. Subscribers without a handler are
+// frontend windows that share one bridge, so their events are emitted once. Each handler,
+// and the bridge, has its own queue: Publish never waits for a subscriber.
+type Bus struct {
+	frontend *queue
+
+	mu          sync.Mutex
+	topics      map[string]Level
+	subscribers map[string]subscriber
+	closed      bool
+}
+
+// NewBus creates a bus that emits frontend events through frontend
+func NewBus(frontend Handler) *Bus {
+	b := &Bus{
+		topics:      make(map[string]Level),
+		subscribers: make(map[string]subscriber),
+	}
+	if frontend != nil {
+		b.frontend = newQueue("frontend", frontend)
+	}
+	return b
+}
+
+// RegisterTopic sets the level a topic is published at; unregistered topics are state events
+func (b *Bus) RegisterTopic(topic string, level Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics[topic] = level
+}
+
+// Subscribe registers or updates a subscriber; a nil handler subscribes a frontend window
+func (b *Bus) Subscribe(id string, level Level, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	if previous, ok := b.subscribers[id]; ok && previous.queue != nil {
+		previous.queue.close()
+	}
+	sub := subscriber{level: level}
+	if handler != nil {
+		sub.queue = newQueue(id, handler)
+	}
+	b.subscribers[id] = sub
+}
+
+// Unsubscribe removes a subscriber
+func (b *Bus) Unsubscribe(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sub, ok := b.subscribers[id]; ok && sub.queue != nil {
+		sub.queue.close()
+	}
+	delete(b.subscribers, id)
+}
+
+// Publish queues an event for every subscriber whose level includes the topic's level
+func (b *Bus) Publish(topic string, data ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	e := event{topic: topic, data: data}
+	level := b.topics[topic]
+	frontendSubscribed := false
+	frontendWants := false
+	for _, sub := range b.subscribers {
+		if sub.queue == nil {
+			frontendSubscribed = true
+			frontendWants = frontendWants || sub.level >= level
+			continue
+		}
+		if sub.level >= level {
+			sub.queue.push(e)
+		}
+	}
+
+	// Until a window states its verbosity it receives everything, as before subscriptions existed
+	if b.frontend != nil && (frontendWants || !frontendSubscribed) {
+		b.frontend.push(e)
+	}
+}
+
+// Close stops delivery, waiting for the events already queued to be delivered
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	queues := make([]*queue, 0, len(b.subscribers)+1)
+	for id, sub := range b.subscribers {
+		if sub.queue != nil {
+			queues = append(queues, sub.queue)
+		}
+		delete(b.subscribers, id)
+	}
+	if b.frontend != nil {
+		queues = append(queues, b.frontend)
+	}
+	b.mu.Unlock()
+
+	for _, q := range queues {
+		q.close()
+		<-q.done
+	}
+}
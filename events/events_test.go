@@ -0,0 +1,103 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the topics a handler receives; received is safe to read after Close
+type recorder struct {
+	mu     sync.Mutex
+	topics []string
+}
+
+func (r *recorder) handle(topic string, _ ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics = append(r.topics, topic)
+}
+
+func (r *recorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.topics...)
+}
+
+func TestPublishFiltersByLevel(t *testing.T) {
+	frontend := &recorder{}
+	bus := NewBus(frontend.handle)
+	bus.RegisterTopic("pull:progress", LevelProgress)
+	bus.RegisterTopic("tests:output", LevelDebug)
+
+	// Without frontend subscriptions everything is emitted
+	bus.Publish("tests:output")
+
+	bus.Subscribe("tray", LevelState, nil)
+	bus.Publish("pull:progress")
+	bus.Publish("alert")
+
+	// A more verbose window raises what the shared bridge carries, once per event
+	bus.Subscribe("main", LevelProgress, nil)
+	bus.Publish("pull:progress")
+
+	api := make(chan string, 2)
+	bus.Subscribe("api", LevelDebug, func(topic string, _ ...interface{}) { api <- topic })
+	bus.Publish("tests:output")
+	select {
+	case <-api:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the debug subscriber to receive the debug event")
+	}
+	bus.Unsubscribe("api")
+	bus.Publish("tests:output")
+	bus.Close()
+
+	if got := frontend.received(); !slices.Equal(got, []string{"tests:output", "alert", "pull:progress"}) {
+		t.Errorf("Unexpected frontend events %v", got)
+	}
+	if len(api) != 0 {
+		t.Errorf("Expected no events after unsubscribe, got %d", len(api))
+	}
+}
+
+func TestPublishDoesNotWaitForSlowSubscribers(t *testing.T) {
+	release := make(chan struct{})
+	bus := NewBus(func(string, ...interface{}) { <-release })
+	fast := make(chan string, queueSize+10)
+	bus.Subscribe("api", LevelState, func(topic string, _ ...interface{}) { fast <- topic })
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < queueSize+10; i++ {
+			bus.Publish("alert")
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a stuck frontend")
+	}
+	select {
+	case <-fast:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the other subscriber to receive events while the frontend is stuck")
+	}
+
+	close(release)
+	bus.Close()
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{LevelState, LevelProgress, LevelDebug} {
+		parsed, err := ParseLevel(level.String())
+		if err != nil || parsed != level {
+			t.Errorf("Expected %v to round-trip, got %v (%v)", level, parsed, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
@@ -0,0 +1,201 @@
+// Package fleet lets one manager discover and control other managers on the LAN,
+// e.g. a trainer driving a classroom of identical lab machines.
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/mdns"
+	"moodle-prototype-manager/utils"
+)
+
+// Local API paths served by each manager instance
+const (
+	StatusPath = "/api/v1/status"
+	StartPath  = "/api/v1/start"
+	StopPath   = "/api/v1/stop"
+	ResetPath  = "/api/v1/reset"
+
+	// TXT record keys advertised over mDNS
+	TextName    = "name"
+	TextURL     = "url"
+	TextAPIPort = "api"
+	TextVersion = "version"
+
+	discoveryTimeout = 3 * time.Second
+	requestTimeout   = 10 * time.Second
+)
+
+// commandPaths maps broadcastable commands to API paths
+var commandPaths = map[string]string{
+	"start": StartPath,
+	"stop":  StopPath,
+	"reset": ResetPath,
+}
+
+// MemberStatus is the status document returned by a member's local API
+type MemberStatus struct {
+	State string `json:"state"`
+	URL   string `json:"url"`
+	Image string `json:"image"`
+}
+
+// Member is a discovered manager instance on the LAN
+type Member struct {
+	// Name is the sharing name the member chose, or else its host name
+	Name    string        `json:"name"`
+	Address string        `json:"address"`
+	APIPort int           `json:"apiPort"`
+	URL     string        `json:"url"`
+	Status  *MemberStatus `json:"status,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// CommandResult is the outcome of a broadcast command on one member
+type CommandResult struct {
+	Member  string `json:"member"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Controller discovers fleet members and sends them commands
+type Controller struct {
+	token  string
+	client *http.Client
+}
+
+// NewController creates a controller authenticating with the shared fleet token
+func NewController(token string) *Controller {
+	return &Controller{
+		token:  token,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Discover browses mDNS for members and fetches each member's status
+func (c *Controller) Discover() ([]Member, error) {
+	entries, err := mdns.Browse(mdns.ServiceType, discoveryTimeout)
+	if err != nil {
+		return nil, errors.NewNetworkError("fleet_discover", err)
+	}
+
+	members := make([]Member, 0, len(entries))
+	for _, entry := range entries {
+		// The SRV port is the Moodle web port; only instances exposing the local API can join the fleet
+		apiPort, err := strconv.Atoi(entry.Text[TextAPIPort])
+		if err != nil || apiPort <= 0 {
+			utils.LogDebug(fmt.Sprintf("Skipping %s: no local API advertised", entry.Instance))
+			continue
+		}
+
+		member := Member{
+			Name:    entry.Text[TextName],
+			Address: entry.Address,
+			APIPort: apiPort,
+			URL:     entry.Text[TextURL],
+		}
+		if member.Name == "" {
+			member.Name = entry.Instance
+		}
+		members = append(members, member)
+	}
+
+	var wg sync.WaitGroup
+	for i := range members {
+		wg.Add(1)
+		go func(member *Member) {
+			defer wg.Done()
+			status, err := c.fetchStatus(member)
+			if err != nil {
+				member.Error = err.Error()
+				return
+			}
+			member.Status = status
+		}(&members[i])
+	}
+	wg.Wait()
+
+	utils.LogInfo(fmt.Sprintf("Fleet discovery found %d members", len(members)))
+	return members, nil
+}
+
+// Broadcast sends a command (start, stop or reset) to every member concurrently
+func (c *Controller) Broadcast(members []Member, command string) ([]CommandResult, error) {
+	path, ok := commandPaths[command]
+	if !ok {
+		return nil, errors.NewValidationError("command", "unsupported fleet command", command)
+	}
+
+	results := make([]CommandResult, len(members))
+	var wg sync.WaitGroup
+	for i := range members {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = CommandResult{Member: members[i].Name, Success: true}
+			if err := c.post(&members[i], path); err != nil {
+				results[i].Success = false
+				results[i].Error = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// fetchStatus reads a member's status document
+func (c *Controller) fetchStatus(member *Member) (*MemberStatus, error) {
+	resp, err := c.do(member, http.MethodGet, StatusPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var status MemberStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, errors.NewNetworkErrorWithURL("fleet_status", memberURL(member, StatusPath), err)
+	}
+	return &status, nil
+}
+
+// post sends a command request to a member
+func (c *Controller) post(member *Member, path string) error {
+	resp, err := c.do(member, http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do performs an authenticated request and rejects non-2xx responses
+func (c *Controller) do(member *Member, method, path string) (*http.Response, error) {
+	url := memberURL(member, path)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("fleet_request", url, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.NewNetworkErrorWithURL("fleet_request", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, errors.NewNetworkErrorWithURL("fleet_request", url, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return resp, nil
+}
+
+// memberURL builds the API URL for a member
+func memberURL(member *Member, path string) string {
+	return fmt.Sprintf("http://%s:%d%s", member.Address, member.APIPort, path)
+}
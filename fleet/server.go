@@ -0,0 +1,139 @@
+package fleet
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Backend is the prototype a fleet member lets the controller drive
+type Backend interface {
+	Status() (*MemberStatus, error)
+	// Start starts Moodle; it may return before Moodle is ready
+	Start() error
+	Stop() error
+	// Reset removes the container and its data and starts a fresh install
+	Reset() error
+}
+
+// Server serves the member API that a Controller discovers and drives. Unlike the kiosk and
+// control APIs it listens on every interface, since the controller is on another machine, so
+// every request must carry the shared fleet token.
+type Server struct {
+	backend Backend
+	token   string
+	server  *http.Server
+	port    int
+}
+
+// NewServer creates a member API server; requests must send token as a bearer token, and
+// every request is refused while it is empty
+func NewServer(backend Backend, token string) *Server {
+	s := &Server{backend: backend, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, s.handle(http.MethodGet, s.status))
+	for command, path := range commandPaths {
+		mux.HandleFunc(path, s.handle(http.MethodPost, s.command(command)))
+	}
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// Start listens on port on every interface
+func (s *Server) Start(port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return errors.NewNetworkError("fleet_listen", err)
+	}
+	s.port = port
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			utils.LogError("Fleet member API stopped", err)
+		}
+	}()
+	utils.LogInfo(fmt.Sprintf("Fleet member API listening on port %d", port))
+	return nil
+}
+
+// Port returns the port the server listens on, advertised over mDNS as TextAPIPort
+func (s *Server) Port() int {
+	return s.port
+}
+
+// Stop shuts the server down
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		utils.LogWarning(fmt.Sprintf("Fleet member API shutdown: %v", err))
+	}
+}
+
+// ServeHTTP handles a request; used by tests without a listener
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
+}
+
+// handle checks the method and token before calling fn
+func (s *Server) handle(method string, fn func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	status, err := s.backend.Status()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// command returns the handler running a broadcast command
+func (s *Server) command(command string) func(http.ResponseWriter, *http.Request) {
+	run := map[string]func() error{
+		"start": s.backend.Start,
+		"stop":  s.backend.Stop,
+		"reset": s.backend.Reset,
+	}[command]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.LogInfo(fmt.Sprintf("Fleet %s request from %s", command, r.RemoteAddr))
+		if err := run(); err != nil {
+			utils.LogError(fmt.Sprintf("Fleet %s request failed", command), err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to write fleet API response: %v", err))
+	}
+}
@@ -0,0 +1,98 @@
+package fleet
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type fakeMember struct {
+	state    string
+	commands []string
+}
+
+func (f *fakeMember) Status() (*MemberStatus, error) {
+	return &MemberStatus{State: f.state, URL: "http://192.0.2.10:8080"}, nil
+}
+func (f *fakeMember) Start() error {
+	f.commands = append(f.commands, "start")
+	f.state = "running"
+	return nil
+}
+func (f *fakeMember) Stop() error {
+	f.commands = append(f.commands, "stop")
+	f.state = "stopped"
+	return nil
+}
+func (f *fakeMember) Reset() error {
+	f.commands = append(f.commands, "reset")
+	f.state = "running"
+	return nil
+}
+
+// serveMember runs backend's member API and returns the Member a controller would discover
+func serveMember(t *testing.T, backend Backend, token string) Member {
+	t.Helper()
+	httpServer := httptest.NewServer(NewServer(backend, token))
+	t.Cleanup(httpServer.Close)
+
+	host, port, err := net.SplitHostPort(httpServer.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected listener address: %v", err)
+	}
+	apiPort, _ := strconv.Atoi(port)
+	return Member{Name: "lab-01", Address: host, APIPort: apiPort}
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	for _, server := range []*Server{NewServer(&fakeMember{}, "classroom"), NewServer(&fakeMember{}, "")} {
+		for _, header := range []string{"", "Bearer ", "Bearer wrong", "classroom"} {
+			req := httptest.NewRequest(http.MethodPost, ResetPath, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("token %q (server token %q): expected 401, got %d", header, server.token, rec.Code)
+			}
+		}
+	}
+}
+
+func TestControllerDrivesMembers(t *testing.T) {
+	backend := &fakeMember{state: "stopped"}
+	member := serveMember(t, backend, "classroom")
+	controller := NewController("classroom")
+
+	status, err := controller.fetchStatus(&member)
+	if err != nil {
+		t.Fatalf("fetchStatus failed: %v", err)
+	}
+	if status.State != "stopped" {
+		t.Errorf("expected stopped, got %q", status.State)
+	}
+
+	for _, command := range []string{"start", "reset", "stop"} {
+		results, err := controller.Broadcast([]Member{member}, command)
+		if err != nil {
+			t.Fatalf("Broadcast(%s) failed: %v", command, err)
+		}
+		if len(results) != 1 || !results[0].Success {
+			t.Errorf("Broadcast(%s): unexpected results %+v", command, results)
+		}
+	}
+	if got := backend.commands; len(got) != 3 || got[0] != "start" || got[1] != "reset" || got[2] != "stop" {
+		t.Errorf("unexpected commands received: %v", got)
+	}
+
+	results, _ := NewController("wrong").Broadcast([]Member{member}, "stop")
+	if results[0].Success {
+		t.Error("expected a broadcast with the wrong token to fail")
+	}
+	if _, err := controller.Broadcast([]Member{member}, "delete"); err == nil {
+		t.Error("expected an unsupported command to be rejected")
+	}
+}
@@ -8,6 +8,103 @@ function IsContainerReady() {
     return window.go?.main?.App?.IsContainerReady?.() || Promise.resolve(false);
 }
 
+// Add GetSiteInfo manually until Wails regenerates properly
+function GetSiteInfo() {
+    return window.go?.main?.App?.GetSiteInfo?.() || Promise.resolve(null);
+}
+
+// Add credential bindings manually until Wails regenerates properly
+function GetMaskedCredentials() {
+    return window.go?.main?.App?.GetMaskedCredentials?.() ||
+        GetCredentials().then(credentials => ({ ...credentials, hasPassword: Boolean(credentials.password) }));
+}
+
+function RevealPassword() {
+    return window.go?.main?.App?.RevealPassword?.() || Promise.reject(new Error('RevealPassword is not available'));
+}
+
+function CopyCredentialToClipboard(field) {
+    return window.go?.main?.App?.CopyCredentialToClipboard?.(field) || Promise.reject(new Error('CopyCredentialToClipboard is not available'));
+}
+
+// Add ValidateEnvironment manually until Wails regenerates properly
+function ValidateEnvironment() {
+    return window.go?.main?.App?.ValidateEnvironment?.() || Promise.resolve(null);
+}
+
+// Add RefreshCredentials manually until Wails regenerates properly
+function RefreshCredentials() {
+    return window.go?.main?.App?.RefreshCredentials?.() || Promise.reject(new Error('RefreshCredentials is not available'));
+}
+
+// Add quit dialog bindings manually until Wails regenerates properly
+function Quit(keepRunning, remember) {
+    return window.go?.main?.App?.Quit?.(keepRunning, remember) || Promise.reject(new Error('Quit is not available'));
+}
+
+function CancelQuit() {
+    return window.go?.main?.App?.CancelQuit?.() || Promise.resolve();
+}
+
+// Add Moodle debug mode bindings manually until Wails regenerates properly
+function GetMoodleDebugMode() {
+    return window.go?.main?.App?.GetMoodleDebugMode?.() || Promise.resolve(null);
+}
+
+function SetMoodleDebugMode(level) {
+    return window.go?.main?.App?.SetMoodleDebugMode?.(level) || Promise.reject(new Error('SetMoodleDebugMode is not available'));
+}
+
+// Add EmergencyCleanup manually until Wails regenerates properly
+function EmergencyCleanup() {
+    return window.go?.main?.App?.EmergencyCleanup?.() || Promise.reject(new Error('EmergencyCleanup is not available'));
+}
+
+// Add GetLanguage manually until Wails regenerates properly
+function GetLanguage() {
+    return window.go?.main?.App?.GetLanguage?.() || Promise.reject(new Error('GetLanguage is not available'));
+}
+
+// Add instance template bindings manually until Wails regenerates properly
+function ListInstanceTemplates() {
+    return window.go?.main?.App?.ListInstanceTemplates?.() || Promise.resolve([]);
+}
+
+function SaveInstanceTemplate(name, seedSize) {
+    return window.go?.main?.App?.SaveInstanceTemplate?.(name, seedSize) || Promise.reject(new Error('SaveInstanceTemplate is not available'));
+}
+
+function DeleteInstanceTemplate(name) {
+    return window.go?.main?.App?.DeleteInstanceTemplate?.(name) || Promise.reject(new Error('DeleteInstanceTemplate is not available'));
+}
+
+function CreateInstanceFromTemplate(name, instance) {
+    return window.go?.main?.App?.CreateInstanceFromTemplate?.(name, instance) || Promise.reject(new Error('CreateInstanceFromTemplate is not available'));
+}
+
+// Add site details bindings manually until Wails regenerates properly
+function GetSiteDetails() {
+    return window.go?.main?.App?.GetSiteDetails?.() || Promise.resolve(null);
+}
+
+function SetSiteDetails(fullName, shortName, adminEmail) {
+    return window.go?.main?.App?.SetSiteDetails?.(fullName, shortName, adminEmail) || Promise.reject(new Error('SetSiteDetails is not available'));
+}
+
+// Add event subscription bindings manually until Wails regenerates properly
+function SubscribeEvents(subscriberID, level) {
+    return window.go?.main?.App?.SubscribeEvents?.(subscriberID, level) || Promise.resolve();
+}
+
+// Add PHP error log bindings manually until Wails regenerates properly
+function GetPHPErrorLog(limit, fatalOnly) {
+    return window.go?.main?.App?.GetPHPErrorLog?.(limit, fatalOnly) || Promise.reject(new Error('GetPHPErrorLog is not available'));
+}
+
+function AckPHPLogBatch(seq) {
+    return window.go?.main?.App?.AckPHPLogBatch?.(seq) || Promise.resolve();
+}
+
 // Import shared state and functions from app.js
 import { AppState, updateStatusText, updateHealthCheckResults } from './app.js';
 
@@ -15,7 +112,10 @@ import { AppState, updateStatusText, updateHealthCheckResults } from './app.js';
 import { 
     showNotification, hideCredentials, showDownloadModal, hideDownloadModal,
     showStartupModal, hideStartupModal, setUIEnabled, setButtonLoading, showBrowserDialog,
-    hideBrowserDialog, displayCredentials
+    hideBrowserDialog, showQuitDialog, hideQuitDialog, showCleanupDialog, hideCleanupDialog, displayCredentials, describeErrorWithSteps, updateMoodleHealth, setErrorLanguage,
+    showPHPLogDialog, hidePHPLogDialog, appendPHPLogLines, showTemplatesDialog, hideTemplatesDialog, renderTemplates,
+    showSiteDetailsDialog, hideSiteDetailsDialog,
+    showActionNotification, displaySiteStatus, displayDebugMode, resetRunSteps, recordRunStep
 } from './ui.js';
 
 // Wails bindings (will be available at runtime)
@@ -34,7 +134,9 @@ function initializeWailsBindings() {
             HealthCheck: HealthCheck,
             RunMoodle: RunMoodle,
             StopMoodle: StopMoodle,
-            GetCredentials: GetCredentials,
+            GetCredentials: GetMaskedCredentials,
+            RevealPassword: RevealPassword,
+            CopyCredentialToClipboard: CopyCredentialToClipboard,
             OpenBrowser: OpenBrowser,
             IsContainerReady: IsContainerReady,
             GetImageName: GetImageName
@@ -46,6 +148,8 @@ function initializeWailsBindings() {
             RunMoodle: mockRunMoodle,
             StopMoodle: mockStopMoodle,
             GetCredentials: mockGetCredentials,
+            RevealPassword: mockRevealPassword,
+            CopyCredentialToClipboard: mockCopyCredentialToClipboard,
             OpenBrowser: mockOpenBrowser,
             IsContainerReady: mockIsContainerReady,
             GetImageName: mockGetImageName
@@ -80,11 +184,20 @@ function mockStopMoodle() {
 function mockGetCredentials() {
     return Promise.resolve({
         username: 'admin',
-        password: 'admin123!@#',
+        password: '••••••••',
+        hasPassword: true,
         url: 'http://localhost:8080'
     });
 }
 
+function mockRevealPassword() {
+    return Promise.resolve('admin123!@#');
+}
+
+function mockCopyCredentialToClipboard(field) {
+    return navigator.clipboard.writeText(field === 'password' ? 'admin123!@#' : 'http://localhost:8080');
+}
+
 function mockOpenBrowser() {
     return Promise.resolve(null);
 }
@@ -133,6 +246,13 @@ async function performHealthChecks() {
         });
         
         updateHealthCheckResults();
+
+        // The container health arrives as a moodle:health event when it changes
+        if (isWailsEnvironment() && window.go.main.App.GetContainerHealth) {
+            window.go.main.App.GetContainerHealth().then(updateMoodleHealth).catch((error) => {
+                console.error('Failed to get container health:', error);
+            });
+        }
         
         // Update status text based on results
         if (AppState.dockerStatus && AppState.internetStatus) {
@@ -144,6 +264,10 @@ async function performHealthChecks() {
         } else if (!AppState.internetStatus) {
             updateStatusText('Internet unavailable');
         }
+
+        if (AppState.dockerStatus) {
+            await applyPreflight();
+        }
         
     } catch (error) {
         console.error('Health check service failed:', error);
@@ -164,8 +288,46 @@ async function performHealthChecks() {
     }
 }
 
-// Enhanced container start function
-async function startMoodleContainer() {
+// Run the backend preflight and let it decide whether Moodle can be started; an image that is
+// already downloaded starts without internet, while a busy port or full disk blocks the start
+async function applyPreflight() {
+    if (AppState.containerRunning) {
+        return;
+    }
+
+    let report = null;
+    try {
+        report = await ValidateEnvironment();
+    } catch (error) {
+        console.warn('Preflight failed, keeping the health check result:', error);
+    }
+    if (!report) {
+        return;
+    }
+
+    const runButton = document.getElementById('run-moodle-btn');
+    if (runButton) {
+        runButton.disabled = !report.canRun;
+        runButton.title = report.checks
+            .filter(check => check.status !== 'pass')
+            .map(check => `${check.label}: ${check.message}`)
+            .join('\n');
+    }
+
+    const failed = report.checks.find(check => check.status === 'fail');
+    const warned = report.checks.find(check => check.status === 'warn');
+    if (failed) {
+        updateStatusText(`${failed.label}: ${failed.message}`);
+    } else if (warned) {
+        updateStatusText(`Ready - ${warned.message}`);
+    } else {
+        updateStatusText('All systems ready');
+    }
+}
+
+// Enhanced container start function; start replaces RunMoodle, e.g. to create the instance
+// from a template first
+async function startMoodleContainer(start = () => wailsBindings.RunMoodle()) {
     console.log('Starting Moodle container...');
     
     try {
@@ -175,6 +337,10 @@ async function startMoodleContainer() {
         showDownloadModal();
         updateStatusText('Starting Moodle container...');
 
+        // Every lifecycle step of the start is listed in the startup modal as it is reached
+        resetRunSteps();
+        window.runtime.EventsOn('moodle:run:progress', recordRunStep);
+
         // Listen for real Docker pull progress events
         let progressListener = null;
         let hasReceivedProgress = false;
@@ -205,7 +371,7 @@ async function startMoodleContainer() {
 
         // Start the container (backend will handle image pull if needed)
         try {
-            await wailsBindings.RunMoodle();
+            await start();
 
             // Complete download progress
             const progressFill = document.getElementById('download-progress');
@@ -218,17 +384,7 @@ async function startMoodleContainer() {
                 window.runtime.EventsOff('docker:pull:progress');
             }
             
-            // Extract error message properly
-            let errorMessage = 'Failed to start container';
-            if (runError && typeof runError === 'string') {
-                errorMessage = runError;
-            } else if (runError && runError.message) {
-                errorMessage = runError.message;
-            } else if (runError && runError.toString) {
-                errorMessage = runError.toString();
-            }
-            
-            throw new Error(errorMessage);
+            throw new Error(describeErrorWithSteps(runError, 'Failed to start container'));
         }
 
         // Clean up event listener
@@ -248,9 +404,41 @@ async function startMoodleContainer() {
         
         // Keep the modal open and update status
         updateStatusText('Container is starting, please wait...');
-        
+
+        // First runs install Moodle; the backend reports its phases parsed from the container logs
+        let installProgress = null;
+        window.runtime.EventsOn('moodle:install:progress', (data) => {
+            installProgress = data;
+            const container = document.getElementById('install-progress-container');
+            if (container) {
+                container.style.display = 'block';
+            }
+            const installFill = document.getElementById('install-progress');
+            if (installFill) {
+                installFill.style.width = Math.min(data.percentage, 100) + '%';
+            }
+            const installStatus = document.querySelector('.install-status');
+            if (installStatus) {
+                installStatus.textContent = `${data.label} - ${Math.round(data.percentage)}%`;
+            }
+        });
+
+        // The backend gives up on installs that hit a fatal error, stop or take too long
+        let installFailure = null;
+        window.runtime.EventsOn('moodle:install:failed', (data) => {
+            installFailure = data;
+        });
+
         let isReady = false;
         while (!isReady) {
+            if (installFailure) {
+                console.error('Moodle installation failed:\n' + (installFailure.excerpt || ''));
+                window.runtime.EventsOff('moodle:install:failed');
+                if (installFailure.logFile) {
+                    throw new Error(`${installFailure.message}. The container was removed; its log is in ${installFailure.logFile}`);
+                }
+                throw new Error(installFailure.message);
+            }
             try {
                 isReady = await wailsBindings.IsContainerReady();
                 
@@ -262,7 +450,12 @@ async function startMoodleContainer() {
                 // Update status with progress indication
                 const minutes = Math.floor(attempts * 2 / 60);
                 const seconds = (attempts * 2) % 60;
-                updateStatusText(`Container starting... ${minutes}:${seconds.toString().padStart(2, '0')}`);
+                const elapsed = `${minutes}:${seconds.toString().padStart(2, '0')}`;
+                if (installProgress) {
+                    updateStatusText(`${installProgress.label} (${Math.round(installProgress.percentage)}%)... ${elapsed}`);
+                } else {
+                    updateStatusText(`Container starting... ${elapsed}`);
+                }
                 
                 // Wait 2 seconds before next check
                 await new Promise(resolve => setTimeout(resolve, 2000));
@@ -276,6 +469,9 @@ async function startMoodleContainer() {
         }
         
         // Only hide modal after we're done checking
+        window.runtime.EventsOff('moodle:run:progress');
+        window.runtime.EventsOff('moodle:install:progress');
+        window.runtime.EventsOff('moodle:install:failed');
         hideStartupModal();
         
         // Container is ready (since we only exit the loop when isReady is true)
@@ -288,6 +484,7 @@ async function startMoodleContainer() {
             AppState.credentials = credentials;
             updateStatusText('Container running - Moodle is ready!');
             displayCredentials(credentials);
+            refreshSiteStatus();
             
             // Update button to stop mode
             const runButton = document.getElementById('run-moodle-btn');
@@ -327,6 +524,9 @@ async function startMoodleContainer() {
         }
         
         // Hide modals and reset UI
+        window.runtime.EventsOff('moodle:run:progress');
+        window.runtime.EventsOff('moodle:install:progress');
+        window.runtime.EventsOff('moodle:install:failed');
         hideDownloadModal();
         hideStartupModal();
         updateStatusText('Container start failed');
@@ -344,17 +544,7 @@ async function stopMoodleContainer() {
         try {
             await wailsBindings.StopMoodle();
         } catch (stopError) {
-            // Extract error message properly
-            let errorMessage = 'Failed to stop container';
-            if (stopError && typeof stopError === 'string') {
-                errorMessage = stopError;
-            } else if (stopError && stopError.message) {
-                errorMessage = stopError.message;
-            } else if (stopError && stopError.toString) {
-                errorMessage = stopError.toString();
-            }
-            
-            throw new Error(errorMessage);
+            throw new Error(describeErrorWithSteps(stopError, 'Failed to stop container'));
         }
         
         // Container stopped successfully
@@ -377,14 +567,299 @@ async function stopMoodleContainer() {
     }
 }
 
+// Show that the backend stopped an idle container and offer to start it again
+function handleIdleStopped(data) {
+    AppState.containerRunning = false;
+
+    const runButton = document.getElementById('run-moodle-btn');
+    runButton.textContent = 'Run Moodle';
+    runButton.classList.remove('stop');
+    runButton.disabled = false;
+
+    hideCredentials();
+    updateStatusText('Moodle stopped while idle');
+    showActionNotification(
+        `Moodle was stopped after ${data.idleMinutes} minutes without use to free up resources.`,
+        'Resume',
+        startMoodleContainer
+    );
+}
+
+// Reset the UI once the backend has removed the container; the next run installs a fresh site
+function handleContainerRemoved(data) {
+    AppState.containerRunning = false;
+    AppState.credentials = null;
+
+    const runButton = document.getElementById('run-moodle-btn');
+    runButton.textContent = 'Run Moodle';
+    runButton.classList.remove('stop');
+
+    hideCredentials();
+    updateStatusText('Moodle container removed');
+    // Removals after a failed install are explained by the install failure itself
+    if (data.reason) {
+        return;
+    }
+    const volumes = data.volumesRemoved.length;
+    showNotification(volumes > 0
+        ? `Moodle container and ${volumes} volume(s) removed`
+        : 'Moodle container removed', 'success');
+}
+
+// Remove everything the manager created after the user confirmed; moodle:cleanup:done reports
+// the result
+async function handleCleanupConfirm() {
+    hideCleanupDialog();
+    const button = document.getElementById('cleanup-btn');
+    if (button) {
+        button.disabled = true;
+    }
+    updateStatusText('Removing Moodle containers and data...');
+
+    try {
+        await EmergencyCleanup();
+    } catch (error) {
+        console.error('Emergency cleanup failed:', error);
+        updateStatusText('Cleanup failed');
+        showNotification('Cleanup failed: ' + describeErrorWithSteps(error), 'error');
+    } finally {
+        if (button) {
+            button.disabled = false;
+        }
+    }
+}
+
+// Open the PHP error log view with the entries the backend kept
+async function handleShowPHPLog() {
+    try {
+        const entries = await GetPHPErrorLog(200, false);
+        showPHPLogDialog((entries || []).map(entry => entry.raw));
+    } catch (error) {
+        console.error('Failed to load the PHP error log:', error);
+        showNotification('Could not load the PHP error log: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// Open the saved instance templates
+async function handleShowTemplates() {
+    try {
+        showTemplatesDialog(await ListInstanceTemplates() || []);
+    } catch (error) {
+        console.error('Failed to load templates:', error);
+        showNotification('Could not load templates: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// Save the current setup under the entered name, replacing a template of the same name
+async function handleTemplateSave() {
+    const nameInput = document.getElementById('template-name');
+    const name = nameInput?.value.trim() || '';
+    if (!name) {
+        showNotification('Enter a name for the template', 'warning');
+        return;
+    }
+    try {
+        await SaveInstanceTemplate(name, document.getElementById('template-seed')?.value || '');
+        nameInput.value = '';
+        renderTemplates(await ListInstanceTemplates() || []);
+        showNotification(`Template ${name} saved`, 'success');
+    } catch (error) {
+        console.error('Failed to save template:', error);
+        showNotification('Could not save the template: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// Create an instance from a template, or delete one, from the buttons of the template list
+async function handleTemplateAction(event) {
+    const button = event.target.closest('button[data-action]');
+    if (!button) {
+        return;
+    }
+    const name = button.dataset.name;
+
+    if (button.dataset.action === 'create') {
+        hideTemplatesDialog();
+        await startMoodleContainer(() => CreateInstanceFromTemplate(name, ''));
+        loadImageName();
+        return;
+    }
+    try {
+        await DeleteInstanceTemplate(name);
+        renderTemplates(await ListInstanceTemplates() || []);
+    } catch (error) {
+        console.error('Failed to delete template:', error);
+        showNotification('Could not delete the template: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// Open the site details form
+async function handleShowSiteDetails() {
+    try {
+        showSiteDetailsDialog(await GetSiteDetails());
+    } catch (error) {
+        console.error('Failed to load site details:', error);
+        showNotification('Could not load the site details: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// Save the entered site details; the backend applies them to an installed, running site
+async function handleSiteDetailsSave() {
+    const value = id => document.getElementById(id)?.value.trim() || '';
+    try {
+        await SetSiteDetails(value('site-full-name'), value('site-short-name'), value('site-admin-email'));
+        hideSiteDetailsDialog();
+        showNotification('Site details saved', 'success');
+    } catch (error) {
+        console.error('Failed to save site details:', error);
+        showNotification('Could not save the site details: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// New PHP error log lines; acknowledging releases the next batch
+function handlePHPLogBatch(batch) {
+    appendPHPLogLines(batch.lines || []);
+    AckPHPLogBatch(batch.seq);
+}
+
+// A page failed with a PHP fatal error, usually a bug in a plugin being prototyped
+function handlePHPFatal(entry) {
+    const where = entry.file ? ` (${entry.file}:${entry.line})` : '';
+    showNotification(`PHP fatal error: ${entry.message}${where}`, 'error', 10000);
+}
+
+// Everything the app created was removed; back to a fresh start
+function handleCleanupDone(report) {
+    AppState.containerRunning = false;
+    AppState.credentials = null;
+
+    const runButton = document.getElementById('run-moodle-btn');
+    runButton.textContent = 'Run Moodle';
+    runButton.classList.remove('stop');
+
+    hideCredentials();
+    updateStatusText('Ready to run Moodle');
+    if (report.failures.length > 0) {
+        showNotification(`Cleanup finished, but ${report.failures.length} item(s) could not be removed:\n${report.failures.join('\n')}`, 'warning');
+    } else {
+        showNotification('All Moodle containers, volumes and networks were removed', 'success');
+    }
+}
+
+// The backend compared the state left by the last session with Docker after startup
+function handleReconciled(data) {
+    if (data.state === 'missing') {
+        AppState.containerRunning = false;
+        AppState.credentials = null;
+        hideCredentials();
+        updateStatusText('Ready to run Moodle');
+        showNotification('The Moodle container was deleted outside the manager; Run Moodle creates a new one', 'warning');
+        return;
+    }
+    if (data.state === 'running') {
+        if (data.previousPort) {
+            showNotification(`Moodle is still running on port ${data.hostPort}`, 'info');
+        }
+        // The login URL may have changed; show the container as running once it answers
+        loadCredentials();
+    }
+}
+
+// Quit from the quit dialog, stopping Moodle or leaving it running for the next launch
+async function handleQuit(keepRunning) {
+    const remember = document.getElementById('quit-remember')?.checked || false;
+    hideQuitDialog();
+    if (!keepRunning) {
+        updateStatusText('Stopping Moodle...');
+    }
+
+    try {
+        await Quit(keepRunning, remember);
+    } catch (error) {
+        console.error('Failed to quit:', error);
+        showNotification('Failed to quit: ' + describeErrorWithSteps(error), 'error');
+    }
+}
+
+// The quit dialog was dismissed; the app stays open
+function handleQuitCancel() {
+    hideQuitDialog();
+    CancelQuit().catch(error => console.error('Failed to cancel quit:', error));
+}
+
+// A backend call hit a bug; the call itself rejects with the error, this only makes sure the
+// user hears about it even when the caller swallows failures
+function handleAppFatal(data) {
+    console.error(`${data.binding} failed unexpectedly:`, data.error);
+    showNotification(describeErrorWithSteps(data.error), 'error');
+}
+
+// Reflect a background health check that changed mid-session, e.g. Docker Desktop quitting
+async function handleHealthChanged(change) {
+    const current = change.current;
+    AppState.dockerStatus = current.docker;
+    AppState.internetStatus = current.internet;
+    updateHealthCheckResults();
+
+    if (change.changed.includes('docker')) {
+        if (current.docker) {
+            showNotification('Docker is available again', 'success');
+        } else {
+            updateStatusText('Docker unavailable');
+            showNotification('Docker stopped responding. Start Docker to manage Moodle again.', 'error');
+        }
+    }
+    if (change.changed.includes('internet') && !current.internet) {
+        showNotification('Internet connection lost', 'warning');
+    }
+
+    if (current.docker) {
+        await applyPreflight();
+    }
+}
+
+// Show live site details and the debug level next to the credentials; rows stay hidden if
+// Moodle cannot be queried
+async function refreshSiteStatus() {
+    try {
+        displaySiteStatus(await GetSiteInfo());
+    } catch (error) {
+        console.warn('Failed to load site info:', error);
+        displaySiteStatus(null);
+    }
+    try {
+        displayDebugMode(await GetMoodleDebugMode());
+    } catch (error) {
+        console.warn('Failed to load debug mode:', error);
+        displayDebugMode(null);
+    }
+}
+
+// Switch developer debugging on or off; the checkbox goes back if the change fails
+async function handleDebugToggle(event) {
+    const toggle = event.target;
+    const level = toggle.checked ? 'developer' : 'none';
+    toggle.disabled = true;
+    try {
+        const mode = await SetMoodleDebugMode(level);
+        displayDebugMode(mode);
+        showNotification(level === 'developer' ? 'Developer debugging is on' : 'Debugging is off', 'success');
+    } catch (error) {
+        console.error('Failed to change debugging:', error);
+        toggle.checked = !toggle.checked;
+        showNotification('Failed to change debugging: ' + (error?.message || error), 'error');
+    } finally {
+        toggle.disabled = false;
+    }
+}
+
 // Load credentials from backend and check if container is running
 async function loadCredentials() {
     try {
         // First check if we have stored credentials and if container might be running
         const credentials = await wailsBindings.GetCredentials();
 
-        // If we have a valid password, it means credentials were saved previously
-        if (credentials && credentials.password && credentials.password !== '') {
+        // If we have a password, it means credentials were saved previously
+        if (credentials && credentials.hasPassword) {
             console.log('Found stored credentials, checking if container is running...');
 
             // Check if container is actually ready/running
@@ -407,6 +882,7 @@ async function loadCredentials() {
 
                     // Display credentials using the proper function
                     displayCredentials(credentials);
+                    refreshSiteStatus();
                     updateStatusText('Container running - Moodle is ready!');
                 } else {
                     console.log('Stored credentials found but container is not running');
@@ -459,6 +935,26 @@ document.addEventListener('DOMContentLoaded', function() {
     // Initialize Wails bindings
     initializeWailsBindings();
 
+    if (isWailsEnvironment()) {
+        // The window shows lifecycle and progress only; line-by-line debug output is left off the bridge
+        SubscribeEvents('main', 'progress')
+            .catch(error => console.warn('Could not set the event verbosity:', error));
+        window.runtime.EventsOn('moodle:health', updateMoodleHealth);
+        window.runtime.EventsOn('moodle:idle:stopped', handleIdleStopped);
+        window.runtime.EventsOn('health:changed', handleHealthChanged);
+        window.runtime.EventsOn('moodle:container:removed', handleContainerRemoved);
+        window.runtime.EventsOn('moodle:reconciled', handleReconciled);
+        window.runtime.EventsOn('moodle:cleanup:done', handleCleanupDone);
+        window.runtime.EventsOn('moodle:php:fatal', handlePHPFatal);
+        window.runtime.EventsOn('moodle:phplog:batch', handlePHPLogBatch);
+        window.runtime.EventsOn('app:quit:requested', showQuitDialog);
+        window.runtime.EventsOn('app:fatal', handleAppFatal);
+        window.runtime.EventsOn('app:language:changed', (info) => setErrorLanguage(info.language));
+        GetLanguage()
+            .then(info => setErrorLanguage(info.language))
+            .catch(error => console.warn('Could not read the app language:', error));
+    }
+
     // Load and display image name
     setTimeout(async function() {
         console.log('Attempting to load image name...');
@@ -476,6 +972,36 @@ document.addEventListener('DOMContentLoaded', function() {
     if (copyPasswordBtn) {
         copyPasswordBtn.addEventListener('click', handleCopyPassword);
     }
+
+    const refreshCredentialsBtn = document.getElementById('refresh-credentials-btn');
+    if (refreshCredentialsBtn) {
+        refreshCredentialsBtn.addEventListener('click', handleRefreshCredentials);
+    }
+
+    const revealPasswordBtn = document.getElementById('reveal-password-btn');
+    if (revealPasswordBtn) {
+        revealPasswordBtn.addEventListener('click', handleRevealPassword);
+    }
+
+    document.getElementById('debug-mode-toggle')?.addEventListener('change', handleDebugToggle);
+
+    document.getElementById('quit-stop')?.addEventListener('click', () => handleQuit(false));
+    document.getElementById('quit-keep')?.addEventListener('click', () => handleQuit(true));
+    document.getElementById('quit-cancel')?.addEventListener('click', handleQuitCancel);
+
+    document.getElementById('templates-btn')?.addEventListener('click', handleShowTemplates);
+    document.getElementById('templates-list')?.addEventListener('click', handleTemplateAction);
+    document.getElementById('template-save')?.addEventListener('click', handleTemplateSave);
+    document.getElementById('templates-close')?.addEventListener('click', hideTemplatesDialog);
+    document.getElementById('site-details-btn')?.addEventListener('click', handleShowSiteDetails);
+    document.getElementById('site-details-save')?.addEventListener('click', handleSiteDetailsSave);
+    document.getElementById('site-details-cancel')?.addEventListener('click', hideSiteDetailsDialog);
+    document.getElementById('phplog-btn')?.addEventListener('click', handleShowPHPLog);
+    document.getElementById('phplog-close')?.addEventListener('click', hidePHPLogDialog);
+
+    document.getElementById('cleanup-btn')?.addEventListener('click', showCleanupDialog);
+    document.getElementById('cleanup-confirm')?.addEventListener('click', handleCleanupConfirm);
+    document.getElementById('cleanup-cancel')?.addEventListener('click', hideCleanupDialog);
 });
 
 // Handle keyboard shortcuts
@@ -490,6 +1016,13 @@ document.addEventListener('keydown', function(event) {
     // Escape key to close modals
     if (event.key === 'Escape') {
         hideBrowserDialog();
+        hidePHPLogDialog();
+        hideTemplatesDialog();
+        hideSiteDetailsDialog();
+        hideCleanupDialog();
+        if (document.getElementById('quit-dialog')?.style.display === 'flex') {
+            handleQuitCancel();
+        }
         // Don't close other modals as they represent ongoing operations
     }
 });
@@ -506,10 +1039,10 @@ window.addEventListener('beforeunload', function(event) {
 // Track if health check is in progress to prevent overlapping
 let healthCheckInProgress = false;
 
-// Periodic health check (optional - every 30 seconds)
+// In Wails the backend health monitor sends health:changed; elsewhere poll every 30 seconds
 setInterval(function() {
     // Only perform periodic checks if not already checking
-    if (!healthCheckInProgress) {
+    if (!isWailsEnvironment() && !healthCheckInProgress) {
         performHealthChecks();
     }
 }, 30000);
@@ -523,44 +1056,75 @@ async function handleBrowserYes() {
         showNotification('Opening browser...', 'success');
     } catch (error) {
         console.error('Failed to open browser:', error);
-        showNotification('Failed to open browser: ' + error.message, 'error');
+        showNotification('Failed to open browser: ' + describeErrorWithSteps(error), 'error');
     }
 }
 
-// Handle copy password functionality
-async function handleCopyPassword() {
+// Reconcile the stored password and URL with the running container and show the result
+async function handleRefreshCredentials() {
+    const button = document.getElementById('refresh-credentials-btn');
+    if (button) {
+        button.disabled = true;
+    }
+
+    try {
+        const result = await RefreshCredentials();
+        const credentials = await wailsBindings.GetCredentials();
+        AppState.credentials = credentials;
+        displayCredentials(credentials);
+        showNotification(result.message, result.drift ? 'error' : 'success');
+    } catch (error) {
+        console.error('Failed to refresh credentials:', error);
+        showNotification('Failed to check credentials: ' + (error?.message || error), 'error');
+    } finally {
+        if (button) {
+            button.disabled = false;
+        }
+    }
+}
+
+// Show the password, or mask it again; the backend only sends it when asked
+async function handleRevealPassword() {
     const passwordElement = document.getElementById('password');
-    const copyButton = document.getElementById('copy-password-btn');
+    const revealButton = document.getElementById('reveal-password-btn');
+    if (!passwordElement || !revealButton) {
+        return;
+    }
 
-    if (!passwordElement || !copyButton) {
-        console.error('Password element or copy button not found');
+    if (revealButton.classList.contains('revealed')) {
+        passwordElement.textContent = AppState.credentials?.password || '-';
+        revealButton.classList.remove('revealed');
+        revealButton.title = 'Show password';
         return;
     }
 
-    const password = passwordElement.textContent;
+    try {
+        passwordElement.textContent = await wailsBindings.RevealPassword();
+        revealButton.classList.add('revealed');
+        revealButton.title = 'Hide password';
+    } catch (error) {
+        console.error('Failed to reveal password:', error);
+        showNotification('Failed to show password: ' + (error?.message || error), 'error');
+    }
+}
+
+// Handle copy password functionality; the backend writes the clipboard so the password never
+// passes through the page
+async function handleCopyPassword() {
+    const copyButton = document.getElementById('copy-password-btn');
 
-    if (!password || password === '-') {
+    if (!copyButton) {
+        console.error('Copy button not found');
+        return;
+    }
+
+    if (!AppState.credentials?.hasPassword) {
         showNotification('No password available to copy', 'error');
         return;
     }
 
     try {
-        // Use the Clipboard API if available
-        if (navigator.clipboard && window.isSecureContext) {
-            await navigator.clipboard.writeText(password);
-        } else {
-            // Fallback for older browsers or non-secure contexts
-            const textArea = document.createElement('textarea');
-            textArea.value = password;
-            textArea.style.position = 'fixed';
-            textArea.style.left = '-999999px';
-            textArea.style.top = '-999999px';
-            document.body.appendChild(textArea);
-            textArea.focus();
-            textArea.select();
-            document.execCommand('copy');
-            document.body.removeChild(textArea);
-        }
+        await wailsBindings.CopyCredentialToClipboard('password');
 
         // Visual feedback
         const originalText = copyButton.innerHTML;
@@ -575,11 +1139,11 @@ async function handleCopyPassword() {
             copyButton.className = originalClass;
         }, 2000);
 
-        showNotification('Password copied to clipboard!', 'success');
+        showNotification('Password copied to clipboard; it is cleared again in 45 seconds', 'success');
 
     } catch (error) {
         console.error('Failed to copy password:', error);
-        showNotification('Failed to copy password', 'error');
+        showNotification('Failed to copy password: ' + (error?.message || error), 'error');
     }
 }
 
@@ -19,6 +19,36 @@ export function hideDownloadModal() {
     }
 }
 
+// Clear the list of start steps before a new start
+export function resetRunSteps() {
+    const list = document.getElementById('run-steps');
+    if (list) {
+        list.innerHTML = '';
+    }
+}
+
+// Add a moodle:run:progress step to the startup modal, marking the steps before it done
+export function recordRunStep(progress) {
+    const list = document.getElementById('run-steps');
+    if (!list) {
+        return;
+    }
+
+    let item = list.lastElementChild;
+    if (!item || item.dataset.step !== progress.step) {
+        if (item) {
+            item.classList.add('done');
+        }
+        item = document.createElement('li');
+        item.dataset.step = progress.step;
+        list.appendChild(item);
+    }
+    item.classList.toggle('failed', progress.step === 'failed');
+    item.textContent = progress.message && progress.step !== 'ready'
+        ? `${progress.label}: ${progress.message}`
+        : progress.label;
+}
+
 
 // Show startup modal
 export function showStartupModal() {
@@ -96,6 +126,168 @@ export function hideBrowserDialog() {
     }
 }
 
+// Show quit dialog
+export function showQuitDialog() {
+    const modal = document.getElementById('quit-dialog');
+    if (modal) {
+        modal.style.display = 'flex';
+    }
+}
+
+// Hide quit dialog
+export function hideQuitDialog() {
+    const modal = document.getElementById('quit-dialog');
+    if (modal) {
+        modal.style.display = 'none';
+    }
+}
+
+// phpLogLimit is how many lines the PHP error log view keeps
+const phpLogLimit = 200;
+
+// Show the PHP error log view with lines, replacing what it showed
+export function showPHPLogDialog(lines) {
+    const view = document.getElementById('phplog-lines');
+    if (view) {
+        view.textContent = lines.length > 0 ? lines.slice(-phpLogLimit).join('\n') : 'No PHP errors logged since Moodle started.';
+        view.dataset.empty = lines.length === 0 ? 'true' : '';
+        view.scrollTop = view.scrollHeight;
+    }
+    const modal = document.getElementById('phplog-dialog');
+    if (modal) {
+        modal.style.display = 'flex';
+    }
+}
+
+// Hide the PHP error log view
+export function hidePHPLogDialog() {
+    const modal = document.getElementById('phplog-dialog');
+    if (modal) {
+        modal.style.display = 'none';
+    }
+}
+
+// Add new PHP error log lines to the view while it is open
+export function appendPHPLogLines(lines) {
+    const modal = document.getElementById('phplog-dialog');
+    const view = document.getElementById('phplog-lines');
+    if (!view || modal?.style.display !== 'flex' || lines.length === 0) {
+        return;
+    }
+    const shown = view.dataset.empty === 'true' ? [] : view.textContent.split('\n');
+    view.textContent = shown.concat(lines).slice(-phpLogLimit).join('\n');
+    view.dataset.empty = '';
+    view.scrollTop = view.scrollHeight;
+}
+
+// Show the saved instance templates
+export function showTemplatesDialog(templates) {
+    renderTemplates(templates);
+    const modal = document.getElementById('templates-dialog');
+    if (modal) {
+        modal.style.display = 'flex';
+    }
+}
+
+// Hide the instance templates
+export function hideTemplatesDialog() {
+    const modal = document.getElementById('templates-dialog');
+    if (modal) {
+        modal.style.display = 'none';
+    }
+}
+
+// List templates with buttons to create an instance from each or delete it; the buttons carry
+// the action and template name for the click handler
+export function renderTemplates(templates) {
+    const list = document.getElementById('templates-list');
+    if (!list) {
+        return;
+    }
+    list.replaceChildren();
+    if (templates.length === 0) {
+        const empty = document.createElement('li');
+        empty.textContent = 'No templates saved yet.';
+        list.appendChild(empty);
+        return;
+    }
+    for (const template of templates) {
+        const item = document.createElement('li');
+        const name = document.createElement('span');
+        name.className = 'template-name';
+        name.textContent = template.name;
+        name.title = [template.image, template.seedSize ? `${template.seedSize} demo data` : ''].filter(Boolean).join(', ');
+        item.appendChild(name);
+        for (const [action, label, style] of [['create', 'Create', 'primary'], ['delete', 'Delete', 'danger']]) {
+            const button = document.createElement('button');
+            button.className = `dialog-button ${style}`;
+            button.textContent = label;
+            button.dataset.action = action;
+            button.dataset.name = template.name;
+            item.appendChild(button);
+        }
+        list.appendChild(item);
+    }
+}
+
+// Show the site details form filled in with the saved details
+export function showSiteDetailsDialog(details) {
+    for (const [id, value] of [['site-full-name', details?.fullName], ['site-short-name', details?.shortName], ['site-admin-email', details?.adminEmail]]) {
+        const input = document.getElementById(id);
+        if (input) {
+            input.value = value || '';
+        }
+    }
+    const modal = document.getElementById('site-details-dialog');
+    if (modal) {
+        modal.style.display = 'flex';
+    }
+}
+
+// Hide the site details form
+export function hideSiteDetailsDialog() {
+    const modal = document.getElementById('site-details-dialog');
+    if (modal) {
+        modal.style.display = 'none';
+    }
+}
+
+// Show the emergency cleanup confirmation
+export function showCleanupDialog() {
+    const modal = document.getElementById('cleanup-dialog');
+    if (modal) {
+        modal.style.display = 'flex';
+    }
+}
+
+// Hide the emergency cleanup confirmation
+export function hideCleanupDialog() {
+    const modal = document.getElementById('cleanup-dialog');
+    if (modal) {
+        modal.style.display = 'none';
+    }
+}
+
+// Show the Moodle container's Docker health in the status bar; hidden without a health check
+export function updateMoodleHealth(health) {
+    const indicator = document.getElementById('moodle-health-indicator');
+    const circle = document.getElementById('moodle-health-status');
+    if (!indicator || !circle) {
+        return;
+    }
+
+    const classes = { healthy: 'green', starting: 'checking', unhealthy: 'red' };
+    if (!health || !classes[health.status]) {
+        indicator.style.display = 'none';
+        return;
+    }
+    indicator.style.display = 'flex';
+    circle.className = 'status-circle ' + classes[health.status];
+    indicator.title = health.lastOutput
+        ? `Moodle is ${health.status}: ${health.lastOutput}`
+        : `Moodle is ${health.status}`;
+}
+
 // Update button loading state
 export function setButtonLoading(buttonId, isLoading, loadingText = 'Loading...') {
     const button = document.getElementById(buttonId);
@@ -132,6 +324,7 @@ export function showNotification(message, type = 'info', duration = 5000) {
     notification.style.fontSize = '14px';
     notification.style.zIndex = '10000';
     notification.style.maxWidth = '300px';
+    notification.style.whiteSpace = 'pre-line';
     notification.style.boxShadow = '0 4px 6px rgba(0, 0, 0, 0.1)';
     
     // Set background color based on type
@@ -161,6 +354,168 @@ export function showNotification(message, type = 'info', duration = 5000) {
     }, duration);
 }
 
+// Show a notification with a button, kept until the button is clicked or it is dismissed
+export function showActionNotification(message, actionLabel, onAction, type = 'info') {
+    const notification = document.createElement('div');
+    notification.className = `notification notification-${type}`;
+    notification.style.position = 'fixed';
+    notification.style.top = '20px';
+    notification.style.right = '20px';
+    notification.style.padding = '12px 20px';
+    notification.style.borderRadius = '6px';
+    notification.style.color = 'white';
+    notification.style.backgroundColor = '#17a2b8';
+    notification.style.fontSize = '14px';
+    notification.style.zIndex = '10000';
+    notification.style.maxWidth = '300px';
+    notification.style.boxShadow = '0 4px 6px rgba(0, 0, 0, 0.1)';
+
+    const text = document.createElement('div');
+    text.textContent = message;
+    notification.appendChild(text);
+
+    const remove = () => {
+        if (notification.parentNode) {
+            notification.parentNode.removeChild(notification);
+        }
+    };
+
+    const buttons = document.createElement('div');
+    buttons.style.marginTop = '8px';
+    buttons.style.display = 'flex';
+    buttons.style.gap = '8px';
+
+    const action = document.createElement('button');
+    action.className = 'dialog-button primary';
+    action.textContent = actionLabel;
+    action.addEventListener('click', () => {
+        remove();
+        onAction();
+    });
+
+    const dismiss = document.createElement('button');
+    dismiss.className = 'dialog-button secondary';
+    dismiss.textContent = 'Dismiss';
+    dismiss.addEventListener('click', remove);
+
+    buttons.appendChild(action);
+    buttons.appendChild(dismiss);
+    notification.appendChild(buttons);
+    document.body.appendChild(notification);
+}
+
+// Messages for the error codes sent by the backend, per language
+const errorMessages = {
+    en: {
+        DOCKER_NOT_INSTALLED: 'Docker is not installed. Install Docker Desktop and try again.',
+        DOCKER_NOT_RUNNING: 'Docker is not running. Start Docker Desktop and try again.',
+        DOCKER_PERMISSION_DENIED: 'You do not have permission to use Docker. Add your user to the docker group or run Docker Desktop.',
+        IMAGE_NOT_FOUND: 'The Moodle image could not be found. Check the image name in image.docker.',
+        PULL_AUTH_FAILED: 'The registry refused the download. Log in with "docker login" and try again.',
+        CONTAINER_NOT_FOUND: 'The Moodle container no longer exists. Run Moodle to create a new one.',
+        CONTAINER_ALREADY_RUNNING: 'Moodle is already running.',
+        CONTAINER_NOT_RUNNING: 'Moodle is not running. Start it first.',
+        PORT_IN_USE: 'The port Moodle uses is taken by another program. Close it or choose another port in settings.',
+        FILE_PERMISSION_DENIED: 'A file in the data folder could not be written. Check its permissions.',
+        NETWORK_UNAVAILABLE: 'No network connection. Check your internet connection and try again.',
+        TIMEOUT: 'The operation took too long. Try again in a moment.',
+        OPERATION_IN_PROGRESS: 'Another operation is still running. Wait for it to finish.',
+        READ_ONLY_INSTANCE: 'Another Moodle Prototype Manager window is running; this one is read-only.',
+        STATE_FROM_NEWER_VERSION: 'Your settings were saved by a newer version. Update the app on this machine.',
+        INTERNAL_ERROR: 'Something went wrong inside Moodle Prototype Manager. Try again, or restart the app if it keeps happening.'
+    },
+    de: {
+        DOCKER_NOT_INSTALLED: 'Docker ist nicht installiert. Installieren Sie Docker Desktop und versuchen Sie es erneut.',
+        DOCKER_NOT_RUNNING: 'Docker läuft nicht. Starten Sie Docker Desktop und versuchen Sie es erneut.',
+        DOCKER_PERMISSION_DENIED: 'Sie haben keine Berechtigung, Docker zu verwenden. Fügen Sie Ihren Benutzer der Gruppe docker hinzu oder starten Sie Docker Desktop.',
+        IMAGE_NOT_FOUND: 'Das Moodle-Image wurde nicht gefunden. Prüfen Sie den Image-Namen in image.docker.',
+        PULL_AUTH_FAILED: 'Die Registry hat den Download abgelehnt. Melden Sie sich mit "docker login" an und versuchen Sie es erneut.',
+        CONTAINER_NOT_FOUND: 'Der Moodle-Container existiert nicht mehr. Starten Sie Moodle, um einen neuen zu erstellen.',
+        CONTAINER_ALREADY_RUNNING: 'Moodle läuft bereits.',
+        CONTAINER_NOT_RUNNING: 'Moodle läuft nicht. Starten Sie es zuerst.',
+        PORT_IN_USE: 'Der Port von Moodle wird von einem anderen Programm belegt. Beenden Sie es oder wählen Sie in den Einstellungen einen anderen Port.',
+        FILE_PERMISSION_DENIED: 'Eine Datei im Datenordner konnte nicht geschrieben werden. Prüfen Sie ihre Berechtigungen.',
+        NETWORK_UNAVAILABLE: 'Keine Netzwerkverbindung. Prüfen Sie Ihre Internetverbindung und versuchen Sie es erneut.',
+        TIMEOUT: 'Der Vorgang hat zu lange gedauert. Versuchen Sie es gleich noch einmal.',
+        OPERATION_IN_PROGRESS: 'Ein anderer Vorgang läuft noch. Warten Sie, bis er abgeschlossen ist.',
+        READ_ONLY_INSTANCE: 'Ein anderes Fenster von Moodle Prototype Manager läuft bereits; dieses ist schreibgeschützt.',
+        STATE_FROM_NEWER_VERSION: 'Ihre Einstellungen wurden von einer neueren Version gespeichert. Aktualisieren Sie die App auf diesem Rechner.',
+        INTERNAL_ERROR: 'In Moodle Prototype Manager ist ein Fehler aufgetreten. Versuchen Sie es erneut oder starten Sie die App neu, wenn er wieder auftritt.'
+    },
+    es: {
+        DOCKER_NOT_INSTALLED: 'Docker no está instalado. Instale Docker Desktop e inténtelo de nuevo.',
+        DOCKER_NOT_RUNNING: 'Docker no se está ejecutando. Inicie Docker Desktop e inténtelo de nuevo.',
+        DOCKER_PERMISSION_DENIED: 'No tiene permiso para usar Docker. Añada su usuario al grupo docker o ejecute Docker Desktop.',
+        IMAGE_NOT_FOUND: 'No se encontró la imagen de Moodle. Compruebe el nombre de la imagen en image.docker.',
+        PULL_AUTH_FAILED: 'El registro rechazó la descarga. Inicie sesión con "docker login" e inténtelo de nuevo.',
+        CONTAINER_NOT_FOUND: 'El contenedor de Moodle ya no existe. Ejecute Moodle para crear uno nuevo.',
+        CONTAINER_ALREADY_RUNNING: 'Moodle ya se está ejecutando.',
+        CONTAINER_NOT_RUNNING: 'Moodle no se está ejecutando. Inícielo primero.',
+        PORT_IN_USE: 'Otro programa ocupa el puerto que usa Moodle. Ciérrelo o elija otro puerto en los ajustes.',
+        FILE_PERMISSION_DENIED: 'No se pudo escribir un archivo de la carpeta de datos. Compruebe sus permisos.',
+        NETWORK_UNAVAILABLE: 'No hay conexión de red. Compruebe su conexión a Internet e inténtelo de nuevo.',
+        TIMEOUT: 'La operación tardó demasiado. Inténtelo de nuevo en un momento.',
+        OPERATION_IN_PROGRESS: 'Otra operación sigue en curso. Espere a que termine.',
+        READ_ONLY_INSTANCE: 'Ya hay otra ventana de Moodle Prototype Manager abierta; esta es de solo lectura.',
+        STATE_FROM_NEWER_VERSION: 'Sus ajustes se guardaron con una versión más reciente. Actualice la aplicación en este equipo.',
+        INTERNAL_ERROR: 'Algo salió mal en Moodle Prototype Manager. Inténtelo de nuevo o reinicie la aplicación si vuelve a ocurrir.'
+    },
+    fr: {
+        DOCKER_NOT_INSTALLED: 'Docker n\'est pas installé. Installez Docker Desktop et réessayez.',
+        DOCKER_NOT_RUNNING: 'Docker n\'est pas démarré. Lancez Docker Desktop et réessayez.',
+        DOCKER_PERMISSION_DENIED: 'Vous n\'avez pas l\'autorisation d\'utiliser Docker. Ajoutez votre utilisateur au groupe docker ou lancez Docker Desktop.',
+        IMAGE_NOT_FOUND: 'L\'image Moodle est introuvable. Vérifiez le nom de l\'image dans image.docker.',
+        PULL_AUTH_FAILED: 'Le registre a refusé le téléchargement. Connectez-vous avec "docker login" et réessayez.',
+        CONTAINER_NOT_FOUND: 'Le conteneur Moodle n\'existe plus. Lancez Moodle pour en créer un nouveau.',
+        CONTAINER_ALREADY_RUNNING: 'Moodle est déjà en cours d\'exécution.',
+        CONTAINER_NOT_RUNNING: 'Moodle n\'est pas en cours d\'exécution. Démarrez-le d\'abord.',
+        PORT_IN_USE: 'Le port utilisé par Moodle est occupé par un autre programme. Fermez-le ou choisissez un autre port dans les paramètres.',
+        FILE_PERMISSION_DENIED: 'Un fichier du dossier de données n\'a pas pu être écrit. Vérifiez ses permissions.',
+        NETWORK_UNAVAILABLE: 'Aucune connexion réseau. Vérifiez votre connexion Internet et réessayez.',
+        TIMEOUT: 'L\'opération a pris trop de temps. Réessayez dans un instant.',
+        OPERATION_IN_PROGRESS: 'Une autre opération est en cours. Attendez qu\'elle se termine.',
+        READ_ONLY_INSTANCE: 'Une autre fenêtre de Moodle Prototype Manager est ouverte ; celle-ci est en lecture seule.',
+        STATE_FROM_NEWER_VERSION: 'Vos paramètres ont été enregistrés par une version plus récente. Mettez à jour l\'application sur cette machine.',
+        INTERNAL_ERROR: 'Une erreur s\'est produite dans Moodle Prototype Manager. Réessayez, ou redémarrez l\'application si le problème persiste.'
+    }
+};
+
+// The backend's language, which follows the language setting or the system locale; the
+// webview's navigator.language does not always match it
+let errorLanguage = null;
+
+// Show error messages in the backend's language
+export function setErrorLanguage(language) {
+    errorLanguage = language;
+}
+
+// Turn an error from a backend call into a message for the user. Errors carry a stable
+// code; unknown codes and plain errors fall back to the backend's message.
+export function describeError(error, fallback = 'Unknown error') {
+    if (!error) {
+        return fallback;
+    }
+    if (typeof error === 'string') {
+        return error;
+    }
+
+    const language = errorLanguage || (navigator.language || 'en').split('-')[0];
+    const messages = errorMessages[language] || errorMessages.en;
+    if (error.code && messages[error.code]) {
+        return messages[error.code];
+    }
+    return error.message || fallback;
+}
+
+// Like describeError, followed by the remediation steps the backend suggests, one per line
+export function describeErrorWithSteps(error, fallback = 'Unknown error') {
+    const message = describeError(error, fallback);
+    const steps = (error && Array.isArray(error.suggestions)) ? error.suggestions : [];
+    if (steps.length === 0) {
+        return message;
+    }
+    return message + '\n' + steps.map(step => '• ' + step).join('\n');
+}
 
 // Display credentials in the UI
 export function displayCredentials(credentials) {
@@ -173,8 +528,15 @@ export function displayCredentials(credentials) {
     }
     
     if (passwordElement) {
+        // Masked unless the user reveals it
         passwordElement.textContent = credentials.password || '-';
     }
+
+    const revealButton = document.getElementById('reveal-password-btn');
+    if (revealButton) {
+        revealButton.classList.remove('revealed');
+        revealButton.title = 'Show password';
+    }
     
     if (urlElement) {
         const url = credentials.url || 'http://localhost:8080';
@@ -183,6 +545,43 @@ export function displayCredentials(credentials) {
     }
 }
 
+// Show what is in the Moodle site (version, courses, users, last cron run); hidden when unknown
+export function displaySiteStatus(status) {
+    const row = document.getElementById('site-status-row');
+    const value = document.getElementById('site-status');
+    if (!row || !value) {
+        return;
+    }
+    if (!status) {
+        row.style.display = 'none';
+        return;
+    }
+
+    const cronRun = new Date(status.cronLastRun);
+    const cron = isNaN(cronRun) || cronRun.getFullYear() < 2000
+        ? 'cron never ran'
+        : `cron ran ${cronRun.toLocaleString()}`;
+    value.textContent = `${status.release} · ${status.courses} courses · ${status.users} users · ${cron}`;
+    value.title = status.siteName;
+    row.style.display = '';
+}
+
+// Show whether developer debugging is on; the row is hidden when the level cannot be read
+export function displayDebugMode(mode) {
+    const row = document.getElementById('debug-mode-row');
+    const toggle = document.getElementById('debug-mode-toggle');
+    if (!row || !toggle) {
+        return;
+    }
+    if (!mode) {
+        row.style.display = 'none';
+        return;
+    }
+    toggle.checked = mode.level === 'developer';
+    toggle.title = `Current level: ${mode.level}`;
+    row.style.display = '';
+}
+
 // Handle URL click - use backend OpenBrowser instead of direct navigation
 window.handleUrlClick = function(event) {
     event.preventDefault();
@@ -1,16 +1,291 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {docker} from '../models';
+import {bundle} from '../models';
+import {main} from '../models';
+import {fleet} from '../models';
+import {storage} from '../models';
+import {moodle} from '../models';
+import {kiosk} from '../models';
 
-export function GetCredentials():Promise<Record<string, string>>;
+export function AckLogBatch(arg1:number):Promise<void>;
+
+export function AckPHPLogBatch(arg1:number):Promise<void>;
+
+export function AdoptContainer(arg1:string):Promise<void>;
+
+export function ApplyMemoryLimit(arg1:number):Promise<void>;
+
+export function CancelQuit():Promise<void>;
+
+export function CaptureProfile(arg1:string):Promise<docker.ProfileResult>;
+
+export function CaptureScreenshots():Promise<docker.ScreenshotResult>;
+
+export function CheckAssetUpdates():Promise<bundle.UpdateInfo>;
+
+export function CheckForImageUpdate():Promise<docker.ImageUpdateInfo>;
+
+export function CheckOOMKill():Promise<docker.OOMRecommendation>;
+
+export function CheckStateCompatibility():Promise<void>;
+
+export function CleanupUnused():Promise<docker.CleanupResult>;
+
+export function CloneSnapshot(arg1:string,arg2:string):Promise<main.ClonedInstance>;
+
+export function CopyCredentialToClipboard(arg1:string):Promise<void>;
+
+export function CreateInstanceFromTemplate(arg1:string,arg2:string):Promise<void>;
+
+export function DeleteInstanceTemplate(arg1:string):Promise<void>;
+
+export function DeleteSnapshot(arg1:string):Promise<void>;
+
+export function DisableXdebug():Promise<void>;
+
+export function DiscoverFleet():Promise<Array<fleet.Member>>;
+
+export function EmergencyCleanup():Promise<docker.CleanupReport>;
+
+export function EnableXdebug():Promise<docker.XdebugInfo>;
+
+export function ExportCompose(arg1:string):Promise<string>;
+
+export function ExportForProduction(arg1:string):Promise<docker.ProductionExport>;
+
+export function ExportInstance(arg1:string):Promise<docker.InstanceArchive>;
+
+export function FleetBroadcast(arg1:string):Promise<Array<fleet.CommandResult>>;
+
+export function FollowLogs(arg1:number):Promise<void>;
+
+export function GenerateChangeReport(arg1:string):Promise<storage.ChangeReport>;
+
+export function GetAdminerInfo():Promise<docker.AdminerInfo>;
+
+export function GetAppInfo():Promise<main.AppInfo>;
+
+export function GetContainerHealth():Promise<docker.HealthReport>;
+
+export function GetContainerLogLevels():Promise<docker.LogLevels>;
+
+export function GetContainerStats():Promise<docker.ContainerStats>;
+
+export function GetControlAPIInfo():Promise<main.ControlAPIInfo>;
+
+export function GetCourseEnrolments(arg1:number):Promise<Array<moodle.EnrolledUser>>;
+
+export function GetCredentials():Promise<storage.Credentials>;
+
+export function GetCronStatus():Promise<docker.CronStatus>;
+
+export function GetDockerDiskUsage():Promise<docker.DockerDiskUsage>;
+
+export function GetImageAdapter():Promise<docker.ImageAdapter>;
 
 export function GetImageName():Promise<string>;
 
-export function HealthCheck():Promise<Record<string, boolean>>;
+export function GetInstalledBrowsers():Promise<Array<string>>;
+
+export function GetInstanceLockStatus():Promise<main.InstanceLockStatus>;
+
+export function GetInterruptedPull():Promise<docker.PullSnapshot>;
+
+export function GetKioskAPIInfo():Promise<main.KioskAPIInfo>;
+
+export function GetKioskStatus():Promise<kiosk.Status>;
+
+export function GetLanguage():Promise<main.LanguageInfo>;
+
+export function GetMaskedCredentials():Promise<storage.MaskedCredentials>;
+
+export function GetMoodleDebugMode():Promise<docker.DebugMode>;
+
+export function GetNotificationSettings():Promise<storage.NotificationSettings>;
+
+export function GetOperationHistory(arg1:number):Promise<Array<storage.OperationRecord>>;
+
+export function GetPHPErrorLog(arg1:number,arg2:boolean):Promise<Array<docker.PHPLogEntry>>;
+
+export function GetPerformanceStats():Promise<docker.PerformanceStats>;
+
+export function GetPrePullStatus():Promise<docker.PrePullStatus>;
+
+export function GetQuotaReport():Promise<Array<docker.QuotaStatus>>;
+
+export function GetRecentLogs(arg1:number):Promise<docker.LogChunk>;
+
+export function GetResourceReport():Promise<docker.ResourceReport>;
+
+export function GetSiteDetails():Promise<storage.SiteSettings>;
+
+export function GetSiteInfo():Promise<main.SiteStatus>;
+
+export function GetTelemetrySettings():Promise<storage.TelemetrySettings>;
+
+export function GetTimeline():Promise<Array<storage.TimelineEntry>>;
+
+export function GetVirtualizationStatus():Promise<docker.VirtualizationStatus>;
+
+export function HealthCheck():Promise<main.HealthCheckResult>;
+
+export function ImportInstance(arg1:string):Promise<main.InstanceImport>;
+
+export function InstallPlugin(arg1:string):Promise<docker.PluginInstallResult>;
 
 export function IsContainerReady():Promise<boolean>;
 
+export function IsRecording():Promise<boolean>;
+
+export function ListAvailableImageTags(arg1:string):Promise<Array<string>>;
+
+export function ListAvailableImages():Promise<Array<main.ImageOption>>;
+
+export function ListCourses():Promise<Array<moodle.Course>>;
+
+export function ListInstanceTemplates():Promise<Array<storage.InstanceTemplate>>;
+
+export function ListInstances(arg1:string):Promise<Array<main.InstanceInfo>>;
+
+export function ListOrphanedContainers():Promise<Array<docker.ManagedContainer>>;
+
+export function ListOtherUsersContainers():Promise<Array<docker.ManagedContainer>>;
+
+export function ListSnapshots():Promise<Array<docker.Snapshot>>;
+
+export function ListUndoableActions():Promise<Array<main.UndoableAction>>;
+
+export function LoadImageFromFile(arg1:string):Promise<docker.ImageLoadResult>;
+
 export function OpenBrowser():Promise<void>;
 
+export function OpenDataDirectory():Promise<void>;
+
+export function OpenLogsDirectory():Promise<void>;
+
+export function OpenMailUI():Promise<void>;
+
+export function PurgeDemoUsers():Promise<docker.PurgeResult>;
+
+export function Quit(arg1:boolean,arg2:boolean):Promise<void>;
+
+export function RefreshCredentials():Promise<main.CredentialRefresh>;
+
+export function RegenerateControlAPIToken():Promise<main.ControlAPIInfo>;
+
+export function RemoveContainer(arg1:boolean):Promise<void>;
+
+export function RemoveOrphanedContainer(arg1:string):Promise<void>;
+
+export function ResetPerformanceStats():Promise<void>;
+
+export function ResumePull():Promise<void>;
+
+export function RevealPassword():Promise<string>;
+
+export function RunCronNow():Promise<void>;
+
 export function RunMoodle():Promise<void>;
 
+export function RunMoodleTests(arg1:string,arg2:boolean):Promise<Array<docker.TestRunResult>>;
+
+export function SaveImageToFile(arg1:string):Promise<docker.ImageSaveResult>;
+
+export function SaveInstanceTemplate(arg1:string,arg2:string):Promise<storage.InstanceTemplate>;
+
+export function SeedDemoData(arg1:string):Promise<docker.SeedResult>;
+
+export function SelectImage(arg1:string):Promise<void>;
+
+export function SendTestNotification(arg1:string):Promise<void>;
+
+export function SetAdminer(arg1:boolean):Promise<void>;
+
+export function SetAutoRestart(arg1:boolean):Promise<void>;
+
+export function SetBrowser(arg1:string,arg2:string,arg3:boolean,arg4:string):Promise<void>;
+
+export function SetContainerEnv(arg1:Record<string, string>):Promise<void>;
+
+export function SetContainerLogLevels(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetControlAPI(arg1:boolean,arg2:number):Promise<main.ControlAPIInfo>;
+
+export function SetCronSettings(arg1:boolean,arg2:number):Promise<void>;
+
+export function SetDockerHost(arg1:string,arg2:string,arg3:boolean,arg4:string):Promise<void>;
+
+export function SetEmailNotifications(arg1:storage.SMTPSettings):Promise<void>;
+
+export function SetExitAction(arg1:string):Promise<void>;
+
+export function SetFleetMode(arg1:boolean,arg2:string,arg3:number):Promise<void>;
+
+export function SetHTTPS(arg1:boolean,arg2:number):Promise<void>;
+
+export function SetHealthMonitor(arg1:boolean,arg2:number):Promise<void>;
+
+export function SetHostname(arg1:string):Promise<void>;
+
+export function SetIdleStop(arg1:boolean,arg2:number):Promise<void>;
+
+export function SetInstallCleanup(arg1:string):Promise<void>;
+
+export function SetInstanceTags(arg1:string,arg2:Array<string>):Promise<Array<string>>;
+
+export function SetKioskMode(arg1:boolean,arg2:number):Promise<main.KioskAPIInfo>;
+
+export function SetLANSharing(arg1:boolean,arg2:string):Promise<void>;
+
+export function SetLanguage(arg1:string):Promise<main.LanguageInfo>;
+
+export function SetLogScanWindow(arg1:number,arg2:number):Promise<void>;
+
+export function SetMailCatcher(arg1:boolean):Promise<void>;
+
+export function SetMaxInstallTime(arg1:number):Promise<void>;
+
+export function SetMoodleDebugMode(arg1:string):Promise<docker.DebugMode>;
+
+export function SetNightlyPrePull(arg1:boolean,arg2:number,arg3:number):Promise<void>;
+
+export function SetNotificationRoute(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function SetProxySettings(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetResourceAlerts(arg1:boolean,arg2:number,arg3:number):Promise<void>;
+
+export function SetSiteDetails(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetStopTimeout(arg1:number):Promise<void>;
+
+export function SetTelemetry(arg1:boolean,arg2:string):Promise<void>;
+
+export function SetWakeProxy(arg1:boolean,arg2:number,arg3:string):Promise<void>;
+
+export function SetWebhookNotifications(arg1:string):Promise<void>;
+
+export function SetWorkspaceQuota(arg1:string,arg2:number):Promise<void>;
+
+export function SnapshotInstance(arg1:string):Promise<docker.Snapshot>;
+
+export function StartRecording():Promise<void>;
+
+export function StopFollowingLogs():Promise<void>;
+
 export function StopMoodle():Promise<void>;
+
+export function StopRecording(arg1:string):Promise<string>;
+
+export function SubscribeEvents(arg1:string,arg2:string):Promise<void>;
+
+export function UndoLastAction():Promise<main.UndoableAction>;
+
+export function UnsubscribeEvents(arg1:string):Promise<void>;
+
+export function UpdateAssets():Promise<bundle.UpdateInfo>;
+
+export function UpdateImage():Promise<void>;
+
+export function ValidateEnvironment():Promise<docker.PreflightReport>;
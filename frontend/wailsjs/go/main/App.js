@@ -2,30 +2,566 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AckLogBatch(arg1) {
+  return window['go']['main']['App']['AckLogBatch'](arg1);
+}
+
+export function AckPHPLogBatch(arg1) {
+  return window['go']['main']['App']['AckPHPLogBatch'](arg1);
+}
+
+export function AdoptContainer(arg1) {
+  return window['go']['main']['App']['AdoptContainer'](arg1);
+}
+
+export function ApplyMemoryLimit(arg1) {
+  return window['go']['main']['App']['ApplyMemoryLimit'](arg1);
+}
+
+export function CancelQuit() {
+  return window['go']['main']['App']['CancelQuit']();
+}
+
+export function CaptureProfile(arg1) {
+  return window['go']['main']['App']['CaptureProfile'](arg1);
+}
+
+export function CaptureScreenshots() {
+  return window['go']['main']['App']['CaptureScreenshots']();
+}
+
+export function CheckAssetUpdates() {
+  return window['go']['main']['App']['CheckAssetUpdates']();
+}
+
+export function CheckForImageUpdate() {
+  return window['go']['main']['App']['CheckForImageUpdate']();
+}
+
+export function CheckOOMKill() {
+  return window['go']['main']['App']['CheckOOMKill']();
+}
+
+export function CheckStateCompatibility() {
+  return window['go']['main']['App']['CheckStateCompatibility']();
+}
+
+export function CleanupUnused() {
+  return window['go']['main']['App']['CleanupUnused']();
+}
+
+export function CloneSnapshot(arg1, arg2) {
+  return window['go']['main']['App']['CloneSnapshot'](arg1, arg2);
+}
+
+export function CopyCredentialToClipboard(arg1) {
+  return window['go']['main']['App']['CopyCredentialToClipboard'](arg1);
+}
+
+export function CreateInstanceFromTemplate(arg1, arg2) {
+  return window['go']['main']['App']['CreateInstanceFromTemplate'](arg1, arg2);
+}
+
+export function DeleteInstanceTemplate(arg1) {
+  return window['go']['main']['App']['DeleteInstanceTemplate'](arg1);
+}
+
+export function DeleteSnapshot(arg1) {
+  return window['go']['main']['App']['DeleteSnapshot'](arg1);
+}
+
+export function DisableXdebug() {
+  return window['go']['main']['App']['DisableXdebug']();
+}
+
+export function DiscoverFleet() {
+  return window['go']['main']['App']['DiscoverFleet']();
+}
+
+export function EmergencyCleanup() {
+  return window['go']['main']['App']['EmergencyCleanup']();
+}
+
+export function EnableXdebug() {
+  return window['go']['main']['App']['EnableXdebug']();
+}
+
+export function ExportCompose(arg1) {
+  return window['go']['main']['App']['ExportCompose'](arg1);
+}
+
+export function ExportForProduction(arg1) {
+  return window['go']['main']['App']['ExportForProduction'](arg1);
+}
+
+export function ExportInstance(arg1) {
+  return window['go']['main']['App']['ExportInstance'](arg1);
+}
+
+export function FleetBroadcast(arg1) {
+  return window['go']['main']['App']['FleetBroadcast'](arg1);
+}
+
+export function FollowLogs(arg1) {
+  return window['go']['main']['App']['FollowLogs'](arg1);
+}
+
+export function GenerateChangeReport(arg1) {
+  return window['go']['main']['App']['GenerateChangeReport'](arg1);
+}
+
+export function GetAdminerInfo() {
+  return window['go']['main']['App']['GetAdminerInfo']();
+}
+
+export function GetAppInfo() {
+  return window['go']['main']['App']['GetAppInfo']();
+}
+
+export function GetContainerHealth() {
+  return window['go']['main']['App']['GetContainerHealth']();
+}
+
+export function GetContainerLogLevels() {
+  return window['go']['main']['App']['GetContainerLogLevels']();
+}
+
+export function GetContainerStats() {
+  return window['go']['main']['App']['GetContainerStats']();
+}
+
+export function GetControlAPIInfo() {
+  return window['go']['main']['App']['GetControlAPIInfo']();
+}
+
+export function GetCourseEnrolments(arg1) {
+  return window['go']['main']['App']['GetCourseEnrolments'](arg1);
+}
+
 export function GetCredentials() {
   return window['go']['main']['App']['GetCredentials']();
 }
 
+export function GetCronStatus() {
+  return window['go']['main']['App']['GetCronStatus']();
+}
+
+export function GetDockerDiskUsage() {
+  return window['go']['main']['App']['GetDockerDiskUsage']();
+}
+
+export function GetImageAdapter() {
+  return window['go']['main']['App']['GetImageAdapter']();
+}
+
 export function GetImageName() {
   return window['go']['main']['App']['GetImageName']();
 }
 
+export function GetInstalledBrowsers() {
+  return window['go']['main']['App']['GetInstalledBrowsers']();
+}
+
+export function GetInstanceLockStatus() {
+  return window['go']['main']['App']['GetInstanceLockStatus']();
+}
+
+export function GetInterruptedPull() {
+  return window['go']['main']['App']['GetInterruptedPull']();
+}
+
+export function GetKioskAPIInfo() {
+  return window['go']['main']['App']['GetKioskAPIInfo']();
+}
+
+export function GetKioskStatus() {
+  return window['go']['main']['App']['GetKioskStatus']();
+}
+
+export function GetLanguage() {
+  return window['go']['main']['App']['GetLanguage']();
+}
+
+export function GetMaskedCredentials() {
+  return window['go']['main']['App']['GetMaskedCredentials']();
+}
+
+export function GetMoodleDebugMode() {
+  return window['go']['main']['App']['GetMoodleDebugMode']();
+}
+
+export function GetNotificationSettings() {
+  return window['go']['main']['App']['GetNotificationSettings']();
+}
+
+export function GetOperationHistory(arg1) {
+  return window['go']['main']['App']['GetOperationHistory'](arg1);
+}
+
+export function GetPHPErrorLog(arg1, arg2) {
+  return window['go']['main']['App']['GetPHPErrorLog'](arg1, arg2);
+}
+
+export function GetPerformanceStats() {
+  return window['go']['main']['App']['GetPerformanceStats']();
+}
+
+export function GetPrePullStatus() {
+  return window['go']['main']['App']['GetPrePullStatus']();
+}
+
+export function GetQuotaReport() {
+  return window['go']['main']['App']['GetQuotaReport']();
+}
+
+export function GetRecentLogs(arg1) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1);
+}
+
+export function GetResourceReport() {
+  return window['go']['main']['App']['GetResourceReport']();
+}
+
+export function GetSiteDetails() {
+  return window['go']['main']['App']['GetSiteDetails']();
+}
+
+export function GetSiteInfo() {
+  return window['go']['main']['App']['GetSiteInfo']();
+}
+
+export function GetTelemetrySettings() {
+  return window['go']['main']['App']['GetTelemetrySettings']();
+}
+
+export function GetTimeline() {
+  return window['go']['main']['App']['GetTimeline']();
+}
+
+export function GetVirtualizationStatus() {
+  return window['go']['main']['App']['GetVirtualizationStatus']();
+}
+
 export function HealthCheck() {
   return window['go']['main']['App']['HealthCheck']();
 }
 
+export function ImportInstance(arg1) {
+  return window['go']['main']['App']['ImportInstance'](arg1);
+}
+
+export function InstallPlugin(arg1) {
+  return window['go']['main']['App']['InstallPlugin'](arg1);
+}
+
 export function IsContainerReady() {
   return window['go']['main']['App']['IsContainerReady']();
 }
 
+export function IsRecording() {
+  return window['go']['main']['App']['IsRecording']();
+}
+
+export function ListAvailableImageTags(arg1) {
+  return window['go']['main']['App']['ListAvailableImageTags'](arg1);
+}
+
+export function ListAvailableImages() {
+  return window['go']['main']['App']['ListAvailableImages']();
+}
+
+export function ListCourses() {
+  return window['go']['main']['App']['ListCourses']();
+}
+
+export function ListInstanceTemplates() {
+  return window['go']['main']['App']['ListInstanceTemplates']();
+}
+
+export function ListInstances(arg1) {
+  return window['go']['main']['App']['ListInstances'](arg1);
+}
+
+export function ListOrphanedContainers() {
+  return window['go']['main']['App']['ListOrphanedContainers']();
+}
+
+export function ListOtherUsersContainers() {
+  return window['go']['main']['App']['ListOtherUsersContainers']();
+}
+
+export function ListSnapshots() {
+  return window['go']['main']['App']['ListSnapshots']();
+}
+
+export function ListUndoableActions() {
+  return window['go']['main']['App']['ListUndoableActions']();
+}
+
+export function LoadImageFromFile(arg1) {
+  return window['go']['main']['App']['LoadImageFromFile'](arg1);
+}
+
 export function OpenBrowser() {
   return window['go']['main']['App']['OpenBrowser']();
 }
 
+export function OpenDataDirectory() {
+  return window['go']['main']['App']['OpenDataDirectory']();
+}
+
+export function OpenLogsDirectory() {
+  return window['go']['main']['App']['OpenLogsDirectory']();
+}
+
+export function OpenMailUI() {
+  return window['go']['main']['App']['OpenMailUI']();
+}
+
+export function PurgeDemoUsers() {
+  return window['go']['main']['App']['PurgeDemoUsers']();
+}
+
+export function Quit(arg1, arg2) {
+  return window['go']['main']['App']['Quit'](arg1, arg2);
+}
+
+export function RefreshCredentials() {
+  return window['go']['main']['App']['RefreshCredentials']();
+}
+
+export function RegenerateControlAPIToken() {
+  return window['go']['main']['App']['RegenerateControlAPIToken']();
+}
+
+export function RemoveContainer(arg1) {
+  return window['go']['main']['App']['RemoveContainer'](arg1);
+}
+
+export function RemoveOrphanedContainer(arg1) {
+  return window['go']['main']['App']['RemoveOrphanedContainer'](arg1);
+}
+
+export function ResetPerformanceStats() {
+  return window['go']['main']['App']['ResetPerformanceStats']();
+}
+
+export function ResumePull() {
+  return window['go']['main']['App']['ResumePull']();
+}
+
+export function RevealPassword() {
+  return window['go']['main']['App']['RevealPassword']();
+}
+
+export function RunCronNow() {
+  return window['go']['main']['App']['RunCronNow']();
+}
+
 export function RunMoodle() {
   return window['go']['main']['App']['RunMoodle']();
 }
 
+export function RunMoodleTests(arg1, arg2) {
+  return window['go']['main']['App']['RunMoodleTests'](arg1, arg2);
+}
+
+export function SaveImageToFile(arg1) {
+  return window['go']['main']['App']['SaveImageToFile'](arg1);
+}
+
+export function SaveInstanceTemplate(arg1, arg2) {
+  return window['go']['main']['App']['SaveInstanceTemplate'](arg1, arg2);
+}
+
+export function SeedDemoData(arg1) {
+  return window['go']['main']['App']['SeedDemoData'](arg1);
+}
+
+export function SelectImage(arg1) {
+  return window['go']['main']['App']['SelectImage'](arg1);
+}
+
+export function SendTestNotification(arg1) {
+  return window['go']['main']['App']['SendTestNotification'](arg1);
+}
+
+export function SetAdminer(arg1) {
+  return window['go']['main']['App']['SetAdminer'](arg1);
+}
+
+export function SetAutoRestart(arg1) {
+  return window['go']['main']['App']['SetAutoRestart'](arg1);
+}
+
+export function SetBrowser(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetBrowser'](arg1, arg2, arg3, arg4);
+}
+
+export function SetContainerEnv(arg1) {
+  return window['go']['main']['App']['SetContainerEnv'](arg1);
+}
+
+export function SetContainerLogLevels(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetContainerLogLevels'](arg1, arg2, arg3);
+}
+
+export function SetControlAPI(arg1, arg2) {
+  return window['go']['main']['App']['SetControlAPI'](arg1, arg2);
+}
+
+export function SetCronSettings(arg1, arg2) {
+  return window['go']['main']['App']['SetCronSettings'](arg1, arg2);
+}
+
+export function SetDockerHost(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetDockerHost'](arg1, arg2, arg3, arg4);
+}
+
+export function SetEmailNotifications(arg1) {
+  return window['go']['main']['App']['SetEmailNotifications'](arg1);
+}
+
+export function SetExitAction(arg1) {
+  return window['go']['main']['App']['SetExitAction'](arg1);
+}
+
+export function SetFleetMode(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetFleetMode'](arg1, arg2, arg3);
+}
+
+export function SetHTTPS(arg1, arg2) {
+  return window['go']['main']['App']['SetHTTPS'](arg1, arg2);
+}
+
+export function SetHealthMonitor(arg1, arg2) {
+  return window['go']['main']['App']['SetHealthMonitor'](arg1, arg2);
+}
+
+export function SetHostname(arg1) {
+  return window['go']['main']['App']['SetHostname'](arg1);
+}
+
+export function SetIdleStop(arg1, arg2) {
+  return window['go']['main']['App']['SetIdleStop'](arg1, arg2);
+}
+
+export function SetInstallCleanup(arg1) {
+  return window['go']['main']['App']['SetInstallCleanup'](arg1);
+}
+
+export function SetInstanceTags(arg1, arg2) {
+  return window['go']['main']['App']['SetInstanceTags'](arg1, arg2);
+}
+
+export function SetKioskMode(arg1, arg2) {
+  return window['go']['main']['App']['SetKioskMode'](arg1, arg2);
+}
+
+export function SetLANSharing(arg1, arg2) {
+  return window['go']['main']['App']['SetLANSharing'](arg1, arg2);
+}
+
+export function SetLanguage(arg1) {
+  return window['go']['main']['App']['SetLanguage'](arg1);
+}
+
+export function SetLogScanWindow(arg1, arg2) {
+  return window['go']['main']['App']['SetLogScanWindow'](arg1, arg2);
+}
+
+export function SetMailCatcher(arg1) {
+  return window['go']['main']['App']['SetMailCatcher'](arg1);
+}
+
+export function SetMaxInstallTime(arg1) {
+  return window['go']['main']['App']['SetMaxInstallTime'](arg1);
+}
+
+export function SetMoodleDebugMode(arg1) {
+  return window['go']['main']['App']['SetMoodleDebugMode'](arg1);
+}
+
+export function SetNightlyPrePull(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetNightlyPrePull'](arg1, arg2, arg3);
+}
+
+export function SetNotificationRoute(arg1, arg2) {
+  return window['go']['main']['App']['SetNotificationRoute'](arg1, arg2);
+}
+
+export function SetProxySettings(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetProxySettings'](arg1, arg2, arg3);
+}
+
+export function SetResourceAlerts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetResourceAlerts'](arg1, arg2, arg3);
+}
+
+export function SetSiteDetails(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetSiteDetails'](arg1, arg2, arg3);
+}
+
+export function SetStopTimeout(arg1) {
+  return window['go']['main']['App']['SetStopTimeout'](arg1);
+}
+
+export function SetTelemetry(arg1, arg2) {
+  return window['go']['main']['App']['SetTelemetry'](arg1, arg2);
+}
+
+export function SetWakeProxy(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetWakeProxy'](arg1, arg2, arg3);
+}
+
+export function SetWebhookNotifications(arg1) {
+  return window['go']['main']['App']['SetWebhookNotifications'](arg1);
+}
+
+export function SetWorkspaceQuota(arg1, arg2) {
+  return window['go']['main']['App']['SetWorkspaceQuota'](arg1, arg2);
+}
+
+export function SnapshotInstance(arg1) {
+  return window['go']['main']['App']['SnapshotInstance'](arg1);
+}
+
+export function StartRecording() {
+  return window['go']['main']['App']['StartRecording']();
+}
+
+export function StopFollowingLogs() {
+  return window['go']['main']['App']['StopFollowingLogs']();
+}
+
 export function StopMoodle() {
   return window['go']['main']['App']['StopMoodle']();
 }
+
+export function StopRecording(arg1) {
+  return window['go']['main']['App']['StopRecording'](arg1);
+}
+
+export function SubscribeEvents(arg1, arg2) {
+  return window['go']['main']['App']['SubscribeEvents'](arg1, arg2);
+}
+
+export function UndoLastAction() {
+  return window['go']['main']['App']['UndoLastAction']();
+}
+
+export function UnsubscribeEvents(arg1) {
+  return window['go']['main']['App']['UnsubscribeEvents'](arg1);
+}
+
+export function UpdateAssets() {
+  return window['go']['main']['App']['UpdateAssets']();
+}
+
+export function UpdateImage() {
+  return window['go']['main']['App']['UpdateImage']();
+}
+
+export function ValidateEnvironment() {
+  return window['go']['main']['App']['ValidateEnvironment']();
+}
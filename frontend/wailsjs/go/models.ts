@@ -0,0 +1,2228 @@
+export namespace bundle {
+	
+	export class UpdateInfo {
+	    installedVersion: number;
+	    availableVersion: number;
+	    updateAvailable: boolean;
+	    // Go type: time
+	    published: any;
+	    files: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.installedVersion = source["installedVersion"];
+	        this.availableVersion = source["availableVersion"];
+	        this.updateAvailable = source["updateAvailable"];
+	        this.published = this.convertValues(source["published"], null);
+	        this.files = source["files"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace docker {
+	
+	export class AdapterEnv {
+	    apacheLogLevel?: string;
+	    phpErrorReporting?: string;
+	    phpDisplayErrors?: string;
+	    moodleDebug?: string;
+	    smtpHosts?: string;
+	    adminUser?: string;
+	    adminPassword?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AdapterEnv(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.apacheLogLevel = source["apacheLogLevel"];
+	        this.phpErrorReporting = source["phpErrorReporting"];
+	        this.phpDisplayErrors = source["phpDisplayErrors"];
+	        this.moodleDebug = source["moodleDebug"];
+	        this.smtpHosts = source["smtpHosts"];
+	        this.adminUser = source["adminUser"];
+	        this.adminPassword = source["adminPassword"];
+	    }
+	}
+	export class AdminerInfo {
+	    url: string;
+	    server: string;
+	    driver: string;
+	    database: string;
+	    username: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AdminerInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.server = source["server"];
+	        this.driver = source["driver"];
+	        this.database = source["database"];
+	        this.username = source["username"];
+	    }
+	}
+	export class CleanupReport {
+	    containers: string[];
+	    volumes: string[];
+	    networks: string[];
+	    images: string[];
+	    failures: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.containers = source["containers"];
+	        this.volumes = source["volumes"];
+	        this.networks = source["networks"];
+	        this.images = source["images"];
+	        this.failures = source["failures"];
+	    }
+	}
+	export class CleanupResult {
+	    removedImages: string[];
+	    reclaimedBytes: number;
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.removedImages = source["removedImages"];
+	        this.reclaimedBytes = source["reclaimedBytes"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class CommandStats {
+	    command: string;
+	    count: number;
+	    failures: number;
+	    totalMs: number;
+	    averageMs: number;
+	    maxMs: number;
+	    lastMs: number;
+	    outputBytes: number;
+	    exitCodes: Record<number, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommandStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.command = source["command"];
+	        this.count = source["count"];
+	        this.failures = source["failures"];
+	        this.totalMs = source["totalMs"];
+	        this.averageMs = source["averageMs"];
+	        this.maxMs = source["maxMs"];
+	        this.lastMs = source["lastMs"];
+	        this.outputBytes = source["outputBytes"];
+	        this.exitCodes = source["exitCodes"];
+	    }
+	}
+	export class ContainerDiskUsage {
+	    name: string;
+	    instance: string;
+	    containerBytes: number;
+	    volumeBytes: number;
+	    totalBytes: number;
+	    partial: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ContainerDiskUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.instance = source["instance"];
+	        this.containerBytes = source["containerBytes"];
+	        this.volumeBytes = source["volumeBytes"];
+	        this.totalBytes = source["totalBytes"];
+	        this.partial = source["partial"];
+	    }
+	}
+	export class ContainerStats {
+	    cpuPercent: number;
+	    memoryUsageBytes: number;
+	    memoryLimitBytes: number;
+	    memoryPercent: number;
+	    diskUsedBytes: number;
+	    diskTotalBytes: number;
+	    diskPercent: number;
+	    // Go type: time
+	    timestamp: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ContainerStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.cpuPercent = source["cpuPercent"];
+	        this.memoryUsageBytes = source["memoryUsageBytes"];
+	        this.memoryLimitBytes = source["memoryLimitBytes"];
+	        this.memoryPercent = source["memoryPercent"];
+	        this.diskUsedBytes = source["diskUsedBytes"];
+	        this.diskTotalBytes = source["diskTotalBytes"];
+	        this.diskPercent = source["diskPercent"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CredentialPatternSet {
+	    Name: string;
+	    Images: string[];
+	    Password: regexp.Regexp[];
+	    URL: regexp.Regexp[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CredentialPatternSet(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Images = source["Images"];
+	        this.Password = this.convertValues(source["Password"], regexp.Regexp);
+	        this.URL = this.convertValues(source["URL"], regexp.Regexp);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CronStatus {
+	    enabled: boolean;
+	    intervalMinutes: number;
+	    running: boolean;
+	    // Go type: time
+	    lastRun: any;
+	    lastDuration: string;
+	    lastSuccess: boolean;
+	    lastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CronStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.intervalMinutes = source["intervalMinutes"];
+	        this.running = source["running"];
+	        this.lastRun = this.convertValues(source["lastRun"], null);
+	        this.lastDuration = source["lastDuration"];
+	        this.lastSuccess = source["lastSuccess"];
+	        this.lastError = source["lastError"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DebugMode {
+	    level: string;
+	    value: number;
+	    display: boolean;
+	    reloaded: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DebugMode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	        this.value = source["value"];
+	        this.display = source["display"];
+	        this.reloaded = source["reloaded"];
+	    }
+	}
+	export class ImageDiskUsage {
+	    id: string;
+	    repository: string;
+	    tag: string;
+	    created: string;
+	    bytes: number;
+	    current: boolean;
+	    inUse: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageDiskUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.repository = source["repository"];
+	        this.tag = source["tag"];
+	        this.created = source["created"];
+	        this.bytes = source["bytes"];
+	        this.current = source["current"];
+	        this.inUse = source["inUse"];
+	    }
+	}
+	export class DockerDiskUsage {
+	    images: ImageDiskUsage[];
+	    containers: ContainerDiskUsage[];
+	    imageBytes: number;
+	    containerBytes: number;
+	    volumeBytes: number;
+	    totalBytes: number;
+	    reclaimableBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DockerDiskUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = this.convertValues(source["images"], ImageDiskUsage);
+	        this.containers = this.convertValues(source["containers"], ContainerDiskUsage);
+	        this.imageBytes = source["imageBytes"];
+	        this.containerBytes = source["containerBytes"];
+	        this.volumeBytes = source["volumeBytes"];
+	        this.totalBytes = source["totalBytes"];
+	        this.reclaimableBytes = source["reclaimableBytes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DockerVersion {
+	    client: string;
+	    server?: string;
+	    apiVersion?: string;
+	    os?: string;
+	    arch?: string;
+	    platform?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DockerVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.client = source["client"];
+	        this.server = source["server"];
+	        this.apiVersion = source["apiVersion"];
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.platform = source["platform"];
+	    }
+	}
+	export class HealthReport {
+	    status: string;
+	    failingStreak: number;
+	    lastOutput?: string;
+	    // Go type: time
+	    checkedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.failingStreak = source["failingStreak"];
+	        this.lastOutput = source["lastOutput"];
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImageAdapter {
+	    name: string;
+	    images: string[];
+	    containerPort: number;
+	    moodleDir: string;
+	    dataDir: string;
+	    healthPath: string;
+	    errorLogs: string[];
+	    env: AdapterEnv;
+	    requiredEnv?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageAdapter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.images = source["images"];
+	        this.containerPort = source["containerPort"];
+	        this.moodleDir = source["moodleDir"];
+	        this.dataDir = source["dataDir"];
+	        this.healthPath = source["healthPath"];
+	        this.errorLogs = source["errorLogs"];
+	        this.env = this.convertValues(source["env"], AdapterEnv);
+	        this.requiredEnv = source["requiredEnv"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ImageLoadResult {
+	    images: string[];
+	    bytes: number;
+	    includesConfigured: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageLoadResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = source["images"];
+	        this.bytes = source["bytes"];
+	        this.includesConfigured = source["includesConfigured"];
+	    }
+	}
+	export class ImageSaveResult {
+	    image: string;
+	    path: string;
+	    bytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageSaveResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.path = source["path"];
+	        this.bytes = source["bytes"];
+	    }
+	}
+	export class ImageUpdateInfo {
+	    image: string;
+	    localDigest: string;
+	    remoteDigest: string;
+	    updateAvailable: boolean;
+	    // Go type: time
+	    checkedAt: any;
+	    // Go type: time
+	    prePulledAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageUpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.localDigest = source["localDigest"];
+	        this.remoteDigest = source["remoteDigest"];
+	        this.updateAvailable = source["updateAvailable"];
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	        this.prePulledAt = this.convertValues(source["prePulledAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class InstanceArchive {
+	    path: string;
+	    bytes: number;
+	    image: string;
+	    volumes: number;
+	    instance: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceArchive(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.bytes = source["bytes"];
+	        this.image = source["image"];
+	        this.volumes = source["volumes"];
+	        this.instance = source["instance"];
+	    }
+	}
+	export class LayerProgress {
+	    id: string;
+	    status: string;
+	    downloadCurrent: number;
+	    downloadTotal: number;
+	    extractCurrent: number;
+	    extractTotal: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LayerProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.status = source["status"];
+	        this.downloadCurrent = source["downloadCurrent"];
+	        this.downloadTotal = source["downloadTotal"];
+	        this.extractCurrent = source["extractCurrent"];
+	        this.extractTotal = source["extractTotal"];
+	    }
+	}
+	export class LogChunk {
+	    logs: string;
+	    truncated: boolean;
+	    totalBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogChunk(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.logs = source["logs"];
+	        this.truncated = source["truncated"];
+	        this.totalBytes = source["totalBytes"];
+	    }
+	}
+	export class LogLevels {
+	    apache: string;
+	    php: string;
+	    moodle: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogLevels(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.apache = source["apache"];
+	        this.php = source["php"];
+	        this.moodle = source["moodle"];
+	    }
+	}
+	export class ManagedContainer {
+	    id: string;
+	    name: string;
+	    user: string;
+	    instance: string;
+	    state: string;
+	    hostPorts: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ManagedContainer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.user = source["user"];
+	        this.instance = source["instance"];
+	        this.state = source["state"];
+	        this.hostPorts = source["hostPorts"];
+	    }
+	}
+	export class OOMRecommendation {
+	    oomKilled: boolean;
+	    currentLimitBytes: number;
+	    suggestedLimitBytes: number;
+	    dockerMemoryBytes: number;
+	    canIncrease: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OOMRecommendation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oomKilled = source["oomKilled"];
+	        this.currentLimitBytes = source["currentLimitBytes"];
+	        this.suggestedLimitBytes = source["suggestedLimitBytes"];
+	        this.dockerMemoryBytes = source["dockerMemoryBytes"];
+	        this.canIncrease = source["canIncrease"];
+	        this.message = source["message"];
+	    }
+	}
+	export class PHPLogEntry {
+	    level: string;
+	    message: string;
+	    file?: string;
+	    line?: number;
+	    raw: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PHPLogEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	        this.message = source["message"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.raw = source["raw"];
+	    }
+	}
+	export class PerformanceStats {
+	    // Go type: time
+	    since: any;
+	    totalCommands: number;
+	    totalMs: number;
+	    commands: CommandStats[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PerformanceStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.since = this.convertValues(source["since"], null);
+	        this.totalCommands = source["totalCommands"];
+	        this.totalMs = source["totalMs"];
+	        this.commands = this.convertValues(source["commands"], CommandStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PluginInstallResult {
+	    component: string;
+	    directory: string;
+	    output: string;
+	    replaced: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginInstallResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.component = source["component"];
+	        this.directory = source["directory"];
+	        this.output = source["output"];
+	        this.replaced = source["replaced"];
+	    }
+	}
+	export class PrePulledImage {
+	    image: string;
+	    digest: string;
+	    // Go type: time
+	    pulledAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrePulledImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.digest = source["digest"];
+	        this.pulledAt = this.convertValues(source["pulledAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PrePullStatus {
+	    enabled: boolean;
+	    windowStartHour: number;
+	    windowEndHour: number;
+	    // Go type: time
+	    lastAttempt: any;
+	    lastSkipReason: string;
+	    prePulled: PrePulledImage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PrePullStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.windowStartHour = source["windowStartHour"];
+	        this.windowEndHour = source["windowEndHour"];
+	        this.lastAttempt = this.convertValues(source["lastAttempt"], null);
+	        this.lastSkipReason = source["lastSkipReason"];
+	        this.prePulled = this.convertValues(source["prePulled"], PrePulledImage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class PreflightCheck {
+	    name: string;
+	    label: string;
+	    status: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PreflightCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.label = source["label"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	    }
+	}
+	export class PreflightReport {
+	    checks: PreflightCheck[];
+	    status: string;
+	    canRun: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PreflightReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checks = this.convertValues(source["checks"], PreflightCheck);
+	        this.status = source["status"];
+	        this.canRun = source["canRun"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SiteConfig {
+	    wwwroot: string;
+	    dataroot: string;
+	    dbtype: string;
+	    dbhost: string;
+	    dbname: string;
+	    dbuser: string;
+	    prefix: string;
+	    release: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SiteConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.wwwroot = source["wwwroot"];
+	        this.dataroot = source["dataroot"];
+	        this.dbtype = source["dbtype"];
+	        this.dbhost = source["dbhost"];
+	        this.dbname = source["dbname"];
+	        this.dbuser = source["dbuser"];
+	        this.prefix = source["prefix"];
+	        this.release = source["release"];
+	    }
+	}
+	export class ProductionExport {
+	    directory: string;
+	    files: string[];
+	    checklist: string[];
+	    site: SiteConfig;
+	    plugins?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductionExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.files = source["files"];
+	        this.checklist = source["checklist"];
+	        this.site = this.convertValues(source["site"], SiteConfig);
+	        this.plugins = source["plugins"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileResult {
+	    url: string;
+	    statusCode: number;
+	    duration: number;
+	    file: string;
+	    sizeBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.statusCode = source["statusCode"];
+	        this.duration = source["duration"];
+	        this.file = source["file"];
+	        this.sizeBytes = source["sizeBytes"];
+	    }
+	}
+	export class PullSnapshot {
+	    percentage: number;
+	    status: string;
+	    layers: LayerProgress[];
+	    downloadedBytes: number;
+	    totalBytes: number;
+	    bytesRemaining: number;
+	    speedBytesPerSec: number;
+	    etaSeconds: number;
+	    // Go type: time
+	    timestamp: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PullSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.percentage = source["percentage"];
+	        this.status = source["status"];
+	        this.layers = this.convertValues(source["layers"], LayerProgress);
+	        this.downloadedBytes = source["downloadedBytes"];
+	        this.totalBytes = source["totalBytes"];
+	        this.bytesRemaining = source["bytesRemaining"];
+	        this.speedBytesPerSec = source["speedBytesPerSec"];
+	        this.etaSeconds = source["etaSeconds"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PurgeResult {
+	    count: number;
+	    usernames: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PurgeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.count = source["count"];
+	        this.usernames = source["usernames"];
+	    }
+	}
+	export class QuotaStatus {
+	    instance: string;
+	    containerBytes: number;
+	    volumeBytes: number;
+	    totalBytes: number;
+	    partial: boolean;
+	    quotaBytes: number;
+	    overQuota: boolean;
+	    suggestions?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new QuotaStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.instance = source["instance"];
+	        this.containerBytes = source["containerBytes"];
+	        this.volumeBytes = source["volumeBytes"];
+	        this.totalBytes = source["totalBytes"];
+	        this.partial = source["partial"];
+	        this.quotaBytes = source["quotaBytes"];
+	        this.overQuota = source["overQuota"];
+	        this.suggestions = source["suggestions"];
+	    }
+	}
+	export class ResourceReport {
+	    diskPath: string;
+	    diskFreeBytes: number;
+	    diskRequiredBytes: number;
+	    diskOk: boolean;
+	    diskMessage: string;
+	    memoryBytes: number;
+	    memoryRequiredBytes: number;
+	    memoryRecommendedBytes: number;
+	    memoryOk: boolean;
+	    memoryMessage: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResourceReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.diskPath = source["diskPath"];
+	        this.diskFreeBytes = source["diskFreeBytes"];
+	        this.diskRequiredBytes = source["diskRequiredBytes"];
+	        this.diskOk = source["diskOk"];
+	        this.diskMessage = source["diskMessage"];
+	        this.memoryBytes = source["memoryBytes"];
+	        this.memoryRequiredBytes = source["memoryRequiredBytes"];
+	        this.memoryRecommendedBytes = source["memoryRecommendedBytes"];
+	        this.memoryOk = source["memoryOk"];
+	        this.memoryMessage = source["memoryMessage"];
+	    }
+	}
+	export class Screenshot {
+	    page: string;
+	    url: string;
+	    file?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Screenshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = source["page"];
+	        this.url = source["url"];
+	        this.file = source["file"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ScreenshotResult {
+	    directory: string;
+	    // Go type: time
+	    capturedAt: any;
+	    screenshots: Screenshot[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ScreenshotResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.capturedAt = this.convertValues(source["capturedAt"], null);
+	        this.screenshots = this.convertValues(source["screenshots"], Screenshot);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SeedResult {
+	    size: string;
+	    courses: number;
+	    duration: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SeedResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.size = source["size"];
+	        this.courses = source["courses"];
+	        this.duration = source["duration"];
+	    }
+	}
+	
+	export class Snapshot {
+	    name: string;
+	    image: string;
+	    sourceInstance: string;
+	    // Go type: time
+	    created: any;
+	    sizeBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Snapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.image = source["image"];
+	        this.sourceInstance = source["sourceInstance"];
+	        this.created = this.convertValues(source["created"], null);
+	        this.sizeBytes = source["sizeBytes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TestRunResult {
+	    component: string;
+	    suite: string;
+	    passed: boolean;
+	    summary: string;
+	    duration: string;
+	    output: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TestRunResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.component = source["component"];
+	        this.suite = source["suite"];
+	        this.passed = source["passed"];
+	        this.summary = source["summary"];
+	        this.duration = source["duration"];
+	        this.output = source["output"];
+	    }
+	}
+	export class VirtualizationStatus {
+	    applicable: boolean;
+	    wslInstalled: boolean;
+	    wsl2Default: boolean;
+	    dockerDistroOnWsl2: boolean;
+	    virtualizationEnabled: boolean;
+	    hypervisorPresent: boolean;
+	    hints: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VirtualizationStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.applicable = source["applicable"];
+	        this.wslInstalled = source["wslInstalled"];
+	        this.wsl2Default = source["wsl2Default"];
+	        this.dockerDistroOnWsl2 = source["dockerDistroOnWsl2"];
+	        this.virtualizationEnabled = source["virtualizationEnabled"];
+	        this.hypervisorPresent = source["hypervisorPresent"];
+	        this.hints = source["hints"];
+	    }
+	}
+	export class XdebugInfo {
+	    enabled: boolean;
+	    version: string;
+	    clientHost: string;
+	    port: number;
+	    ideKey: string;
+	    serverPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new XdebugInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.version = source["version"];
+	        this.clientHost = source["clientHost"];
+	        this.port = source["port"];
+	        this.ideKey = source["ideKey"];
+	        this.serverPath = source["serverPath"];
+	    }
+	}
+
+}
+
+export namespace fleet {
+	
+	export class CommandResult {
+	    member: string;
+	    success: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommandResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.member = source["member"];
+	        this.success = source["success"];
+	        this.error = source["error"];
+	    }
+	}
+	export class MemberStatus {
+	    state: string;
+	    url: string;
+	    image: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MemberStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.url = source["url"];
+	        this.image = source["image"];
+	    }
+	}
+	export class Member {
+	    name: string;
+	    address: string;
+	    apiPort: number;
+	    url: string;
+	    status?: MemberStatus;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Member(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.apiPort = source["apiPort"];
+	        this.url = source["url"];
+	        this.status = this.convertValues(source["status"], MemberStatus);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace kiosk {
+	
+	export class Status {
+	    state: string;
+	    url: string;
+	    recoveries: number;
+	    // Go type: time
+	    lastRecovery?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.url = source["url"];
+	        this.recoveries = source["recoveries"];
+	        this.lastRecovery = this.convertValues(source["lastRecovery"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class AppInfo {
+	    version: string;
+	    commit: string;
+	    buildTime: string;
+	    goVersion: string;
+	    wailsVersion: string;
+	    os: string;
+	    arch: string;
+	    docker?: docker.DockerVersion;
+	    dockerError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.commit = source["commit"];
+	        this.buildTime = source["buildTime"];
+	        this.goVersion = source["goVersion"];
+	        this.wailsVersion = source["wailsVersion"];
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.docker = this.convertValues(source["docker"], docker.DockerVersion);
+	        this.dockerError = source["dockerError"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ClonedInstance {
+	    containerId: string;
+	    instance: string;
+	    snapshot: string;
+	    hostPort: number;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClonedInstance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.containerId = source["containerId"];
+	        this.instance = source["instance"];
+	        this.snapshot = source["snapshot"];
+	        this.hostPort = source["hostPort"];
+	        this.url = source["url"];
+	    }
+	}
+	export class ControlAPIInfo {
+	    enabled: boolean;
+	    url: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ControlAPIInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.url = source["url"];
+	        this.token = source["token"];
+	    }
+	}
+	export class CredentialRefresh {
+	    url: string;
+	    passwordVerified: boolean;
+	    passwordUpdated: boolean;
+	    urlUpdated: boolean;
+	    drift: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CredentialRefresh(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.passwordVerified = source["passwordVerified"];
+	        this.passwordUpdated = source["passwordUpdated"];
+	        this.urlUpdated = source["urlUpdated"];
+	        this.drift = source["drift"];
+	        this.message = source["message"];
+	    }
+	}
+	export class HealthCheckResult {
+	    docker: boolean;
+	    internet: boolean;
+	    diskSpace: boolean;
+	    memory: boolean;
+	    virtualization?: boolean;
+	    quota?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthCheckResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.docker = source["docker"];
+	        this.internet = source["internet"];
+	        this.diskSpace = source["diskSpace"];
+	        this.memory = source["memory"];
+	        this.virtualization = source["virtualization"];
+	        this.quota = source["quota"];
+	    }
+	}
+	export class ImageOption {
+	    image: string;
+	    moodleVersion: string;
+	    label: string;
+	    local: boolean;
+	    selected: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageOption(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.moodleVersion = source["moodleVersion"];
+	        this.label = source["label"];
+	        this.local = source["local"];
+	        this.selected = source["selected"];
+	    }
+	}
+	export class InstanceImport {
+	    containerId: string;
+	    image: string;
+	    volumes: number;
+	    sourceInstance: string;
+	    // Go type: time
+	    exportedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceImport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.containerId = source["containerId"];
+	        this.image = source["image"];
+	        this.volumes = source["volumes"];
+	        this.sourceInstance = source["sourceInstance"];
+	        this.exportedAt = this.convertValues(source["exportedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class InstanceInfo {
+	    id: string;
+	    name: string;
+	    user: string;
+	    instance: string;
+	    state: string;
+	    hostPorts: number[];
+	    tags: string[];
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.user = source["user"];
+	        this.instance = source["instance"];
+	        this.state = source["state"];
+	        this.hostPorts = source["hostPorts"];
+	        this.tags = source["tags"];
+	        this.current = source["current"];
+	    }
+	}
+	export class InstanceLockStatus {
+	    readOnly: boolean;
+	    holder?: storage.LockInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceLockStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.readOnly = source["readOnly"];
+	        this.holder = this.convertValues(source["holder"], storage.LockInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KioskAPIInfo {
+	    enabled: boolean;
+	    url: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KioskAPIInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.url = source["url"];
+	        this.token = source["token"];
+	    }
+	}
+	export class LanguageInfo {
+	    language: string;
+	    automatic: boolean;
+	    supported: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LanguageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.language = source["language"];
+	        this.automatic = source["automatic"];
+	        this.supported = source["supported"];
+	    }
+	}
+	export class SiteStatus {
+	    siteName: string;
+	    siteUrl: string;
+	    release: string;
+	    version: string;
+	    courses: number;
+	    users: number;
+	    // Go type: time
+	    cronLastRun: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SiteStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.siteName = source["siteName"];
+	        this.siteUrl = source["siteUrl"];
+	        this.release = source["release"];
+	        this.version = source["version"];
+	        this.courses = source["courses"];
+	        this.users = source["users"];
+	        this.cronLastRun = this.convertValues(source["cronLastRun"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UndoableAction {
+	    id: number;
+	    description: string;
+	    // Go type: time
+	    time: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new UndoableAction(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.description = source["description"];
+	        this.time = this.convertValues(source["time"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace moodle {
+	
+	export class Course {
+	    id: number;
+	    shortname: string;
+	    fullname: string;
+	    categoryid: number;
+	    format: string;
+	    visible: number;
+	    startdate: number;
+	    enddate: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Course(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.shortname = source["shortname"];
+	        this.fullname = source["fullname"];
+	        this.categoryid = source["categoryid"];
+	        this.format = source["format"];
+	        this.visible = source["visible"];
+	        this.startdate = source["startdate"];
+	        this.enddate = source["enddate"];
+	    }
+	}
+	export class Role {
+	    roleid: number;
+	    shortname: string;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Role(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.roleid = source["roleid"];
+	        this.shortname = source["shortname"];
+	        this.name = source["name"];
+	    }
+	}
+	export class EnrolledUser {
+	    id: number;
+	    username: string;
+	    fullname: string;
+	    email: string;
+	    lastaccess: number;
+	    lastcourseaccess: number;
+	    roles: Role[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EnrolledUser(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.username = source["username"];
+	        this.fullname = source["fullname"];
+	        this.email = source["email"];
+	        this.lastaccess = source["lastaccess"];
+	        this.lastcourseaccess = source["lastcourseaccess"];
+	        this.roles = this.convertValues(source["roles"], Role);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace storage {
+	
+	export class TimelineEntry {
+	    // Go type: time
+	    time: any;
+	    kind: string;
+	    message: string;
+	    details?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.kind = source["kind"];
+	        this.message = source["message"];
+	        this.details = source["details"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SettingChange {
+	    key: string;
+	    before: string;
+	    after: string;
+	    // Go type: time
+	    time?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.time = this.convertValues(source["time"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChangeReport {
+	    // Go type: time
+	    since: any;
+	    // Go type: time
+	    generatedAt: any;
+	    settings: SettingChange[];
+	    plugins: TimelineEntry[];
+	    images: TimelineEntry[];
+	    runtime: TimelineEntry[];
+	    other: TimelineEntry[];
+	    markdown: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChangeReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.since = this.convertValues(source["since"], null);
+	        this.generatedAt = this.convertValues(source["generatedAt"], null);
+	        this.settings = this.convertValues(source["settings"], SettingChange);
+	        this.plugins = this.convertValues(source["plugins"], TimelineEntry);
+	        this.images = this.convertValues(source["images"], TimelineEntry);
+	        this.runtime = this.convertValues(source["runtime"], TimelineEntry);
+	        this.other = this.convertValues(source["other"], TimelineEntry);
+	        this.markdown = source["markdown"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Credentials {
+	    username: string;
+	    password: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Credentials(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.url = source["url"];
+	    }
+	}
+	export class SiteSettings {
+	    fullName?: string;
+	    shortName?: string;
+	    adminEmail?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SiteSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fullName = source["fullName"];
+	        this.shortName = source["shortName"];
+	        this.adminEmail = source["adminEmail"];
+	    }
+	}
+	export class MemorySettings {
+	    limitMB: number;
+	    autoAdjustOnOOM: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MemorySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.limitMB = source["limitMB"];
+	        this.autoAdjustOnOOM = source["autoAdjustOnOOM"];
+	    }
+	}
+	export class InstanceTemplate {
+	    name: string;
+	    image?: string;
+	    hostPort?: number;
+	    env?: Record<string, string>;
+	    seedSize?: string;
+	    mail: boolean;
+	    adminer: boolean;
+	    memory: MemorySettings;
+	    site: SiteSettings;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.image = source["image"];
+	        this.hostPort = source["hostPort"];
+	        this.env = source["env"];
+	        this.seedSize = source["seedSize"];
+	        this.mail = source["mail"];
+	        this.adminer = source["adminer"];
+	        this.memory = this.convertValues(source["memory"], MemorySettings);
+	        this.site = this.convertValues(source["site"], SiteSettings);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LockInfo {
+	    pid: number;
+	    hostname: string;
+	    version: string;
+	    // Go type: time
+	    startedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new LockInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.hostname = source["hostname"];
+	        this.version = source["version"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MaskedCredentials {
+	    username: string;
+	    password: string;
+	    url: string;
+	    hasPassword: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MaskedCredentials(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.url = source["url"];
+	        this.hasPassword = source["hasPassword"];
+	    }
+	}
+	
+	export class SMTPSettings {
+	    host: string;
+	    port: number;
+	    username: string;
+	    password?: string;
+	    from: string;
+	    to: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SMTPSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class NotificationSettings {
+	    routes: Record<string, Array<string>>;
+	    webhookURL: string;
+	    smtp: SMTPSettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new NotificationSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.routes = source["routes"];
+	        this.webhookURL = source["webhookURL"];
+	        this.smtp = this.convertValues(source["smtp"], SMTPSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OperationRecord {
+	    id: string;
+	    operation: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    finishedAt?: any;
+	    outcome: string;
+	    error?: string;
+	    details?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new OperationRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.operation = source["operation"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	        this.outcome = source["outcome"];
+	        this.error = source["error"];
+	        this.details = source["details"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	export class TelemetrySettings {
+	    enabled: boolean;
+	    endpoint: string;
+	    installId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TelemetrySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.endpoint = source["endpoint"];
+	        this.installId = source["installId"];
+	    }
+	}
+
+}
+
@@ -0,0 +1,174 @@
+package i18n
+
+// catalogs maps a language to the translations of the English strings used as keys.
+// Translations keep the formatting verbs of their key, in the same order.
+var catalogs = map[string]map[string]string{
+	"de": {
+		// Run progress
+		"Checking the Docker image":    "Docker-Image wird geprüft",
+		"Downloading the Docker image": "Docker-Image wird heruntergeladen",
+		"Creating the container":       "Container wird erstellt",
+		"Starting the container":       "Container wird gestartet",
+		"Waiting for the database":     "Warten auf die Datenbank",
+		"Installing Moodle":            "Moodle wird installiert",
+		"Extracting the login details": "Anmeldedaten werden ausgelesen",
+		"Moodle is ready":              "Moodle ist bereit",
+		"Starting Moodle failed":       "Moodle konnte nicht gestartet werden",
+
+		// Notifications
+		"Moodle installation failed":                       "Die Moodle-Installation ist fehlgeschlagen",
+		"New Moodle image downloaded":                      "Neues Moodle-Image heruntergeladen",
+		"A new build of %s is ready to use.":               "Eine neue Version von %s ist einsatzbereit.",
+		"Moodle was restarted":                             "Moodle wurde neu gestartet",
+		"Kiosk watchdog restarted Moodle: %s":              "Die Kiosk-Überwachung hat Moodle neu gestartet: %s",
+		"Kiosk watchdog could not restart Moodle (%s): %v": "Die Kiosk-Überwachung konnte Moodle nicht neu starten (%s): %v",
+		"Moodle ran out of memory":                         "Moodle hat nicht genug Arbeitsspeicher",
+		"Moodle stopped while idle":                        "Moodle wegen Inaktivität angehalten",
+		"Moodle was stopped after %d minutes without use. Open Moodle Prototype Manager and click Resume to start it again.": "Moodle wurde nach %d Minuten ohne Nutzung angehalten. Öffnen Sie Moodle Prototype Manager, um es fortzusetzen.",
+		"Docker is not running":                                   "Docker läuft nicht",
+		"Moodle cannot be managed until Docker is started again.": "Moodle kann erst wieder verwaltet werden, wenn Docker gestartet ist.",
+		"Internet connection lost":                                "Internetverbindung unterbrochen",
+		"Downloading or updating the Moodle image will fail until the connection is back.": "Das Herunterladen oder Aktualisieren des Moodle-Images schlägt fehl, bis die Verbindung wiederhergestellt ist.",
+		"Moodle resource alert": "Moodle-Ressourcenwarnung",
+		"Test notification":     "Testbenachrichtigung",
+		"Moodle Prototype Manager will deliver %s notifications here.": "Moodle Prototype Manager stellt %s-Benachrichtigungen hier zu.",
+
+		// Error suggestions
+		"Install Docker Desktop from https://www.docker.com/products/docker-desktop": "Installieren Sie Docker Desktop von https://www.docker.com/products/docker-desktop",
+		"Restart the application after installing Docker":                            "Starten Sie die Anwendung nach der Installation von Docker neu",
+		"Start Docker Desktop and wait until it reports that it is running":          "Starten Sie Docker Desktop und warten Sie, bis es meldet, dass es läuft",
+		"Run the health check again":                                                 "Führen Sie die Systemprüfung erneut aus",
+		"On Linux, add your user to the docker group and log in again":               "Fügen Sie unter Linux Ihren Benutzer zur Gruppe docker hinzu und melden Sie sich erneut an",
+		"Make sure Docker Desktop is running under your account":                     "Stellen Sie sicher, dass Docker Desktop unter Ihrem Konto läuft",
+		"Check the image name and tag in image.docker":                               "Prüfen Sie Image-Namen und Tag in image.docker",
+		"Choose an image from the catalog":                                           "Wählen Sie ein Image aus dem Katalog",
+		"Log in to the registry with docker login":                                   "Melden Sie sich mit docker login an der Registry an",
+		"Check that the image name in image.docker is correct":                       "Prüfen Sie, ob der Image-Name in image.docker stimmt",
+		"Run Moodle to create a new container":                                       "Starten Sie Moodle, um einen neuen Container zu erstellen",
+		"Start Moodle first":                                                         "Starten Sie zuerst Moodle",
+		"Close the program that is using the port":                                   "Schließen Sie das Programm, das den Port belegt",
+		"Choose a different host port in settings":                                   "Wählen Sie in den Einstellungen einen anderen Port",
+		"Check that you can write to the ~/.moodle-prototype-manager folder":         "Prüfen Sie, ob Sie in den Ordner ~/.moodle-prototype-manager schreiben können",
+		"Check your internet connection":                                             "Prüfen Sie Ihre Internetverbindung",
+		"Configure a proxy in settings if your network requires one":                 "Richten Sie in den Einstellungen einen Proxy ein, falls Ihr Netzwerk einen erfordert",
+		"Try again in a moment":                                                      "Versuchen Sie es gleich noch einmal",
+		"Wait for the running operation to finish":                                   "Warten Sie, bis der laufende Vorgang abgeschlossen ist",
+		"Free up space in the workspace or raise its quota in settings":              "Geben Sie Speicherplatz im Arbeitsbereich frei oder erhöhen Sie dessen Kontingent in den Einstellungen",
+		"Close the other Moodle Prototype Manager window, then restart this one":     "Schließen Sie das andere Fenster von Moodle Prototype Manager und starten Sie dieses neu",
+		"Update Moodle Prototype Manager on this machine":                            "Aktualisieren Sie Moodle Prototype Manager auf diesem Rechner",
+		"Try again; if the problem persists, restart the application":                "Versuchen Sie es erneut; besteht das Problem weiter, starten Sie die Anwendung neu",
+		"Report the problem with the log file from the logs folder":                  "Melden Sie das Problem mit der Protokolldatei aus dem Ordner logs",
+	},
+	"es": {
+		// Run progress
+		"Checking the Docker image":    "Comprobando la imagen de Docker",
+		"Downloading the Docker image": "Descargando la imagen de Docker",
+		"Creating the container":       "Creando el contenedor",
+		"Starting the container":       "Iniciando el contenedor",
+		"Waiting for the database":     "Esperando a la base de datos",
+		"Installing Moodle":            "Instalando Moodle",
+		"Extracting the login details": "Obteniendo los datos de acceso",
+		"Moodle is ready":              "Moodle está listo",
+		"Starting Moodle failed":       "No se pudo iniciar Moodle",
+
+		// Notifications
+		"Moodle installation failed":                       "La instalación de Moodle ha fallado",
+		"New Moodle image downloaded":                      "Nueva imagen de Moodle descargada",
+		"A new build of %s is ready to use.":               "Una nueva versión de %s está lista para usar.",
+		"Moodle was restarted":                             "Moodle se ha reiniciado",
+		"Kiosk watchdog restarted Moodle: %s":              "La supervisión del modo quiosco reinició Moodle: %s",
+		"Kiosk watchdog could not restart Moodle (%s): %v": "La supervisión del modo quiosco no pudo reiniciar Moodle (%s): %v",
+		"Moodle ran out of memory":                         "Moodle se quedó sin memoria",
+		"Moodle stopped while idle":                        "Moodle se detuvo por inactividad",
+		"Moodle was stopped after %d minutes without use. Open Moodle Prototype Manager and click Resume to start it again.": "Moodle se detuvo tras %d minutos sin uso. Abre Moodle Prototype Manager para reanudarlo.",
+		"Docker is not running":                                   "Docker no se está ejecutando",
+		"Moodle cannot be managed until Docker is started again.": "No se puede gestionar Moodle hasta que Docker vuelva a iniciarse.",
+		"Internet connection lost":                                "Se perdió la conexión a Internet",
+		"Downloading or updating the Moodle image will fail until the connection is back.": "La descarga o actualización de la imagen de Moodle fallará hasta que vuelva la conexión.",
+		"Moodle resource alert": "Alerta de recursos de Moodle",
+		"Test notification":     "Notificación de prueba",
+		"Moodle Prototype Manager will deliver %s notifications here.": "Moodle Prototype Manager enviará aquí las notificaciones de tipo %s.",
+
+		// Error suggestions
+		"Install Docker Desktop from https://www.docker.com/products/docker-desktop": "Instala Docker Desktop desde https://www.docker.com/products/docker-desktop",
+		"Restart the application after installing Docker":                            "Reinicia la aplicación después de instalar Docker",
+		"Start Docker Desktop and wait until it reports that it is running":          "Inicia Docker Desktop y espera a que indique que se está ejecutando",
+		"Run the health check again":                                                 "Vuelve a ejecutar la comprobación del sistema",
+		"On Linux, add your user to the docker group and log in again":               "En Linux, añade tu usuario al grupo docker y vuelve a iniciar sesión",
+		"Make sure Docker Desktop is running under your account":                     "Asegúrate de que Docker Desktop se ejecuta con tu cuenta",
+		"Check the image name and tag in image.docker":                               "Comprueba el nombre y la etiqueta de la imagen en image.docker",
+		"Choose an image from the catalog":                                           "Elige una imagen del catálogo",
+		"Log in to the registry with docker login":                                   "Inicia sesión en el registro con docker login",
+		"Check that the image name in image.docker is correct":                       "Comprueba que el nombre de la imagen en image.docker es correcto",
+		"Run Moodle to create a new container":                                       "Ejecuta Moodle para crear un contenedor nuevo",
+		"Start Moodle first":                                                         "Inicia Moodle primero",
+		"Close the program that is using the port":                                   "Cierra el programa que está usando el puerto",
+		"Choose a different host port in settings":                                   "Elige otro puerto en la configuración",
+		"Check that you can write to the ~/.moodle-prototype-manager folder":         "Comprueba que puedes escribir en la carpeta ~/.moodle-prototype-manager",
+		"Check your internet connection":                                             "Comprueba tu conexión a Internet",
+		"Configure a proxy in settings if your network requires one":                 "Configura un proxy en la configuración si tu red lo requiere",
+		"Try again in a moment":                                                      "Vuelve a intentarlo en un momento",
+		"Wait for the running operation to finish":                                   "Espera a que termine la operación en curso",
+		"Free up space in the workspace or raise its quota in settings":              "Libera espacio en el espacio de trabajo o aumenta su cuota en la configuración",
+		"Close the other Moodle Prototype Manager window, then restart this one":     "Cierra la otra ventana de Moodle Prototype Manager y reinicia esta",
+		"Update Moodle Prototype Manager on this machine":                            "Actualiza Moodle Prototype Manager en este equipo",
+		"Try again; if the problem persists, restart the application":                "Vuelve a intentarlo; si el problema continúa, reinicia la aplicación",
+		"Report the problem with the log file from the logs folder":                  "Informa del problema adjuntando el archivo de registro de la carpeta logs",
+	},
+	"fr": {
+		// Run progress
+		"Checking the Docker image":    "Vérification de l'image Docker",
+		"Downloading the Docker image": "Téléchargement de l'image Docker",
+		"Creating the container":       "Création du conteneur",
+		"Starting the container":       "Démarrage du conteneur",
+		"Waiting for the database":     "En attente de la base de données",
+		"Installing Moodle":            "Installation de Moodle",
+		"Extracting the login details": "Récupération des identifiants de connexion",
+		"Moodle is ready":              "Moodle est prêt",
+		"Starting Moodle failed":       "Le démarrage de Moodle a échoué",
+
+		// Notifications
+		"Moodle installation failed":                       "L'installation de Moodle a échoué",
+		"New Moodle image downloaded":                      "Nouvelle image Moodle téléchargée",
+		"A new build of %s is ready to use.":               "Une nouvelle version de %s est prête à l'emploi.",
+		"Moodle was restarted":                             "Moodle a été redémarré",
+		"Kiosk watchdog restarted Moodle: %s":              "La surveillance du mode kiosque a redémarré Moodle : %s",
+		"Kiosk watchdog could not restart Moodle (%s): %v": "La surveillance du mode kiosque n'a pas pu redémarrer Moodle (%s) : %v",
+		"Moodle ran out of memory":                         "Moodle manque de mémoire",
+		"Moodle stopped while idle":                        "Moodle arrêté pour inactivité",
+		"Moodle was stopped after %d minutes without use. Open Moodle Prototype Manager and click Resume to start it again.": "Moodle a été arrêté après %d minutes d'inactivité. Ouvrez Moodle Prototype Manager pour le relancer.",
+		"Docker is not running":                                   "Docker n'est pas démarré",
+		"Moodle cannot be managed until Docker is started again.": "Moodle ne peut pas être géré tant que Docker n'est pas redémarré.",
+		"Internet connection lost":                                "Connexion Internet perdue",
+		"Downloading or updating the Moodle image will fail until the connection is back.": "Le téléchargement ou la mise à jour de l'image Moodle échouera tant que la connexion n'est pas rétablie.",
+		"Moodle resource alert": "Alerte de ressources Moodle",
+		"Test notification":     "Notification de test",
+		"Moodle Prototype Manager will deliver %s notifications here.": "Moodle Prototype Manager enverra ici les notifications de type %s.",
+
+		// Error suggestions
+		"Install Docker Desktop from https://www.docker.com/products/docker-desktop": "Installez Docker Desktop depuis https://www.docker.com/products/docker-desktop",
+		"Restart the application after installing Docker":                            "Redémarrez l'application après avoir installé Docker",
+		"Start Docker Desktop and wait until it reports that it is running":          "Démarrez Docker Desktop et attendez qu'il indique être en cours d'exécution",
+		"Run the health check again":                                                 "Relancez la vérification de l'état",
+		"On Linux, add your user to the docker group and log in again":               "Sous Linux, ajoutez votre utilisateur au groupe docker et reconnectez-vous",
+		"Make sure Docker Desktop is running under your account":                     "Vérifiez que Docker Desktop s'exécute sous votre compte",
+		"Check the image name and tag in image.docker":                               "Vérifiez le nom et l'étiquette de l'image dans image.docker",
+		"Choose an image from the catalog":                                           "Choisissez une image dans le catalogue",
+		"Log in to the registry with docker login":                                   "Connectez-vous au registre avec docker login",
+		"Check that the image name in image.docker is correct":                       "Vérifiez que le nom de l'image dans image.docker est correct",
+		"Run Moodle to create a new container":                                       "Lancez Moodle pour créer un nouveau conteneur",
+		"Start Moodle first":                                                         "Démarrez d'abord Moodle",
+		"Close the program that is using the port":                                   "Fermez le programme qui utilise le port",
+		"Choose a different host port in settings":                                   "Choisissez un autre port dans les paramètres",
+		"Check that you can write to the ~/.moodle-prototype-manager folder":         "Vérifiez que vous pouvez écrire dans le dossier ~/.moodle-prototype-manager",
+		"Check your internet connection":                                             "Vérifiez votre connexion Internet",
+		"Configure a proxy in settings if your network requires one":                 "Configurez un proxy dans les paramètres si votre réseau l'exige",
+		"Try again in a moment":                                                      "Réessayez dans un instant",
+		"Wait for the running operation to finish":                                   "Attendez la fin de l'opération en cours",
+		"Free up space in the workspace or raise its quota in settings":              "Libérez de l'espace dans l'espace de travail ou augmentez son quota dans les paramètres",
+		"Close the other Moodle Prototype Manager window, then restart this one":     "Fermez l'autre fenêtre de Moodle Prototype Manager, puis redémarrez celle-ci",
+		"Update Moodle Prototype Manager on this machine":                            "Mettez à jour Moodle Prototype Manager sur cet ordinateur",
+		"Try again; if the problem persists, restart the application":                "Réessayez ; si le problème persiste, redémarrez l'application",
+		"Report the problem with the log file from the logs folder":                  "Signalez le problème en joignant le fichier journal du dossier logs",
+	},
+}
@@ -0,0 +1,123 @@
+// Package i18n translates the user-facing strings of the backend: notifications, run progress
+// labels and error suggestions. Strings are looked up by their English text, so a string
+// without a translation, or a language without a catalog, falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language the strings are written in
+const DefaultLanguage = "en"
+
+// Localizer translates strings into the selected language
+type Localizer struct {
+	mu       sync.RWMutex
+	language string
+}
+
+// New creates a localizer for language, falling back to English if it is not supported
+func New(language string) *Localizer {
+	l := &Localizer{language: DefaultLanguage}
+	if IsSupported(language) {
+		l.language = Normalize(language)
+	}
+	return l
+}
+
+// SetLanguage selects the language of later translations
+func (l *Localizer) SetLanguage(language string) error {
+	if !IsSupported(language) {
+		return fmt.Errorf("unsupported language %q (supported: %s)", language, strings.Join(Supported(), ", "))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.language = Normalize(language)
+	return nil
+}
+
+// Language returns the selected language
+func (l *Localizer) Language() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.language
+}
+
+// T translates text
+func (l *Localizer) T(text string) string {
+	if translated, ok := catalogs[l.Language()][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// Tf translates format, then formats it with args like fmt.Sprintf
+func (l *Localizer) Tf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Strings translates each of texts, returning a new slice
+func (l *Localizer) Strings(texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = l.T(text)
+	}
+	return translated
+}
+
+// Supported lists the languages with a catalog, English first
+func Supported() []string {
+	languages := []string{DefaultLanguage}
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+// IsSupported reports whether language, in any form Normalize accepts, has a catalog
+func IsSupported(language string) bool {
+	normalized := Normalize(language)
+	if normalized == DefaultLanguage {
+		return true
+	}
+	_, ok := catalogs[normalized]
+	return ok
+}
+
+// Normalize reduces a locale such as "es_ES.UTF-8", "pt-BR" or "FR" to its language code.
+// The C and POSIX locales have no language and normalize to "".
+func Normalize(locale string) string {
+	language := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(language, "_-.@"); i >= 0 {
+		language = language[:i]
+	}
+	if language == "c" || language == "posix" {
+		return ""
+	}
+	return language
+}
+
+// Detect returns the first supported language of the user's locale, or English. The LANGUAGE,
+// LC_ALL, LC_MESSAGES and LANG environment variables are read in the order gettext uses, then
+// the system setting: GetUserDefaultLocaleName on Windows, AppleLanguages and AppleLocale on
+// macOS.
+func Detect() string {
+	var candidates []string
+	candidates = append(candidates, strings.Split(os.Getenv("LANGUAGE"), ":")...)
+	candidates = append(candidates, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+	candidates = append(candidates, systemLocales()...)
+	for _, candidate := range candidates {
+		if language := Normalize(candidate); language != "" && IsSupported(language) {
+			return language
+		}
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,69 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// verbRegex matches fmt formatting verbs
+var verbRegex = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogsKeepFormattingVerbs(t *testing.T) {
+	for language, catalog := range catalogs {
+		for key, translation := range catalog {
+			expected := strings.Join(verbRegex.FindAllString(key, -1), " ")
+			actual := strings.Join(verbRegex.FindAllString(translation, -1), " ")
+			if expected != actual {
+				t.Errorf("%s translation of %q has verbs %q, expected %q", language, key, actual, expected)
+			}
+		}
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	localizer := New("xx")
+	if localizer.Language() != DefaultLanguage || localizer.T("Moodle is ready") != "Moodle is ready" {
+		t.Errorf("Expected an unsupported language to fall back to English, got %s", localizer.Language())
+	}
+
+	if err := localizer.SetLanguage("tlh"); err == nil {
+		t.Error("Expected an error for an unsupported language")
+	}
+	if err := localizer.SetLanguage("es_ES.UTF-8"); err != nil {
+		t.Fatalf("SetLanguage failed: %v", err)
+	}
+	if localizer.Language() != "es" || localizer.T("Moodle is ready") != "Moodle está listo" {
+		t.Errorf("Expected Spanish, got %s: %s", localizer.Language(), localizer.T("Moodle is ready"))
+	}
+	if got := localizer.Tf("A new build of %s is ready to use.", "moodle:4.5"); got != "Una nueva versión de moodle:4.5 está lista para usar." {
+		t.Errorf("Unexpected formatted translation: %s", got)
+	}
+	// Strings without a translation are returned unchanged
+	if got := localizer.Strings([]string{"Start Moodle first", "Something new"}); got[0] != "Inicia Moodle primero" || got[1] != "Something new" {
+		t.Errorf("Unexpected translations: %v", got)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		language, lcAll, lang string
+		expected              string
+	}{
+		{"", "", "", DefaultLanguage},
+		{"", "", "C.UTF-8", DefaultLanguage},
+		{"", "", "de_DE.UTF-8", "de"},
+		{"", "fr_CA", "de_DE.UTF-8", "fr"},
+		// Unsupported languages in LANGUAGE are skipped
+		{"tlh:es", "", "de_DE.UTF-8", "es"},
+	}
+	for _, test := range tests {
+		t.Setenv("LANGUAGE", test.language)
+		t.Setenv("LC_ALL", test.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", test.lang)
+		if got := Detect(); got != test.expected {
+			t.Errorf("Detect() with LANGUAGE=%q LC_ALL=%q LANG=%q = %s, expected %s", test.language, test.lcAll, test.lang, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build darwin
+
+package i18n
+
+import (
+	"os/exec"
+	"strings"
+
+	"moodle-prototype-manager/utils"
+)
+
+// systemLocales returns the user's preferred languages and region locale, such as "de-DE" and
+// "de_DE"; apps started from Finder have no LANG
+func systemLocales() []string {
+	var locales []string
+	// AppleLanguages is printed as a plist array: ( "de-DE", "en-US" )
+	if output, err := utils.Commands().Output(exec.Command("defaults", "read", "-g", "AppleLanguages")); err == nil {
+		locales = append(locales, strings.FieldsFunc(string(output), func(r rune) bool {
+			return strings.ContainsRune("(),\" \n\t", r)
+		})...)
+	}
+	if output, err := utils.Commands().Output(exec.Command("defaults", "read", "-g", "AppleLocale")); err == nil {
+		locales = append(locales, strings.TrimSpace(string(output)))
+	}
+	return locales
+}
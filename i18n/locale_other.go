@@ -0,0 +1,8 @@
+//go:build !darwin && !windows
+
+package i18n
+
+// systemLocales returns nothing; elsewhere the environment variables carry the locale
+func systemLocales() []string {
+	return nil
+}
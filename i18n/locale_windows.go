@@ -0,0 +1,27 @@
+//go:build windows
+
+package i18n
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// localeNameMaxLength is LOCALE_NAME_MAX_LENGTH, the buffer size GetUserDefaultLocaleName needs
+const localeNameMaxLength = 85
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGetUserDefaultLocaleName = kernel32.NewProc("GetUserDefaultLocaleName")
+)
+
+// systemLocales returns the user's display locale, such as "de-DE"; apps started from
+// Explorer have no LANG
+func systemLocales() []string {
+	buffer := make([]uint16, localeNameMaxLength)
+	ret, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buffer[0])), uintptr(len(buffer)))
+	if ret == 0 {
+		return nil
+	}
+	return []string{syscall.UTF16ToString(buffer)}
+}
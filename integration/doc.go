@@ -0,0 +1,9 @@
+// Package integration holds end-to-end tests of the container lifecycle against a real Docker
+// daemon. They drive core.Service and docker.Manager through pull, run, install, ready and
+// stop with a small stub image that replays scripted Moodle logs and serves HTTP, so they
+// need no Moodle image and finish in about a minute.
+//
+// The tests are behind the integration build tag and skip when Docker is not available:
+//
+//	go test -tags integration ./integration/
+package integration
@@ -0,0 +1,276 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// baseImage is pulled through the manager, then used to build stubImage
+	baseImage = "busybox:1.36"
+	// stubImage is built from testdata/stub
+	stubImage = "moodle-manager-stub:integration"
+
+	installTimeout = 2 * time.Minute
+)
+
+var (
+	stubOnce sync.Once
+	stubErr  error
+)
+
+// requireStub skips the test without Docker, and otherwise pulls the base image and builds
+// the stub image once per run
+func requireStub(t *testing.T) {
+	t.Helper()
+	if !docker.CheckDockerHealth() {
+		t.Skip("Docker is not available")
+	}
+
+	stubOnce.Do(func() {
+		manager := docker.NewManager()
+		manager.SetImageName(baseImage)
+		var last float64
+		if err := manager.PullImageWithProgress(func(progress float64, status string) { last = progress }); err != nil {
+			stubErr = fmt.Errorf("failed to pull %s: %w", baseImage, err)
+			return
+		}
+		if exists, err := manager.CheckImageExists(); err != nil || !exists {
+			stubErr = fmt.Errorf("expected %s to exist after the pull (reached %.0f%%): %v", baseImage, last, err)
+			return
+		}
+
+		if output, err := docker.GetDockerCommand("build", "-t", stubImage, filepath.Join("testdata", "stub")).CombinedOutput(); err != nil {
+			stubErr = fmt.Errorf("failed to build %s: %w\n%s", stubImage, err, output)
+		}
+	})
+	if stubErr != nil {
+		t.Fatal(stubErr)
+	}
+}
+
+// noCommands fails every helper program but the docker CLI, which it runs with docker, so
+// credentials go to the encrypted file rather than the keychain of the machine running the tests
+type noCommands struct {
+	docker utils.CommandRunner
+}
+
+var errNoCommands = stderrors.New("commands are disabled in tests")
+
+// isDocker reports whether cmd runs the docker CLI
+func isDocker(cmd *exec.Cmd) bool {
+	return strings.TrimSuffix(filepath.Base(cmd.Path), ".exe") == "docker"
+}
+
+func (r noCommands) Run(cmd *exec.Cmd) error {
+	if isDocker(cmd) {
+		return r.docker.Run(cmd)
+	}
+	return errNoCommands
+}
+func (r noCommands) Output(cmd *exec.Cmd) ([]byte, error) {
+	if isDocker(cmd) {
+		return r.docker.Output(cmd)
+	}
+	return nil, errNoCommands
+}
+func (r noCommands) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if isDocker(cmd) {
+		return r.docker.CombinedOutput(cmd)
+	}
+	return nil, errNoCommands
+}
+func (r noCommands) Start(cmd *exec.Cmd) error { return errNoCommands }
+
+// newService returns a service for a throwaway instance whose container replays logFile and
+// then exits with exitCode, or serves HTTP when exitCode is empty. The container is removed
+// when the test ends.
+func newService(t *testing.T, logFile, exitCode string) (*core.Service, *docker.Manager) {
+	t.Helper()
+	requireStub(t)
+
+	logs, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	// Keep the docker CLI configuration, e.g. the Docker Desktop context, when HOME moves
+	if os.Getenv("DOCKER_CONFIG") == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			t.Setenv("DOCKER_CONFIG", filepath.Join(home, ".docker"))
+		}
+	}
+	t.Setenv("HOME", t.TempDir())
+	previous := utils.SetCommandRunner(noCommands{docker: utils.Commands()})
+	t.Cleanup(func() { utils.SetCommandRunner(previous) })
+
+	manager := docker.NewManager()
+	manager.SetImageName(stubImage)
+	manager.SetInstanceName(fmt.Sprintf("it-%d", time.Now().UnixNano()))
+	manager.SetStopTimeout(3 * time.Second)
+	env := map[string]string{"STUB_LOG": string(logs), "STUB_LINE_DELAY_US": "100000"}
+	if exitCode != "" {
+		env["STUB_EXIT"] = exitCode
+	}
+	manager.SetContainerEnv(env)
+
+	service := core.NewServiceWithDocker(manager)
+	t.Cleanup(func() {
+		if id, err := service.ContainerID(); err == nil {
+			if err := manager.RemoveContainer(id, true); err != nil {
+				t.Logf("Failed to remove container %s: %v", id, err)
+			}
+		}
+	})
+	return service, manager
+}
+
+func TestLifecycle(t *testing.T) {
+	service, manager := newService(t, filepath.Join("testdata", "stub-install.log"), "")
+
+	var steps []string
+	result, err := service.Start(core.StartHooks{Step: func(step, message string) { steps = append(steps, step) }})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !result.Created || slices.Contains(steps, docker.RunStepPullingImage) {
+		t.Errorf("Expected a new container from the local stub image, got %+v after %v", result, steps)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+	defer cancel()
+	var phases []string
+	creds, err := service.WaitForInstall(ctx, result.ContainerID, result.StartTime, func(progress *docker.InstallProgress) {
+		if len(phases) == 0 || phases[len(phases)-1] != progress.Phase {
+			phases = append(phases, progress.Phase)
+		}
+	})
+	if err != nil {
+		t.Fatalf("WaitForInstall failed: %v", err)
+	}
+	if creds.Password != "St4b-Passw0rd" {
+		t.Errorf("Expected the logged password, got %q", creds.Password)
+	}
+	if len(phases) == 0 || phases[len(phases)-1] != docker.InstallPhaseComplete {
+		t.Errorf("Expected install progress to end complete, got %v", phases)
+	}
+
+	if err := service.WaitForReady(ctx, result.ContainerID, time.Minute); err != nil {
+		t.Fatalf("WaitForReady failed: %v", err)
+	}
+	resp, err := http.Get(service.MoodleURL())
+	if err != nil {
+		t.Fatalf("Expected the stub to answer on %s: %v", service.MoodleURL(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected HTTP 200, got %d", resp.StatusCode)
+	}
+
+	var stages []string
+	stopped, err := service.Stop(func(progress docker.StopProgress) { stages = append(stages, progress.Stage) })
+	if err != nil || !stopped {
+		t.Fatalf("Expected the container to stop, got %v (%v)", stopped, err)
+	}
+	if running, err := manager.IsContainerRunning(result.ContainerID); err != nil || running {
+		t.Errorf("Expected the container to be stopped, got running=%v (%v)", running, err)
+	}
+	if len(stages) == 0 || stages[0] != docker.StopStageTerminating {
+		t.Errorf("Expected the stop to start with SIGTERM, got stages %v", stages)
+	}
+
+	// A second start reuses the stopped container
+	again, err := service.Start(core.StartHooks{})
+	if err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if again.Created || again.ContainerID != result.ContainerID {
+		t.Errorf("Expected container %s to be restarted, got %+v", result.ContainerID, again)
+	}
+	if _, err := service.Stop(nil); err != nil {
+		t.Errorf("Failed to stop the restarted container: %v", err)
+	}
+}
+
+func TestInstallCredentialFixtures(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		password string
+	}{
+		{filepath.Join("testdata", "stub-install.log"), "St4b-Passw0rd"},
+		// The parser fixtures, streamed a line at a time rather than read whole
+		{filepath.Join("..", "docker", "testdata", "logs", "custom-entrypoint.log"), "Cust0m-Secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.fixture), func(t *testing.T) {
+			service, _ := newService(t, tt.fixture, "")
+			result, err := service.Start(core.StartHooks{})
+			if err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+			defer cancel()
+			creds, err := service.WaitForInstall(ctx, result.ContainerID, result.StartTime, nil)
+			if err != nil {
+				t.Fatalf("WaitForInstall failed: %v", err)
+			}
+			// The saved URL is the local one; the logged URL only completes the credentials
+			if creds.Password != tt.password || creds.URL != service.MoodleURL() {
+				t.Errorf("Expected %q at %s, got %q at %s", tt.password, service.MoodleURL(), creds.Password, creds.URL)
+			}
+		})
+	}
+}
+
+func TestInstallFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		exitCode string
+		reason   string
+	}{
+		// The fatal error is read from the log while the container still runs
+		{"log error", "stub-fatal.log", "", docker.InstallFailureLogError},
+		// Without a recognisable error the stopped container is reported
+		{"exited", "stub-exited.log", "3", docker.InstallFailureExited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newService(t, filepath.Join("testdata", tt.fixture), tt.exitCode)
+			result, err := service.Start(core.StartHooks{})
+			if err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+			defer cancel()
+			_, err = service.WaitForInstall(ctx, result.ContainerID, result.StartTime, nil)
+			var failure *docker.InstallFailure
+			if !stderrors.As(err, &failure) || failure.Reason != tt.reason {
+				t.Fatalf("Expected a %s install failure, got %v", tt.reason, err)
+			}
+			if tt.reason == docker.InstallFailureExited && failure.ExitCode != 3 {
+				t.Errorf("Expected exit code 3, got %d", failure.ExitCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+#!/bin/sh
+# Replays STUB_LOG a line at a time, STUB_LINE_DELAY_US microseconds apart, like an installing
+# Moodle. Then exits with STUB_EXIT when it is set, or serves HTTP until stopped.
+
+printf '%s\n' "$STUB_LOG" | while IFS= read -r line; do
+    echo "$line"
+    usleep "${STUB_LINE_DELAY_US:-200000}"
+done
+
+if [ -n "$STUB_EXIT" ]; then
+    exit "$STUB_EXIT"
+fi
+
+exec httpd -f -p 8080 -h /www
@@ -0,0 +1,159 @@
+package kiosk
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/fleet"
+)
+
+type fakePrototype struct {
+	running, ready   bool
+	starts, restarts int
+	opened           int
+}
+
+func (f *fakePrototype) Status() Status {
+	if f.running {
+		return Status{State: "running"}
+	}
+	return Status{State: "stopped"}
+}
+func (f *fakePrototype) Running() bool { return f.running }
+func (f *fakePrototype) Ready() bool   { return f.ready }
+func (f *fakePrototype) OpenURL() error {
+	f.opened++
+	return nil
+}
+func (f *fakePrototype) Start() error {
+	f.starts++
+	f.running = true
+	return nil
+}
+func (f *fakePrototype) Restart() error {
+	f.restarts++
+	return nil
+}
+
+func TestServerExposesOnlyKioskEndpoints(t *testing.T) {
+	prototype := &fakePrototype{}
+	server := NewServer(prototype, "booth")
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, fleet.StatusPath, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the token, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, fleet.StartPath, "booth"); rec.Code != http.StatusAccepted || prototype.starts != 1 {
+		t.Errorf("Expected start to be accepted, got %d (starts: %d)", rec.Code, prototype.starts)
+	}
+
+	rec := request(http.MethodGet, fleet.StatusPath, "booth")
+	var status Status
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || status.State != "running" {
+		t.Errorf("Expected running status, got %+v (err: %v)", status, err)
+	}
+
+	if rec := request(http.MethodPost, OpenURLPath, "booth"); rec.Code != http.StatusNoContent || prototype.opened != 1 {
+		t.Errorf("Expected the URL to be opened, got %d", rec.Code)
+	}
+	for _, path := range []string{fleet.StopPath, fleet.ResetPath} {
+		if rec := request(http.MethodPost, path, "booth"); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected %s to be unavailable in kiosk mode, got %d", path, rec.Code)
+		}
+	}
+	if rec := request(http.MethodGet, fleet.StartPath, "booth"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET start to be rejected, got %d", rec.Code)
+	}
+}
+
+// brokenPrototype cannot be started
+type brokenPrototype struct{ fakePrototype }
+
+func (b *brokenPrototype) Start() error {
+	return errors.WithCode(stderrors.New("docker is not running"), errors.CodeDockerNotRunning)
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	for _, server := range []*Server{NewServer(&fakePrototype{}, "booth"), NewServer(&fakePrototype{}, "")} {
+		for _, header := range []string{"", "Bearer ", "Bearer wrong", "booth"} {
+			req := httptest.NewRequest(http.MethodPost, fleet.StartPath, nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("token %q (server token %q): expected 401, got %d", header, server.token, rec.Code)
+			}
+		}
+	}
+}
+
+func TestServerReportsSerializedErrors(t *testing.T) {
+	server := NewServer(&brokenPrototype{}, "booth")
+	req := httptest.NewRequest(http.MethodPost, fleet.StartPath, nil)
+	req.Header.Set("Authorization", "Bearer booth")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	var serialized errors.SerializedError
+	if err := json.NewDecoder(rec.Body).Decode(&serialized); err != nil {
+		t.Fatalf("Expected a serialized error, got %v", err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || serialized.Code != errors.CodeDockerNotRunning {
+		t.Errorf("Expected 503 with code %s, got %d %+v", errors.CodeDockerNotRunning, rec.Code, serialized)
+	}
+}
+
+func TestWatchdogRecovers(t *testing.T) {
+	prototype := &fakePrototype{}
+	var reasons []string
+	watchdog := NewWatchdog(prototype, func(reason string, err error) { reasons = append(reasons, reason) })
+
+	watchdog.check()
+	if prototype.starts != 1 {
+		t.Fatalf("Expected a stopped prototype to be started, got %d starts", prototype.starts)
+	}
+
+	// Not ready yet after starting: still installing, not hung
+	for i := 0; i < hungChecks*2; i++ {
+		watchdog.check()
+	}
+	if prototype.restarts != 0 {
+		t.Errorf("Expected no restart before Moodle was ever ready, got %d", prototype.restarts)
+	}
+
+	prototype.ready = true
+	watchdog.check()
+	prototype.ready = false
+	for i := 0; i < hungChecks; i++ {
+		watchdog.check()
+	}
+	if prototype.restarts != 1 {
+		t.Errorf("Expected a hung prototype to be restarted, got %d restarts", prototype.restarts)
+	}
+
+	watchdog.Suspend()
+	prototype.running = false
+	watchdog.check()
+	if prototype.starts != 1 {
+		t.Errorf("Expected no recovery while suspended, got %d starts", prototype.starts)
+	}
+
+	if count, _ := watchdog.Recoveries(); count != 2 || len(reasons) != 2 {
+		t.Errorf("Expected 2 recoveries, got %d (%v)", count, reasons)
+	}
+}
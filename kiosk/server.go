@@ -0,0 +1,157 @@
+// Package kiosk runs the prototype unattended, e.g. on an exhibition booth PC: a minimal
+// local API limited to status, start and open-url, and a watchdog that restarts Moodle
+// after crashes without anyone at the keyboard.
+package kiosk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/fleet"
+	"moodle-prototype-manager/utils"
+)
+
+// OpenURLPath opens Moodle in the machine's browser; status and start share the fleet API paths
+const OpenURLPath = "/api/v1/open-url"
+
+// Status is the document served on the status endpoint
+type Status struct {
+	// State is running, starting or stopped
+	State string `json:"state"`
+	URL   string `json:"url"`
+	// Recoveries counts automatic restarts since the manager started
+	Recoveries   int       `json:"recoveries"`
+	LastRecovery time.Time `json:"lastRecovery,omitempty"`
+}
+
+// Backend is the prototype controlled through the kiosk API
+type Backend interface {
+	Status() Status
+	// Start starts Moodle; it may return before Moodle is ready
+	Start() error
+	// OpenURL opens Moodle in the local browser
+	OpenURL() error
+}
+
+// Server serves the kiosk API on the loopback interface only
+type Server struct {
+	backend Backend
+	token   string
+	server  *http.Server
+}
+
+// NewServer creates a kiosk API server; requests must send token as a bearer token, and every
+// request is refused while it is empty. The API can start Moodle and open the browser, so a web
+// page or a DNS-rebinding attack must not be able to call it.
+func NewServer(backend Backend, token string) *Server {
+	s := &Server{backend: backend, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(fleet.StatusPath, s.handle(http.MethodGet, s.status))
+	mux.HandleFunc(fleet.StartPath, s.handle(http.MethodPost, s.start))
+	mux.HandleFunc(OpenURLPath, s.handle(http.MethodPost, s.openURL))
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// NewToken returns a random token for the API
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate kiosk API token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Start listens on 127.0.0.1:port
+func (s *Server) Start(port int) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.NewNetworkError("kiosk_listen", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			utils.LogError("Kiosk API stopped", err)
+		}
+	}()
+	utils.LogInfo(fmt.Sprintf("Kiosk API listening on http://%s", addr))
+	return nil
+}
+
+// Stop shuts the server down
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		utils.LogWarning(fmt.Sprintf("Kiosk API shutdown: %v", err))
+	}
+}
+
+// ServeHTTP handles a request; used by tests without a listener
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
+}
+
+// handle checks the method and token before calling fn
+func (s *Server) handle(method string, fn func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend.Status())
+}
+
+func (s *Server) start(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend.Start(); err != nil {
+		utils.LogError("Kiosk start request failed", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.backend.Status())
+}
+
+func (s *Server) openURL(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend.OpenURL(); err != nil {
+		utils.LogError("Kiosk open-url request failed", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError writes err in the shape the desktop frontend and the control API use
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, errors.HTTPStatus(err), errors.Serialize(err))
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to write kiosk API response: %v", err))
+	}
+}
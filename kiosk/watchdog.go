@@ -0,0 +1,166 @@
+package kiosk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// DefaultCheckInterval is how often the watchdog checks the prototype
+const DefaultCheckInterval = 30 * time.Second
+
+// hungChecks is how many consecutive failed readiness checks of a running container that has
+// been ready before count as a hang and trigger a restart
+const hungChecks = 6
+
+// Target is the prototype the watchdog keeps alive
+type Target interface {
+	// Running reports whether the Moodle container is running
+	Running() bool
+	// Ready reports whether Moodle answers HTTP
+	Ready() bool
+	// Start starts a stopped prototype
+	Start() error
+	// Restart stops and starts a hung prototype
+	Restart() error
+}
+
+// Watchdog restarts the prototype when its container stops or Moodle stops answering
+type Watchdog struct {
+	target    Target
+	onRecover func(reason string, err error)
+
+	mu         sync.Mutex
+	suspended  bool
+	wasReady   bool
+	failures   int
+	recoveries int
+	last       time.Time
+	stopChan   chan struct{}
+}
+
+// NewWatchdog creates a watchdog; onRecover, if not nil, is called after each recovery attempt
+func NewWatchdog(target Target, onRecover func(reason string, err error)) *Watchdog {
+	return &Watchdog{target: target, onRecover: onRecover}
+}
+
+// Start checks the prototype every interval until Stop
+func (w *Watchdog) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	w.mu.Lock()
+	if w.stopChan != nil {
+		w.mu.Unlock()
+		return
+	}
+	stopChan := make(chan struct{})
+	w.stopChan = stopChan
+	w.mu.Unlock()
+
+	utils.LogInfo(fmt.Sprintf("Kiosk watchdog started (every %v)", interval))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.check()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the checks
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopChan != nil {
+		close(w.stopChan)
+		w.stopChan = nil
+	}
+}
+
+// Suspend pauses recovery, e.g. while an operator deliberately stopped the prototype
+func (w *Watchdog) Suspend() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.suspended = true
+	w.wasReady = false
+	w.failures = 0
+}
+
+// Resume re-enables recovery
+func (w *Watchdog) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.suspended = false
+}
+
+// Recoveries returns the number of recovery attempts and when the last one happened
+func (w *Watchdog) Recoveries() (int, time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.recoveries, w.last
+}
+
+// check inspects the prototype once and recovers it when needed
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	suspended := w.suspended
+	w.mu.Unlock()
+	if suspended {
+		return
+	}
+
+	if !w.target.Running() {
+		w.recover("container stopped", w.target.Start)
+		return
+	}
+
+	ready := w.target.Ready()
+	w.mu.Lock()
+	if ready {
+		w.wasReady = true
+		w.failures = 0
+		w.mu.Unlock()
+		return
+	}
+	// A container that has never been ready may still be installing Moodle
+	if !w.wasReady {
+		w.mu.Unlock()
+		return
+	}
+	w.failures++
+	hung := w.failures >= hungChecks
+	w.mu.Unlock()
+
+	if hung {
+		w.recover("Moodle stopped responding", w.target.Restart)
+	}
+}
+
+// recover runs a recovery action and records it
+func (w *Watchdog) recover(reason string, action func() error) {
+	utils.LogWarning(fmt.Sprintf("Kiosk watchdog recovering the prototype: %s", reason))
+	err := action()
+	if err != nil {
+		utils.LogError("Kiosk watchdog recovery failed", err)
+	}
+
+	w.mu.Lock()
+	w.recoveries++
+	w.last = time.Now()
+	w.wasReady = false
+	w.failures = 0
+	w.mu.Unlock()
+
+	if w.onRecover != nil {
+		w.onRecover(reason, err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"runtime/debug"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -14,6 +15,15 @@ var assets embed.FS
 func main() {
 	// Create an instance of the app structure
 	app := NewApp()
+	// Report a crash of the main goroutine when the user opted in to telemetry, then crash as
+	// before. Other goroutines report their own: bindings through recoverBinding, background
+	// tasks through tasks.onPanic and the rest through goReported.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			app.telemetry.ReportPanic(recovered, debug.Stack())
+			panic(recovered)
+		}
+	}()
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -25,7 +35,12 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.OnStartup,
+		OnBeforeClose:    app.OnBeforeClose,
 		OnShutdown:       app.OnShutdown,
+		// Errors reach the frontend as {code, message, details} so it can show actionable messages
+		ErrorFormatter: func(err error) any {
+			return app.serializeError(err)
+		},
 		Bind: []interface{}{
 			app,
 		},
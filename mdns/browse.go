@@ -0,0 +1,132 @@
+// Package mdns implements the small subset of multicast DNS needed to
+// discover other Moodle Prototype Manager instances on the local network.
+package mdns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// ServiceType is the DNS-SD service type advertised by the manager
+const ServiceType = "_moodleproto._tcp.local."
+
+// multicastAddr is the IPv4 mDNS group
+var multicastAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// ServiceEntry is a discovered service instance
+type ServiceEntry struct {
+	Instance string            `json:"instance"`
+	Host     string            `json:"host"`
+	Port     int               `json:"port"`
+	Address  string            `json:"address"`
+	Text     map[string]string `json:"text"`
+}
+
+// Browse queries the LAN for instances of service and collects answers until timeout
+func Browse(service string, timeout time.Duration) ([]ServiceEntry, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	query := &Message{Questions: []Question{{Name: service, Type: TypePTR, Unicast: true}}}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mDNS query: %w", err)
+	}
+
+	if _, err := conn.WriteToUDP(packet, multicastAddr); err != nil {
+		return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	records := make([]Record, 0)
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 9000)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("failed to read mDNS response: %w", err)
+		}
+
+		msg, err := Unpack(buf[:n])
+		if err != nil {
+			utils.LogDebug(fmt.Sprintf("Ignoring malformed mDNS packet from %v: %v", src, err))
+			continue
+		}
+		if !msg.Response {
+			continue
+		}
+
+		// Remember the sender so entries without A records remain reachable
+		for i := range msg.Records {
+			if msg.Records[i].Type == TypeSRV && msg.Records[i].IP == nil {
+				msg.Records[i].IP = src.IP.To4()
+			}
+		}
+		records = append(records, msg.Records...)
+	}
+
+	return collectEntries(service, records), nil
+}
+
+// collectEntries joins PTR, SRV, TXT and A records into service entries
+func collectEntries(service string, records []Record) []ServiceEntry {
+	addresses := make(map[string]string)
+	for _, r := range records {
+		if r.Type == TypeA && len(r.IP) == 4 {
+			addresses[strings.ToLower(r.Name)] = net.IP(r.IP).String()
+		}
+	}
+
+	entries := make([]ServiceEntry, 0)
+	seen := make(map[string]bool)
+	for _, ptr := range records {
+		if ptr.Type != TypePTR || !strings.EqualFold(ptr.Name, service) || seen[ptr.Target] {
+			continue
+		}
+		seen[ptr.Target] = true
+
+		entry := ServiceEntry{
+			Instance: strings.TrimSuffix(strings.TrimSuffix(ptr.Target, service), "."),
+			Text:     make(map[string]string),
+		}
+		for _, r := range records {
+			if !strings.EqualFold(r.Name, ptr.Target) {
+				continue
+			}
+			switch r.Type {
+			case TypeSRV:
+				entry.Host = r.Target
+				entry.Port = int(r.Port)
+				if entry.Address == "" && len(r.IP) == 4 {
+					entry.Address = net.IP(r.IP).String()
+				}
+			case TypeTXT:
+				for _, text := range r.Text {
+					kv := strings.SplitN(text, "=", 2)
+					if len(kv) == 2 {
+						entry.Text[kv[0]] = kv[1]
+					} else {
+						entry.Text[kv[0]] = ""
+					}
+				}
+			}
+		}
+		if address, ok := addresses[strings.ToLower(entry.Host)]; ok {
+			entry.Address = address
+		}
+		if entry.Port != 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
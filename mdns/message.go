@@ -0,0 +1,263 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// DNS record types used for service discovery
+const (
+	TypeA   uint16 = 1
+	TypePTR uint16 = 12
+	TypeTXT uint16 = 16
+	TypeSRV uint16 = 33
+	TypeANY uint16 = 255
+
+	classIN      uint16 = 1
+	classMask    uint16 = 0x7fff
+	unicastBit   uint16 = 0x8000
+	flagResponse uint16 = 0x8400 // QR + AA
+)
+
+// Question is a single DNS question
+type Question struct {
+	Name    string
+	Type    uint16
+	Unicast bool
+}
+
+// Record is a decoded resource record; only the fields relevant to its type are set
+type Record struct {
+	Name string
+	Type uint16
+	TTL  uint32
+
+	Target string   // PTR target or SRV target host
+	Port   uint16   // SRV port
+	Text   []string // TXT strings
+	IP     []byte   // A address
+}
+
+// Message is a minimal DNS message holding questions and all answer sections
+type Message struct {
+	ID        uint16
+	Response  bool
+	Questions []Question
+	Records   []Record
+}
+
+// Pack encodes the message in DNS wire format (answers only, no compression)
+func (m *Message) Pack() ([]byte, error) {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint16(buf[0:], m.ID)
+	if m.Response {
+		binary.BigEndian.PutUint16(buf[2:], flagResponse)
+	}
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(m.Records)))
+
+	var err error
+	for _, q := range m.Questions {
+		if buf, err = appendName(buf, q.Name); err != nil {
+			return nil, err
+		}
+		class := classIN
+		if q.Unicast {
+			class |= unicastBit
+		}
+		buf = binary.BigEndian.AppendUint16(buf, q.Type)
+		buf = binary.BigEndian.AppendUint16(buf, class)
+	}
+
+	for _, r := range m.Records {
+		if buf, err = appendName(buf, r.Name); err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint16(buf, r.Type)
+		buf = binary.BigEndian.AppendUint16(buf, classIN)
+		buf = binary.BigEndian.AppendUint32(buf, r.TTL)
+
+		rdata, err := packRData(r)
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(rdata)))
+		buf = append(buf, rdata...)
+	}
+
+	return buf, nil
+}
+
+// packRData encodes the type-specific record data
+func packRData(r Record) ([]byte, error) {
+	switch r.Type {
+	case TypePTR:
+		return appendName(nil, r.Target)
+	case TypeSRV:
+		rdata := make([]byte, 6) // priority, weight, port
+		binary.BigEndian.PutUint16(rdata[4:], r.Port)
+		return appendName(rdata, r.Target)
+	case TypeTXT:
+		rdata := make([]byte, 0)
+		for _, text := range r.Text {
+			if len(text) > 255 {
+				return nil, fmt.Errorf("TXT string too long: %d bytes", len(text))
+			}
+			rdata = append(rdata, byte(len(text)))
+			rdata = append(rdata, text...)
+		}
+		if len(rdata) == 0 {
+			rdata = append(rdata, 0)
+		}
+		return rdata, nil
+	case TypeA:
+		if len(r.IP) != 4 {
+			return nil, fmt.Errorf("A record requires an IPv4 address")
+		}
+		return r.IP, nil
+	}
+	return nil, fmt.Errorf("unsupported record type %d", r.Type)
+}
+
+// appendName encodes a dotted domain name as DNS labels
+func appendName(buf []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid DNS label in %q", name)
+			}
+			buf = append(buf, byte(len(label)))
+			buf = append(buf, label...)
+		}
+	}
+	return append(buf, 0), nil
+}
+
+// Unpack decodes a DNS message, skipping record types it does not understand
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("message too short")
+	}
+
+	m := &Message{
+		ID:       binary.BigEndian.Uint16(data[0:]),
+		Response: data[2]&0x80 != 0,
+	}
+	qdCount := int(binary.BigEndian.Uint16(data[4:]))
+	rrCount := int(binary.BigEndian.Uint16(data[6:])) +
+		int(binary.BigEndian.Uint16(data[8:])) +
+		int(binary.BigEndian.Uint16(data[10:]))
+
+	offset := 12
+	for i := 0; i < qdCount; i++ {
+		name, next, err := readName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(data) {
+			return nil, fmt.Errorf("truncated question")
+		}
+		class := binary.BigEndian.Uint16(data[next+2:])
+		m.Questions = append(m.Questions, Question{
+			Name:    name,
+			Type:    binary.BigEndian.Uint16(data[next:]),
+			Unicast: class&unicastBit != 0,
+		})
+		offset = next + 4
+	}
+
+	for i := 0; i < rrCount; i++ {
+		name, next, err := readName(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(data) {
+			return nil, fmt.Errorf("truncated record")
+		}
+		r := Record{
+			Name: name,
+			Type: binary.BigEndian.Uint16(data[next:]),
+			TTL:  binary.BigEndian.Uint32(data[next+4:]),
+		}
+		rdLen := int(binary.BigEndian.Uint16(data[next+8:]))
+		start := next + 10
+		end := start + rdLen
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated record data")
+		}
+
+		switch r.Type {
+		case TypePTR:
+			if r.Target, _, err = readName(data, start); err != nil {
+				return nil, err
+			}
+		case TypeSRV:
+			if rdLen < 7 {
+				return nil, fmt.Errorf("short SRV record")
+			}
+			r.Port = binary.BigEndian.Uint16(data[start+4:])
+			if r.Target, _, err = readName(data, start+6); err != nil {
+				return nil, err
+			}
+		case TypeTXT:
+			for pos := start; pos < end; {
+				length := int(data[pos])
+				if pos+1+length > end {
+					return nil, fmt.Errorf("truncated TXT string")
+				}
+				if length > 0 {
+					r.Text = append(r.Text, string(data[pos+1:pos+1+length]))
+				}
+				pos += 1 + length
+			}
+		case TypeA:
+			if rdLen == 4 {
+				r.IP = append([]byte(nil), data[start:end]...)
+			}
+		}
+
+		m.Records = append(m.Records, r)
+		offset = end
+	}
+
+	return m, nil
+}
+
+// readName decodes a possibly compressed domain name starting at offset
+func readName(data []byte, offset int) (string, int, error) {
+	labels := make([]string, 0)
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(data) {
+			return "", 0, fmt.Errorf("name extends past message")
+		}
+		length := int(data[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(data) {
+				return "", 0, fmt.Errorf("truncated name pointer")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			jumps++
+			if jumps > 10 {
+				return "", 0, fmt.Errorf("too many name compression pointers")
+			}
+			offset = int(binary.BigEndian.Uint16(data[offset:]) & 0x3fff)
+		default:
+			if offset+1+length > len(data) {
+				return "", 0, fmt.Errorf("truncated label")
+			}
+			labels = append(labels, string(data[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
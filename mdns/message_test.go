@@ -0,0 +1,41 @@
+package mdns
+
+import (
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	instance := "Lab PC 1." + ServiceType
+	msg := &Message{
+		Response: true,
+		Records: []Record{
+			{Name: ServiceType, Type: TypePTR, TTL: 120, Target: instance},
+			{Name: instance, Type: TypeSRV, TTL: 120, Target: "labpc1.local.", Port: 47800},
+			{Name: instance, Type: TypeTXT, TTL: 120, Text: []string{"name=Lab PC 1", "url=http://192.168.1.20:8080"}},
+			{Name: "labpc1.local.", Type: TypeA, TTL: 120, IP: []byte{192, 168, 1, 20}},
+		},
+	}
+
+	packet, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("Failed to pack message: %v", err)
+	}
+
+	decoded, err := Unpack(packet)
+	if err != nil {
+		t.Fatalf("Failed to unpack message: %v", err)
+	}
+
+	entries := collectEntries(ServiceType, decoded.Records)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Instance != "Lab PC 1" || entry.Port != 47800 || entry.Address != "192.168.1.20" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.Text["url"] != "http://192.168.1.20:8080" {
+		t.Errorf("Unexpected TXT url: %s", entry.Text["url"])
+	}
+}
@@ -0,0 +1,242 @@
+package mdns
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// recordTTL is the TTL (seconds) advertised for our records
+const recordTTL = 120
+
+// Service describes an instance to advertise
+type Service struct {
+	Instance string
+	Port     int
+	Text     map[string]string
+}
+
+// Responder answers mDNS queries for a single advertised service
+type Responder struct {
+	service Service
+	host    string
+	ip      net.IP
+
+	mu     sync.Mutex
+	conn   *net.UDPConn
+	closed chan struct{}
+}
+
+// NewResponder prepares a responder advertising service from this host
+func NewResponder(service Service) (*Responder, error) {
+	ip, err := LocalIPv4()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "moodle-prototype"
+	}
+	hostname = strings.TrimSuffix(strings.Split(hostname, ".")[0], ".")
+
+	return &Responder{
+		service: service,
+		host:    hostname + ".local.",
+		ip:      ip,
+	}, nil
+}
+
+// Start joins the mDNS group, announces the service and answers queries until Stop
+func (r *Responder) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		return nil
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, multicastAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+	r.conn = conn
+	r.closed = make(chan struct{})
+
+	go r.serve(conn, r.closed)
+
+	// Unsolicited announcements so browsers already listening see us immediately
+	go func(closed chan struct{}) {
+		for i := 0; i < 2; i++ {
+			r.send(r.response(recordTTL), multicastAddr)
+			select {
+			case <-time.After(time.Second):
+			case <-closed:
+				return
+			}
+		}
+	}(r.closed)
+
+	utils.LogInfo(fmt.Sprintf("Advertising %q via mDNS as %s on %s:%d", r.service.Instance, ServiceType, r.ip, r.service.Port))
+	return nil
+}
+
+// Stop sends a goodbye packet and stops answering queries
+func (r *Responder) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return
+	}
+
+	// TTL 0 tells listeners to drop the records
+	r.sendLocked(r.response(0), multicastAddr)
+	close(r.closed)
+	r.conn.Close()
+	r.conn = nil
+	utils.LogInfo("Stopped mDNS advertisement")
+}
+
+// serve answers incoming queries
+func (r *Responder) serve(conn *net.UDPConn, closed chan struct{}) {
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-closed:
+				return
+			default:
+			}
+			utils.LogDebug(fmt.Sprintf("mDNS read error: %v", err))
+			continue
+		}
+
+		query, err := Unpack(buf[:n])
+		if err != nil || query.Response {
+			continue
+		}
+
+		unicast := false
+		matched := false
+		for _, q := range query.Questions {
+			if r.matches(q) {
+				matched = true
+				unicast = unicast || q.Unicast
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		// Queries from non-5353 ports are legacy unicast and must be answered directly
+		dest := multicastAddr
+		if unicast || src.Port != multicastAddr.Port {
+			dest = src
+		}
+		response := r.response(recordTTL)
+		response.ID = query.ID
+		r.send(response, dest)
+	}
+}
+
+// matches reports whether a question concerns our service
+func (r *Responder) matches(q Question) bool {
+	name := strings.ToLower(q.Name)
+	switch {
+	case name == strings.ToLower(ServiceType):
+		return q.Type == TypePTR || q.Type == TypeANY
+	case name == strings.ToLower(r.instanceName()):
+		return q.Type == TypeSRV || q.Type == TypeTXT || q.Type == TypeANY
+	case name == strings.ToLower(r.host):
+		return q.Type == TypeA || q.Type == TypeANY
+	}
+	return false
+}
+
+// instanceName returns the fully qualified service instance name
+func (r *Responder) instanceName() string {
+	return r.service.Instance + "." + ServiceType
+}
+
+// response builds the full record set for our service
+func (r *Responder) response(ttl uint32) *Message {
+	keys := make([]string, 0, len(r.service.Text))
+	for key := range r.service.Text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	text := make([]string, 0, len(keys))
+	for _, key := range keys {
+		text = append(text, key+"="+r.service.Text[key])
+	}
+
+	instance := r.instanceName()
+	return &Message{
+		Response: true,
+		Records: []Record{
+			{Name: ServiceType, Type: TypePTR, TTL: ttl, Target: instance},
+			{Name: instance, Type: TypeSRV, TTL: ttl, Target: r.host, Port: uint16(r.service.Port)},
+			{Name: instance, Type: TypeTXT, TTL: ttl, Text: text},
+			{Name: r.host, Type: TypeA, TTL: ttl, IP: r.ip.To4()},
+		},
+	}
+}
+
+// send writes a message, taking the lock
+func (r *Responder) send(msg *Message, dest *net.UDPAddr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendLocked(msg, dest)
+}
+
+// sendLocked writes a message; the caller holds r.mu
+func (r *Responder) sendLocked(msg *Message, dest *net.UDPAddr) {
+	if r.conn == nil {
+		return
+	}
+
+	packet, err := msg.Pack()
+	if err != nil {
+		utils.LogError("Failed to encode mDNS response", err)
+		return
+	}
+	if _, err := r.conn.WriteToUDP(packet, dest); err != nil {
+		utils.LogDebug(fmt.Sprintf("Failed to send mDNS response to %v: %v", dest, err))
+	}
+}
+
+// LocalIPv4 returns the first non-loopback IPv4 address of an active interface
+func LocalIPv4() (net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				if ip := ipNet.IP.To4(); ip != nil {
+					return ip, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no LAN IPv4 address found")
+}
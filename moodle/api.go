@@ -0,0 +1,116 @@
+package moodle
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Web service functions used by the client; all are part of MobileService
+const (
+	FunctionSiteInfo      = "core_webservice_get_site_info"
+	FunctionCoursesBy     = "core_course_get_courses_by_field"
+	FunctionEnrolledUsers = "core_enrol_get_enrolled_users"
+)
+
+const (
+	// siteCourseFormat is the format of the front page, which Moodle stores as a course
+	siteCourseFormat = "site"
+	// siteCourseID is the ID of the front page course (SITEID)
+	siteCourseID = 1
+)
+
+// SiteInfo describes the Moodle site and the user the client is logged in as
+type SiteInfo struct {
+	SiteName string `json:"sitename"`
+	SiteURL  string `json:"siteurl"`
+	Release  string `json:"release"`
+	Version  string `json:"version"`
+	Username string `json:"username"`
+	FullName string `json:"fullname"`
+	UserID   int    `json:"userid"`
+	Language string `json:"lang"`
+}
+
+// Course is a course as returned by core_course_get_courses_by_field
+type Course struct {
+	ID         int    `json:"id"`
+	ShortName  string `json:"shortname"`
+	FullName   string `json:"fullname"`
+	CategoryID int    `json:"categoryid"`
+	Format     string `json:"format"`
+	Visible    int    `json:"visible"`
+	StartDate  int64  `json:"startdate"`
+	EndDate    int64  `json:"enddate"`
+}
+
+// Role is a role a user holds in a course
+type Role struct {
+	RoleID    int    `json:"roleid"`
+	ShortName string `json:"shortname"`
+	Name      string `json:"name"`
+}
+
+// EnrolledUser is a participant of a course
+type EnrolledUser struct {
+	ID               int    `json:"id"`
+	Username         string `json:"username"`
+	FullName         string `json:"fullname"`
+	Email            string `json:"email"`
+	LastAccess       int64  `json:"lastaccess"`
+	LastCourseAccess int64  `json:"lastcourseaccess"`
+	Roles            []Role `json:"roles"`
+}
+
+// SiteInfo returns the site name, Moodle release and the logged-in user
+func (c *Client) SiteInfo() (*SiteInfo, error) {
+	var info SiteInfo
+	if err := c.Call(FunctionSiteInfo, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Courses returns every course on the site except the front page
+func (c *Client) Courses() ([]Course, error) {
+	var response struct {
+		Courses []Course `json:"courses"`
+	}
+	if err := c.Call(FunctionCoursesBy, nil, &response); err != nil {
+		return nil, err
+	}
+
+	courses := make([]Course, 0, len(response.Courses))
+	for _, course := range response.Courses {
+		if course.Format != siteCourseFormat {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+// EnrolledUsers returns the participants of a course with their roles
+func (c *Client) EnrolledUsers(courseID int) ([]EnrolledUser, error) {
+	users := make([]EnrolledUser, 0)
+	params := url.Values{"courseid": {fmt.Sprintf("%d", courseID)}}
+	if err := c.Call(FunctionEnrolledUsers, params, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UserCount returns the number of active accounts. Every user is a participant of the front
+// page, so its participant list, fetched with only the id field, is the site's user list.
+func (c *Client) UserCount() (int, error) {
+	var users []struct {
+		ID int `json:"id"`
+	}
+	params := url.Values{
+		"courseid":          {fmt.Sprintf("%d", siteCourseID)},
+		"options[0][name]":  {"userfields"},
+		"options[0][value]": {"id"},
+	}
+	if err := c.Call(FunctionEnrolledUsers, params, &users); err != nil {
+		return 0, err
+	}
+	return len(users), nil
+}
@@ -0,0 +1,193 @@
+// Package moodle is a small client for Moodle's REST web services, used to show site, course
+// and enrolment details without parsing container logs.
+package moodle
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// MobileService is the built-in web service site admins may request tokens for through
+	// login/token.php; it covers the read-only functions used here
+	MobileService = "moodle_mobile_app"
+
+	tokenPath   = "/login/token.php"
+	restPath    = "/webservice/rest/server.php"
+	restFormat  = "json"
+	httpTimeout = 30 * time.Second
+	// maxResponseBytes bounds what is read from a single response
+	maxResponseBytes = 32 * 1024 * 1024
+)
+
+// Moodle error codes the client reacts to
+const (
+	// ErrorCodeInvalidToken means the token expired or was revoked; the client logs in again
+	ErrorCodeInvalidToken = "invalidtoken"
+	// ErrorCodeWebServicesDisabled means web services or the mobile service are switched off
+	ErrorCodeWebServicesDisabled = "enablewsdescription"
+	// ErrorCodeServiceUnavailable means the requested service is disabled or unknown
+	ErrorCodeServiceUnavailable = "servicenotavailable"
+)
+
+// APIError is an error reported by Moodle itself rather than by the transport
+type APIError struct {
+	Code      string `json:"errorcode"`
+	Exception string `json:"exception"`
+	Message   string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("moodle error %s: %s", e.Code, e.Message)
+}
+
+// IsWebServicesDisabled reports whether err means web services must be enabled first
+func IsWebServicesDisabled(err error) bool {
+	var apiErr *APIError
+	if !stderrors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == ErrorCodeWebServicesDisabled || apiErr.Code == ErrorCodeServiceUnavailable
+}
+
+// Client calls Moodle web service functions, logging in with a username and password and
+// keeping the token for later calls
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	service  string
+	http     *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewClient creates a client for the Moodle site at baseURL
+func NewClient(baseURL, username, password string) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		service:  MobileService,
+		http:     &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Login requests a token with the client's credentials, replacing any token held
+func (c *Client) Login() error {
+	form := url.Values{
+		"username": {c.username},
+		"password": {c.password},
+		"service":  {c.service},
+	}
+
+	var response struct {
+		APIError
+		Token string `json:"token"`
+		Error string `json:"error"`
+	}
+	if err := c.post(tokenPath, form, &response); err != nil {
+		return errors.WrapWithContext(err, "failed to request web service token")
+	}
+	if response.Token == "" {
+		apiErr := response.APIError
+		if apiErr.Message == "" {
+			apiErr.Message = response.Error
+		}
+		return &apiErr
+	}
+
+	c.mu.Lock()
+	c.token = response.Token
+	c.mu.Unlock()
+	utils.LogDebug(fmt.Sprintf("Obtained Moodle web service token for %s", c.username))
+	return nil
+}
+
+// Call runs a web service function, decoding its result into out. It logs in first when no
+// token is held, and once more if Moodle rejects the token.
+func (c *Client) Call(function string, params url.Values, out any) error {
+	err := c.call(function, params, out)
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) && apiErr.Code == ErrorCodeInvalidToken {
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		err = c.call(function, params, out)
+	}
+	return err
+}
+
+// call runs a function once with the current token
+func (c *Client) call(function string, params url.Values, out any) error {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if token == "" {
+		if err := c.Login(); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		token = c.token
+		c.mu.Unlock()
+	}
+
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("wstoken", token)
+	form.Set("wsfunction", function)
+	form.Set("moodlewsrestformat", restFormat)
+
+	if err := c.post(restPath, form, out); err != nil {
+		return errors.WrapWithContext(err, "web service call %s failed", function)
+	}
+	return nil
+}
+
+// post sends a form and decodes the JSON reply into out. Moodle answers errors with status
+// 200 and an exception object, which becomes an *APIError.
+func (c *Client) post(path string, form url.Values, out any) error {
+	endpoint := c.baseURL + path
+	resp, err := c.http.PostForm(endpoint, form)
+	if err != nil {
+		return errors.NewNetworkErrorWithURL("request", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return errors.NewNetworkErrorWithURL("read", endpoint, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.NewNetworkErrorWithURL("request", endpoint, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return decodeResponse(body, out)
+}
+
+// decodeResponse turns an exception reply into an *APIError, or else decodes body into out
+func decodeResponse(body []byte, out any) error {
+	var apiErr APIError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Exception != "" {
+		return &apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return errors.WrapWithContext(errors.ErrInvalidFormat, "unexpected web service response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package moodle
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeMoodle serves login/token.php and the REST endpoint, issuing a new token per login
+type fakeMoodle struct {
+	logins  int
+	enabled bool
+	valid   string
+}
+
+func (f *fakeMoodle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	switch r.URL.Path {
+	case tokenPath:
+		if !f.enabled {
+			fmt.Fprint(w, `{"error":"Web services must be enabled in Advanced features.","errorcode":"enablewsdescription"}`)
+			return
+		}
+		if r.Form.Get("password") != "secret" || r.Form.Get("service") != MobileService {
+			fmt.Fprint(w, `{"error":"Invalid login, please try again","errorcode":"invalidlogin"}`)
+			return
+		}
+		f.logins++
+		f.valid = fmt.Sprintf("token%d", f.logins)
+		fmt.Fprintf(w, `{"token":%q,"privatetoken":null}`, f.valid)
+	case restPath:
+		if r.Form.Get("wstoken") != f.valid {
+			fmt.Fprint(w, `{"exception":"moodle_exception","errorcode":"invalidtoken","message":"Invalid token - token not found"}`)
+			return
+		}
+		switch r.Form.Get("wsfunction") {
+		case FunctionSiteInfo:
+			fmt.Fprint(w, `{"sitename":"Prototype","release":"5.0.2 (Build: 20250811)","username":"admin","userid":2}`)
+		case FunctionCoursesBy:
+			fmt.Fprint(w, `{"courses":[{"id":1,"shortname":"site","format":"site"},{"id":2,"shortname":"demo","fullname":"Demo course","format":"topics"}],"warnings":[]}`)
+		case FunctionEnrolledUsers:
+			if r.Form.Get("courseid") == "1" && r.Form.Get("options[0][value]") == "id" {
+				fmt.Fprint(w, `[{"id":2},{"id":3},{"id":4}]`)
+				return
+			}
+			if r.Form.Get("courseid") != "2" {
+				fmt.Fprint(w, `{"exception":"dml_missing_record_exception","errorcode":"invalidrecord","message":"Can't find data record in database table course."}`)
+				return
+			}
+			fmt.Fprint(w, `[{"id":3,"username":"student1","fullname":"Student One","roles":[{"roleid":5,"shortname":"student","name":""}]}]`)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClientCalls(t *testing.T) {
+	fake := &fakeMoodle{enabled: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "admin", "secret")
+
+	info, err := client.SiteInfo()
+	if err != nil {
+		t.Fatalf("SiteInfo failed: %v", err)
+	}
+	if info.SiteName != "Prototype" || info.UserID != 2 {
+		t.Errorf("Unexpected site info: %+v", info)
+	}
+
+	courses, err := client.Courses()
+	if err != nil {
+		t.Fatalf("Courses failed: %v", err)
+	}
+	if len(courses) != 1 || courses[0].ShortName != "demo" {
+		t.Errorf("Expected the front page to be skipped, got %+v", courses)
+	}
+
+	users, err := client.EnrolledUsers(2)
+	if err != nil {
+		t.Fatalf("EnrolledUsers failed: %v", err)
+	}
+	if len(users) != 1 || users[0].Roles[0].ShortName != "student" {
+		t.Errorf("Unexpected enrolled users: %+v", users)
+	}
+
+	if count, err := client.UserCount(); err != nil || count != 3 {
+		t.Errorf("Expected 3 users, got %d (%v)", count, err)
+	}
+
+	if _, err := client.EnrolledUsers(99); err == nil {
+		t.Error("Expected a Moodle exception to be returned as an error")
+	}
+
+	if fake.logins != 1 {
+		t.Errorf("Expected the token to be reused, got %d logins", fake.logins)
+	}
+}
+
+func TestClientRenewsRevokedToken(t *testing.T) {
+	fake := &fakeMoodle{enabled: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(server.URL, "admin", "secret")
+	if _, err := client.SiteInfo(); err != nil {
+		t.Fatalf("SiteInfo failed: %v", err)
+	}
+
+	fake.valid = "revoked"
+	if _, err := client.SiteInfo(); err != nil {
+		t.Fatalf("Expected the client to log in again, got %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("Expected 2 logins, got %d", fake.logins)
+	}
+}
+
+func TestClientLoginErrors(t *testing.T) {
+	fake := &fakeMoodle{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "admin", "secret").SiteInfo()
+	if !IsWebServicesDisabled(err) {
+		t.Errorf("Expected disabled web services to be recognised, got %v", err)
+	}
+
+	fake.enabled = true
+	_, err = NewClient(server.URL, "admin", "wrong").SiteInfo()
+	if err == nil || IsWebServicesDisabled(err) {
+		t.Errorf("Expected an invalid login error, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// DesktopNotifier shows notifications through the operating system's notification center
+type DesktopNotifier struct{}
+
+// NewDesktopNotifier creates a desktop notification sink
+func NewDesktopNotifier() *DesktopNotifier {
+	return &DesktopNotifier{}
+}
+
+// Name identifies the sink in routes
+func (d *DesktopNotifier) Name() string {
+	return SinkDesktop
+}
+
+// Notify shows the notification with osascript, notify-send or a PowerShell balloon tip
+func (d *DesktopNotifier) Notify(notification Notification) error {
+	cmd, err := desktopCommand(runtime.GOOS, notification.Title, notification.Message)
+	if err != nil {
+		return err
+	}
+	if output, err := utils.Commands().CombinedOutput(cmd); err != nil {
+		return errors.WrapWithContext(err, "failed to show desktop notification: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// desktopCommand builds the command that shows a notification on goos
+func desktopCommand(goos, title, message string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script), nil
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, %s, %s, 'Info')
+Start-Sleep -Seconds 10
+$n.Dispose()`, powerShellString(title), powerShellString(message))
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil, errors.WrapWithContext(err, "notify-send is not installed")
+		}
+		return exec.Command("notify-send", "--app-name=Moodle Prototype Manager", title, message), nil
+	}
+	return nil, errors.NewValidationError("os", "desktop notifications are not supported on this platform", goos)
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/errors"
+)
+
+// SMTPConfig is the mail server used by the email sink
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password enable PLAIN authentication when Username is set
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotifier sends notifications by mail through a configured SMTP server
+type EmailNotifier struct {
+	config   SMTPConfig
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates an email sink using config
+func NewEmailNotifier(config SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{config: config, sendMail: smtp.SendMail}
+}
+
+// Name identifies the sink in routes
+func (e *EmailNotifier) Name() string {
+	return SinkEmail
+}
+
+// Notify mails the notification to every configured recipient
+func (e *EmailNotifier) Notify(notification Notification) error {
+	if e.config.Host == "" || e.config.From == "" || len(e.config.To) == 0 {
+		return errors.NewValidationError("smtp", "SMTP host, sender and recipients must be configured", nil)
+	}
+
+	port := e.config.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	if err := e.sendMail(addr, auth, e.config.From, e.config.To, e.message(notification)); err != nil {
+		return errors.WrapWithContext(err, "failed to send notification email via %s", addr)
+	}
+	return nil
+}
+
+// message renders the notification as a plain-text email
+func (e *EmailNotifier) message(notification Notification) []byte {
+	// Header values must not contain line breaks
+	clean := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", clean.Replace(e.config.From))
+	fmt.Fprintf(&msg, "To: %s\r\n", clean.Replace(strings.Join(e.config.To, ", ")))
+	fmt.Fprintf(&msg, "Subject: [Moodle Prototype] %s\r\n", clean.Replace(notification.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
@@ -0,0 +1,123 @@
+// Package notify delivers user-facing notifications such as resource alerts through
+// pluggable sinks (desktop, webhook, email), routed per event category.
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Event categories a notification can be routed by
+const (
+	// CategoryAlert covers resource usage threshold alerts
+	CategoryAlert = "alert"
+	// CategoryContainer covers container failures such as OOM kills
+	CategoryContainer = "container"
+	// CategoryUpdate covers new image builds
+	CategoryUpdate = "update"
+)
+
+// Built-in sink names
+const (
+	SinkDesktop = "desktop"
+	SinkWebhook = "webhook"
+	SinkEmail   = "email"
+	// SinkNone silences a category
+	SinkNone = "none"
+)
+
+// Categories lists every notification category
+var Categories = []string{CategoryAlert, CategoryContainer, CategoryUpdate}
+
+// Notification is one message to deliver
+type Notification struct {
+	Category string    `json:"category"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier is a notification sink. New sinks (Slack, Teams, ...) implement it and are
+// registered on the Router under their name.
+type Notifier interface {
+	// Name identifies the sink in per-category routes
+	Name() string
+	// Notify delivers the notification, returning an error if it could not be sent
+	Notify(notification Notification) error
+}
+
+// Router sends each notification to the sinks selected for its category
+type Router struct {
+	mu     sync.RWMutex
+	sinks  map[string]Notifier
+	routes map[string][]string
+}
+
+// NewRouter creates a router with the given sinks and no routes
+func NewRouter(sinks ...Notifier) *Router {
+	r := &Router{
+		sinks:  make(map[string]Notifier),
+		routes: make(map[string][]string),
+	}
+	for _, sink := range sinks {
+		r.Register(sink)
+	}
+	return r
+}
+
+// Register adds a sink, replacing any sink with the same name
+func (r *Router) Register(sink Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks[sink.Name()] = sink
+}
+
+// SetRoutes selects the sinks for each category; categories without a route are not delivered
+func (r *Router) SetRoutes(routes map[string][]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.routes = make(map[string][]string, len(routes))
+	for category, sinks := range routes {
+		r.routes[category] = append([]string(nil), sinks...)
+	}
+}
+
+// Notify delivers the notification to every sink routed for its category
+func (r *Router) Notify(notification Notification) error {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+
+	r.mu.RLock()
+	var targets []Notifier
+	var missing []string
+	for _, name := range r.routes[notification.Category] {
+		if name == SinkNone {
+			continue
+		}
+		if sink, ok := r.sinks[name]; ok {
+			targets = append(targets, sink)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	r.mu.RUnlock()
+
+	multiErr := errors.NewMultiError(fmt.Sprintf("%s notification", notification.Category))
+	for _, name := range missing {
+		multiErr.Add(errors.NewValidationError("sink", "notification sink is not available", name))
+	}
+	for _, sink := range targets {
+		if err := sink.Notify(notification); err != nil {
+			multiErr.Add(errors.WrapWithContext(err, "%s sink failed", sink.Name()))
+			continue
+		}
+		utils.LogDebug(fmt.Sprintf("Sent %s notification via %s: %s", notification.Category, sink.Name(), notification.Title))
+	}
+	return multiErr.ToError()
+}
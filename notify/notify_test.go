@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+type fakeSink struct {
+	name string
+	sent []Notification
+	err  error
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) Notify(notification Notification) error {
+	f.sent = append(f.sent, notification)
+	return f.err
+}
+
+func TestRouterRoutesByCategory(t *testing.T) {
+	desktop := &fakeSink{name: SinkDesktop}
+	webhook := &fakeSink{name: SinkWebhook, err: fmt.Errorf("unreachable")}
+	router := NewRouter(desktop, webhook)
+	router.SetRoutes(map[string][]string{
+		CategoryAlert:  {SinkDesktop, SinkWebhook},
+		CategoryUpdate: {SinkNone},
+	})
+
+	err := router.Notify(Notification{Category: CategoryAlert, Title: "Memory high"})
+	if err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Expected the failing webhook to be reported, got %v", err)
+	}
+	if len(desktop.sent) != 1 || len(webhook.sent) != 1 {
+		t.Errorf("Expected both sinks to receive the alert, got desktop=%d webhook=%d", len(desktop.sent), len(webhook.sent))
+	}
+	if desktop.sent[0].Time.IsZero() {
+		t.Error("Expected the notification time to be filled in")
+	}
+
+	for _, category := range []string{CategoryUpdate, CategoryContainer} {
+		if err := router.Notify(Notification{Category: category}); err != nil {
+			t.Errorf("Unexpected error for %s: %v", category, err)
+		}
+	}
+	if len(desktop.sent) != 1 {
+		t.Errorf("Expected silenced and unrouted categories not to be delivered, got %d", len(desktop.sent))
+	}
+
+	router.SetRoutes(map[string][]string{CategoryContainer: {"teams"}})
+	if err := router.Notify(Notification{Category: CategoryContainer}); err == nil {
+		t.Error("Expected an error for a route to an unregistered sink")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(Notification{Category: CategoryAlert, Title: "Disk full"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if received.Title != "Disk full" || received.Category != CategoryAlert {
+		t.Errorf("Expected the notification as JSON, got %+v", received)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := NewWebhookNotifier(failing.URL).Notify(Notification{}); err == nil {
+		t.Error("Expected an error for a non-2xx response")
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	notifier := NewEmailNotifier(SMTPConfig{
+		Host: "mail.example.com",
+		From: "manager@example.com",
+		To:   []string{"ops@example.com"},
+	})
+	var addr string
+	var msg []byte
+	notifier.sendMail = func(a string, auth smtp.Auth, from string, to []string, m []byte) error {
+		addr, msg = a, m
+		return nil
+	}
+
+	if err := notifier.Notify(Notification{Title: "OOM\r\nBcc: x@example.com", Message: "Moodle was killed"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if addr != "mail.example.com:587" {
+		t.Errorf("Expected the default submission port, got %s", addr)
+	}
+	if strings.Contains(string(msg), "\r\nBcc:") {
+		t.Error("Expected line breaks in the title not to inject headers")
+	}
+	if !strings.Contains(string(msg), "Moodle was killed") {
+		t.Errorf("Expected the message body, got %q", msg)
+	}
+
+	if err := NewEmailNotifier(SMTPConfig{}).Notify(Notification{}); err == nil {
+		t.Error("Expected an error without an SMTP configuration")
+	}
+}
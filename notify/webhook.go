@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"moodle-prototype-manager/errors"
+)
+
+// webhookTimeout bounds a webhook delivery
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier posts notifications as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a webhook sink posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Name identifies the sink in routes
+func (w *WebhookNotifier) Name() string {
+	return SinkWebhook
+}
+
+// Notify posts the notification; any non-2xx response is an error
+func (w *WebhookNotifier) Notify(notification Notification) error {
+	if w.url == "" {
+		return errors.NewValidationError("webhookURL", "no webhook URL configured", nil)
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode notification")
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to post notification webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
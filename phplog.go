@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/events"
+	"moodle-prototype-manager/utils"
+)
+
+const (
+	// phpLogHistory is how many error log entries are kept for GetPHPErrorLog
+	phpLogHistory = 500
+	// phpFatalRepeat is how long the same fatal error is not flagged again, so a page that
+	// fails on every request does not flood the frontend
+	phpFatalRepeat = time.Minute
+)
+
+// phpLogWatch follows the container's PHP error log while Moodle runs
+type phpLogWatch struct {
+	mu       sync.Mutex
+	follower *docker.LogFollower
+	batcher  *events.Batcher
+	// containerID is the container entries were read from
+	containerID string
+	entries     []docker.PHPLogEntry
+	// flagged records when each fatal message was last sent to the frontend
+	flagged map[string]time.Time
+}
+
+// startPHPLogWatch follows the PHP error log of the container, sending new lines as
+// moodle:phplog:batch events and flagging fatal errors with moodle:php:fatal. The entries
+// kept for another container are dropped.
+func (a *App) startPHPLogWatch(containerID string) {
+	a.stopPHPLogWatch()
+
+	a.phpLog.mu.Lock()
+	if a.phpLog.containerID != containerID {
+		a.phpLog.containerID = containerID
+		a.phpLog.entries = nil
+		a.phpLog.flagged = nil
+	}
+	a.phpLog.mu.Unlock()
+
+	batcher := events.NewBatcher(events.DefaultBatchInterval, events.DefaultMaxPendingLines, func(batch events.Batch) {
+		a.emit("moodle:phplog:batch", batch)
+	})
+	follower, err := a.dockerManager.FollowPHPErrorLog(containerID, 0, func(line string) {
+		batcher.Add(line)
+		a.recordPHPLogLine(line)
+	})
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Could not follow the PHP error log: %v", err))
+		return
+	}
+	batcher.Start()
+
+	a.phpLog.mu.Lock()
+	a.phpLog.follower = follower
+	a.phpLog.batcher = batcher
+	a.phpLog.mu.Unlock()
+
+	a.goReported("followPHPLog", func() {
+		<-follower.Done()
+		batcher.Stop()
+
+		a.phpLog.mu.Lock()
+		current := a.phpLog.follower == follower
+		if current {
+			a.phpLog.follower = nil
+			a.phpLog.batcher = nil
+		}
+		a.phpLog.mu.Unlock()
+
+		// Exit status 3 means the image has no separate error log
+		if current && follower.Err() != nil {
+			utils.LogDebug(fmt.Sprintf("PHP error log stream ended: %v", follower.Err()))
+		}
+	})
+}
+
+// stopPHPLogWatch ends the PHP error log watch, if any
+func (a *App) stopPHPLogWatch() {
+	a.phpLog.mu.Lock()
+	follower := a.phpLog.follower
+	a.phpLog.follower = nil
+	a.phpLog.batcher = nil
+	a.phpLog.mu.Unlock()
+
+	if follower != nil {
+		follower.Stop()
+	}
+}
+
+// recordPHPLogLine keeps a parsed error log line and flags it when it is fatal
+func (a *App) recordPHPLogLine(line string) {
+	entry := docker.ParsePHPLogLine(line)
+	if entry == nil {
+		return
+	}
+
+	a.phpLog.mu.Lock()
+	a.phpLog.entries = append(a.phpLog.entries, *entry)
+	if len(a.phpLog.entries) > phpLogHistory {
+		a.phpLog.entries = append([]docker.PHPLogEntry{}, a.phpLog.entries[len(a.phpLog.entries)-phpLogHistory:]...)
+	}
+	flag := false
+	if entry.Fatal() {
+		if a.phpLog.flagged == nil {
+			a.phpLog.flagged = make(map[string]time.Time)
+		}
+		if last, ok := a.phpLog.flagged[entry.Message]; !ok || time.Since(last) > phpFatalRepeat {
+			a.phpLog.flagged[entry.Message] = time.Now()
+			flag = true
+		}
+	}
+	a.phpLog.mu.Unlock()
+
+	if !flag {
+		return
+	}
+	utils.LogWarning(fmt.Sprintf("PHP fatal error in Moodle: %s", entry.Message))
+	a.emit("moodle:php:fatal", entry)
+	if err := a.timeline.Add("php:fatal", entry.Message, map[string]string{"file": entry.File, "line": fmt.Sprintf("%d", entry.Line)}); err != nil {
+		utils.LogError("Failed to record PHP fatal error in timeline", err)
+	}
+}
+
+// GetPHPErrorLog returns the latest limit entries of the PHP error log seen while Moodle ran,
+// oldest first; 0 returns all that are kept. With fatalOnly only fatal errors are returned.
+func (a *App) GetPHPErrorLog(limit int, fatalOnly bool) []docker.PHPLogEntry {
+	defer a.recoverBinding("GetPHPErrorLog", nil)
+	utils.LogInfo(fmt.Sprintf("GetPHPErrorLog called (limit: %d, fatalOnly: %v)", limit, fatalOnly))
+
+	a.phpLog.mu.Lock()
+	defer a.phpLog.mu.Unlock()
+	entries := make([]docker.PHPLogEntry, 0, len(a.phpLog.entries))
+	for _, entry := range a.phpLog.entries {
+		if !fatalOnly || entry.Fatal() {
+			entries = append(entries, entry)
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// AckPHPLogBatch confirms the frontend has rendered a PHP error log batch, releasing the next one
+func (a *App) AckPHPLogBatch(seq int64) {
+	defer a.recoverBinding("AckPHPLogBatch", nil)
+	a.phpLog.mu.Lock()
+	batcher := a.phpLog.batcher
+	a.phpLog.mu.Unlock()
+
+	if batcher != nil {
+		batcher.Ack(seq)
+	}
+}
@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// Certificate sources reported by EnsureCertificate
+const (
+	CertSourceExisting   = "existing"
+	CertSourceMkcert     = "mkcert"
+	CertSourceSelfSigned = "self-signed"
+)
+
+// certRenewBefore regenerates certificates that expire within this window
+const certRenewBefore = 30 * 24 * time.Hour
+
+// TLSProxy terminates HTTPS in front of Moodle's plain HTTP port
+type TLSProxy struct {
+	mu       sync.Mutex
+	target   *url.URL
+	proxy    *httputil.ReverseProxy
+	certFile string
+	keyFile  string
+	server   *http.Server
+}
+
+// NewTLSProxy creates an HTTPS proxy for target using the given certificate files
+func NewTLSProxy(target, certFile, keyFile string) (*TLSProxy, error) {
+	tp := &TLSProxy{certFile: certFile, keyFile: keyFile}
+	if err := tp.SetTarget(target); err != nil {
+		return nil, err
+	}
+	return tp, nil
+}
+
+// SetTarget points the proxy at a new Moodle URL, e.g. after the container moved to another
+// host port; requests in flight finish against the old one
+func (tp *TLSProxy) SetTarget(target string) error {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid proxy target %q: %w", target, err)
+	}
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(targetURL)
+	director := reverseProxy.Director
+	reverseProxy.Director = func(r *http.Request) {
+		director(r)
+		// Moodle's sslproxy setting relies on this header to build https:// links
+		r.Header.Set("X-Forwarded-Proto", "https")
+	}
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.target = targetURL
+	tp.proxy = reverseProxy
+	return nil
+}
+
+// ServeHTTP forwards a request to the current target
+func (tp *TLSProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tp.mu.Lock()
+	reverseProxy := tp.proxy
+	tp.mu.Unlock()
+	reverseProxy.ServeHTTP(w, r)
+}
+
+// Start listens for HTTPS on addr (e.g. ":8443") and serves until Stop
+func (tp *TLSProxy) Start(addr string) error {
+	certificate, err := tls.LoadX509KeyPair(tp.certFile, tp.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	tlsListener := tls.NewListener(listener, &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	tp.server = &http.Server{Handler: tp, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := tp.server.Serve(tlsListener); err != nil && err != http.ErrServerClosed {
+			utils.LogError("HTTPS proxy stopped unexpectedly", err)
+		}
+	}()
+
+	utils.LogInfo(fmt.Sprintf("HTTPS proxy listening on %s for %s", addr, tp.target))
+	return nil
+}
+
+// Stop shuts the proxy down
+func (tp *TLSProxy) Stop() {
+	if tp.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tp.server.Shutdown(ctx); err != nil {
+		utils.LogWarning(fmt.Sprintf("HTTPS proxy shutdown error: %v", err))
+	}
+	tp.server = nil
+}
+
+// EnsureCertificate makes sure a valid certificate for hosts exists, preferring a
+// locally trusted mkcert certificate and falling back to a self-signed one
+func EnsureCertificate(certFile, keyFile string, hosts []string) (string, error) {
+	if certificateValid(certFile, keyFile, hosts) {
+		return CertSourceExisting, nil
+	}
+
+	if mkcert, err := exec.LookPath("mkcert"); err == nil {
+		args := append([]string{"-cert-file", certFile, "-key-file", keyFile}, hosts...)
+		if output, err := utils.Commands().CombinedOutput(exec.Command(mkcert, args...)); err == nil {
+			utils.LogInfo("Created locally trusted certificate with mkcert")
+			return CertSourceMkcert, nil
+		} else {
+			utils.LogWarning(fmt.Sprintf("mkcert failed, falling back to self-signed certificate: %v (%s)", err, output))
+		}
+	}
+
+	if err := writeSelfSignedCertificate(certFile, keyFile, hosts); err != nil {
+		return "", err
+	}
+	utils.LogInfo("Created self-signed certificate; browsers will show a warning until it is trusted")
+	return CertSourceSelfSigned, nil
+}
+
+// certificateValid reports whether the certificate loads, covers hosts and is not about to expire
+func certificateValid(certFile, keyFile string, hosts []string) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil || len(pair.Certificate) == 0 {
+		return false
+	}
+
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	for _, host := range hosts {
+		if certificate.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return time.Until(certificate.NotAfter) > certRenewBefore
+}
+
+// writeSelfSignedCertificate generates a one-year ECDSA certificate for hosts
+func writeSelfSignedCertificate(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Moodle Prototype Manager"}, CommonName: hosts[0]},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureCertificateReusesValidCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	if err := writeSelfSignedCertificate(certFile, keyFile, []string{"localhost", "127.0.0.1"}); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if !certificateValid(certFile, keyFile, []string{"localhost", "127.0.0.1"}) {
+		t.Fatal("Expected generated certificate to be valid")
+	}
+	if certificateValid(certFile, keyFile, []string{"moodle.local"}) {
+		t.Error("Expected certificate not covering moodle.local to be invalid")
+	}
+
+	source, err := EnsureCertificate(certFile, keyFile, []string{"localhost"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if source != CertSourceExisting {
+		t.Errorf("Expected existing certificate to be reused, got %s", source)
+	}
+}
+
+func TestTLSProxyFollowsNewTarget(t *testing.T) {
+	upstream := func(name string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name+" "+r.Header.Get("X-Forwarded-Proto"))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	first, second := upstream("first"), upstream("second")
+
+	tp, err := NewTLSProxy(first.URL, "cert.pem", "key.pem")
+	if err != nil {
+		t.Fatalf("NewTLSProxy failed: %v", err)
+	}
+	get := func() string {
+		rec := httptest.NewRecorder()
+		tp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login/index.php", nil))
+		return rec.Body.String()
+	}
+
+	if got := get(); got != "first https" {
+		t.Errorf("Expected the first upstream over https, got %q", got)
+	}
+	if err := tp.SetTarget(second.URL); err != nil {
+		t.Fatalf("SetTarget failed: %v", err)
+	}
+	if got := get(); got != "second https" {
+		t.Errorf("Expected the proxy to follow the new target, got %q", got)
+	}
+}
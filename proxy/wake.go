@@ -0,0 +1,366 @@
+// Package proxy serves the shared prototype URL from the manager so a stopped
+// container can be woken up by visitors instead of returning connection errors.
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// WakePath is the placeholder form's submit endpoint
+const WakePath = "/__moodleproto/wake"
+
+// readinessCacheTTL limits how often the backend readiness probe runs
+const readinessCacheTTL = 2 * time.Second
+
+// defaultWakeTimeout is how long a wake may take before visitors can retry it
+const defaultWakeTimeout = 10 * time.Minute
+
+// Passcode attempt limiting: after maxPasscodeFailures wrong passcodes within
+// passcodeFailureWindow, a client is locked out for passcodeLockout
+const (
+	maxPasscodeFailures   = 5
+	passcodeFailureWindow = 15 * time.Minute
+	passcodeLockout       = 5 * time.Minute
+)
+
+// Backend is the prototype behind the proxy
+type Backend interface {
+	// IsReady reports whether Moodle is answering HTTP
+	IsReady() bool
+	// Wake starts the prototype; it may return before Moodle is ready
+	Wake() error
+	// IsStopped reports whether the prototype's container is not running
+	IsStopped() bool
+}
+
+// passcodeAttempts tracks wrong passcodes from one client
+type passcodeAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// WakeProxy proxies to Moodle when it is up and serves a wake-up page otherwise
+type WakeProxy struct {
+	backend  Backend
+	passcode string
+	target   *url.URL
+	proxy    *httputil.ReverseProxy
+
+	mu        sync.Mutex
+	ready     bool
+	checkedAt time.Time
+	waking    bool
+	// wakeStarted is when the current wake began; wakeReturned is set once Wake returns
+	wakeStarted  time.Time
+	wakeReturned bool
+	wakeTimeout  time.Duration
+	// startError is shown to every visitor until the prototype starts
+	startError string
+	attempts   map[string]*passcodeAttempts
+
+	server *http.Server
+}
+
+// NewWakeProxy creates a proxy for target; an empty passcode disables remote wake-up
+func NewWakeProxy(target string, passcode string, backend Backend) (*WakeProxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy target %q: %w", target, err)
+	}
+
+	return &WakeProxy{
+		backend:  backend,
+		passcode: passcode,
+		target:   targetURL,
+		proxy:    httputil.NewSingleHostReverseProxy(targetURL),
+
+		wakeTimeout: defaultWakeTimeout,
+		attempts:    make(map[string]*passcodeAttempts),
+	}, nil
+}
+
+// Start listens on addr (e.g. ":8090") and serves until Stop
+func (wp *WakeProxy) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	wp.server = &http.Server{Handler: wp, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := wp.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			utils.LogError("Wake proxy stopped unexpectedly", err)
+		}
+	}()
+
+	utils.LogInfo(fmt.Sprintf("Wake-on-demand proxy listening on %s for %s", addr, wp.target))
+	return nil
+}
+
+// Stop shuts the proxy down
+func (wp *WakeProxy) Stop() {
+	if wp.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wp.server.Shutdown(ctx); err != nil {
+		utils.LogWarning(fmt.Sprintf("Wake proxy shutdown error: %v", err))
+	}
+	wp.server = nil
+}
+
+// ServeHTTP implements http.Handler
+func (wp *WakeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == WakePath {
+		wp.handleWake(w, r)
+		return
+	}
+
+	if wp.isReady() {
+		wp.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	wp.renderPlaceholder(w, http.StatusServiceUnavailable, "")
+}
+
+// isReady probes the backend, caching the answer briefly
+func (wp *WakeProxy) isReady() bool {
+	wp.mu.Lock()
+	if time.Since(wp.checkedAt) < readinessCacheTTL {
+		ready := wp.ready
+		wp.mu.Unlock()
+		return ready
+	}
+	wp.mu.Unlock()
+
+	ready := wp.backend.IsReady()
+
+	wp.mu.Lock()
+	wp.ready = ready
+	wp.checkedAt = time.Now()
+	if ready {
+		wp.waking = false
+		wp.startError = ""
+	}
+	wp.mu.Unlock()
+
+	if !ready {
+		wp.checkWake()
+	}
+	return ready
+}
+
+// checkWake ends a wake that timed out or whose container stopped, so visitors can retry
+func (wp *WakeProxy) checkWake() {
+	wp.mu.Lock()
+	waking, returned, started := wp.waking, wp.wakeReturned, wp.wakeStarted
+	wp.mu.Unlock()
+	if !waking {
+		return
+	}
+
+	var startError string
+	switch {
+	case time.Since(started) > wp.wakeTimeout:
+		utils.LogWarning(fmt.Sprintf("Wake-on-demand gave up: Moodle was not ready after %v", wp.wakeTimeout))
+		startError = "The prototype did not finish starting. Please try again or contact its owner."
+	case returned && wp.backend.IsStopped():
+		// The container can only be missing before Wake returns, so this is a crash or failed start
+		utils.LogWarning("Wake-on-demand gave up: the container stopped before Moodle was ready")
+		startError = "The prototype stopped while starting. Please try again or contact its owner."
+	default:
+		return
+	}
+
+	wp.mu.Lock()
+	if wp.waking && wp.wakeStarted.Equal(started) {
+		wp.waking = false
+		wp.startError = startError
+	}
+	wp.mu.Unlock()
+}
+
+// handleWake validates the passcode and starts the prototype
+func (wp *WakeProxy) handleWake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	client := clientIP(r)
+	if wp.lockedOut(client) {
+		utils.LogWarning(fmt.Sprintf("Rejected wake request from %s: too many invalid passcodes", r.RemoteAddr))
+		wp.renderPlaceholder(w, http.StatusTooManyRequests, "Too many invalid passcodes. Please try again later.")
+		return
+	}
+
+	if wp.passcode == "" || subtle.ConstantTimeCompare([]byte(r.FormValue("passcode")), []byte(wp.passcode)) != 1 {
+		utils.LogWarning(fmt.Sprintf("Rejected wake request from %s: invalid passcode", r.RemoteAddr))
+		wp.recordFailure(client)
+		// Only the visitor who entered the passcode is told it was wrong
+		wp.renderPlaceholder(w, http.StatusForbidden, "Invalid passcode")
+		return
+	}
+
+	wp.mu.Lock()
+	delete(wp.attempts, client)
+	alreadyWaking := wp.waking
+	wp.waking = true
+	wp.startError = ""
+	if !alreadyWaking {
+		wp.wakeStarted = time.Now()
+		wp.wakeReturned = false
+	}
+	started := wp.wakeStarted
+	wp.mu.Unlock()
+
+	if !alreadyWaking {
+		utils.LogInfo(fmt.Sprintf("Wake request accepted from %s", r.RemoteAddr))
+		go func() {
+			err := wp.backend.Wake()
+			wp.mu.Lock()
+			defer wp.mu.Unlock()
+			if !wp.wakeStarted.Equal(started) {
+				// A later wake replaced this one after it timed out
+				return
+			}
+			wp.wakeReturned = true
+			if err != nil {
+				utils.LogError("Wake-on-demand start failed", err)
+				wp.waking = false
+				wp.startError = "The prototype could not be started. Please contact its owner."
+			}
+		}()
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// lockedOut reports whether client must wait before trying another passcode
+func (wp *WakeProxy) lockedOut(client string) bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	attempts, ok := wp.attempts[client]
+	return ok && time.Now().Before(attempts.lockedUntil)
+}
+
+// recordFailure counts a wrong passcode from client, locking it out after maxPasscodeFailures
+func (wp *WakeProxy) recordFailure(client string) {
+	now := time.Now()
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	// Forget clients that have been quiet for a while so the map stays small
+	for key, attempts := range wp.attempts {
+		if now.Sub(attempts.lastFailure) > passcodeFailureWindow && now.After(attempts.lockedUntil) {
+			delete(wp.attempts, key)
+		}
+	}
+
+	attempts, ok := wp.attempts[client]
+	if !ok {
+		attempts = &passcodeAttempts{}
+		wp.attempts[client] = attempts
+	}
+	attempts.failures++
+	attempts.lastFailure = now
+	if attempts.failures >= maxPasscodeFailures {
+		utils.LogWarning(fmt.Sprintf("Locking out %s from wake requests for %v after %d invalid passcodes", client, passcodeLockout, attempts.failures))
+		attempts.failures = 0
+		attempts.lockedUntil = now.Add(passcodeLockout)
+	}
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// placeholderData is rendered into the placeholder page
+type placeholderData struct {
+	Waking    bool
+	CanWake   bool
+	Error     string
+	WakePath  string
+	RefreshIn int
+}
+
+var placeholderTemplate = template.Must(template.New("placeholder").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Moodle prototype is sleeping</title>
+{{if .Waking}}<meta http-equiv="refresh" content="{{.RefreshIn}}">{{end}}
+<style>
+body { font-family: sans-serif; background: #1b2636; color: #fff; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; }
+main { text-align: center; max-width: 28rem; }
+input, button { font-size: 1rem; padding: .5rem; border-radius: 4px; border: none; }
+button { background: #f98012; color: #fff; cursor: pointer; }
+.error { color: #ff8a80; }
+</style>
+</head>
+<body>
+<main>
+{{if .Waking}}
+<h1>Starting the prototype&hellip;</h1>
+<p>This page will refresh automatically. First starts can take a few minutes.</p>
+{{else}}
+<h1>This Moodle prototype is asleep</h1>
+{{if .CanWake}}
+<form method="post" action="{{.WakePath}}">
+<p>Enter the passcode from the prototype owner to start it.</p>
+<input type="password" name="passcode" placeholder="Passcode" autofocus>
+<button type="submit">Start the prototype</button>
+</form>
+{{else}}
+<p>Ask the prototype owner to start it from the Moodle Prototype Manager.</p>
+{{end}}
+{{end}}
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+</main>
+</body>
+</html>
+`))
+
+// renderPlaceholder writes the wake-up page, showing requestError for this request only
+func (wp *WakeProxy) renderPlaceholder(w http.ResponseWriter, status int, requestError string) {
+	wp.mu.Lock()
+	data := placeholderData{
+		Waking:    wp.waking,
+		CanWake:   wp.passcode != "",
+		Error:     wp.startError,
+		WakePath:  WakePath,
+		RefreshIn: 5,
+	}
+	wp.mu.Unlock()
+	if requestError != "" {
+		data.Error = requestError
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := placeholderTemplate.Execute(w, data); err != nil {
+		utils.LogError("Failed to render wake placeholder", err)
+	}
+}
@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeBackend struct {
+	mu      sync.Mutex
+	ready   bool
+	stopped bool
+	woken   chan struct{}
+}
+
+func (f *fakeBackend) IsReady() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ready
+}
+
+func (f *fakeBackend) Wake() error {
+	close(f.woken)
+	return nil
+}
+
+func (f *fakeBackend) IsStopped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stopped
+}
+
+func TestWakeProxyPlaceholderAndWake(t *testing.T) {
+	backend := &fakeBackend{woken: make(chan struct{})}
+	wp, err := NewWakeProxy("http://127.0.0.1:1", "secret", backend)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	// Stopped backend serves the placeholder
+	rec := httptest.NewRecorder()
+	wp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "asleep") {
+		t.Fatalf("Expected placeholder page, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Wrong passcode is rejected
+	rec = httptest.NewRecorder()
+	wp.ServeHTTP(rec, wakeRequest("wrong"))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for wrong passcode, got %d", rec.Code)
+	}
+
+	// Correct passcode wakes the backend
+	rec = httptest.NewRecorder()
+	wp.ServeHTTP(rec, wakeRequest("secret"))
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("Expected redirect after wake, got %d", rec.Code)
+	}
+
+	select {
+	case <-backend.woken:
+	case <-time.After(time.Second):
+		t.Fatal("Backend was not woken")
+	}
+}
+
+func TestWakeProxyForwardsWhenReady(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("moodle"))
+	}))
+	defer upstream.Close()
+
+	wp, err := NewWakeProxy(upstream.URL, "", &fakeBackend{ready: true})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	wp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login/index.php", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "moodle" {
+		t.Errorf("Expected proxied response, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWakeProxyShowsInvalidPasscodeToItsVisitorOnly(t *testing.T) {
+	wp, err := NewWakeProxy("http://127.0.0.1:1", "secret", &fakeBackend{woken: make(chan struct{})})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	wp.ServeHTTP(rec, wakeRequest("wrong"))
+	if !strings.Contains(rec.Body.String(), "Invalid passcode") {
+		t.Errorf("Expected the visitor to be told the passcode is wrong, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	wp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), "Invalid passcode") {
+		t.Error("Expected other visitors not to see the rejected passcode")
+	}
+}
+
+func TestWakeProxyGivesUpWhenContainerStops(t *testing.T) {
+	backend := &fakeBackend{woken: make(chan struct{}), stopped: true}
+	wp, err := NewWakeProxy("http://127.0.0.1:1", "secret", backend)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	wp.ServeHTTP(httptest.NewRecorder(), wakeRequest("secret"))
+	<-backend.woken
+	waitFor(t, func() bool {
+		wp.mu.Lock()
+		defer wp.mu.Unlock()
+		return wp.wakeReturned
+	})
+
+	rec := servePlaceholder(wp)
+	if !strings.Contains(rec.Body.String(), "stopped while starting") || !strings.Contains(rec.Body.String(), "passcode") {
+		t.Errorf("Expected the wake to end and the form to return, got %s", rec.Body.String())
+	}
+}
+
+func TestWakeProxyGivesUpAfterTimeout(t *testing.T) {
+	backend := &fakeBackend{woken: make(chan struct{})}
+	wp, err := NewWakeProxy("http://127.0.0.1:1", "secret", backend)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	wp.wakeTimeout = time.Millisecond
+
+	wp.ServeHTTP(httptest.NewRecorder(), wakeRequest("secret"))
+	<-backend.woken
+	time.Sleep(5 * time.Millisecond)
+
+	rec := servePlaceholder(wp)
+	if !strings.Contains(rec.Body.String(), "did not finish starting") {
+		t.Errorf("Expected the wake to time out, got %s", rec.Body.String())
+	}
+
+	// A visitor can start a new wake afterwards
+	backend.woken = make(chan struct{})
+	wp.ServeHTTP(httptest.NewRecorder(), wakeRequest("secret"))
+	select {
+	case <-backend.woken:
+	case <-time.After(time.Second):
+		t.Fatal("Backend was not woken again")
+	}
+}
+
+func TestWakeProxyLocksOutRepeatedInvalidPasscodes(t *testing.T) {
+	backend := &fakeBackend{woken: make(chan struct{})}
+	wp, err := NewWakeProxy("http://127.0.0.1:1", "secret", backend)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	for i := 0; i < maxPasscodeFailures; i++ {
+		rec := httptest.NewRecorder()
+		wp.ServeHTTP(rec, wakeRequest("wrong"))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Attempt %d: expected 403, got %d", i+1, rec.Code)
+		}
+	}
+
+	// Even the right passcode is refused while locked out
+	rec := httptest.NewRecorder()
+	wp.ServeHTTP(rec, wakeRequest("secret"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 while locked out, got %d", rec.Code)
+	}
+
+	// Other clients are not affected
+	req := wakeRequest("secret")
+	req.RemoteAddr = "192.0.2.99:4321"
+	rec = httptest.NewRecorder()
+	wp.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("Expected another client to wake the prototype, got %d", rec.Code)
+	}
+}
+
+// servePlaceholder requests the root page, bypassing the readiness cache
+func servePlaceholder(wp *WakeProxy) *httptest.ResponseRecorder {
+	wp.mu.Lock()
+	wp.checkedAt = time.Time{}
+	wp.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	wp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func wakeRequest(passcode string) *http.Request {
+	form := url.Values{"passcode": {passcode}}
+	req := httptest.NewRequest(http.MethodPost, WakePath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// FatalEvent is emitted as "app:fatal" when a binding panicked
+type FatalEvent struct {
+	Binding string                  `json:"binding"`
+	Error   *errors.SerializedError `json:"error"`
+}
+
+// recoverBinding is deferred at the top of every binding. Wails recovers panics in bindings
+// too, but then drops the call, leaving the frontend waiting forever; instead the panic is
+// logged with its stack, reported to telemetry, emitted as "app:fatal" and, when errp is not
+// nil, returned to the caller as a CodeInternal error.
+func (a *App) recoverBinding(name string, errp *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	err := errors.FromPanic(name, recovered)
+	utils.LogError(fmt.Sprintf("Binding %s panicked\n%s", name, stack), err)
+	go a.telemetry.ReportPanic(recovered, stack)
+	a.emit("app:fatal", FatalEvent{Binding: name, Error: a.serializeError(err)})
+
+	if errp != nil {
+		*errp = err
+	}
+}
+
+// goReported runs fn in a goroutine whose panic is reported to telemetry before it crashes the
+// app as before. A recover in main only sees the main goroutine; bindings and tasks recover
+// their own panics.
+func (a *App) goReported(name string, fn func()) {
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				utils.LogError(fmt.Sprintf("Goroutine %s panicked", name), fmt.Errorf("%v", recovered))
+				a.telemetry.ReportPanic(recovered, debug.Stack())
+				panic(recovered)
+			}
+		}()
+		fn()
+	}()
+}
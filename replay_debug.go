@@ -0,0 +1,57 @@
+//go:build debug
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/scenario"
+	"moodle-prototype-manager/utils"
+)
+
+var (
+	replayMu   sync.Mutex
+	replayStop chan struct{}
+)
+
+// ReplayTrace replays a session recorded with StartRecording: its events are sent to the
+// frontend with their original timing divided by speed, and its docker commands are logged
+// rather than run. Only available in debug builds (wails build -tags debug).
+func (a *App) ReplayTrace(path string, speed float64) (err error) {
+	defer a.recoverBinding("ReplayTrace", &err)
+	utils.LogInfo(fmt.Sprintf("ReplayTrace called (path: %s, speed: %.1f)", path, speed))
+
+	trace, err := scenario.LoadTrace(path)
+	if err != nil {
+		return fmt.Errorf("failed to load session recording: %w", err)
+	}
+
+	a.StopReplay()
+	stop := make(chan struct{})
+	replayMu.Lock()
+	replayStop = stop
+	replayMu.Unlock()
+
+	utils.LogInfo(fmt.Sprintf("Replaying %d entries recorded on %s/%s at %s", len(trace.Entries), trace.OS, trace.Arch, trace.StartedAt))
+	a.goReported("replay", func() {
+		// Replayed events go straight to the frontend so they are not re-recorded or filtered
+		completed := scenario.Replay(trace, speed, a.emitToFrontend, func(args []string) {
+			utils.LogInfo("Replay: docker " + strings.Join(args, " "))
+		}, stop)
+		a.emitToFrontend("moodle:replay:finished", completed)
+	})
+	return nil
+}
+
+// StopReplay stops a running replay
+func (a *App) StopReplay() {
+	defer a.recoverBinding("StopReplay", nil)
+	replayMu.Lock()
+	defer replayMu.Unlock()
+	if replayStop != nil {
+		close(replayStop)
+		replayStop = nil
+	}
+}
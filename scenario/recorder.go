@@ -0,0 +1,186 @@
+// Package scenario records a user's session (backend events and docker commands with their
+// timing) into a sanitized trace file that maintainers can replay to reproduce a bug report.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// TraceVersion is the trace file format version
+const TraceVersion = 1
+
+// DefaultTraceFile is the suggested file name for a saved trace
+const DefaultTraceFile = "moodle-session-trace.json"
+
+// MaxEntries bounds a recording so a forgotten session cannot exhaust memory
+const MaxEntries = 50000
+
+// Entry kinds
+const (
+	KindEvent   = "event"
+	KindCommand = "command"
+)
+
+// Entry is one recorded event or command
+type Entry struct {
+	// OffsetMs is the time since recording started
+	OffsetMs int64  `json:"offsetMs"`
+	Kind     string `json:"kind"`
+	// Name is the event topic, or "docker" for commands
+	Name string `json:"name"`
+	// Args holds a command's sanitized arguments
+	Args []string `json:"args,omitempty"`
+	// Data holds an event's sanitized payload values
+	Data []json.RawMessage `json:"data,omitempty"`
+}
+
+// Trace is a recorded session
+type Trace struct {
+	Version   int       `json:"version"`
+	StartedAt time.Time `json:"startedAt"`
+	// DurationMs is the length of the recording
+	DurationMs int64  `json:"durationMs"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	// Dropped counts entries discarded after MaxEntries was reached
+	Dropped int     `json:"dropped"`
+	Entries []Entry `json:"entries"`
+}
+
+// Recorder captures events and commands while a recording is active
+type Recorder struct {
+	sanitizer *Sanitizer
+
+	mu        sync.Mutex
+	recording bool
+	trace     *Trace
+	now       func() time.Time
+}
+
+// NewRecorder creates an idle recorder that sanitizes with sanitizer
+func NewRecorder(sanitizer *Sanitizer) *Recorder {
+	return &Recorder{sanitizer: sanitizer, now: time.Now}
+}
+
+// IsRecording reports whether a recording is active
+func (r *Recorder) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// Start begins a new recording, discarding any unsaved one
+func (r *Recorder) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recording = true
+	r.trace = &Trace{
+		Version:   TraceVersion,
+		StartedAt: r.now(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Entries:   make([]Entry, 0),
+	}
+	utils.LogInfo("Session recording started")
+}
+
+// Stop ends the recording and returns the trace, or nil when nothing was being recorded
+func (r *Recorder) Stop() *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recording {
+		return nil
+	}
+	r.recording = false
+	trace := r.trace
+	r.trace = nil
+	trace.DurationMs = r.now().Sub(trace.StartedAt).Milliseconds()
+
+	utils.LogInfo(fmt.Sprintf("Session recording stopped (%d entries, %d dropped)", len(trace.Entries), trace.Dropped))
+	return trace
+}
+
+// RecordEvent records a published event; it matches the events.Handler signature
+func (r *Recorder) RecordEvent(topic string, data ...interface{}) {
+	if !r.IsRecording() {
+		return
+	}
+
+	values := make([]json.RawMessage, 0, len(data))
+	for _, value := range data {
+		sanitized, err := r.sanitizer.Value(value)
+		if err != nil {
+			utils.LogDebug(fmt.Sprintf("Recording %s without an unencodable payload: %v", topic, err))
+			continue
+		}
+		values = append(values, sanitized)
+	}
+	r.add(Entry{Kind: KindEvent, Name: topic, Data: values})
+}
+
+// RecordCommand records a docker CLI invocation; it matches the docker command observer signature
+func (r *Recorder) RecordCommand(args []string) {
+	if !r.IsRecording() {
+		return
+	}
+	r.add(Entry{Kind: KindCommand, Name: "docker", Args: r.sanitizer.Args(args)})
+}
+
+// add appends an entry stamped with its offset, unless the recording is full
+func (r *Recorder) add(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recording {
+		return
+	}
+	if len(r.trace.Entries) >= MaxEntries {
+		r.trace.Dropped++
+		return
+	}
+	entry.OffsetMs = r.now().Sub(r.trace.StartedAt).Milliseconds()
+	r.trace.Entries = append(r.trace.Entries, entry)
+}
+
+// Save writes the trace as indented JSON
+func (t *Trace) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode session trace")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return errors.NewFileError("write", path, err)
+	}
+	return nil
+}
+
+// LoadTrace reads a trace saved by Save
+func LoadTrace(path string) (*Trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewFileError("read", path, err)
+	}
+
+	var trace Trace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to parse session trace %s", path)
+	}
+	if trace.Version != TraceVersion {
+		return nil, errors.NewValidationError("version", fmt.Sprintf("unsupported trace version (expected %d)", TraceVersion), trace.Version)
+	}
+	return &trace, nil
+}
@@ -0,0 +1,43 @@
+package scenario
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Replay re-emits a trace's events through emit with the recorded timing divided by speed
+// (speed <= 0 replays in real time). Recorded commands are passed to onCommand instead of being
+// run. Replay returns false if stop was closed before the end of the trace.
+func Replay(trace *Trace, speed float64, emit func(topic string, data ...interface{}), onCommand func(args []string), stop <-chan struct{}) bool {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	start := time.Now()
+	for _, entry := range trace.Entries {
+		due := start.Add(time.Duration(float64(entry.OffsetMs) * float64(time.Millisecond) / speed))
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return false
+			}
+		}
+
+		switch entry.Kind {
+		case KindEvent:
+			data := make([]interface{}, len(entry.Data))
+			for i, value := range entry.Data {
+				data[i] = json.RawMessage(value)
+			}
+			emit(entry.Name, data...)
+		case KindCommand:
+			if onCommand != nil {
+				onCommand(entry.Args)
+			}
+		}
+	}
+	return true
+}
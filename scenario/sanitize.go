@@ -0,0 +1,109 @@
+package scenario
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values in traces
+const redacted = "***"
+
+// secretNamePattern matches argument and field names whose values are secrets
+var secretNamePattern = regexp.MustCompile(`(?i)(password|passwd|passcode|pwd|dbpass|secret|token|api_?key|private_?key|credential|^auth$|authorization)`)
+
+// isSecretFlag reports whether flag's following argument is a secret; -p is only a password
+// for `docker login` (elsewhere it publishes ports)
+func isSecretFlag(args []string, flag string) bool {
+	return flag == "--password" || (flag == "-p" && len(args) > 0 && args[0] == "login")
+}
+
+// Sanitizer removes secrets and personal details (home directory, user name) from traces
+type Sanitizer struct {
+	replacer *strings.Replacer
+	userName *regexp.Regexp
+}
+
+// NewSanitizer creates a sanitizer replacing homeDir with "~" and userName with "<user>";
+// either may be empty. User names shorter than three characters are left alone, as they
+// would match unrelated text.
+func NewSanitizer(homeDir, userName string) *Sanitizer {
+	s := &Sanitizer{replacer: strings.NewReplacer()}
+	if homeDir != "" {
+		s.replacer = strings.NewReplacer(homeDir, "~")
+	}
+	if len(userName) >= 3 {
+		s.userName = regexp.MustCompile(`\b` + regexp.QuoteMeta(userName) + `\b`)
+	}
+	return s
+}
+
+// String removes personal details from s
+func (s *Sanitizer) String(value string) string {
+	value = s.replacer.Replace(value)
+	if s.userName != nil {
+		value = s.userName.ReplaceAllString(value, "<user>")
+	}
+	return value
+}
+
+// Args sanitizes command arguments: values of secret flags and NAME=value pairs with secret
+// names are redacted
+func (s *Sanitizer) Args(args []string) []string {
+	sanitized := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && isSecretFlag(args, args[i-1]):
+			sanitized[i] = redacted
+		case strings.Contains(arg, "="):
+			name, _, _ := strings.Cut(arg, "=")
+			if secretNamePattern.MatchString(name) {
+				sanitized[i] = name + "=" + redacted
+			} else {
+				sanitized[i] = s.String(arg)
+			}
+		default:
+			sanitized[i] = s.String(arg)
+		}
+	}
+	return sanitized
+}
+
+// Value encodes an event payload as JSON with secret fields redacted and strings sanitized
+func (s *Sanitizer) Value(value interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(s.walk(decoded))
+}
+
+// walk sanitizes a decoded JSON value
+func (s *Sanitizer) walk(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if secretNamePattern.MatchString(key) {
+				if _, isString := field.(string); isString {
+					v[key] = redacted
+					continue
+				}
+			}
+			v[key] = s.walk(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.walk(item)
+		}
+		return v
+	case string:
+		return s.String(v)
+	}
+	return value
+}
@@ -0,0 +1,92 @@
+package scenario
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizer(t *testing.T) {
+	sanitizer := NewSanitizer("/home/jdoe", "jdoe")
+
+	args := sanitizer.Args([]string{"run", "-p", "8080:80", "-e", "MOODLE_ADMIN_PASSWORD=hunter2", "-v", "/home/jdoe/plugins:/plugins", "--name", "moodle-prototype-jdoe"})
+	joined := strings.Join(args, " ")
+	if strings.Contains(joined, "hunter2") || !strings.Contains(joined, "MOODLE_ADMIN_PASSWORD=***") {
+		t.Errorf("Expected the password to be redacted, got %q", joined)
+	}
+	if !strings.Contains(joined, "-p 8080:80") {
+		t.Errorf("Expected published ports to be kept, got %q", joined)
+	}
+	if strings.Contains(joined, "jdoe") || !strings.Contains(joined, "~/plugins") || !strings.Contains(joined, "moodle-prototype-<user>") {
+		t.Errorf("Expected home directory and user name to be replaced, got %q", joined)
+	}
+	id := strings.Repeat("a", 64)
+	for _, argv := range [][]string{
+		{"exec", id, "env", "MYSQL_PWD=s3cret", "sh", "-c", "mysqldump -h 'db' -u 'moodle' 'moodle' > /tmp/database.sql"},
+		{"exec", id, "env", "PGPASSWORD=s3cret", "sh", "-c", "pg_dump -h 'db' -U 'moodle' 'moodle'"},
+		{"exec", id, "php", "/var/www/html/admin/cli/install.php", "--dbpass=s3cret"},
+	} {
+		if joined := strings.Join(sanitizer.Args(argv), " "); strings.Contains(joined, "s3cret") {
+			t.Errorf("Expected the database password to be redacted, got %q", joined)
+		}
+	}
+	if login := sanitizer.Args([]string{"login", "-u", "bot", "-p", "s3cret"}); login[4] != redacted {
+		t.Errorf("Expected the login password to be redacted, got %v", login)
+	}
+
+	value, err := sanitizer.Value(map[string]any{"password": "hunter2", "url": "http://localhost:8080", "nested": []any{map[string]any{"token": "abc"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(string(value), "hunter2") || strings.Contains(string(value), "abc") {
+		t.Errorf("Expected secret fields to be redacted, got %s", value)
+	}
+}
+
+func TestRecordSaveAndReplay(t *testing.T) {
+	recorder := NewRecorder(NewSanitizer("", ""))
+	clock := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return clock }
+
+	recorder.RecordEvent("ignored:before:start")
+	recorder.Start()
+	recorder.RecordCommand([]string{"ps", "-a"})
+	clock = clock.Add(1500 * time.Millisecond)
+	recorder.RecordEvent("docker:pull:progress", map[string]any{"percentage": 50})
+	trace := recorder.Stop()
+	recorder.RecordEvent("ignored:after:stop")
+
+	if len(trace.Entries) != 2 || trace.Entries[1].OffsetMs != 1500 || trace.DurationMs != 1500 {
+		t.Fatalf("Unexpected trace: %+v", trace)
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultTraceFile)
+	if err := trace.Save(path); err != nil {
+		t.Fatalf("Failed to save trace: %v", err)
+	}
+	loaded, err := LoadTrace(path)
+	if err != nil {
+		t.Fatalf("Failed to load trace: %v", err)
+	}
+
+	var topics []string
+	var commands [][]string
+	completed := Replay(loaded, 1e6, func(topic string, data ...interface{}) {
+		topics = append(topics, topic)
+		var payload map[string]float64
+		if raw, ok := data[0].(json.RawMessage); !ok || json.Unmarshal(raw, &payload) != nil || payload["percentage"] != 50 {
+			t.Errorf("Expected the recorded payload, got %v", data)
+		}
+	}, func(args []string) {
+		commands = append(commands, args)
+	}, nil)
+
+	if !completed || len(topics) != 1 || topics[0] != "docker:pull:progress" {
+		t.Errorf("Expected the event to be replayed, got %v", topics)
+	}
+	if len(commands) != 1 || strings.Join(commands[0], " ") != "ps -a" {
+		t.Errorf("Expected the command to be reported, got %v", commands)
+	}
+}
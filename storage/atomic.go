@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+)
+
+// Suffixes of the files kept next to a checked file
+const (
+	checksumSuffix = ".sha256"
+	backupSuffix   = ".bak"
+)
+
+// WriteFileAtomic writes data to a temporary file in the same directory, syncs it to disk and
+// renames it over path, so a crash leaves either the old or the new content, never a
+// truncated file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.NewFileError("create", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.NewFileError("write", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.NewFileError("sync", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.NewFileError("close", tmpPath, err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return errors.NewFileError("chmod", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.NewFileError("rename", path, err)
+	}
+
+	// Persist the rename itself; directories cannot be synced on Windows, so this is best effort
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// writeChecked atomically writes data with a SHA-256 checksum next to it, first keeping the
+// current content, if intact, as a .bak copy. The checksum is written before the data, so a
+// crash between the two is detected on read and answered from the backup.
+func writeChecked(path string, data []byte, perm os.FileMode) error {
+	if current, err := readVerified(path); err == nil {
+		if bytes.Equal(current, data) {
+			return nil
+		}
+		if err := writeWithChecksum(path+backupSuffix, current, perm); err != nil {
+			return errors.WrapWithContext(err, "failed to back up %s", filepath.Base(path))
+		}
+	}
+	return writeWithChecksum(path, data, perm)
+}
+
+// writeWithChecksum writes the checksum of data, then data
+func writeWithChecksum(path string, data []byte, perm os.FileMode) error {
+	if err := WriteFileAtomic(path+checksumSuffix, []byte(checksum(data)+"\n"), perm); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, perm)
+}
+
+// readChecked reads a file written by writeChecked. When its checksum does not match, the
+// .bak copy is returned instead and restored over the damaged file. Files written before
+// checksums were introduced have no checksum and are read as they are.
+func readChecked(path string) ([]byte, error) {
+	data, err := readVerified(path)
+	if err == nil {
+		return data, nil
+	}
+	if os.IsNotExist(err) {
+		return nil, errors.NewFileError("read", path, err)
+	}
+
+	backup, backupErr := readVerified(path + backupSuffix)
+	if backupErr != nil {
+		fmt.Printf("[ERROR] readChecked: %s is damaged and no intact backup exists: %v\n", path, err)
+		return nil, err
+	}
+
+	fmt.Printf("[WARNING] readChecked: %s is damaged (%v), recovering from backup\n", path, err)
+	if err := writeWithChecksum(path, backup, 0644); err != nil {
+		fmt.Printf("[WARNING] readChecked: Failed to restore %s from backup: %v\n", path, err)
+	}
+	return backup, nil
+}
+
+// readVerified reads a file and checks it against its checksum, if it has one
+func readVerified(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := os.ReadFile(path + checksumSuffix)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, errors.NewFileError("read", path+checksumSuffix, err)
+	}
+	if strings.TrimSpace(string(sum)) != checksum(data) {
+		return nil, errors.NewFileError("verify", path, errors.ErrFileCorrupted)
+	}
+	return data, nil
+}
+
+// removeChecked removes a checked file with its checksum and backup
+func removeChecked(path string) error {
+	for _, p := range []string{path, path + checksumSuffix, path + backupSuffix, path + backupSuffix + checksumSuffix} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return errors.NewFileError("delete", p, err)
+		}
+	}
+	return nil
+}
+
+// checksum returns the hex SHA-256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCheckedRecoversFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SettingsFile)
+
+	if err := writeChecked(path, []byte(`{"version":1}`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writeChecked(path, []byte(`{"version":2}`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := readChecked(path); err != nil || string(data) != `{"version":2}` {
+		t.Fatalf("Expected the latest version, got %q (err: %v)", data, err)
+	}
+
+	// A truncated write is detected and answered from the previous version, which is restored
+	if err := os.WriteFile(path, []byte(`{"vers`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := readChecked(path)
+	if err != nil || string(data) != `{"version":1}` {
+		t.Fatalf("Expected recovery from the backup, got %q (err: %v)", data, err)
+	}
+	if restored, err := readVerified(path); err != nil || string(restored) != `{"version":1}` {
+		t.Errorf("Expected the damaged file to be restored, got %q (err: %v)", restored, err)
+	}
+
+	if err := removeChecked(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if matches, _ := filepath.Glob(path + "*"); len(matches) != 0 {
+		t.Errorf("Expected every related file removed, found %v", matches)
+	}
+}
+
+func TestReadCheckedAcceptsLegacyFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ContainerIDFile)
+	if err := os.WriteFile(path, []byte("abc123"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Files written before checksums existed have none and are trusted
+	if data, err := readChecked(path); err != nil || string(data) != "abc123" {
+		t.Errorf("Expected the legacy file to be read, got %q (err: %v)", data, err)
+	}
+
+	// Without an intact backup, damage is reported rather than silently accepted
+	if err := os.WriteFile(path+checksumSuffix, []byte("0000\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := readChecked(path); err == nil {
+		t.Error("Expected an error for a damaged file without a backup")
+	}
+}
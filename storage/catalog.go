@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+)
+
+// ImageCatalogEntry describes a supported Moodle image tag
+type ImageCatalogEntry struct {
+	Image         string `json:"image"`
+	MoodleVersion string `json:"moodleVersion"`
+	Label         string `json:"label"`
+}
+
+// DefaultImageCatalog returns the built-in list of supported prototype images
+func DefaultImageCatalog() []ImageCatalogEntry {
+	return []ImageCatalogEntry{
+		{Image: "wenkhairu/moodle-prototype:502-stable", MoodleVersion: "5.0", Label: "Moodle 5.0 (stable)"},
+		{Image: "wenkhairu/moodle-prototype:404-stable", MoodleVersion: "4.4", Label: "Moodle 4.4 (stable)"},
+		{Image: "wenkhairu/moodle-prototype:403-stable", MoodleVersion: "4.3", Label: "Moodle 4.3 (stable)"},
+	}
+}
+
+// CatalogManager handles the image catalog stored in images.json
+type CatalogManager struct {
+	fileManager *FileManager
+}
+
+// NewCatalogManager creates a new catalog manager
+func NewCatalogManager() *CatalogManager {
+	return &CatalogManager{
+		fileManager: NewFileManager(),
+	}
+}
+
+// Load returns the catalog from file, or the built-in catalog if none is stored
+func (cm *CatalogManager) Load() ([]ImageCatalogEntry, error) {
+	if !cm.fileManager.DataFileExists(CatalogFile) {
+		return DefaultImageCatalog(), nil
+	}
+
+	data, err := cm.fileManager.LoadDataFile(CatalogFile)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load image catalog")
+	}
+
+	var entries []ImageCatalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrConfigInvalid, "failed to parse image catalog: %v", err)
+	}
+
+	for _, entry := range entries {
+		if err := errors.ValidateImageName(entry.Image); err != nil {
+			return nil, errors.WrapWithContext(err, "image catalog contains an invalid entry")
+		}
+	}
+
+	return entries, nil
+}
+
+// Save writes the catalog to file
+func (cm *CatalogManager) Save(entries []ImageCatalogEntry) error {
+	for _, entry := range entries {
+		if err := errors.ValidateImageName(entry.Image); err != nil {
+			return errors.WrapWithContext(err, "refusing to save invalid image catalog")
+		}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode image catalog")
+	}
+
+	return cm.fileManager.SaveDataFile(CatalogFile, data)
+}
+
+// Find returns the catalog entry for an image name
+func (cm *CatalogManager) Find(image string) (*ImageCatalogEntry, error) {
+	entries, err := cm.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if docker.SameImage(entry.Image, image) {
+			found := entry
+			return &found, nil
+		}
+	}
+
+	return nil, errors.WrapWithContext(errors.ErrImageNotFound, "image %s is not in the catalog", image)
+}
+
+// EnsureEntry adds an image to the catalog if it is missing (used for image.docker overrides)
+func (cm *CatalogManager) EnsureEntry(image string) error {
+	if _, err := cm.Find(image); err == nil {
+		return nil
+	}
+
+	entries, err := cm.Load()
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, ImageCatalogEntry{Image: image, Label: image})
+	return cm.Save(entries)
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timeline kinds recorded for changes the change report correlates
+const (
+	TimelineSettingsChanged = "settings:changed"
+	TimelinePluginInstalled = "plugin:installed"
+	TimelineImageUpdated    = "image:updated"
+)
+
+// Prefixes of settings-change timeline details holding the old and new value of a key
+const (
+	settingBeforePrefix = "before."
+	settingAfterPrefix  = "after."
+)
+
+// hiddenSettingValue replaces secrets in diffs and reports
+const hiddenSettingValue = "(hidden)"
+
+// SettingChange is one settings key whose value changed
+type SettingChange struct {
+	Key    string    `json:"key"`
+	Before string    `json:"before"`
+	After  string    `json:"after"`
+	Time   time.Time `json:"time,omitempty"`
+}
+
+// ChangeReport answers "what changed since it last worked": settings, plugins, images and
+// container events recorded in the timeline since a point in time
+type ChangeReport struct {
+	Since       time.Time `json:"since"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Settings holds the net change of each key; changes reverted in the window are left out
+	Settings []SettingChange `json:"settings"`
+	Plugins  []TimelineEntry `json:"plugins"`
+	Images   []TimelineEntry `json:"images"`
+	// Runtime holds container lifecycle, memory, alert and recovery events
+	Runtime []TimelineEntry `json:"runtime"`
+	Other   []TimelineEntry `json:"other"`
+	// Markdown renders the report for pasting into a ticket or status update
+	Markdown string `json:"markdown"`
+}
+
+// DiffSettings lists the keys that differ between two settings, as dotted JSON paths such as
+// "logScan.tailLines". Secrets are hidden and the version stamp is ignored.
+func DiffSettings(before, after *Settings) []SettingChange {
+	beforeValues := flattenSettings(before)
+	afterValues := flattenSettings(after)
+
+	keys := make(map[string]bool)
+	for key := range beforeValues {
+		keys[key] = true
+	}
+	for key := range afterValues {
+		keys[key] = true
+	}
+
+	changes := make([]SettingChange, 0)
+	for key := range keys {
+		if beforeValues[key] != afterValues[key] {
+			changes = append(changes, SettingChange{
+				Key:    key,
+				Before: maskSetting(key, beforeValues[key]),
+				After:  maskSetting(key, afterValues[key]),
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// SettingChangeDetails encodes changes as timeline details
+func SettingChangeDetails(changes []SettingChange) map[string]string {
+	details := make(map[string]string, len(changes)*2)
+	for _, change := range changes {
+		details[settingBeforePrefix+change.Key] = change.Before
+		details[settingAfterPrefix+change.Key] = change.After
+	}
+	return details
+}
+
+// BuildChangeReport groups the timeline entries recorded at or after since
+func BuildChangeReport(entries []TimelineEntry, since, now time.Time) *ChangeReport {
+	report := &ChangeReport{
+		Since:       since,
+		GeneratedAt: now,
+		Settings:    make([]SettingChange, 0),
+		Plugins:     make([]TimelineEntry, 0),
+		Images:      make([]TimelineEntry, 0),
+		Runtime:     make([]TimelineEntry, 0),
+		Other:       make([]TimelineEntry, 0),
+	}
+
+	// Net settings changes: the first old value against the last new value of each key
+	net := make(map[string]*SettingChange)
+	for _, entry := range entries {
+		if entry.Time.Before(since) {
+			continue
+		}
+		switch kind := entry.Kind; {
+		case kind == TimelineSettingsChanged:
+			for detail, before := range entry.Details {
+				key, ok := strings.CutPrefix(detail, settingBeforePrefix)
+				if !ok {
+					continue
+				}
+				after := entry.Details[settingAfterPrefix+key]
+				if change, seen := net[key]; seen {
+					change.After = after
+					change.Time = entry.Time
+				} else {
+					net[key] = &SettingChange{Key: key, Before: before, After: after, Time: entry.Time}
+				}
+			}
+		case strings.HasPrefix(kind, "plugin:"):
+			report.Plugins = append(report.Plugins, entry)
+		case strings.HasPrefix(kind, "image:"):
+			report.Images = append(report.Images, entry)
+		case strings.HasPrefix(kind, "container:"), strings.HasPrefix(kind, "oom:"), strings.HasPrefix(kind, "memory:"),
+			strings.HasPrefix(kind, "alert:"), strings.HasPrefix(kind, "kiosk:"):
+			report.Runtime = append(report.Runtime, entry)
+		default:
+			report.Other = append(report.Other, entry)
+		}
+	}
+	for _, change := range net {
+		if change.Before != change.After || change.Before == hiddenSettingValue {
+			report.Settings = append(report.Settings, *change)
+		}
+	}
+	sort.Slice(report.Settings, func(i, j int) bool { return report.Settings[i].Key < report.Settings[j].Key })
+
+	report.Markdown = report.render()
+	return report
+}
+
+// render formats the report as Markdown
+func (r *ChangeReport) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changes since %s\n\n", r.Since.Local().Format(time.RFC1123))
+
+	if len(r.Settings)+len(r.Plugins)+len(r.Images)+len(r.Runtime)+len(r.Other) == 0 {
+		b.WriteString("Nothing was recorded in this period.\n")
+		return b.String()
+	}
+
+	if len(r.Settings) > 0 {
+		b.WriteString("## Settings\n\n")
+		for _, change := range r.Settings {
+			fmt.Fprintf(&b, "- `%s`: %s → %s\n", change.Key, displaySetting(change.Before), displaySetting(change.After))
+		}
+		b.WriteString("\n")
+	}
+	for _, section := range []struct {
+		title   string
+		entries []TimelineEntry
+	}{
+		{"Plugins", r.Plugins},
+		{"Images", r.Images},
+		{"Container", r.Runtime},
+		{"Other", r.Other},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(&b, "- %s: %s\n", entry.Time.Local().Format("2006-01-02 15:04"), entry.Message)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// displaySetting shows an empty value explicitly
+func displaySetting(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return "`" + value + "`"
+}
+
+// flattenSettings maps each leaf of the settings' JSON form to its encoded value
+func flattenSettings(settings *Settings) map[string]string {
+	values := make(map[string]string)
+	if settings == nil {
+		return values
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return values
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return values
+	}
+	delete(tree, "stamp")
+	flattenInto(values, "", tree)
+	return values
+}
+
+// flattenInto walks nested objects; arrays and scalars are leaves
+func flattenInto(values map[string]string, prefix string, node any) {
+	if object, ok := node.(map[string]any); ok {
+		for key, child := range object {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenInto(values, path, child)
+		}
+		return
+	}
+	if text, ok := node.(string); ok {
+		values[prefix] = text
+		return
+	}
+	encoded, _ := json.Marshal(node)
+	values[prefix] = string(encoded)
+}
+
+// secretSettingWords mark keys whose whole value is a secret. Webhook URLs carry their token
+// in the path, so they are hidden too.
+var secretSettingWords = []string{"password", "passcode", "token", "secret", "webhookurl"}
+
+// maskSetting hides the value of keys holding secrets, and the password of URLs with
+// credentials such as an authenticating proxy
+func maskSetting(key, value string) string {
+	if value == "" {
+		return value
+	}
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, word := range secretSettingWords {
+		if strings.Contains(name, word) {
+			return hiddenSettingValue
+		}
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return parsed.Redacted()
+		}
+	}
+	return value
+}
@@ -1,7 +1,13 @@
 package storage
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+
 	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
 )
 
 // Credentials represents Moodle login credentials
@@ -20,19 +26,38 @@ func DefaultCredentials() *Credentials {
 	}
 }
 
-// CredentialManager handles credential operations
+// CredentialManager handles credential operations. Credentials are kept in the OS keychain
+// (macOS Keychain, Secret Service on Linux, DPAPI on Windows), falling back to an encrypted
+// file when the keychain is unavailable. A plaintext moodle.txt left by older versions is
+// migrated on first load and then removed.
 type CredentialManager struct {
 	fileManager *FileManager
+	// keychain is nil on platforms without a supported keychain
+	keychain SecretStore
+	fallback SecretStore
+
+	mu     sync.Mutex
+	cached *Credentials
 }
 
 // NewCredentialManager creates a new credential manager
 func NewCredentialManager() *CredentialManager {
+	fileManager := NewFileManager()
+	return newCredentialManager(fileManager,
+		newKeychainStore(filepath.Dir(fileManager.DataFilePath(CredentialsFile)), keychainAccount),
+		newEncryptedFileStore(fileManager.DataFilePath(EncryptedCredentialsFile), fileManager.DataFilePath(CredentialsKeyFile)))
+}
+
+// newCredentialManager creates a credential manager on the given secret stores
+func newCredentialManager(fileManager *FileManager, keychain, fallback SecretStore) *CredentialManager {
 	return &CredentialManager{
-		fileManager: NewFileManager(),
+		fileManager: fileManager,
+		keychain:    keychain,
+		fallback:    fallback,
 	}
 }
 
-// Save saves credentials to file
+// Save stores credentials in the keychain, or the encrypted file when the keychain fails
 func (cm *CredentialManager) Save(creds *Credentials) error {
 	if creds == nil {
 		return errors.NewValidationError("credentials", "credentials object cannot be nil", creds)
@@ -43,21 +68,105 @@ func (cm *CredentialManager) Save(creds *Credentials) error {
 		return errors.NewValidationError("credentials", "credentials are invalid (missing password or URL)", creds)
 	}
 
-	err := cm.fileManager.SaveCredentials(creds.Password, creds.URL)
+	data, err := json.Marshal(creds)
 	if err != nil {
-		return errors.WrapWithContext(err, "failed to save credentials to file")
+		return errors.WrapWithContext(err, "failed to encode credentials")
 	}
 
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	stored := false
+	if cm.keychain != nil {
+		if err := cm.keychain.Set(string(data)); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to save credentials to the %s, using an encrypted file: %v", cm.keychain.Name(), err))
+		} else {
+			stored = true
+			// Do not leave an older copy behind in the fallback
+			if err := cm.fallback.Delete(); err != nil {
+				utils.LogWarning(fmt.Sprintf("Failed to remove credentials from the %s: %v", cm.fallback.Name(), err))
+			}
+		}
+	}
+	if !stored {
+		if err := cm.fallback.Set(string(data)); err != nil {
+			return errors.WrapWithContext(err, "failed to save credentials to the %s", cm.fallback.Name())
+		}
+	}
+
+	if err := cm.fileManager.DeleteCredentials(); err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to remove plaintext credentials file: %v", err))
+	}
+
+	copied := *creds
+	cm.cached = &copied
 	return nil
 }
 
-// Load loads credentials from file
+// Load loads credentials, returning the defaults when none are stored
 func (cm *CredentialManager) Load() (*Credentials, error) {
+	cm.mu.Lock()
+	if cm.cached != nil {
+		copied := *cm.cached
+		cm.mu.Unlock()
+		return &copied, nil
+	}
+	secret, store, err := cm.loadSecret()
+	cm.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if secret != "" {
+		creds := DefaultCredentials()
+		if err := json.Unmarshal([]byte(secret), creds); err != nil {
+			return nil, errors.WrapWithContext(err, "failed to decode credentials from the %s", store.Name())
+		}
+		cm.mu.Lock()
+		copied := *creds
+		cm.cached = &copied
+		cm.mu.Unlock()
+		return creds, nil
+	}
+
 	if !cm.fileManager.CredentialsExist() {
-		// Return default credentials when file doesn't exist (first run)
+		// Return default credentials when nothing is stored (first run)
 		return DefaultCredentials(), nil
 	}
 
+	creds, err := cm.loadPlaintext()
+	if err != nil {
+		return nil, err
+	}
+	if creds.IsValid() {
+		utils.LogInfo("Moving credentials from the plaintext file to secure storage")
+		if err := cm.Save(creds); err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to migrate plaintext credentials: %v", err))
+		}
+	}
+	return creds, nil
+}
+
+// loadSecret reads the stored secret from the keychain, then the fallback file
+func (cm *CredentialManager) loadSecret() (string, SecretStore, error) {
+	if cm.keychain != nil {
+		secret, err := cm.keychain.Get()
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to read credentials from the %s: %v", cm.keychain.Name(), err))
+		} else if secret != "" {
+			return secret, cm.keychain, nil
+		}
+	}
+
+	secret, err := cm.fallback.Get()
+	if err != nil {
+		return "", cm.fallback, errors.WrapWithContext(err, "failed to load credentials from the %s", cm.fallback.Name())
+	}
+	return secret, cm.fallback, nil
+}
+
+// loadPlaintext reads the key=value credentials file written by older versions
+func (cm *CredentialManager) loadPlaintext() (*Credentials, error) {
 	data, err := cm.fileManager.LoadCredentials()
 	if err != nil {
 		return nil, errors.WrapWithContext(err, "failed to load credentials from file")
@@ -103,18 +212,31 @@ func (cm *CredentialManager) Update(password, url string) error {
 	return cm.Save(creds)
 }
 
-// Clear removes stored credentials
+// Clear removes stored credentials from every backend
 func (cm *CredentialManager) Clear() error {
-	err := cm.fileManager.DeleteCredentials()
-	if err != nil {
-		return errors.WrapWithContext(err, "failed to clear stored credentials")
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.cached = nil
+
+	multiErr := errors.NewMultiError("clear stored credentials")
+	for _, store := range []SecretStore{cm.keychain, cm.fallback} {
+		if store == nil {
+			continue
+		}
+		if err := store.Delete(); err != nil {
+			multiErr.Add(errors.WrapWithContext(err, "failed to clear credentials from the %s", store.Name()))
+		}
 	}
-	return nil
+	if err := cm.fileManager.DeleteCredentials(); err != nil {
+		multiErr.Add(errors.WrapWithContext(err, "failed to clear stored credentials"))
+	}
+	return multiErr.ToError()
 }
 
 // Exists checks if credentials are stored
 func (cm *CredentialManager) Exists() bool {
-	return cm.fileManager.CredentialsExist()
+	creds, err := cm.Load()
+	return err == nil && creds.IsValid()
 }
 
 // IsValid checks if credentials are valid (non-empty password and URL)
@@ -162,6 +284,32 @@ func (creds *Credentials) ToMap() map[string]string {
 	}
 }
 
+// MaskedPassword replaces the password in MaskedCredentials
+const MaskedPassword = "••••••••"
+
+// MaskedCredentials are credentials safe to show in the frontend, without the password itself
+type MaskedCredentials struct {
+	Username string `json:"username"`
+	// Password is MaskedPassword when one is stored and empty otherwise
+	Password    string `json:"password"`
+	URL         string `json:"url"`
+	HasPassword bool   `json:"hasPassword"`
+}
+
+// Masked returns the credentials with the password masked, for showing them without handing
+// the password to the frontend
+func (creds *Credentials) Masked() *MaskedCredentials {
+	if creds == nil {
+		return DefaultCredentials().Masked()
+	}
+
+	masked := &MaskedCredentials{Username: creds.Username, URL: creds.URL, HasPassword: creds.Password != ""}
+	if masked.HasPassword {
+		masked.Password = MaskedPassword
+	}
+	return masked
+}
+
 // IsComplete checks if credentials have both password and URL (used by log parser)
 func (creds *Credentials) IsComplete() bool {
 	if creds == nil {
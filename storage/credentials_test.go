@@ -0,0 +1,19 @@
+package storage
+
+import "testing"
+
+func TestMasked(t *testing.T) {
+	creds := &Credentials{Username: "admin", Password: "s3cret!", URL: "http://localhost:8080"}
+	masked := creds.Masked()
+	if masked.Password != MaskedPassword || !masked.HasPassword {
+		t.Errorf("Expected the password masked, got %+v", masked)
+	}
+	if masked.URL != creds.URL || masked.Username != "admin" {
+		t.Errorf("Expected other fields unchanged, got %+v", masked)
+	}
+
+	empty := (&Credentials{Username: "admin"}).Masked()
+	if empty.Password != "" || empty.HasPassword {
+		t.Errorf("Expected no password to stay empty, got %+v", empty)
+	}
+}
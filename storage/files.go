@@ -10,9 +10,18 @@ import (
 )
 
 const (
-	ContainerIDFile = "container.id"
-	CredentialsFile = "moodle.txt"
-	ImageConfigFile = "image.docker"
+	ContainerIDFile   = "container.id"
+	CredentialsFile   = "moodle.txt"
+	ImageConfigFile   = "image.docker"
+	SettingsFile      = "settings.json"
+	CatalogFile       = "images.json"
+	TLSCertFile       = "tls-cert.pem"
+	TLSKeyFile        = "tls-key.pem"
+	ProfilesDir       = "profiles"
+	ScreenshotsDir    = "screenshots"
+	AssetBundleDir    = "asset-bundle"
+	RegistryCacheFile = "registry-cache.json"
+	PrePullStateFile  = "prepull-state.json"
 )
 
 // FileManager handles file I/O operations
@@ -132,10 +141,10 @@ func (fm *FileManager) SaveContainerID(containerID string) error {
 		return errors.WrapWithContext(err, "failed to ensure directory exists for container ID file")
 	}
 
-	err := os.WriteFile(filePath, []byte(containerID), 0644)
+	err := writeChecked(filePath, []byte(containerID), 0644)
 	if err != nil {
 		fmt.Printf("[ERROR] SaveContainerID: Failed to write to %s: %v\n", filePath, err)
-		return errors.NewFileError("write", filePath, err)
+		return errors.WrapWithContext(err, "failed to write container ID file")
 	}
 
 	fmt.Printf("[DEBUG] SaveContainerID: Successfully wrote container ID to %s\n", filePath)
@@ -147,10 +156,10 @@ func (fm *FileManager) LoadContainerID() (string, error) {
 	filePath := fm.getFilePath(ContainerIDFile)
 	fmt.Printf("[DEBUG] LoadContainerID: Reading from %s\n", filePath)
 
-	data, err := os.ReadFile(filePath)
+	data, err := readChecked(filePath)
 	if err != nil {
 		fmt.Printf("[ERROR] LoadContainerID: Failed to read from %s: %v\n", filePath, err)
-		return "", errors.NewFileError("read", filePath, err)
+		return "", errors.WrapWithContext(err, "failed to read container ID file")
 	}
 
 	containerID := strings.TrimSpace(string(data))
@@ -192,10 +201,10 @@ func (fm *FileManager) SaveCredentials(password, url string) error {
 	}
 
 	content := fmt.Sprintf("password=%s\nurl=%s\n", password, url)
-	err := os.WriteFile(filePath, []byte(content), 0644)
+	err := writeChecked(filePath, []byte(content), 0644)
 	if err != nil {
 		fmt.Printf("[ERROR] SaveCredentials: Failed to write to %s: %v\n", filePath, err)
-		return errors.NewFileError("write", filePath, err)
+		return errors.WrapWithContext(err, "failed to write credentials file")
 	}
 
 	fmt.Printf("[DEBUG] SaveCredentials: Successfully wrote credentials to %s\n", filePath)
@@ -207,10 +216,10 @@ func (fm *FileManager) LoadCredentials() (map[string]string, error) {
 	filePath := fm.getFilePath(CredentialsFile)
 	fmt.Printf("[DEBUG] LoadCredentials: Reading from %s\n", filePath)
 
-	data, err := os.ReadFile(filePath)
+	data, err := readChecked(filePath)
 	if err != nil {
 		fmt.Printf("[ERROR] LoadCredentials: Failed to read from %s: %v\n", filePath, err)
-		return nil, errors.NewFileError("read", filePath, err)
+		return nil, errors.WrapWithContext(err, "failed to read credentials file")
 	}
 
 	if len(data) == 0 {
@@ -260,34 +269,125 @@ func (fm *FileManager) CredentialsExist() bool {
 
 // DeleteContainerID removes the container ID file
 func (fm *FileManager) DeleteContainerID() error {
-	filePath := fm.getFilePath(ContainerIDFile)
-	if !fm.ContainerIDExists() {
-		// File doesn't exist, nothing to delete
-		return nil
+	// Also removes the checksum and backup; missing files are ignored
+	return removeChecked(fm.getFilePath(ContainerIDFile))
+}
+
+// DeleteCredentials removes the credentials file
+func (fm *FileManager) DeleteCredentials() error {
+	// Also removes the checksum and backup; missing files are ignored
+	return removeChecked(fm.getFilePath(CredentialsFile))
+}
+
+// SaveDataFile writes raw data to a named file in the data directory
+func (fm *FileManager) SaveDataFile(filename string, data []byte) error {
+	if err := errors.ValidateFilePath("filename", filename); err != nil {
+		return errors.WrapWithContext(err, "invalid filename provided to SaveDataFile")
 	}
 
-	err := os.Remove(filePath)
-	if err != nil {
-		return errors.NewFileError("delete", filePath, err)
+	filePath := fm.getFilePath(filename)
+	fmt.Printf("[DEBUG] SaveDataFile: Writing to %s\n", filePath)
+
+	// Ensure directory exists
+	if err := fm.ensureDirectoryExists(filepath.Dir(filePath)); err != nil {
+		return errors.WrapWithContext(err, "failed to ensure directory exists for %s", filename)
 	}
+
+	if err := WriteFileAtomic(filePath, data, 0644); err != nil {
+		fmt.Printf("[ERROR] SaveDataFile: Failed to write to %s: %v\n", filePath, err)
+		return err
+	}
+
 	return nil
 }
 
-// DeleteCredentials removes the credentials file
-func (fm *FileManager) DeleteCredentials() error {
-	filePath := fm.getFilePath(CredentialsFile)
-	if !fm.CredentialsExist() {
-		// File doesn't exist, nothing to delete
-		return nil
+// LoadDataFile reads raw data from a named file in the data directory
+func (fm *FileManager) LoadDataFile(filename string) ([]byte, error) {
+	filePath := fm.getFilePath(filename)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("[ERROR] LoadDataFile: Failed to read from %s: %v\n", filePath, err)
+		return nil, errors.NewFileError("read", filePath, err)
 	}
 
-	err := os.Remove(filePath)
+	if len(data) == 0 {
+		return nil, errors.NewFileError("parse", filePath, errors.ErrFileCorrupted)
+	}
+
+	return data, nil
+}
+
+// DataDirectory returns the directory the app keeps its files in
+func (fm *FileManager) DataDirectory() string {
+	return fm.getBaseDir()
+}
+
+// CheckWritable verifies that files, such as the stored credentials, can be written to the
+// data directory by creating and removing a probe file
+func (fm *FileManager) CheckWritable() error {
+	dir := fm.getBaseDir()
+	if err := fm.ensureDirectoryExists(dir); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".write-check-*")
 	if err != nil {
-		return errors.NewFileError("delete", filePath, err)
+		return errors.NewFileError("write", dir, err)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return errors.NewFileError("remove", probe.Name(), err)
+	}
+	return nil
+}
+
+// DataFilePath returns the absolute path of a named file in the data directory
+func (fm *FileManager) DataFilePath(filename string) string {
+	return fm.getFilePath(filename)
+}
+
+// DataFileExists checks if a named file exists in the data directory
+func (fm *FileManager) DataFileExists(filename string) bool {
+	_, err := os.Stat(fm.getFilePath(filename))
+	return err == nil
+}
+
+// SaveSettings writes the encoded settings to file, keeping the previous version as a backup
+func (fm *FileManager) SaveSettings(data []byte) error {
+	if len(data) == 0 {
+		return errors.NewValidationError("settings", "settings data cannot be empty", nil)
+	}
+
+	filePath := fm.getFilePath(SettingsFile)
+	if err := fm.ensureDirectoryExists(filepath.Dir(filePath)); err != nil {
+		return errors.WrapWithContext(err, "failed to ensure directory exists for settings file")
+	}
+	if err := writeChecked(filePath, data, 0644); err != nil {
+		fmt.Printf("[ERROR] SaveSettings: Failed to write to %s: %v\n", filePath, err)
+		return errors.WrapWithContext(err, "failed to write settings file")
 	}
 	return nil
 }
 
+// LoadSettings reads the encoded settings from file, recovering from the backup when the
+// file is damaged
+func (fm *FileManager) LoadSettings() ([]byte, error) {
+	filePath := fm.getFilePath(SettingsFile)
+	data, err := readChecked(filePath)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to read settings file")
+	}
+	if len(data) == 0 {
+		return nil, errors.NewFileError("parse", filePath, errors.ErrFileCorrupted)
+	}
+	return data, nil
+}
+
+// SettingsExist checks if the settings file exists
+func (fm *FileManager) SettingsExist() bool {
+	return fm.DataFileExists(SettingsFile)
+}
+
 // LoadImageName loads the Docker image name from configuration file
 func (fm *FileManager) LoadImageName() (string, error) {
 	// Try multiple potential paths for the image configuration file
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// JournalFile stores the operation history
+const JournalFile = "operations.json"
+
+// MaxJournalEntries caps the number of operations kept on disk
+const MaxJournalEntries = 1000
+
+// Operations recorded in the journal
+const (
+	OpImagePull       = "image:pull"
+	OpImageLoad       = "image:load"
+	OpImageSave       = "image:save"
+	OpContainerCreate = "container:create"
+	OpContainerStart  = "container:start"
+	OpContainerStop   = "container:stop"
+	OpContainerRemove = "container:remove"
+	OpMoodleInstall   = "moodle:install"
+	OpPluginInstall   = "plugin:install"
+	OpExport          = "export"
+	OpSnapshotCreate  = "snapshot:create"
+	OpSnapshotClone   = "snapshot:clone"
+	OpSnapshotDelete  = "snapshot:delete"
+	OpDemoSeed        = "demo:seed"
+	OpInstanceExport  = "instance:export"
+	OpInstanceImport  = "instance:import"
+	OpCleanup         = "cleanup:emergency"
+)
+
+// Operation outcomes
+const (
+	OutcomeRunning   = "running"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	// OutcomeInterrupted marks operations still running when the app last exited
+	OutcomeInterrupted = "interrupted"
+)
+
+// OperationRecord is one operation the app performed
+type OperationRecord struct {
+	ID         string            `json:"id"`
+	Operation  string            `json:"operation"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// Journal is the storage-backed history of operations such as pulls and container starts,
+// kept so users and support can see what the app did and when
+type Journal struct {
+	fileManager *FileManager
+	mu          sync.Mutex
+	nextID      int64
+}
+
+// JournalOperation is a started operation awaiting its outcome
+type JournalOperation struct {
+	journal *Journal
+	id      string
+}
+
+// NewJournal creates a new operation journal
+func NewJournal() *Journal {
+	return &Journal{
+		fileManager: NewFileManager(),
+	}
+}
+
+// Begin records the start of an operation. Failures to save are logged rather than returned
+// so that the journal never blocks the operation itself.
+func (j *Journal) Begin(operation string, details map[string]string) *JournalOperation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.nextID++
+	id := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatInt(j.nextID, 36)
+	err := j.update(func(records []OperationRecord) []OperationRecord {
+		return append(records, OperationRecord{
+			ID:        id,
+			Operation: operation,
+			StartedAt: now,
+			Outcome:   OutcomeRunning,
+			Details:   details,
+		})
+	})
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to record start of %s: %v", operation, err))
+	}
+	return &JournalOperation{journal: j, id: id}
+}
+
+// Finish records the outcome of the operation; a nil error means it succeeded
+func (op *JournalOperation) Finish(opErr error) {
+	j := op.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	err := j.update(func(records []OperationRecord) []OperationRecord {
+		for i := range records {
+			if records[i].ID != op.id {
+				continue
+			}
+			records[i].FinishedAt = &now
+			records[i].Outcome = OutcomeSucceeded
+			if opErr != nil {
+				records[i].Outcome = OutcomeFailed
+				records[i].Error = opErr.Error()
+			}
+		}
+		return records
+	})
+	if err != nil {
+		utils.LogWarning(fmt.Sprintf("Failed to record outcome of operation %s: %v", op.id, err))
+	}
+}
+
+// MarkInterrupted flags operations left running by a previous run of the app
+func (j *Journal) MarkInterrupted() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.update(func(records []OperationRecord) []OperationRecord {
+		for i := range records {
+			if records[i].Outcome == OutcomeRunning {
+				records[i].Outcome = OutcomeInterrupted
+			}
+		}
+		return records
+	})
+}
+
+// History returns up to limit operations, newest first; limit <= 0 returns all
+func (j *Journal) History(limit int) ([]OperationRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	records, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]OperationRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		if limit > 0 && len(history) == limit {
+			break
+		}
+		history = append(history, records[i])
+	}
+	return history, nil
+}
+
+// update applies fn to the stored records, dropping the oldest beyond MaxJournalEntries
+func (j *Journal) update(fn func([]OperationRecord) []OperationRecord) error {
+	records, err := j.load()
+	if err != nil {
+		return err
+	}
+
+	records = fn(records)
+	if len(records) > MaxJournalEntries {
+		records = records[len(records)-MaxJournalEntries:]
+	}
+
+	data, err := encodeState(records)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode operation journal")
+	}
+	return j.fileManager.SaveDataFile(JournalFile, data)
+}
+
+// load reads the journal without locking
+func (j *Journal) load() ([]OperationRecord, error) {
+	if !j.fileManager.DataFileExists(JournalFile) {
+		return []OperationRecord{}, nil
+	}
+
+	data, err := j.fileManager.LoadDataFile(JournalFile)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load operation journal")
+	}
+
+	var records []OperationRecord
+	if err := decodeState(JournalFile, data, &records); err != nil {
+		if IsStateFromNewerVersion(err) {
+			return nil, err
+		}
+		return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "failed to parse operation journal: %v", err)
+	}
+	return records, nil
+}
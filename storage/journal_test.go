@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestJournalRecordsOutcomes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	journal := NewJournal()
+
+	pull := journal.Begin(OpImagePull, map[string]string{"image": "moodle:502"})
+	pull.Finish(nil)
+	stop := journal.Begin(OpContainerStop, nil)
+	stop.Finish(fmt.Errorf("daemon gone"))
+	journal.Begin(OpContainerStart, nil)
+
+	// A restart flags the operation that never finished
+	if err := NewJournal().MarkInterrupted(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	history, err := journal.History(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 operations, got %+v", history)
+	}
+	if history[0].Operation != OpContainerStart || history[0].Outcome != OutcomeInterrupted {
+		t.Errorf("Expected the newest operation to be interrupted, got %+v", history[0])
+	}
+	if history[1].Outcome != OutcomeFailed || history[1].Error != "daemon gone" || history[1].FinishedAt == nil {
+		t.Errorf("Expected the stop to have failed, got %+v", history[1])
+	}
+	if history[2].Outcome != OutcomeSucceeded || history[2].Details["image"] != "moodle:502" {
+		t.Errorf("Expected the pull to have succeeded, got %+v", history[2])
+	}
+
+	if limited, _ := journal.History(1); len(limited) != 1 {
+		t.Errorf("Expected the limit to apply, got %d operations", len(limited))
+	}
+}
@@ -0,0 +1,70 @@
+//go:build darwin
+
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// securityItemNotFound is the exit code of `security` when no keychain item matches
+const securityItemNotFound = 44
+
+// macKeychainStore keeps the secret in the login keychain through the security CLI
+type macKeychainStore struct {
+	account string
+}
+
+// newKeychainStore returns the macOS keychain item for account
+func newKeychainStore(dataDir, account string) SecretStore {
+	return &macKeychainStore{account: account}
+}
+
+func (s *macKeychainStore) Name() string {
+	return "macOS keychain"
+}
+
+func (s *macKeychainStore) Get() (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", s.account, "-w")
+	output, err := utils.Commands().Output(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == securityItemNotFound {
+			return "", nil
+		}
+		return "", errors.WrapWithContext(err, "failed to read keychain item")
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to decode keychain item")
+	}
+	return string(secret), nil
+}
+
+func (s *macKeychainStore) Set(secret string) error {
+	// Pass the secret through stdin in interactive mode so it never appears in the process
+	// list; base64 keeps it free of characters the command parser would interpret
+	encoded := base64.StdEncoding.EncodeToString([]byte(secret))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", keychainService, s.account, encoded))
+	if output, err := utils.Commands().CombinedOutput(cmd); err != nil {
+		return errors.WrapWithContext(err, "failed to write keychain item: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *macKeychainStore) Delete() error {
+	cmd := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", s.account)
+	if output, err := utils.Commands().CombinedOutput(cmd); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == securityItemNotFound {
+			return nil
+		}
+		return errors.WrapWithContext(err, "failed to delete keychain item: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
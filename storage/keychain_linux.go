@@ -0,0 +1,66 @@
+//go:build linux
+
+package storage
+
+import (
+	"os/exec"
+	"strings"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// secretServiceStore keeps the secret in the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool from libsecret
+type secretServiceStore struct {
+	account string
+}
+
+// newKeychainStore returns the Secret Service keyring item for account
+func newKeychainStore(dataDir, account string) SecretStore {
+	return &secretServiceStore{account: account}
+}
+
+func (s *secretServiceStore) Name() string {
+	return "Secret Service keyring"
+}
+
+// attributes identifies the keyring item
+func (s *secretServiceStore) attributes() []string {
+	return []string{"service", keychainService, "account", s.account}
+}
+
+func (s *secretServiceStore) Get() (string, error) {
+	output, err := utils.Commands().Output(exec.Command("secret-tool", append([]string{"lookup"}, s.attributes()...)...))
+	if err != nil {
+		// lookup exits 1 without output when no item matches
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && len(exitErr.Stderr) == 0 {
+			return "", nil
+		}
+		return "", errors.WrapWithContext(err, "failed to read keyring item")
+	}
+	return string(output), nil
+}
+
+func (s *secretServiceStore) Set(secret string) error {
+	args := append([]string{"store", "--label=Moodle Prototype Manager"}, s.attributes()...)
+	cmd := exec.Command("secret-tool", args...)
+	// secret-tool reads the secret from stdin, keeping it out of the process list
+	cmd.Stdin = strings.NewReader(secret)
+	if output, err := utils.Commands().CombinedOutput(cmd); err != nil {
+		return errors.WrapWithContext(err, "failed to write keyring item: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *secretServiceStore) Delete() error {
+	cmd := exec.Command("secret-tool", append([]string{"clear"}, s.attributes()...)...)
+	if output, err := utils.Commands().CombinedOutput(cmd); err != nil {
+		// clear exits 1 when nothing matched
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && len(output) == 0 {
+			return nil
+		}
+		return errors.WrapWithContext(err, "failed to delete keyring item: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux && !windows
+
+package storage
+
+// newKeychainStore returns nil: there is no supported keychain on this platform, so
+// secrets go to the encrypted file
+func newKeychainStore(dataDir, account string) SecretStore {
+	return nil
+}
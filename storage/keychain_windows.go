@@ -0,0 +1,106 @@
+//go:build windows
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"moodle-prototype-manager/errors"
+)
+
+// DPAPICredentialsFile holds the credentials encrypted with the Windows Data Protection API
+const DPAPICredentialsFile = "credentials.dpapi"
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = syscall.NewLazyDLL("kernel32.dll").NewProc("LocalFree")
+)
+
+// cryptUIForbidden stops DPAPI from ever prompting the user
+const cryptUIForbidden = 0x1
+
+// dataBlob mirrors the Win32 DATA_BLOB structure
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+func newDataBlob(data []byte) *dataBlob {
+	if len(data) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{size: uint32(len(data)), data: &data[0]}
+}
+
+// bytes copies the blob out of memory allocated by DPAPI and frees it
+func (b *dataBlob) bytes() []byte {
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(b.data)))
+	return append([]byte(nil), unsafe.Slice(b.data, b.size)...)
+}
+
+// dpapiStore keeps the secret in a file encrypted with DPAPI under the current Windows user,
+// so only that user on this machine can decrypt it
+type dpapiStore struct {
+	path string
+}
+
+// newKeychainStore returns the DPAPI-protected file for account; the admin credentials keep
+// the file name of earlier versions
+func newKeychainStore(dataDir, account string) SecretStore {
+	name := DPAPICredentialsFile
+	if account != keychainAccount {
+		name = account + ".dpapi"
+	}
+	return &dpapiStore{path: filepath.Join(dataDir, name)}
+}
+
+func (s *dpapiStore) Name() string {
+	return "Windows DPAPI"
+}
+
+func (s *dpapiStore) Get() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.NewFileError("read", s.path, err)
+	}
+
+	var out dataBlob
+	ret, _, callErr := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(newDataBlob(data))), 0, 0, 0, 0,
+		cryptUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if ret == 0 {
+		return "", errors.NewFileError("decrypt", s.path, callErr)
+	}
+	return string(out.bytes()), nil
+}
+
+func (s *dpapiStore) Set(secret string) error {
+	var out dataBlob
+	ret, _, callErr := procCryptProtectData.Call(uintptr(unsafe.Pointer(newDataBlob([]byte(secret)))), 0, 0, 0, 0,
+		cryptUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if ret == 0 {
+		return errors.WrapWithContext(callErr, "failed to encrypt credentials with DPAPI")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return errors.NewFileError("create", filepath.Dir(s.path), err)
+	}
+	if err := os.WriteFile(s.path, out.bytes(), 0600); err != nil {
+		return errors.NewFileError("write", s.path, err)
+	}
+	return nil
+}
+
+func (s *dpapiStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return errors.NewFileError("delete", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// LockFile marks the data directory as in use by a running manager
+const LockFile = "manager.lock"
+
+const (
+	// lockHeartbeat is how often a running manager touches its lock file
+	lockHeartbeat = 30 * time.Second
+	// lockStaleAfter is how long a lock from another machine, whose process cannot be checked,
+	// may go untouched before it is considered abandoned
+	lockStaleAfter = 4 * lockHeartbeat
+)
+
+// LockInfo identifies the manager holding the lock
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// AlreadyRunningError is returned when another manager holds the lock
+type AlreadyRunningError struct {
+	Holder LockInfo
+}
+
+func (e *AlreadyRunningError) Error() string {
+	return fmt.Sprintf("Moodle Prototype Manager is already running (PID %d on %s, started %s)",
+		e.Holder.PID, e.Holder.Hostname, e.Holder.StartedAt.Format(time.RFC1123))
+}
+
+// ErrorCode reports the instance as read-only to the frontend
+func (e *AlreadyRunningError) ErrorCode() errors.Code {
+	return errors.CodeReadOnly
+}
+
+// Remediation points the user at the manager holding the lock
+func (e *AlreadyRunningError) Remediation() []string {
+	return []string{
+		fmt.Sprintf("Close Moodle Prototype Manager running as PID %d on %s, then restart this window", e.Holder.PID, e.Holder.Hostname),
+	}
+}
+
+// InstanceLock is an acquired data directory lock
+type InstanceLock struct {
+	path     string
+	stopChan chan struct{}
+	once     sync.Once
+}
+
+// AcquireInstanceLock takes the lock file at path. A lock left by a process that has exited,
+// or by another machine that stopped refreshing it, is taken over; a live one yields an
+// *AlreadyRunningError describing its holder.
+func AcquireInstanceLock(path string) (*InstanceLock, error) {
+	hostname, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Hostname: hostname, Version: buildinfo.Version, StartedAt: time.Now()}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to encode lock")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.NewFileError("create", filepath.Dir(path), err)
+	}
+
+	// Two attempts: the second follows the removal of a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, writeErr := file.Write(data)
+			closeErr := file.Close()
+			if writeErr != nil || closeErr != nil {
+				os.Remove(path)
+				return nil, errors.NewFileError("write", path, fmt.Errorf("%v %v", writeErr, closeErr))
+			}
+			lock := &InstanceLock{path: path, stopChan: make(chan struct{})}
+			go lock.heartbeat()
+			return lock, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.NewFileError("create", path, err)
+		}
+
+		holder, stale := readLock(path, hostname)
+		if !stale {
+			return nil, &AlreadyRunningError{Holder: holder}
+		}
+		utils.LogWarning(fmt.Sprintf("Taking over stale lock of PID %d on %s", holder.PID, holder.Hostname))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.NewFileError("delete", path, err)
+		}
+	}
+	// Another instance took over the stale lock between our removal and creation
+	holder, _ := readLock(path, hostname)
+	return nil, &AlreadyRunningError{Holder: holder}
+}
+
+// readLock reads a lock file and reports whether its holder is gone
+func readLock(path, hostname string) (LockInfo, bool) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &info) != nil {
+		// Unreadable or half-written: only stale once nobody has touched it for a while
+		stat, statErr := os.Stat(path)
+		return info, statErr == nil && time.Since(stat.ModTime()) > lockStaleAfter
+	}
+
+	if info.Hostname == hostname {
+		return info, !processAlive(info.PID)
+	}
+	// A synced data directory may hold the lock of another machine; trust its heartbeat
+	stat, err := os.Stat(path)
+	return info, err == nil && time.Since(stat.ModTime()) > lockStaleAfter
+}
+
+// heartbeat refreshes the lock's modification time until Release
+func (l *InstanceLock) heartbeat() {
+	ticker := time.NewTicker(lockHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := os.Chtimes(l.path, now, now); err != nil {
+				utils.LogWarning(fmt.Sprintf("Failed to refresh lock file: %v", err))
+			}
+		case <-l.stopChan:
+			return
+		}
+	}
+}
+
+// Release removes the lock file
+func (l *InstanceLock) Release() error {
+	var err error
+	l.once.Do(func() {
+		close(l.stopChan)
+		if removeErr := os.Remove(l.path); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = errors.NewFileError("delete", l.path, removeErr)
+		}
+	})
+	return err
+}
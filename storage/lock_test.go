@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInstanceLockRefusesSecondInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+
+	lock, err := AcquireInstanceLock(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := AcquireInstanceLock(path); err == nil {
+		t.Fatal("Expected the second acquisition to fail")
+	} else if running, ok := err.(*AlreadyRunningError); !ok || running.Holder.PID != os.Getpid() {
+		t.Errorf("Expected an already-running error naming this process, got: %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	again, err := AcquireInstanceLock(path)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free after release, got: %v", err)
+	}
+	again.Release()
+}
+
+func TestInstanceLockTakesOverStaleLocks(t *testing.T) {
+	dir := t.TempDir()
+	hostname, _ := os.Hostname()
+
+	writeLock := func(name string, info LockInfo, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		data, _ := json.Marshal(info)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		modified := time.Now().Add(-age)
+		os.Chtimes(path, modified, modified)
+		return path
+	}
+
+	// A process on this machine that no longer exists
+	path := writeLock("exited.lock", LockInfo{PID: 1 << 30, Hostname: hostname}, 0)
+	if lock, err := AcquireInstanceLock(path); err != nil {
+		t.Errorf("Expected the lock of an exited process to be taken over, got: %v", err)
+	} else {
+		lock.Release()
+	}
+
+	// Another machine sharing the data directory: fresh locks hold, abandoned ones do not
+	path = writeLock("remote.lock", LockInfo{PID: 1, Hostname: "other-" + hostname}, 0)
+	if _, err := AcquireInstanceLock(path); err == nil {
+		t.Error("Expected a fresh lock from another machine to be respected")
+	}
+	path = writeLock("abandoned.lock", LockInfo{PID: 1, Hostname: "other-" + hostname}, 2*lockStaleAfter)
+	if lock, err := AcquireInstanceLock(path); err != nil {
+		t.Errorf("Expected an abandoned lock to be taken over, got: %v", err)
+	} else {
+		lock.Release()
+	}
+}
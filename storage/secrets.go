@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// Names of the secrets kept outside settings.json
+const (
+	// SMTPPasswordSecret is the password of the email notification SMTP server
+	SMTPPasswordSecret = "smtp-password"
+	// WakePasscodeSecret is the passcode visitors enter to wake a shared prototype
+	WakePasscodeSecret = "wake-passcode"
+	// FleetTokenSecret is the token shared by the machines of a fleet
+	FleetTokenSecret = "fleet-token"
+	// KioskTokenSecret is the kiosk API's bearer token
+	KioskTokenSecret = "kiosk-token"
+	// ControlAPITokenSecret is the control API's bearer token
+	ControlAPITokenSecret = "control-api-token"
+)
+
+// SecretManager keeps one named secret the way CredentialManager keeps the admin credentials:
+// in the OS keychain, falling back to an encrypted file when the keychain is unavailable
+type SecretManager struct {
+	name string
+	// keychain is nil on platforms without a supported keychain
+	keychain SecretStore
+	fallback SecretStore
+
+	mu sync.Mutex
+}
+
+// NewSecretManager creates a manager for the secret called name
+func NewSecretManager(name string) *SecretManager {
+	fileManager := NewFileManager()
+	return newSecretManager(name,
+		newKeychainStore(filepath.Dir(fileManager.DataFilePath(CredentialsFile)), name),
+		newEncryptedFileStore(fileManager.DataFilePath(name+".enc"), fileManager.DataFilePath(name+".key")))
+}
+
+// newSecretManager creates a secret manager on the given secret stores
+func newSecretManager(name string, keychain, fallback SecretStore) *SecretManager {
+	return &SecretManager{name: name, keychain: keychain, fallback: fallback}
+}
+
+// Get returns the secret, or "" when none is stored
+func (sm *SecretManager) Get() (string, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.keychain != nil {
+		secret, err := sm.keychain.Get()
+		if err != nil {
+			utils.LogWarning(fmt.Sprintf("Failed to read %s from the %s: %v", sm.name, sm.keychain.Name(), err))
+		} else if secret != "" {
+			return secret, nil
+		}
+	}
+
+	secret, err := sm.fallback.Get()
+	if err != nil {
+		return "", errors.WrapWithContext(err, "failed to load %s from the %s", sm.name, sm.fallback.Name())
+	}
+	return secret, nil
+}
+
+// Set stores the secret in the keychain, or the encrypted file when the keychain fails; an
+// empty secret removes it
+func (sm *SecretManager) Set(secret string) error {
+	if secret == "" {
+		return sm.Delete()
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.keychain != nil {
+		err := sm.keychain.Set(secret)
+		if err == nil {
+			// Do not leave an older copy behind in the fallback
+			if err := sm.fallback.Delete(); err != nil {
+				utils.LogWarning(fmt.Sprintf("Failed to remove %s from the %s: %v", sm.name, sm.fallback.Name(), err))
+			}
+			return nil
+		}
+		utils.LogWarning(fmt.Sprintf("Failed to save %s to the %s, using an encrypted file: %v", sm.name, sm.keychain.Name(), err))
+	}
+	if err := sm.fallback.Set(secret); err != nil {
+		return errors.WrapWithContext(err, "failed to save %s to the %s", sm.name, sm.fallback.Name())
+	}
+	return nil
+}
+
+// Delete removes the secret from every backend
+func (sm *SecretManager) Delete() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	multiErr := errors.NewMultiError("clear " + sm.name)
+	for _, store := range []SecretStore{sm.keychain, sm.fallback} {
+		if store == nil {
+			continue
+		}
+		if err := store.Delete(); err != nil {
+			multiErr.Add(errors.WrapWithContext(err, "failed to clear %s from the %s", sm.name, store.Name()))
+		}
+	}
+	return multiErr.ToError()
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	stderrors "errors"
+	"path/filepath"
+	"testing"
+)
+
+// failingStore is a keychain that cannot be written
+type failingStore struct{}
+
+func (failingStore) Name() string            { return "failing keychain" }
+func (failingStore) Get() (string, error)    { return "", nil }
+func (failingStore) Set(secret string) error { return stderrors.New("keychain locked") }
+func (failingStore) Delete() error           { return nil }
+
+func TestSecretManagerFallsBackToEncryptedFile(t *testing.T) {
+	dir := t.TempDir()
+	fallback := newEncryptedFileStore(filepath.Join(dir, SMTPPasswordSecret+".enc"), filepath.Join(dir, SMTPPasswordSecret+".key"))
+	secrets := newSecretManager(SMTPPasswordSecret, failingStore{}, fallback)
+
+	if err := secrets.Set("smtp-s3cret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if secret, err := secrets.Get(); err != nil || secret != "smtp-s3cret" {
+		t.Errorf("Expected the secret from the encrypted file, got %q (err: %v)", secret, err)
+	}
+
+	if err := secrets.Set(""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if secret, err := secrets.Get(); err != nil || secret != "" {
+		t.Errorf("Expected an empty secret to remove it, got %q (err: %v)", secret, err)
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"moodle-prototype-manager/errors"
+)
+
+// Secret storage file names in the data directory
+const (
+	EncryptedCredentialsFile = "credentials.enc"
+	CredentialsKeyFile       = "credentials.key"
+)
+
+// Keychain entry the credentials are stored under
+const (
+	keychainService = "moodle-prototype-manager"
+	keychainAccount = "admin"
+)
+
+// SecretStore keeps a single secret out of plaintext files
+type SecretStore interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Get returns the stored secret, or "" when none is stored
+	Get() (string, error)
+	Set(secret string) error
+	Delete() error
+}
+
+// encryptedFileStore keeps the secret AES-256-GCM encrypted in a file, with the key in a
+// second file readable only by the user. It is the fallback when no OS keychain is usable:
+// it keeps the password out of plain sight and out of copies of the data file alone.
+type encryptedFileStore struct {
+	path    string
+	keyPath string
+}
+
+// newEncryptedFileStore creates a store encrypting into path with the key kept at keyPath
+func newEncryptedFileStore(path, keyPath string) *encryptedFileStore {
+	return &encryptedFileStore{path: path, keyPath: keyPath}
+}
+
+func (s *encryptedFileStore) Name() string {
+	return "encrypted file"
+}
+
+func (s *encryptedFileStore) Get() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.NewFileError("read", s.path, err)
+	}
+
+	key, err := os.ReadFile(s.keyPath)
+	if err != nil {
+		return "", errors.NewFileError("read", s.keyPath, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", errors.NewFileError("parse", s.keyPath, err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.NewFileError("parse", s.path, errors.ErrFileCorrupted)
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.NewFileError("decrypt", s.path, err)
+	}
+	return string(plaintext), nil
+}
+
+func (s *encryptedFileStore) Set(secret string) error {
+	key, err := s.loadOrCreateKey()
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return errors.NewFileError("parse", s.keyPath, err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.WrapWithContext(err, "failed to generate nonce")
+	}
+	data := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return WriteFileAtomic(s.path, data, 0600)
+}
+
+func (s *encryptedFileStore) Delete() error {
+	for _, path := range []string{s.path, s.keyPath} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.NewFileError("delete", path, err)
+		}
+	}
+	return nil
+}
+
+// loadOrCreateKey reads the encryption key, generating it on first use
+func (s *encryptedFileStore) loadOrCreateKey() ([]byte, error) {
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.NewFileError("read", s.keyPath, err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.WrapWithContext(err, "failed to generate credentials key")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0755); err != nil {
+		return nil, errors.NewFileError("create", filepath.Dir(s.keyPath), err)
+	}
+	if err := os.WriteFile(s.keyPath, key, 0600); err != nil {
+		return nil, errors.NewFileError("write", s.keyPath, err)
+	}
+	return key, nil
+}
+
+// newGCM creates the AES-GCM cipher for a 256-bit key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("expected a 32-byte key, got %d bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
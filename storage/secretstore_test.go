@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store := newEncryptedFileStore(filepath.Join(dir, EncryptedCredentialsFile), filepath.Join(dir, CredentialsKeyFile))
+
+	if secret, err := store.Get(); err != nil || secret != "" {
+		t.Fatalf("Expected nothing stored yet, got %q (err: %v)", secret, err)
+	}
+
+	secret := `{"username":"admin","password":"S3cret!","url":"http://localhost:8080"}`
+	if err := store.Set(secret); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if bytes.Contains(data, []byte("S3cret!")) {
+		t.Error("Expected the password to be encrypted on disk")
+	}
+
+	if got, err := store.Get(); err != nil || got != secret {
+		t.Errorf("Expected the secret back, got %q (err: %v)", got, err)
+	}
+
+	// A file encrypted under another key cannot be read
+	if err := os.WriteFile(store.keyPath, bytes.Repeat([]byte{1}, 32), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.Get(); err == nil {
+		t.Error("Expected decryption with the wrong key to fail")
+	}
+
+	if err := store.Delete(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if secret, err := store.Get(); err != nil || secret != "" {
+		t.Errorf("Expected nothing stored after delete, got %q (err: %v)", secret, err)
+	}
+}
@@ -0,0 +1,841 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/i18n"
+	"moodle-prototype-manager/utils"
+)
+
+// Settings defaults
+const (
+	DefaultWakeProxyPort       = 8090
+	DefaultTLSPort             = 8443
+	DefaultKioskAPIPort        = 8095
+	DefaultKioskCheckSecs      = 30
+	DefaultControlAPIPort      = 8096
+	DefaultFleetAPIPort        = 8097
+	DefaultPrePullStartHour    = 1
+	DefaultPrePullEndHour      = 5
+	DefaultCronIntervalMinutes = 5
+	MinCronIntervalMinutes     = 1
+	DefaultMemoryAlertPercent  = 90
+	DefaultDiskAlertPercent    = 95
+	DefaultStatsIntervalSecs   = 30
+	DefaultStopTimeoutSeconds  = 10
+	MaxStopTimeoutSeconds      = 300
+	DefaultLogScanTailLines    = 5000
+	MaxLogScanTailLines        = 1000000
+	DefaultLogScanSinceMinutes = 24 * 60
+	DefaultMaxInstallMinutes   = 90
+	DefaultIdleStopMinutes     = 60
+	MinIdleStopMinutes         = 5
+	DefaultHealthMonitorSecs   = 60
+	MinHealthMonitorSecs       = 10
+	// MaxSiteNameLength matches Moodle's course fullname and shortname columns
+	MaxSiteNameLength = 254
+)
+
+// CronSettings controls the background Moodle cron scheduler
+type CronSettings struct {
+	Enabled         bool `json:"enabled"`
+	IntervalMinutes int  `json:"intervalMinutes"`
+}
+
+// FleetSettings makes this instance a member of a fleet of lab machines, which any member can
+// discover and control
+type FleetSettings struct {
+	Enabled bool `json:"enabled"`
+	// TokenConfigured is set while the token shared by every machine in the fleet is in the
+	// secret store (FleetTokenSecret)
+	TokenConfigured bool `json:"tokenConfigured"`
+	// Token is only read from files written by earlier versions; it is moved on startup
+	Token string `json:"token,omitempty"`
+	// APIPort is where the member API listens on the LAN; it is advertised over mDNS
+	APIPort int `json:"apiPort"`
+}
+
+// SharingSettings controls sharing the prototype with colleagues on the LAN
+type SharingSettings struct {
+	Enabled bool `json:"enabled"`
+	// Name is the advertised instance name; defaults to the host name
+	Name string `json:"name"`
+	// WakeProxyEnabled serves the shared URL from the manager so visitors can wake a stopped prototype
+	WakeProxyEnabled bool `json:"wakeProxyEnabled"`
+	WakeProxyPort    int  `json:"wakeProxyPort"`
+	// WakePasscode must be entered by visitors to start the prototype; empty disables remote
+	// wake-up. It is kept in the secret store (WakePasscodeSecret); earlier versions saved it
+	// here and it is moved on startup.
+	WakePasscode string `json:"wakePasscode,omitempty"`
+}
+
+// AlertSettings controls resource usage alerts for the running container
+type AlertSettings struct {
+	Enabled         bool    `json:"enabled"`
+	MemoryPercent   float64 `json:"memoryPercent"`
+	DiskPercent     float64 `json:"diskPercent"`
+	IntervalSeconds int     `json:"intervalSeconds"`
+}
+
+// MemorySettings controls the container memory limit and OOM handling
+type MemorySettings struct {
+	// LimitMB caps container memory; 0 leaves it to Docker
+	LimitMB int `json:"limitMB"`
+	// AutoAdjustOnOOM raises the limit automatically after an OOM kill instead of asking
+	AutoAdjustOnOOM bool `json:"autoAdjustOnOOM"`
+}
+
+// LoggingSettings selects container log verbosity; empty values keep the image defaults
+type LoggingSettings struct {
+	ApacheLogLevel string `json:"apacheLogLevel"`
+	PHPErrorLevel  string `json:"phpErrorLevel"`
+	MoodleDebug    string `json:"moodleDebug"`
+}
+
+// TLSSettings controls serving the prototype over HTTPS through the bundled reverse proxy
+type TLSSettings struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+}
+
+// MailSettings controls the mail catcher sidecar that captures Moodle's outgoing mail
+type MailSettings struct {
+	Enabled bool `json:"enabled"`
+	UIPort  int  `json:"uiPort"`
+}
+
+// AdminerSettings controls the database admin sidecar run alongside Moodle
+type AdminerSettings struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+}
+
+// PrePullSettings controls downloading new image builds overnight
+type PrePullSettings struct {
+	Enabled bool `json:"enabled"`
+	// StartHour and EndHour bound the local-time window; the window may span midnight
+	StartHour int `json:"startHour"`
+	EndHour   int `json:"endHour"`
+}
+
+// ProxySettings configures an HTTP(S) proxy; empty values fall back to HTTP(S)_PROXY
+type ProxySettings struct {
+	HTTPProxy  string `json:"httpProxy"`
+	HTTPSProxy string `json:"httpsProxy"`
+	// NoProxy is a comma-separated list of hosts or domains that bypass the proxy
+	NoProxy string `json:"noProxy"`
+}
+
+// DockerHostSettings selects a remote Docker daemon; empty values use DOCKER_HOST/DOCKER_CONTEXT
+type DockerHostSettings struct {
+	// Host is a daemon address such as tcp://build-box:2376 or ssh://user@build-box
+	Host string `json:"host"`
+	// Context is a docker context name, used instead of Host
+	Context   string `json:"context"`
+	TLSVerify bool   `json:"tlsVerify"`
+	// CertPath is the directory holding ca.pem, cert.pem and key.pem
+	CertPath string `json:"certPath"`
+}
+
+// KioskSettings runs the prototype unattended: started automatically, restarted after crashes
+// and controlled through a minimal local API (status, start, open-url)
+type KioskSettings struct {
+	Enabled bool `json:"enabled"`
+	APIPort int  `json:"apiPort"`
+	// TokenConfigured is set while the kiosk API's bearer token is in the secret store
+	// (KioskTokenSecret); the token is generated when kiosk mode is first enabled
+	TokenConfigured bool `json:"tokenConfigured"`
+	// Token is only read from files written by earlier versions; it is moved on startup
+	Token string `json:"token,omitempty"`
+	// CheckIntervalSeconds is how often the watchdog checks that Moodle is up
+	CheckIntervalSeconds int `json:"checkIntervalSeconds"`
+}
+
+// ControlAPISettings exposes the manager's operations on 127.0.0.1 so other tools, e.g. test
+// harnesses, can provision and tear down Moodle
+type ControlAPISettings struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+	// TokenConfigured is set while the API's bearer token is in the secret store
+	// (ControlAPITokenSecret); the token is generated when the API is first enabled
+	TokenConfigured bool `json:"tokenConfigured"`
+	// Token is only read from files written by earlier versions; it is moved on startup
+	Token string `json:"token,omitempty"`
+}
+
+// TelemetrySettings opts in to anonymous usage and crash reports
+type TelemetrySettings struct {
+	Enabled bool `json:"enabled"`
+	// Endpoint receives the reports as JSON posts
+	Endpoint string `json:"endpoint"`
+	// InstallID groups the reports of this installation; it is random, not derived from the
+	// user or machine
+	InstallID string `json:"installId,omitempty"`
+}
+
+// LogScanSettings bounds the container log the credential scanner reads when it starts; later
+// polls read only new output. The defaults cover a first-run install, which can take 30+ minutes on Windows, without rescanning
+// months of output on adopted containers.
+type LogScanSettings struct {
+	// TailLines is how many of the latest log lines are scanned
+	TailLines int `json:"tailLines"`
+	// SinceMinutes ignores output logged that many minutes before scanning started; 0 scans all
+	SinceMinutes int `json:"sinceMinutes"`
+	// MaxInstallMinutes is how long a first-run install may take before it is reported as
+	// failed; 0 waits indefinitely
+	MaxInstallMinutes int `json:"maxInstallMinutes"`
+}
+
+// IdleStopSettings stops the container when nobody has used Moodle for a while, freeing
+// laptop memory and battery
+type IdleStopSettings struct {
+	Enabled bool `json:"enabled"`
+	// Minutes without HTTP traffic before the container is stopped
+	Minutes int `json:"minutes"`
+}
+
+// What happens to a container whose first-run install failed
+const (
+	// InstallCleanupRemove deletes a container created for the failed install, with its
+	// volumes; the installer's log is saved first
+	InstallCleanupRemove = "remove"
+	// InstallCleanupKeep leaves the container for inspection. It is the default.
+	InstallCleanupKeep = "keep"
+)
+
+// What happens to a running container when the app quits
+const (
+	// ExitActionStop stops Moodle with the app
+	ExitActionStop = "stop"
+	// ExitActionKeep leaves Moodle running; the next launch picks it up again
+	ExitActionKeep = "keep"
+	// ExitActionAsk asks in the quit dialog
+	ExitActionAsk = "ask"
+)
+
+// InstallCleanupSettings decides what happens to a container whose first-run install failed
+type InstallCleanupSettings struct {
+	Policy string `json:"policy"`
+}
+
+// HealthMonitorSettings controls the background Docker and connectivity checks
+type HealthMonitorSettings struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"intervalSeconds"`
+}
+
+// SiteSettings names the Moodle site and its admin account; empty values keep what the
+// image's installer chose
+type SiteSettings struct {
+	FullName   string `json:"fullName,omitempty"`
+	ShortName  string `json:"shortName,omitempty"`
+	AdminEmail string `json:"adminEmail,omitempty"`
+}
+
+// BrowserSettings selects the browser OpenBrowser launches, e.g. a clean profile or a private
+// window for demos where extensions and cached logins get in the way
+type BrowserSettings struct {
+	// Browser is default, chrome, firefox, edge or safari
+	Browser string `json:"browser"`
+	// Profile is a Chrome/Edge profile directory or a Firefox profile name
+	Profile string `json:"profile,omitempty"`
+	Private bool   `json:"private"`
+	// Path overrides the browser executable
+	Path string `json:"path,omitempty"`
+}
+
+// SMTPSettings is the mail server used for email notifications
+type SMTPSettings struct {
+	Host string `json:"host"`
+	// Port 0 uses 587
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	// Password is only passed to and from the frontend; it is kept in the secret store
+	// (SMTPPasswordSecret). Earlier versions saved it here and it is moved on startup.
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// NotificationSettings selects where notifications of each category are delivered
+type NotificationSettings struct {
+	// Routes maps a category (alert, container, update) to sink names (desktop, webhook, email, none)
+	Routes     map[string][]string `json:"routes"`
+	WebhookURL string              `json:"webhookURL"`
+	SMTP       SMTPSettings        `json:"smtp"`
+}
+
+// Settings holds user-configurable application settings
+type Settings struct {
+	// SelectedImages maps an instance name to the catalog image chosen for it, which overrides
+	// image.docker for that instance; use SelectedImage and SelectImage
+	SelectedImages map[string]string `json:"selectedImages,omitempty"`
+	// LegacySelectedImage is the one selection earlier versions kept for every instance; it is
+	// moved to the instance in use when the settings are loaded
+	LegacySelectedImage string `json:"selectedImage,omitempty"`
+
+	HostPort int             `json:"hostPort"`
+	Cron     CronSettings    `json:"cron"`
+	Fleet    FleetSettings   `json:"fleet"`
+	Sharing  SharingSettings `json:"sharing"`
+	Proxy    ProxySettings   `json:"proxy"`
+	Alerts   AlertSettings   `json:"alerts"`
+	Memory   MemorySettings  `json:"memory"`
+	Logging  LoggingSettings `json:"logging"`
+	// InstanceName names the container (moodle-prototype-<instance>); empty uses the OS user
+	InstanceName string      `json:"instanceName,omitempty"`
+	TLS          TLSSettings `json:"tls"`
+	// StopTimeoutSeconds is how long Moodle gets to shut down before it is killed
+	StopTimeoutSeconds int `json:"stopTimeoutSeconds"`
+	// AutoRestart keeps Moodle running across Docker Desktop restarts (restart policy unless-stopped)
+	AutoRestart bool `json:"autoRestart"`
+	// ExitAction is stop, keep or ask: whether a running container is stopped when the app quits
+	ExitAction string `json:"exitAction"`
+	// Hostname is a friendly name such as moodle.local mapped in the hosts file; empty uses localhost
+	Hostname string `json:"hostname,omitempty"`
+	// Language of notifications, progress labels and error suggestions; empty follows the
+	// system locale
+	Language string `json:"language,omitempty"`
+	// Mail routes Moodle's outgoing mail to a local mail catcher
+	Mail MailSettings `json:"mail"`
+	// PrePull downloads new image builds at night on AC power and unmetered networks
+	PrePull PrePullSettings `json:"prePull"`
+	// Adminer runs a database admin UI next to Moodle
+	Adminer AdminerSettings `json:"adminer"`
+	// QuotasMB caps the disk used by each workspace (instance name to megabytes); missing means unlimited
+	QuotasMB map[string]int `json:"quotasMB,omitempty"`
+	// DockerHost manages Moodle on a remote Docker daemon
+	DockerHost DockerHostSettings `json:"dockerHost"`
+	// Notifications routes alerts and other events to desktop, webhook or email sinks
+	Notifications NotificationSettings `json:"notifications"`
+	// Kiosk runs the prototype unattended, e.g. on an exhibition booth PC
+	Kiosk KioskSettings `json:"kiosk"`
+	// ControlAPI lets local tools drive the manager over HTTP
+	ControlAPI ControlAPISettings `json:"controlAPI"`
+	// Telemetry sends anonymous usage and crash reports when the user opts in
+	Telemetry TelemetrySettings `json:"telemetry"`
+	// LogScan bounds how much container log the credential scanner considers
+	LogScan LogScanSettings `json:"logScan"`
+	// Browser selects the browser, profile and private mode used to open Moodle
+	Browser BrowserSettings `json:"browser"`
+	// IdleStop stops Moodle after a period without traffic
+	IdleStop IdleStopSettings `json:"idleStop"`
+	// HealthMonitor notices Docker or the network going away mid-session
+	HealthMonitor HealthMonitorSettings `json:"healthMonitor"`
+	// InstallCleanup can remove half-provisioned containers after a failed install
+	InstallCleanup InstallCleanupSettings `json:"installCleanup"`
+	// Site is applied to Moodle when its install finishes
+	Site SiteSettings `json:"site"`
+	// ContainerEnv adds environment variables to new Moodle containers
+	ContainerEnv map[string]string `json:"containerEnv,omitempty"`
+	// SeedOnInstall seeds demo data of this size (S, M or L) when an install finishes
+	SeedOnInstall string `json:"seedOnInstall,omitempty"`
+	// Templates are saved instance configurations new instances can be created from
+	Templates []InstanceTemplate `json:"templates,omitempty"`
+	// Stamp records the app version that wrote the file
+	Stamp StateStamp `json:"stamp"`
+}
+
+// DefaultSettings returns the settings used when no settings file exists
+func DefaultSettings() *Settings {
+	return &Settings{
+		HostPort:           docker.PreferredHostPort(docker.CurrentUser()),
+		StopTimeoutSeconds: DefaultStopTimeoutSeconds,
+		ExitAction:         ExitActionAsk,
+		Sharing: SharingSettings{
+			WakeProxyPort: DefaultWakeProxyPort,
+		},
+		Mail: MailSettings{
+			UIPort: docker.DefaultMailUIPort,
+		},
+		Adminer: AdminerSettings{
+			Port: docker.DefaultAdminerPort,
+		},
+		Kiosk: KioskSettings{
+			APIPort:              DefaultKioskAPIPort,
+			CheckIntervalSeconds: DefaultKioskCheckSecs,
+		},
+		ControlAPI: ControlAPISettings{
+			Port: DefaultControlAPIPort,
+		},
+		Fleet: FleetSettings{
+			APIPort: DefaultFleetAPIPort,
+		},
+		LogScan: LogScanSettings{
+			TailLines:         DefaultLogScanTailLines,
+			SinceMinutes:      DefaultLogScanSinceMinutes,
+			MaxInstallMinutes: DefaultMaxInstallMinutes,
+		},
+		Browser: BrowserSettings{
+			Browser: utils.BrowserDefault,
+		},
+		IdleStop: IdleStopSettings{
+			Minutes: DefaultIdleStopMinutes,
+		},
+		HealthMonitor: HealthMonitorSettings{
+			Enabled:         true,
+			IntervalSeconds: DefaultHealthMonitorSecs,
+		},
+		InstallCleanup: InstallCleanupSettings{
+			Policy: InstallCleanupKeep,
+		},
+		PrePull: PrePullSettings{
+			StartHour: DefaultPrePullStartHour,
+			EndHour:   DefaultPrePullEndHour,
+		},
+		TLS: TLSSettings{
+			Port: DefaultTLSPort,
+		},
+		Cron: CronSettings{
+			Enabled:         true,
+			IntervalMinutes: DefaultCronIntervalMinutes,
+		},
+		Alerts: AlertSettings{
+			Enabled:         true,
+			MemoryPercent:   DefaultMemoryAlertPercent,
+			DiskPercent:     DefaultDiskAlertPercent,
+			IntervalSeconds: DefaultStatsIntervalSecs,
+		},
+		Notifications: NotificationSettings{
+			Routes: map[string][]string{
+				"alert":     {"desktop"},
+				"container": {"desktop"},
+				"update":    {"none"},
+			},
+		},
+	}
+}
+
+// PortableSettings are the settings that shape an instance rather than this machine, carried
+// in instance archives so a shared prototype behaves the same for its recipient
+type PortableSettings struct {
+	Memory      MemorySettings  `json:"memory"`
+	Logging     LoggingSettings `json:"logging"`
+	Cron        CronSettings    `json:"cron"`
+	Site        SiteSettings    `json:"site"`
+	AutoRestart bool            `json:"autoRestart"`
+}
+
+// Portable returns the settings carried in an instance archive
+func (s *Settings) Portable() PortableSettings {
+	return PortableSettings{
+		Memory:      s.Memory,
+		Logging:     s.Logging,
+		Cron:        s.Cron,
+		Site:        s.Site,
+		AutoRestart: s.AutoRestart,
+	}
+}
+
+// ApplyPortable replaces the portable settings with those from an instance archive
+func (s *Settings) ApplyPortable(portable PortableSettings) {
+	s.Memory = portable.Memory
+	s.Logging = portable.Logging
+	s.Cron = portable.Cron
+	s.Site = portable.Site
+	s.AutoRestart = portable.AutoRestart
+}
+
+// SelectedImage returns the catalog image chosen for the current instance, or "" when it uses
+// image.docker
+func (s *Settings) SelectedImage() string {
+	return s.SelectedImages[s.InstanceName]
+}
+
+// SelectImage chooses image for the current instance, normalised so that references to the
+// same image are stored alike; an empty image returns the instance to image.docker
+func (s *Settings) SelectImage(image string) {
+	// Copy rather than modify a map shared with an earlier copy of the settings
+	images := make(map[string]string, len(s.SelectedImages)+1)
+	for instance, selected := range s.SelectedImages {
+		images[instance] = selected
+	}
+	if image == "" {
+		delete(images, s.InstanceName)
+	} else {
+		images[s.InstanceName] = docker.NormalizeImageReference(image)
+	}
+	s.SelectedImages = images
+}
+
+// Validate checks settings values for consistency
+func (s *Settings) Validate() error {
+	if s == nil {
+		return errors.NewValidationError("settings", "settings object is nil", nil)
+	}
+
+	multiErr := errors.NewMultiError("settings validation")
+
+	for _, image := range s.SelectedImages {
+		if err := errors.ValidateImageName(image); err != nil {
+			multiErr.Add(err)
+		}
+	}
+
+	if s.HostPort < 1 || s.HostPort > 65535 {
+		multiErr.Add(errors.NewValidationError("hostPort", "port must be between 1 and 65535", s.HostPort))
+	}
+
+	if s.Fleet.Enabled {
+		if !s.Fleet.TokenConfigured && s.Fleet.Token == "" {
+			multiErr.Add(errors.NewValidationError("fleet.token", "a shared token is required when fleet mode is enabled", nil))
+		}
+		if s.Fleet.APIPort < 1 || s.Fleet.APIPort > 65535 {
+			multiErr.Add(errors.NewValidationError("fleet.apiPort", "port must be between 1 and 65535", s.Fleet.APIPort))
+		} else if s.Fleet.APIPort == s.HostPort {
+			multiErr.Add(errors.NewValidationError("fleet.apiPort", "fleet API port must differ from the Moodle host port", s.Fleet.APIPort))
+		}
+	}
+
+	if s.Sharing.WakeProxyEnabled {
+		if s.Sharing.WakeProxyPort < 1 || s.Sharing.WakeProxyPort > 65535 {
+			multiErr.Add(errors.NewValidationError("sharing.wakeProxyPort", "port must be between 1 and 65535", s.Sharing.WakeProxyPort))
+		} else if s.Sharing.WakeProxyPort == s.HostPort {
+			multiErr.Add(errors.NewValidationError("sharing.wakeProxyPort", "wake proxy port must differ from the Moodle host port", s.Sharing.WakeProxyPort))
+		}
+	}
+
+	for field, value := range map[string]string{"proxy.httpProxy": s.Proxy.HTTPProxy, "proxy.httpsProxy": s.Proxy.HTTPSProxy} {
+		if value == "" {
+			continue
+		}
+		if parsed, err := url.Parse(value); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			multiErr.Add(errors.NewValidationError(field, "must be a URL such as http://proxy:3128", value))
+		}
+	}
+
+	if s.Alerts.MemoryPercent < 0 || s.Alerts.MemoryPercent > 100 {
+		multiErr.Add(errors.NewValidationError("alerts.memoryPercent", "threshold must be between 0 and 100", s.Alerts.MemoryPercent))
+	}
+	if s.Alerts.DiskPercent < 0 || s.Alerts.DiskPercent > 100 {
+		multiErr.Add(errors.NewValidationError("alerts.diskPercent", "threshold must be between 0 and 100", s.Alerts.DiskPercent))
+	}
+	if s.Alerts.Enabled && s.Alerts.IntervalSeconds < 5 {
+		multiErr.Add(errors.NewValidationError("alerts.intervalSeconds", "interval must be at least 5 seconds", s.Alerts.IntervalSeconds))
+	}
+
+	if s.TLS.Enabled {
+		if s.TLS.Port < 1 || s.TLS.Port > 65535 {
+			multiErr.Add(errors.NewValidationError("tls.port", "port must be between 1 and 65535", s.TLS.Port))
+		} else if s.TLS.Port == s.HostPort {
+			multiErr.Add(errors.NewValidationError("tls.port", "HTTPS port must differ from the Moodle host port", s.TLS.Port))
+		}
+	}
+
+	if s.StopTimeoutSeconds < 1 || s.StopTimeoutSeconds > MaxStopTimeoutSeconds {
+		multiErr.Add(errors.NewValidationError("stopTimeoutSeconds", "timeout must be between 1 and 300 seconds", s.StopTimeoutSeconds))
+	}
+
+	if s.Mail.Enabled {
+		if s.Mail.UIPort < 1 || s.Mail.UIPort > 65535 {
+			multiErr.Add(errors.NewValidationError("mail.uiPort", "port must be between 1 and 65535", s.Mail.UIPort))
+		} else if s.Mail.UIPort == s.HostPort {
+			multiErr.Add(errors.NewValidationError("mail.uiPort", "mail UI port must differ from the Moodle host port", s.Mail.UIPort))
+		}
+	}
+
+	if s.Adminer.Enabled {
+		if s.Adminer.Port < 1 || s.Adminer.Port > 65535 {
+			multiErr.Add(errors.NewValidationError("adminer.port", "port must be between 1 and 65535", s.Adminer.Port))
+		} else if s.Adminer.Port == s.HostPort || (s.Mail.Enabled && s.Adminer.Port == s.Mail.UIPort) {
+			multiErr.Add(errors.NewValidationError("adminer.port", "Adminer port must differ from the Moodle and mail UI ports", s.Adminer.Port))
+		}
+	}
+
+	if s.Kiosk.Enabled {
+		if s.Kiosk.APIPort < 1 || s.Kiosk.APIPort > 65535 {
+			multiErr.Add(errors.NewValidationError("kiosk.apiPort", "port must be between 1 and 65535", s.Kiosk.APIPort))
+		} else if s.Kiosk.APIPort == s.HostPort {
+			multiErr.Add(errors.NewValidationError("kiosk.apiPort", "kiosk API port must differ from the Moodle host port", s.Kiosk.APIPort))
+		}
+		if s.Kiosk.CheckIntervalSeconds < 5 {
+			multiErr.Add(errors.NewValidationError("kiosk.checkIntervalSeconds", "interval must be at least 5 seconds", s.Kiosk.CheckIntervalSeconds))
+		}
+	}
+
+	if s.ControlAPI.Enabled {
+		if s.ControlAPI.Port < 1 || s.ControlAPI.Port > 65535 {
+			multiErr.Add(errors.NewValidationError("controlAPI.port", "port must be between 1 and 65535", s.ControlAPI.Port))
+		} else if s.ControlAPI.Port == s.HostPort || (s.Kiosk.Enabled && s.ControlAPI.Port == s.Kiosk.APIPort) {
+			multiErr.Add(errors.NewValidationError("controlAPI.port", "control API port must differ from the Moodle and kiosk API ports", s.ControlAPI.Port))
+		}
+		if !s.ControlAPI.TokenConfigured && s.ControlAPI.Token == "" {
+			multiErr.Add(errors.NewValidationError("controlAPI.token", "a token is required when the control API is enabled", nil))
+		}
+	}
+
+	if s.Telemetry.Enabled {
+		if parsed, err := url.Parse(s.Telemetry.Endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			multiErr.Add(errors.NewValidationError("telemetry.endpoint", "must be an http(s) URL when telemetry is enabled", s.Telemetry.Endpoint))
+		}
+	}
+
+	if s.LogScan.TailLines < 1 || s.LogScan.TailLines > MaxLogScanTailLines {
+		multiErr.Add(errors.NewValidationError("logScan.tailLines", "tail must be between 1 and 1000000 lines", s.LogScan.TailLines))
+	}
+	if s.LogScan.SinceMinutes < 0 {
+		multiErr.Add(errors.NewValidationError("logScan.sinceMinutes", "window cannot be negative", s.LogScan.SinceMinutes))
+	}
+	if s.LogScan.MaxInstallMinutes < 0 {
+		multiErr.Add(errors.NewValidationError("logScan.maxInstallMinutes", "maximum install time cannot be negative", s.LogScan.MaxInstallMinutes))
+	}
+
+	if !slices.Contains(utils.SupportedBrowsers, s.Browser.Browser) {
+		multiErr.Add(errors.NewValidationError("browser.browser", "must be default, chrome, firefox, edge or safari", s.Browser.Browser))
+	} else if s.Browser.Private || s.Browser.Profile != "" {
+		switch s.Browser.Browser {
+		case utils.BrowserDefault:
+			multiErr.Add(errors.NewValidationError("browser", "choose a browser to use a profile or private window", s.Browser.Browser))
+		case utils.BrowserSafari:
+			multiErr.Add(errors.NewValidationError("browser", "Safari cannot be opened with a profile or private window", s.Browser.Browser))
+		}
+	}
+
+	if s.IdleStop.Enabled && s.IdleStop.Minutes < MinIdleStopMinutes {
+		multiErr.Add(errors.NewValidationError("idleStop.minutes", fmt.Sprintf("must be at least %d minutes", MinIdleStopMinutes), s.IdleStop.Minutes))
+	}
+	if s.HealthMonitor.Enabled && s.HealthMonitor.IntervalSeconds < MinHealthMonitorSecs {
+		multiErr.Add(errors.NewValidationError("healthMonitor.intervalSeconds", fmt.Sprintf("interval must be at least %d seconds", MinHealthMonitorSecs), s.HealthMonitor.IntervalSeconds))
+	}
+	if s.InstallCleanup.Policy != InstallCleanupRemove && s.InstallCleanup.Policy != InstallCleanupKeep {
+		multiErr.Add(errors.NewValidationError("installCleanup.policy", "must be remove or keep", s.InstallCleanup.Policy))
+	}
+	if s.ExitAction != ExitActionStop && s.ExitAction != ExitActionKeep && s.ExitAction != ExitActionAsk {
+		multiErr.Add(errors.NewValidationError("exitAction", "must be stop, keep or ask", s.ExitAction))
+	}
+
+	if len(s.Site.FullName) > MaxSiteNameLength {
+		multiErr.Add(errors.NewValidationError("site.fullName", fmt.Sprintf("must be at most %d characters", MaxSiteNameLength), s.Site.FullName))
+	}
+	if len(s.Site.ShortName) > MaxSiteNameLength {
+		multiErr.Add(errors.NewValidationError("site.shortName", fmt.Sprintf("must be at most %d characters", MaxSiteNameLength), s.Site.ShortName))
+	}
+	if s.Site.AdminEmail != "" {
+		if address, err := mail.ParseAddress(s.Site.AdminEmail); err != nil || address.Address != s.Site.AdminEmail {
+			multiErr.Add(errors.NewValidationError("site.adminEmail", "must be an email address such as admin@example.com", s.Site.AdminEmail))
+		}
+	}
+
+	if s.PrePull.StartHour < 0 || s.PrePull.StartHour > 23 || s.PrePull.EndHour < 0 || s.PrePull.EndHour > 23 {
+		multiErr.Add(errors.NewValidationError("prePull", "window hours must be between 0 and 23", fmt.Sprintf("%d-%d", s.PrePull.StartHour, s.PrePull.EndHour)))
+	} else if s.PrePull.StartHour == s.PrePull.EndHour {
+		multiErr.Add(errors.NewValidationError("prePull", "window start and end must differ", s.PrePull.StartHour))
+	}
+
+	if s.Language != "" && !i18n.IsSupported(s.Language) {
+		multiErr.Add(errors.NewValidationError("language", "language must be one of "+strings.Join(i18n.Supported(), ", "), s.Language))
+	}
+	if s.Hostname != "" {
+		if err := ValidateHostname(s.Hostname); err != nil {
+			multiErr.Add(err)
+		}
+	}
+
+	if s.Memory.LimitMB < 0 {
+		multiErr.Add(errors.NewValidationError("memory.limitMB", "limit cannot be negative", s.Memory.LimitMB))
+	}
+
+	for instance, quotaMB := range s.QuotasMB {
+		if quotaMB < 0 {
+			multiErr.Add(errors.NewValidationError("quotasMB."+instance, "quota cannot be negative", quotaMB))
+		}
+	}
+
+	if s.DockerHost.Host != "" {
+		if s.DockerHost.Context != "" {
+			multiErr.Add(errors.NewValidationError("dockerHost", "set either a Docker host or a context, not both", s.DockerHost.Context))
+		}
+		if parsed, err := url.Parse(s.DockerHost.Host); err != nil || !validDockerHostSchemes[parsed.Scheme] {
+			multiErr.Add(errors.NewValidationError("dockerHost.host", "must be an address such as tcp://host:2376 or ssh://user@host", s.DockerHost.Host))
+		}
+	}
+
+	routed := make(map[string]bool)
+	for category, sinks := range s.Notifications.Routes {
+		if !validNotificationCategories[category] {
+			multiErr.Add(errors.NewValidationError("notifications.routes", "unknown notification category", category))
+		}
+		for _, sink := range sinks {
+			if !validNotificationSinks[sink] {
+				multiErr.Add(errors.NewValidationError("notifications.routes."+category, "unknown notification sink", sink))
+			}
+			routed[sink] = true
+		}
+	}
+	if routed["webhook"] {
+		if parsed, err := url.Parse(s.Notifications.WebhookURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			multiErr.Add(errors.NewValidationError("notifications.webhookURL", "must be an http(s) URL when the webhook sink is used", s.Notifications.WebhookURL))
+		}
+	}
+	if routed["email"] {
+		smtp := s.Notifications.SMTP
+		if smtp.Host == "" || smtp.From == "" || len(smtp.To) == 0 {
+			multiErr.Add(errors.NewValidationError("notifications.smtp", "host, sender and recipients are required when the email sink is used", nil))
+		}
+		// 0 lets the email sink use the submission port, 587
+		if smtp.Port != 0 && (smtp.Port < 1 || smtp.Port > 65535) {
+			multiErr.Add(errors.NewValidationError("notifications.smtp.port", "port must be between 1 and 65535, or 0 for the default", smtp.Port))
+		}
+	}
+
+	if s.Cron.IntervalMinutes < MinCronIntervalMinutes {
+		multiErr.Add(errors.NewValidationError("cron.intervalMinutes", "interval must be at least 1 minute", s.Cron.IntervalMinutes))
+	}
+
+	s.validateTemplates(multiErr)
+
+	return multiErr.ToError()
+}
+
+// validDockerHostSchemes lists the DOCKER_HOST schemes the docker CLI accepts
+var validDockerHostSchemes = map[string]bool{"tcp": true, "ssh": true, "unix": true, "npipe": true}
+
+// validNotificationCategories and validNotificationSinks list the names accepted in notification routes
+var (
+	validNotificationCategories = map[string]bool{"alert": true, "container": true, "update": true}
+	validNotificationSinks      = map[string]bool{"desktop": true, "webhook": true, "email": true, "none": true}
+)
+
+// hostnameRegex matches DNS names of one or more dot-separated labels
+var hostnameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// ValidateHostname checks that hostname is usable as a hosts-file entry and Moodle wwwroot
+func ValidateHostname(hostname string) error {
+	if len(hostname) > 253 || !hostnameRegex.MatchString(hostname) {
+		return errors.NewValidationError("hostname", "must be a valid hostname such as moodle.local", hostname)
+	}
+	if hostname == "localhost" {
+		return errors.NewValidationError("hostname", "localhost is already used by default", hostname)
+	}
+	return nil
+}
+
+// SettingsManager handles loading and saving application settings
+type SettingsManager struct {
+	fileManager *FileManager
+	mu          sync.Mutex
+}
+
+// NewSettingsManager creates a new settings manager
+func NewSettingsManager() *SettingsManager {
+	return &SettingsManager{
+		fileManager: NewFileManager(),
+	}
+}
+
+// Load reads settings from file, falling back to defaults on first run
+func (sm *SettingsManager) Load() (*Settings, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	return sm.load()
+}
+
+// Save validates and writes settings to file
+func (sm *SettingsManager) Save(settings *Settings) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	return sm.save(settings)
+}
+
+// Update loads the current settings, applies fn and saves the result
+func (sm *SettingsManager) Update(fn func(*Settings)) (*Settings, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	settings, err := sm.load()
+	if err != nil {
+		return nil, err
+	}
+
+	fn(settings)
+
+	if err := sm.save(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// load reads settings without locking
+func (sm *SettingsManager) load() (*Settings, error) {
+	if !sm.fileManager.SettingsExist() {
+		return DefaultSettings(), nil
+	}
+
+	data, err := sm.fileManager.LoadSettings()
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load settings from file")
+	}
+
+	// Start from defaults so fields missing from older files keep sensible values
+	settings := DefaultSettings()
+	if err := json.Unmarshal(data, settings); err != nil {
+		// A newer layout may fail to parse; report the version mismatch rather than a corrupt file
+		if stampErr := checkSettingsStamp(data); stampErr != nil {
+			return nil, stampErr
+		}
+		return nil, errors.WrapWithContext(errors.ErrConfigInvalid, "failed to parse settings file: %v", err)
+	}
+	if err := checkStamp(SettingsFile, settings.Stamp); err != nil {
+		return nil, err
+	}
+	if settings.LegacySelectedImage != "" {
+		if settings.SelectedImage() == "" {
+			settings.SelectImage(settings.LegacySelectedImage)
+		}
+		settings.LegacySelectedImage = ""
+	}
+
+	return settings, nil
+}
+
+// save writes settings without locking
+func (sm *SettingsManager) save(settings *Settings) error {
+	if err := settings.Validate(); err != nil {
+		return errors.WrapWithContext(err, "refusing to save invalid settings")
+	}
+
+	// Never overwrite settings from a newer version, even when the caller fell back to defaults
+	if sm.fileManager.SettingsExist() {
+		if existing, err := sm.fileManager.LoadSettings(); err == nil {
+			if err := checkSettingsStamp(existing); err != nil {
+				return errors.WrapWithContext(err, "refusing to overwrite settings")
+			}
+		}
+	}
+
+	settings.Stamp = currentStamp()
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode settings")
+	}
+
+	if err := sm.fileManager.SaveSettings(data); err != nil {
+		return errors.WrapWithContext(err, "failed to save settings to file")
+	}
+	return nil
+}
+
+// checkSettingsStamp checks only the stamp of an encoded settings file
+func checkSettingsStamp(data []byte) error {
+	var stamped struct {
+		Stamp StateStamp `json:"stamp"`
+	}
+	if err := json.Unmarshal(data, &stamped); err != nil {
+		return nil
+	}
+	if stamped.Stamp.FormatVersion > StateFormatVersion {
+		return &StateVersionError{File: SettingsFile, Stamp: stamped.Stamp}
+	}
+	return nil
+}
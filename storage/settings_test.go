@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	settings := DefaultSettings()
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("Default settings should be valid, got: %v", err)
+	}
+
+	settings.Cron.IntervalMinutes = 0
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for zero cron interval")
+	}
+
+	settings = DefaultSettings()
+	settings.Proxy.HTTPSProxy = "proxy.corp:3128"
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for proxy without scheme")
+	}
+
+	settings = DefaultSettings()
+	settings.Notifications.Routes["alert"] = []string{"webhook"}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for webhook route without a URL")
+	}
+	settings.Notifications.WebhookURL = "https://hooks.example.com/moodle"
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Expected webhook route with a URL to be valid, got: %v", err)
+	}
+
+	settings = DefaultSettings()
+	settings.Notifications.Routes["alert"] = []string{"email"}
+	settings.Notifications.SMTP = SMTPSettings{Host: "smtp.example.com", From: "moodle@example.com", To: []string{"ops@example.com"}}
+	for _, port := range []int{0, 1, 65535} {
+		settings.Notifications.SMTP.Port = port
+		if err := settings.Validate(); err != nil {
+			t.Errorf("Expected SMTP port %d to be valid, got: %v", port, err)
+		}
+	}
+	for _, port := range []int{-1, 65536} {
+		settings.Notifications.SMTP.Port = port
+		if err := settings.Validate(); err == nil {
+			t.Errorf("Expected validation error for SMTP port %d", port)
+		}
+	}
+
+	settings = DefaultSettings()
+	settings.LogScan.TailLines = 0
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an empty log scan tail")
+	}
+	settings.LogScan.TailLines = DefaultLogScanTailLines
+	settings.LogScan.MaxInstallMinutes = -1
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a negative maximum install time")
+	}
+
+	settings = DefaultSettings()
+	settings.Browser.Private = true
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a private window in the default browser")
+	}
+	settings.Browser.Browser = "firefox"
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Expected a private Firefox window to be valid, got: %v", err)
+	}
+
+	settings = DefaultSettings()
+	settings.IdleStop = IdleStopSettings{Enabled: true, Minutes: 1}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a one-minute idle stop")
+	}
+
+	settings = DefaultSettings()
+	settings.HealthMonitor.IntervalSeconds = 1
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a one-second health monitor interval")
+	}
+
+	settings = DefaultSettings()
+	settings.InstallCleanup.Policy = "archive"
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an unknown install cleanup policy")
+	}
+
+	settings = DefaultSettings()
+	settings.ExitAction = "hide"
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an unknown exit action")
+	}
+
+	settings = DefaultSettings()
+	settings.Site = SiteSettings{FullName: "Quiz prototype", AdminEmail: "Admin <admin@example.com>"}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an admin email with a display name")
+	}
+	settings.Site.AdminEmail = "admin@example.com"
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Expected site settings to be valid, got: %v", err)
+	}
+
+	settings = DefaultSettings()
+	settings.ControlAPI.Enabled = true
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a control API without a token")
+	}
+	settings.ControlAPI.TokenConfigured = true
+	settings.ControlAPI.Port = settings.HostPort
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for a control API on the Moodle port")
+	}
+
+	settings = DefaultSettings()
+	settings.Language = "fr_FR"
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Expected a supported locale to be valid, got: %v", err)
+	}
+	settings.Language = "tlh"
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an unsupported language")
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	for _, hostname := range []string{"moodle.local", "proto-1.lab.example"} {
+		if err := ValidateHostname(hostname); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", hostname, err)
+		}
+	}
+	for _, hostname := range []string{"localhost", "-moodle.local", "moodle..local", "moodle local", "Moodle.local"} {
+		if err := ValidateHostname(hostname); err == nil {
+			t.Errorf("Expected %q to be rejected", hostname)
+		}
+	}
+}
+
+func TestSettingsDecodeKeepsDefaults(t *testing.T) {
+	// Older settings files may not contain newer fields
+	settings := DefaultSettings()
+	if err := json.Unmarshal([]byte(`{"cron":{"enabled":false}}`), settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+
+	if settings.Cron.Enabled {
+		t.Error("Expected cron to be disabled from file")
+	}
+
+	if settings.Cron.IntervalMinutes != DefaultCronIntervalMinutes {
+		t.Errorf("Expected default interval %d, got %d", DefaultCronIntervalMinutes, settings.Cron.IntervalMinutes)
+	}
+}
+
+func TestInstallCleanupDefaultsToKeep(t *testing.T) {
+	// Removing a container deletes its volumes, so it must be asked for
+	if policy := DefaultSettings().InstallCleanup.Policy; policy != InstallCleanupKeep {
+		t.Errorf("Expected failed installs to be kept by default, got %q", policy)
+	}
+}
+
+func TestSelectedImageIsPerInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager := NewSettingsManager()
+
+	// Earlier versions kept one selection for every instance
+	legacy := `{"hostPort": 8080, "instanceName": "course", "selectedImage": "docker.io/moodlehq/moodle:4.4"}`
+	if err := manager.fileManager.SaveSettings([]byte(legacy)); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := manager.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if settings.SelectedImage() != "moodlehq/moodle:4.4" || settings.LegacySelectedImage != "" {
+		t.Errorf("Expected the legacy selection to move to instance course, got %v", settings.SelectedImages)
+	}
+
+	settings.InstanceName = "quiz"
+	if settings.SelectedImage() != "" {
+		t.Errorf("Expected another instance to keep image.docker, got %q", settings.SelectedImage())
+	}
+	settings.SelectImage("moodlehq/moodle")
+	if settings.SelectedImage() != "moodlehq/moodle:latest" || settings.SelectedImages["course"] != "moodlehq/moodle:4.4" {
+		t.Errorf("Expected a normalized selection for quiz only, got %v", settings.SelectedImages)
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/utils"
+)
+
+// StateFormatVersion is the layout of the JSON state files this build reads and writes. Bump
+// it whenever older builds would misread a file, and teach the loaders to migrate the old
+// layout. Files written before stamping was introduced have no stamp and count as format 0.
+const StateFormatVersion = 1
+
+// ErrStateFromNewerVersion marks state written by a newer, incompatible app version
+var ErrStateFromNewerVersion = fmt.Errorf("state was written by a newer version of the app")
+
+// StateStamp records which app version wrote a state file
+type StateStamp struct {
+	FormatVersion int    `json:"formatVersion"`
+	WrittenBy     string `json:"writtenBy"`
+}
+
+// currentStamp is the stamp of state written by this build
+func currentStamp() StateStamp {
+	return StateStamp{FormatVersion: StateFormatVersion, WrittenBy: buildinfo.Version}
+}
+
+// StateVersionError reports a state file this build cannot safely read or overwrite, e.g.
+// when a home directory is synced between machines running different app versions
+type StateVersionError struct {
+	File  string
+	Stamp StateStamp
+}
+
+func (e *StateVersionError) Error() string {
+	return fmt.Sprintf("%s was written by version %s (state format %d), newer than this version %s (format %d); update the app on this machine",
+		e.File, e.Stamp.WrittenBy, e.Stamp.FormatVersion, buildinfo.Version, StateFormatVersion)
+}
+
+// ErrorCode asks the frontend to suggest updating the app
+func (e *StateVersionError) ErrorCode() errors.Code {
+	return errors.CodeStateFromNewerVersion
+}
+
+// Remediation names the version to update to
+func (e *StateVersionError) Remediation() []string {
+	return []string{fmt.Sprintf("Update Moodle Prototype Manager on this machine to version %s or newer", e.Stamp.WrittenBy)}
+}
+
+func (e *StateVersionError) Unwrap() error {
+	return ErrStateFromNewerVersion
+}
+
+// checkStamp verifies this build understands a state file's format
+func checkStamp(file string, stamp StateStamp) error {
+	if stamp.FormatVersion > StateFormatVersion {
+		return &StateVersionError{File: file, Stamp: stamp}
+	}
+	if stamp.FormatVersion < StateFormatVersion {
+		utils.LogDebug(fmt.Sprintf("Reading %s in state format %d; it is migrated to %d when next saved", file, stamp.FormatVersion, StateFormatVersion))
+	} else if stamp.WrittenBy != buildinfo.Version {
+		utils.LogDebug(fmt.Sprintf("%s was written by version %s", file, stamp.WrittenBy))
+	}
+	return nil
+}
+
+// stateEnvelope wraps state whose top level cannot carry a stamp field, such as a list
+type stateEnvelope struct {
+	Stamp StateStamp      `json:"stamp"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// encodeState wraps v in a stamped envelope
+func encodeState(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(stateEnvelope{Stamp: currentStamp(), Data: data}, "", "  ")
+}
+
+// decodeState unwraps a file written by encodeState into v after checking its stamp. Files
+// written before stamping hold the bare value and are read as format 0.
+func decodeState(file string, data []byte, v any) error {
+	var envelope stateEnvelope
+	if isEnvelope(data) {
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return err
+		}
+		data = envelope.Data
+	}
+	if err := checkStamp(file, envelope.Stamp); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// isEnvelope reports whether data is a stamped envelope rather than a bare legacy value
+func isEnvelope(data []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	_, hasStamp := fields["stamp"]
+	_, hasData := fields["data"]
+	return hasStamp && hasData && len(fields) == 2
+}
+
+// IsStateFromNewerVersion reports whether err was caused by state from a newer app version
+func IsStateFromNewerVersion(err error) bool {
+	return errors.IsSpecificError(err, ErrStateFromNewerVersion)
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeStateReadsLegacyAndStampedFiles(t *testing.T) {
+	var legacy []TimelineEntry
+	if err := decodeState(TimelineFile, []byte(`[{"kind":"image:saved","message":"Saved"}]`), &legacy); err != nil {
+		t.Fatalf("Expected a legacy file to be read, got: %v", err)
+	}
+	if len(legacy) != 1 || legacy[0].Kind != "image:saved" {
+		t.Errorf("Unexpected legacy entries: %+v", legacy)
+	}
+
+	data, err := encodeState(map[string][]string{"default": {"sprint-12"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var tags map[string][]string
+	if err := decodeState(TagsFile, data, &tags); err != nil || tags["default"][0] != "sprint-12" {
+		t.Errorf("Expected the stamped file to round-trip, got %v (err: %v)", tags, err)
+	}
+}
+
+func TestNewerStateIsRefused(t *testing.T) {
+	newer := []byte(`{"stamp":{"formatVersion":99,"writtenBy":"9.0.0"},"data":[]}`)
+	var entries []TimelineEntry
+	err := decodeState(TimelineFile, newer, &entries)
+	if !IsStateFromNewerVersion(err) {
+		t.Fatalf("Expected a newer-version error, got: %v", err)
+	}
+
+	settings := DefaultSettings()
+	settings.Stamp = StateStamp{FormatVersion: 99, WrittenBy: "9.0.0"}
+	data, _ := json.Marshal(settings)
+	if err := checkSettingsStamp(data); !IsStateFromNewerVersion(err) {
+		t.Errorf("Expected newer settings to be refused, got: %v", err)
+	}
+	if err := checkSettingsStamp([]byte(`{"hostPort":8080}`)); err != nil {
+		t.Errorf("Expected unstamped settings to be accepted, got: %v", err)
+	}
+}
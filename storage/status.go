@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+)
+
+// StatusFile mirrors the instance state for scripts and the CLI, which can read it without
+// asking Docker
+const StatusFile = "status.json"
+
+// healthCheckRefresh is how stale the recorded health check may get before an unchanged
+// status is written again
+const healthCheckRefresh = time.Minute
+
+// InstanceStatus is the machine-readable instance state kept in status.json
+type InstanceStatus struct {
+	Instance string `json:"instance"`
+	// State is none, running or stopped, or unknown when Docker could not be asked
+	State string `json:"state"`
+	// ContainerID is empty when the instance has no container
+	ContainerID string `json:"containerId,omitempty"`
+	Port        int    `json:"port"`
+	URL         string `json:"url"`
+	Image       string `json:"image"`
+	// ImageDigest is the registry digest of the local image, empty for images built locally
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// Health is the Docker health status seen at LastHealthCheck, which is nil while the
+	// container is not running
+	Health          string     `json:"health,omitempty"`
+	LastHealthCheck *time.Time `json:"lastHealthCheck,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	Stamp           StateStamp `json:"stamp"`
+}
+
+// StatusStore writes status.json when the instance state changes
+type StatusStore struct {
+	fileManager *FileManager
+	mu          sync.Mutex
+	// last is the status last written, nil until the first write
+	last *InstanceStatus
+	// recordedHealth is the health last passed to RecordHealth
+	recordedHealth string
+}
+
+// NewStatusStore creates a new status store
+func NewStatusStore() *StatusStore {
+	return &StatusStore{
+		fileManager: NewFileManager(),
+	}
+}
+
+// Save writes status unless it matches the status last written and its health check is recent
+func (ss *StatusStore) Save(status InstanceStatus) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.save(status)
+}
+
+// RecordHealth records a health check in the last written status and reports whether the
+// health changed since the previous check. Nothing is written before a full status has been
+// saved.
+func (ss *StatusStore) RecordHealth(health string, checkedAt time.Time) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	changed := health != ss.recordedHealth
+	ss.recordedHealth = health
+	if ss.last == nil {
+		return changed, nil
+	}
+	status := *ss.last
+	status.Health = health
+	status.LastHealthCheck = &checkedAt
+	return changed, ss.save(status)
+}
+
+// Load reads status.json
+func (ss *StatusStore) Load() (*InstanceStatus, error) {
+	data, err := ss.fileManager.LoadDataFile(StatusFile)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load instance status")
+	}
+
+	var status InstanceStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "failed to parse instance status: %v", err)
+	}
+	if err := checkStamp(StatusFile, status.Stamp); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// save writes status without locking
+func (ss *StatusStore) save(status InstanceStatus) error {
+	if ss.last != nil && sameStatus(*ss.last, status) && !healthCheckStale(ss.last.LastHealthCheck, status.LastHealthCheck) {
+		return nil
+	}
+
+	status.UpdatedAt = time.Now().UTC()
+	status.Stamp = currentStamp()
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode instance status")
+	}
+	if err := ss.fileManager.SaveDataFile(StatusFile, data); err != nil {
+		return err
+	}
+	ss.last = &status
+	return nil
+}
+
+// sameStatus reports whether two statuses differ only in their timestamps
+func sameStatus(a, b InstanceStatus) bool {
+	a.LastHealthCheck, b.LastHealthCheck = nil, nil
+	a.UpdatedAt, b.UpdatedAt = time.Time{}, time.Time{}
+	a.Stamp, b.Stamp = StateStamp{}, StateStamp{}
+	return a == b
+}
+
+// healthCheckStale reports whether a health check at checked should replace the one written at
+// written: when only one of them is set, or when written is older than healthCheckRefresh
+func healthCheckStale(written, checked *time.Time) bool {
+	if written == nil || checked == nil {
+		return written != checked
+	}
+	return checked.Sub(*written) >= healthCheckRefresh
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusStoreWritesChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store := NewStatusStore()
+
+	if _, err := store.RecordHealth("healthy", time.Now()); err != nil || store.fileManager.DataFileExists(StatusFile) {
+		t.Fatalf("Expected no status file before a full status is saved (err: %v)", err)
+	}
+
+	running := InstanceStatus{Instance: "alice", State: "running", ContainerID: "abc123", Port: 8080, URL: "http://localhost:8080", Image: "moodle:502"}
+	if err := store.Save(running); err != nil {
+		t.Fatalf("Failed to save status: %v", err)
+	}
+	written, err := NewStatusStore().Load()
+	if err != nil {
+		t.Fatalf("Failed to load status: %v", err)
+	}
+	if written.State != "running" || written.Port != 8080 || written.LastHealthCheck != nil || written.Stamp.FormatVersion != StateFormatVersion {
+		t.Errorf("Unexpected status: %+v", written)
+	}
+
+	// Repeated checks with the same result are only written once the last one is stale
+	checked := time.Now()
+	store.RecordHealth("healthy", checked)
+	first := store.last.UpdatedAt
+	store.RecordHealth("healthy", checked.Add(10*time.Second))
+	if store.last.UpdatedAt != first {
+		t.Error("Expected an unchanged health check not to rewrite the status")
+	}
+	store.RecordHealth("healthy", checked.Add(2*time.Minute))
+	if store.last.UpdatedAt == first {
+		t.Error("Expected a stale health check to be refreshed")
+	}
+
+	if changed, _ := store.RecordHealth("unhealthy", checked.Add(2*time.Minute)); !changed {
+		t.Error("Expected the health change to be reported")
+	}
+	if loaded, _ := store.Load(); loaded.Health != "unhealthy" || loaded.ContainerID != "abc123" {
+		t.Errorf("Expected the health change to be written, got %+v", loaded)
+	}
+}
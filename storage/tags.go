@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"moodle-prototype-manager/errors"
+)
+
+// TagsFile stores the free-form tags of each instance
+const TagsFile = "tags.json"
+
+// Tag limits keep tags readable as chips in the instance list
+const (
+	MaxTagLength       = 40
+	MaxTagsPerInstance = 20
+)
+
+// tagPrefix restricts a search term to exact tag matches (tag:sprint-12)
+const tagPrefix = "tag:"
+
+// TagStore records tags such as client name, sprint or feature per instance in tags.json
+type TagStore struct {
+	fileManager *FileManager
+	mu          sync.Mutex
+}
+
+// NewTagStore creates a new tag store
+func NewTagStore() *TagStore {
+	return &TagStore{
+		fileManager: NewFileManager(),
+	}
+}
+
+// All returns the tags of every instance, keyed by instance name
+func (ts *TagStore) All() (map[string][]string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.load()
+}
+
+// SetTags replaces the tags of an instance; an empty list removes them.
+// Returns the normalized tags that were stored.
+func (ts *TagStore) SetTags(instance string, tags []string) ([]string, error) {
+	if err := errors.ValidateNotEmpty("instance", instance); err != nil {
+		return nil, err
+	}
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	all, err := ts.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		delete(all, instance)
+	} else {
+		all[instance] = normalized
+	}
+
+	data, err := encodeState(all)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to encode tags")
+	}
+	if err := ts.fileManager.SaveDataFile(TagsFile, data); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// load reads the tags without locking
+func (ts *TagStore) load() (map[string][]string, error) {
+	all := make(map[string][]string)
+	if !ts.fileManager.DataFileExists(TagsFile) {
+		return all, nil
+	}
+
+	data, err := ts.fileManager.LoadDataFile(TagsFile)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load tags")
+	}
+	if err := decodeState(TagsFile, data, &all); err != nil {
+		if IsStateFromNewerVersion(err) {
+			return nil, err
+		}
+		return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "failed to parse tags: %v", err)
+	}
+	return all, nil
+}
+
+// NormalizeTags trims, de-duplicates (case-insensitively) and sorts tags, dropping empty ones
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		// Search terms are split on whitespace, so a tag must be a single word
+		if strings.ContainsAny(tag, " \t\n") {
+			return nil, errors.NewValidationError("tags", "tags cannot contain spaces (use - instead)", tag)
+		}
+		if len(tag) > MaxTagLength {
+			return nil, errors.NewValidationError("tags", "tags must be at most 40 characters", tag)
+		}
+		if strings.HasPrefix(strings.ToLower(tag), tagPrefix) {
+			return nil, errors.NewValidationError("tags", "tags cannot start with \"tag:\"", tag)
+		}
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTagsPerInstance {
+		return nil, errors.NewValidationError("tags", "an instance can have at most 20 tags", len(normalized))
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		return strings.ToLower(normalized[i]) < strings.ToLower(normalized[j])
+	})
+	return normalized, nil
+}
+
+// MatchesQuery reports whether an instance matches a search query. Every whitespace-separated
+// term must match, case-insensitively: "tag:<value>" matches a whole tag, any other term
+// matches part of the instance name or of a tag. An empty query matches everything.
+func MatchesQuery(query, instance string, tags []string) bool {
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if value, ok := strings.CutPrefix(term, tagPrefix); ok {
+			if !hasTag(tags, value) {
+				return false
+			}
+			continue
+		}
+
+		matched := strings.Contains(strings.ToLower(instance), term)
+		for _, tag := range tags {
+			if matched {
+				break
+			}
+			matched = strings.Contains(strings.ToLower(tag), term)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTag reports whether tags contains value, ignoring case
+func hasTag(tags []string, value string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, value) {
+			return true
+		}
+	}
+	return false
+}
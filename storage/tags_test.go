@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" sprint-12 ", "Acme", "", "acme", "checkout"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"Acme", "checkout", "sprint-12"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+
+	for _, invalid := range []string{"two words", "tag:nested"} {
+		if _, err := NormalizeTags([]string{invalid}); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	tags := []string{"Acme", "sprint-12"}
+	cases := map[string]bool{
+		"":                  true,
+		"demo":              true,
+		"ACME":              true,
+		"sprint":            true,
+		"acme demo":         true,
+		"acme globex":       false,
+		"tag:sprint-12":     true,
+		"tag:sprint":        false,
+		"tag:acme checkout": false,
+	}
+	for query, expected := range cases {
+		if got := MatchesQuery(query, "client-demo", tags); got != expected {
+			t.Errorf("MatchesQuery(%q) = %v, expected %v", query, got, expected)
+		}
+	}
+}
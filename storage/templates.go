@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"moodle-prototype-manager/docker"
+	"moodle-prototype-manager/errors"
+)
+
+// envNameRegex matches environment variable names Docker passes through unchanged
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// InstanceTemplate is a named instance configuration new instances can be created from
+type InstanceTemplate struct {
+	Name string `json:"name"`
+	// Image is the image reference; empty uses image.docker
+	Image string `json:"image,omitempty"`
+	// HostPort is the preferred host port; 0 keeps the current one
+	HostPort int `json:"hostPort,omitempty"`
+	// Env is passed to the Moodle container
+	Env map[string]string `json:"env,omitempty"`
+	// SeedSize seeds demo data (S, M or L) when the first install of an instance created from
+	// it finishes; empty seeds nothing
+	SeedSize string `json:"seedSize,omitempty"`
+	// Mail and Adminer run the mail catcher and Adminer sidecars
+	Mail      bool           `json:"mail"`
+	Adminer   bool           `json:"adminer"`
+	Memory    MemorySettings `json:"memory"`
+	Site      SiteSettings   `json:"site"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// TemplateFromSettings captures the configuration new containers get from settings, using
+// image when no catalog image is selected
+func (s *Settings) TemplateFromSettings(name, image, seedSize string) InstanceTemplate {
+	if selected := s.SelectedImage(); selected != "" {
+		image = selected
+	}
+	env := make(map[string]string, len(s.ContainerEnv))
+	for key, value := range s.ContainerEnv {
+		env[key] = value
+	}
+	return InstanceTemplate{
+		Name:      strings.TrimSpace(name),
+		Image:     image,
+		HostPort:  s.HostPort,
+		Env:       env,
+		SeedSize:  strings.ToUpper(strings.TrimSpace(seedSize)),
+		Mail:      s.Mail.Enabled,
+		Adminer:   s.Adminer.Enabled,
+		Memory:    s.Memory,
+		Site:      s.Site,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Template returns the template called name
+func (s *Settings) Template(name string) (InstanceTemplate, bool) {
+	for _, template := range s.Templates {
+		if template.Name == name {
+			return template, true
+		}
+	}
+	return InstanceTemplate{}, false
+}
+
+// SaveTemplate adds template, replacing one with the same name
+func (s *Settings) SaveTemplate(template InstanceTemplate) {
+	for i, existing := range s.Templates {
+		if existing.Name == template.Name {
+			s.Templates[i] = template
+			return
+		}
+	}
+	s.Templates = append(s.Templates, template)
+}
+
+// DeleteTemplate removes the template called name, reporting whether it existed
+func (s *Settings) DeleteTemplate(name string) bool {
+	for i, template := range s.Templates {
+		if template.Name == name {
+			s.Templates = append(s.Templates[:i], s.Templates[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTemplate configures the next container from template
+func (s *Settings) ApplyTemplate(template InstanceTemplate) {
+	s.SelectImage(template.Image)
+	if template.HostPort != 0 {
+		s.HostPort = template.HostPort
+	}
+	s.ContainerEnv = template.Env
+	s.SeedOnInstall = template.SeedSize
+	s.Mail.Enabled = template.Mail
+	s.Adminer.Enabled = template.Adminer
+	s.Memory = template.Memory
+	s.Site = template.Site
+}
+
+// validateTemplates checks the templates and the settings they fill in
+func (s *Settings) validateTemplates(multiErr *errors.MultiError) {
+	validateEnv(multiErr, "containerEnv", s.ContainerEnv)
+	validateSeedSize(multiErr, "seedOnInstall", s.SeedOnInstall)
+
+	names := make(map[string]bool, len(s.Templates))
+	for i, template := range s.Templates {
+		field := fmt.Sprintf("templates[%d]", i)
+		if strings.TrimSpace(template.Name) == "" {
+			multiErr.Add(errors.NewValidationError(field+".name", "template name is required", template.Name))
+		} else if names[template.Name] {
+			multiErr.Add(errors.NewValidationError(field+".name", "template names must be unique", template.Name))
+		}
+		names[template.Name] = true
+
+		if template.Image != "" {
+			if err := errors.ValidateImageName(template.Image); err != nil {
+				multiErr.Add(err)
+			}
+		}
+		if template.HostPort < 0 || template.HostPort > 65535 {
+			multiErr.Add(errors.NewValidationError(field+".hostPort", "port must be between 1 and 65535", template.HostPort))
+		}
+		if template.Memory.LimitMB < 0 {
+			multiErr.Add(errors.NewValidationError(field+".memory.limitMB", "limit cannot be negative", template.Memory.LimitMB))
+		}
+		validateEnv(multiErr, field+".env", template.Env)
+		validateSeedSize(multiErr, field+".seedSize", template.SeedSize)
+	}
+}
+
+// validateEnv checks environment variable names
+func validateEnv(multiErr *errors.MultiError, field string, env map[string]string) {
+	for name := range env {
+		if !envNameRegex.MatchString(name) {
+			multiErr.Add(errors.NewValidationError(field, "variable names may contain only letters, digits and underscores", name))
+		}
+	}
+}
+
+// validateSeedSize checks a demo data size, which may be empty
+func validateSeedSize(multiErr *errors.MultiError, field, size string) {
+	if size == "" {
+		return
+	}
+	if normalized, err := docker.ValidateDemoDataSize(size); err != nil || normalized != size {
+		multiErr.Add(errors.NewValidationError(field, "must be S, M or L", size))
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import "testing"
+
+func TestTemplateRoundTrip(t *testing.T) {
+	settings := DefaultSettings()
+	settings.HostPort = 8180
+	settings.ContainerEnv = map[string]string{"MOODLE_LANG": "de"}
+	settings.Mail.Enabled = true
+	settings.Memory.LimitMB = 3072
+
+	template := settings.TemplateFromSettings(" Workshop ", "moodlehq/moodle:4.4", "m")
+	if template.Name != "Workshop" || template.Image != "moodlehq/moodle:4.4" || template.SeedSize != "M" {
+		t.Errorf("Unexpected template: %+v", template)
+	}
+	settings.SaveTemplate(template)
+
+	// The template keeps its own copy of the environment
+	settings.ContainerEnv["MOODLE_LANG"] = "fr"
+	if saved, _ := settings.Template("Workshop"); saved.Env["MOODLE_LANG"] != "de" {
+		t.Errorf("Expected the template environment to be copied, got %v", saved.Env)
+	}
+
+	fresh := DefaultSettings()
+	fresh.ApplyTemplate(template)
+	if fresh.SelectedImage() != "moodlehq/moodle:4.4" || fresh.HostPort != 8180 || !fresh.Mail.Enabled || fresh.Memory.LimitMB != 3072 || fresh.SeedOnInstall != "M" {
+		t.Errorf("Template not applied: %+v", fresh)
+	}
+
+	template.Name = "Workshop"
+	template.Adminer = true
+	settings.SaveTemplate(template)
+	if len(settings.Templates) != 1 {
+		t.Errorf("Expected saving under the same name to replace the template, got %d", len(settings.Templates))
+	}
+	if !settings.DeleteTemplate("Workshop") || settings.DeleteTemplate("Workshop") {
+		t.Error("Expected the template to be deleted exactly once")
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Templates = []InstanceTemplate{{Name: "a"}, {Name: "a"}}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for duplicate template names")
+	}
+
+	settings = DefaultSettings()
+	settings.Templates = []InstanceTemplate{{Name: "a", SeedSize: "XL"}}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an unknown seed size")
+	}
+
+	settings = DefaultSettings()
+	settings.ContainerEnv = map[string]string{"MOODLE-LANG": "de"}
+	if err := settings.Validate(); err == nil {
+		t.Error("Expected validation error for an environment variable name with a dash")
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/errors"
+)
+
+// TimelineFile stores recent notable events
+const TimelineFile = "timeline.json"
+
+// MaxTimelineEntries caps the number of events kept on disk
+const MaxTimelineEntries = 500
+
+// TimelineEntry is a single notable event shown in the activity timeline
+type TimelineEntry struct {
+	Time    time.Time         `json:"time"`
+	Kind    string            `json:"kind"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Timeline records notable events to timeline.json
+type Timeline struct {
+	fileManager *FileManager
+	mu          sync.Mutex
+}
+
+// NewTimeline creates a new timeline
+func NewTimeline() *Timeline {
+	return &Timeline{
+		fileManager: NewFileManager(),
+	}
+}
+
+// Add appends an event, dropping the oldest entries beyond MaxTimelineEntries
+func (t *Timeline) Add(kind, message string, details map[string]string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries, err := t.load()
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, TimelineEntry{
+		Time:    time.Now(),
+		Kind:    kind,
+		Message: message,
+		Details: details,
+	})
+	if len(entries) > MaxTimelineEntries {
+		entries = entries[len(entries)-MaxTimelineEntries:]
+	}
+
+	data, err := encodeState(entries)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode timeline")
+	}
+	return t.fileManager.SaveDataFile(TimelineFile, data)
+}
+
+// Entries returns all recorded events, oldest first
+func (t *Timeline) Entries() ([]TimelineEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.load()
+}
+
+// load reads the timeline without locking
+func (t *Timeline) load() ([]TimelineEntry, error) {
+	if !t.fileManager.DataFileExists(TimelineFile) {
+		return []TimelineEntry{}, nil
+	}
+
+	data, err := t.fileManager.LoadDataFile(TimelineFile)
+	if err != nil {
+		return nil, errors.WrapWithContext(err, "failed to load timeline")
+	}
+
+	var entries []TimelineEntry
+	if err := decodeState(TimelineFile, data, &entries); err != nil {
+		if IsStateFromNewerVersion(err) {
+			return nil, err
+		}
+		return nil, errors.WrapWithContext(errors.ErrFileCorrupted, "failed to parse timeline: %v", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/utils"
+)
+
+// Groups of background tasks that are cancelled together
+const (
+	// taskGroupCredentials holds the waiters polling a starting container for its credentials
+	taskGroupCredentials = "credentials"
+)
+
+// taskCancelTimeout bounds how long cancelling waits for tasks stuck in a docker command
+const taskCancelTimeout = 15 * time.Second
+
+// backgroundTask is a supervised goroutine
+type backgroundTask struct {
+	group  string
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// taskRegistry ties background goroutines to a context so that stopping the container or
+// quitting the app cancels them and waits for them to return, instead of leaving them polling
+type taskRegistry struct {
+	mu     sync.Mutex
+	tasks  map[int]*backgroundTask
+	nextID int
+	// onPanic, if set, is told about a task that panicked
+	onPanic func(recovered any, stack []byte)
+}
+
+// start runs fn in a goroutine with a context cancelled by cancel or cancelAll. Panics are
+// logged rather than taking the app down.
+func (r *taskRegistry) start(group, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &backgroundTask{group: group, name: name, cancel: cancel, done: make(chan struct{})}
+
+	r.mu.Lock()
+	if r.tasks == nil {
+		r.tasks = make(map[int]*backgroundTask)
+	}
+	r.nextID++
+	id := r.nextID
+	r.tasks[id] = task
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				utils.LogError(fmt.Sprintf("Background task %q panicked", name), fmt.Errorf("%v", recovered))
+				if r.onPanic != nil {
+					r.onPanic(recovered, debug.Stack())
+				}
+			}
+			cancel()
+			close(task.done)
+
+			r.mu.Lock()
+			delete(r.tasks, id)
+			r.mu.Unlock()
+		}()
+		fn(ctx)
+	}()
+}
+
+// running reports whether any task of the group has not returned yet
+func (r *taskRegistry) running(group string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range r.tasks {
+		if task.group == group {
+			return true
+		}
+	}
+	return false
+}
+
+// cancel stops the tasks of a group and waits for them to return
+func (r *taskRegistry) cancel(group string) {
+	r.cancelMatching(func(task *backgroundTask) bool { return task.group == group })
+}
+
+// cancelAll stops every task and waits for them to return
+func (r *taskRegistry) cancelAll() {
+	r.cancelMatching(func(*backgroundTask) bool { return true })
+}
+
+// cancelMatching cancels the selected tasks, then waits up to taskCancelTimeout for all of them
+func (r *taskRegistry) cancelMatching(selected func(*backgroundTask) bool) {
+	r.mu.Lock()
+	tasks := make([]*backgroundTask, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		if selected(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, task := range tasks {
+		task.cancel()
+	}
+
+	deadline := time.After(taskCancelTimeout)
+	for _, task := range tasks {
+		select {
+		case <-task.done:
+			utils.LogDebug(fmt.Sprintf("Background task %q stopped", task.name))
+		case <-deadline:
+			utils.LogWarning(fmt.Sprintf("Background task %q did not stop within %v", task.name, taskCancelTimeout))
+			return
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package telemetry sends anonymous, opt-in usage reports: the OS and Docker version, how long
+// image pulls and first-run installs take, what category of failure stopped them, and panics.
+// Reports never carry names, paths, URLs, credentials or container IDs; an install ID chosen at
+// random when telemetry is enabled lets reports from one installation be grouped.
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/buildinfo"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/scenario"
+	"moodle-prototype-manager/utils"
+)
+
+// Event kinds
+const (
+	// KindSession is sent once when the manager starts
+	KindSession = "session"
+	KindPull    = "pull"
+	KindInstall = "install"
+	// KindFailure reports an operation that failed before it could be timed, e.g. starting Moodle
+	KindFailure = "failure"
+	KindPanic   = "panic"
+)
+
+// Outcomes of timed operations
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+const (
+	// requestTimeout bounds a report delivery
+	requestTimeout = 10 * time.Second
+	// maxStackBytes keeps panic reports small; the top of the stack is what matters
+	maxStackBytes = 16 * 1024
+)
+
+// Event is one anonymous report
+type Event struct {
+	Kind string `json:"kind"`
+	// Operation names the failed operation of a KindFailure event, e.g. "run"
+	Operation string `json:"operation,omitempty"`
+	Outcome   string `json:"outcome,omitempty"`
+	// Category classifies a failure: an error code or an install failure reason
+	Category        string  `json:"category,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	// Panic and Stack describe a panic, with the home directory and user name removed
+	Panic string `json:"panic,omitempty"`
+	Stack string `json:"stack,omitempty"`
+}
+
+// Report is the document posted to the endpoint
+type Report struct {
+	Event
+	InstallID  string `json:"installId"`
+	AppVersion string `json:"appVersion"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	// DockerVersion and DockerPlatform are empty until the daemon has answered
+	DockerVersion  string    `json:"dockerVersion,omitempty"`
+	DockerPlatform string    `json:"dockerPlatform,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// Reporter posts events to the configured endpoint while telemetry is enabled
+type Reporter struct {
+	mu             sync.Mutex
+	enabled        bool
+	endpoint       string
+	installID      string
+	dockerVersion  string
+	dockerPlatform string
+
+	client    *http.Client
+	sanitizer *scenario.Sanitizer
+	inFlight  sync.WaitGroup
+}
+
+// NewReporter creates a disabled reporter; sanitizer removes personal details from panics
+func NewReporter(sanitizer *scenario.Sanitizer) *Reporter {
+	return &Reporter{
+		client:    &http.Client{Timeout: requestTimeout},
+		sanitizer: sanitizer,
+	}
+}
+
+// NewInstallID returns a random ID identifying one installation in reports
+func NewInstallID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate telemetry install ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Configure turns reporting on or off
+func (r *Reporter) Configure(enabled bool, endpoint, installID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled && endpoint != "" && installID != ""
+	r.endpoint = endpoint
+	r.installID = installID
+}
+
+// SetDocker records the Docker version included in later reports
+func (r *Reporter) SetDocker(version, platform string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dockerVersion = version
+	r.dockerPlatform = platform
+}
+
+// Enabled reports whether events are sent
+func (r *Reporter) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// Report sends event in the background; it does nothing while telemetry is disabled
+func (r *Reporter) Report(event Event) {
+	endpoint, report, ok := r.prepare(event)
+	if !ok {
+		return
+	}
+
+	r.inFlight.Add(1)
+	go func() {
+		defer r.inFlight.Done()
+		if err := r.send(endpoint, report); err != nil {
+			utils.LogDebug(fmt.Sprintf("Telemetry %s report not sent: %v", event.Kind, err))
+		}
+	}()
+}
+
+// ReportPanic sends a recovered panic and waits for the delivery, since the process may be
+// about to exit
+func (r *Reporter) ReportPanic(recovered any, stack []byte) {
+	if len(stack) > maxStackBytes {
+		stack = stack[:maxStackBytes]
+	}
+	endpoint, report, ok := r.prepare(Event{
+		Kind:  KindPanic,
+		Panic: r.sanitizer.String(fmt.Sprint(recovered)),
+		Stack: r.sanitizer.String(string(stack)),
+	})
+	if !ok {
+		return
+	}
+	if err := r.send(endpoint, report); err != nil {
+		utils.LogDebug(fmt.Sprintf("Telemetry panic report not sent: %v", err))
+	}
+}
+
+// Flush waits at most timeout for reports being sent
+func (r *Reporter) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		r.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// prepare completes event into a report, reporting false while telemetry is disabled
+func (r *Reporter) prepare(event Event) (string, Report, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return "", Report{}, false
+	}
+	return r.endpoint, Report{
+		Event:          event,
+		InstallID:      r.installID,
+		AppVersion:     buildinfo.Version,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		DockerVersion:  r.dockerVersion,
+		DockerPlatform: r.dockerPlatform,
+		Time:           time.Now().UTC(),
+	}, true
+}
+
+// send posts report; any non-2xx response is an error
+func (r *Reporter) send(endpoint string, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errors.WrapWithContext(err, "failed to encode telemetry report")
+	}
+	resp, err := r.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.NewNetworkErrorWithURL("telemetry_report", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("telemetry endpoint returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
+
+// Timed builds the event of an operation that took duration, categorising err if it failed
+func Timed(kind string, duration time.Duration, err error) Event {
+	event := Event{Kind: kind, Outcome: OutcomeSucceeded, DurationSeconds: duration.Seconds()}
+	if err != nil {
+		event.Outcome = OutcomeFailed
+		event.Category = Category(err)
+	}
+	return event
+}
+
+// Category classifies err by its error code, which never includes user data
+func Category(err error) string {
+	return string(errors.CodeOf(err))
+}
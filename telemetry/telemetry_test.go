@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/scenario"
+)
+
+func TestReporterSendsOnlyWhenEnabled(t *testing.T) {
+	var mu sync.Mutex
+	var reports []Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Failed to decode report: %v", err)
+		}
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reporter := NewReporter(scenario.NewSanitizer("/home/alice", "alice"))
+	reporter.Report(Event{Kind: KindSession})
+	reporter.Configure(true, server.URL, "")
+	reporter.Report(Event{Kind: KindSession})
+	reporter.Flush(time.Second)
+	if len(reports) != 0 {
+		t.Fatalf("Expected nothing to be sent while disabled or without an install ID, got %d", len(reports))
+	}
+
+	reporter.Configure(true, server.URL, "install-1")
+	reporter.SetDocker("27.1.1", "Docker Desktop 4.33.0")
+	reporter.Report(Timed(KindPull, 90*time.Second, fmt.Errorf("pull: %w", errors.ErrNetworkUnavailable)))
+	reporter.ReportPanic("index out of range for alice", []byte("main.run()\n\t/home/alice/src/app.go:12"))
+	reporter.Flush(time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) != 2 {
+		t.Fatalf("Expected two reports, got %d", len(reports))
+	}
+	for _, report := range reports {
+		if report.InstallID != "install-1" || report.DockerVersion != "27.1.1" || report.OS == "" {
+			t.Errorf("Report is missing its environment: %+v", report)
+		}
+		if report.Kind == KindPull && (report.Outcome != OutcomeFailed || report.Category != string(errors.CodeNetworkUnavailable) || report.DurationSeconds != 90) {
+			t.Errorf("Unexpected pull report: %+v", report)
+		}
+		if report.Kind == KindPanic && (strings.Contains(report.Stack, "/home/alice") || strings.Contains(report.Panic, "alice")) {
+			t.Errorf("Expected personal details to be removed from the panic, got %+v", report)
+		}
+	}
+}
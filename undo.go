@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"moodle-prototype-manager/core"
+	"moodle-prototype-manager/errors"
+	"moodle-prototype-manager/storage"
+	"moodle-prototype-manager/utils"
+)
+
+// maxUndoActions caps how many recent actions can be undone
+const maxUndoActions = 20
+
+// UndoableAction describes a recent action that can be reverted
+type UndoableAction struct {
+	ID          int       `json:"id"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+}
+
+// undoEntry pairs an action with its inverse operation
+type undoEntry struct {
+	UndoableAction
+	undo func() error
+}
+
+// undoStack keeps the reversible actions of the current session, newest last
+type undoStack struct {
+	mu      sync.Mutex
+	entries []undoEntry
+	nextID  int
+}
+
+// push records an action and its inverse, dropping the oldest beyond maxUndoActions
+func (s *undoStack) push(description string, undo func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.entries = append(s.entries, undoEntry{
+		UndoableAction: UndoableAction{ID: s.nextID, Description: description, Time: time.Now()},
+		undo:           undo,
+	})
+	if len(s.entries) > maxUndoActions {
+		s.entries = s.entries[len(s.entries)-maxUndoActions:]
+	}
+}
+
+// pop removes and returns the most recent action
+func (s *undoStack) pop() (undoEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) == 0 {
+		return undoEntry{}, false
+	}
+	entry := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	return entry, true
+}
+
+// restore puts back an action whose undo failed so it can be retried
+func (s *undoStack) restore(entry undoEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+// list returns recorded actions, newest first
+func (s *undoStack) list() []UndoableAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actions := make([]UndoableAction, 0, len(s.entries))
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		actions = append(actions, s.entries[i].UndoableAction)
+	}
+	return actions
+}
+
+// ListUndoableActions returns the actions UndoLastAction can revert, newest first
+func (a *App) ListUndoableActions() []UndoableAction {
+	defer a.recoverBinding("ListUndoableActions", nil)
+	return a.undo.list()
+}
+
+// UndoLastAction reverts the most recent reversible action
+func (a *App) UndoLastAction() (_ *UndoableAction, err error) {
+	defer a.recoverBinding("UndoLastAction", &err)
+	utils.LogInfo("UndoLastAction called")
+
+	entry, ok := a.undo.pop()
+	if !ok {
+		return nil, fmt.Errorf("there is nothing to undo")
+	}
+
+	utils.LogInfo(fmt.Sprintf("Undoing: %s", entry.Description))
+	if err := entry.undo(); err != nil {
+		a.undo.restore(entry)
+		utils.LogError(fmt.Sprintf("Failed to undo %q", entry.Description), err)
+		return nil, fmt.Errorf("failed to undo %q: %w", entry.Description, err)
+	}
+
+	if err := a.timeline.Add("undo", fmt.Sprintf("Undid: %s", entry.Description), nil); err != nil {
+		utils.LogError("Failed to record undo in timeline", err)
+	}
+	return &entry.UndoableAction, nil
+}
+
+// updateSettings saves a settings change and records its inverse for UndoLastAction
+func (a *App) updateSettings(description string, fn func(*storage.Settings)) (*storage.Settings, error) {
+	previous, settings, err := a.saveSettingsChange(description, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restores the saved settings, background services and the state of the current instance
+	a.undo.push(description, func() error {
+		current, err := a.settingsManager.Load()
+		if err != nil {
+			return err
+		}
+		restored := *previous
+		// The API tokens live in the secret store, which undo leaves alone, so the flags saying
+		// whether they are stored must not be rolled back either
+		restored.Fleet.TokenConfigured = current.Fleet.TokenConfigured
+		restored.Kiosk.TokenConfigured = current.Kiosk.TokenConfigured
+		restored.ControlAPI.TokenConfigured = current.ControlAPI.TokenConfigured
+		if err := a.settingsManager.Save(&restored); err != nil {
+			return fmt.Errorf("failed to restore settings: %w", err)
+		}
+		a.recordSettingsChange("Undo: "+description, current, &restored)
+		a.applySettings(&restored)
+		return a.applyRevertedSettings(current, &restored)
+	})
+	return settings, nil
+}
+
+// applyRevertedSettings brings what applySettings does not cover back in line after an undo:
+// the container's restart policy and memory limit, the hosts entry and wwwroot, the sidecars
+// and the site details
+func (a *App) applyRevertedSettings(before, after *storage.Settings) error {
+	multiErr := errors.NewMultiError("apply reverted settings")
+
+	if core.RestartPolicyFor(before.AutoRestart || before.Kiosk.Enabled) != core.RestartPolicyFor(after.AutoRestart || after.Kiosk.Enabled) {
+		multiErr.Add(a.applyRestartPolicy(after))
+	}
+	if before.Memory.LimitMB != after.Memory.LimitMB {
+		multiErr.Add(a.updateContainerMemoryLimit(int64(after.Memory.LimitMB) * 1024 * 1024))
+	}
+	if before.Hostname != after.Hostname || before.TLS != after.TLS {
+		if after.Hostname != "" && after.Hostname != before.Hostname {
+			if err := utils.AddHostsEntry(after.Hostname); err != nil {
+				multiErr.Add(fmt.Errorf("failed to add hosts entry: %w", err))
+			}
+		}
+		multiErr.Add(a.applyHostname(before.Hostname, after))
+	}
+	if before.Mail.Enabled != after.Mail.Enabled {
+		multiErr.Add(a.applyMailCatcher(after.Mail.Enabled))
+	}
+	if before.Adminer.Enabled != after.Adminer.Enabled {
+		a.applyAdminer(after.Adminer.Enabled)
+	}
+	if before.Site != after.Site {
+		multiErr.Add(a.applySiteDetails())
+	}
+	return multiErr.ToError()
+}
+
+// updateSettingsWithoutUndo saves a settings change that must not be reverted, such as a
+// replaced secret
+func (a *App) updateSettingsWithoutUndo(description string, fn func(*storage.Settings)) (*storage.Settings, error) {
+	_, settings, err := a.saveSettingsChange(description, fn)
+	return settings, err
+}
+
+// saveSettingsChange saves a settings change and records it in the timeline, returning the
+// settings before and after it
+func (a *App) saveSettingsChange(description string, fn func(*storage.Settings)) (*storage.Settings, *storage.Settings, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, nil, err
+	}
+
+	previous, err := a.settingsManager.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, err := a.settingsManager.Update(fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.recordSettingsChange(description, previous, settings)
+	return previous, settings, nil
+}
+
+// recordSettingsChange adds the changed keys to the timeline for the change report
+func (a *App) recordSettingsChange(description string, before, after *storage.Settings) {
+	changes := storage.DiffSettings(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	if err := a.timeline.Add(storage.TimelineSettingsChanged, description, storage.SettingChangeDetails(changes)); err != nil {
+		utils.LogError("Failed to record settings change in timeline", err)
+	}
+}
+
+// applySettings pushes settings to the Docker manager and (re)starts background services
+func (a *App) applySettings(settings *storage.Settings) {
+	a.core.Configure(settings)
+	a.hostname = settings.Hostname
+	a.applyLanguageSettings(settings.Language)
+	// Crashes in read-only windows are reported too
+	a.applyTelemetrySettings(settings.Telemetry)
+
+	// Background services run in the manager holding the instance lock only
+	if a.lockHolder != nil {
+		return
+	}
+	a.applyCronSettings(settings.Cron)
+	a.applyAlertSettings(settings.Alerts)
+	a.applyIdleStopSettings(settings.IdleStop)
+	a.applyHealthMonitorSettings(settings.HealthMonitor)
+	a.applyNotificationSettings(settings.Notifications)
+	a.applyPrePullSettings(settings.PrePull)
+	// The advertisement names the fleet member API, so it starts first
+	a.applyFleetSettings(settings.Fleet)
+	a.applySharingSettings(settings.Sharing)
+	a.applyKioskSettings(settings.Kiosk)
+	a.applyControlAPISettings(settings.ControlAPI)
+	if err := a.applyTLSSettings(settings.TLS); err != nil {
+		utils.LogWarning(fmt.Sprintf("HTTPS proxy not started: %v", err))
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Browsers OpenBrowser can launch
+const (
+	BrowserDefault = "default"
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+	BrowserEdge    = "edge"
+	BrowserSafari  = "safari"
+)
+
+// SupportedBrowsers lists the browser names accepted in settings
+var SupportedBrowsers = []string{BrowserDefault, BrowserChrome, BrowserFirefox, BrowserEdge, BrowserSafari}
+
+// BrowserOptions selects how a URL is opened
+type BrowserOptions struct {
+	// Browser is one of SupportedBrowsers; empty means the system default
+	Browser string
+	// Profile is a Chrome/Edge profile directory (e.g. "Profile 2") or a Firefox profile name
+	Profile string
+	// Private opens an incognito/private window, so no extensions or cached logins are used
+	Private bool
+	// Path overrides the browser executable when it is installed somewhere unusual
+	Path string
+}
+
+// macAppNames maps browsers to their macOS application names
+var macAppNames = map[string]string{
+	BrowserChrome:  "Google Chrome",
+	BrowserFirefox: "Firefox",
+	BrowserEdge:    "Microsoft Edge",
+	BrowserSafari:  "Safari",
+}
+
+// linuxExecutables lists the executable names each browser is installed under on Linux
+var linuxExecutables = map[string][]string{
+	BrowserChrome:  {"google-chrome", "google-chrome-stable", "chromium", "chromium-browser"},
+	BrowserFirefox: {"firefox"},
+	BrowserEdge:    {"microsoft-edge", "microsoft-edge-stable"},
+}
+
+// windowsExecutables lists each browser's executable relative to the program directories
+var windowsExecutables = map[string]string{
+	BrowserChrome:  `Google\Chrome\Application\chrome.exe`,
+	BrowserFirefox: `Mozilla Firefox\firefox.exe`,
+	BrowserEdge:    `Microsoft\Edge\Application\msedge.exe`,
+}
+
+// OpenURLInBrowser opens url in the browser selected by opts without waiting for it to exit
+func OpenURLInBrowser(url string, opts BrowserOptions) error {
+	cmd, err := browserCommand(runtime.GOOS, url, opts, findBrowser)
+	if err != nil {
+		return err
+	}
+	return Commands().Start(cmd)
+}
+
+// InstalledBrowsers returns the supported browsers found on this machine, starting with the default
+func InstalledBrowsers() []string {
+	installed := []string{BrowserDefault}
+	for _, browser := range SupportedBrowsers[1:] {
+		if runtime.GOOS == "darwin" {
+			if _, err := os.Stat(filepath.Join("/Applications", macAppNames[browser]+".app")); err == nil {
+				installed = append(installed, browser)
+			}
+			continue
+		}
+		if _, err := findBrowser(runtime.GOOS, browser); err == nil {
+			installed = append(installed, browser)
+		}
+	}
+	return installed
+}
+
+// browserCommand builds the command that opens url on goos; find locates a browser executable
+func browserCommand(goos, url string, opts BrowserOptions, find func(goos, browser string) (string, error)) (*exec.Cmd, error) {
+	browser := opts.Browser
+	if browser == "" {
+		browser = BrowserDefault
+	}
+	if browser == BrowserDefault {
+		if opts.Private || opts.Profile != "" {
+			return nil, fmt.Errorf("choose a browser to use a profile or private window")
+		}
+		return openCommand(goos, url)
+	}
+
+	args, err := browserArgs(browser, opts)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, url)
+
+	if opts.Path != "" {
+		return exec.Command(opts.Path, args...), nil
+	}
+	if goos == "darwin" {
+		app, ok := macAppNames[browser]
+		if !ok {
+			return nil, fmt.Errorf("unsupported browser: %s", browser)
+		}
+		// -n starts a new instance so the flags apply even when the browser is already open
+		if len(args) == 1 {
+			return exec.Command("open", "-a", app, url), nil
+		}
+		return exec.Command("open", append([]string{"-n", "-a", app, "--args"}, args...)...), nil
+	}
+
+	path, err := find(goos, browser)
+	if err != nil {
+		return nil, err
+	}
+	return exec.Command(path, args...), nil
+}
+
+// openCommand opens target, a URL or a local path, with the system's default handler
+func openCommand(goos, target string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", target), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target), nil
+	case "linux":
+		return exec.Command("xdg-open", target), nil
+	}
+	return nil, fmt.Errorf("unsupported platform: %s", goos)
+}
+
+// OpenPath shows a local file or folder in Finder, Explorer or the desktop's file manager
+func OpenPath(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	cmd, err := openCommand(runtime.GOOS, path)
+	if err != nil {
+		return err
+	}
+	return Commands().Start(cmd)
+}
+
+// browserArgs returns the command-line flags selecting opts.Profile and a private window
+func browserArgs(browser string, opts BrowserOptions) ([]string, error) {
+	var args []string
+	switch browser {
+	case BrowserChrome, BrowserEdge:
+		if opts.Profile != "" {
+			args = append(args, "--profile-directory="+opts.Profile)
+		}
+		if opts.Private {
+			if browser == BrowserEdge {
+				args = append(args, "--inprivate")
+			} else {
+				args = append(args, "--incognito")
+			}
+		}
+	case BrowserFirefox:
+		if opts.Profile != "" {
+			args = append(args, "-P", opts.Profile)
+		}
+		if opts.Private {
+			args = append(args, "-private-window")
+		}
+	case BrowserSafari:
+		// Safari has no command-line flags for profiles or private windows
+		if opts.Private || opts.Profile != "" {
+			return nil, fmt.Errorf("safari does not support opening a profile or private window")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported browser: %s", browser)
+	}
+	return args, nil
+}
+
+// findBrowser locates the executable of browser on goos
+func findBrowser(goos, browser string) (string, error) {
+	switch goos {
+	case "windows":
+		relative, ok := windowsExecutables[browser]
+		if !ok {
+			return "", fmt.Errorf("%s is not available on Windows", browser)
+		}
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LOCALAPPDATA"} {
+			dir := os.Getenv(env)
+			if dir == "" {
+				continue
+			}
+			path := filepath.Join(dir, relative)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		return "", fmt.Errorf("%s is not installed", browser)
+	case "darwin":
+		return "", fmt.Errorf("browsers are launched through open on macOS")
+	}
+
+	names, ok := linuxExecutables[browser]
+	if !ok {
+		return "", fmt.Errorf("%s is not available on %s", browser, goos)
+	}
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not installed", browser)
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCommandTimeout bounds how long a helper program may run. It is generous because some
+// wait for the user, e.g. an administrator password prompt.
+const DefaultCommandTimeout = 5 * time.Minute
+
+// commandWaitDelay bounds how long Wait keeps reading the output of a killed or finished command
+// whose pipes are held open by a child it started, such as a daemon launched by a script; tests
+// shorten it
+var commandWaitDelay = 5 * time.Second
+
+// CommandRunner runs the programs the app uses: the docker CLI, ping, the keychain tools,
+// notification and browser launchers. Every such command goes through the runner returned by
+// Commands, so the platform setup that keeps Windows from flashing console windows, timeouts
+// and logging apply to all of them, and tests can swap in a fake.
+type CommandRunner interface {
+	// Run runs cmd and waits for it to finish
+	Run(cmd *exec.Cmd) error
+	// Output runs cmd and returns its standard output. If cmd fails, the *exec.ExitError
+	// carries its standard error.
+	Output(cmd *exec.Cmd) ([]byte, error)
+	// CombinedOutput runs cmd and returns its standard output and error
+	CombinedOutput(cmd *exec.Cmd) ([]byte, error)
+	// Start starts cmd without waiting for it, for programs that keep running such as browsers
+	Start(cmd *exec.Cmd) error
+}
+
+var (
+	commandRunnerMu sync.RWMutex
+	commandRunner   CommandRunner = &ExecRunner{Timeout: DefaultCommandTimeout}
+)
+
+// Commands returns the runner helper programs are run with
+func Commands() CommandRunner {
+	commandRunnerMu.RLock()
+	defer commandRunnerMu.RUnlock()
+	return commandRunner
+}
+
+// SetCommandRunner replaces the runner, e.g. with a fake in tests, and returns the previous one
+func SetCommandRunner(runner CommandRunner) CommandRunner {
+	commandRunnerMu.Lock()
+	defer commandRunnerMu.Unlock()
+	previous := commandRunner
+	commandRunner = runner
+	return previous
+}
+
+// ExecRunner runs commands with os/exec
+type ExecRunner struct {
+	// Timeout kills commands still running after it; zero waits for them indefinitely.
+	// Commands created with exec.CommandContext are bounded by their context instead.
+	Timeout time.Duration
+}
+
+// Run runs cmd and waits for it to finish
+func (r *ExecRunner) Run(cmd *exec.Cmd) error {
+	return r.run(cmd)
+}
+
+// Output runs cmd and returns its standard output
+func (r *ExecRunner) Output(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	captureStderr := cmd.Stderr == nil
+	if captureStderr {
+		cmd.Stderr = &stderr
+	}
+
+	err := r.run(cmd)
+	var exitErr *exec.ExitError
+	if captureStderr && errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs cmd and returns its standard output and error
+func (r *ExecRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return nil, errors.New("exec: Stdout or Stderr already set")
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := r.run(cmd)
+	return output.Bytes(), err
+}
+
+// Start starts cmd and reaps it in the background once it exits. Started programs are not
+// subject to the timeout.
+func (r *ExecRunner) Start(cmd *exec.Cmd) error {
+	SetupCommandForPlatform(cmd)
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		logCommand(cmd, started, err)
+		return err
+	}
+	go func() {
+		logCommand(cmd, started, cmd.Wait())
+	}()
+	return nil
+}
+
+// run starts cmd, kills it when the timeout passes and waits for it
+func (r *ExecRunner) run(cmd *exec.Cmd) error {
+	SetupCommandForPlatform(cmd)
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = commandWaitDelay
+	}
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		logCommand(cmd, started, err)
+		return err
+	}
+
+	var timedOut atomic.Bool
+	// exec.CommandContext sets Cancel
+	if r.Timeout > 0 && cmd.Cancel == nil {
+		timer := time.AfterFunc(r.Timeout, func() {
+			timedOut.Store(true)
+			_ = cmd.Process.Kill()
+		})
+		defer timer.Stop()
+	}
+
+	err := cmd.Wait()
+	logCommand(cmd, started, err)
+	if err != nil && timedOut.Load() {
+		return fmt.Errorf("%s did not finish within %v: %w", commandName(cmd), r.Timeout, err)
+	}
+	return err
+}
+
+// logCommand logs how a command ended. Only the program name is logged: arguments can hold
+// paths and scripts with personal details.
+func logCommand(cmd *exec.Cmd, started time.Time, err error) {
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	LogDebug(fmt.Sprintf("%s took %dms (exit code %d)", commandName(cmd), time.Since(started).Milliseconds(), exitCode))
+}
+
+// commandName is the base name of the program cmd runs
+func commandName(cmd *exec.Cmd) string {
+	if len(cmd.Args) > 0 {
+		return filepath.Base(cmd.Args[0])
+	}
+	return filepath.Base(cmd.Path)
+}
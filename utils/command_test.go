@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRunner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	runner := &ExecRunner{Timeout: time.Second}
+
+	output, err := runner.Output(exec.Command("sh", "-c", "echo out; echo oops >&2; exit 3"))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("Expected exit code 3, got %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "out" || strings.TrimSpace(string(exitErr.Stderr)) != "oops" {
+		t.Errorf("Expected stdout in the output and stderr in the error, got %q and %q", output, exitErr.Stderr)
+	}
+
+	output, err = runner.CombinedOutput(exec.Command("sh", "-c", "echo out; echo err >&2"))
+	if err != nil || !strings.Contains(string(output), "out") || !strings.Contains(string(output), "err") {
+		t.Errorf("Expected both streams in the combined output, got %q (%v)", output, err)
+	}
+
+	started := time.Now()
+	err = runner.Run(exec.Command("sh", "-c", "sleep 10"))
+	if err == nil || !strings.Contains(err.Error(), "did not finish within") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Error("Expected the command to be killed at the timeout")
+	}
+}
+
+func TestExecRunnerBounds(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	previous := commandWaitDelay
+	commandWaitDelay = 200 * time.Millisecond
+	t.Cleanup(func() { commandWaitDelay = previous })
+	runner := &ExecRunner{Timeout: 100 * time.Millisecond}
+
+	// A command with a context is bounded by it rather than the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := runner.Run(exec.CommandContext(ctx, "sh", "-c", "sleep 0.5")); err != nil {
+		t.Errorf("Expected a command with a context to outlive the timeout, got %v", err)
+	}
+
+	// A child holding the output pipe open must not keep Output waiting after the command exits
+	started := time.Now()
+	output, _ := runner.Output(exec.CommandContext(ctx, "sh", "-c", "echo out; sleep 3 &"))
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected Output to return once the command exited, took %v", elapsed)
+	}
+	if strings.TrimSpace(string(output)) != "out" {
+		t.Errorf("Expected the command's output, got %q", output)
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// hostsMarker tags hosts-file lines added by the manager so they can be removed safely
+const hostsMarker = "# moodle-prototype-manager"
+
+// HostsFilePath returns the system hosts file for the current platform
+func HostsFilePath() string {
+	if runtime.GOOS == "windows" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return filepath.Join(root, "System32", "drivers", "etc", "hosts")
+	}
+	return "/etc/hosts"
+}
+
+// AddHostsEntry maps hostname to 127.0.0.1, prompting for elevation when needed
+func AddHostsEntry(hostname string) error {
+	data, err := os.ReadFile(HostsFilePath())
+	if err != nil {
+		return err
+	}
+	if hostsHasEntry(string(data), hostname) {
+		LogDebug(fmt.Sprintf("Hosts entry for %s already present", hostname))
+		return nil
+	}
+
+	LogInfo(fmt.Sprintf("Adding hosts entry for %s", hostname))
+	return writeHostsFileElevated(addHostsLine(string(data), hostname))
+}
+
+// RemoveHostsEntry removes hosts-file lines the manager added for hostname
+func RemoveHostsEntry(hostname string) error {
+	data, err := os.ReadFile(HostsFilePath())
+	if err != nil {
+		return err
+	}
+
+	updated := removeHostsLine(string(data), hostname)
+	if updated == string(data) {
+		return nil
+	}
+
+	LogInfo(fmt.Sprintf("Removing hosts entry for %s", hostname))
+	return writeHostsFileElevated(updated)
+}
+
+// hostsHasEntry reports whether any active line in contents maps hostname
+func hostsHasEntry(contents, hostname string) bool {
+	for _, line := range strings.Split(contents, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		for _, field := range fields[min(1, len(fields)):] {
+			if strings.EqualFold(field, hostname) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addHostsLine appends a tagged loopback entry for hostname
+func addHostsLine(contents, hostname string) string {
+	newline := "\n"
+	if strings.Contains(contents, "\r\n") {
+		newline = "\r\n"
+	}
+	if contents != "" && !strings.HasSuffix(contents, "\n") {
+		contents += newline
+	}
+	return contents + fmt.Sprintf("127.0.0.1\t%s\t%s", hostname, hostsMarker) + newline
+}
+
+// removeHostsLine drops tagged lines for hostname, leaving user-written entries alone
+func removeHostsLine(contents, hostname string) string {
+	lines := strings.SplitAfter(contents, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.Contains(line, hostsMarker) && hostsHasEntry(line, hostname) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "")
+}
+
+// writeHostsFileElevated replaces the hosts file, asking the OS for administrator rights
+func writeHostsFileElevated(contents string) error {
+	tmp, err := os.CreateTemp("", "moodle-hosts-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	hostsPath := HostsFilePath()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("do shell script \"cp '%s' '%s'\" with administrator privileges", tmp.Name(), hostsPath)
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		inner := fmt.Sprintf("Copy-Item -LiteralPath '%s' -Destination '%s' -Force", tmp.Name(), hostsPath)
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			fmt.Sprintf("Start-Process powershell -Verb RunAs -Wait -WindowStyle Hidden -ArgumentList '-NoProfile','-Command',\"%s\"", inner))
+	case "linux":
+		cmd = exec.Command("pkexec", "cp", tmp.Name(), hostsPath)
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	if output, err := Commands().CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to update %s (administrator rights are required): %w (%s)", hostsPath, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -10,10 +10,12 @@ import (
 
 var logger *log.Logger
 
+// logDir holds moodle.log, relative to the working directory
+const logDir = "logs"
+
 // InitLogger initializes the logger to write to moodle.log
 func InitLogger() {
 	// Create logs directory if it doesn't exist
-	logDir := "logs"
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		fmt.Printf("Failed to create logs directory: %v\n", err)
 		return
@@ -34,6 +36,11 @@ func InitLogger() {
 	LogInfo("=== Moodle Prototype Manager Started ===")
 }
 
+// LogDirectory returns the absolute path of the directory moodle.log is written to
+func LogDirectory() (string, error) {
+	return filepath.Abs(logDir)
+}
+
 // LogInfo logs an info message
 func LogInfo(message string) {
 	logMessage("INFO", message)
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// windowsCostScript prints the cost type of the active internet connection profile
+const windowsCostScript = `[Windows.Networking.Connectivity.NetworkInformation,Windows.Networking.Connectivity,ContentType=WindowsRuntime] | Out-Null; ` +
+	`[Windows.Networking.Connectivity.NetworkInformation]::GetInternetConnectionProfile().GetConnectionCost().NetworkCostType`
+
+// IsMeteredNetwork reports whether the active connection is metered (e.g. a phone hotspot).
+// Platforms without a way to tell report false.
+func IsMeteredNetwork() (bool, error) {
+	switch runtime.GOOS {
+	case "windows":
+		output, err := Commands().CombinedOutput(exec.Command("powershell", "-NoProfile", "-Command", windowsCostScript))
+		if err != nil {
+			return false, err
+		}
+		cost := strings.TrimSpace(string(output))
+		return cost == "Fixed" || cost == "Variable", nil
+	case "linux":
+		// NetworkManager's NMMetered: 1 yes, 3 guessed yes
+		output, err := Commands().CombinedOutput(exec.Command("busctl", "get-property", "org.freedesktop.NetworkManager",
+			"/org/freedesktop/NetworkManager", "org.freedesktop.NetworkManager", "Metered"))
+		if err != nil {
+			return false, err
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(output)), "u"))
+		return value == "1" || value == "3", nil
+	default:
+		return false, nil
+	}
+}
@@ -8,11 +8,15 @@ import (
 	"syscall"
 )
 
+// createNoWindow keeps console programs from opening a console window; HideWindow alone only
+// hides the window after it was created, which still flashes (CREATE_NO_WINDOW, not in syscall)
+const createNoWindow = 0x08000000
+
 // SetupCommandForPlatform configures the command for the current platform
 // On Windows, this hides the console window to prevent flashing
 func SetupCommandForPlatform(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		HideWindow:    true,
-		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+		CreationFlags: createNoWindow | syscall.CREATE_NEW_PROCESS_GROUP,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// OnACPower reports whether the machine is running on mains power; desktops without a battery count as AC
+func OnACPower() (bool, error) {
+	if runtime.GOOS == "darwin" {
+		output, err := Commands().CombinedOutput(exec.Command("pmset", "-g", "batt"))
+		if err != nil {
+			return false, err
+		}
+		return strings.Contains(string(output), "'AC Power'"), nil
+	}
+
+	supplies, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil {
+		return false, err
+	}
+
+	hasMains := false
+	for _, supply := range supplies {
+		kind, err := os.ReadFile(filepath.Join(supply, "type"))
+		if err != nil || strings.TrimSpace(string(kind)) != "Mains" {
+			continue
+		}
+		hasMains = true
+		if online, err := os.ReadFile(filepath.Join(supply, "online")); err == nil && strings.TrimSpace(string(online)) == "1" {
+			return true, nil
+		}
+	}
+	// No mains adapter reported: assume a desktop or VM rather than a laptop on battery
+	return !hasMains, nil
+}
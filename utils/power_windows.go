@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import (
+	"unsafe"
+)
+
+var procGetSystemPowerStatus = kernel32.NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus mirrors the Win32 SYSTEM_POWER_STATUS structure
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// OnACPower reports whether the machine is running on mains power
+func OnACPower() (bool, error) {
+	var status systemPowerStatus
+	ret, _, callErr := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return false, callErr
+	}
+	// 1 is online; 255 (unknown) is treated as AC so desktops are not blocked
+	return status.ACLineStatus != 0, nil
+}